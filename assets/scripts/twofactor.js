@@ -12,16 +12,41 @@
   const tokenInput = d.getElementById('two-factor-token')
   const trustCheckbox = d.getElementById('two-factor-trust-device')
   const longRunCheckbox = d.getElementById('long-run-session')
+  const webauthnButton = d.getElementById('two-factor-webauthn')
 
   const storage = w.localStorage
 
-  const onSubmitTwoFactorCode = function (event) {
+  const base64urlToBuffer = function (str) {
+    const base64 = str.replace(/-/g, '+').replace(/_/g, '/')
+    const padded = base64 + '==='.slice((base64.length + 3) % 4)
+    const binary = w.atob(padded)
+    const bytes = new Uint8Array(binary.length)
+    for (let i = 0; i < binary.length; i++) {
+      bytes[i] = binary.charCodeAt(i)
+    }
+    return bytes.buffer
+  }
+
+  const bufferToBase64url = function (buffer) {
+    const bytes = new Uint8Array(buffer)
+    let binary = ''
+    for (let i = 0; i < bytes.length; i++) {
+      binary += String.fromCharCode(bytes[i])
+    }
+    return w
+      .btoa(binary)
+      .replace(/\+/g, '-')
+      .replace(/\//g, '_')
+      .replace(/=+$/, '')
+  }
+
+  const onSubmitTwoFactorCode = function (event, webauthnPasscode) {
     event.preventDefault()
     passcodeInput.setAttribute('disabled', true)
     submitButton.setAttribute('disabled', true)
 
     const longRun = longRunCheckbox && longRunCheckbox.checked ? '1' : '0'
-    const passcode = passcodeInput.value
+    const passcode = webauthnPasscode || passcodeInput.value
     const token = tokenInput.value
     const trustDevice = trustCheckbox && trustCheckbox.checked ? '1' : '0'
     const redirect = redirectInput.value + w.location.hash
@@ -75,6 +100,8 @@
             submitButton.classList.add('btn-done')
             w.location = body.redirect
           } else {
+            passcodeInput.removeAttribute('disabled')
+            submitButton.removeAttribute('disabled')
             w.showError(twofaField, body.error)
           }
         })
@@ -82,5 +109,42 @@
       .catch((err) => w.showError(twofaField, err))
   }
 
-  twofaForm.addEventListener('submit', onSubmitTwoFactorCode)
+  const onUseSecurityKey = function (event) {
+    event.preventDefault()
+    const options = JSON.parse(twofaForm.dataset.webauthn)
+    options.challenge = base64urlToBuffer(options.challenge)
+    options.allowCredentials = (options.allowCredentials || []).map(
+      function (cred) {
+        return { type: cred.type, id: base64urlToBuffer(cred.id) }
+      }
+    )
+    w.navigator.credentials
+      .get({ publicKey: options })
+      .then(function (cred) {
+        const passcode = JSON.stringify({
+          id: cred.id,
+          type: cred.type,
+          response: {
+            clientDataJSON: bufferToBase64url(cred.response.clientDataJSON),
+            authenticatorData: bufferToBase64url(
+              cred.response.authenticatorData
+            ),
+            signature: bufferToBase64url(cred.response.signature),
+          },
+        })
+        return onSubmitTwoFactorCode(event, passcode)
+      })
+      .catch((err) => w.showError(twofaField, err))
+  }
+
+  twofaForm.addEventListener('submit', function (event) {
+    onSubmitTwoFactorCode(event)
+  })
+  if (webauthnButton) {
+    if (w.navigator.credentials && w.PublicKeyCredential) {
+      webauthnButton.addEventListener('click', onUseSecurityKey)
+    } else {
+      webauthnButton.classList.add('d-none')
+    }
+  }
 })(window, document)
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
 	"strconv"
@@ -96,9 +97,26 @@ type AppManifest struct {
 		UpdatedAt time.Time `json:"updated_at"`
 
 		Error string `json:"error,omitempty"`
+
+		DependenciesCheck *struct {
+			Checks []*DependencyCheck `json:"checks"`
+		} `json:"dependencies_check,omitempty"`
 	} `json:"attributes,omitempty"`
 }
 
+// DependencyCheck is the result of the check of a dependency of an
+// application, made before its installation.
+type DependencyCheck struct {
+	Kind        string `json:"kind"`
+	Name        string `json:"name"`
+	Required    string `json:"required,omitempty"`
+	Found       string `json:"found,omitempty"`
+	Level       string `json:"level"`
+	Message     string `json:"message,omitempty"`
+	Installable bool   `json:"installable,omitempty"`
+	Installed   bool   `json:"installed,omitempty"`
+}
+
 // AppOptions holds the options to install an application.
 type AppOptions struct {
 	AppType             string
@@ -106,6 +124,13 @@ type AppOptions struct {
 	SourceURL           string
 	Deactivated         bool
 	OverridenParameters *json.RawMessage
+
+	// DryRun only checks the dependencies of the application, without
+	// installing it
+	DryRun bool
+	// InstallDependencies installs the missing applications declared in the
+	// dependencies of the application
+	InstallDependencies bool
 }
 
 // ListApps is used to get the list of all installed applications.
@@ -149,6 +174,21 @@ func (c *Client) InstallApp(opts *AppOptions) (*AppManifest, error) {
 		}
 		q["Parameters"] = []string{string(b)}
 	}
+	if opts.InstallDependencies {
+		q["InstallDependencies"] = []string{"true"}
+	}
+	if opts.DryRun {
+		q["DryRun"] = []string{"true"}
+		res, err := c.Req(&request.Options{
+			Method:  "POST",
+			Path:    makeAppsPath(opts.AppType, url.PathEscape(opts.Slug)),
+			Queries: q,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return readAppManifest(res)
+	}
 	res, err := c.Req(&request.Options{
 		Method:  "POST",
 		Path:    makeAppsPath(opts.AppType, url.PathEscape(opts.Slug)),
@@ -249,6 +289,122 @@ func (c *Client) DeactivateMaintenance(slug string) error {
 	return err
 }
 
+// RolloutOptions holds the options to start a staged rollout.
+type RolloutOptions struct {
+	Slug          string   `json:"slug"`
+	Type          string   `json:"type"`
+	Source        string   `json:"source,omitempty"`
+	Percentage    int      `json:"percentage"`
+	Contexts      []string `json:"contexts,omitempty"`
+	Force         bool     `json:"force,omitempty"`
+	MaxErrorRate  float64  `json:"max_error_rate,omitempty"`
+	MinJobs       int      `json:"min_jobs,omitempty"`
+	Watch         string   `json:"watch,omitempty"`
+	CheckInterval string   `json:"check_interval,omitempty"`
+}
+
+// StartRollout starts a staged rollout for the update of an application.
+func (c *Client) StartRollout(opts *RolloutOptions) (map[string]interface{}, error) {
+	body, err := json.Marshal(opts)
+	if err != nil {
+		return nil, err
+	}
+	return c.rolloutRequest("POST", "/apps/rollouts", bytes.NewReader(body))
+}
+
+// ListRollouts returns the list of the staged rollouts.
+func (c *Client) ListRollouts() ([]map[string]interface{}, error) {
+	return c.rolloutsList("/apps/rollouts", "rollouts")
+}
+
+// ListRolloutInstances returns the list of the instances updated by a staged
+// rollout.
+func (c *Client) ListRolloutInstances(id string) ([]map[string]interface{}, error) {
+	return c.rolloutsList("/apps/rollouts/"+url.PathEscape(id)+"/instances", "instances")
+}
+
+// rolloutsList fetches all the pages of a list of the rollouts API.
+func (c *Client) rolloutsList(path, key string) ([]map[string]interface{}, error) {
+	list := []map[string]interface{}{}
+	cursor := ""
+	for {
+		var queries url.Values
+		if cursor != "" {
+			queries = url.Values{"page[cursor]": {cursor}}
+		}
+		res, err := c.Req(&request.Options{
+			Method:  "GET",
+			Path:    path,
+			Queries: queries,
+		})
+		if err != nil {
+			return nil, err
+		}
+		var page map[string]json.RawMessage
+		err = json.NewDecoder(res.Body).Decode(&page)
+		res.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		var items []map[string]interface{}
+		if err := json.Unmarshal(page[key], &items); err != nil {
+			return nil, err
+		}
+		list = append(list, items...)
+		cursor = ""
+		if next, ok := page["next_cursor"]; ok {
+			_ = json.Unmarshal(next, &cursor)
+		}
+		if cursor == "" {
+			return list, nil
+		}
+	}
+}
+
+// GetRollout returns the staged rollout with the given ID.
+func (c *Client) GetRollout(id string) (map[string]interface{}, error) {
+	return c.rolloutRequest("GET", "/apps/rollouts/"+url.PathEscape(id), nil)
+}
+
+// RollbackRollout starts the rollback of the instances updated by a staged
+// rollout (the rollback is done by a job).
+func (c *Client) RollbackRollout(id string) (map[string]interface{}, error) {
+	return c.rolloutRequest("POST", "/apps/rollouts/"+url.PathEscape(id)+"/rollback", nil)
+}
+
+// UnpinRollout puts back the source of the application to its channel on the
+// instances rolled back by a staged rollout.
+func (c *Client) UnpinRollout(id string) (map[string]interface{}, error) {
+	return c.rolloutRequest("POST", "/apps/rollouts/"+url.PathEscape(id)+"/unpin", nil)
+}
+
+// CancelRollout stops a staged rollout, without rolling back the instances
+// already updated.
+func (c *Client) CancelRollout(id string) (map[string]interface{}, error) {
+	return c.rolloutRequest("DELETE", "/apps/rollouts/"+url.PathEscape(id), nil)
+}
+
+func (c *Client) rolloutRequest(method, path string, body io.Reader) (map[string]interface{}, error) {
+	res, err := c.Req(&request.Options{
+		Method: method,
+		Path:   path,
+		Headers: request.Headers{
+			"Content-Type": "application/json",
+			"Accept":       "application/json",
+		},
+		Body: body,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	var rollout map[string]interface{}
+	if err := json.NewDecoder(res.Body).Decode(&rollout); err != nil {
+		return nil, err
+	}
+	return rollout, nil
+}
+
 func makeAppsPath(appType, path string) string {
 	switch appType {
 	case consts.Apps:
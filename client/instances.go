@@ -39,9 +39,17 @@ type Instance struct {
 		PassphraseResetToken []byte    `json:"passphrase_reset_token"`
 		PassphraseResetTime  time.Time `json:"passphrase_reset_time"`
 		RegisterToken        []byte    `json:"register_token,omitempty"`
+
+		AppsQuotas map[string]AppQuota `json:"apps_quotas,omitempty"`
 	} `json:"attributes"`
 }
 
+// AppQuota is the storage quota of an application on an instance.
+type AppQuota struct {
+	Disk int64 `json:"disk,string,omitempty"`
+	Docs int64 `json:"docs,omitempty"`
+}
+
 // InstanceOptions contains the options passed on instance creation.
 type InstanceOptions struct {
 	Domain             string
@@ -58,6 +66,9 @@ type InstanceOptions struct {
 	Settings           string
 	SwiftLayout        int
 	DiskQuota          int64
+	AppQuotaSlug       string
+	AppDiskQuota       int64
+	AppDocsQuota       int64
 	Apps               []string
 	Passphrase         string
 	KdfIterations      int
@@ -149,6 +160,11 @@ func (c *Client) CreateInstance(opts *InstanceOptions) (*Instance, error) {
 		"Passphrase":    {opts.Passphrase},
 		"KdfIterations": {strconv.Itoa(opts.KdfIterations)},
 	}
+	if opts.AppQuotaSlug != "" {
+		q.Add("AppQuotaSlug", opts.AppQuotaSlug)
+		q.Add("AppDiskQuota", strconv.FormatInt(opts.AppDiskQuota, 10))
+		q.Add("AppDocsQuota", strconv.FormatInt(opts.AppDocsQuota, 10))
+	}
 	if opts.DomainAliases != nil {
 		q.Add("DomainAliases", strings.Join(opts.DomainAliases, ","))
 	}
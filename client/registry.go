@@ -0,0 +1,38 @@
+package client
+
+import (
+	"encoding/json"
+	"io"
+	"net/url"
+
+	"github.com/cozy/cozy-stack/client/request"
+)
+
+// PublishToRegistry publishes the tarball of an application on the registry
+// server of the stack. The signature is optional, and is the base64-encoded
+// ed25519 signature of the tarball.
+func (c *Client) PublishToRegistry(tarball io.Reader, signature string) (map[string]interface{}, error) {
+	var queries url.Values
+	if signature != "" {
+		queries = url.Values{"signature": {signature}}
+	}
+	res, err := c.Req(&request.Options{
+		Method:  "POST",
+		Path:    "/registry/versions",
+		Queries: queries,
+		Headers: request.Headers{
+			"Content-Type": "application/gzip",
+			"Accept":       "application/json",
+		},
+		Body: tarball,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	var version map[string]interface{}
+	if err := json.NewDecoder(res.Body).Decode(&version); err != nil {
+		return nil, err
+	}
+	return version, nil
+}
@@ -16,12 +16,22 @@ import (
 
 var flagAllDomains bool
 var flagAppsDeactivated bool
+var flagAppsDryRun bool
+var flagAppsInstallDependencies bool
 var flagSafeUpdate bool
 
 var flagKonnectorAccountID string
 var flagKonnectorsParameters string
+var flagKonnectorRecord bool
+var flagKonnectorReplay string
 
 var flagKonnectorContext string
+var flagRolloutPercentage int
+var flagRolloutContexts []string
+var flagRolloutForce bool
+var flagRolloutMaxErrorRate float64
+var flagRolloutMinJobs int
+var flagRolloutWatch string
 var flagKonnectorsShortMaintenance bool
 var flagKonnectorsDisallowManualExec bool
 
@@ -188,7 +198,15 @@ var lsKonnectorsCmd = &cobra.Command{
 var runKonnectorsCmd = &cobra.Command{
 	Use:   "run <slug>",
 	Short: "Run a konnector.",
-	Long:  "Run a konnector named with specified slug using the specified options.",
+	Long: `Run a konnector named with specified slug using the specified options.
+
+With the --record flag, the HTTP traffic of the konnector is saved in a HAR
+file, in the "Konnectors recordings" directory of the instance. This file can
+then be given to the --replay flag to run the konnector again, with the
+recorded responses instead of the real websites. The credentials (authorization
+and cookie headers, fields named like password, token or secret) are redacted
+in the recording.
+`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		if flagDomain == "" {
 			errPrintfln("%s", errMissingDomain)
@@ -214,6 +232,7 @@ var runKonnectorsCmd = &cobra.Command{
 		type localTrigger struct {
 			id        string
 			accountID string
+			message   json.RawMessage
 		}
 
 		var triggers []*localTrigger
@@ -226,7 +245,7 @@ var runKonnectorsCmd = &cobra.Command{
 				return err
 			}
 			if msg.Slug == slug {
-				triggers = append(triggers, &localTrigger{t.ID, msg.Account})
+				triggers = append(triggers, &localTrigger{t.ID, msg.Account, t.Attrs.Message})
 			}
 		}
 
@@ -254,7 +273,25 @@ var runKonnectorsCmd = &cobra.Command{
 			trigger = triggers[0]
 		}
 
-		j, err := c.TriggerLaunch(trigger.id)
+		var j *client.Job
+		if flagKonnectorRecord || flagKonnectorReplay != "" {
+			// The recorded and replayed executions are pushed as jobs with
+			// the message of the trigger, as they are not normal executions
+			var msg map[string]interface{}
+			if err = json.Unmarshal(trigger.message, &msg); err != nil {
+				return err
+			}
+			msg["record"] = flagKonnectorRecord
+			if flagKonnectorReplay != "" {
+				msg["replay"] = flagKonnectorReplay
+			}
+			j, err = c.JobPush(&client.JobOptions{
+				Worker:    "konnector",
+				Arguments: msg,
+			})
+		} else {
+			j, err = c.TriggerLaunch(trigger.id)
+		}
 		if err != nil {
 			return err
 		}
@@ -382,16 +419,47 @@ func installApp(cmd *cobra.Command, args []string, appType string) error {
 		SourceURL:   source,
 		Deactivated: flagAppsDeactivated,
 
+		DryRun:              flagAppsDryRun,
+		InstallDependencies: flagAppsInstallDependencies,
 		OverridenParameters: overridenParameters,
 	})
 	if err != nil {
 		return err
 	}
+	unmet := false
+	if deps := manifest.Attrs.DependenciesCheck; deps != nil {
+		for _, check := range deps.Checks {
+			printDependencyCheck(check)
+			unmet = unmet || check.Level == "error"
+		}
+	}
+	if flagAppsDryRun {
+		if unmet {
+			return fmt.Errorf("%s (%s) can't be installed on %s: the dependencies are not satisfied",
+				slug, manifest.Attrs.Version, flagDomain)
+		}
+		fmt.Printf("%s (%s) can be installed on %s\n", slug, manifest.Attrs.Version, flagDomain)
+		return nil
+	}
 	fmt.Printf("%s (%s) has been installed on %s\n", slug, manifest.Attrs.Version, flagDomain)
 
 	return nil
 }
 
+func printDependencyCheck(check *client.DependencyCheck) {
+	line := fmt.Sprintf("%-8s %s %s", check.Level, check.Kind, check.Name)
+	if check.Required != "" {
+		line += " " + check.Required
+	}
+	switch {
+	case check.Installed:
+		line += ": installed"
+	case check.Message != "":
+		line += ": " + check.Message
+	}
+	fmt.Println(line)
+}
+
 func updateApp(cmd *cobra.Command, args []string, appType string) error {
 	if len(args) == 0 || len(args) > 2 {
 		return cmd.Usage()
@@ -649,15 +717,185 @@ func foreachDomains(predicate func(*client.Instance) error) error {
 	return nil
 }
 
+// newRolloutCmdGroup returns the rollout commands for the webapps or the
+// konnectors.
+func newRolloutCmdGroup(appType, example string) *cobra.Command {
+	group := &cobra.Command{
+		Use:   "rollout <command>",
+		Short: "Manage the staged rollouts of the updates",
+		Long: `
+A staged rollout updates an application only on a share of the instances (or on
+the instances of some contexts), then watches the error rates of its jobs, and
+rolls back automatically the update if they degrade.
+`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Usage()
+		},
+	}
+
+	startCmd := &cobra.Command{
+		Use:     "start <slug> [sourceurl]",
+		Short:   "Start a staged rollout for the update of an application",
+		Example: example,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) < 1 {
+				return cmd.Usage()
+			}
+			opts := &client.RolloutOptions{
+				Slug:         args[0],
+				Type:         appType,
+				Percentage:   flagRolloutPercentage,
+				Contexts:     flagRolloutContexts,
+				Force:        flagRolloutForce,
+				MaxErrorRate: flagRolloutMaxErrorRate,
+				MinJobs:      flagRolloutMinJobs,
+				Watch:        flagRolloutWatch,
+			}
+			if len(args) > 1 {
+				opts.Source = args[1]
+			}
+			c := newAdminClient()
+			rollout, err := c.StartRollout(opts)
+			if err != nil {
+				return err
+			}
+			return printJSON(rollout)
+		},
+	}
+	startCmd.Flags().IntVar(&flagRolloutPercentage, "percentage", 10, "percentage of the instances to update")
+	startCmd.Flags().StringSliceVar(&flagRolloutContexts, "contexts", nil, "only update the instances of these contexts")
+	startCmd.Flags().BoolVar(&flagRolloutForce, "force", false, "also update the instances where the auto-update is disabled")
+	startCmd.Flags().Float64Var(&flagRolloutMaxErrorRate, "max-error-rate", 0.1, "maximal increase of the error rate before the rollback")
+	startCmd.Flags().IntVar(&flagRolloutMinJobs, "min-jobs", 10, "minimal number of jobs before taking a decision")
+	startCmd.Flags().StringVar(&flagRolloutWatch, "watch", "6h", "duration of the watch of the error rates")
+
+	lsCmd := &cobra.Command{
+		Use:   "ls",
+		Short: "List the staged rollouts",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c := newAdminClient()
+			list, err := c.ListRollouts()
+			if err != nil {
+				return err
+			}
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			for _, r := range list {
+				fmt.Fprintf(w, "%v\t%v\t%v\t%v%%\t%v instances\t%v\n",
+					r["_id"], r["slug"], r["status"], r["percentage"], r["updated"], r["created_at"])
+			}
+			return w.Flush()
+		},
+	}
+
+	showCmd := &cobra.Command{
+		Use:   "show <id>",
+		Short: "Show the status of a staged rollout",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return cmd.Usage()
+			}
+			c := newAdminClient()
+			rollout, err := c.GetRollout(args[0])
+			if err != nil {
+				return err
+			}
+			instances, err := c.ListRolloutInstances(args[0])
+			if err != nil {
+				return err
+			}
+			rollout["instances"] = instances
+			return printJSON(rollout)
+		},
+	}
+
+	rollbackCmd := &cobra.Command{
+		Use:   "rollback <id>",
+		Short: "Roll back the instances updated by a staged rollout",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return cmd.Usage()
+			}
+			c := newAdminClient()
+			rollout, err := c.RollbackRollout(args[0])
+			if err != nil {
+				return err
+			}
+			return printJSON(rollout)
+		},
+	}
+
+	unpinCmd := &cobra.Command{
+		Use:   "unpin <id>",
+		Short: "Unpin the version of the application on the instances rolled back by a staged rollout",
+		Long: `
+After a rollback, the instances are pinned to the previous version of the
+application, and they are not updated by the auto-updates. This command puts
+back the source of the application to its channel on these instances, without
+changing the installed version. It can be used when a fixed version has been
+published in the registry.
+`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return cmd.Usage()
+			}
+			c := newAdminClient()
+			rollout, err := c.UnpinRollout(args[0])
+			if err != nil {
+				return err
+			}
+			return printJSON(rollout)
+		},
+	}
+
+	cancelCmd := &cobra.Command{
+		Use:   "cancel <id>",
+		Short: "Stop a staged rollout, without rolling back the updated instances",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return cmd.Usage()
+			}
+			c := newAdminClient()
+			rollout, err := c.CancelRollout(args[0])
+			if err != nil {
+				return err
+			}
+			return printJSON(rollout)
+		},
+	}
+
+	group.AddCommand(startCmd)
+	group.AddCommand(lsCmd)
+	group.AddCommand(showCmd)
+	group.AddCommand(rollbackCmd)
+	group.AddCommand(unpinCmd)
+	group.AddCommand(cancelCmd)
+	return group
+}
+
+func printJSON(v interface{}) error {
+	out, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(out))
+	return nil
+}
+
 func init() {
 	webappsCmdGroup.PersistentFlags().StringVar(&flagDomain, "domain", cozyDomain(), "specify the domain name of the instance")
 	webappsCmdGroup.PersistentFlags().BoolVar(&flagAllDomains, "all-domains", false, "work on all domains iteratively")
 
 	installWebappCmd.PersistentFlags().BoolVar(&flagAppsDeactivated, "ask-permissions", false, "specify that the application should not be activated after installation")
+	installWebappCmd.PersistentFlags().BoolVar(&flagAppsDryRun, "dry-run", false, "only check the dependencies of the application, without installing it")
+	installWebappCmd.PersistentFlags().BoolVar(&flagAppsInstallDependencies, "install-dependencies", false, "install the missing applications required by the application")
+	installKonnectorCmd.PersistentFlags().BoolVar(&flagAppsDryRun, "dry-run", false, "only check the dependencies of the konnector, without installing it")
+	installKonnectorCmd.PersistentFlags().BoolVar(&flagAppsInstallDependencies, "install-dependencies", false, "install the missing applications required by the konnector")
 	updateWebappCmd.PersistentFlags().BoolVar(&flagSafeUpdate, "safe", false, "do not upgrade if there are blocking changes")
 	updateKonnectorCmd.PersistentFlags().BoolVar(&flagSafeUpdate, "safe", false, "do not upgrade if there are blocking changes")
 
 	runKonnectorsCmd.PersistentFlags().StringVar(&flagKonnectorAccountID, "account-id", "", "specify the account ID to use for running the konnector")
+	runKonnectorsCmd.PersistentFlags().BoolVar(&flagKonnectorRecord, "record", false, "record the HTTP traffic of the konnector in a file of the instance")
+	runKonnectorsCmd.PersistentFlags().StringVar(&flagKonnectorReplay, "replay", "", "replay the konnector with the HTTP traffic recorded in the file with this ID")
 
 	listMaintenancesCmd.PersistentFlags().StringVar(&flagKonnectorContext, "context", "", "include konnectors in maintenance for apps registry of this context")
 	activateMaintenanceKonnectorsCmd.PersistentFlags().BoolVar(&flagKonnectorsShortMaintenance, "short", false, "specify a short maintenance")
@@ -673,6 +911,9 @@ func init() {
 	webappsCmdGroup.AddCommand(installWebappCmd)
 	webappsCmdGroup.AddCommand(updateWebappCmd)
 	webappsCmdGroup.AddCommand(uninstallWebappCmd)
+	webappsCmdGroup.AddCommand(newRolloutCmdGroup("webapp", `
+$ cozy-stack apps rollout start drive --percentage 10 --contexts beta --watch 12h
+`))
 
 	konnectorsCmdGroup.PersistentFlags().StringVar(&flagDomain, "domain", cozyDomain(), "specify the domain name of the instance")
 	konnectorsCmdGroup.PersistentFlags().StringVar(&flagKonnectorsParameters, "parameters", "", "override the parameters of the installed konnector")
@@ -687,6 +928,9 @@ func init() {
 	konnectorsCmdGroup.AddCommand(listMaintenancesCmd)
 	konnectorsCmdGroup.AddCommand(activateMaintenanceKonnectorsCmd)
 	konnectorsCmdGroup.AddCommand(deactivateMaintenanceKonnectorsCmd)
+	konnectorsCmdGroup.AddCommand(newRolloutCmdGroup("konnector", `
+$ cozy-stack konnectors rollout start trainline --percentage 5 --max-error-rate 0.05
+`))
 
 	RootCmd.AddCommand(triggersCmdGroup)
 	RootCmd.AddCommand(webappsCmdGroup)
@@ -4,17 +4,24 @@ import (
 	"bufio"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/url"
 	"os"
 	"strconv"
+	"text/tabwriter"
+	"time"
 
+	"github.com/cozy/cozy-stack/client"
 	"github.com/cozy/cozy-stack/client/request"
+	"github.com/cozy/cozy-stack/model/sharing"
 	"github.com/spf13/cobra"
 )
 
 var flagCheckFSIndexIntegrity bool
 var flagCheckFSFilesConsistensy bool
 var flagCheckFSFailFast bool
+var flagCheckSharingsWatch bool
+var flagCheckSharingsInterval time.Duration
 
 var checkCmdGroup = &cobra.Command{
 	Use:   "check <command>",
@@ -173,6 +180,10 @@ var checkSharingsCmd = &cobra.Command{
 This command checks that the io.cozy.sharings have no inconsistencies. It can
 be triggers that are missing on an active sharing, or missing credentials for
 an active member.
+
+With the --watch flag, it displays instead the progress of the replication for
+the active sharings (last sequence number, pending documents and bytes, last
+error and number of retries), and refreshes it periodically.
 `,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		if len(args) == 0 {
@@ -181,6 +192,9 @@ an active member.
 		domain := args[0]
 
 		c := newAdminClient()
+		if flagCheckSharingsWatch {
+			return watchSharingsStatus(c, domain)
+		}
 		res, err := c.Req(&request.Options{
 			Method: "POST",
 			Path:   "/instances/" + url.PathEscape(domain) + "/checks/sharings",
@@ -206,6 +220,58 @@ an active member.
 	},
 }
 
+func watchSharingsStatus(c *client.Client, domain string) error {
+	for {
+		res, err := c.Req(&request.Options{
+			Method: "GET",
+			Path:   "/instances/" + url.PathEscape(domain) + "/checks/sharings/status",
+		})
+		if err != nil {
+			return err
+		}
+		var list []sharing.SharingReplicationStatus
+		err = json.NewDecoder(res.Body).Decode(&list)
+		res.Body.Close()
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("\033[H\033[2J%s - %s\n\n", domain, time.Now().Format(time.RFC3339))
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "SHARING\tMEMBER\tWORKER\tPENDING\tBYTES\tRETRIES\tUPDATED\tERROR")
+		for _, s := range list {
+			for _, m := range s.Members {
+				member := fmt.Sprintf("%d %s", m.Index, m.Instance)
+				printSharingWorkerStatus(w, s.SharingID, member, "replicator", &m.Replicator)
+				if m.Upload != nil {
+					printSharingWorkerStatus(w, s.SharingID, member, "upload", m.Upload)
+				}
+			}
+		}
+		if err = w.Flush(); err != nil {
+			return err
+		}
+		time.Sleep(flagCheckSharingsInterval)
+	}
+}
+
+func printSharingWorkerStatus(w io.Writer, sharingID, member, worker string, status *sharing.ReplicationStatus) {
+	updated := "-"
+	if status.UpdatedAt != nil {
+		updated = status.UpdatedAt.Local().Format(time.Stamp)
+	}
+	fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\t%d\t%s\t%s\n",
+		sharingID,
+		member,
+		worker,
+		status.PendingDocs,
+		status.PendingBytes,
+		status.Retries,
+		updated,
+		status.LastError,
+	)
+}
+
 func init() {
 	checkCmdGroup.AddCommand(checkFSCmd)
 	checkCmdGroup.AddCommand(checkTriggers)
@@ -214,6 +280,8 @@ func init() {
 	checkFSCmd.Flags().BoolVar(&flagCheckFSIndexIntegrity, "index-integrity", false, "Check the index integrity only")
 	checkFSCmd.Flags().BoolVar(&flagCheckFSFilesConsistensy, "files-consistency", false, "Check the files consistency only (between CouchDB and Swift)")
 	checkFSCmd.Flags().BoolVar(&flagCheckFSFailFast, "fail-fast", false, "Stop the FSCK on the first error")
+	checkSharingsCmd.Flags().BoolVar(&flagCheckSharingsWatch, "watch", false, "Display the replication status of the sharings and refresh it periodically")
+	checkSharingsCmd.Flags().DurationVar(&flagCheckSharingsInterval, "interval", 5*time.Second, "Interval between two refreshes with --watch")
 
 	RootCmd.AddCommand(checkCmdGroup)
 }
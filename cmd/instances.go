@@ -33,6 +33,7 @@ var flagEmail string
 var flagPublicName string
 var flagSettings string
 var flagDiskQuota string
+var flagDocsQuota int64
 var flagApps []string
 var flagBlocked bool
 var flagDeleting bool
@@ -363,6 +364,42 @@ instance of the given domain. Set the quota to 0 to remove the quota.
 	},
 }
 
+var appQuotaInstanceCmd = &cobra.Command{
+	Use:   "set-app-quota <domain> <slug>",
+	Short: "Change the storage quota of an application on the instance",
+	Long: `
+cozy-stack instances set-app-quota allows to limit the size of the files and
+the number of documents that an application (webapp or konnector) can create
+on the instance of the given domain. The files and documents are attributed to
+an application via their cozyMetadata.createdByApp field.
+
+The documents quota is the number of documents that the application can create
+in each doctype. Set both quotas to 0 to remove the quota of the application.
+`,
+	Example: "$ cozy-stack instances set-app-quota cozy.localhost:8080 impots --disk-quota 500MB --docs-quota 10000",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 2 {
+			return cmd.Usage()
+		}
+		var diskQuota int64
+		if flagDiskQuota != "" {
+			parsed, err := humanize.ParseBytes(flagDiskQuota)
+			if err != nil {
+				return fmt.Errorf("Could not parse disk-quota: %s", err)
+			}
+			diskQuota = int64(parsed)
+		}
+		c := newAdminClient()
+		_, err := c.ModifyInstance(&client.InstanceOptions{
+			Domain:       args[0],
+			AppQuotaSlug: args[1],
+			AppDiskQuota: diskQuota,
+			AppDocsQuota: flagDocsQuota,
+		})
+		return err
+	},
+}
+
 var debugInstanceCmd = &cobra.Command{
 	Use:   "debug <true/false>",
 	Short: "Activate or deactivate debugging of the instance",
@@ -1062,6 +1099,7 @@ func init() {
 	instanceCmdGroup.AddCommand(countInstanceCmd)
 	instanceCmdGroup.AddCommand(lsInstanceCmd)
 	instanceCmdGroup.AddCommand(quotaInstanceCmd)
+	instanceCmdGroup.AddCommand(appQuotaInstanceCmd)
 	instanceCmdGroup.AddCommand(debugInstanceCmd)
 	instanceCmdGroup.AddCommand(destroyInstanceCmd)
 	instanceCmdGroup.AddCommand(fsckInstanceCmd)
@@ -1110,6 +1148,8 @@ func init() {
 	modifyInstanceCmd.Flags().BoolVar(&flagBlocked, "blocked", false, "Block the instance")
 	modifyInstanceCmd.Flags().BoolVar(&flagDeleting, "deleting", false, "Set (or remove) the deleting flag (ex: `--deleting=false`)")
 	modifyInstanceCmd.Flags().BoolVar(&flagOnboardingFinished, "onboarding-finished", false, "Force the finishing of the onboarding")
+	appQuotaInstanceCmd.Flags().StringVar(&flagDiskQuota, "disk-quota", "", "The size of the files that the application can create (eg 500MB)")
+	appQuotaInstanceCmd.Flags().Int64Var(&flagDocsQuota, "docs-quota", 0, "The number of documents that the application can create in each doctype")
 	destroyInstanceCmd.Flags().BoolVar(&flagForce, "force", false, "Force the deletion without asking for confirmation")
 	debugInstanceCmd.Flags().StringVar(&flagDomain, "domain", cozyDomain(), "Specify the domain name of the instance")
 	debugInstanceCmd.Flags().DurationVar(&flagTTL, "ttl", 24*time.Hour, "Specify how long the debug mode will last")
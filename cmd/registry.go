@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var flagRegistrySignature string
+
+var registryCmdGroup = &cobra.Command{
+	Use:   "registry <command>",
+	Short: "Manage the apps registry served by the stack",
+	Long: `
+The stack can act as an apps registry, for the deployments that can't access
+the official registries. It is enabled with the registry_server.enabled
+parameter of the configuration file, and it is served on the admin server.
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return cmd.Usage()
+	},
+}
+
+var registryPublishCmd = &cobra.Command{
+	Use:   "publish <tarball>",
+	Short: "Publish a version of an application on the registry",
+	Long: `
+Publish a version of an application on the registry served by the stack. The
+tarball must be a tar.gz archive with the manifest of the application: the
+slug and the version are read from this manifest, and the channel (stable,
+beta or dev) is deduced from the version number.
+`,
+	Example: `$ cozy-stack registry publish drive-1.2.3.tar.gz`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return cmd.Usage()
+		}
+		f, err := os.Open(args[0])
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		c := newAdminClient()
+		version, err := c.PublishToRegistry(f, flagRegistrySignature)
+		if err != nil {
+			return err
+		}
+		return printJSON(version)
+	},
+}
+
+func init() {
+	registryPublishCmd.Flags().StringVar(&flagRegistrySignature, "signature", "", "base64-encoded ed25519 signature of the tarball")
+	registryCmdGroup.AddCommand(registryPublishCmd)
+	RootCmd.AddCommand(registryCmdGroup)
+}
@@ -37,6 +37,10 @@ type Account struct {
 	// to not try doing the cleaning in the hook as it is already too late (the
 	// konnector is no longer available).
 	ManualCleaning bool `json:"manual_cleaning,omitempty"`
+	// UserAction is set by the stack when the konnector can't run for this
+	// account without an action of the user (for example, re-authenticating
+	// on the website). The triggers of the account are paused until then.
+	UserAction *UserAction `json:"user_action,omitempty"`
 }
 
 // OauthInfo holds configuration information for an oauth account
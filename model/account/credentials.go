@@ -79,17 +79,7 @@ func EncryptCredentialsData(data interface{}) (string, error) {
 // EncryptBufferWithKey encrypts the given bytee buffer with the specified encryption
 // key.
 func EncryptBufferWithKey(encryptorKey *keymgmt.NACLKey, buf []byte) ([]byte, error) {
-	var nonce [nonceLen]byte
-	if _, err := io.ReadFull(rand.Reader, nonce[:]); err != nil {
-		panic(err)
-	}
-
-	encryptedOut := make([]byte, len(cipherHeader)+len(nonce))
-	copy(encryptedOut[0:], cipherHeader)
-	copy(encryptedOut[len(cipherHeader):], nonce[:])
-
-	encryptedCreds := box.Seal(encryptedOut, buf, &nonce, encryptorKey.PublicKey(), encryptorKey.PrivateKey())
-	return encryptedCreds, nil
+	return keymgmt.EncryptBuffer(encryptorKey, buf)
 }
 
 // EncryptCredentials encrypts the given credentials with the specified encryption
@@ -184,32 +174,9 @@ func DecryptCredentialsData(encryptedData string) (interface{}, error) {
 // DecryptBufferWithKey takes an encrypted buffer and decrypts it using the
 // given private key.
 func DecryptBufferWithKey(decryptorKey *keymgmt.NACLKey, encryptedBuffer []byte) ([]byte, error) {
-	// check the cipher text starts with the cipher header
-	if !bytes.HasPrefix(encryptedBuffer, []byte(cipherHeader)) {
-		return nil, ErrBadCredentials
-	}
-
-	// skip the cipher header
-	encryptedBuffer = encryptedBuffer[len(cipherHeader):]
-
-	// check the encrypted creds contains the space for the nonce as prefix
-	if len(encryptedBuffer) < nonceLen {
-		return nil, ErrBadCredentials
-	}
-
-	// extrct the nonce from the first 24 bytes
-	var nonce [nonceLen]byte
-	copy(nonce[:], encryptedBuffer[:nonceLen])
-
-	// skip the nonce
-	encryptedBuffer = encryptedBuffer[nonceLen:]
-
-	// decrypt the cipher text and check that the plain text is more the 4 bytes
-	// long, to contain the login length
-	plainBuffer, ok := box.Open(nil, encryptedBuffer, &nonce, decryptorKey.PublicKey(), decryptorKey.PrivateKey())
-	if !ok {
+	plainBuffer, err := keymgmt.DecryptBuffer(decryptorKey, encryptedBuffer)
+	if err != nil {
 		return nil, ErrBadCredentials
 	}
-
 	return plainBuffer, nil
 }
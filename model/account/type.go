@@ -57,6 +57,10 @@ var RefreshToken = "refresh_token"
 // within an account does not allow refreshing it.
 var ErrUnrefreshable = errors.New("this account can not be refreshed")
 
+// ErrInvalidGrant is the error when the OAuth service has refused the refresh
+// token of an account, and the user must authorize the access again.
+var ErrInvalidGrant = errors.New("the refresh token of this account is no longer valid")
+
 // AccountType holds configuration information for
 type AccountType struct {
 	DocID  string `json:"_id,omitempty"`
@@ -342,17 +346,25 @@ func (at *AccountType) RefreshAccount(a Account) error {
 		return err
 	}
 
+	defer res.Body.Close()
+
+	var out tokenEndpointResponse
 	if res.StatusCode != 200 {
 		resBody, _ := ioutil.ReadAll(res.Body)
+		if err := json.Unmarshal(resBody, &out); err == nil && out.Error == "invalid_grant" {
+			return ErrInvalidGrant
+		}
 		return errors.New("oauth services responded with non-200 res: " + string(resBody))
 	}
 
-	var out tokenEndpointResponse
 	err = json.NewDecoder(res.Body).Decode(&out)
 	if err != nil {
 		return err
 	}
 
+	if out.Error == "invalid_grant" {
+		return ErrInvalidGrant
+	}
 	if out.Error != "" {
 		return fmt.Errorf("OauthError(%s) %s", out.Error, out.ErrorDescription)
 	}
@@ -0,0 +1,109 @@
+package account
+
+import (
+	"strings"
+	"time"
+
+	"github.com/cozy/cozy-stack/model/instance"
+	"github.com/cozy/cozy-stack/pkg/consts"
+	"github.com/cozy/cozy-stack/pkg/couchdb"
+	"github.com/cozy/cozy-stack/pkg/prefixer"
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+)
+
+// UserAction is the reason why a konnector can't run for an account until the
+// user has done something, like re-authenticating on the website.
+type UserAction struct {
+	Reason string    `json:"reason"`
+	Since  time.Time `json:"since"`
+}
+
+// oauthRefreshMargin is the delay before the expiration of an OAuth access
+// token from which the stack refreshes it before running the konnector.
+const oauthRefreshMargin = 5 * time.Minute
+
+// NeedsOauthRefresh returns true if the OAuth access token of the account has
+// expired, or will expire soon, and can be refreshed.
+func (ac *Account) NeedsOauthRefresh() bool {
+	if ac.Oauth == nil || ac.Oauth.RefreshToken == "" || ac.Oauth.ExpiresAt.IsZero() {
+		return false
+	}
+	return time.Until(ac.Oauth.ExpiresAt) < oauthRefreshMargin
+}
+
+// RefreshOauth asks a new access token to the OAuth service with the refresh
+// token of the account, and saves it. ErrInvalidGrant is returned if the user
+// must authorize the access again.
+func RefreshOauth(inst *instance.Instance, ac *Account) error {
+	at, err := TypeInfo(ac.AccountType, inst.ContextName)
+	if err != nil {
+		return err
+	}
+	if err := at.RefreshAccount(*ac); err != nil {
+		return err
+	}
+	// A JSONDoc is used to keep the fields of the account that are unknown to
+	// the stack
+	var doc couchdb.JSONDoc
+	if err := couchdb.GetDoc(inst, consts.Accounts, ac.ID(), &doc); err != nil {
+		return err
+	}
+	doc.Type = consts.Accounts
+	doc.M["oauth"] = ac.Oauth
+	if err := couchdb.UpdateDoc(inst, &doc); err != nil {
+		return err
+	}
+	ac.SetRev(doc.Rev())
+	return nil
+}
+
+// SetUserAction marks the account as needing an action of the user. It
+// returns false if the account was already marked.
+func SetUserAction(db prefixer.Prefixer, accountID, reason string) (bool, error) {
+	var doc couchdb.JSONDoc
+	if err := couchdb.GetDoc(db, consts.Accounts, accountID, &doc); err != nil {
+		return false, err
+	}
+	if _, ok := doc.M["user_action"]; ok {
+		return false, nil
+	}
+	doc.Type = consts.Accounts
+	doc.M["user_action"] = &UserAction{Reason: reason, Since: time.Now().UTC()}
+	if err := couchdb.UpdateDoc(db, &doc); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// ClearUserAction removes the mark on the account that needed an action of
+// the user. It returns false if the account was not marked.
+func ClearUserAction(db prefixer.Prefixer, accountID string) (bool, error) {
+	var doc couchdb.JSONDoc
+	if err := couchdb.GetDoc(db, consts.Accounts, accountID, &doc); err != nil {
+		return false, err
+	}
+	if _, ok := doc.M["user_action"]; !ok {
+		return false, nil
+	}
+	doc.Type = consts.Accounts
+	delete(doc.M, "user_action")
+	if err := couchdb.UpdateDoc(db, &doc); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// TOTPCode returns the one-time password for the given time, computed from
+// the TOTP seed (encoded in base32) of the two-factor authentication of a
+// website. The defaults of the authenticator applications are used: 6 digits,
+// SHA-1 and a period of 30 seconds.
+func TOTPCode(seed string, at time.Time) (string, error) {
+	// The websites often display the seed in groups of 4 characters
+	seed = strings.ReplaceAll(seed, " ", "")
+	return totp.GenerateCodeCustom(seed, at, totp.ValidateOpts{
+		Period:    30,
+		Digits:    otp.DigitsSix,
+		Algorithm: otp.AlgorithmSHA1,
+	})
+}
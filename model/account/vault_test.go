@@ -0,0 +1,62 @@
+package account
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTOTPCode(t *testing.T) {
+	// Test vector from RFC 6238, with 6 digits
+	seed := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	code, err := TOTPCode(seed, time.Unix(59, 0))
+	assert.NoError(t, err)
+	assert.Equal(t, "287082", code)
+
+	code, err = TOTPCode("gezd gnbv gy3t qojq gezd gnbv gy3t qojq", time.Unix(1111111109, 0))
+	assert.NoError(t, err)
+	assert.Equal(t, "081804", code)
+
+	_, err = TOTPCode("not base32!", time.Now())
+	assert.Error(t, err)
+}
+
+func TestNeedsOauthRefresh(t *testing.T) {
+	acc := &Account{}
+	assert.False(t, acc.NeedsOauthRefresh())
+	acc.Oauth = &OauthInfo{AccessToken: "foo", ExpiresAt: time.Now().Add(-time.Hour)}
+	assert.False(t, acc.NeedsOauthRefresh())
+	acc.Oauth.RefreshToken = "bar"
+	assert.True(t, acc.NeedsOauthRefresh())
+	acc.Oauth.ExpiresAt = time.Now().Add(2 * time.Minute)
+	assert.True(t, acc.NeedsOauthRefresh())
+	acc.Oauth.ExpiresAt = time.Now().Add(time.Hour)
+	assert.False(t, acc.NeedsOauthRefresh())
+}
+
+func TestRefreshAccountInvalidGrant(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("refresh_token") == "revoked" {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error": "invalid_grant"}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token": "new-access", "refresh_token": "new-refresh", "expires_in": 3600}`))
+	}))
+	defer ts.Close()
+	at := &AccountType{TokenEndpoint: ts.URL, ClientID: "client", ClientSecret: "secret"}
+
+	acc := Account{Oauth: &OauthInfo{RefreshToken: "revoked"}}
+	assert.Equal(t, ErrInvalidGrant, at.RefreshAccount(acc))
+
+	acc = Account{Oauth: &OauthInfo{RefreshToken: "valid"}}
+	assert.NoError(t, at.RefreshAccount(acc))
+	assert.Equal(t, "new-access", acc.Oauth.AccessToken)
+	assert.Equal(t, "new-refresh", acc.Oauth.RefreshToken)
+	assert.False(t, acc.NeedsOauthRefresh())
+}
@@ -126,3 +126,39 @@ func TestFindIntent(t *testing.T) {
 	found = man.FindIntent("PICK", "io.cozy.files")
 	assert.Nil(t, found)
 }
+
+func TestFindServiceRoute(t *testing.T) {
+	var man WebappManifest
+	name, service, _ := man.FindServiceRoute("/hello")
+	assert.Equal(t, "", name)
+	assert.Nil(t, service)
+
+	man.val.Services = Services{
+		"hello":   {File: "/services/hello.js", Routes: []string{"/hello"}},
+		"hooks":   {File: "/services/hooks.js", Routes: []string{"/hooks/*"}, Public: true},
+		"github":  {File: "/services/github.js", Routes: []string{"/hooks/github/*"}},
+		"default": {File: "/services/default.js", Routes: []string{"/*"}},
+	}
+
+	name, service, rest := man.FindServiceRoute("/hello")
+	assert.Equal(t, "hello", name)
+	assert.Equal(t, "/services/hello.js", service.File)
+	assert.Equal(t, "", rest)
+
+	name, service, rest = man.FindServiceRoute("/hooks/stripe/events")
+	assert.Equal(t, "hooks", name)
+	assert.True(t, service.Public)
+	assert.Equal(t, "stripe/events", rest)
+
+	name, _, rest = man.FindServiceRoute("/hooks/github/push")
+	assert.Equal(t, "github", name)
+	assert.Equal(t, "push", rest)
+
+	name, _, rest = man.FindServiceRoute("/hooksfoo")
+	assert.Equal(t, "default", name)
+	assert.Equal(t, "hooksfoo", rest)
+
+	name, _, rest = man.FindServiceRoute("/hello/world")
+	assert.Equal(t, "default", name)
+	assert.Equal(t, "hello/world", rest)
+}
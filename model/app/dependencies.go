@@ -0,0 +1,382 @@
+package app
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	semver "github.com/Masterminds/semver/v3"
+	"github.com/cozy/cozy-stack/model/instance"
+	build "github.com/cozy/cozy-stack/pkg/config"
+	"github.com/cozy/cozy-stack/pkg/consts"
+	"github.com/cozy/cozy-stack/pkg/couchdb"
+	"github.com/cozy/cozy-stack/pkg/prefixer"
+)
+
+// Dependencies are the requirements declared in the manifest of an
+// application, in the "dependencies" field. They are checked before the
+// installation of the application.
+type Dependencies struct {
+	// Stack is the minimal version of the stack
+	Stack string `json:"stack,omitempty"`
+	// Apps and Konnectors are the applications that must be installed, with
+	// their minimal version (or an empty string for any version)
+	Apps       map[string]string `json:"apps,omitempty"`
+	Konnectors map[string]string `json:"konnectors,omitempty"`
+	// Intents are the intents that must be served by an installed webapp
+	Intents []IntentDependency `json:"intents,omitempty"`
+	// Doctypes are the doctypes that must be managed by an installed
+	// application (a permission to write them)
+	Doctypes []string `json:"doctypes,omitempty"`
+}
+
+// IntentDependency is an intent required by an application.
+type IntentDependency struct {
+	Action string `json:"action"`
+	Type   string `json:"type"`
+}
+
+// The levels of the dependency checks: an error prevents the installation,
+// a warning is only reported.
+const (
+	DependencyOK      = "ok"
+	DependencyWarning = "warning"
+	DependencyError   = "error"
+)
+
+// DependencyCheck is the result of the check of a dependency.
+type DependencyCheck struct {
+	// Kind is stack, webapp, konnector, intent or doctype
+	Kind     string `json:"kind"`
+	Name     string `json:"name"`
+	Required string `json:"required,omitempty"`
+	Found    string `json:"found,omitempty"`
+	Level    string `json:"level"`
+	Message  string `json:"message,omitempty"`
+	// Installable is true for a missing application that can be installed
+	// with the InstallDependencies option
+	Installable bool `json:"installable,omitempty"`
+	// Installed is true when the application has been installed as a
+	// dependency
+	Installed bool `json:"installed,omitempty"`
+}
+
+// DependencyReport is the list of the checks of the dependencies of an
+// application.
+type DependencyReport struct {
+	Checks []*DependencyCheck `json:"checks"`
+}
+
+// OK returns true if no dependency check has failed.
+func (r *DependencyReport) OK() bool {
+	return len(r.Errors()) == 0
+}
+
+// Errors returns the messages of the failed checks.
+func (r *DependencyReport) Errors() []string {
+	var errs []string
+	for _, c := range r.Checks {
+		if c.Level == DependencyError {
+			errs = append(errs, c.Message)
+		}
+	}
+	return errs
+}
+
+func (r *DependencyReport) add(c *DependencyCheck) {
+	if c.Level == "" {
+		c.Level = DependencyOK
+	}
+	r.Checks = append(r.Checks, c)
+}
+
+// installedApps are the applications installed on an instance, used for the
+// dependency checks.
+type installedApps struct {
+	webapps    []*WebappManifest
+	konnectors []*KonnManifest
+}
+
+// loadInstalledApps loads all the webapps and konnectors of the instance,
+// page by page.
+func loadInstalledApps(db prefixer.Prefixer) (*installedApps, error) {
+	apps := &installedApps{}
+	startKey := ""
+	for {
+		webapps, next, err := ListWebappsWithPagination(db, 0, startKey)
+		if err != nil {
+			if couchdb.IsNoDatabaseError(err) {
+				break
+			}
+			return nil, err
+		}
+		apps.webapps = append(apps.webapps, webapps...)
+		if next == "" {
+			break
+		}
+		startKey = next
+	}
+	startKey = ""
+	for {
+		konnectors, next, err := ListKonnectorsWithPagination(db, 0, startKey)
+		if err != nil {
+			if couchdb.IsNoDatabaseError(err) {
+				break
+			}
+			return nil, err
+		}
+		apps.konnectors = append(apps.konnectors, konnectors...)
+		if next == "" {
+			break
+		}
+		startKey = next
+	}
+	return apps, nil
+}
+
+func (apps *installedApps) version(appType consts.AppType, slug string) (string, bool) {
+	if appType == consts.WebappType {
+		for _, man := range apps.webapps {
+			if man.Slug() == slug {
+				return man.Version(), true
+			}
+		}
+	} else {
+		for _, man := range apps.konnectors {
+			if man.Slug() == slug {
+				return man.Version(), true
+			}
+		}
+	}
+	return "", false
+}
+
+func (apps *installedApps) findIntent(action, typ string) *WebappManifest {
+	for _, man := range apps.webapps {
+		if man.FindIntent(action, typ) != nil {
+			return man
+		}
+	}
+	return nil
+}
+
+func (apps *installedApps) managesDoctype(doctype string) bool {
+	var perms []Manifest
+	for _, man := range apps.webapps {
+		perms = append(perms, man)
+	}
+	for _, man := range apps.konnectors {
+		perms = append(perms, man)
+	}
+	for _, man := range perms {
+		for _, rule := range man.Permissions() {
+			if rule.Type == doctype && !rule.Verbs.ReadOnly() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// versionAtLeast returns true if the found version is the required version
+// or a more recent one.
+func versionAtLeast(found, required string) bool {
+	if required == "" {
+		return true
+	}
+	constraint, err := semver.NewVersion(required)
+	if err != nil {
+		return true
+	}
+	v, err := semver.NewVersion(found)
+	if err != nil {
+		return false
+	}
+	return !v.LessThan(constraint)
+}
+
+// checkDependencies checks the dependencies of the manifest against the
+// installed applications.
+func checkDependencies(man Manifest, deps *Dependencies, apps *installedApps, stackVersion string) *DependencyReport {
+	report := &DependencyReport{Checks: []*DependencyCheck{}}
+	if deps == nil {
+		deps = &Dependencies{}
+	}
+
+	if deps.Stack != "" {
+		c := &DependencyCheck{Kind: "stack", Name: "cozy-stack", Required: deps.Stack, Found: stackVersion}
+		if _, err := semver.NewVersion(stackVersion); err != nil {
+			c.Level = DependencyWarning
+			c.Message = fmt.Sprintf("The version of the stack %q can't be checked", stackVersion)
+		} else if !versionAtLeast(stackVersion, deps.Stack) {
+			c.Level = DependencyError
+			c.Message = fmt.Sprintf("The stack %s is required, %s is running", deps.Stack, stackVersion)
+		}
+		report.add(c)
+	}
+
+	checkApp := func(appType consts.AppType, kind, slug, required string) {
+		c := &DependencyCheck{Kind: kind, Name: slug, Required: required}
+		found, ok := apps.version(appType, slug)
+		c.Found = found
+		if !ok {
+			c.Level = DependencyError
+			c.Installable = true
+			c.Message = fmt.Sprintf("The %s %s is not installed", kind, slug)
+		} else if !versionAtLeast(found, required) {
+			c.Level = DependencyError
+			c.Message = fmt.Sprintf("The %s %s %s is required, %s is installed",
+				kind, slug, required, found)
+		}
+		report.add(c)
+	}
+	for _, slug := range sortedKeys(deps.Apps) {
+		checkApp(consts.WebappType, "webapp", slug, deps.Apps[slug])
+	}
+	for _, slug := range sortedKeys(deps.Konnectors) {
+		checkApp(consts.KonnectorType, "konnector", slug, deps.Konnectors[slug])
+	}
+
+	self, _ := man.(*WebappManifest)
+	for _, intent := range deps.Intents {
+		c := &DependencyCheck{Kind: "intent", Name: intent.Action + " " + intent.Type}
+		if self != nil && self.FindIntent(intent.Action, intent.Type) != nil {
+			c.Found = self.Slug()
+		} else if found := apps.findIntent(intent.Action, intent.Type); found != nil {
+			c.Found = found.Slug()
+		} else {
+			c.Level = DependencyWarning
+			c.Message = fmt.Sprintf("No installed application serves the intent %s", c.Name)
+		}
+		report.add(c)
+	}
+
+	for _, doctype := range deps.Doctypes {
+		c := &DependencyCheck{Kind: "doctype", Name: doctype}
+		if !apps.managesDoctype(doctype) {
+			c.Level = DependencyWarning
+			c.Message = fmt.Sprintf("No installed application manages the doctype %s", doctype)
+		}
+		report.add(c)
+	}
+
+	// The intents declared by the application that are already served by
+	// another webapp are reported, as the user will have to choose
+	if self != nil {
+		for _, intent := range self.val.Intents {
+			for _, typ := range intent.Types {
+				found := apps.findIntent(intent.Action, typ)
+				if found == nil || found.Slug() == self.Slug() {
+					continue
+				}
+				report.add(&DependencyCheck{
+					Kind:    "intent",
+					Name:    intent.Action + " " + typ,
+					Found:   found.Slug(),
+					Level:   DependencyWarning,
+					Message: fmt.Sprintf("The intent %s %s is also served by %s", intent.Action, typ, found.Slug()),
+				})
+			}
+		}
+	}
+
+	return report
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// CheckDependencies reads the manifest of the application to install, and
+// checks its dependencies, without installing anything.
+func (i *Installer) CheckDependencies() (Manifest, *DependencyReport, error) {
+	man, err := i.ReadManifest(Installing)
+	if err != nil {
+		return nil, nil, err
+	}
+	apps, err := loadInstalledApps(i.db)
+	if err != nil {
+		return nil, nil, err
+	}
+	return man, checkDependencies(man, i.dependencies, apps, build.Version), nil
+}
+
+// Dependencies returns the report of the dependency checks made during the
+// installation (nil if the checks have not been done).
+func (i *Installer) Dependencies() *DependencyReport {
+	return i.report
+}
+
+// resolveDependencies checks the dependencies of the new manifest, installs
+// the missing applications if asked, and returns an error if some
+// dependencies are still not satisfied.
+func (i *Installer) resolveDependencies(man Manifest) error {
+	apps, err := loadInstalledApps(i.db)
+	if err != nil {
+		return err
+	}
+	report := checkDependencies(man, i.dependencies, apps, build.Version)
+
+	var installed []*DependencyCheck
+	if i.installDependencies {
+		for _, c := range report.Checks {
+			if !c.Installable {
+				continue
+			}
+			if err := i.installDependency(c); err != nil {
+				i.log.Infof("Cannot install the dependency %s: %s", c.Name, err)
+				continue
+			}
+			installed = append(installed, c)
+		}
+	}
+	if len(installed) > 0 {
+		if apps, err = loadInstalledApps(i.db); err != nil {
+			return err
+		}
+		report = checkDependencies(man, i.dependencies, apps, build.Version)
+		for _, c := range report.Checks {
+			for _, dep := range installed {
+				if c.Kind == dep.Kind && c.Name == dep.Name {
+					c.Installed = true
+				}
+			}
+		}
+	}
+
+	i.report = report
+	if errs := report.Errors(); len(errs) > 0 {
+		return fmt.Errorf("%w: %s", ErrUnmetDependencies, strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// installDependency installs a missing application from the registry. The
+// dependencies of this application are not installed automatically.
+func (i *Installer) installDependency(c *DependencyCheck) error {
+	appType := consts.WebappType
+	if c.Kind == "konnector" {
+		appType = consts.KonnectorType
+	}
+	inst, err := instance.Get(i.Domain())
+	if err != nil {
+		return err
+	}
+	installer, err := NewInstaller(inst, Copier(appType, inst), &InstallerOptions{
+		Operation:  Install,
+		Type:       appType,
+		Slug:       c.Name,
+		SourceURL:  "registry://" + c.Name + "/stable",
+		Registries: i.registries,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = installer.RunSync()
+	return err
+}
@@ -0,0 +1,112 @@
+package app
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/cozy/cozy-stack/model/permission"
+	"github.com/cozy/cozy-stack/pkg/consts"
+	"github.com/cozy/cozy-stack/pkg/couchdb"
+	"github.com/cozy/cozy-stack/pkg/prefixer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckDependencies(t *testing.T) {
+	contacts := &WebappManifest{}
+	contacts.val.Slug = "contacts"
+	contacts.val.Version = "1.2.0"
+	contacts.val.Intents = []Intent{{Action: "PICK", Types: []string{"io.cozy.contacts"}}}
+	contacts.val.Permissions = permission.Set{
+		{Type: "io.cozy.contacts", Verbs: permission.ALL},
+	}
+	drive := &WebappManifest{}
+	drive.val.Slug = "drive"
+	drive.val.Version = "1.30.0"
+	drive.val.Intents = []Intent{{Action: "OPEN", Types: []string{"io.cozy.files"}}}
+	drive.val.Permissions = permission.Set{
+		{Type: "io.cozy.bank.operations", Verbs: permission.Verbs(permission.GET)},
+	}
+	apps := &installedApps{webapps: []*WebappManifest{contacts, drive}}
+
+	man := &WebappManifest{}
+	man.val.Slug = "mespapiers"
+	man.val.Intents = []Intent{{Action: "OPEN", Types: []string{"io.cozy.files"}}}
+
+	report := checkDependencies(man, nil, apps, "1.5.0")
+	require.Len(t, report.Checks, 1)
+	assert.Equal(t, DependencyWarning, report.Checks[0].Level)
+	assert.Equal(t, "drive", report.Checks[0].Found)
+	assert.True(t, report.OK())
+
+	deps := &Dependencies{
+		Stack:      "1.4.0",
+		Apps:       map[string]string{"contacts": "1.0.0", "drive": "2.0.0"},
+		Konnectors: map[string]string{"impots": ""},
+		Intents: []IntentDependency{
+			{Action: "PICK", Type: "io.cozy.contacts"},
+			{Action: "SHARE", Type: "io.cozy.files"},
+		},
+		Doctypes: []string{"io.cozy.contacts", "io.cozy.bank.operations"},
+	}
+	report = checkDependencies(man, deps, apps, "1.5.0")
+	assert.False(t, report.OK())
+	levels := make(map[string]string)
+	for _, c := range report.Checks {
+		levels[c.Kind+" "+c.Name] = c.Level
+	}
+	assert.Equal(t, DependencyOK, levels["stack cozy-stack"])
+	assert.Equal(t, DependencyOK, levels["webapp contacts"])
+	assert.Equal(t, DependencyError, levels["webapp drive"])
+	assert.Equal(t, DependencyError, levels["konnector impots"])
+	assert.Equal(t, DependencyOK, levels["intent PICK io.cozy.contacts"])
+	assert.Equal(t, DependencyWarning, levels["intent SHARE io.cozy.files"])
+	assert.Equal(t, DependencyOK, levels["doctype io.cozy.contacts"])
+	assert.Equal(t, DependencyWarning, levels["doctype io.cozy.bank.operations"])
+	assert.Equal(t, []string{
+		"The webapp drive 2.0.0 is required, 1.30.0 is installed",
+		"The konnector impots is not installed",
+	}, report.Errors())
+	for _, c := range report.Checks {
+		assert.Equal(t, c.Kind == "konnector", c.Installable)
+	}
+
+	report = checkDependencies(man, &Dependencies{Stack: "2.0.0"}, apps, "1.5.0")
+	assert.False(t, report.OK())
+	report = checkDependencies(man, &Dependencies{Stack: "2.0.0"}, apps, "")
+	assert.True(t, report.OK())
+}
+
+func TestLoadInstalledAppsPagination(t *testing.T) {
+	db := prefixer.NewPrefixer("", "deps-test")
+	require.NoError(t, couchdb.ResetDB(db, consts.Apps))
+	require.NoError(t, couchdb.ResetDB(db, consts.Konnectors))
+	defer func() {
+		_ = couchdb.DeleteDB(db, consts.Apps)
+		_ = couchdb.DeleteDB(db, consts.Konnectors)
+	}()
+
+	nb := 2*defaultAppListLimit + 10
+	for i := 0; i < nb; i++ {
+		man := &WebappManifest{}
+		man.val.Slug = fmt.Sprintf("webapp-%03d", i)
+		man.val.Version = "1.0.0"
+		man.SetID(consts.Apps + "/" + man.val.Slug)
+		require.NoError(t, couchdb.CreateNamedDocWithDB(db, man))
+	}
+	konn := &KonnManifest{}
+	konn.val.Slug = "impots"
+	konn.val.Version = "2.0.0"
+	konn.SetID(consts.Konnectors + "/" + konn.val.Slug)
+	require.NoError(t, couchdb.CreateNamedDocWithDB(db, konn))
+
+	apps, err := loadInstalledApps(db)
+	require.NoError(t, err)
+	assert.Len(t, apps.webapps, nb)
+	version, ok := apps.version(consts.WebappType, fmt.Sprintf("webapp-%03d", nb-1))
+	assert.True(t, ok)
+	assert.Equal(t, "1.0.0", version)
+	version, ok = apps.version(consts.KonnectorType, "impots")
+	assert.True(t, ok)
+	assert.Equal(t, "2.0.0", version)
+}
@@ -34,4 +34,28 @@ var (
 	ErrBadChecksum = errors.New("Application checksum does not match")
 	// ErrLinkedAppExists is used when an OAuth client is linked to this app
 	ErrLinkedAppExists = errors.New("A linked OAuth client exists for this app")
+	// ErrInvalidAppType is used when the type of application is neither
+	// webapp nor konnector
+	ErrInvalidAppType = errors.New("Invalid application type")
+	// ErrRolloutNotRunning is used when trying to roll back or cancel a
+	// rollout that is no longer running.
+	ErrRolloutNotRunning = errors.New("The rollout is not running")
+	// ErrRolloutNotRolledBack is used when trying to unpin the instances of
+	// a rollout that has not been rolled back.
+	ErrRolloutNotRolledBack = errors.New("The rollout has not been rolled back")
+	// ErrRollbackNotSupported is used when an application can't be rolled
+	// back, because it has not been installed from the registry.
+	ErrRollbackNotSupported = errors.New("Only the applications installed from the registry can be rolled back")
+	// ErrMissingSignature is used when the bundle of an application has no
+	// signature, and the signatures are enforced.
+	ErrMissingSignature = errors.New("The application bundle is not signed")
+	// ErrBadSignature is used when the signature of the bundle of an
+	// application is not valid for any of the trusted keys.
+	ErrBadSignature = errors.New("The application bundle signature is invalid")
+	// ErrUnmetDependencies is used when the dependencies declared in the
+	// manifest of an application are not satisfied.
+	ErrUnmetDependencies = errors.New("The dependencies of the application are not satisfied")
+	// ErrDocsQuotaExceeded is used when an application tries to create a
+	// document, but it has already created too many documents in this doctype.
+	ErrDocsQuotaExceeded = errors.New("The application has exceeded its quota of documents")
 )
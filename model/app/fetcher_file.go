@@ -14,6 +14,7 @@ import (
 
 type fileFetcher struct {
 	manFilename string
+	signatures  *signatureChecker
 	log         *logger.Entry
 }
 
@@ -21,9 +22,10 @@ type fileFetcher struct {
 // application installed with this mode is appended with a random number so
 // that multiple version can be installed from the same directory without
 // having to increase the version number from the manifest.
-func newFileFetcher(manFilename string, log *logger.Entry) *fileFetcher {
+func newFileFetcher(manFilename string, signatures *signatureChecker, log *logger.Entry) *fileFetcher {
 	return &fileFetcher{
 		manFilename: manFilename,
+		signatures:  signatures,
 		log:         log,
 	}
 }
@@ -40,6 +42,9 @@ func (f *fileFetcher) FetchManifest(src *url.URL) (io.ReadCloser, error) {
 }
 
 func (f *fileFetcher) Fetch(src *url.URL, fs appfs.Copier, man Manifest) (err error) {
+	if err = f.signatures.unsigned(); err != nil {
+		return err
+	}
 	version := man.Version() + "-" + utils.RandomString(10)
 	man.SetVersion(version)
 	exists, err := fs.Start(man.Slug(), man.Version(), "")
@@ -38,12 +38,14 @@ var (
 
 type gitFetcher struct {
 	manFilename string
+	signatures  *signatureChecker
 	log         *logger.Entry
 }
 
-func newGitFetcher(manFilename string, log *logger.Entry) *gitFetcher {
+func newGitFetcher(manFilename string, signatures *signatureChecker, log *logger.Entry) *gitFetcher {
 	return &gitFetcher{
 		manFilename: manFilename,
+		signatures:  signatures,
 		log:         log,
 	}
 }
@@ -146,6 +148,11 @@ func (g *gitFetcher) Fetch(src *url.URL, fs appfs.Copier, man Manifest) (err err
 		}
 	}()
 
+	// A git repository has no signature
+	if err = g.signatures.unsigned(); err != nil {
+		return err
+	}
+
 	osFs := afero.NewOsFs()
 	gitDir, err := afero.TempDir(osFs, "", "cozy-app-"+man.Slug())
 	if err != nil {
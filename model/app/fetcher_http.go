@@ -28,12 +28,14 @@ var httpClient = http.Client{
 type httpFetcher struct {
 	manFilename string
 	prefix      string
+	signatures  *signatureChecker
 	log         *logger.Entry
 }
 
-func newHTTPFetcher(manFilename string, log *logger.Entry) *httpFetcher {
+func newHTTPFetcher(manFilename string, signatures *signatureChecker, log *logger.Entry) *httpFetcher {
 	return &httpFetcher{
 		manFilename: manFilename,
+		signatures:  signatures,
 		log:         log,
 	}
 }
@@ -103,14 +105,59 @@ func (f *httpFetcher) Fetch(src *url.URL, fs appfs.Copier, man Manifest) (err er
 	if frag := src.Fragment; frag != "" {
 		shasum, _ = hex.DecodeString(frag)
 	}
-	return fetchHTTP(src, shasum, fs, man, f.prefix)
+	var verify func(digest []byte) error
+	if f.signatures != nil {
+		signature := f.fetchSignature(src)
+		verify = func(digest []byte) error {
+			return f.signatures.check(man.Slug(), man.Version(), digest, signature)
+		}
+	}
+	return fetchHTTP(src, shasum, verify, fs, man, f.prefix)
 }
 
-func fetchHTTP(src *url.URL, shasum []byte, fs appfs.Copier, man Manifest, prefix string) (err error) {
+// fetchSignature returns the detached signature of a tarball, published next
+// to it with the .sig extension. An empty string is returned if there is no
+// signature.
+func (f *httpFetcher) fetchSignature(src *url.URL) string {
+	u := *src
+	u.Fragment = ""
+	u.Path += ".sig"
+	resp, err := httpClient.Get(u.String())
+	if err != nil {
+		f.log.Infof("Cannot fetch the signature %s: %s", u.String(), err)
+		return ""
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return ""
+	}
+	sig, err := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(sig))
+}
+
+// fetchHTTP downloads the tarball and copies its files. The sha256 of the
+// tarball is compared to shasum if not empty, and given to the verify
+// function (if not nil) before committing the files. If the files are already
+// here, the tarball is not downloaded, and the verify function is called with
+// the checksum of the manifest.
+func fetchHTTP(src *url.URL, shasum []byte, verify func(digest []byte) error, fs appfs.Copier, man Manifest, prefix string) (err error) {
 	exists, err := fs.Start(man.Slug(), man.Version(), man.Checksum())
-	if err != nil || exists {
+	if err != nil {
 		return err
 	}
+	if exists {
+		if verify == nil {
+			return nil
+		}
+		digest, _ := hex.DecodeString(man.Checksum())
+		if len(digest) == 0 {
+			digest = shasum
+		}
+		return verify(digest)
+	}
 	defer func() {
 		if err != nil {
 			_ = fs.Abort()
@@ -143,10 +190,11 @@ func fetchHTTP(src *url.URL, shasum []byte, fs appfs.Copier, man Manifest, prefi
 	var reader io.Reader = resp.Body
 	var h hash.Hash
 
-	if len(shasum) > 0 {
+	if len(shasum) > 0 || verify != nil {
 		h = sha256.New()
 		reader = io.TeeReader(reader, h)
 	}
+	raw := reader
 
 	contentType := resp.Header.Get("Content-Type")
 	switch contentType {
@@ -187,8 +235,20 @@ func fetchHTTP(src *url.URL, shasum []byte, fs appfs.Copier, man Manifest, prefi
 			return err
 		}
 	}
-	if len(shasum) > 0 && !bytes.Equal(shasum, h.Sum(nil)) {
+	if h == nil {
+		return nil
+	}
+	// Read the end of the tarball (padding) to compute the sha256 of the
+	// whole file
+	if _, err = io.Copy(ioutil.Discard, raw); err != nil {
+		return err
+	}
+	digest := h.Sum(nil)
+	if len(shasum) > 0 && !bytes.Equal(shasum, digest) {
 		return ErrBadChecksum
 	}
+	if verify != nil {
+		return verify(digest)
+	}
 	return nil
 }
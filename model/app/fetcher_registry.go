@@ -17,11 +17,12 @@ import (
 type registryFetcher struct {
 	log        *logger.Entry
 	registries []*url.URL
+	signatures *signatureChecker
 	version    *registry.Version
 }
 
-func newRegistryFetcher(registries []*url.URL, log *logger.Entry) Fetcher {
-	return &registryFetcher{log: log, registries: registries}
+func newRegistryFetcher(registries []*url.URL, signatures *signatureChecker, log *logger.Entry) Fetcher {
+	return &registryFetcher{log: log, registries: registries, signatures: signatures}
 }
 
 func (f *registryFetcher) FetchManifest(src *url.URL) (io.ReadCloser, error) {
@@ -60,7 +61,10 @@ func (f *registryFetcher) Fetch(src *url.URL, fs appfs.Copier, man Manifest) err
 	}
 	man.SetVersion(v.Version)
 	man.SetChecksum(v.Sha256)
-	return fetchHTTP(u, shasum, fs, man, v.TarPrefix)
+	verify := func(digest []byte) error {
+		return f.signatures.check(man.Slug(), v.Version, digest, v.Signature)
+	}
+	return fetchHTTP(u, shasum, verify, fs, man, v.TarPrefix)
 }
 
 func getRegistryChannel(src *url.URL) (string, string) {
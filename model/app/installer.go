@@ -10,6 +10,7 @@ import (
 	"time"
 
 	semver "github.com/Masterminds/semver/v3"
+	"github.com/cozy/cozy-stack/model/audit"
 	"github.com/cozy/cozy-stack/model/instance"
 	"github.com/cozy/cozy-stack/model/permission"
 	"github.com/cozy/cozy-stack/pkg/appfs"
@@ -49,6 +50,10 @@ type Installer struct {
 
 	overridenParameters map[string]interface{}
 	permissionsAcked    bool
+	installDependencies bool
+	registries          []*url.URL
+	dependencies        *Dependencies
+	report              *DependencyReport
 
 	man     Manifest
 	src     *url.URL
@@ -70,6 +75,9 @@ type InstallerOptions struct {
 	Deactivated      bool
 	PermissionsAcked bool
 	Registries       []*url.URL
+	// InstallDependencies allows to install the missing applications declared
+	// in the dependencies of the manifest, from the registries
+	InstallDependencies bool
 
 	// Used to override the "Parameters" field of konnectors during installation.
 	// This modification is useful to allow the parameterization of a konnector
@@ -149,16 +157,21 @@ func NewInstaller(in *instance.Instance, fs appfs.Copier, opts *InstallerOptions
 		manFilename = KonnectorManifestName
 	}
 
+	var signatures *signatureChecker
+	if opts.Operation != Delete {
+		signatures = newSignatureChecker(in, opts.Registries, log)
+	}
+
 	var fetcher Fetcher
 	switch src.Scheme {
 	case "git", "git+ssh", "ssh+git", "git+https":
-		fetcher = newGitFetcher(manFilename, log)
+		fetcher = newGitFetcher(manFilename, signatures, log)
 	case "http", "https":
-		fetcher = newHTTPFetcher(manFilename, log)
+		fetcher = newHTTPFetcher(manFilename, signatures, log)
 	case "registry":
-		fetcher = newRegistryFetcher(opts.Registries, log)
+		fetcher = newRegistryFetcher(opts.Registries, signatures, log)
 	case "file":
-		fetcher = newFileFetcher(manFilename, log)
+		fetcher = newFileFetcher(manFilename, signatures, log)
 	default:
 		return nil, ErrNotSupportedSource
 	}
@@ -172,6 +185,8 @@ func NewInstaller(in *instance.Instance, fs appfs.Copier, opts *InstallerOptions
 
 		overridenParameters: opts.OverridenParameters,
 		permissionsAcked:    opts.PermissionsAcked,
+		installDependencies: opts.InstallDependencies,
+		registries:          opts.Registries,
 
 		man:     man,
 		src:     src,
@@ -305,6 +320,9 @@ func (i *Installer) install() error {
 			i.log.Debugf("Could not read manifest")
 			return err
 		}
+		if err := i.resolveDependencies(newManifest); err != nil {
+			return err
+		}
 		i.man = newManifest
 		i.sendRealtimeEvent()
 		i.notifyChannel()
@@ -313,7 +331,19 @@ func (i *Installer) install() error {
 			return err
 		}
 		i.man.SetState(i.endState)
-		return i.man.Create(i.db)
+		if err := i.man.Create(i.db); err != nil {
+			return err
+		}
+		audit.Record(i.db, &audit.Entry{
+			Action: audit.ActionAppInstalled,
+			Target: i.man.DocType() + "/" + i.slug,
+			Details: map[string]interface{}{
+				"type":    i.man.AppType().String(),
+				"version": i.man.Version(),
+				"source":  i.man.Source(),
+			},
+		})
+		return nil
 	})
 }
 
@@ -406,6 +436,14 @@ func (i *Installer) update() error {
 		availableVersion = newManifest.Version()
 	}
 
+	// The dependencies are checked like for an installation, and a new
+	// version with unmet dependencies is not installed.
+	if makeUpdate {
+		if err := i.resolveDependencies(newManifest); err != nil {
+			return err
+		}
+	}
+
 	extraPerms := permission.Set{}
 	var alteredPerms *permission.Permission
 	// The "extraPerms" set represents the post-install alterations of the
@@ -527,11 +565,13 @@ func (i *Installer) ReadManifest(state State) (Manifest, error) {
 	// Checking the new manifest apptype to prevent human mistakes (like asking
 	// a konnector installation instead of a webapp)
 	newAppType := struct {
-		AppType string `json:"type"`
+		AppType      string        `json:"type"`
+		Dependencies *Dependencies `json:"dependencies"`
 	}{}
 
 	var newManifestAppType consts.AppType
 	if err = json.Unmarshal(buf.Bytes(), &newAppType); err == nil {
+		i.dependencies = newAppType.Dependencies
 		if newAppType.AppType == "konnector" {
 			newManifestAppType = consts.KonnectorType
 		}
@@ -42,6 +42,9 @@ type KonnManifest struct {
 		Icon            string `json:"icon"`
 		Language        string `json:"language"`
 		OnDeleteAccount string `json:"on_delete_account"`
+		// AllowedHosts is the list of the hosts that the konnector can
+		// connect to via the egress proxy, when it is enabled
+		AllowedHosts []string `json:"allowed_hosts"`
 
 		// Fields with complex types
 		Permissions   permission.Set `json:"permissions"`
@@ -163,6 +166,10 @@ func (m *KonnManifest) Icon() string { return m.val.Icon }
 // (only "node" for the moment).
 func (m *KonnManifest) Language() string { return m.val.Language }
 
+// AllowedHosts returns the list of the hosts that the konnector can connect
+// to. A host can start with a wildcard, like *.example.com.
+func (m *KonnManifest) AllowedHosts() []string { return m.val.AllowedHosts }
+
 // OnDeleteAccount can be used to specify a file path which will be executed
 // when an account associated with the konnector is deleted.
 func (m *KonnManifest) OnDeleteAccount() string { return m.val.OnDeleteAccount }
@@ -0,0 +1,74 @@
+package app
+
+import (
+	"context"
+
+	"github.com/cozy/cozy-stack/model/instance"
+	"github.com/cozy/cozy-stack/pkg/couchdb"
+	"github.com/cozy/cozy-stack/pkg/prefixer"
+	"golang.org/x/sync/errgroup"
+)
+
+// docsByAppView returns the view used for counting the documents of a
+// doctype created by each application. As the doctype can be any doctype, the
+// view is defined lazily, on the first request.
+func docsByAppView(doctype string) *couchdb.View {
+	return &couchdb.View{
+		Name:    "docs-by-created-by-app",
+		Doctype: doctype,
+		Map: `
+function(doc) {
+  if (doc.cozyMetadata && doc.cozyMetadata.createdByApp) {
+    emit(doc.cozyMetadata.createdByApp);
+  }
+}
+`,
+		Reduce: "_count",
+	}
+}
+
+// CountDocsCreatedByApp returns the number of documents of the given doctype
+// that have been created by the application (via their
+// cozyMetadata.createdByApp field).
+func CountDocsCreatedByApp(db prefixer.Prefixer, doctype, slug string) (int64, error) {
+	view := docsByAppView(doctype)
+	req := &couchdb.ViewRequest{Key: slug, Reduce: true}
+	var res couchdb.ViewResponse
+	err := couchdb.ExecView(db, view, req, &res)
+	if couchdb.IsNoDatabaseError(err) {
+		return 0, nil
+	}
+	if couchdb.IsNotFoundError(err) {
+		g, _ := errgroup.WithContext(context.Background())
+		couchdb.DefineViews(g, db, []*couchdb.View{view})
+		if err = g.Wait(); err != nil {
+			return 0, err
+		}
+		err = couchdb.ExecView(db, view, req, &res)
+	}
+	if err != nil {
+		return 0, err
+	}
+	if len(res.Rows) == 0 {
+		return 0, nil
+	}
+	count, _ := res.Rows[0].Value.(float64)
+	return int64(count), nil
+}
+
+// CheckDocsQuota returns ErrDocsQuotaExceeded if the application can't create
+// a new document in the given doctype.
+func CheckDocsQuota(inst *instance.Instance, slug, doctype string) error {
+	quota := inst.AppDocsQuota(slug)
+	if quota <= 0 {
+		return nil
+	}
+	count, err := CountDocsCreatedByApp(inst, doctype, slug)
+	if err != nil {
+		return err
+	}
+	if count >= quota {
+		return ErrDocsQuotaExceeded
+	}
+	return nil
+}
@@ -0,0 +1,575 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/cozy/cozy-stack/model/instance"
+	"github.com/cozy/cozy-stack/model/job"
+	"github.com/cozy/cozy-stack/pkg/consts"
+	"github.com/cozy/cozy-stack/pkg/couchdb"
+	"github.com/cozy/cozy-stack/pkg/couchdb/mango"
+	"github.com/cozy/cozy-stack/pkg/logger"
+	"github.com/cozy/cozy-stack/pkg/prefixer"
+	"github.com/cozy/cozy-stack/pkg/utils"
+)
+
+// RolloutStatus is the status of a staged rollout.
+type RolloutStatus string
+
+const (
+	// RolloutRunning is the status of a rollout when the instances are
+	// updated, or when the error rates are watched.
+	RolloutRunning RolloutStatus = "running"
+	// RolloutRollingBack is the status of a rollout when the instances are
+	// being rolled back to their previous version.
+	RolloutRollingBack RolloutStatus = "rolling_back"
+	// RolloutCompleted is the status of a rollout when the watch period has
+	// ended without degradation of the error rates.
+	RolloutCompleted RolloutStatus = "completed"
+	// RolloutRolledBack is the status of a rollout when the instances have
+	// been rolled back to their previous version.
+	RolloutRolledBack RolloutStatus = "rolled_back"
+	// RolloutCanceled is the status of a rollout that has been stopped by an
+	// administrator, without rollback.
+	RolloutCanceled RolloutStatus = "canceled"
+)
+
+const (
+	defaultRolloutMaxErrorRate  = 0.1
+	defaultRolloutMinJobs       = 10
+	defaultRolloutWatch         = 6 * time.Hour
+	maxRolloutWatch             = 72 * time.Hour
+	defaultRolloutCheckInterval = 5 * time.Minute
+
+	// RolloutJobTimeout is the timeout for a job of the rollouts worker.
+	RolloutJobTimeout = 30 * time.Minute
+	// rolloutJobLease is the delay before a job is pushed again for a
+	// rollout, if the previous job has been interrupted.
+	rolloutJobLease      = RolloutJobTimeout + 10*time.Minute
+	rolloutSweepInterval = 1 * time.Minute
+	rolloutPageSize      = 100
+)
+
+// RolloutInstance is an instance that has been updated by a rollout, with
+// the informations needed to roll it back. It is saved in its own document,
+// as a rollout can update a lot of instances.
+type RolloutInstance struct {
+	DocID           string    `json:"_id,omitempty"`
+	DocRev          string    `json:"_rev,omitempty"`
+	RolloutID       string    `json:"rollout_id"`
+	Domain          string    `json:"domain"`
+	PreviousVersion string    `json:"previous_version"`
+	PreviousSource  string    `json:"previous_source"`
+	Version         string    `json:"version"`
+	UpdatedAt       time.Time `json:"updated_at"`
+	// BaselineJobs and BaselineErrors are the number of jobs for the
+	// application on the instance, and the number of errored ones, before
+	// the update (for the same duration as the watch period)
+	BaselineJobs   int `json:"baseline_jobs"`
+	BaselineErrors int `json:"baseline_errors"`
+	// Jobs and Errors are the number of jobs, and errored ones, since the
+	// update
+	Jobs   int `json:"jobs"`
+	Errors int `json:"errors"`
+	// RolledBack is true when the instance has been rolled back, and
+	// RollbackError is filled if the rollback has failed
+	RolledBack    bool   `json:"rolled_back,omitempty"`
+	RollbackError string `json:"rollback_error,omitempty"`
+	// Unpinned is true when the source of the application has been put back
+	// to the channel after a rollback
+	Unpinned bool `json:"unpinned,omitempty"`
+}
+
+// RolloutStats are the numbers of jobs for the application on the updated
+// instances, before and after the update.
+type RolloutStats struct {
+	BaselineJobs   int `json:"baseline_jobs"`
+	BaselineErrors int `json:"baseline_errors"`
+	Jobs           int `json:"jobs"`
+	Errors         int `json:"errors"`
+}
+
+// Add adds the numbers of jobs of an updated instance to the stats.
+func (s *RolloutStats) Add(ri *RolloutInstance) {
+	s.BaselineJobs += ri.BaselineJobs
+	s.BaselineErrors += ri.BaselineErrors
+	s.Jobs += ri.Jobs
+	s.Errors += ri.Errors
+}
+
+// Rollout is a staged rollout of an application update: only a share of the
+// instances are updated, and the error rates of the jobs for this application
+// are watched to roll back automatically the update if they degrade.
+type Rollout struct {
+	DocID  string `json:"_id,omitempty"`
+	DocRev string `json:"_rev,omitempty"`
+
+	Slug    string         `json:"slug"`
+	AppType consts.AppType `json:"app_type"`
+	// Source is the source URL used for the update (the source of the
+	// application on each instance if empty)
+	Source string `json:"source,omitempty"`
+	// Percentage is the share of the instances to update, from 1 to 100
+	Percentage int `json:"percentage"`
+	// Contexts can be used to limit the rollout to the instances of these
+	// contexts
+	Contexts []string `json:"contexts,omitempty"`
+	// Force is used to update also the instances where the auto-update has
+	// been disabled
+	Force bool `json:"force,omitempty"`
+
+	// MaxErrorRate is the maximal increase of the error rate, compared to the
+	// error rate before the update, before the rollback
+	MaxErrorRate float64 `json:"max_error_rate"`
+	// MinJobs is the number of jobs needed before taking a decision
+	MinJobs       int           `json:"min_jobs"`
+	Watch         time.Duration `json:"watch"`
+	CheckInterval time.Duration `json:"check_interval"`
+
+	Status RolloutStatus `json:"status"`
+	Reason string        `json:"reason,omitempty"`
+	// Updated is the number of instances updated by the rollout (see
+	// ListRolloutInstances for the details), and Stats are the numbers of
+	// jobs on these instances at the last check
+	Updated   int          `json:"updated"`
+	Stats     RolloutStats `json:"stats"`
+	CreatedAt time.Time    `json:"created_at"`
+	UpdatedAt time.Time    `json:"updated_at"`
+	// WatchUntil is the end of the watch period, set when the instances have
+	// been updated
+	WatchUntil *time.Time `json:"watch_until,omitempty"`
+	// Cursor is the ID of the next instance to look at, when the update of
+	// the instances has been split in several jobs (or the bookmark of the
+	// next page of updated instances for a rollback)
+	Cursor string `json:"cursor,omitempty"`
+	// NextStepAt is when the next job for the rollout must be pushed (to
+	// update more instances, or to check the error rates). It is pushed back
+	// while a job is running, so that the job is pushed again only if it has
+	// been interrupted.
+	NextStepAt time.Time `json:"next_step_at"`
+}
+
+// RolloutMessage is the message for the rollouts worker.
+type RolloutMessage struct {
+	RolloutID string `json:"rollout_id"`
+}
+
+// ID implements couchdb.Doc
+func (r *Rollout) ID() string { return r.DocID }
+
+// Rev implements couchdb.Doc
+func (r *Rollout) Rev() string { return r.DocRev }
+
+// DocType implements couchdb.Doc
+func (r *Rollout) DocType() string { return consts.AppsRollouts }
+
+// SetID implements couchdb.Doc
+func (r *Rollout) SetID(id string) { r.DocID = id }
+
+// SetRev implements couchdb.Doc
+func (r *Rollout) SetRev(rev string) { r.DocRev = rev }
+
+// Clone implements couchdb.Doc
+func (r *Rollout) Clone() couchdb.Doc {
+	cloned := *r
+	cloned.Contexts = make([]string, len(r.Contexts))
+	copy(cloned.Contexts, r.Contexts)
+	if r.WatchUntil != nil {
+		until := *r.WatchUntil
+		cloned.WatchUntil = &until
+	}
+	return &cloned
+}
+
+// CreateRollout checks the parameters of a rollout, fills the default values
+// and saves it in the global database.
+func CreateRollout(r *Rollout) error {
+	if r.Slug == "" || !slugReg.MatchString(r.Slug) {
+		return ErrInvalidSlugName
+	}
+	if r.AppType != consts.WebappType && r.AppType != consts.KonnectorType {
+		return ErrInvalidAppType
+	}
+	if r.Source != "" {
+		if _, err := url.Parse(r.Source); err != nil {
+			return ErrNotSupportedSource
+		}
+		if !strings.HasPrefix(r.Source, "registry://") {
+			return ErrRollbackNotSupported
+		}
+	}
+	if r.Percentage <= 0 || r.Percentage > 100 {
+		return fmt.Errorf("Invalid percentage: %d", r.Percentage)
+	}
+	if r.MaxErrorRate <= 0 {
+		r.MaxErrorRate = defaultRolloutMaxErrorRate
+	}
+	if r.MinJobs <= 0 {
+		r.MinJobs = defaultRolloutMinJobs
+	}
+	if r.Watch <= 0 {
+		r.Watch = defaultRolloutWatch
+	}
+	if r.Watch > maxRolloutWatch {
+		r.Watch = maxRolloutWatch
+	}
+	if r.CheckInterval <= 0 {
+		r.CheckInterval = defaultRolloutCheckInterval
+	}
+	r.DocID = ""
+	r.DocRev = ""
+	r.Status = RolloutRunning
+	r.Reason = ""
+	r.Updated = 0
+	r.Stats = RolloutStats{}
+	r.WatchUntil = nil
+	r.Cursor = ""
+	r.CreatedAt = time.Now().UTC()
+	r.UpdatedAt = r.CreatedAt
+	r.NextStepAt = r.CreatedAt
+	return couchdb.CreateDoc(couchdb.GlobalDB, r)
+}
+
+// GetRollout returns the rollout with the given ID.
+func GetRollout(id string) (*Rollout, error) {
+	r := &Rollout{}
+	if err := couchdb.GetDoc(couchdb.GlobalDB, consts.AppsRollouts, id, r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// ListRollouts returns the rollouts, from the most recent to the oldest, with
+// the given status if not empty. The bookmark can be used to fetch the next
+// page.
+func ListRollouts(status RolloutStatus, limit int, bookmark string) ([]*Rollout, string, error) {
+	req := &couchdb.FindRequest{
+		Limit:    limit,
+		Bookmark: bookmark,
+	}
+	if status != "" {
+		req.UseIndex = "by-status"
+		req.Selector = mango.And(
+			mango.Equal("status", status),
+			mango.Exists("created_at"),
+		)
+		req.Sort = mango.SortBy{
+			{Field: "status", Direction: mango.Desc},
+			{Field: "created_at", Direction: mango.Desc},
+		}
+	} else {
+		req.UseIndex = "by-created-at"
+		req.Selector = mango.Exists("created_at")
+		req.Sort = mango.SortBy{{Field: "created_at", Direction: mango.Desc}}
+	}
+	var rollouts []*Rollout
+	res, err := couchdb.FindDocsRaw(couchdb.GlobalDB, consts.AppsRollouts, req, &rollouts)
+	if err != nil {
+		if couchdb.IsNoDatabaseError(err) {
+			return []*Rollout{}, "", nil
+		}
+		return nil, "", err
+	}
+	if len(rollouts) < limit {
+		return rollouts, "", nil
+	}
+	return rollouts, res.Bookmark, nil
+}
+
+// ListActiveRollouts returns the rollouts that are running or rolling back.
+func ListActiveRollouts() ([]*Rollout, error) {
+	var active []*Rollout
+	for _, status := range []RolloutStatus{RolloutRunning, RolloutRollingBack} {
+		bookmark := ""
+		for {
+			rollouts, next, err := ListRollouts(status, rolloutPageSize, bookmark)
+			if err != nil {
+				return nil, err
+			}
+			active = append(active, rollouts...)
+			if next == "" {
+				break
+			}
+			bookmark = next
+		}
+	}
+	return active, nil
+}
+
+// Active returns true if the rollout is running or rolling back.
+func (r *Rollout) Active() bool {
+	return r.Status == RolloutRunning || r.Status == RolloutRollingBack
+}
+
+// Save updates the rollout in the global database.
+func (r *Rollout) Save() error {
+	r.UpdatedAt = time.Now().UTC()
+	return couchdb.UpdateDoc(couchdb.GlobalDB, r)
+}
+
+// Selects returns true if the instance is one of the instances targeted by
+// the rollout. The selection for the percentage is stable: an instance
+// selected with 10% is also selected with 20%.
+func (r *Rollout) Selects(inst *instance.Instance) bool {
+	if len(r.Contexts) > 0 {
+		found := false
+		for _, ctx := range r.Contexts {
+			if ctx == inst.ContextName {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if r.Percentage >= 100 {
+		return true
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(r.Slug + "/" + inst.Domain))
+	return int(h.Sum32()%100) < r.Percentage
+}
+
+// Holds returns true if the application of the rollout must not be updated
+// on the instance by the auto-updates: the instance is selected by the
+// rollout (the updated instances are always selected).
+func (r *Rollout) Holds(inst *instance.Instance, slug string, appType consts.AppType) bool {
+	if !r.Active() || r.Slug != slug || r.AppType != appType {
+		return false
+	}
+	return r.Selects(inst)
+}
+
+// PushJob pushes a job for the next step of the rollout. The next step is
+// pushed back and the rollout is saved before: if it fails with a conflict,
+// the rollout has been modified since it was loaded (by another job, or by
+// an administrator), and no job is pushed.
+func (r *Rollout) PushJob() (*job.Job, error) {
+	r.NextStepAt = time.Now().UTC().Add(rolloutJobLease)
+	if err := r.Save(); err != nil {
+		return nil, err
+	}
+	msg, err := job.NewMessage(&RolloutMessage{RolloutID: r.ID()})
+	if err != nil {
+		return nil, err
+	}
+	return job.System().PushJob(prefixer.GlobalPrefixer, &job.JobRequest{
+		WorkerType:  "rollouts",
+		Message:     msg,
+		ForwardLogs: true,
+	})
+}
+
+// StartRollback changes the status of a running rollout to rolling back, and
+// pushes a job to roll back the updated instances. It returns a conflict
+// error if the rollout has been modified since it was loaded.
+func (r *Rollout) StartRollback(reason string) (*job.Job, error) {
+	if r.Status != RolloutRunning {
+		return nil, ErrRolloutNotRunning
+	}
+	r.Status = RolloutRollingBack
+	r.Reason = reason
+	r.Cursor = ""
+	return r.PushJob()
+}
+
+// SweepRollouts starts the scheduler of the staged rollouts: it looks
+// periodically for the running rollouts with a step to do, and pushes a job
+// for them. It allows to resume a rollout after a restart of the stack.
+func SweepRollouts() utils.Shutdowner {
+	closed := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-time.After(rolloutSweepInterval):
+				if err := sweepRollouts(); err != nil {
+					logger.WithNamespace("rollouts").
+						Errorf("Could not sweep the rollouts: %s", err)
+				}
+			case <-closed:
+				return
+			}
+		}
+	}()
+	return &rolloutsSweeper{closed}
+}
+
+func sweepRollouts() error {
+	rollouts, err := ListActiveRollouts()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, r := range rollouts {
+		if r.NextStepAt.After(now) {
+			continue
+		}
+		if _, err := r.PushJob(); err != nil && !couchdb.IsConflictError(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+type rolloutsSweeper struct {
+	closed chan struct{}
+}
+
+func (s *rolloutsSweeper) Shutdown(ctx context.Context) error {
+	select {
+	case s.closed <- struct{}{}:
+	case <-ctx.Done():
+	}
+	return nil
+}
+
+// ErrorRates returns the error rates of the jobs for the updated instances,
+// before and after the update, and the number of jobs after the update.
+func (r *Rollout) ErrorRates() (before, after float64, jobs int) {
+	s := r.Stats
+	if s.BaselineJobs > 0 {
+		before = float64(s.BaselineErrors) / float64(s.BaselineJobs)
+	}
+	if s.Jobs > 0 {
+		after = float64(s.Errors) / float64(s.Jobs)
+	}
+	return before, after, s.Jobs
+}
+
+// Degraded returns true if the error rate has increased too much since the
+// update, and the rollout must be rolled back.
+func (r *Rollout) Degraded() bool {
+	before, after, jobs := r.ErrorRates()
+	if jobs < r.MinJobs {
+		return false
+	}
+	return after-before > r.MaxErrorRate
+}
+
+// ID implements couchdb.Doc
+func (ri *RolloutInstance) ID() string { return ri.DocID }
+
+// Rev implements couchdb.Doc
+func (ri *RolloutInstance) Rev() string { return ri.DocRev }
+
+// DocType implements couchdb.Doc
+func (ri *RolloutInstance) DocType() string { return consts.AppsRolloutInstances }
+
+// SetID implements couchdb.Doc
+func (ri *RolloutInstance) SetID(id string) { ri.DocID = id }
+
+// SetRev implements couchdb.Doc
+func (ri *RolloutInstance) SetRev(rev string) { ri.DocRev = rev }
+
+// Clone implements couchdb.Doc
+func (ri *RolloutInstance) Clone() couchdb.Doc {
+	cloned := *ri
+	return &cloned
+}
+
+// AddInstance saves an instance updated by the rollout in the global
+// database. Its ID is made from the ID of the rollout and the domain of the
+// instance, so that an instance is never added twice to a rollout.
+func (r *Rollout) AddInstance(ri *RolloutInstance) error {
+	ri.DocID = r.ID() + "-" + ri.Domain
+	ri.DocRev = ""
+	ri.RolloutID = r.ID()
+	if err := couchdb.CreateNamedDocWithDB(couchdb.GlobalDB, ri); err != nil {
+		if couchdb.IsConflictError(err) {
+			return nil
+		}
+		return err
+	}
+	r.Updated++
+	return nil
+}
+
+// Save updates the instance in the global database.
+func (ri *RolloutInstance) Save() error {
+	return couchdb.UpdateDoc(couchdb.GlobalDB, ri)
+}
+
+// ListRolloutInstances returns the instances updated by the given rollout,
+// sorted by domain. The bookmark can be used to fetch the next page.
+func ListRolloutInstances(rolloutID string, limit int, bookmark string) ([]*RolloutInstance, string, error) {
+	req := &couchdb.FindRequest{
+		UseIndex: "by-rollout",
+		Selector: mango.And(
+			mango.Equal("rollout_id", rolloutID),
+			mango.Exists("domain"),
+		),
+		Sort: mango.SortBy{
+			{Field: "rollout_id", Direction: mango.Asc},
+			{Field: "domain", Direction: mango.Asc},
+		},
+		Limit:    limit,
+		Bookmark: bookmark,
+	}
+	var instances []*RolloutInstance
+	res, err := couchdb.FindDocsRaw(couchdb.GlobalDB, consts.AppsRolloutInstances, req, &instances)
+	if err != nil {
+		if couchdb.IsNoDatabaseError(err) {
+			return []*RolloutInstance{}, "", nil
+		}
+		return nil, "", err
+	}
+	if len(instances) < limit {
+		return instances, "", nil
+	}
+	return instances, res.Bookmark, nil
+}
+
+// ForEachInstance calls the function for each instance updated by the
+// rollout, page by page.
+func (r *Rollout) ForEachInstance(fn func(ri *RolloutInstance) error) error {
+	bookmark := ""
+	for {
+		instances, next, err := ListRolloutInstances(r.ID(), rolloutPageSize, bookmark)
+		if err != nil {
+			return err
+		}
+		for _, ri := range instances {
+			if err := fn(ri); err != nil {
+				return err
+			}
+		}
+		if next == "" {
+			return nil
+		}
+		bookmark = next
+	}
+}
+
+// PinnedRegistrySource returns the registry source URL for the given version
+// of an application, in the same channel as the given source.
+func PinnedRegistrySource(source, version string) (string, error) {
+	src, err := url.Parse(source)
+	if err != nil || src.Scheme != "registry" || src.Host == "" {
+		return "", ErrRollbackNotSupported
+	}
+	channel, _ := getRegistryChannel(src)
+	if channel == "" {
+		channel = "stable"
+	}
+	return fmt.Sprintf("registry://%s/%s/%s", src.Host, channel, version), nil
+}
+
+// ChannelRegistrySource returns the registry source URL for the channel of
+// the given source, without a pinned version.
+func ChannelRegistrySource(source string) (string, error) {
+	src, err := url.Parse(source)
+	if err != nil || src.Scheme != "registry" || src.Host == "" {
+		return "", ErrRollbackNotSupported
+	}
+	channel, _ := getRegistryChannel(src)
+	if channel == "" {
+		channel = "stable"
+	}
+	return fmt.Sprintf("registry://%s/%s", src.Host, channel), nil
+}
@@ -0,0 +1,96 @@
+package app
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/cozy/cozy-stack/model/instance"
+	"github.com/cozy/cozy-stack/pkg/consts"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRolloutSelects(t *testing.T) {
+	r := &Rollout{Slug: "drive", Percentage: 100, Contexts: []string{"beta"}}
+	assert.True(t, r.Selects(&instance.Instance{Domain: "a.cozy.example", ContextName: "beta"}))
+	assert.False(t, r.Selects(&instance.Instance{Domain: "a.cozy.example", ContextName: "prod"}))
+
+	r = &Rollout{Slug: "drive", Percentage: 10}
+	r20 := &Rollout{Slug: "drive", Percentage: 20}
+	selected := 0
+	for i := 0; i < 1000; i++ {
+		inst := &instance.Instance{Domain: fmt.Sprintf("user%d.cozy.example", i)}
+		if r.Selects(inst) {
+			selected++
+			assert.True(t, r20.Selects(inst))
+		}
+	}
+	assert.InDelta(t, 100, selected, 40)
+}
+
+func TestRolloutDegraded(t *testing.T) {
+	r := &Rollout{MaxErrorRate: 0.1, MinJobs: 10}
+	r.Stats.Add(&RolloutInstance{BaselineJobs: 50, BaselineErrors: 5, Jobs: 5, Errors: 5})
+	assert.False(t, r.Degraded(), "not enough jobs")
+
+	r.Stats.Add(&RolloutInstance{BaselineJobs: 50, BaselineErrors: 5, Jobs: 15, Errors: 1})
+	before, after, jobs := r.ErrorRates()
+	assert.InDelta(t, 0.1, before, 0.001)
+	assert.InDelta(t, 0.3, after, 0.001)
+	assert.Equal(t, 20, jobs)
+	assert.True(t, r.Degraded())
+
+	r.MaxErrorRate = 0.5
+	assert.False(t, r.Degraded())
+}
+
+func TestPinnedRegistrySource(t *testing.T) {
+	src, err := PinnedRegistrySource("registry://drive/stable", "1.2.3")
+	assert.NoError(t, err)
+	assert.Equal(t, "registry://drive/stable/1.2.3", src)
+
+	src, err = PinnedRegistrySource("registry://drive/beta/1.3.0-beta.1", "1.2.3")
+	assert.NoError(t, err)
+	assert.Equal(t, "registry://drive/beta/1.2.3", src)
+
+	src, err = PinnedRegistrySource("registry://drive", "1.2.3")
+	assert.NoError(t, err)
+	assert.Equal(t, "registry://drive/stable/1.2.3", src)
+
+	_, err = PinnedRegistrySource("git://github.com/cozy/cozy-drive.git", "1.2.3")
+	assert.Equal(t, ErrRollbackNotSupported, err)
+}
+
+func TestChannelRegistrySource(t *testing.T) {
+	src, err := ChannelRegistrySource("registry://drive/beta/1.3.0-beta.1")
+	assert.NoError(t, err)
+	assert.Equal(t, "registry://drive/beta", src)
+
+	src, err = ChannelRegistrySource("registry://drive")
+	assert.NoError(t, err)
+	assert.Equal(t, "registry://drive/stable", src)
+
+	_, err = ChannelRegistrySource("git://github.com/cozy/cozy-drive.git")
+	assert.Equal(t, ErrRollbackNotSupported, err)
+}
+
+func TestRolloutHolds(t *testing.T) {
+	r := &Rollout{
+		Slug:       "drive",
+		AppType:    consts.WebappType,
+		Percentage: 100,
+		Contexts:   []string{"beta"},
+		Status:     RolloutRunning,
+	}
+	beta := &instance.Instance{Domain: "a.cozy.example", ContextName: "beta"}
+	prod := &instance.Instance{Domain: "b.cozy.example", ContextName: "prod"}
+	assert.True(t, r.Holds(beta, "drive", consts.WebappType))
+	assert.False(t, r.Holds(prod, "drive", consts.WebappType))
+	assert.False(t, r.Holds(beta, "photos", consts.WebappType))
+	assert.False(t, r.Holds(beta, "drive", consts.KonnectorType))
+
+	r.Status = RolloutRollingBack
+	assert.True(t, r.Holds(beta, "drive", consts.WebappType))
+
+	r.Status = RolloutCompleted
+	assert.False(t, r.Holds(beta, "drive", consts.WebappType))
+}
@@ -0,0 +1,186 @@
+package app
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+
+	"github.com/cozy/cozy-stack/model/instance"
+	"github.com/cozy/cozy-stack/pkg/logger"
+	"github.com/cozy/cozy-stack/pkg/registry"
+)
+
+// SignaturePolicy tells what to do with the signatures of the application
+// bundles.
+type SignaturePolicy string
+
+const (
+	// SignatureDisabled means that the signatures are not checked.
+	SignatureDisabled SignaturePolicy = ""
+	// SignatureWarn means that the signatures are checked, but an application
+	// with a missing or invalid signature is still installed (with a warning
+	// in the logs).
+	SignatureWarn SignaturePolicy = "warn"
+	// SignatureEnforce means that an application can be installed only if
+	// its bundle has a valid signature.
+	SignatureEnforce SignaturePolicy = "enforce"
+)
+
+// SignedMessage returns the message that is signed by the publisher of an
+// application: it binds the sha256 of the tarball to the slug and version.
+func SignedMessage(slug, version, sha256 string) []byte {
+	return []byte(fmt.Sprintf("cozy-app-signature:v1:%s:%s:%s", slug, version, sha256))
+}
+
+// signatureChecker checks the signature of the application bundles against
+// the trusted keys.
+type signatureChecker struct {
+	policy       SignaturePolicy
+	keys         []ed25519.PublicKey
+	registryKeys bool
+	rootKeys     []ed25519.PublicKey
+	registries   []*url.URL
+	log          *logger.Entry
+}
+
+// newSignatureChecker returns the checker for the context of the instance,
+// configured by the app_signatures parameter of the context. It returns nil
+// if the signatures are not checked for this context.
+func newSignatureChecker(in *instance.Instance, registries []*url.URL, log *logger.Entry) *signatureChecker {
+	ctxSettings, ok := in.SettingsContext()
+	if !ok {
+		return nil
+	}
+	settings, ok := ctxSettings["app_signatures"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	c := &signatureChecker{registries: registries, log: log}
+	switch policy, _ := settings["policy"].(string); SignaturePolicy(policy) {
+	case SignatureWarn, SignatureEnforce:
+		c.policy = SignaturePolicy(policy)
+	default:
+		return nil
+	}
+	c.registryKeys, _ = settings["registry_keys"].(bool)
+	c.keys = parsePublicKeys(settings["keys"], log)
+	c.rootKeys = parsePublicKeys(settings["registry_root_keys"], log)
+	return c
+}
+
+func parsePublicKeys(param interface{}, log *logger.Entry) []ed25519.PublicKey {
+	var keys []ed25519.PublicKey
+	list, _ := param.([]interface{})
+	for _, k := range list {
+		str, _ := k.(string)
+		key, err := parsePublicKey(str)
+		if err != nil {
+			log.Errorf("Invalid key in app_signatures: %s", err)
+			continue
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// parsePublicKey parses a base64-encoded ed25519 public key.
+func parsePublicKey(str string) (ed25519.PublicKey, error) {
+	raw, err := base64.StdEncoding.DecodeString(str)
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("bad size for an ed25519 public key: %d", len(raw))
+	}
+	return ed25519.PublicKey(raw), nil
+}
+
+// check verifies the base64-encoded signature of a tarball, given its sha256
+// digest. With the warn policy, it only logs the error.
+func (c *signatureChecker) check(slug, version string, digest []byte, signature string) error {
+	if c == nil || c.policy == SignatureDisabled {
+		return nil
+	}
+	if signature == "" {
+		return c.fail(ErrMissingSignature)
+	}
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil || len(sig) != ed25519.SignatureSize {
+		return c.fail(ErrBadSignature)
+	}
+	msg := SignedMessage(slug, version, hex.EncodeToString(digest))
+	for _, key := range c.trustedKeys() {
+		if ed25519.Verify(key, msg, sig) {
+			return nil
+		}
+	}
+	return c.fail(ErrBadSignature)
+}
+
+// unsigned is used for the sources that have no signature (git and file).
+func (c *signatureChecker) unsigned() error {
+	if c == nil || c.policy == SignatureDisabled {
+		return nil
+	}
+	return c.fail(ErrMissingSignature)
+}
+
+func (c *signatureChecker) trustedKeys() []ed25519.PublicKey {
+	if !c.registryKeys {
+		return c.keys
+	}
+	if len(c.rootKeys) == 0 {
+		c.log.Errorf("registry_keys is enabled without registry_root_keys: " +
+			"the keys published by the registry are not trusted")
+		return c.keys
+	}
+	keys := append([]ed25519.PublicKey{}, c.keys...)
+	published, err := registry.GetSigningKeys(c.registries)
+	if err != nil {
+		c.log.Infof("Cannot fetch the signing keys from the registry: %s", err)
+		return keys
+	}
+	return append(keys, c.endorsedKeys(published)...)
+}
+
+// endorsedKeys returns the keys published by the registry that have been
+// endorsed by one of the root keys. The registry is not trusted by itself: a
+// compromised registry could publish its own key and sign a malicious bundle.
+func (c *signatureChecker) endorsedKeys(published []registry.SigningKey) []ed25519.PublicKey {
+	var keys []ed25519.PublicKey
+	for _, pk := range published {
+		key, err := parsePublicKey(pk.Key)
+		if err != nil {
+			continue
+		}
+		sig, err := base64.StdEncoding.DecodeString(pk.Signature)
+		if err != nil || len(sig) != ed25519.SignatureSize {
+			c.log.Warnf("The signing key %s has no valid endorsement", pk.Key)
+			continue
+		}
+		msg := registry.EndorsementMessage(pk.Key)
+		endorsed := false
+		for _, root := range c.rootKeys {
+			if ed25519.Verify(root, msg, sig) {
+				endorsed = true
+				break
+			}
+		}
+		if endorsed {
+			keys = append(keys, key)
+		} else {
+			c.log.Warnf("The signing key %s is not endorsed by a root key", pk.Key)
+		}
+	}
+	return keys
+}
+
+func (c *signatureChecker) fail(err error) error {
+	if c.policy == SignatureWarn {
+		c.log.Warnf("Signature check failed: %s", err)
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,119 @@
+package app
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/url"
+	"testing"
+
+	"github.com/cozy/cozy-stack/pkg/appfs"
+	"github.com/cozy/cozy-stack/pkg/logger"
+	"github.com/cozy/cozy-stack/pkg/registry"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignatureChecker(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	_, otherPriv, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+
+	digest := sha256.Sum256([]byte("tarball"))
+	msg := SignedMessage("drive", "1.2.3", hex.EncodeToString(digest[:]))
+	good := base64.StdEncoding.EncodeToString(ed25519.Sign(priv, msg))
+	bad := base64.StdEncoding.EncodeToString(ed25519.Sign(otherPriv, msg))
+
+	c := &signatureChecker{
+		policy: SignatureEnforce,
+		keys:   []ed25519.PublicKey{pub},
+		log:    logger.WithNamespace("test"),
+	}
+	assert.NoError(t, c.check("drive", "1.2.3", digest[:], good))
+	assert.Equal(t, ErrBadSignature, c.check("drive", "1.2.4", digest[:], good))
+	assert.Equal(t, ErrBadSignature, c.check("drive", "1.2.3", digest[:], bad))
+	assert.Equal(t, ErrBadSignature, c.check("drive", "1.2.3", digest[:], "not-base64"))
+	assert.Equal(t, ErrMissingSignature, c.check("drive", "1.2.3", digest[:], ""))
+	assert.Equal(t, ErrMissingSignature, c.unsigned())
+
+	c.policy = SignatureWarn
+	assert.NoError(t, c.check("drive", "1.2.3", digest[:], bad))
+	assert.NoError(t, c.unsigned())
+
+	var disabled *signatureChecker
+	assert.NoError(t, disabled.check("drive", "1.2.3", digest[:], ""))
+	assert.NoError(t, disabled.unsigned())
+}
+
+func TestParsePublicKey(t *testing.T) {
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	key, err := parsePublicKey(base64.StdEncoding.EncodeToString(pub))
+	assert.NoError(t, err)
+	assert.Equal(t, pub, key)
+
+	_, err = parsePublicKey(base64.StdEncoding.EncodeToString([]byte("short")))
+	assert.Error(t, err)
+	_, err = parsePublicKey("not base64!")
+	assert.Error(t, err)
+}
+
+func TestEndorsedKeys(t *testing.T) {
+	rootPub, rootPriv, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	_, otherPriv, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	rogue, _, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+
+	endorse := func(priv ed25519.PrivateKey, key ed25519.PublicKey) registry.SigningKey {
+		str := base64.StdEncoding.EncodeToString(key)
+		sig := ed25519.Sign(priv, registry.EndorsementMessage(str))
+		return registry.SigningKey{Key: str, Signature: base64.StdEncoding.EncodeToString(sig)}
+	}
+	published := []registry.SigningKey{
+		endorse(rootPriv, pub),
+		endorse(otherPriv, rogue),
+		{Key: base64.StdEncoding.EncodeToString(rogue)},
+	}
+
+	c := &signatureChecker{
+		policy:   SignatureEnforce,
+		rootKeys: []ed25519.PublicKey{rootPub},
+		log:      logger.WithNamespace("test"),
+	}
+	assert.Equal(t, []ed25519.PublicKey{pub}, c.endorsedKeys(published))
+
+	c.rootKeys = nil
+	assert.Empty(t, c.endorsedKeys(published))
+}
+
+func TestFetchHTTPVerifiesExistingBundle(t *testing.T) {
+	fs := appfs.NewAferoCopier(afero.NewMemMapFs())
+	man := &WebappManifest{}
+	man.SetSlug("drive")
+	man.SetVersion("1.2.3")
+	digest := sha256.Sum256([]byte("tarball"))
+	man.SetChecksum(hex.EncodeToString(digest[:]))
+	exists, err := fs.Start(man.Slug(), man.Version(), man.Checksum())
+	assert.NoError(t, err)
+	assert.False(t, exists)
+	assert.NoError(t, fs.Commit())
+
+	// The bundle is already here: it is not downloaded again, but the
+	// signature is still checked, with the checksum of the manifest
+	src, _ := url.Parse("http://unreachable.cozy.invalid/drive-1.2.3.tar.gz")
+	var verified []byte
+	verify := func(d []byte) error {
+		verified = d
+		return ErrBadSignature
+	}
+	err = fetchHTTP(src, nil, verify, fs, man, "")
+	assert.Equal(t, ErrBadSignature, err)
+	assert.Equal(t, digest[:], verified)
+}
@@ -47,6 +47,13 @@ type Service struct {
 	Debounce       string `json:"debounce"`
 	TriggerOptions string `json:"trigger"`
 	TriggerID      string `json:"trigger_id"`
+
+	// Routes are the HTTP routes served by the service, relative to
+	// /apps/:slug/routes. A route ending with /* matches all the sub-paths.
+	Routes []string `json:"routes,omitempty"`
+	// Public is true if the routes can be called without authentication
+	// (for webhooks for example).
+	Public bool `json:"public,omitempty"`
 }
 
 // Services is a map to define services assciated with an application.
@@ -393,7 +400,7 @@ func diffServices(db prefixer.Prefixer, slug string, oldServices, newServices Se
 			deleted = append(deleted, oldService)
 			created = append(created, newService)
 		} else {
-			*newService = *oldService
+			newService.TriggerID = oldService.TriggerID
 		}
 		newService.name = name
 	}
@@ -484,6 +491,38 @@ func (m *WebappManifest) FindRoute(vpath string) (Route, string) {
 	return best, rest
 }
 
+// FindServiceRoute returns the name and the service that serves the given
+// path, with the part of the path matched by a wildcard. The exact routes
+// win over the wildcards, and the longest wildcard wins.
+func (m *WebappManifest) FindServiceRoute(vpath string) (string, *Service, string) {
+	vpath = path.Join("/", vpath)
+	var name, rest string
+	var best *Service
+	specificity := -1
+	for n, service := range m.val.Services {
+		for _, route := range service.Routes {
+			route = path.Join("/", route)
+			if route == vpath {
+				return n, service, ""
+			}
+			if path.Base(route) != "*" {
+				continue
+			}
+			prefix := path.Dir(route)
+			if prefix != "/" && vpath != prefix && !strings.HasPrefix(vpath, prefix+"/") {
+				continue
+			}
+			if len(prefix) > specificity {
+				specificity = len(prefix)
+				name = n
+				best = service
+				rest = strings.TrimPrefix(strings.TrimPrefix(vpath, prefix), "/")
+			}
+		}
+	}
+	return name, best, rest
+}
+
 // FindIntent returns an intent for the given action and type if the manifest has one
 func (m *WebappManifest) FindIntent(action, typ string) *Intent {
 	for _, intent := range m.val.Intents {
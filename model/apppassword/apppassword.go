@@ -0,0 +1,218 @@
+// Package apppassword is for the app passwords: they are passwords generated
+// by the stack that can be used with HTTP Basic auth by the tools that cannot
+// do OAuth (WebDAV clients, scripts, etc.). They can be scoped to a set of
+// permissions and can expire.
+package apppassword
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cozy/cozy-stack/model/permission"
+	"github.com/cozy/cozy-stack/pkg/consts"
+	"github.com/cozy/cozy-stack/pkg/couchdb"
+	"github.com/cozy/cozy-stack/pkg/crypto"
+	"github.com/cozy/cozy-stack/pkg/prefixer"
+	"github.com/labstack/echo/v4"
+)
+
+// secretLen is the number of characters of the generated passwords
+const secretLen = 32
+
+// defaultScope is the scope used for the app passwords created without a
+// scope
+const defaultScope = consts.Files
+
+// lastUsedPrecision is the minimal delay between two updates of the last_used
+// field, to avoid writing in CouchDB on each request
+const lastUsedPrecision = time.Hour
+
+var (
+	// ErrInvalidName is used when an app password has no name
+	ErrInvalidName = echo.NewHTTPError(http.StatusBadRequest, "The name is mandatory")
+	// ErrInvalidCredentials is used when the login or the password is wrong,
+	// or when the app password has expired
+	ErrInvalidCredentials = errors.New("Invalid app password")
+)
+
+// AppPassword is a password that can be used with HTTP Basic auth. The login
+// is the identifier of the document, and only a hash of the password is
+// persisted.
+type AppPassword struct {
+	DocID      string     `json:"_id,omitempty"`
+	DocRev     string     `json:"_rev,omitempty"`
+	Name       string     `json:"name"`
+	Hash       []byte     `json:"hash"`
+	Scope      string     `json:"scope,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+
+	// Password is only filled when the app password is created, and is never
+	// persisted (only its hash is)
+	Password string `json:"-"`
+}
+
+// ID implements jsonapi.Doc
+func (a *AppPassword) ID() string { return a.DocID }
+
+// Rev implements jsonapi.Doc
+func (a *AppPassword) Rev() string { return a.DocRev }
+
+// DocType implements jsonapi.Doc
+func (a *AppPassword) DocType() string { return consts.AppPasswords }
+
+// SetID implements jsonapi.Doc
+func (a *AppPassword) SetID(id string) { a.DocID = id }
+
+// SetRev implements jsonapi.Doc
+func (a *AppPassword) SetRev(rev string) { a.DocRev = rev }
+
+// Clone implements couchdb.Doc
+func (a *AppPassword) Clone() couchdb.Doc {
+	cloned := *a
+	cloned.Hash = make([]byte, len(a.Hash))
+	copy(cloned.Hash, a.Hash)
+	if a.ExpiresAt != nil {
+		at := *a.ExpiresAt
+		cloned.ExpiresAt = &at
+	}
+	if a.LastUsedAt != nil {
+		at := *a.LastUsedAt
+		cloned.LastUsedAt = &at
+	}
+	return &cloned
+}
+
+// Expired returns true if the app password can no longer be used.
+func (a *AppPassword) Expired() bool {
+	return a.ExpiresAt != nil && a.ExpiresAt.Before(time.Now())
+}
+
+// Permissions returns the set of permissions given by this app password. If
+// the app password is not scoped, it gives access to the files, as it is what
+// the WebDAV clients need.
+func (a *AppPassword) Permissions() (permission.Set, error) {
+	scope := a.Scope
+	if scope == "" {
+		scope = defaultScope
+	}
+	return permission.UnmarshalScopeString(scope)
+}
+
+// PermissionDoc returns a permission document (not persisted) that can be
+// used for the requests authenticated with this app password.
+func (a *AppPassword) PermissionDoc() (*permission.Permission, error) {
+	set, err := a.Permissions()
+	if err != nil {
+		return nil, err
+	}
+	return &permission.Permission{
+		Type:        permission.TypeAppPassword,
+		SourceID:    consts.AppPasswords + "/" + a.DocID,
+		Permissions: set,
+		ExpiresAt:   a.ExpiresAt,
+	}, nil
+}
+
+// Create generates a new password and persists the app password. The
+// generated password is available in the Password field.
+func Create(db prefixer.Prefixer, name string, set permission.Set, expiresAt *time.Time) (*AppPassword, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, ErrInvalidName
+	}
+	a := &AppPassword{
+		Name:      name,
+		CreatedAt: time.Now().UTC(),
+		ExpiresAt: expiresAt,
+	}
+	if len(set) > 0 {
+		scope, err := set.MarshalScopeString()
+		if err != nil {
+			return nil, err
+		}
+		a.Scope = scope
+	}
+	a.Password = crypto.GenerateRandomString(secretLen)
+	a.Hash = hashPassword(a.Password)
+	if err := couchdb.CreateDoc(db, a); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// Get returns the app password with the given identifier.
+func Get(db prefixer.Prefixer, id string) (*AppPassword, error) {
+	a := &AppPassword{}
+	if err := couchdb.GetDoc(db, consts.AppPasswords, id, a); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// GetAll returns the app passwords of the instance.
+func GetAll(db prefixer.Prefixer, limit int, bookmark string) ([]*AppPassword, string, error) {
+	res, err := couchdb.NormalDocs(db, consts.AppPasswords, 0, limit, bookmark, false)
+	if err != nil {
+		if couchdb.IsNoDatabaseError(err) {
+			return []*AppPassword{}, "", nil
+		}
+		return nil, "", err
+	}
+	passwords := make([]*AppPassword, len(res.Rows))
+	for i, row := range res.Rows {
+		var a AppPassword
+		if err := json.Unmarshal(row, &a); err != nil {
+			return nil, "", err
+		}
+		passwords[i] = &a
+	}
+	return passwords, res.Bookmark, nil
+}
+
+// Delete revokes the app password.
+func (a *AppPassword) Delete(db prefixer.Prefixer) error {
+	return couchdb.DeleteDoc(db, a)
+}
+
+// Check returns the app password for the given login if the password is
+// valid. It also updates the last_used_at field.
+func Check(db prefixer.Prefixer, login, password string) (*AppPassword, error) {
+	if login == "" || password == "" {
+		return nil, ErrInvalidCredentials
+	}
+	a, err := Get(db, login)
+	if err != nil {
+		if couchdb.IsNotFoundError(err) || couchdb.IsNoDatabaseError(err) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare(a.Hash, hashPassword(password)) != 1 || a.Expired() {
+		return nil, ErrInvalidCredentials
+	}
+	now := time.Now().UTC()
+	if a.LastUsedAt == nil || now.Sub(*a.LastUsedAt) > lastUsedPrecision {
+		a.LastUsedAt = &now
+		// A conflict is not an issue, another request has already updated it
+		_ = couchdb.UpdateDoc(db, a)
+	}
+	return a, nil
+}
+
+// hashPassword returns the hash of a generated password. The passwords are
+// random strings with enough entropy, so a simple hash is enough (and it
+// avoids the cost of scrypt on each request, as WebDAV clients can send a
+// lot of requests).
+func hashPassword(password string) []byte {
+	sum := sha256.Sum256([]byte(password))
+	return sum[:]
+}
+
+var _ couchdb.Doc = &AppPassword{}
@@ -0,0 +1,44 @@
+package apppassword
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cozy/cozy-stack/model/permission"
+	"github.com/cozy/cozy-stack/pkg/consts"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPermissionDoc(t *testing.T) {
+	a := &AppPassword{DocID: "ap1"}
+	pdoc, err := a.PermissionDoc()
+	require.NoError(t, err)
+	assert.Equal(t, permission.TypeAppPassword, pdoc.Type)
+	assert.Equal(t, consts.AppPasswords+"/ap1", pdoc.SourceID)
+	assert.True(t, pdoc.Permissions.AllowWholeType(permission.PUT, consts.Files))
+	assert.False(t, pdoc.Permissions.AllowWholeType(permission.GET, consts.Contacts))
+
+	a.Scope = "io.cozy.contacts:GET"
+	pdoc, err = a.PermissionDoc()
+	require.NoError(t, err)
+	assert.True(t, pdoc.Permissions.AllowWholeType(permission.GET, consts.Contacts))
+	assert.False(t, pdoc.Permissions.AllowWholeType(permission.PUT, consts.Contacts))
+	assert.False(t, pdoc.Permissions.AllowWholeType(permission.GET, consts.Files))
+}
+
+func TestExpired(t *testing.T) {
+	a := &AppPassword{}
+	assert.False(t, a.Expired())
+	past := time.Now().Add(-time.Minute)
+	a.ExpiresAt = &past
+	assert.True(t, a.Expired())
+	future := time.Now().Add(time.Hour)
+	a.ExpiresAt = &future
+	assert.False(t, a.Expired())
+}
+
+func TestHashPassword(t *testing.T) {
+	assert.Equal(t, hashPassword("foo"), hashPassword("foo"))
+	assert.NotEqual(t, hashPassword("foo"), hashPassword("bar"))
+}
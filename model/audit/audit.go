@@ -0,0 +1,195 @@
+// Package audit is for the audit log of the security-sensitive actions on an
+// instance, like permission grants, OAuth clients registrations, passphrase
+// changes or app installations.
+package audit
+
+import (
+	"time"
+
+	"github.com/cozy/cozy-stack/pkg/config/config"
+	"github.com/cozy/cozy-stack/pkg/consts"
+	"github.com/cozy/cozy-stack/pkg/couchdb"
+	"github.com/cozy/cozy-stack/pkg/couchdb/mango"
+	"github.com/cozy/cozy-stack/pkg/logger"
+	"github.com/cozy/cozy-stack/pkg/prefixer"
+	"github.com/justincampbell/bigduration"
+)
+
+// The list of the actions that are recorded in the audit log
+const (
+	// ActionPermissionGranted is used when some permissions are given to an
+	// app or a konnector, or added to an existing set of permissions.
+	ActionPermissionGranted = "permission.granted"
+	// ActionShareLinkCreated is used when a share by link or a file drop link
+	// is created.
+	ActionShareLinkCreated = "share-link.created"
+	// ActionOAuthClientRegistered is used when a new OAuth client is
+	// registered.
+	ActionOAuthClientRegistered = "oauth-client.registered"
+	// ActionAppPasswordCreated is used when a new app password is created.
+	ActionAppPasswordCreated = "app-password.created"
+	// ActionPassphraseChanged is used when the passphrase is changed or reset.
+	ActionPassphraseChanged = "passphrase.changed"
+	// ActionSharingAccepted is used when the owner of the instance accepts a
+	// sharing from another Cozy.
+	ActionSharingAccepted = "sharing.accepted"
+	// ActionAppInstalled is used when a webapp or a konnector is installed.
+	ActionAppInstalled = "app.installed"
+)
+
+// Entry is an entry in the audit log. The entries are never modified: the
+// doctype is read-only for the applications.
+type Entry struct {
+	DocID  string `json:"_id,omitempty"`
+	DocRev string `json:"_rev,omitempty"`
+	Action string `json:"action"`
+	// Actor is who has made the action, like owner, io.cozy.apps/drive, or
+	// io.cozy.oauth.clients/<id>
+	Actor string `json:"actor,omitempty"`
+	IP    string `json:"ip,omitempty"`
+	// Target is the document concerned by the action, like
+	// io.cozy.permissions/<id>
+	Target    string                 `json:"target,omitempty"`
+	Details   map[string]interface{} `json:"details,omitempty"`
+	CreatedAt time.Time              `json:"created_at"`
+}
+
+// ID implements jsonapi.Doc
+func (e *Entry) ID() string { return e.DocID }
+
+// Rev implements jsonapi.Doc
+func (e *Entry) Rev() string { return e.DocRev }
+
+// DocType implements jsonapi.Doc
+func (e *Entry) DocType() string { return consts.AuditLogs }
+
+// SetID implements jsonapi.Doc
+func (e *Entry) SetID(id string) { e.DocID = id }
+
+// SetRev implements jsonapi.Doc
+func (e *Entry) SetRev(rev string) { e.DocRev = rev }
+
+// Clone implements couchdb.Doc
+func (e *Entry) Clone() couchdb.Doc {
+	cloned := *e
+	if e.Details != nil {
+		cloned.Details = make(map[string]interface{}, len(e.Details))
+		for k, v := range e.Details {
+			cloned.Details[k] = v
+		}
+	}
+	return &cloned
+}
+
+// Record adds an entry to the audit log. An error is only logged, as it
+// should not prevent the action to happen.
+func Record(db prefixer.Prefixer, entry *Entry) {
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now().UTC()
+	}
+	if err := couchdb.CreateDoc(db, entry); err != nil {
+		logger.WithDomain(db.DomainName()).WithNamespace("audit").
+			Errorf("Cannot record %s: %s", entry.Action, err)
+	}
+}
+
+// Filter is used to select the entries of the audit log.
+type Filter struct {
+	Action string
+	Actor  string
+	Since  *time.Time
+	Until  *time.Time
+}
+
+// Find returns the entries of the audit log that match the filter, from the
+// most recent to the oldest. The bookmark can be used to fetch the next page.
+func Find(db prefixer.Prefixer, filter Filter, limit int, bookmark string) ([]*Entry, string, error) {
+	var conds []mango.Filter
+	req := &couchdb.FindRequest{
+		Limit:    limit,
+		Bookmark: bookmark,
+	}
+	if filter.Action != "" {
+		conds = append(conds, mango.Equal("action", filter.Action))
+		req.UseIndex = "by-action-created-at"
+		req.Sort = mango.SortBy{
+			{Field: "action", Direction: mango.Desc},
+			{Field: "created_at", Direction: mango.Desc},
+		}
+	} else {
+		req.UseIndex = "by-created-at"
+		req.Sort = mango.SortBy{{Field: "created_at", Direction: mango.Desc}}
+	}
+	if filter.Since != nil {
+		conds = append(conds, mango.Gte("created_at", filter.Since.UTC()))
+	} else {
+		conds = append(conds, mango.Exists("created_at"))
+	}
+	if filter.Until != nil {
+		conds = append(conds, mango.Lt("created_at", filter.Until.UTC()))
+	}
+	if filter.Actor != "" {
+		conds = append(conds, mango.Equal("actor", filter.Actor))
+	}
+	if len(conds) == 1 {
+		req.Selector = conds[0]
+	} else {
+		req.Selector = mango.And(conds...)
+	}
+
+	var entries []*Entry
+	res, err := couchdb.FindDocsRaw(db, consts.AuditLogs, req, &entries)
+	if err != nil {
+		if couchdb.IsNoDatabaseError(err) {
+			return []*Entry{}, "", nil
+		}
+		return nil, "", err
+	}
+	return entries, res.Bookmark, nil
+}
+
+// Retention returns for how long the entries of the audit log must be kept
+// for the given context. It returns false if they are kept forever.
+func Retention(contextName string) (time.Duration, bool) {
+	cfg := config.GetConfig().AuditRetention
+	after, ok := cfg[contextName]
+	if !ok {
+		after, ok = cfg[config.DefaultInstanceContext]
+	}
+	if !ok || after == "" {
+		return 0, false
+	}
+	d, err := bigduration.ParseDuration(after)
+	if err != nil || d <= 0 {
+		return 0, false
+	}
+	return d, true
+}
+
+// Purge deletes the entries of the audit log created before the given date.
+// It deletes at most limit entries, and returns the number of deleted entries.
+func Purge(db prefixer.Prefixer, before time.Time, limit int) (int, error) {
+	req := &couchdb.FindRequest{
+		UseIndex: "by-created-at",
+		Selector: mango.Lt("created_at", before.UTC()),
+		Limit:    limit,
+	}
+	var entries []*Entry
+	if _, err := couchdb.FindDocsRaw(db, consts.AuditLogs, req, &entries); err != nil {
+		if couchdb.IsNoDatabaseError(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	if len(entries) == 0 {
+		return 0, nil
+	}
+	docs := make([]couchdb.Doc, len(entries))
+	for i, e := range entries {
+		docs[i] = e
+	}
+	if err := couchdb.BulkDeleteDocs(db, consts.AuditLogs, docs); err != nil {
+		return 0, err
+	}
+	return len(docs), nil
+}
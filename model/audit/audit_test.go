@@ -0,0 +1,141 @@
+package audit
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/cozy/cozy-stack/pkg/config/config"
+	"github.com/cozy/cozy-stack/pkg/consts"
+	"github.com/cozy/cozy-stack/pkg/couchdb"
+	"github.com/cozy/cozy-stack/pkg/prefixer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testDB = prefixer.NewPrefixer("audit.cozy.local", "audit-test")
+
+func TestRetention(t *testing.T) {
+	backup := config.GetConfig().AuditRetention
+	defer func() { config.GetConfig().AuditRetention = backup }()
+
+	config.GetConfig().AuditRetention = nil
+	_, ok := Retention("foo")
+	assert.False(t, ok)
+
+	config.GetConfig().AuditRetention = map[string]string{
+		"default": "1Y",
+		"short":   "3D",
+		"invalid": "xyz",
+	}
+	d, ok := Retention("short")
+	assert.True(t, ok)
+	assert.Equal(t, 3*24*time.Hour, d)
+	d, ok = Retention("foo")
+	assert.True(t, ok)
+	assert.True(t, d > 300*24*time.Hour)
+	_, ok = Retention("invalid")
+	assert.False(t, ok)
+}
+
+func TestFind(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	entries := []*Entry{
+		{Action: ActionAppInstalled, Actor: "owner", CreatedAt: now.Add(-72 * time.Hour)},
+		{Action: ActionPassphraseChanged, Actor: "owner", CreatedAt: now.Add(-48 * time.Hour)},
+		{Action: ActionAppInstalled, Actor: "io.cozy.apps/store", CreatedAt: now.Add(-24 * time.Hour)},
+		{Action: ActionOAuthClientRegistered, Actor: "owner", CreatedAt: now.Add(-1 * time.Hour)},
+	}
+	for _, e := range entries {
+		Record(testDB, e)
+		require.NotEmpty(t, e.ID())
+	}
+
+	// From the most recent to the oldest
+	found, _, err := Find(testDB, Filter{}, 10, "")
+	require.NoError(t, err)
+	require.Len(t, found, 4)
+	assert.Equal(t, entries[3].ID(), found[0].ID())
+	assert.Equal(t, entries[0].ID(), found[3].ID())
+
+	found, _, err = Find(testDB, Filter{Action: ActionAppInstalled}, 10, "")
+	require.NoError(t, err)
+	require.Len(t, found, 2)
+	assert.Equal(t, entries[2].ID(), found[0].ID())
+	assert.Equal(t, entries[0].ID(), found[1].ID())
+
+	found, _, err = Find(testDB, Filter{Action: ActionAppInstalled, Actor: "owner"}, 10, "")
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, entries[0].ID(), found[0].ID())
+
+	since := now.Add(-50 * time.Hour)
+	until := now.Add(-2 * time.Hour)
+	found, _, err = Find(testDB, Filter{Since: &since, Until: &until}, 10, "")
+	require.NoError(t, err)
+	require.Len(t, found, 2)
+	assert.Equal(t, entries[2].ID(), found[0].ID())
+	assert.Equal(t, entries[1].ID(), found[1].ID())
+
+	// Pagination with a bookmark
+	found, bookmark, err := Find(testDB, Filter{}, 3, "")
+	require.NoError(t, err)
+	require.Len(t, found, 3)
+	assert.NotEmpty(t, bookmark)
+	found, _, err = Find(testDB, Filter{}, 3, bookmark)
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, entries[0].ID(), found[0].ID())
+
+	// Purge deletes the old entries, by batches
+	n, err := Purge(testDB, now.Add(-36*time.Hour), 1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	n, err = Purge(testDB, now.Add(-36*time.Hour), 10)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	n, err = Purge(testDB, now.Add(-36*time.Hour), 10)
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+	found, _, err = Find(testDB, Filter{}, 10, "")
+	require.NoError(t, err)
+	require.Len(t, found, 2)
+	assert.Equal(t, entries[3].ID(), found[0].ID())
+	assert.Equal(t, entries[2].ID(), found[1].ID())
+}
+
+func TestFindNoDatabase(t *testing.T) {
+	db := prefixer.NewPrefixer("no-audit.cozy.local", "no-audit-test")
+	found, bookmark, err := Find(db, Filter{}, 10, "")
+	assert.NoError(t, err)
+	assert.Empty(t, found)
+	assert.Empty(t, bookmark)
+	n, err := Purge(db, time.Now(), 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, n)
+}
+
+func TestMain(m *testing.M) {
+	config.UseTestFile()
+
+	if _, err := couchdb.CheckStatus(); err != nil {
+		fmt.Println("This test need couchdb to run.")
+		os.Exit(1)
+	}
+
+	if err := couchdb.ResetDB(testDB, consts.AuditLogs); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	for _, index := range couchdb.IndexesByDoctype(consts.AuditLogs) {
+		if err := couchdb.DefineIndex(testDB, index); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+
+	res := m.Run()
+	_ = couchdb.DeleteDB(testDB, consts.AuditLogs)
+	os.Exit(res)
+}
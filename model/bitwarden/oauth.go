@@ -3,6 +3,7 @@ package bitwarden
 import (
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/cozy/cozy-stack/model/bitwarden/settings"
 	"github.com/cozy/cozy-stack/model/instance"
@@ -92,7 +93,8 @@ func ParseBitwardenDeviceType(deviceType string) (string, string) {
 // apps. It is an access token, with some additional custom fields.
 // See https://github.com/bitwarden/jslib/blob/master/common/src/services/token.service.ts
 func CreateAccessJWT(i *instance.Instance, c *oauth.Client) (string, error) {
-	now := crypto.Timestamp()
+	issuedAt := time.Now()
+	now := issuedAt.Unix()
 	name, err := i.SettingsPublicName()
 	if err != nil || name == "" {
 		name = "Anonymous"
@@ -111,8 +113,9 @@ func CreateAccessJWT(i *instance.Instance, c *oauth.Client) (string, error) {
 				ExpiresAt: now + int64(consts.AccessTokenValidityDuration.Seconds()),
 				Subject:   i.ID(),
 			},
-			SStamp: stamp,
-			Scope:  BitwardenScope,
+			IssuedAtMs: issuedAt.UnixNano() / int64(time.Millisecond),
+			SStamp:     stamp,
+			Scope:      BitwardenScope,
 		},
 		ClientID: c.CouchID,
 		Name:     name,
@@ -134,15 +137,17 @@ func CreateRefreshJWT(i *instance.Instance, c *oauth.Client) (string, error) {
 	if settings, err := settings.Get(i); err == nil {
 		stamp = settings.SecurityStamp
 	}
+	issuedAt := time.Now()
 	token, err := crypto.NewJWT(i.OAuthSecret, permission.Claims{
 		StandardClaims: crypto.StandardClaims{
 			Audience: consts.RefreshTokenAudience,
 			Issuer:   i.Domain,
-			IssuedAt: crypto.Timestamp(),
+			IssuedAt: issuedAt.Unix(),
 			Subject:  c.CouchID,
 		},
-		SStamp: stamp,
-		Scope:  BitwardenScope,
+		IssuedAtMs: issuedAt.UnixNano() / int64(time.Millisecond),
+		SStamp:     stamp,
+		Scope:      BitwardenScope,
 	})
 	if err != nil {
 		i.Logger().WithNamespace("oauth").
@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/cozy/cozy-stack/pkg/config/config"
 	"github.com/cozy/cozy-stack/pkg/crypto"
 	"github.com/mssola/user_agent"
 	"github.com/pquerna/otp"
@@ -50,6 +51,11 @@ const (
 	Basic AuthMode = iota
 	// TwoFactorMail authentication mode, with passcode sent via email
 	TwoFactorMail
+	// TwoFactorTOTP authentication mode, with passcode generated by an
+	// authenticator application (RFC 6238)
+	TwoFactorTOTP
+	// TwoFactorWebAuthn authentication mode, with a security key (FIDO2)
+	TwoFactorWebAuthn
 )
 
 // AuthModeToString encode authentication mode in a string
@@ -57,6 +63,10 @@ func AuthModeToString(authMode AuthMode) string {
 	switch authMode {
 	case TwoFactorMail:
 		return "two_factor_mail"
+	case TwoFactorTOTP:
+		return "two_factor_totp"
+	case TwoFactorWebAuthn:
+		return "two_factor_webauthn"
 	default:
 		return "basic"
 	}
@@ -68,6 +78,10 @@ func StringToAuthMode(authMode string) (AuthMode, error) {
 	switch authMode {
 	case "two_factor_mail":
 		return TwoFactorMail, nil
+	case "two_factor_totp":
+		return TwoFactorTOTP, nil
+	case "two_factor_webauthn":
+		return TwoFactorWebAuthn, nil
 	case "basic":
 		return Basic, nil
 	default:
@@ -81,6 +95,12 @@ func (i *Instance) HasAuthMode(authMode AuthMode) bool {
 	return i.AuthMode == authMode
 }
 
+// HasTwoFactor returns true if a second factor is required to log in, whatever
+// it is (mail, TOTP, or a security key).
+func (i *Instance) HasTwoFactor() bool {
+	return i.AuthMode != Basic
+}
+
 // GenerateTwoFactorSecrets generates a (token, passcode) pair that can be
 // used as a two factor authentication secret value. The token is used to allow
 // the two-factor form — meaning the user has correctly entered its passphrase
@@ -88,12 +108,7 @@ func (i *Instance) HasAuthMode(authMode AuthMode) bool {
 //
 // The passcode should be send to the user by another mean (mail, SMS, ...)
 func (i *Instance) GenerateTwoFactorSecrets() (token []byte, passcode string, err error) {
-	// A salt is used when we generate a new 2FA secret to derive a new TOTP
-	// function from. This allow us to have TOTP derived from a new key each time
-	// we check the first step of the 2FA ("the passphrase step"). This salt is
-	// given to the user and signed in the "two-factor-token" MAC.
-	salt := crypto.GenerateRandomBytes(sha256.Size)
-	token, err = crypto.EncodeAuthMessage(totpMACConfig, i.SessionSecret(), salt, nil)
+	token, salt, err := i.newTwoFactorToken()
 	if err != nil {
 		return
 	}
@@ -109,8 +124,27 @@ func (i *Instance) GenerateTwoFactorSecrets() (token []byte, passcode string, er
 	return
 }
 
+// GenerateTwoFactorToken generates a token for the second step of the two
+// factor authentication, when the passcode is not sent by the stack but comes
+// from an authenticator application or a security key.
+func (i *Instance) GenerateTwoFactorToken() ([]byte, error) {
+	token, _, err := i.newTwoFactorToken()
+	return token, err
+}
+
+func (i *Instance) newTwoFactorToken() (token, salt []byte, err error) {
+	// A salt is used when we generate a new 2FA secret to derive a new TOTP
+	// function from. This allow us to have TOTP derived from a new key each time
+	// we check the first step of the 2FA ("the passphrase step"). This salt is
+	// given to the user and signed in the "two-factor-token" MAC. It is also
+	// used as the challenge for the security keys.
+	salt = crypto.GenerateRandomBytes(sha256.Size)
+	token, err = crypto.EncodeAuthMessage(totpMACConfig, i.SessionSecret(), salt, nil)
+	return
+}
+
 // ValidateTwoFactorPasscode validates the given (token, passcode) pair for two
-// factor authentication.
+// factor authentication, when the passcode has been sent by mail.
 func (i *Instance) ValidateTwoFactorPasscode(token []byte, passcode string) bool {
 	salt, err := crypto.DecodeAuthMessage(totpMACConfig, i.SessionSecret(), token, nil)
 	if err != nil {
@@ -128,6 +162,44 @@ func (i *Instance) ValidateTwoFactorPasscode(token []byte, passcode string) bool
 	return ok && err == nil
 }
 
+// ValidateTwoFactor validates the second factor for the authentication mode
+// of the instance. The passcode can be a code sent by mail, a code from an
+// authenticator application, a signed assertion from a security key, or one
+// of the recovery codes. A recovery code can be used only once, and the
+// instance can be updated after a successful validation.
+func (i *Instance) ValidateTwoFactor(token []byte, passcode string) bool {
+	return i.validateTwoFactor(token, passcode, nil)
+}
+
+// ValidateBitwardenTwoFactor is like ValidateTwoFactor, but the signed
+// assertion of a security key can also come from the bitwarden clients, with
+// the origins allowed in the configuration.
+func (i *Instance) ValidateBitwardenTwoFactor(token []byte, passcode string) bool {
+	return i.validateTwoFactor(token, passcode, config.GetConfig().BitwardenWebAuthnOrigins)
+}
+
+func (i *Instance) validateTwoFactor(token []byte, passcode string, origins []string) bool {
+	switch i.AuthMode {
+	case TwoFactorMail:
+		return i.ValidateTwoFactorPasscode(token, passcode)
+	case TwoFactorTOTP, TwoFactorWebAuthn:
+	default:
+		return false
+	}
+
+	challenge, err := crypto.DecodeAuthMessage(totpMACConfig, i.SessionSecret(), token, nil)
+	if err != nil {
+		return false
+	}
+	if i.AuthMode == TwoFactorTOTP && i.validateTOTP(passcode) {
+		return true
+	}
+	if i.AuthMode == TwoFactorWebAuthn && i.validateWebAuthnAssertion(challenge, passcode, origins) {
+		return true
+	}
+	return i.useRecoveryCode(passcode)
+}
+
 // GenerateTwoFactorTrustedDeviceSecret generates a token that can be kept by the
 // user on-demand to avoid having two-factor authentication on a specific
 // machine.
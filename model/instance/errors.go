@@ -25,6 +25,15 @@ var (
 	// ErrUnknownAuthMode is returned when an unknown authentication mode is
 	// used.
 	ErrUnknownAuthMode = errors.New("Unknown authentication mode")
+	// ErrSecondFactorNotEnrolled is returned when trying to activate an
+	// authentication mode without having enrolled the second factor before.
+	ErrSecondFactorNotEnrolled = errors.New("The second factor has not been enrolled")
+	// ErrInvalidWebAuthnCredential is returned when the response of a
+	// security key cannot be verified.
+	ErrInvalidWebAuthnCredential = errors.New("Invalid security key credential")
+	// ErrWebAuthnCredentialNotFound is returned when a security key is not
+	// registered for the instance.
+	ErrWebAuthnCredentialNotFound = errors.New("Security key not found")
 	// ErrBadTOSVersion is returned when a malformed TOS version is provided.
 	ErrBadTOSVersion = errors.New("Bad format for TOS version")
 	// ErrInvalidSwiftLayout is returned when the Swift layout is unknown.
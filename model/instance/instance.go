@@ -59,6 +59,9 @@ type Instance struct {
 	BytesDiskQuota     int64 `json:"disk_quota,string,omitempty"`   // The total size in bytes allowed to the user
 	IndexViewsVersion  int   `json:"indexes_version,omitempty"`
 
+	// AppsQuotas are the storage quotas of some applications, by slug
+	AppsQuotas map[string]*AppQuota `json:"apps_quotas,omitempty"`
+
 	// Swift layout number:
 	// - 0 for layout v1
 	// - 1 for layout v2
@@ -88,6 +91,24 @@ type Instance struct {
 	OAuthSecret []byte `json:"oauth_secret,omitempty"`
 	// CLISecret is used to authenticate request from the CLI
 	CLISecret []byte `json:"cli_secret,omitempty"`
+	// OIDCKey is the RSA private key (PKCS#1, DER encoded) used to sign the
+	// ID tokens when the instance acts as an OpenID Connect provider
+	OIDCKey []byte `json:"oidc_key,omitempty"`
+	// TOTPSecret is the secret shared with the authenticator application of
+	// the user, for the two factor authentication with TOTP. It is only kept
+	// in clear when the stack has no vault key, else TOTPSecretEncrypted is
+	// used.
+	TOTPSecret          string `json:"totp_secret,omitempty"`
+	TOTPSecretEncrypted string `json:"totp_secret_encrypted,omitempty"`
+	// TOTPLastCounter is the time step of the last TOTP passcode that has been
+	// accepted, to avoid a passcode to be used twice
+	TOTPLastCounter int64 `json:"totp_last_counter,omitempty"`
+	// WebAuthnCredentials are the security keys registered by the user for
+	// the two factor authentication
+	WebAuthnCredentials []*WebAuthnCredential `json:"webauthn_credentials,omitempty"`
+	// RecoveryCodes are the hashes of the codes that can be used once if the
+	// user has lost their second factor
+	RecoveryCodes []string `json:"recovery_codes,omitempty"`
 
 	// FeatureFlags is the feature flags that are specific to this instance
 	FeatureFlags map[string]interface{} `json:"feature_flags,omitempty"`
@@ -142,6 +163,18 @@ func (i *Instance) Clone() couchdb.Doc {
 
 	cloned.CLISecret = make([]byte, len(i.CLISecret))
 	copy(cloned.CLISecret, i.CLISecret)
+
+	cloned.OIDCKey = make([]byte, len(i.OIDCKey))
+	copy(cloned.OIDCKey, i.OIDCKey)
+
+	cloned.WebAuthnCredentials = make([]*WebAuthnCredential, len(i.WebAuthnCredentials))
+	for j, cred := range i.WebAuthnCredentials {
+		tmp := *cred
+		cloned.WebAuthnCredentials[j] = &tmp
+	}
+
+	cloned.RecoveryCodes = make([]string, len(i.RecoveryCodes))
+	copy(cloned.RecoveryCodes, i.RecoveryCodes)
 	return &cloned
 }
 
@@ -406,6 +439,37 @@ func (i *Instance) DiskQuota() int64 {
 	return i.BytesDiskQuota
 }
 
+// AppQuota is the storage quota of an application (webapp or konnector). The
+// usage of an application is computed from the cozyMetadata.createdByApp
+// field of the files and documents.
+type AppQuota struct {
+	// Disk is the total size in bytes of the files that the application can
+	// create. If zero, there is no limit (except the disk quota of the
+	// instance).
+	Disk int64 `json:"disk,string,omitempty"`
+	// Docs is the number of documents that the application can create in
+	// each doctype. If zero, there is no limit.
+	Docs int64 `json:"docs,omitempty"`
+}
+
+// AppDiskQuota returns the number of bytes allowed on the disk to the files
+// created by the given application.
+func (i *Instance) AppDiskQuota(slug string) int64 {
+	if q, ok := i.AppsQuotas[slug]; ok {
+		return q.Disk
+	}
+	return 0
+}
+
+// AppDocsQuota returns the number of documents that the given application
+// can create in a doctype.
+func (i *Instance) AppDocsQuota(slug string) int64 {
+	if q, ok := i.AppsQuotas[slug]; ok {
+		return q.Docs
+	}
+	return 0
+}
+
 // WithContextualDomain the current instance context with the given hostname.
 func (i *Instance) WithContextualDomain(domain string) *Instance {
 	if i.HasDomain(domain) {
@@ -624,8 +688,9 @@ func (i *Instance) MakeJWT(audience, subject, scope, sessionID string, issuedAt
 			IssuedAt: issuedAt.Unix(),
 			Subject:  subject,
 		},
-		Scope:     scope,
-		SessionID: sessionID,
+		IssuedAtMs: issuedAt.UnixNano() / int64(time.Millisecond),
+		Scope:      scope,
+		SessionID:  sessionID,
 	})
 }
 
@@ -0,0 +1,39 @@
+package lifecycle
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/cozy/cozy-stack/model/audit"
+	"github.com/cozy/cozy-stack/model/instance"
+	"github.com/cozy/cozy-stack/model/job"
+)
+
+// EnsureAuditCleanTrigger creates the trigger for cleaning the old entries of
+// the audit log, if a retention is configured for the context of the
+// instance and the trigger does not exist. It is called when the instance is
+// created, and by the audit-clean-trigger migration for the existing
+// instances.
+func EnsureAuditCleanTrigger(inst *instance.Instance) error {
+	if _, ok := audit.Retention(inst.ContextName); !ok {
+		return nil
+	}
+
+	sched := job.System()
+	infos := job.TriggerInfos{
+		Type:       "@cron",
+		WorkerType: "clean-audit-logs",
+	}
+	if sched.HasTrigger(inst, infos) {
+		return nil
+	}
+
+	now := time.Now()
+	hours := (now.Hour() + 12) % 24
+	infos.Arguments = fmt.Sprintf("0 %d %d * * *", now.Minute(), hours)
+	trigger, err := job.NewTrigger(inst, infos, nil)
+	if err != nil {
+		return err
+	}
+	return sched.AddTrigger(trigger)
+}
@@ -44,6 +44,8 @@ type Options struct {
 	KdfIterations      int
 	SwiftLayout        int
 	DiskQuota          int64
+	AppQuotaSlug       string
+	AppQuota           *instance.AppQuota
 	Apps               []string
 	AutoUpdate         *bool
 	Debug              *bool
@@ -138,7 +140,11 @@ func CreateWithoutHooks(opts *Options) (*instance.Instance, error) {
 		i.SessSecret = crypto.GenerateRandomBytes(instance.SessionSecretLen)
 		i.OAuthSecret = crypto.GenerateRandomBytes(instance.OauthSecretLen)
 		i.CLISecret = crypto.GenerateRandomBytes(instance.OauthSecretLen)
+		i.OIDCKey, err = instance.GenerateOIDCKey()
 	})
+	if err != nil {
+		return nil, err
+	}
 
 	switch config.FsURL().Scheme {
 	case config.SchemeSwift, config.SchemeSwiftSecure:
@@ -352,7 +358,7 @@ func addTriggers(i *instance.Instance) error {
 			return err
 		}
 	}
-	return nil
+	return EnsureAuditCleanTrigger(i)
 }
 
 // Triggers returns the list of the triggers to add when an instance is created
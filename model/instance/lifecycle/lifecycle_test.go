@@ -2,6 +2,8 @@ package lifecycle_test
 
 import (
 	"bytes"
+	"crypto/rsa"
+	"crypto/x509"
 	"fmt"
 	"os"
 	"testing"
@@ -146,6 +148,39 @@ func TestInstancehasOAuthSecret(t *testing.T) {
 	}
 }
 
+func TestInstanceHasOIDCKey(t *testing.T) {
+	i, err := lifecycle.GetInstance("test.cozycloud.cc")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NotEmpty(t, i.OIDCKey)
+	key, err := i.OIDCSigningKey()
+	assert.NoError(t, err)
+	assert.Equal(t, i.OIDCKey, x509.MarshalPKCS1PrivateKey(key))
+
+	// An instance created before the OpenID Connect provider has no key, and
+	// concurrent requests must agree on the key that is generated
+	i.OIDCKey = nil
+	assert.NoError(t, i.Update())
+	other := i.Clone().(*instance.Instance)
+	keys := make(chan *rsa.PrivateKey, 2)
+	for _, inst := range []*instance.Instance{i, other} {
+		go func(inst *instance.Instance) {
+			key, err := inst.OIDCSigningKey()
+			assert.NoError(t, err)
+			keys <- key
+		}(inst)
+	}
+	first, second := <-keys, <-keys
+	if assert.NotNil(t, first) && assert.NotNil(t, second) {
+		assert.True(t, first.Equal(second))
+	}
+	fresh, err := instance.GetFromCouch("test.cozycloud.cc")
+	if assert.NoError(t, err) {
+		assert.Equal(t, i.OIDCKey, fresh.OIDCKey)
+	}
+}
+
 func TestInstanceHasRootDir(t *testing.T) {
 	var root vfs.DirDoc
 	prefix := getDB(t, "test.cozycloud.cc")
@@ -105,6 +105,18 @@ func Patch(i *instance.Instance, opts *Options) error {
 			needUpdate = true
 		}
 
+		if opts.AppQuotaSlug != "" && opts.AppQuota != nil {
+			if opts.AppQuota.Disk > 0 || opts.AppQuota.Docs > 0 {
+				if i.AppsQuotas == nil {
+					i.AppsQuotas = make(map[string]*instance.AppQuota)
+				}
+				i.AppsQuotas[opts.AppQuotaSlug] = opts.AppQuota
+			} else {
+				delete(i.AppsQuotas, opts.AppQuotaSlug)
+			}
+			needUpdate = true
+		}
+
 		if opts.AutoUpdate != nil && !(*opts.AutoUpdate) != i.NoAutoUpdate {
 			i.NoAutoUpdate = !(*opts.AutoUpdate)
 			needUpdate = true
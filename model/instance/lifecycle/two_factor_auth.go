@@ -19,6 +19,16 @@ func SendTwoFactorPasscode(inst *instance.Instance) ([]byte, error) {
 	return token, nil
 }
 
+// StartTwoFactor is called when the user has given their passphrase and a
+// second factor is required. It returns a token for the second step. For the
+// mail authentication mode, it also sends the passcode by mail.
+func StartTwoFactor(inst *instance.Instance) ([]byte, error) {
+	if inst.HasAuthMode(instance.TwoFactorMail) {
+		return SendTwoFactorPasscode(inst)
+	}
+	return inst.GenerateTwoFactorToken()
+}
+
 // SendMailConfirmationCode send a code to validate the email of the instance
 // in order to activate 2FA.
 func SendMailConfirmationCode(inst *instance.Instance) error {
@@ -0,0 +1,79 @@
+package instance
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"math/big"
+
+	"github.com/cozy/cozy-stack/pkg/lock"
+)
+
+const oidcKeyBits = 2048
+
+// GenerateOIDCKey returns a new RSA private key, in the PKCS#1 DER form, for
+// signing the ID tokens.
+func GenerateOIDCKey() ([]byte, error) {
+	key, err := rsa.GenerateKey(rand.Reader, oidcKeyBits)
+	if err != nil {
+		return nil, err
+	}
+	return x509.MarshalPKCS1PrivateKey(key), nil
+}
+
+// OIDCSigningKey returns the RSA private key used by the instance to sign the
+// ID tokens when it acts as an OpenID Connect provider. The key is generated
+// when the instance is created, but the instances created before that may
+// not have one: it is then generated under a lock, after reloading the
+// instance, to avoid two requests persisting different keys.
+func (i *Instance) OIDCSigningKey() (*rsa.PrivateKey, error) {
+	if len(i.OIDCKey) > 0 {
+		return x509.ParsePKCS1PrivateKey(i.OIDCKey)
+	}
+
+	mu := lock.ReadWrite(i, "oidc")
+	if err := mu.Lock(); err != nil {
+		return nil, err
+	}
+	defer mu.Unlock()
+
+	fresh, err := GetFromCouch(i.Domain)
+	if err != nil {
+		return nil, err
+	}
+	if len(fresh.OIDCKey) == 0 {
+		fresh.OIDCKey, err = GenerateOIDCKey()
+		if err != nil {
+			return nil, err
+		}
+		if err := fresh.Update(); err != nil {
+			return nil, err
+		}
+	}
+	i.OIDCKey = fresh.OIDCKey
+	i.SetRev(fresh.Rev())
+	return x509.ParsePKCS1PrivateKey(i.OIDCKey)
+}
+
+// OIDCKeyID returns the identifier of the signing key, used for the kid
+// header of the ID tokens and in the JSON Web Key Set.
+func OIDCKeyID(key *rsa.PublicKey) string {
+	sum := sha256.Sum256(x509.MarshalPKCS1PublicKey(key))
+	return base64.RawURLEncoding.EncodeToString(sum[:16])
+}
+
+// OIDCJWK returns the public part of the signing key in the JSON Web Key
+// format.
+// See https://tools.ietf.org/html/rfc7517
+func OIDCJWK(key *rsa.PublicKey) map[string]interface{} {
+	return map[string]interface{}{
+		"kty": "RSA",
+		"use": "sig",
+		"alg": "RS256",
+		"kid": OIDCKeyID(key),
+		"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
@@ -0,0 +1,284 @@
+package instance
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"image/png"
+	"strings"
+	"time"
+
+	"github.com/cozy/cozy-stack/pkg/config/config"
+	"github.com/cozy/cozy-stack/pkg/crypto"
+	"github.com/cozy/cozy-stack/pkg/keymgmt"
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+)
+
+// NbRecoveryCodes is the number of recovery codes generated for a user when
+// they activate the two factor authentication with TOTP or a security key.
+const NbRecoveryCodes = 10
+
+// The TOTP options for the authenticator applications: they are the defaults
+// from Google Authenticator, as some applications don't support other values.
+const totpPeriod = 30
+
+var userTOTPOptions = totp.ValidateOpts{
+	Period:    totpPeriod,
+	Skew:      1,
+	Digits:    otp.DigitsSix,
+	Algorithm: otp.AlgorithmSHA1,
+}
+
+var errCannotDecryptTOTPSecret = errors.New("cannot decrypt the TOTP secret")
+
+var totpEnrolmentMACConfig = crypto.MACConfig{
+	Name:   "totp-enrolment",
+	MaxAge: 15 * time.Minute,
+	MaxLen: 256,
+}
+
+// TOTPEnrolment contains the informations for adding the instance to an
+// authenticator application.
+type TOTPEnrolment struct {
+	// Secret is the shared secret, encoded in base32, for the users that
+	// cannot scan the QR code
+	Secret string `json:"secret"`
+	// URL is the otpauth:// URL with the secret and the parameters
+	URL string `json:"url"`
+	// QRCode is the URL encoded as a QR code, in a data URI for a PNG image
+	QRCode string `json:"qr_code"`
+	// Token must be sent back with a passcode to confirm the enrolment
+	Token string `json:"token"`
+}
+
+// BeginTOTPEnrolment generates a new secret for TOTP. The secret is not
+// persisted until the user has confirmed it with a passcode from their
+// authenticator application.
+func (i *Instance) BeginTOTPEnrolment() (*TOTPEnrolment, error) {
+	key, err := totp.Generate(totp.GenerateOpts{
+		Issuer:      i.TemplateTitle(),
+		AccountName: i.ContextualDomain(),
+		Period:      totpPeriod,
+		Digits:      userTOTPOptions.Digits,
+		Algorithm:   userTOTPOptions.Algorithm,
+	})
+	if err != nil {
+		return nil, err
+	}
+	token, err := crypto.EncodeAuthMessage(totpEnrolmentMACConfig, i.SessionSecret(), []byte(key.Secret()), nil)
+	if err != nil {
+		return nil, err
+	}
+	img, err := key.Image(256, 256)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return &TOTPEnrolment{
+		Secret: key.Secret(),
+		URL:    key.URL(),
+		QRCode: "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes()),
+		Token:  string(token),
+	}, nil
+}
+
+// ConfirmTOTPEnrolment checks that the passcode has been generated with the
+// secret of the enrolment, and if it is the case, it activates the two factor
+// authentication with TOTP. It returns a new set of recovery codes.
+func (i *Instance) ConfirmTOTPEnrolment(token []byte, passcode string) ([]string, error) {
+	secret, err := crypto.DecodeAuthMessage(totpEnrolmentMACConfig, i.SessionSecret(), token, nil)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	ok, err := totp.ValidateCustom(passcode, string(secret), time.Now().UTC(), userTOTPOptions)
+	if err != nil || !ok {
+		return nil, ErrInvalidTwoFactor
+	}
+	if err := i.setTOTPSecret(string(secret)); err != nil {
+		return nil, err
+	}
+	i.TOTPLastCounter = time.Now().Unix() / totpPeriod
+	i.AuthMode = TwoFactorTOTP
+	codes := i.newRecoveryCodes()
+	if err := i.Update(); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// RemoveTOTP removes the TOTP secret. If TOTP was the authentication mode, it
+// falls back to the security keys if the user has some, or to the basic mode.
+func (i *Instance) RemoveTOTP() error {
+	if !i.HasTOTP() {
+		return ErrSecondFactorNotEnrolled
+	}
+	_ = i.setTOTPSecret("")
+	i.TOTPLastCounter = 0
+	if i.AuthMode == TwoFactorTOTP {
+		if len(i.WebAuthnCredentials) > 0 {
+			i.AuthMode = TwoFactorWebAuthn
+		} else {
+			i.AuthMode = Basic
+			i.RecoveryCodes = nil
+		}
+	}
+	return i.Update()
+}
+
+// HasTOTP returns true if the user has enrolled an authenticator application.
+func (i *Instance) HasTOTP() bool {
+	return i.TOTPSecret != "" || i.TOTPSecretEncrypted != ""
+}
+
+// setTOTPSecret keeps the TOTP secret in the instance, encrypted with the
+// vault key of the stack (like the credentials of the accounts) if there is
+// one. An empty secret removes it.
+func (i *Instance) setTOTPSecret(secret string) error {
+	i.TOTPSecret, i.TOTPSecretEncrypted = "", ""
+	if secret == "" {
+		return nil
+	}
+	encryptorKey := config.GetVault().CredentialsEncryptorKey()
+	if encryptorKey == nil {
+		i.TOTPSecret = secret
+		return nil
+	}
+	encrypted, err := keymgmt.EncryptBuffer(encryptorKey, []byte(secret))
+	if err != nil {
+		return err
+	}
+	i.TOTPSecretEncrypted = base64.StdEncoding.EncodeToString(encrypted)
+	return nil
+}
+
+// totpSecret returns the TOTP secret in clear.
+func (i *Instance) totpSecret() (string, error) {
+	if i.TOTPSecretEncrypted == "" {
+		return i.TOTPSecret, nil
+	}
+	decryptorKey := config.GetVault().CredentialsDecryptorKey()
+	if decryptorKey == nil {
+		return "", errCannotDecryptTOTPSecret
+	}
+	encrypted, err := base64.StdEncoding.DecodeString(i.TOTPSecretEncrypted)
+	if err != nil {
+		return "", errCannotDecryptTOTPSecret
+	}
+	secret, err := keymgmt.DecryptBuffer(decryptorKey, encrypted)
+	if err != nil {
+		return "", errCannotDecryptTOTPSecret
+	}
+	return string(secret), nil
+}
+
+func (i *Instance) validateTOTP(passcode string) bool {
+	if !i.HasTOTP() || len(passcode) != int(userTOTPOptions.Digits) {
+		return false
+	}
+	secret, err := i.totpSecret()
+	if err != nil {
+		i.Logger().WithNamespace("auth").
+			Errorf("Cannot read the TOTP secret: %s", err)
+		return false
+	}
+	now := time.Now().Unix() / totpPeriod
+	for counter := now - int64(userTOTPOptions.Skew); counter <= now+int64(userTOTPOptions.Skew); counter++ {
+		// A passcode can be used only once
+		if counter <= i.TOTPLastCounter {
+			continue
+		}
+		at := time.Unix(counter*totpPeriod, 0).UTC()
+		code, err := totp.GenerateCodeCustom(secret, at, userTOTPOptions)
+		if err != nil {
+			return false
+		}
+		if subtle.ConstantTimeCompare([]byte(code), []byte(passcode)) == 1 {
+			i.TOTPLastCounter = counter
+			// The secrets saved before the vault key was configured are
+			// encrypted on their next use
+			if i.TOTPSecret != "" {
+				_ = i.setTOTPSecret(secret)
+			}
+			if err := i.Update(); err != nil {
+				i.Logger().WithNamespace("auth").
+					Warnf("Cannot save the TOTP counter: %s", err)
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateTOTPOrRecoveryCode validates a code from the authenticator
+// application, or one of the recovery codes (which is then consumed). It is
+// used to confirm a sensitive change of the two-factor settings, without a
+// token, as the user is already logged in.
+func (i *Instance) ValidateTOTPOrRecoveryCode(passcode string) bool {
+	if i.validateTOTP(passcode) {
+		return true
+	}
+	return i.useRecoveryCode(passcode)
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes by new ones, and
+// returns them.
+func (i *Instance) RegenerateRecoveryCodes() ([]string, error) {
+	if i.AuthMode != TwoFactorTOTP && i.AuthMode != TwoFactorWebAuthn {
+		return nil, ErrSecondFactorNotEnrolled
+	}
+	codes := i.newRecoveryCodes()
+	if err := i.Update(); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// newRecoveryCodes generates new recovery codes. Only their hashes are kept in
+// the instance, and the caller must persist it.
+func (i *Instance) newRecoveryCodes() []string {
+	codes := make([]string, NbRecoveryCodes)
+	hashes := make([]string, NbRecoveryCodes)
+	for j := range codes {
+		random := crypto.GenerateRandomBytes(8)
+		code := strings.ToLower(base32.StdEncoding.EncodeToString(random))[:10]
+		codes[j] = code[:5] + "-" + code[5:]
+		hashes[j] = hashRecoveryCode(code)
+	}
+	i.RecoveryCodes = hashes
+	return codes
+}
+
+func (i *Instance) useRecoveryCode(code string) bool {
+	code = strings.ToLower(code)
+	code = strings.Replace(code, "-", "", -1)
+	code = strings.Replace(code, " ", "", -1)
+	if len(code) != 10 {
+		return false
+	}
+	hashed := hashRecoveryCode(code)
+	for j, h := range i.RecoveryCodes {
+		if subtle.ConstantTimeCompare([]byte(h), []byte(hashed)) == 1 {
+			i.RecoveryCodes = append(i.RecoveryCodes[:j], i.RecoveryCodes[j+1:]...)
+			if err := i.Update(); err != nil {
+				i.Logger().WithNamespace("auth").
+					Errorf("Cannot remove a used recovery code: %s", err)
+				return false
+			}
+			return true
+		}
+	}
+	return false
+}
+
+func hashRecoveryCode(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
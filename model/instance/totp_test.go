@@ -0,0 +1,37 @@
+package instance
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pquerna/otp/totp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTOTPSecretIsEncrypted(t *testing.T) {
+	secret := "JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP"
+	inst := newWebAuthnInstance()
+	require.NoError(t, inst.setTOTPSecret(secret))
+	assert.True(t, inst.HasTOTP())
+	assert.Empty(t, inst.TOTPSecret)
+	assert.NotEmpty(t, inst.TOTPSecretEncrypted)
+	assert.NotContains(t, inst.TOTPSecretEncrypted, secret)
+	decrypted, err := inst.totpSecret()
+	assert.NoError(t, err)
+	assert.Equal(t, secret, decrypted)
+
+	code, err := totp.GenerateCodeCustom(secret, time.Now(), userTOTPOptions)
+	require.NoError(t, err)
+	assert.True(t, inst.validateTOTP(code))
+
+	// A secret saved in clear is encrypted on its next use
+	legacy := newWebAuthnInstance()
+	legacy.TOTPSecret = secret
+	assert.True(t, legacy.validateTOTP(code))
+	assert.Empty(t, legacy.TOTPSecret)
+	assert.NotEmpty(t, legacy.TOTPSecretEncrypted)
+
+	require.NoError(t, inst.setTOTPSecret(""))
+	assert.False(t, inst.HasTOTP())
+}
@@ -0,0 +1,502 @@
+package instance
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net"
+	"strings"
+	"time"
+
+	stackcrypto "github.com/cozy/cozy-stack/pkg/crypto"
+	"github.com/ugorji/go/codec"
+)
+
+// The COSE algorithms supported for the security keys
+const (
+	coseAlgES256 = -7
+	coseAlgEdDSA = -8
+	coseAlgRS256 = -257
+)
+
+// The flags of the authenticator data
+const (
+	authDataUserPresent  = 0x01
+	authDataAttestedData = 0x40
+)
+
+const webauthnTimeout = 2 * time.Minute
+
+var webauthnMACConfig = stackcrypto.MACConfig{
+	Name:   "webauthn-registration",
+	MaxAge: webauthnTimeout,
+	MaxLen: 256,
+}
+
+var errInvalidAuthData = errors.New("invalid authenticator data")
+
+// WebAuthnCredential is a security key registered for the two factor
+// authentication.
+type WebAuthnCredential struct {
+	ID         string     `json:"id"` // base64url encoded
+	Name       string     `json:"name,omitempty"`
+	PublicKey  []byte     `json:"public_key"` // PKIX, ASN.1 DER form
+	Algorithm  int        `json:"alg"`
+	SignCount  uint32     `json:"sign_count"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+// WebAuthnRegistration contains the options to give to the browser for
+// creating a new credential on a security key
+// (navigator.credentials.create), and a token that must be sent back with
+// the response of the security key.
+type WebAuthnRegistration struct {
+	Token   string                 `json:"token"`
+	Options map[string]interface{} `json:"publicKey"`
+}
+
+// WebAuthnResponse is the response of the browser after a call to
+// navigator.credentials.create or navigator.credentials.get, with the binary
+// fields encoded in base64url.
+type WebAuthnResponse struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Response struct {
+		ClientDataJSON    string `json:"clientDataJSON"`
+		ClientDataJSONAlt string `json:"clientDataJson"` // Used by bitwarden
+		AttestationObject string `json:"attestationObject,omitempty"`
+		AuthenticatorData string `json:"authenticatorData,omitempty"`
+		Signature         string `json:"signature,omitempty"`
+	} `json:"response"`
+}
+
+type clientData struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"`
+	Origin    string `json:"origin"`
+}
+
+type authenticatorData struct {
+	rpIDHash  []byte
+	flags     byte
+	signCount uint32
+	credID    []byte
+	publicKey []byte
+	alg       int
+}
+
+// rpID returns the relying party identifier, ie the domain of the instance
+// without the port.
+func (i *Instance) rpID() string {
+	host := i.ContextualDomain()
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return host
+}
+
+func (i *Instance) webauthnOrigin() string {
+	return i.Scheme() + "://" + i.ContextualDomain()
+}
+
+// allowedWebAuthnOrigin returns true if the origin of the client data is the
+// origin of the instance, or one of the other allowed origins. An allowed
+// origin ending with a * matches all the origins with this prefix.
+func (i *Instance) allowedWebAuthnOrigin(origin string, others []string) bool {
+	if origin == i.webauthnOrigin() {
+		return true
+	}
+	for _, allowed := range others {
+		if strings.HasSuffix(allowed, "*") {
+			if strings.HasPrefix(origin, strings.TrimSuffix(allowed, "*")) {
+				return true
+			}
+		} else if origin == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// BeginWebAuthnRegistration returns the options for registering a new
+// security key.
+func (i *Instance) BeginWebAuthnRegistration() (*WebAuthnRegistration, error) {
+	challenge := stackcrypto.GenerateRandomBytes(32)
+	token, err := stackcrypto.EncodeAuthMessage(webauthnMACConfig, i.SessionSecret(), challenge, nil)
+	if err != nil {
+		return nil, err
+	}
+	name, _ := i.PublicName()
+	if name == "" {
+		name = i.ContextualDomain()
+	}
+	userID := sha256.Sum256([]byte(i.Domain))
+	exclude := make([]map[string]interface{}, len(i.WebAuthnCredentials))
+	for j, cred := range i.WebAuthnCredentials {
+		exclude[j] = map[string]interface{}{"type": "public-key", "id": cred.ID}
+	}
+	options := map[string]interface{}{
+		"challenge": base64.RawURLEncoding.EncodeToString(challenge),
+		"rp": map[string]interface{}{
+			"id":   i.rpID(),
+			"name": i.TemplateTitle(),
+		},
+		"user": map[string]interface{}{
+			"id":          base64.RawURLEncoding.EncodeToString(userID[:]),
+			"name":        i.ContextualDomain(),
+			"displayName": name,
+		},
+		"pubKeyCredParams": []map[string]interface{}{
+			{"type": "public-key", "alg": coseAlgES256},
+			{"type": "public-key", "alg": coseAlgEdDSA},
+			{"type": "public-key", "alg": coseAlgRS256},
+		},
+		"timeout":            webauthnTimeout.Milliseconds(),
+		"attestation":        "none",
+		"excludeCredentials": exclude,
+		"authenticatorSelection": map[string]interface{}{
+			"userVerification": "discouraged",
+		},
+	}
+	return &WebAuthnRegistration{Token: string(token), Options: options}, nil
+}
+
+// FinishWebAuthnRegistration checks the response of the security key, and
+// registers the new credential. The authentication mode is switched to
+// WebAuthn if it was not already a second factor with TOTP or WebAuthn. It
+// returns the new recovery codes if they have been generated.
+func (i *Instance) FinishWebAuthnRegistration(token []byte, name string, resp *WebAuthnResponse) (*WebAuthnCredential, []string, error) {
+	challenge, err := stackcrypto.DecodeAuthMessage(webauthnMACConfig, i.SessionSecret(), token, nil)
+	if err != nil {
+		return nil, nil, ErrInvalidToken
+	}
+	if _, err := i.checkClientData(resp, "webauthn.create", challenge, nil); err != nil {
+		return nil, nil, err
+	}
+
+	raw, err := decodeBase64(resp.Response.AttestationObject)
+	if err != nil {
+		return nil, nil, ErrInvalidWebAuthnCredential
+	}
+	var attestation struct {
+		Fmt      string `codec:"fmt"`
+		AuthData []byte `codec:"authData"`
+	}
+	if err := codec.NewDecoderBytes(raw, &codec.CborHandle{}).Decode(&attestation); err != nil {
+		return nil, nil, ErrInvalidWebAuthnCredential
+	}
+	authData, err := i.parseAuthData(attestation.AuthData)
+	if err != nil || authData.credID == nil {
+		return nil, nil, ErrInvalidWebAuthnCredential
+	}
+
+	id := base64.RawURLEncoding.EncodeToString(authData.credID)
+	for _, cred := range i.WebAuthnCredentials {
+		if cred.ID == id {
+			return nil, nil, ErrInvalidWebAuthnCredential
+		}
+	}
+	cred := &WebAuthnCredential{
+		ID:        id,
+		Name:      name,
+		PublicKey: authData.publicKey,
+		Algorithm: authData.alg,
+		SignCount: authData.signCount,
+		CreatedAt: time.Now().UTC(),
+	}
+	i.WebAuthnCredentials = append(i.WebAuthnCredentials, cred)
+
+	var codes []string
+	if i.AuthMode != TwoFactorTOTP && i.AuthMode != TwoFactorWebAuthn {
+		i.AuthMode = TwoFactorWebAuthn
+		codes = i.newRecoveryCodes()
+	}
+	if err := i.Update(); err != nil {
+		return nil, nil, err
+	}
+	return cred, codes, nil
+}
+
+// RemoveWebAuthnCredential removes a security key. If it was the last one and
+// WebAuthn was the authentication mode, it falls back to TOTP if the user has
+// enrolled an authenticator application, or to the basic mode.
+func (i *Instance) RemoveWebAuthnCredential(id string) error {
+	idx := -1
+	for j, cred := range i.WebAuthnCredentials {
+		if cred.ID == id {
+			idx = j
+		}
+	}
+	if idx < 0 {
+		return ErrWebAuthnCredentialNotFound
+	}
+	i.WebAuthnCredentials = append(i.WebAuthnCredentials[:idx], i.WebAuthnCredentials[idx+1:]...)
+	if len(i.WebAuthnCredentials) == 0 && i.AuthMode == TwoFactorWebAuthn {
+		if i.HasTOTP() {
+			i.AuthMode = TwoFactorTOTP
+		} else {
+			i.AuthMode = Basic
+			i.RecoveryCodes = nil
+		}
+	}
+	return i.Update()
+}
+
+// WebAuthnAssertionOptions returns the options to give to the browser for
+// asking a signature to a security key (navigator.credentials.get), for the
+// given two-factor token.
+func (i *Instance) WebAuthnAssertionOptions(token []byte) (map[string]interface{}, error) {
+	challenge, err := stackcrypto.DecodeAuthMessage(totpMACConfig, i.SessionSecret(), token, nil)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	allow := make([]map[string]interface{}, len(i.WebAuthnCredentials))
+	for j, cred := range i.WebAuthnCredentials {
+		allow[j] = map[string]interface{}{"type": "public-key", "id": cred.ID}
+	}
+	return map[string]interface{}{
+		"challenge":        base64.RawURLEncoding.EncodeToString(challenge),
+		"rpId":             i.rpID(),
+		"timeout":          webauthnTimeout.Milliseconds(),
+		"allowCredentials": allow,
+		"userVerification": "discouraged",
+	}, nil
+}
+
+// validateWebAuthnAssertion checks that the passcode is the JSON-encoded
+// response of a registered security key for the challenge. The response can
+// come from the instance or from one of the other given origins.
+func (i *Instance) validateWebAuthnAssertion(challenge []byte, passcode string, origins []string) bool {
+	var resp WebAuthnResponse
+	if err := json.Unmarshal([]byte(passcode), &resp); err != nil {
+		return false
+	}
+	rawID, err := decodeBase64(resp.ID)
+	if err != nil {
+		return false
+	}
+	id := base64.RawURLEncoding.EncodeToString(rawID)
+	var cred *WebAuthnCredential
+	for _, c := range i.WebAuthnCredentials {
+		if c.ID == id {
+			cred = c
+		}
+	}
+	if cred == nil {
+		return false
+	}
+
+	rawClientData, err := i.checkClientData(&resp, "webauthn.get", challenge, origins)
+	if err != nil {
+		return false
+	}
+	rawAuthData, err := decodeBase64(resp.Response.AuthenticatorData)
+	if err != nil {
+		return false
+	}
+	authData, err := i.parseAuthData(rawAuthData)
+	if err != nil {
+		return false
+	}
+	sig, err := decodeBase64(resp.Response.Signature)
+	if err != nil {
+		return false
+	}
+	clientDataHash := sha256.Sum256(rawClientData)
+	signed := append(append([]byte{}, rawAuthData...), clientDataHash[:]...)
+	if !verifyWebAuthnSignature(cred, signed, sig) {
+		return false
+	}
+
+	// The signature counter must increase, or a clone of the security key may
+	// have been made
+	if (authData.signCount != 0 || cred.SignCount != 0) && authData.signCount <= cred.SignCount {
+		i.Logger().WithNamespace("auth").
+			Warnf("The signature counter of the security key %s has not increased", cred.ID)
+		return false
+	}
+	now := time.Now().UTC()
+	cred.SignCount = authData.signCount
+	cred.LastUsedAt = &now
+	if err := i.Update(); err != nil {
+		i.Logger().WithNamespace("auth").
+			Warnf("Cannot save the signature counter: %s", err)
+	}
+	return true
+}
+
+// checkClientData checks the type, challenge and origin of the client data,
+// and returns them in their raw form.
+func (i *Instance) checkClientData(resp *WebAuthnResponse, typ string, challenge []byte, origins []string) ([]byte, error) {
+	encoded := resp.Response.ClientDataJSON
+	if encoded == "" {
+		encoded = resp.Response.ClientDataJSONAlt
+	}
+	raw, err := decodeBase64(encoded)
+	if err != nil {
+		return nil, ErrInvalidWebAuthnCredential
+	}
+	var data clientData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, ErrInvalidWebAuthnCredential
+	}
+	received, err := decodeBase64(data.Challenge)
+	if err != nil || subtle.ConstantTimeCompare(received, challenge) != 1 {
+		return nil, ErrInvalidWebAuthnCredential
+	}
+	if data.Type != typ || !i.allowedWebAuthnOrigin(data.Origin, origins) {
+		return nil, ErrInvalidWebAuthnCredential
+	}
+	return raw, nil
+}
+
+// parseAuthData parses the authenticator data, as described in
+// https://www.w3.org/TR/webauthn-2/#sctn-authenticator-data
+func (i *Instance) parseAuthData(raw []byte) (*authenticatorData, error) {
+	if len(raw) < 37 {
+		return nil, errInvalidAuthData
+	}
+	data := &authenticatorData{
+		rpIDHash:  raw[:32],
+		flags:     raw[32],
+		signCount: binary.BigEndian.Uint32(raw[33:37]),
+	}
+	rpIDHash := sha256.Sum256([]byte(i.rpID()))
+	if !bytes.Equal(data.rpIDHash, rpIDHash[:]) {
+		return nil, errInvalidAuthData
+	}
+	if data.flags&authDataUserPresent == 0 {
+		return nil, errInvalidAuthData
+	}
+	if data.flags&authDataAttestedData == 0 {
+		return data, nil
+	}
+
+	// Attested credential data: AAGUID (16 bytes), the length of the
+	// credential ID (2 bytes), the credential ID, and the public key in COSE
+	if len(raw) < 55 {
+		return nil, errInvalidAuthData
+	}
+	idLen := int(binary.BigEndian.Uint16(raw[53:55]))
+	if len(raw) < 55+idLen {
+		return nil, errInvalidAuthData
+	}
+	data.credID = raw[55 : 55+idLen]
+	var coseKey map[int64]interface{}
+	if err := codec.NewDecoderBytes(raw[55+idLen:], &codec.CborHandle{}).Decode(&coseKey); err != nil {
+		return nil, errInvalidAuthData
+	}
+	pub, alg, err := parseCOSEKey(coseKey)
+	if err != nil {
+		return nil, err
+	}
+	data.publicKey, err = x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return nil, err
+	}
+	data.alg = alg
+	return data, nil
+}
+
+// parseCOSEKey transforms a public key in the COSE format (RFC 8152) to a Go
+// public key.
+func parseCOSEKey(key map[int64]interface{}) (crypto.PublicKey, int, error) {
+	kty, _ := coseInt(key[1])
+	alg, _ := coseInt(key[3])
+	switch {
+	case kty == 2 && alg == coseAlgES256: // EC2 with P-256
+		x, _ := key[-2].([]byte)
+		y, _ := key[-3].([]byte)
+		if len(x) != 32 || len(y) != 32 {
+			return nil, 0, errInvalidAuthData
+		}
+		pub := &ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}
+		if !pub.Curve.IsOnCurve(pub.X, pub.Y) {
+			return nil, 0, errInvalidAuthData
+		}
+		return pub, coseAlgES256, nil
+	case kty == 1 && alg == coseAlgEdDSA: // OKP with Ed25519
+		x, _ := key[-2].([]byte)
+		if len(x) != ed25519.PublicKeySize {
+			return nil, 0, errInvalidAuthData
+		}
+		return ed25519.PublicKey(x), coseAlgEdDSA, nil
+	case kty == 3 && alg == coseAlgRS256: // RSA
+		n, _ := key[-1].([]byte)
+		e, _ := key[-2].([]byte)
+		if len(n) == 0 || len(e) == 0 || len(e) > 4 {
+			return nil, 0, errInvalidAuthData
+		}
+		exp := 0
+		for _, b := range e {
+			exp = exp<<8 | int(b)
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: exp}, coseAlgRS256, nil
+	}
+	return nil, 0, errInvalidAuthData
+}
+
+func coseInt(v interface{}) (int64, bool) {
+	switch n := v.(type) {
+	case int64:
+		return n, true
+	case uint64:
+		return int64(n), true
+	}
+	return 0, false
+}
+
+func verifyWebAuthnSignature(cred *WebAuthnCredential, signed, sig []byte) bool {
+	pub, err := x509.ParsePKIXPublicKey(cred.PublicKey)
+	if err != nil {
+		return false
+	}
+	switch cred.Algorithm {
+	case coseAlgES256:
+		key, ok := pub.(*ecdsa.PublicKey)
+		if !ok {
+			return false
+		}
+		hash := sha256.Sum256(signed)
+		return ecdsa.VerifyASN1(key, hash[:], sig)
+	case coseAlgEdDSA:
+		key, ok := pub.(ed25519.PublicKey)
+		if !ok {
+			return false
+		}
+		return ed25519.Verify(key, signed, sig)
+	case coseAlgRS256:
+		key, ok := pub.(*rsa.PublicKey)
+		if !ok {
+			return false
+		}
+		hash := sha256.Sum256(signed)
+		return rsa.VerifyPKCS1v15(key, crypto.SHA256, hash[:], sig) == nil
+	}
+	return false
+}
+
+// decodeBase64 decodes base64 with the URL or standard alphabet, with or
+// without padding, as the browsers and the bitwarden clients are not
+// consistent.
+func decodeBase64(s string) ([]byte, error) {
+	s = strings.TrimRight(s, "=")
+	s = strings.NewReplacer("+", "-", "/", "_").Replace(s)
+	return base64.RawURLEncoding.DecodeString(s)
+}
@@ -0,0 +1,334 @@
+package instance
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	stackcrypto "github.com/cozy/cozy-stack/pkg/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ugorji/go/codec"
+)
+
+// softKey is a software authenticator, that makes the same messages as a
+// security key used via a browser, following the format of the WebAuthn
+// specification: https://www.w3.org/TR/webauthn-2/
+type softKey struct {
+	id        []byte
+	alg       int
+	signer    crypto.Signer
+	signCount uint32
+}
+
+func newSoftKey(t *testing.T, alg int) *softKey {
+	var signer crypto.Signer
+	var err error
+	switch alg {
+	case coseAlgES256:
+		signer, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case coseAlgEdDSA:
+		_, signer, err = ed25519.GenerateKey(rand.Reader)
+	case coseAlgRS256:
+		signer, err = rsa.GenerateKey(rand.Reader, 2048)
+	}
+	require.NoError(t, err)
+	return &softKey{id: stackcrypto.GenerateRandomBytes(32), alg: alg, signer: signer}
+}
+
+func cborEncode(t *testing.T, v interface{}) []byte {
+	var out []byte
+	require.NoError(t, codec.NewEncoderBytes(&out, &codec.CborHandle{}).Encode(v))
+	return out
+}
+
+// coseKey returns the public key in the COSE format.
+func (k *softKey) coseKey(t *testing.T) []byte {
+	key := map[int]interface{}{3: k.alg}
+	switch pub := k.signer.Public().(type) {
+	case *ecdsa.PublicKey:
+		x := make([]byte, 32)
+		y := make([]byte, 32)
+		pub.X.FillBytes(x)
+		pub.Y.FillBytes(y)
+		key[1], key[-1], key[-2], key[-3] = 2, 1, x, y
+	case ed25519.PublicKey:
+		key[1], key[-1], key[-2] = 1, 6, []byte(pub)
+	case *rsa.PublicKey:
+		key[1], key[-1], key[-2] = 3, pub.N.Bytes(), big.NewInt(int64(pub.E)).Bytes()
+	}
+	return cborEncode(t, key)
+}
+
+func (k *softKey) authData(t *testing.T, rpID string, attested bool) []byte {
+	rpIDHash := sha256.Sum256([]byte(rpID))
+	data := append([]byte{}, rpIDHash[:]...)
+	flags := byte(authDataUserPresent)
+	if attested {
+		flags |= authDataAttestedData
+	}
+	data = append(data, flags)
+	var counter [4]byte
+	binary.BigEndian.PutUint32(counter[:], k.signCount)
+	data = append(data, counter[:]...)
+	if attested {
+		data = append(data, make([]byte, 16)...) // AAGUID
+		var idLen [2]byte
+		binary.BigEndian.PutUint16(idLen[:], uint16(len(k.id)))
+		data = append(data, idLen[:]...)
+		data = append(data, k.id...)
+		data = append(data, k.coseKey(t)...)
+	}
+	return data
+}
+
+func clientDataJSON(typ string, challenge []byte, origin string) []byte {
+	data, _ := json.Marshal(map[string]interface{}{
+		"type":        typ,
+		"challenge":   base64.RawURLEncoding.EncodeToString(challenge),
+		"origin":      origin,
+		"crossOrigin": false,
+	})
+	return data
+}
+
+// create returns the response of navigator.credentials.create.
+func (k *softKey) create(t *testing.T, rpID, origin string, challenge []byte) *WebAuthnResponse {
+	attestation := cborEncode(t, map[string]interface{}{
+		"fmt":      "none",
+		"attStmt":  map[string]interface{}{},
+		"authData": k.authData(t, rpID, true),
+	})
+	resp := &WebAuthnResponse{
+		ID:   base64.RawURLEncoding.EncodeToString(k.id),
+		Type: "public-key",
+	}
+	resp.Response.ClientDataJSON = base64.RawURLEncoding.EncodeToString(clientDataJSON("webauthn.create", challenge, origin))
+	resp.Response.AttestationObject = base64.RawURLEncoding.EncodeToString(attestation)
+	return resp
+}
+
+// get returns the response of navigator.credentials.get, with a signature of
+// the authenticator data and of the hash of the client data.
+func (k *softKey) get(t *testing.T, rpID, origin string, challenge []byte) *WebAuthnResponse {
+	k.signCount++
+	authData := k.authData(t, rpID, false)
+	client := clientDataJSON("webauthn.get", challenge, origin)
+	clientHash := sha256.Sum256(client)
+	signed := append(append([]byte{}, authData...), clientHash[:]...)
+
+	var sig []byte
+	var err error
+	if k.alg == coseAlgEdDSA {
+		sig, err = k.signer.Sign(rand.Reader, signed, crypto.Hash(0))
+	} else {
+		hash := sha256.Sum256(signed)
+		sig, err = k.signer.Sign(rand.Reader, hash[:], crypto.SHA256)
+	}
+	require.NoError(t, err)
+
+	resp := &WebAuthnResponse{
+		ID:   base64.RawURLEncoding.EncodeToString(k.id),
+		Type: "public-key",
+	}
+	resp.Response.ClientDataJSON = base64.RawURLEncoding.EncodeToString(client)
+	resp.Response.AuthenticatorData = base64.RawURLEncoding.EncodeToString(authData)
+	resp.Response.Signature = base64.RawURLEncoding.EncodeToString(sig)
+	return resp
+}
+
+func passcode(resp *WebAuthnResponse) string {
+	data, _ := json.Marshal(resp)
+	return string(data)
+}
+
+func newWebAuthnInstance() *Instance {
+	return &Instance{
+		Domain:     "alice.cozy.example",
+		SessSecret: stackcrypto.GenerateRandomBytes(64),
+	}
+}
+
+// register parses the response of the security key for a registration, like
+// FinishWebAuthnRegistration, and adds the credential to the instance.
+func register(t *testing.T, inst *Instance, key *softKey) *WebAuthnCredential {
+	reg, err := inst.BeginWebAuthnRegistration()
+	require.NoError(t, err)
+	challenge, err := stackcrypto.DecodeAuthMessage(webauthnMACConfig, inst.SessionSecret(), []byte(reg.Token), nil)
+	require.NoError(t, err)
+	assert.Equal(t, base64.RawURLEncoding.EncodeToString(challenge), reg.Options["challenge"])
+
+	resp := key.create(t, inst.rpID(), inst.webauthnOrigin(), challenge)
+	_, err = inst.checkClientData(resp, "webauthn.create", challenge, nil)
+	require.NoError(t, err)
+	raw, err := decodeBase64(resp.Response.AttestationObject)
+	require.NoError(t, err)
+	var attestation struct {
+		AuthData []byte `codec:"authData"`
+	}
+	require.NoError(t, codec.NewDecoderBytes(raw, &codec.CborHandle{}).Decode(&attestation))
+	authData, err := inst.parseAuthData(attestation.AuthData)
+	require.NoError(t, err)
+	assert.Equal(t, key.id, authData.credID)
+	assert.Equal(t, key.alg, authData.alg)
+
+	cred := &WebAuthnCredential{
+		ID:        base64.RawURLEncoding.EncodeToString(authData.credID),
+		PublicKey: authData.publicKey,
+		Algorithm: authData.alg,
+		SignCount: authData.signCount,
+	}
+	inst.WebAuthnCredentials = append(inst.WebAuthnCredentials, cred)
+	return cred
+}
+
+func TestWebAuthnRegistration(t *testing.T) {
+	inst := newWebAuthnInstance()
+	key := newSoftKey(t, coseAlgES256)
+	challenge := stackcrypto.GenerateRandomBytes(32)
+	origin := inst.webauthnOrigin()
+
+	resp := key.create(t, inst.rpID(), origin, challenge)
+	_, err := inst.checkClientData(resp, "webauthn.create", challenge, nil)
+	assert.NoError(t, err)
+	_, err = inst.checkClientData(resp, "webauthn.get", challenge, nil)
+	assert.Equal(t, ErrInvalidWebAuthnCredential, err)
+	_, err = inst.checkClientData(resp, "webauthn.create", stackcrypto.GenerateRandomBytes(32), nil)
+	assert.Equal(t, ErrInvalidWebAuthnCredential, err)
+
+	resp = key.create(t, inst.rpID(), "https://evil.example", challenge)
+	_, err = inst.checkClientData(resp, "webauthn.create", challenge, nil)
+	assert.Equal(t, ErrInvalidWebAuthnCredential, err)
+
+	// The authenticator data must be for the domain of the instance
+	_, err = inst.parseAuthData(key.authData(t, inst.rpID(), true))
+	assert.NoError(t, err)
+	_, err = inst.parseAuthData(key.authData(t, "evil.example", true))
+	assert.Equal(t, errInvalidAuthData, err)
+
+	// The user must be present
+	data := key.authData(t, inst.rpID(), true)
+	data[32] &^= authDataUserPresent
+	_, err = inst.parseAuthData(data)
+	assert.Equal(t, errInvalidAuthData, err)
+
+	// The data can't be truncated
+	data = key.authData(t, inst.rpID(), true)
+	_, err = inst.parseAuthData(data[:60])
+	assert.Equal(t, errInvalidAuthData, err)
+}
+
+func TestWebAuthnAssertion(t *testing.T) {
+	for _, alg := range []int{coseAlgES256, coseAlgEdDSA, coseAlgRS256} {
+		inst := newWebAuthnInstance()
+		key := newSoftKey(t, alg)
+		cred := register(t, inst, key)
+		rpID := inst.rpID()
+		origin := inst.webauthnOrigin()
+		challenge := stackcrypto.GenerateRandomBytes(32)
+
+		resp := key.get(t, rpID, origin, challenge)
+		assert.True(t, inst.validateWebAuthnAssertion(challenge, passcode(resp), nil), "alg %d", alg)
+		assert.EqualValues(t, 1, cred.SignCount)
+		assert.NotNil(t, cred.LastUsedAt)
+
+		// The same response can't be used twice, as the counter must increase
+		assert.False(t, inst.validateWebAuthnAssertion(challenge, passcode(resp), nil))
+
+		// The bitwarden clients use clientDataJson and the standard base64
+		resp = key.get(t, rpID, origin, challenge)
+		raw, _ := decodeBase64(resp.Response.ClientDataJSON)
+		resp.Response.ClientDataJSON = ""
+		resp.Response.ClientDataJSONAlt = base64.StdEncoding.EncodeToString(raw)
+		assert.True(t, inst.validateWebAuthnAssertion(challenge, passcode(resp), nil))
+		assert.EqualValues(t, 2, cred.SignCount)
+	}
+}
+
+func TestWebAuthnAssertionRejected(t *testing.T) {
+	inst := newWebAuthnInstance()
+	key := newSoftKey(t, coseAlgES256)
+	cred := register(t, inst, key)
+	rpID := inst.rpID()
+	origin := inst.webauthnOrigin()
+	challenge := stackcrypto.GenerateRandomBytes(32)
+
+	// Signature made by another key
+	other := newSoftKey(t, coseAlgES256)
+	other.id = key.id
+	resp := other.get(t, rpID, origin, challenge)
+	assert.False(t, inst.validateWebAuthnAssertion(challenge, passcode(resp), nil))
+
+	// Altered signed data
+	resp = key.get(t, rpID, origin, challenge)
+	authData, _ := decodeBase64(resp.Response.AuthenticatorData)
+	authData[36] = 42
+	resp.Response.AuthenticatorData = base64.RawURLEncoding.EncodeToString(authData)
+	assert.False(t, inst.validateWebAuthnAssertion(challenge, passcode(resp), nil))
+
+	// rpIdHash of another site
+	resp = key.get(t, "evil.example", origin, challenge)
+	assert.False(t, inst.validateWebAuthnAssertion(challenge, passcode(resp), nil))
+
+	// Another origin
+	resp = key.get(t, rpID, "https://evil.example", challenge)
+	assert.False(t, inst.validateWebAuthnAssertion(challenge, passcode(resp), nil))
+
+	// Another challenge
+	resp = key.get(t, rpID, origin, stackcrypto.GenerateRandomBytes(32))
+	assert.False(t, inst.validateWebAuthnAssertion(challenge, passcode(resp), nil))
+
+	// Unknown credential
+	unknown := newSoftKey(t, coseAlgES256)
+	resp = unknown.get(t, rpID, origin, challenge)
+	assert.False(t, inst.validateWebAuthnAssertion(challenge, passcode(resp), nil))
+	assert.False(t, inst.validateWebAuthnAssertion(challenge, "not json", nil))
+
+	// A counter that doesn't increase may come from a cloned key
+	cred.SignCount = 10
+	key.signCount = 5
+	resp = key.get(t, rpID, origin, challenge)
+	assert.False(t, inst.validateWebAuthnAssertion(challenge, passcode(resp), nil))
+	key.signCount = 10
+	resp = key.get(t, rpID, origin, challenge)
+	assert.True(t, inst.validateWebAuthnAssertion(challenge, passcode(resp), nil))
+	assert.EqualValues(t, 11, cred.SignCount)
+}
+
+func TestWebAuthnAssertionFromBitwarden(t *testing.T) {
+	inst := newWebAuthnInstance()
+	key := newSoftKey(t, coseAlgES256)
+	cred := register(t, inst, key)
+	rpID := inst.rpID()
+	challenge := stackcrypto.GenerateRandomBytes(32)
+	origins := []string{
+		"chrome-extension://nngceckbapebfimnlniiiahkandclblb",
+		"moz-extension://*",
+	}
+
+	// The origins of the bitwarden clients are only accepted for this flow
+	resp := key.get(t, rpID, "chrome-extension://nngceckbapebfimnlniiiahkandclblb", challenge)
+	assert.False(t, inst.validateWebAuthnAssertion(challenge, passcode(resp), nil))
+	assert.True(t, inst.validateWebAuthnAssertion(challenge, passcode(resp), origins))
+	assert.EqualValues(t, 1, cred.SignCount)
+
+	resp = key.get(t, rpID, "moz-extension://2e0b6d3c-8a41-4f4c-9a3e-6f3d7d1e0a52", challenge)
+	assert.True(t, inst.validateWebAuthnAssertion(challenge, passcode(resp), origins))
+
+	// The origin of the instance is still accepted
+	resp = key.get(t, rpID, inst.webauthnOrigin(), challenge)
+	assert.True(t, inst.validateWebAuthnAssertion(challenge, passcode(resp), origins))
+
+	// But not the other origins
+	resp = key.get(t, rpID, "chrome-extension://another-extension", challenge)
+	assert.False(t, inst.validateWebAuthnAssertion(challenge, passcode(resp), origins))
+}
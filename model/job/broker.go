@@ -112,6 +112,9 @@ type (
 	JobOptions struct {
 		MaxExecCount int           `json:"max_exec_count"`
 		Timeout      time.Duration `json:"timeout"`
+		// MaxMemory is the maximal memory in bytes that can be used by the
+		// job (only for the konnectors and services run with WebAssembly)
+		MaxMemory int64 `json:"max_memory,omitempty"`
 	}
 )
 
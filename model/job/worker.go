@@ -208,6 +208,17 @@ func (c *WorkerContext) TriggerID() (string, bool) {
 	return triggerID, triggerID != ""
 }
 
+// WorkerType returns the type of the worker of the job.
+func (c *WorkerContext) WorkerType() string {
+	return c.job.WorkerType
+}
+
+// Options returns the execution options of the job, or nil if the job has
+// no specific options.
+func (c *WorkerContext) Options() *JobOptions {
+	return c.job.Options
+}
+
 // Cookie returns the cookie associated with the worker context.
 func (c *WorkerContext) Cookie() interface{} {
 	return c.cookie
@@ -507,7 +507,8 @@ func (im *importer) importPermission(zf *zip.File) error {
 		return err
 	}
 	// We only import permission documents for sharings
-	if doc.Type != permission.TypeShareByLink && doc.Type != permission.TypeSharePreview {
+	if doc.Type != permission.TypeShareByLink && doc.Type != permission.TypeSharePreview &&
+		doc.Type != permission.TypeFileDrop {
 		return nil
 	}
 	doc.SetRev("")
@@ -0,0 +1,623 @@
+package note
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"time"
+
+	"github.com/cozy/cozy-stack/model/contact"
+	"github.com/cozy/cozy-stack/model/instance"
+	"github.com/cozy/cozy-stack/model/notification"
+	"github.com/cozy/cozy-stack/model/notification/center"
+	"github.com/cozy/cozy-stack/model/vfs"
+	"github.com/cozy/cozy-stack/pkg/consts"
+	"github.com/cozy/cozy-stack/pkg/couchdb"
+	"github.com/cozy/cozy-stack/pkg/jsonapi"
+	"github.com/cozy/cozy-stack/pkg/prefixer"
+	"github.com/cozy/cozy-stack/pkg/utils"
+	"github.com/cozy/prosemirror-go/model"
+	"github.com/cozy/prosemirror-go/transform"
+)
+
+const (
+	// SuggestionPending is the state of a suggestion waiting for a decision
+	// of the owner of the note.
+	SuggestionPending = "pending"
+	// SuggestionAccepted is the state of a suggestion that has been applied
+	// on the note.
+	SuggestionAccepted = "accepted"
+	// SuggestionRejected is the state of a suggestion that has been refused
+	// by the owner of the note.
+	SuggestionRejected = "rejected"
+)
+
+// Comment is a thread of messages anchored on a range of the content of a
+// note. The positions of the anchor are for the version of the note saved in
+// the comment, and they are mapped through the steps to follow the changes
+// made on the note.
+type Comment struct {
+	DocID      string      `json:"_id,omitempty"`
+	DocRev     string      `json:"_rev,omitempty"`
+	NoteID     string      `json:"note_id"`
+	Version    int64       `json:"version"`
+	From       int         `json:"from"`
+	To         int         `json:"to"`
+	Detached   bool        `json:"detached,omitempty"`
+	Messages   []Message   `json:"messages"`
+	Resolved   bool        `json:"resolved"`
+	ResolvedBy string      `json:"resolved_by,omitempty"`
+	ResolvedAt *time.Time  `json:"resolved_at,omitempty"`
+	Suggestion *Suggestion `json:"suggestion,omitempty"`
+	CreatedAt  time.Time   `json:"created_at"`
+	UpdatedAt  time.Time   `json:"updated_at"`
+}
+
+// Message is a message in a comment thread. The mentions are the identifiers
+// of the io.cozy.contacts mentioned in the text.
+type Message struct {
+	ID        string    `json:"id"`
+	Author    string    `json:"author,omitempty"`
+	Text      string    `json:"text"`
+	Mentions  []string  `json:"mentions,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Suggestion is a list of steps proposed for a note, that are not applied
+// until the owner of the note accepts them. While pending, the steps are
+// rebased on the changes made on the note, like the anchor of the comment.
+type Suggestion struct {
+	Steps     []Step     `json:"steps"`
+	State     string     `json:"state"`
+	DecidedBy string     `json:"decided_by,omitempty"`
+	DecidedAt *time.Time `json:"decided_at,omitempty"`
+}
+
+// ID returns the comment qualified identifier
+func (c *Comment) ID() string { return c.DocID }
+
+// Rev returns the comment revision
+func (c *Comment) Rev() string { return c.DocRev }
+
+// DocType returns the document type
+func (c *Comment) DocType() string { return consts.NotesComments }
+
+// Clone implements couchdb.Doc
+func (c *Comment) Clone() couchdb.Doc {
+	cloned := *c
+	cloned.Messages = make([]Message, len(c.Messages))
+	copy(cloned.Messages, c.Messages)
+	if c.Suggestion != nil {
+		suggestion := *c.Suggestion
+		suggestion.Steps = make([]Step, len(c.Suggestion.Steps))
+		for i, s := range c.Suggestion.Steps {
+			suggestion.Steps[i] = s.Clone().(Step)
+		}
+		cloned.Suggestion = &suggestion
+	}
+	return &cloned
+}
+
+// SetID changes the comment qualified identifier
+func (c *Comment) SetID(id string) { c.DocID = id }
+
+// SetRev changes the comment revision
+func (c *Comment) SetRev(rev string) { c.DocRev = rev }
+
+// Included is part of the jsonapi.Object interface
+func (c *Comment) Included() []jsonapi.Object { return nil }
+
+// Links is part of the jsonapi.Object interface
+func (c *Comment) Links() *jsonapi.LinksList { return nil }
+
+// Relationships is part of the jsonapi.Object interface
+func (c *Comment) Relationships() jsonapi.RelationshipMap { return nil }
+
+// isPendingSuggestion returns true if the comment has a suggestion waiting for
+// a decision.
+func (c *Comment) isPendingSuggestion() bool {
+	return c.Suggestion != nil && c.Suggestion.State == SuggestionPending
+}
+
+// NewComment is the parameters for creating a comment thread.
+type NewComment struct {
+	Version  int64
+	From     int
+	To       int
+	Author   string
+	Text     string
+	Mentions []string
+	Steps    []Step
+}
+
+func newMessage(author, text string, mentions []string) Message {
+	return Message{
+		ID:        utils.RandomString(16),
+		Author:    author,
+		Text:      text,
+		Mentions:  mentions,
+		CreatedAt: time.Now().UTC(),
+	}
+}
+
+// ListComments returns the comment threads of a note, with their anchors
+// mapped to the current version of the note.
+func ListComments(inst *instance.Instance, file *vfs.FileDoc) ([]*Comment, error) {
+	lock := inst.NotesLock()
+	if err := lock.Lock(); err != nil {
+		return nil, err
+	}
+	defer lock.Unlock()
+
+	doc, err := get(inst, file)
+	if err != nil {
+		return nil, err
+	}
+	comments, err := getComments(inst, file.ID())
+	if err != nil {
+		return nil, err
+	}
+	mapComments(inst, doc, comments, false)
+	return comments, nil
+}
+
+// GetComment returns a comment thread of a note, with its anchor mapped to the
+// current version of the note.
+func GetComment(inst *instance.Instance, file *vfs.FileDoc, commentID string) (*Comment, error) {
+	lock := inst.NotesLock()
+	if err := lock.Lock(); err != nil {
+		return nil, err
+	}
+	defer lock.Unlock()
+
+	doc, err := get(inst, file)
+	if err != nil {
+		return nil, err
+	}
+	comment, err := getComment(inst, file.ID(), commentID)
+	if err != nil {
+		return nil, err
+	}
+	mapComments(inst, doc, []*Comment{comment}, false)
+	return comment, nil
+}
+
+// CreateComment starts a new comment thread on a note. If the comment has
+// some steps, it is a suggestion: the steps are not applied on the note until
+// the owner accepts them.
+func CreateComment(inst *instance.Instance, file *vfs.FileDoc, params NewComment) (*Comment, error) {
+	lock := inst.NotesLock()
+	if err := lock.Lock(); err != nil {
+		return nil, err
+	}
+	defer lock.Unlock()
+
+	doc, err := get(inst, file)
+	if err != nil {
+		return nil, err
+	}
+	if params.Version <= 0 || params.Version > doc.Version {
+		params.Version = doc.Version
+	}
+	if params.From < 0 || params.To < params.From {
+		return nil, ErrInvalidAnchor
+	}
+	if params.Text == "" && len(params.Steps) == 0 {
+		return nil, ErrEmptyComment
+	}
+
+	now := time.Now().UTC()
+	comment := &Comment{
+		DocID:     fmt.Sprintf("%s/%s", file.ID(), utils.RandomString(16)),
+		NoteID:    file.ID(),
+		Version:   params.Version,
+		From:      params.From,
+		To:        params.To,
+		Messages:  []Message{},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if params.Text != "" {
+		msg := newMessage(params.Author, params.Text, params.Mentions)
+		comment.Messages = append(comment.Messages, msg)
+	}
+	if len(params.Steps) > 0 {
+		if err := checkSuggestedSteps(inst, doc, params.Version, params.Steps); err != nil {
+			return nil, err
+		}
+		for i := range params.Steps {
+			cleanSuggestedStep(params.Steps[i])
+		}
+		comment.Suggestion = &Suggestion{
+			Steps: params.Steps,
+			State: SuggestionPending,
+		}
+	}
+
+	mapComments(inst, doc, []*Comment{comment}, true)
+	if err := couchdb.CreateNamedDocWithDB(inst, comment); err != nil {
+		return nil, err
+	}
+	if len(comment.Messages) > 0 {
+		notifyMentions(inst, file, comment, &comment.Messages[0])
+	}
+	return comment, nil
+}
+
+// AddReply adds a message to a comment thread.
+func AddReply(inst *instance.Instance, file *vfs.FileDoc, commentID, author, text string, mentions []string) (*Comment, error) {
+	if text == "" {
+		return nil, ErrEmptyComment
+	}
+	var msg Message
+	comment, err := updateComment(inst, file, commentID, func(c *Comment) error {
+		msg = newMessage(author, text, mentions)
+		c.Messages = append(c.Messages, msg)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	notifyMentions(inst, file, comment, &msg)
+	return comment, nil
+}
+
+// ResolveComment marks a comment thread as resolved.
+func ResolveComment(inst *instance.Instance, file *vfs.FileDoc, commentID, author string) (*Comment, error) {
+	return updateComment(inst, file, commentID, func(c *Comment) error {
+		now := time.Now().UTC()
+		c.Resolved = true
+		c.ResolvedBy = author
+		c.ResolvedAt = &now
+		return nil
+	})
+}
+
+// ReopenComment marks a resolved comment thread as open again.
+func ReopenComment(inst *instance.Instance, file *vfs.FileDoc, commentID string) (*Comment, error) {
+	return updateComment(inst, file, commentID, func(c *Comment) error {
+		c.Resolved = false
+		c.ResolvedBy = ""
+		c.ResolvedAt = nil
+		return nil
+	})
+}
+
+// RejectSuggestion refuses the steps proposed in a comment. The comment
+// thread is resolved.
+func RejectSuggestion(inst *instance.Instance, file *vfs.FileDoc, commentID, author string) (*Comment, error) {
+	return updateComment(inst, file, commentID, func(c *Comment) error {
+		if !c.isPendingSuggestion() {
+			return ErrNoPendingSuggestion
+		}
+		now := time.Now().UTC()
+		c.Suggestion.State = SuggestionRejected
+		c.Suggestion.DecidedBy = author
+		c.Suggestion.DecidedAt = &now
+		c.Resolved = true
+		c.ResolvedBy = author
+		c.ResolvedAt = &now
+		return nil
+	})
+}
+
+// AcceptSuggestion applies the steps proposed in a comment on the note. The
+// steps are sent to the live editors like the other steps, and the comment
+// thread is resolved.
+func AcceptSuggestion(inst *instance.Instance, file *vfs.FileDoc, commentID, author, sessionID string) (*vfs.FileDoc, *Comment, error) {
+	lock := inst.NotesLock()
+	if err := lock.Lock(); err != nil {
+		return nil, nil, err
+	}
+	defer lock.Unlock()
+
+	doc, err := get(inst, file)
+	if err != nil {
+		return nil, nil, err
+	}
+	comment, err := getComment(inst, file.ID(), commentID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !comment.isPendingSuggestion() {
+		return nil, nil, ErrNoPendingSuggestion
+	}
+	mapComments(inst, doc, []*Comment{comment}, true)
+	if comment.Version != doc.Version {
+		// The steps since the suggestion are no longer available
+		return nil, nil, ErrTooOld
+	}
+
+	steps := make([]Step, len(comment.Suggestion.Steps))
+	for i, s := range comment.Suggestion.Steps {
+		steps[i] = s.Clone().(Step)
+		steps[i]["sessionID"] = sessionID
+		if author != "" {
+			steps[i]["author"] = author
+		}
+		steps[i]["suggestion"] = comment.ID()
+	}
+	if len(steps) > 0 {
+		if err := apply(inst, doc, steps); err != nil {
+			return nil, nil, err
+		}
+		if err := saveSteps(inst, steps); err != nil {
+			return nil, nil, err
+		}
+		publishSteps(inst, file.ID(), steps)
+		if err := saveToCache(inst, doc); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	now := time.Now().UTC()
+	comment.Version = doc.Version
+	comment.Suggestion.State = SuggestionAccepted
+	comment.Suggestion.DecidedBy = author
+	comment.Suggestion.DecidedAt = &now
+	comment.Resolved = true
+	comment.ResolvedBy = author
+	comment.ResolvedAt = &now
+	comment.UpdatedAt = now
+	if err := couchdb.UpdateDoc(inst, comment); err != nil {
+		return nil, nil, err
+	}
+	return doc.asFile(inst, file), comment, nil
+}
+
+// updateComment loads a comment, maps its anchor to the current version of the
+// note, calls fn to modify it and saves it.
+func updateComment(inst *instance.Instance, file *vfs.FileDoc, commentID string, fn func(c *Comment) error) (*Comment, error) {
+	lock := inst.NotesLock()
+	if err := lock.Lock(); err != nil {
+		return nil, err
+	}
+	defer lock.Unlock()
+
+	doc, err := get(inst, file)
+	if err != nil {
+		return nil, err
+	}
+	comment, err := getComment(inst, file.ID(), commentID)
+	if err != nil {
+		return nil, err
+	}
+	mapComments(inst, doc, []*Comment{comment}, true)
+	if err := fn(comment); err != nil {
+		return nil, err
+	}
+	comment.UpdatedAt = time.Now().UTC()
+	if err := couchdb.UpdateDoc(inst, comment); err != nil {
+		return nil, err
+	}
+	return comment, nil
+}
+
+func getComment(db prefixer.Prefixer, noteID, commentID string) (*Comment, error) {
+	var comment Comment
+	id := fmt.Sprintf("%s/%s", noteID, commentID)
+	err := couchdb.GetDoc(db, consts.NotesComments, id, &comment)
+	if couchdb.IsNotFoundError(err) || couchdb.IsNoDatabaseError(err) {
+		return nil, ErrCommentNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+func getComments(db prefixer.Prefixer, noteID string) ([]*Comment, error) {
+	var comments []*Comment
+	req := couchdb.AllDocsRequest{
+		Limit:    1000,
+		StartKey: startkey(noteID),
+		EndKey:   endkey(noteID),
+	}
+	err := couchdb.GetAllDocs(db, consts.NotesComments, &req, &comments)
+	if couchdb.IsNoDatabaseError(err) {
+		return []*Comment{}, nil
+	}
+	return comments, err
+}
+
+// checkSuggestedSteps verifies that the suggested steps can be applied on the
+// note at the given version. The steps are applied on a copy of the note and
+// the changes are not kept.
+func checkSuggestedSteps(inst *instance.Instance, doc *Document, version int64, steps []Step) error {
+	if version != doc.Version {
+		// The steps will be rebased when the comment is mapped.
+		return nil
+	}
+	copied := doc.Clone().(*Document)
+	cloned := make([]Step, len(steps))
+	for i, s := range steps {
+		cloned[i] = s.Clone().(Step)
+	}
+	return apply(inst, copied, cloned)
+}
+
+// cleanSuggestedStep removes the fields that are added when a step is applied,
+// as the suggested steps are not applied yet.
+func cleanSuggestedStep(s Step) {
+	delete(s, "_id")
+	delete(s, "_rev")
+	delete(s, "version")
+	delete(s, "timestamp")
+	delete(s, "sessionID")
+}
+
+// mapComments maps the anchors of the comments, and rebases the steps of the
+// pending suggestions, to the current version of the note. The comments that
+// have changed are saved if save is true: the reads don't write in CouchDB,
+// the comments are saved when the note is flushed, before the old steps are
+// purged.
+func mapComments(inst *instance.Instance, doc *Document, comments []*Comment, save bool) {
+	schema, err := doc.Schema()
+	if err != nil {
+		return
+	}
+	log := inst.Logger().WithNamespace("notes")
+	cache := make(map[int64][]transform.Step)
+	for _, c := range comments {
+		if c.Version >= doc.Version {
+			continue
+		}
+		concurrent, ok := cache[c.Version]
+		if !ok {
+			steps, err := getSteps(inst, doc.ID(), c.Version)
+			if err != nil {
+				log.Infof("Cannot map comment %s: %s", c.ID(), err)
+				continue
+			}
+			concurrent, err = stepsFromJSON(schema, steps)
+			if err != nil {
+				log.Infof("Cannot map comment %s: %s", c.ID(), err)
+				continue
+			}
+			cache[c.Version] = concurrent
+		}
+		if err := c.mapThrough(schema, concurrent); err != nil {
+			log.Infof("Cannot map comment %s: %s", c.ID(), err)
+			continue
+		}
+		c.Version = doc.Version
+		if !save || c.Rev() == "" {
+			continue
+		}
+		if err := couchdb.UpdateDoc(inst, c); err != nil {
+			log.Warnf("Cannot save comment %s: %s", c.ID(), err)
+		}
+	}
+}
+
+// mapThrough maps the anchor of the comment through the given steps, and
+// rebases the steps of the pending suggestion on them.
+func (c *Comment) mapThrough(schema *model.Schema, steps []transform.Step) error {
+	for _, s := range steps {
+		stepMap := s.GetMap()
+		from := stepMap.MapResult(c.From, 1)
+		to := stepMap.MapResult(c.To, -1)
+		c.From = from.Pos
+		c.To = to.Pos
+		if c.To < c.From {
+			c.To = c.From
+		}
+		if from.Deleted && to.Deleted {
+			c.Detached = true
+		}
+	}
+
+	if !c.isPendingSuggestion() {
+		return nil
+	}
+	suggested, err := stepsFromJSON(schema, c.Suggestion.Steps)
+	if err != nil {
+		return err
+	}
+	suggested = rebaseSteps(suggested, steps)
+	rebased := make([]Step, 0, len(suggested))
+	for _, s := range suggested {
+		rebased = append(rebased, Step(s.ToJSON()))
+	}
+	c.Suggestion.Steps = rebased
+	return nil
+}
+
+// rebaseSteps transforms the suggested steps, made on a version of the note,
+// so that they can be applied after the concurrent steps made on the same
+// version. Each concurrent step is mapped through the suggested steps while
+// they are mapped through it. The suggested steps that are entirely deleted
+// by the concurrent steps are dropped.
+func rebaseSteps(suggested, concurrent []transform.Step) []transform.Step {
+	for _, c := range concurrent {
+		next := make([]transform.Step, 0, len(suggested))
+		for _, s := range suggested {
+			if c == nil {
+				next = append(next, s)
+				continue
+			}
+			mapped := s.Map(c.GetMap())
+			c = c.Map(s.GetMap())
+			if mapped != nil {
+				next = append(next, mapped)
+			}
+		}
+		suggested = next
+	}
+	return suggested
+}
+
+func stepsFromJSON(schema *model.Schema, steps []Step) ([]transform.Step, error) {
+	result := make([]transform.Step, len(steps))
+	for i, s := range steps {
+		step, err := transform.StepFromJSON(schema, s)
+		if err != nil {
+			return nil, ErrInvalidSteps
+		}
+		result[i] = step
+	}
+	return result, nil
+}
+
+// notifyMentions sends a notification to the owner of the instance if they
+// are mentioned in the message.
+func notifyMentions(inst *instance.Instance, file *vfs.FileDoc, comment *Comment, msg *Message) {
+	if len(msg.Mentions) == 0 {
+		return
+	}
+	myself, err := contact.GetMyself(inst)
+	if err != nil {
+		return
+	}
+	mentioned := false
+	for _, id := range msg.Mentions {
+		if id == myself.ID() {
+			mentioned = true
+		}
+	}
+	if !mentioned {
+		return
+	}
+
+	title := strings.TrimSuffix(file.DocName, ".cozy-note")
+	author := msg.Author
+	if author == "" {
+		author = inst.Translate("Notification Note Mention Someone")
+	}
+	link := inst.SubDomain(consts.NotesSlug)
+	link.Fragment = "/n/" + file.ID()
+	subject := inst.Translate("Notification Note Mention Title", author, title)
+	n := &notification.Notification{
+		Title:   subject,
+		Message: msg.Text,
+		Content: fmt.Sprintf("%s\n\n%s\n\n%s", subject, msg.Text, link.String()),
+		ContentHTML: fmt.Sprintf(`<p>%s</p><blockquote>%s</blockquote><p><a href="%s">%s</a></p>`,
+			html.EscapeString(subject), html.EscapeString(msg.Text),
+			html.EscapeString(link.String()),
+			html.EscapeString(inst.Translate("Notification Note Mention Link"))),
+		PreferredChannels: []string{"mobile"},
+		Data: map[string]interface{}{
+			"note_id":    file.ID(),
+			"comment_id": comment.ID(),
+			"message_id": msg.ID,
+		},
+	}
+	if err := center.PushStack(inst.Domain, center.NotificationNoteMention, n); err != nil {
+		inst.Logger().WithNamespace("notes").
+			Warnf("Cannot send notification for mention: %s", err)
+	}
+}
+
+func purgeAllComments(db prefixer.Prefixer, noteID string) {
+	comments, err := getComments(db, noteID)
+	if err != nil || len(comments) == 0 {
+		return
+	}
+	docs := make([]couchdb.Doc, len(comments))
+	for i, c := range comments {
+		docs[i] = c
+	}
+	_ = couchdb.BulkDeleteDocs(db, consts.NotesComments, docs)
+}
+
+var _ jsonapi.Object = &Comment{}
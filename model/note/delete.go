@@ -38,5 +38,8 @@ func deleteNote(db prefixer.Prefixer, noteID string) {
 			}
 			_ = couchdb.BulkDeleteDocs(db, consts.NotesSteps, docs)
 		}
+
+		purgeAllVersions(db, noteID)
+		purgeAllComments(db, noteID)
 	}()
 }
@@ -20,4 +20,17 @@ var (
 	ErrTooOld = errors.New("The revision is too old")
 	// ErrMissingSessionID is used when a telepointer has no identifier.
 	ErrMissingSessionID = errors.New("The session id is missing")
+	// ErrVersionNotFound is used when there is no snapshot for the asked
+	// version of a note.
+	ErrVersionNotFound = errors.New("The version has not been found")
+	// ErrCommentNotFound is used when there is no comment thread with the
+	// given identifier for a note.
+	ErrCommentNotFound = errors.New("The comment has not been found")
+	// ErrInvalidAnchor is used when the positions of a comment are not valid.
+	ErrInvalidAnchor = errors.New("Invalid anchor for the comment")
+	// ErrEmptyComment is used when a comment has no text and no suggestion.
+	ErrEmptyComment = errors.New("The comment is empty")
+	// ErrNoPendingSuggestion is used when trying to accept or reject a
+	// comment that has no suggestion waiting for a decision.
+	ErrNoPendingSuggestion = errors.New("No pending suggestion for this comment")
 )
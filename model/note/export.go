@@ -0,0 +1,642 @@
+package note
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cozy/cozy-stack/model/instance"
+	"github.com/cozy/cozy-stack/model/vfs"
+	"github.com/cozy/cozy-stack/pkg/consts"
+	"github.com/cozy/prosemirror-go/model"
+
+	// Registers the decoders for reading the size of the images
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+)
+
+const (
+	// ExportHTML is the format for exporting a note to a standalone HTML page
+	ExportHTML = "html"
+	// ExportPDF is the format for exporting a note to a PDF document
+	ExportPDF = "pdf"
+	// ExportDOCX is the format for exporting a note to a Word document
+	ExportDOCX = "docx"
+	// ExportODT is the format for exporting a note to an OpenDocument text
+	ExportODT = "odt"
+)
+
+var (
+	// ErrUnknownExportFormat is used when the asked format for an export is
+	// not supported.
+	ErrUnknownExportFormat = errors.New("Unknown format for the export")
+	// ErrUnsupportedPDFCharacter is used when a note has some characters that
+	// can't be written in a PDF (only the latin-1 characters are available
+	// with the standard fonts). The note is exported to DOCX instead.
+	ErrUnsupportedPDFCharacter = errors.New("The note has some characters that can't be exported to PDF")
+)
+
+var exportMimeTypes = map[string]string{
+	ExportHTML: "text/html; charset=utf-8",
+	ExportPDF:  "application/pdf",
+	ExportDOCX: "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+	ExportODT:  "application/vnd.oasis.opendocument.text",
+}
+
+// ExportedNote is a note rendered to a document format.
+type ExportedNote struct {
+	Filename string
+	Mime     string
+	Content  []byte
+}
+
+// Export renders a note to the given format (html, pdf, docx or odt).
+func Export(inst *instance.Instance, file *vfs.FileDoc, format string) (*ExportedNote, error) {
+	mime, ok := exportMimeTypes[format]
+	if !ok {
+		return nil, ErrUnknownExportFormat
+	}
+	doc, images, err := getForExport(inst, file)
+	if err != nil {
+		return nil, err
+	}
+	content, err := doc.Content()
+	if err != nil {
+		return nil, err
+	}
+
+	exp := &exportDoc{
+		title:  doc.Title,
+		images: make(map[string]*exportImage),
+	}
+	for _, img := range images {
+		exp.images[img.ID()] = &exportImage{Image: img}
+	}
+	exp.blocks = exp.convertBlocks(content)
+	exp.loadImages(inst)
+
+	var buf bytes.Buffer
+	written, err := exp.write(&buf, format)
+	if err != nil {
+		return nil, err
+	}
+	if written != format {
+		inst.Logger().WithNamespace("notes").
+			Infof("Note %s exported to %s instead of %s", file.ID(), written, format)
+		mime = exportMimeTypes[written]
+	}
+
+	name := strings.TrimSuffix(file.DocName, ".cozy-note")
+	return &ExportedNote{
+		Filename: name + "." + written,
+		Mime:     mime,
+		Content:  buf.Bytes(),
+	}, nil
+}
+
+// write renders the note to the given format, and returns the format that
+// has been used. The standard fonts of the PDF readers only have the latin-1
+// characters, so a note with other characters is written as a DOCX document
+// instead of a PDF.
+func (exp *exportDoc) write(buf *bytes.Buffer, format string) (string, error) {
+	var err error
+	switch format {
+	case ExportHTML:
+		err = exp.writeHTML(buf)
+	case ExportPDF:
+		err = exp.writePDF(buf)
+		if errors.Is(err, ErrUnsupportedPDFCharacter) {
+			buf.Reset()
+			format = ExportDOCX
+			err = exp.writeDOCX(buf)
+		}
+	case ExportDOCX:
+		err = exp.writeDOCX(buf)
+	case ExportODT:
+		err = exp.writeODT(buf)
+	}
+	return format, err
+}
+
+// ExportDir renders all the notes of a directory to the given format, and
+// puts them in a zip archive, created in the same directory.
+func ExportDir(inst *instance.Instance, dirID, format string) (*vfs.FileDoc, error) {
+	if _, ok := exportMimeTypes[format]; !ok {
+		return nil, ErrUnknownExportFormat
+	}
+	fs := inst.VFS()
+	dir, err := fs.DirByID(dirID)
+	if err != nil {
+		return nil, err
+	}
+
+	var notes []*vfs.FileDoc
+	iter := fs.DirIterator(dir, nil)
+	for {
+		_, f, err := iter.Next()
+		if err == vfs.ErrIteratorDone {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if f != nil && f.Mime == consts.NoteMimeType {
+			notes = append(notes, f)
+		}
+	}
+	if len(notes) == 0 {
+		return nil, ErrInvalidFile
+	}
+
+	name := dir.DocName
+	if dirID == consts.RootDirID {
+		name = "Notes"
+	}
+	// The archive is written in a temporary file, and copied to the VFS only
+	// when all the notes have been exported, to not leave a partial archive
+	tmp, err := ioutil.TempFile("", "notes-export-*.zip")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		tmp.Close()
+		os.Remove(tmp.Name())
+	}()
+	if err := writeZip(inst, tmp, notes, format); err != nil {
+		return nil, err
+	}
+	size, err := tmp.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var zipDoc *vfs.FileDoc
+	var z vfs.File
+	for i := 1; ; i++ {
+		filename := fmt.Sprintf("%s (%s).zip", name, format)
+		if i > 1 {
+			filename = fmt.Sprintf("%s (%s) %d.zip", name, format, i)
+		}
+		zipDoc, err = vfs.NewFileDoc(filename, dirID, size, nil, "application/zip", "zip", now, false, false, nil)
+		if err != nil {
+			return nil, err
+		}
+		zipDoc.CozyMetadata = vfs.NewCozyMetadata(inst.PageURL("/", nil))
+		zipDoc.CozyMetadata.UploadedAt = &now
+		z, err = fs.CreateFile(zipDoc, nil)
+		if err == nil {
+			break
+		}
+		if !os.IsExist(err) || i >= 100 {
+			return nil, err
+		}
+	}
+	_, err = io.Copy(z, tmp)
+	if cerr := z.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return zipDoc, nil
+}
+
+// writeZip writes a zip archive with the notes exported to the given format.
+func writeZip(inst *instance.Instance, w io.Writer, notes []*vfs.FileDoc, format string) error {
+	zw := zip.NewWriter(w)
+	used := make(map[string]int)
+	for _, file := range notes {
+		exported, err := Export(inst, file, format)
+		if err != nil {
+			return err
+		}
+		filename := exported.Filename
+		if n := used[filename]; n > 0 {
+			ext := path.Ext(filename)
+			filename = fmt.Sprintf("%s (%d)%s", strings.TrimSuffix(filename, ext), n, ext)
+		}
+		used[exported.Filename]++
+		header := &zip.FileHeader{
+			Name:     filename,
+			Method:   zip.Deflate,
+			Modified: file.UpdatedAt,
+		}
+		f, err := zw.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		if _, err = f.Write(exported.Content); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+func getForExport(inst *instance.Instance, file *vfs.FileDoc) (*Document, []*Image, error) {
+	lock := inst.NotesLock()
+	if err := lock.Lock(); err != nil {
+		return nil, nil, err
+	}
+	defer lock.Unlock()
+
+	doc, err := get(inst, file)
+	if err != nil {
+		return nil, nil, err
+	}
+	images, err := getImages(inst, file.ID())
+	if err != nil {
+		return nil, nil, err
+	}
+	return doc, images, nil
+}
+
+// exportDoc is an intermediate representation of a note, simpler than the
+// prosemirror document, that is shared by the renderers of the formats.
+type exportDoc struct {
+	title  string
+	blocks []*exportBlock
+	images map[string]*exportImage
+}
+
+const (
+	blockParagraph   = "paragraph"
+	blockHeading     = "heading"
+	blockQuote       = "blockquote"
+	blockPanel       = "panel"
+	blockBulletList  = "bulletList"
+	blockOrderedList = "orderedList"
+	blockCode        = "codeBlock"
+	blockRule        = "rule"
+	blockTable       = "table"
+	blockImage       = "image"
+)
+
+type exportBlock struct {
+	kind     string
+	level    int    // for headings
+	align    string // for paragraphs and headings: "", "center" or "end"
+	panel    string // for panels: info, note, success, warning, error...
+	start    int    // for ordered lists
+	code     string // for code blocks
+	inlines  []exportInline
+	children []*exportBlock   // for blockquotes and panels
+	items    [][]*exportBlock // for lists
+	rows     [][]*exportCell  // for tables
+	image    *exportImage
+}
+
+type exportCell struct {
+	header     bool
+	colspan    int
+	rowspan    int
+	background string
+	blocks     []*exportBlock
+}
+
+type exportInline struct {
+	text      string
+	hardBreak bool
+	bold      bool
+	italic    bool
+	underline bool
+	strike    bool
+	code      bool
+	sub       bool
+	sup       bool
+	link      string
+	color     string
+}
+
+type exportImage struct {
+	*Image
+	data   []byte
+	mime   string
+	width  int
+	height int
+}
+
+// convertBlocks converts the children of a prosemirror node to blocks.
+func (e *exportDoc) convertBlocks(node *model.Node) []*exportBlock {
+	var blocks []*exportBlock
+	node.ForEach(func(child *model.Node, _, _ int) {
+		blocks = append(blocks, e.convertBlock(child)...)
+	})
+	return blocks
+}
+
+func (e *exportDoc) convertBlock(node *model.Node) []*exportBlock {
+	switch node.Type.Name {
+	case "paragraph":
+		return []*exportBlock{{
+			kind:    blockParagraph,
+			align:   alignment(node),
+			inlines: convertInlines(node),
+		}}
+	case "heading":
+		level, _ := node.Attrs["level"].(float64)
+		if level < 1 || level > 6 {
+			level = 1
+		}
+		return []*exportBlock{{
+			kind:    blockHeading,
+			level:   int(level),
+			align:   alignment(node),
+			inlines: convertInlines(node),
+		}}
+	case "blockquote":
+		return []*exportBlock{{kind: blockQuote, children: e.convertBlocks(node)}}
+	case "panel":
+		typ, _ := node.Attrs["panelType"].(string)
+		if typ == "" {
+			typ = "info"
+		}
+		return []*exportBlock{{kind: blockPanel, panel: typ, children: e.convertBlocks(node)}}
+	case "bulletList", "orderedList":
+		block := &exportBlock{kind: blockBulletList}
+		if node.Type.Name == "orderedList" {
+			block.kind = blockOrderedList
+			block.start = 1
+			if order, ok := node.Attrs["order"].(float64); ok && order > 0 {
+				block.start = int(order)
+			}
+		}
+		node.ForEach(func(item *model.Node, _, _ int) {
+			block.items = append(block.items, e.convertBlocks(item))
+		})
+		return []*exportBlock{block}
+	case "codeBlock":
+		return []*exportBlock{{kind: blockCode, code: node.TextContent()}}
+	case "rule":
+		return []*exportBlock{{kind: blockRule}}
+	case "table":
+		block := &exportBlock{kind: blockTable}
+		node.ForEach(func(row *model.Node, _, _ int) {
+			var cells []*exportCell
+			row.ForEach(func(cell *model.Node, _, _ int) {
+				c := &exportCell{
+					header:  cell.Type.Name == "tableHeader",
+					colspan: intAttr(cell, "colspan"),
+					rowspan: intAttr(cell, "rowspan"),
+					blocks:  e.convertBlocks(cell),
+				}
+				if color, ok := cell.Attrs["background"].(string); ok && strings.HasPrefix(color, "#") {
+					c.background = color
+				}
+				cells = append(cells, c)
+			})
+			block.rows = append(block.rows, cells)
+		})
+		return []*exportBlock{block}
+	case "mediaSingle":
+		return e.convertBlocks(node)
+	case "media":
+		id, _ := node.Attrs["url"].(string)
+		img, ok := e.images[id]
+		if !ok {
+			return nil
+		}
+		img.seen = true
+		return []*exportBlock{{kind: blockImage, image: img}}
+	}
+
+	// Unknown blocks are exported as paragraphs with their text
+	if text := node.TextContent(); text != "" {
+		return []*exportBlock{{
+			kind:    blockParagraph,
+			inlines: []exportInline{{text: text}},
+		}}
+	}
+	return nil
+}
+
+func convertInlines(node *model.Node) []exportInline {
+	var inlines []exportInline
+	node.ForEach(func(child *model.Node, _, _ int) {
+		var inline exportInline
+		switch child.Type.Name {
+		case "text":
+			if child.Text != nil {
+				inline.text = *child.Text
+			}
+		case "hardBreak":
+			inline.hardBreak = true
+		case "status":
+			txt, _ := child.Attrs["text"].(string)
+			inline.text = "[" + txt + "]"
+		case "date":
+			if ts, ok := child.Attrs["timestamp"].(string); ok {
+				if ms, err := strconv.ParseInt(ts, 10, 64); err == nil {
+					inline.text = time.Unix(ms/1000, 0).UTC().Format("2006-01-02")
+				}
+			}
+		default:
+			inline.text = child.TextContent()
+		}
+		if inline.text == "" && !inline.hardBreak {
+			return
+		}
+		for _, mark := range child.Marks {
+			switch mark.Type.Name {
+			case "strong":
+				inline.bold = true
+			case "em":
+				inline.italic = true
+			case "underline":
+				inline.underline = true
+			case "strike":
+				inline.strike = true
+			case "code":
+				inline.code = true
+			case "link":
+				inline.link, _ = mark.Attrs["href"].(string)
+			case "textColor":
+				inline.color, _ = mark.Attrs["color"].(string)
+			case "subsup":
+				switch mark.Attrs["type"] {
+				case "sub":
+					inline.sub = true
+				case "sup":
+					inline.sup = true
+				}
+			}
+		}
+		inlines = append(inlines, inline)
+	})
+	return inlines
+}
+
+func alignment(node *model.Node) string {
+	for _, mark := range node.Marks {
+		if mark.Type.Name == "alignment" {
+			if align, ok := mark.Attrs["align"].(string); ok {
+				return align
+			}
+		}
+	}
+	return ""
+}
+
+func intAttr(node *model.Node, name string) int {
+	switch v := node.Attrs[name].(type) {
+	case float64:
+		if v > 1 {
+			return int(v)
+		}
+	case string:
+		if n, err := strconv.Atoi(v); err == nil && n > 1 {
+			return n
+		}
+	}
+	return 1
+}
+
+// loadImages reads the content of the images used in the note. The resized
+// version is used when available.
+func (e *exportDoc) loadImages(inst *instance.Instance) {
+	fs := inst.ThumbsFS()
+	for _, img := range e.images {
+		if !img.seen {
+			continue
+		}
+		var data []byte
+		for _, format := range []string{consts.NoteImageThumbFormat, consts.NoteImageOriginalFormat} {
+			th, err := fs.OpenNoteThumb(img.ID(), format)
+			if err != nil {
+				continue
+			}
+			data, err = ioutil.ReadAll(th)
+			_ = th.Close()
+			if err == nil {
+				break
+			}
+		}
+		if len(data) == 0 {
+			inst.Logger().WithNamespace("notes").
+				Infof("Cannot read image %s for export", img.ID())
+			continue
+		}
+		cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+		if err != nil {
+			continue
+		}
+		img.data = data
+		img.mime = http.DetectContentType(data)
+		img.width = cfg.Width
+		img.height = cfg.Height
+	}
+}
+
+// ext returns the extension for the image file, from its mime type.
+func (img *exportImage) ext() string {
+	switch img.mime {
+	case "image/png":
+		return "png"
+	case "image/gif":
+		return "gif"
+	}
+	return "jpg"
+}
+
+// plainText returns the text of the inlines, without formatting.
+func plainText(inlines []exportInline) string {
+	var sb strings.Builder
+	for _, inline := range inlines {
+		if inline.hardBreak {
+			sb.WriteString("\n")
+		}
+		sb.WriteString(inline.text)
+	}
+	return sb.String()
+}
+
+// listMarker returns the bullet or the number for the nth item of a list.
+func listMarker(block *exportBlock, n int) string {
+	if block.kind == blockOrderedList {
+		return fmt.Sprintf("%d.", block.start+n)
+	}
+	return "•"
+}
+
+// panelColors are the background colors of the panels, by type.
+var panelColors = map[string]string{
+	"info":    "#deebff",
+	"note":    "#eae6ff",
+	"success": "#e3fcef",
+	"warning": "#fffae6",
+	"error":   "#ffebe6",
+}
+
+func panelColor(typ string) string {
+	if color, ok := panelColors[typ]; ok {
+		return color
+	}
+	return panelColors["info"]
+}
+
+// gridSlot is a cell of a table, placed on the grid of the columns. When a
+// cell spans several rows, there is a slot with continued for the next rows.
+type gridSlot struct {
+	cell      *exportCell
+	col       int
+	continued bool
+}
+
+// tableGrid places the cells of a table on a grid, to take care of the
+// colspan and rowspan. It returns the number of columns and the slots for
+// each row, sorted by column.
+func tableGrid(rows [][]*exportCell) (int, [][]gridSlot) {
+	nbCols := 0
+	occupied := make([]map[int]bool, len(rows))
+	for i := range occupied {
+		occupied[i] = make(map[int]bool)
+	}
+	grid := make([][]gridSlot, len(rows))
+	for r, row := range rows {
+		col := 0
+		for _, cell := range row {
+			for occupied[r][col] {
+				col++
+			}
+			grid[r] = append(grid[r], gridSlot{cell: cell, col: col})
+			for dr := 0; dr < cell.rowspan && r+dr < len(rows); dr++ {
+				for dc := 0; dc < cell.colspan; dc++ {
+					occupied[r+dr][col+dc] = true
+				}
+				if dr > 0 {
+					grid[r+dr] = append(grid[r+dr], gridSlot{cell: cell, col: col, continued: true})
+				}
+			}
+			col += cell.colspan
+			if col > nbCols {
+				nbCols = col
+			}
+		}
+	}
+	for r := range grid {
+		sortSlots(grid[r])
+	}
+	return nbCols, grid
+}
+
+func sortSlots(slots []gridSlot) {
+	for i := 1; i < len(slots); i++ {
+		for j := i; j > 0 && slots[j].col < slots[j-1].col; j-- {
+			slots[j], slots[j-1] = slots[j-1], slots[j]
+		}
+	}
+}
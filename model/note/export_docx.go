@@ -0,0 +1,417 @@
+package note
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	docxTwipsPerIndent = 720
+	docxMaxImageWidth  = 5760000 // 6 inches, in EMU
+	docxEMUPerPixel    = 9525
+)
+
+const docxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Default Extension="png" ContentType="image/png"/>
+<Default Extension="jpg" ContentType="image/jpeg"/>
+<Default Extension="gif" ContentType="image/gif"/>
+<Override PartName="/word/document.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"/>
+<Override PartName="/word/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.styles+xml"/>
+<Override PartName="/word/numbering.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.numbering+xml"/>
+<Override PartName="/docProps/core.xml" ContentType="application/vnd.openxmlformats-package.core-properties+xml"/>
+</Types>`
+
+const docxRootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="word/document.xml"/>
+<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/package/2006/relationships/metadata/core-properties" Target="docProps/core.xml"/>
+</Relationships>`
+
+const docxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:styles xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">
+<w:docDefaults><w:rPrDefault><w:rPr><w:rFonts w:ascii="Calibri" w:hAnsi="Calibri" w:cs="Calibri"/><w:sz w:val="22"/></w:rPr></w:rPrDefault>
+<w:pPrDefault><w:pPr><w:spacing w:after="120" w:line="276" w:lineRule="auto"/></w:pPr></w:pPrDefault></w:docDefaults>
+<w:style w:type="paragraph" w:default="1" w:styleId="Normal"><w:name w:val="Normal"/></w:style>
+<w:style w:type="paragraph" w:styleId="Title"><w:name w:val="Title"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:pPr><w:spacing w:after="240"/></w:pPr><w:rPr><w:b/><w:sz w:val="48"/></w:rPr></w:style>
+%s<w:style w:type="paragraph" w:styleId="Code"><w:name w:val="Code"/><w:basedOn w:val="Normal"/><w:pPr><w:spacing w:after="0" w:line="240" w:lineRule="auto"/><w:shd w:val="clear" w:color="auto" w:fill="F5F6F7"/></w:pPr><w:rPr><w:rFonts w:ascii="Courier New" w:hAnsi="Courier New" w:cs="Courier New"/><w:sz w:val="20"/></w:rPr></w:style>
+<w:style w:type="character" w:styleId="CodeChar"><w:name w:val="Code Char"/><w:rPr><w:rFonts w:ascii="Courier New" w:hAnsi="Courier New" w:cs="Courier New"/><w:shd w:val="clear" w:color="auto" w:fill="F5F6F7"/></w:rPr></w:style>
+<w:style w:type="character" w:styleId="Hyperlink"><w:name w:val="Hyperlink"/><w:rPr><w:color w:val="0563C1"/><w:u w:val="single"/></w:rPr></w:style>
+</w:styles>`
+
+const docxHeadingStyle = `<w:style w:type="paragraph" w:styleId="Heading%d"><w:name w:val="heading %d"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:pPr><w:keepNext/><w:spacing w:before="240" w:after="120"/><w:outlineLvl w:val="%d"/></w:pPr><w:rPr><w:b/><w:sz w:val="%d"/></w:rPr></w:style>
+`
+
+// docxWriter keeps the state for writing the document.xml part and its
+// relationships.
+type docxWriter struct {
+	sb      strings.Builder
+	rels    []string
+	media   map[*exportImage]string
+	nums    []string // the w:num elements for numbering.xml
+	drawing int
+}
+
+// docxContext is the context for writing a paragraph: the indentation, the
+// shading and the numbering of the enclosing blocks.
+type docxContext struct {
+	indent  int
+	fill    string
+	border  bool
+	numID   int
+	numLvl  int // the level of the list of the paragraph with numID
+	ilvl    int // the level for the next nested list
+	inTable bool
+}
+
+// writeDOCX renders the note to a Word document (Office Open XML).
+func (e *exportDoc) writeDOCX(w io.Writer) error {
+	dw := &docxWriter{media: make(map[*exportImage]string)}
+	dw.rels = append(dw.rels,
+		`<Relationship Id="rIdStyles" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`,
+		`<Relationship Id="rIdNumbering" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/numbering" Target="numbering.xml"/>`)
+	// The first num is shared by all the bullet lists
+	dw.nums = append(dw.nums, `<w:num w:numId="1"><w:abstractNumId w:val="0"/></w:num>`)
+
+	dw.sb.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships" xmlns:wp="http://schemas.openxmlformats.org/drawingml/2006/wordprocessingDrawing" xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main" xmlns:pic="http://schemas.openxmlformats.org/drawingml/2006/picture"><w:body>`)
+	if e.title != "" {
+		fmt.Fprintf(&dw.sb, `<w:p><w:pPr><w:pStyle w:val="Title"/></w:pPr><w:r><w:t xml:space="preserve">%s</w:t></w:r></w:p>`, xmlEscape(e.title))
+	}
+	dw.blocks(e.blocks, docxContext{})
+	dw.sb.WriteString(`<w:sectPr><w:pgSz w:w="11906" w:h="16838"/><w:pgMar w:top="1440" w:right="1440" w:bottom="1440" w:left="1440" w:header="708" w:footer="708" w:gutter="0"/></w:sectPr></w:body></w:document>`)
+
+	zw := zip.NewWriter(w)
+	var headings strings.Builder
+	sizes := []int{36, 32, 28, 26, 24, 22}
+	for i, size := range sizes {
+		fmt.Fprintf(&headings, docxHeadingStyle, i+1, i+1, i, size)
+	}
+	files := []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", docxContentTypes},
+		{"_rels/.rels", docxRootRels},
+		{"docProps/core.xml", docxCoreProperties(e.title)},
+		{"word/styles.xml", fmt.Sprintf(docxStyles, headings.String())},
+		{"word/numbering.xml", docxNumbering(dw.nums)},
+		{"word/document.xml", dw.sb.String()},
+		{"word/_rels/document.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			strings.Join(dw.rels, "") + `</Relationships>`},
+	}
+	for _, f := range files {
+		if err := zipFile(zw, f.name, []byte(f.content), zip.Deflate); err != nil {
+			return err
+		}
+	}
+	for img, name := range dw.media {
+		if err := zipFile(zw, "word/"+name, img.data, zip.Store); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+func (dw *docxWriter) blocks(blocks []*exportBlock, ctx docxContext) {
+	for _, block := range blocks {
+		dw.block(block, ctx)
+		// Only the first paragraph of a list item has the bullet
+		ctx.numID = 0
+	}
+}
+
+func (dw *docxWriter) block(block *exportBlock, ctx docxContext) {
+	switch block.kind {
+	case blockParagraph:
+		dw.paragraph(ctx, "", block.align, func() { dw.inlines(block.inlines) })
+	case blockHeading:
+		style := fmt.Sprintf("Heading%d", block.level)
+		dw.paragraph(ctx, style, block.align, func() { dw.inlines(block.inlines) })
+	case blockQuote:
+		ctx.indent += docxTwipsPerIndent
+		ctx.border = true
+		dw.blocks(block.children, ctx)
+	case blockPanel:
+		ctx.fill = strings.TrimPrefix(panelColor(block.panel), "#")
+		dw.blocks(block.children, ctx)
+	case blockBulletList, blockOrderedList:
+		numID := 1
+		if block.kind == blockOrderedList {
+			numID = len(dw.nums) + 1
+			dw.nums = append(dw.nums, fmt.Sprintf(
+				`<w:num w:numId="%d"><w:abstractNumId w:val="1"/><w:lvlOverride w:ilvl="%d"><w:startOverride w:val="%d"/></w:lvlOverride></w:num>`,
+				numID, ctx.ilvl, block.start))
+		}
+		for _, item := range block.items {
+			itemCtx := ctx
+			itemCtx.numID = numID
+			itemCtx.numLvl = ctx.ilvl
+			itemCtx.ilvl = ctx.ilvl + 1
+			itemCtx.indent += docxTwipsPerIndent
+			if len(item) == 0 {
+				dw.paragraph(itemCtx, "", "", func() {})
+				continue
+			}
+			for _, child := range item {
+				dw.block(child, itemCtx)
+				itemCtx.numID = 0
+			}
+		}
+	case blockCode:
+		lines := strings.Split(strings.TrimSuffix(block.code, "\n"), "\n")
+		for _, line := range lines {
+			dw.paragraph(ctx, "Code", "", func() {
+				fmt.Fprintf(&dw.sb, `<w:r><w:t xml:space="preserve">%s</w:t></w:r>`, xmlEscape(line))
+			})
+		}
+	case blockRule:
+		dw.sb.WriteString(`<w:p><w:pPr><w:pBdr><w:bottom w:val="single" w:sz="6" w:space="1" w:color="D6D8DA"/></w:pBdr></w:pPr></w:p>`)
+	case blockTable:
+		dw.table(block, ctx)
+	case blockImage:
+		if block.image.data == nil {
+			return
+		}
+		dw.paragraph(ctx, "", "", func() { dw.image(block.image) })
+	}
+}
+
+func (dw *docxWriter) paragraph(ctx docxContext, style, align string, content func()) {
+	dw.sb.WriteString("<w:p><w:pPr>")
+	if style != "" {
+		fmt.Fprintf(&dw.sb, `<w:pStyle w:val="%s"/>`, style)
+	}
+	if ctx.numID > 0 {
+		fmt.Fprintf(&dw.sb, `<w:numPr><w:ilvl w:val="%d"/><w:numId w:val="%d"/></w:numPr>`, ctx.numLvl, ctx.numID)
+	}
+	if ctx.border {
+		dw.sb.WriteString(`<w:pBdr><w:left w:val="single" w:sz="24" w:space="8" w:color="D6D8DA"/></w:pBdr>`)
+	}
+	if ctx.fill != "" {
+		fmt.Fprintf(&dw.sb, `<w:shd w:val="clear" w:color="auto" w:fill="%s"/>`, ctx.fill)
+	}
+	if ctx.indent > 0 {
+		if ctx.numID > 0 {
+			fmt.Fprintf(&dw.sb, `<w:ind w:left="%d" w:hanging="360"/>`, ctx.indent)
+		} else {
+			fmt.Fprintf(&dw.sb, `<w:ind w:left="%d"/>`, ctx.indent)
+		}
+	}
+	switch align {
+	case "center":
+		dw.sb.WriteString(`<w:jc w:val="center"/>`)
+	case "end":
+		dw.sb.WriteString(`<w:jc w:val="right"/>`)
+	}
+	dw.sb.WriteString("</w:pPr>")
+	content()
+	dw.sb.WriteString("</w:p>")
+}
+
+func (dw *docxWriter) inlines(inlines []exportInline) {
+	for _, inline := range inlines {
+		if inline.hardBreak {
+			dw.sb.WriteString("<w:r><w:br/></w:r>")
+			continue
+		}
+		if inline.link != "" {
+			id := dw.addRel(`Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/hyperlink" Target="` +
+				xmlEscape(inline.link) + `" TargetMode="External"`)
+			fmt.Fprintf(&dw.sb, `<w:hyperlink r:id="%s">`, id)
+		}
+		dw.sb.WriteString("<w:r><w:rPr>")
+		if inline.code {
+			dw.sb.WriteString(`<w:rStyle w:val="CodeChar"/>`)
+		} else if inline.link != "" {
+			dw.sb.WriteString(`<w:rStyle w:val="Hyperlink"/>`)
+		}
+		if inline.bold {
+			dw.sb.WriteString("<w:b/>")
+		}
+		if inline.italic {
+			dw.sb.WriteString("<w:i/>")
+		}
+		if inline.strike {
+			dw.sb.WriteString("<w:strike/>")
+		}
+		if inline.color != "" && len(inline.color) == 7 {
+			fmt.Fprintf(&dw.sb, `<w:color w:val="%s"/>`, xmlEscape(inline.color[1:]))
+		}
+		if inline.underline {
+			dw.sb.WriteString(`<w:u w:val="single"/>`)
+		}
+		if inline.sub {
+			dw.sb.WriteString(`<w:vertAlign w:val="subscript"/>`)
+		} else if inline.sup {
+			dw.sb.WriteString(`<w:vertAlign w:val="superscript"/>`)
+		}
+		fmt.Fprintf(&dw.sb, `</w:rPr><w:t xml:space="preserve">%s</w:t></w:r>`, xmlEscape(inline.text))
+		if inline.link != "" {
+			dw.sb.WriteString("</w:hyperlink>")
+		}
+	}
+}
+
+func (dw *docxWriter) table(block *exportBlock, ctx docxContext) {
+	nbCols, grid := tableGrid(block.rows)
+	if nbCols == 0 {
+		return
+	}
+	width := 9026 - ctx.indent // the width of the page without the margins
+	colWidth := width / nbCols
+	dw.sb.WriteString(`<w:tbl><w:tblPr>`)
+	fmt.Fprintf(&dw.sb, `<w:tblW w:w="%d" w:type="dxa"/>`, width)
+	if ctx.indent > 0 {
+		fmt.Fprintf(&dw.sb, `<w:tblInd w:w="%d" w:type="dxa"/>`, ctx.indent)
+	}
+	dw.sb.WriteString(`<w:tblBorders>`)
+	for _, side := range []string{"top", "left", "bottom", "right", "insideH", "insideV"} {
+		fmt.Fprintf(&dw.sb, `<w:%s w:val="single" w:sz="4" w:space="0" w:color="D6D8DA"/>`, side)
+	}
+	dw.sb.WriteString(`</w:tblBorders></w:tblPr><w:tblGrid>`)
+	for i := 0; i < nbCols; i++ {
+		fmt.Fprintf(&dw.sb, `<w:gridCol w:w="%d"/>`, colWidth)
+	}
+	dw.sb.WriteString(`</w:tblGrid>`)
+
+	cellCtx := docxContext{inTable: true}
+	for _, row := range grid {
+		dw.sb.WriteString("<w:tr>")
+		for _, slot := range row {
+			cell := slot.cell
+			fmt.Fprintf(&dw.sb, `<w:tc><w:tcPr><w:tcW w:w="%d" w:type="dxa"/>`, colWidth*cell.colspan)
+			if cell.colspan > 1 {
+				fmt.Fprintf(&dw.sb, `<w:gridSpan w:val="%d"/>`, cell.colspan)
+			}
+			if slot.continued {
+				dw.sb.WriteString(`<w:vMerge/></w:tcPr><w:p/></w:tc>`)
+				continue
+			}
+			if cell.rowspan > 1 {
+				dw.sb.WriteString(`<w:vMerge w:val="restart"/>`)
+			}
+			fill := strings.TrimPrefix(cell.background, "#")
+			if fill == "" && cell.header {
+				fill = "F5F6F7"
+			}
+			if fill != "" {
+				fmt.Fprintf(&dw.sb, `<w:shd w:val="clear" w:color="auto" w:fill="%s"/>`, xmlEscape(fill))
+			}
+			dw.sb.WriteString("</w:tcPr>")
+			if len(cell.blocks) == 0 {
+				dw.sb.WriteString("<w:p/>")
+			}
+			blocks := cell.blocks
+			if cell.header {
+				blocks = boldBlocks(blocks)
+			}
+			dw.blocks(blocks, cellCtx)
+			// A cell must end with a paragraph
+			if len(cell.blocks) > 0 && cell.blocks[len(cell.blocks)-1].kind == blockTable {
+				dw.sb.WriteString("<w:p/>")
+			}
+			dw.sb.WriteString("</w:tc>")
+		}
+		dw.sb.WriteString("</w:tr>")
+	}
+	dw.sb.WriteString("</w:tbl>")
+	if !ctx.inTable {
+		// Two consecutive tables would be merged without a paragraph
+		dw.sb.WriteString("<w:p/>")
+	}
+}
+
+func (dw *docxWriter) image(img *exportImage) {
+	name, ok := dw.media[img]
+	if !ok {
+		name = fmt.Sprintf("media/image%d.%s", len(dw.media)+1, img.ext())
+		dw.media[img] = name
+	}
+	id := dw.addRel(`Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/image" Target="` + name + `"`)
+	cx := img.width * docxEMUPerPixel
+	cy := img.height * docxEMUPerPixel
+	if cx > docxMaxImageWidth {
+		cy = cy * docxMaxImageWidth / cx
+		cx = docxMaxImageWidth
+	}
+	dw.drawing++
+	fmt.Fprintf(&dw.sb, `<w:r><w:drawing><wp:inline distT="0" distB="0" distL="0" distR="0"><wp:extent cx="%d" cy="%d"/><wp:docPr id="%d" name="Picture %d" descr="%s"/><a:graphic><a:graphicData uri="http://schemas.openxmlformats.org/drawingml/2006/picture"><pic:pic><pic:nvPicPr><pic:cNvPr id="%d" name="%s"/><pic:cNvPicPr/></pic:nvPicPr><pic:blipFill><a:blip r:embed="%s"/><a:stretch><a:fillRect/></a:stretch></pic:blipFill><pic:spPr><a:xfrm><a:off x="0" y="0"/><a:ext cx="%d" cy="%d"/></a:xfrm><a:prstGeom prst="rect"><a:avLst/></a:prstGeom></pic:spPr></pic:pic></a:graphicData></a:graphic></wp:inline></w:drawing></w:r>`,
+		cx, cy, dw.drawing, dw.drawing, xmlEscape(img.Name), dw.drawing, xmlEscape(img.Name), id, cx, cy)
+}
+
+func (dw *docxWriter) addRel(attrs string) string {
+	id := fmt.Sprintf("rId%d", len(dw.rels)+1)
+	dw.rels = append(dw.rels, fmt.Sprintf(`<Relationship Id="%s" %s/>`, id, attrs))
+	return id
+}
+
+func docxNumbering(nums []string) string {
+	var sb strings.Builder
+	sb.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:numbering xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">`)
+	for abstract, format := range []string{"bullet", "decimal"} {
+		fmt.Fprintf(&sb, `<w:abstractNum w:abstractNumId="%d"><w:multiLevelType w:val="hybridMultilevel"/>`, abstract)
+		for lvl := 0; lvl < 9; lvl++ {
+			text := "•"
+			if format == "decimal" {
+				text = fmt.Sprintf("%%%d.", lvl+1)
+			}
+			fmt.Fprintf(&sb, `<w:lvl w:ilvl="%d"><w:start w:val="1"/><w:numFmt w:val="%s"/><w:lvlText w:val="%s"/><w:lvlJc w:val="left"/><w:pPr><w:ind w:left="%d" w:hanging="360"/></w:pPr></w:lvl>`,
+				lvl, format, text, docxTwipsPerIndent*(lvl+1))
+		}
+		sb.WriteString(`</w:abstractNum>`)
+	}
+	sb.WriteString(strings.Join(nums, ""))
+	sb.WriteString(`</w:numbering>`)
+	return sb.String()
+}
+
+func docxCoreProperties(title string) string {
+	now := time.Now().UTC().Format(time.RFC3339)
+	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties" xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:dcterms="http://purl.org/dc/terms/" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance"><dc:title>%s</dc:title><dcterms:created xsi:type="dcterms:W3CDTF">%s</dcterms:created><dcterms:modified xsi:type="dcterms:W3CDTF">%s</dcterms:modified></cp:coreProperties>`,
+		xmlEscape(title), now, now)
+}
+
+// boldBlocks returns a copy of the blocks with the text in bold, for the
+// header cells of the tables.
+func boldBlocks(blocks []*exportBlock) []*exportBlock {
+	bolded := make([]*exportBlock, len(blocks))
+	for i, block := range blocks {
+		copied := *block
+		copied.inlines = make([]exportInline, len(block.inlines))
+		for j, inline := range block.inlines {
+			inline.bold = true
+			copied.inlines[j] = inline
+		}
+		bolded[i] = &copied
+	}
+	return bolded
+}
+
+func zipFile(zw *zip.Writer, name string, content []byte, method uint16) error {
+	f, err := zw.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   method,
+		Modified: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(content)
+	return err
+}
+
+func xmlEscape(s string) string {
+	var sb strings.Builder
+	_ = xml.EscapeText(&sb, []byte(s))
+	return sb.String()
+}
@@ -0,0 +1,176 @@
+package note
+
+import (
+	"encoding/base64"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+)
+
+const htmlStyle = `body { font-family: sans-serif; line-height: 1.5; max-width: 50em; margin: 2em auto; padding: 0 1em; color: #32363f; }
+pre { background: #f5f6f7; padding: .5em 1em; overflow-x: auto; }
+code { font-family: monospace; }
+blockquote { border-left: 4px solid #d6d8da; margin-left: 0; padding-left: 1em; color: #5d6165; }
+table { border-collapse: collapse; margin: 1em 0; }
+th, td { border: 1px solid #d6d8da; padding: .4em .6em; vertical-align: top; }
+th { background: #f5f6f7; }
+img { max-width: 100%; }
+.panel { border-radius: 4px; padding: .5em 1em; margin: 1em 0; }
+.panel-info { background: #deebff; }
+.panel-note { background: #eae6ff; }
+.panel-success { background: #e3fcef; }
+.panel-warning { background: #fffae6; }
+.panel-error { background: #ffebe6; }
+`
+
+// writeHTML renders the note to a standalone HTML page. The images are
+// embedded in the page with data URIs.
+func (e *exportDoc) writeHTML(w io.Writer) error {
+	var sb strings.Builder
+	sb.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+	fmt.Fprintf(&sb, "<title>%s</title>\n", html.EscapeString(e.title))
+	fmt.Fprintf(&sb, "<style>\n%s</style>\n</head>\n<body>\n", htmlStyle)
+	if e.title != "" {
+		fmt.Fprintf(&sb, "<h1>%s</h1>\n", html.EscapeString(e.title))
+	}
+	htmlBlocks(&sb, e.blocks)
+	sb.WriteString("</body>\n</html>\n")
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func htmlBlocks(sb *strings.Builder, blocks []*exportBlock) {
+	for _, block := range blocks {
+		htmlBlock(sb, block)
+	}
+}
+
+func htmlBlock(sb *strings.Builder, block *exportBlock) {
+	switch block.kind {
+	case blockParagraph:
+		fmt.Fprintf(sb, "<p%s>", htmlAlign(block.align))
+		htmlInlines(sb, block.inlines)
+		sb.WriteString("</p>\n")
+	case blockHeading:
+		fmt.Fprintf(sb, "<h%d%s>", block.level, htmlAlign(block.align))
+		htmlInlines(sb, block.inlines)
+		fmt.Fprintf(sb, "</h%d>\n", block.level)
+	case blockQuote:
+		sb.WriteString("<blockquote>\n")
+		htmlBlocks(sb, block.children)
+		sb.WriteString("</blockquote>\n")
+	case blockPanel:
+		fmt.Fprintf(sb, "<div class=\"panel panel-%s\">\n", html.EscapeString(block.panel))
+		htmlBlocks(sb, block.children)
+		sb.WriteString("</div>\n")
+	case blockBulletList, blockOrderedList:
+		tag := "ul"
+		attrs := ""
+		if block.kind == blockOrderedList {
+			tag = "ol"
+			if block.start != 1 {
+				attrs = fmt.Sprintf(" start=\"%d\"", block.start)
+			}
+		}
+		fmt.Fprintf(sb, "<%s%s>\n", tag, attrs)
+		for _, item := range block.items {
+			sb.WriteString("<li>")
+			htmlBlocks(sb, item)
+			sb.WriteString("</li>\n")
+		}
+		fmt.Fprintf(sb, "</%s>\n", tag)
+	case blockCode:
+		fmt.Fprintf(sb, "<pre><code>%s</code></pre>\n", html.EscapeString(block.code))
+	case blockRule:
+		sb.WriteString("<hr>\n")
+	case blockTable:
+		sb.WriteString("<table>\n")
+		for _, row := range block.rows {
+			sb.WriteString("<tr>")
+			for _, cell := range row {
+				tag := "td"
+				if cell.header {
+					tag = "th"
+				}
+				var attrs string
+				if cell.colspan > 1 {
+					attrs += fmt.Sprintf(" colspan=\"%d\"", cell.colspan)
+				}
+				if cell.rowspan > 1 {
+					attrs += fmt.Sprintf(" rowspan=\"%d\"", cell.rowspan)
+				}
+				if cell.background != "" {
+					attrs += fmt.Sprintf(" style=\"background: %s\"", html.EscapeString(cell.background))
+				}
+				fmt.Fprintf(sb, "<%s%s>", tag, attrs)
+				htmlBlocks(sb, cell.blocks)
+				fmt.Fprintf(sb, "</%s>", tag)
+			}
+			sb.WriteString("</tr>\n")
+		}
+		sb.WriteString("</table>\n")
+	case blockImage:
+		img := block.image
+		if img.data == nil {
+			return
+		}
+		src := "data:" + img.mime + ";base64," + base64.StdEncoding.EncodeToString(img.data)
+		fmt.Fprintf(sb, "<p><img src=\"%s\" alt=\"%s\" width=\"%d\" height=\"%d\"></p>\n",
+			src, html.EscapeString(img.Name), img.width, img.height)
+	}
+}
+
+func htmlAlign(align string) string {
+	switch align {
+	case "center":
+		return ` style="text-align: center"`
+	case "end":
+		return ` style="text-align: right"`
+	}
+	return ""
+}
+
+func htmlInlines(sb *strings.Builder, inlines []exportInline) {
+	for _, inline := range inlines {
+		if inline.hardBreak {
+			sb.WriteString("<br>")
+			continue
+		}
+		var open, close []string
+		wrap := func(o, c string) {
+			open = append(open, o)
+			close = append([]string{c}, close...)
+		}
+		if inline.link != "" {
+			wrap(fmt.Sprintf("<a href=\"%s\">", html.EscapeString(inline.link)), "</a>")
+		}
+		if inline.bold {
+			wrap("<strong>", "</strong>")
+		}
+		if inline.italic {
+			wrap("<em>", "</em>")
+		}
+		if inline.underline {
+			wrap("<u>", "</u>")
+		}
+		if inline.strike {
+			wrap("<s>", "</s>")
+		}
+		if inline.code {
+			wrap("<code>", "</code>")
+		}
+		if inline.sub {
+			wrap("<sub>", "</sub>")
+		}
+		if inline.sup {
+			wrap("<sup>", "</sup>")
+		}
+		if inline.color != "" {
+			wrap(fmt.Sprintf("<span style=\"color: %s\">", html.EscapeString(inline.color)), "</span>")
+		}
+		sb.WriteString(strings.Join(open, ""))
+		sb.WriteString(html.EscapeString(inline.text))
+		sb.WriteString(strings.Join(close, ""))
+	}
+}
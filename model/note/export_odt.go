@@ -0,0 +1,398 @@
+package note
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	odtMaxImageWidth = 16.0 // in cm
+	odtCmPerPixel    = 2.54 / 96
+)
+
+const odtNamespaces = `xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0" xmlns:style="urn:oasis:names:tc:opendocument:xmlns:style:1.0" xmlns:text="urn:oasis:names:tc:opendocument:xmlns:text:1.0" xmlns:table="urn:oasis:names:tc:opendocument:xmlns:table:1.0" xmlns:draw="urn:oasis:names:tc:opendocument:xmlns:drawing:1.0" xmlns:fo="urn:oasis:names:tc:opendocument:xmlns:xsl-fo-compatible:1.0" xmlns:xlink="http://www.w3.org/1999/xlink" xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:meta="urn:oasis:names:tc:opendocument:xmlns:meta:1.0" xmlns:svg="urn:oasis:names:tc:opendocument:xmlns:svg-compatible:1.0" office:version="1.2"`
+
+const odtStyles = `<?xml version="1.0" encoding="UTF-8"?>
+<office:document-styles ` + odtNamespaces + `>
+<office:font-face-decls>
+<style:font-face style:name="Liberation Sans" svg:font-family="'Liberation Sans'" style:font-family-generic="swiss"/>
+<style:font-face style:name="Liberation Mono" svg:font-family="'Liberation Mono'" style:font-family-generic="modern" style:font-pitch="fixed"/>
+</office:font-face-decls>
+<office:styles>
+<style:default-style style:family="paragraph"><style:paragraph-properties fo:margin-bottom="0.2cm"/><style:text-properties style:font-name="Liberation Sans" fo:font-size="11pt"/></style:default-style>
+<style:style style:name="Standard" style:family="paragraph" style:class="text"/>
+<style:style style:name="Title" style:family="paragraph" style:parent-style-name="Standard"><style:paragraph-properties fo:margin-bottom="0.4cm"/><style:text-properties fo:font-size="24pt" fo:font-weight="bold"/></style:style>
+%s<style:style style:name="Preformatted_20_Text" style:display-name="Preformatted Text" style:family="paragraph" style:parent-style-name="Standard"><style:paragraph-properties fo:margin-bottom="0cm" fo:background-color="#f5f6f7"/><style:text-properties style:font-name="Liberation Mono" fo:font-size="10pt"/></style:style>
+<style:style style:name="Quotations" style:family="paragraph" style:parent-style-name="Standard"><style:paragraph-properties fo:margin-left="1cm" fo:padding-left="0.3cm" fo:border-left="0.1cm solid #d6d8da"/></style:style>
+<style:style style:name="Horizontal_20_Line" style:display-name="Horizontal Line" style:family="paragraph" style:parent-style-name="Standard"><style:paragraph-properties fo:border-bottom="0.05pt solid #d6d8da"/></style:style>
+<style:style style:name="Table_20_Heading" style:display-name="Table Heading" style:family="paragraph" style:parent-style-name="Standard"><style:text-properties fo:font-weight="bold"/></style:style>
+<style:style style:name="Source_20_Text" style:display-name="Source Text" style:family="text"><style:text-properties style:font-name="Liberation Mono" fo:background-color="#f5f6f7"/></style:style>
+<style:style style:name="Internet_20_link" style:display-name="Internet link" style:family="text"><style:text-properties fo:color="#0563c1" style:text-underline-style="solid" style:text-underline-width="auto" style:text-underline-color="font-color"/></style:style>
+</office:styles>
+<office:automatic-styles>
+<style:page-layout style:name="pm1"><style:page-layout-properties fo:page-width="21cm" fo:page-height="29.7cm" fo:margin-top="2cm" fo:margin-bottom="2cm" fo:margin-left="2.5cm" fo:margin-right="2.5cm"/></style:page-layout>
+</office:automatic-styles>
+<office:master-styles><style:master-page style:name="Standard" style:page-layout-name="pm1"/></office:master-styles>
+</office:document-styles>`
+
+const odtHeadingStyle = `<style:style style:name="Heading_20_%d" style:display-name="Heading %d" style:family="paragraph" style:parent-style-name="Standard" style:default-outline-level="%d"><style:paragraph-properties fo:margin-top="0.4cm" fo:margin-bottom="0.2cm" fo:keep-with-next="always"/><style:text-properties fo:font-size="%dpt" fo:font-weight="bold"/></style:style>
+`
+
+// odtWriter keeps the state for writing the content.xml part of an
+// OpenDocument text: the automatic styles are generated while the body is
+// written.
+type odtWriter struct {
+	body   strings.Builder
+	styles strings.Builder
+	text   map[string]string // the text styles, by their properties
+	para   map[string]string // the paragraph styles, by their properties
+	media  map[*exportImage]string
+	tables int
+}
+
+// writeODT renders the note to an OpenDocument text.
+func (e *exportDoc) writeODT(w io.Writer) error {
+	ow := &odtWriter{
+		text:  make(map[string]string),
+		para:  make(map[string]string),
+		media: make(map[*exportImage]string),
+	}
+	ow.styles.WriteString(`<text:list-style style:name="LBullet">`)
+	for lvl := 1; lvl <= 10; lvl++ {
+		fmt.Fprintf(&ow.styles, `<text:list-level-style-bullet text:level="%d" text:bullet-char="•"><style:list-level-properties text:list-level-position-and-space-mode="label-alignment"><style:list-level-label-alignment text:label-followed-by="listtab" fo:text-indent="-0.5cm" fo:margin-left="%.2fcm"/></style:list-level-properties></text:list-level-style-bullet>`, lvl, float64(lvl)*1.0)
+	}
+	ow.styles.WriteString(`</text:list-style><text:list-style style:name="LNumber">`)
+	for lvl := 1; lvl <= 10; lvl++ {
+		fmt.Fprintf(&ow.styles, `<text:list-level-style-number text:level="%d" style:num-suffix="." style:num-format="1"><style:list-level-properties text:list-level-position-and-space-mode="label-alignment"><style:list-level-label-alignment text:label-followed-by="listtab" fo:text-indent="-0.5cm" fo:margin-left="%.2fcm"/></style:list-level-properties></text:list-level-style-number>`, lvl, float64(lvl)*1.0)
+	}
+	ow.styles.WriteString(`</text:list-style>`)
+
+	if e.title != "" {
+		fmt.Fprintf(&ow.body, `<text:p text:style-name="Title">%s</text:p>`, odtText(e.title))
+	}
+	ow.blocks(e.blocks, "")
+
+	content := `<?xml version="1.0" encoding="UTF-8"?>
+<office:document-content ` + odtNamespaces + `><office:automatic-styles>` +
+		ow.styles.String() + `</office:automatic-styles><office:body><office:text>` +
+		ow.body.String() + `</office:text></office:body></office:document-content>`
+
+	var headings strings.Builder
+	sizes := []int{18, 16, 14, 13, 12, 11}
+	for i, size := range sizes {
+		fmt.Fprintf(&headings, odtHeadingStyle, i+1, i+1, i+1, size)
+	}
+
+	var manifest strings.Builder
+	manifest.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<manifest:manifest xmlns:manifest="urn:oasis:names:tc:opendocument:xmlns:manifest:1.0" manifest:version="1.2">
+<manifest:file-entry manifest:full-path="/" manifest:version="1.2" manifest:media-type="application/vnd.oasis.opendocument.text"/>
+<manifest:file-entry manifest:full-path="content.xml" manifest:media-type="text/xml"/>
+<manifest:file-entry manifest:full-path="styles.xml" manifest:media-type="text/xml"/>
+<manifest:file-entry manifest:full-path="meta.xml" manifest:media-type="text/xml"/>
+`)
+	for img, name := range ow.media {
+		fmt.Fprintf(&manifest, `<manifest:file-entry manifest:full-path="%s" manifest:media-type="%s"/>
+`, name, img.mime)
+	}
+	manifest.WriteString(`</manifest:manifest>`)
+
+	meta := `<?xml version="1.0" encoding="UTF-8"?>
+<office:document-meta ` + odtNamespaces + `><office:meta><dc:title>` + xmlEscape(e.title) +
+		`</dc:title><meta:generator>Cozy</meta:generator></office:meta></office:document-meta>`
+
+	zw := zip.NewWriter(w)
+	// The mimetype must be the first file, and it must not be compressed
+	if err := zipFile(zw, "mimetype", []byte(exportMimeTypes[ExportODT]), zip.Store); err != nil {
+		return err
+	}
+	files := []struct {
+		name    string
+		content string
+	}{
+		{"META-INF/manifest.xml", manifest.String()},
+		{"meta.xml", meta},
+		{"styles.xml", fmt.Sprintf(odtStyles, headings.String())},
+		{"content.xml", content},
+	}
+	for _, f := range files {
+		if err := zipFile(zw, f.name, []byte(f.content), zip.Deflate); err != nil {
+			return err
+		}
+	}
+	for img, name := range ow.media {
+		if err := zipFile(zw, name, img.data, zip.Store); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// blocks writes the blocks. The parent is the name of the paragraph style
+// for the paragraphs (used for the quotations, the panels and the table
+// headers).
+func (ow *odtWriter) blocks(blocks []*exportBlock, parent string) {
+	for _, block := range blocks {
+		ow.block(block, parent)
+	}
+}
+
+func (ow *odtWriter) block(block *exportBlock, parent string) {
+	switch block.kind {
+	case blockParagraph:
+		style := ow.paragraphStyle(parent, block.align)
+		fmt.Fprintf(&ow.body, `<text:p text:style-name="%s">`, style)
+		ow.inlines(block.inlines)
+		ow.body.WriteString(`</text:p>`)
+	case blockHeading:
+		style := ow.paragraphStyle(fmt.Sprintf("Heading_20_%d", block.level), block.align)
+		fmt.Fprintf(&ow.body, `<text:h text:style-name="%s" text:outline-level="%d">`, style, block.level)
+		ow.inlines(block.inlines)
+		ow.body.WriteString(`</text:h>`)
+	case blockQuote:
+		ow.blocks(block.children, "Quotations")
+	case blockPanel:
+		props := fmt.Sprintf(`<style:paragraph-properties fo:background-color="%s" fo:padding="0.1cm"/>`, panelColor(block.panel))
+		name := ow.automaticParagraphStyle("Standard", props)
+		ow.blocks(block.children, name)
+	case blockBulletList, blockOrderedList:
+		style := "LBullet"
+		if block.kind == blockOrderedList {
+			style = "LNumber"
+		}
+		fmt.Fprintf(&ow.body, `<text:list text:style-name="%s">`, style)
+		for i, item := range block.items {
+			if i == 0 && block.kind == blockOrderedList && block.start != 1 {
+				fmt.Fprintf(&ow.body, `<text:list-item text:start-value="%d">`, block.start)
+			} else {
+				ow.body.WriteString(`<text:list-item>`)
+			}
+			if len(item) == 0 {
+				ow.body.WriteString(`<text:p/>`)
+			}
+			ow.blocks(item, parent)
+			ow.body.WriteString(`</text:list-item>`)
+		}
+		ow.body.WriteString(`</text:list>`)
+	case blockCode:
+		lines := strings.Split(strings.TrimSuffix(block.code, "\n"), "\n")
+		for _, line := range lines {
+			fmt.Fprintf(&ow.body, `<text:p text:style-name="Preformatted_20_Text">%s</text:p>`, odtText(line))
+		}
+	case blockRule:
+		ow.body.WriteString(`<text:p text:style-name="Horizontal_20_Line"/>`)
+	case blockTable:
+		ow.table(block)
+	case blockImage:
+		img := block.image
+		if img.data == nil {
+			return
+		}
+		name, ok := ow.media[img]
+		if !ok {
+			name = fmt.Sprintf("Pictures/image%d.%s", len(ow.media)+1, img.ext())
+			ow.media[img] = name
+		}
+		width := float64(img.width) * odtCmPerPixel
+		height := float64(img.height) * odtCmPerPixel
+		if width > odtMaxImageWidth {
+			height = height * odtMaxImageWidth / width
+			width = odtMaxImageWidth
+		}
+		fmt.Fprintf(&ow.body, `<text:p text:style-name="%s"><draw:frame draw:name="%s" text:anchor-type="as-char" svg:width="%.2fcm" svg:height="%.2fcm"><draw:image xlink:href="%s" xlink:type="simple" xlink:show="embed" xlink:actuate="onLoad"/></draw:frame></text:p>`,
+			ow.paragraphStyle(parent, ""), xmlEscape(img.Name), width, height, name)
+	}
+}
+
+func (ow *odtWriter) table(block *exportBlock) {
+	nbCols, grid := tableGrid(block.rows)
+	if nbCols == 0 {
+		return
+	}
+	ow.tables++
+	name := fmt.Sprintf("Table%d", ow.tables)
+	fmt.Fprintf(&ow.styles, `<style:style style:name="%s" style:family="table"><style:table-properties style:width="16cm" table:align="margins"/></style:style>`, name)
+	fmt.Fprintf(&ow.body, `<table:table table:name="%s" table:style-name="%s"><table:table-column table:number-columns-repeated="%d"/>`, name, name, nbCols)
+	for _, row := range grid {
+		ow.body.WriteString(`<table:table-row>`)
+		col := 0
+		for _, slot := range row {
+			for ; col < slot.col; col++ {
+				ow.body.WriteString(`<table:covered-table-cell/>`)
+			}
+			cell := slot.cell
+			col += cell.colspan
+			if slot.continued {
+				for i := 0; i < cell.colspan; i++ {
+					ow.body.WriteString(`<table:covered-table-cell/>`)
+				}
+				continue
+			}
+			background := cell.background
+			if background == "" && cell.header {
+				background = "#f5f6f7"
+			}
+			props := `fo:padding="0.1cm" fo:border="0.5pt solid #d6d8da"`
+			if background != "" {
+				props += fmt.Sprintf(` fo:background-color="%s"`, xmlEscape(background))
+			}
+			style := ow.automaticStyle("table-cell", "", `<style:table-cell-properties `+props+`/>`)
+			fmt.Fprintf(&ow.body, `<table:table-cell table:style-name="%s" office:value-type="string"`, style)
+			if cell.colspan > 1 {
+				fmt.Fprintf(&ow.body, ` table:number-columns-spanned="%d"`, cell.colspan)
+			}
+			if cell.rowspan > 1 {
+				fmt.Fprintf(&ow.body, ` table:number-rows-spanned="%d"`, cell.rowspan)
+			}
+			ow.body.WriteString(`>`)
+			parent := ""
+			if cell.header {
+				parent = "Table_20_Heading"
+			}
+			if len(cell.blocks) == 0 {
+				ow.body.WriteString(`<text:p/>`)
+			}
+			ow.blocks(cell.blocks, parent)
+			ow.body.WriteString(`</table:table-cell>`)
+			for i := 1; i < cell.colspan; i++ {
+				ow.body.WriteString(`<table:covered-table-cell/>`)
+			}
+		}
+		for ; col < nbCols; col++ {
+			ow.body.WriteString(`<table:table-cell/>`)
+		}
+		ow.body.WriteString(`</table:table-row>`)
+	}
+	ow.body.WriteString(`</table:table>`)
+}
+
+func (ow *odtWriter) inlines(inlines []exportInline) {
+	for _, inline := range inlines {
+		if inline.hardBreak {
+			ow.body.WriteString(`<text:line-break/>`)
+			continue
+		}
+		if inline.link != "" {
+			fmt.Fprintf(&ow.body, `<text:a xlink:type="simple" xlink:href="%s" text:style-name="Internet_20_link">`, xmlEscape(inline.link))
+		}
+		style := ow.textStyle(inline)
+		if style != "" {
+			fmt.Fprintf(&ow.body, `<text:span text:style-name="%s">%s</text:span>`, style, odtText(inline.text))
+		} else {
+			ow.body.WriteString(odtText(inline.text))
+		}
+		if inline.link != "" {
+			ow.body.WriteString(`</text:a>`)
+		}
+	}
+}
+
+// textStyle returns the name of an automatic style for the formatting of the
+// inline, or an empty string if the inline has no formatting.
+func (ow *odtWriter) textStyle(inline exportInline) string {
+	var props []string
+	if inline.bold {
+		props = append(props, `fo:font-weight="bold"`)
+	}
+	if inline.italic {
+		props = append(props, `fo:font-style="italic"`)
+	}
+	if inline.underline {
+		props = append(props, `style:text-underline-style="solid" style:text-underline-width="auto" style:text-underline-color="font-color"`)
+	}
+	if inline.strike {
+		props = append(props, `style:text-line-through-style="solid"`)
+	}
+	if inline.code {
+		props = append(props, `style:font-name="Liberation Mono" fo:background-color="#f5f6f7"`)
+	}
+	if inline.sub {
+		props = append(props, `style:text-position="sub 58%"`)
+	} else if inline.sup {
+		props = append(props, `style:text-position="super 58%"`)
+	}
+	if inline.color != "" && strings.HasPrefix(inline.color, "#") {
+		props = append(props, fmt.Sprintf(`fo:color="%s"`, xmlEscape(inline.color)))
+	}
+	if len(props) == 0 {
+		return ""
+	}
+	return ow.automaticStyle("text", "", `<style:text-properties `+strings.Join(props, " ")+`/>`)
+}
+
+// paragraphStyle returns the style for a paragraph with the given parent
+// style and alignment.
+func (ow *odtWriter) paragraphStyle(parent, align string) string {
+	if parent == "" {
+		parent = "Standard"
+	}
+	switch align {
+	case "center":
+		return ow.automaticParagraphStyle(parent, `<style:paragraph-properties fo:text-align="center"/>`)
+	case "end":
+		return ow.automaticParagraphStyle(parent, `<style:paragraph-properties fo:text-align="end"/>`)
+	}
+	return parent
+}
+
+func (ow *odtWriter) automaticParagraphStyle(parent, props string) string {
+	return ow.automaticStyle("paragraph", parent, props)
+}
+
+// automaticStyle returns the name of an automatic style with the given
+// family, parent and properties. The style is created if it doesn't exist
+// yet.
+func (ow *odtWriter) automaticStyle(family, parent, props string) string {
+	cache := ow.text
+	prefix := "T"
+	switch family {
+	case "paragraph":
+		cache = ow.para
+		prefix = "P"
+	case "table-cell":
+		cache = ow.para
+		prefix = "C"
+	}
+	key := family + "|" + parent + "|" + props
+	if name, ok := cache[key]; ok {
+		return name
+	}
+	name := fmt.Sprintf("%s%d", prefix, len(cache)+1)
+	cache[key] = name
+	fmt.Fprintf(&ow.styles, `<style:style style:name="%s" style:family="%s"`, name, family)
+	if parent != "" {
+		fmt.Fprintf(&ow.styles, ` style:parent-style-name="%s"`, parent)
+	}
+	fmt.Fprintf(&ow.styles, `>%s</style:style>`, props)
+	return name
+}
+
+// odtText escapes the text, and keeps the consecutive spaces.
+func odtText(text string) string {
+	escaped := xmlEscape(text)
+	if !strings.Contains(escaped, "  ") && !strings.HasPrefix(escaped, " ") {
+		return escaped
+	}
+	var sb strings.Builder
+	spaces := 0
+	flush := func() {
+		if spaces == 1 && sb.Len() > 0 {
+			sb.WriteString(" ")
+		} else if spaces > 0 {
+			fmt.Fprintf(&sb, `<text:s text:c="%d"/>`, spaces)
+		}
+		spaces = 0
+	}
+	for _, r := range escaped {
+		if r == ' ' {
+			spaces++
+			continue
+		}
+		flush()
+		sb.WriteRune(r)
+	}
+	flush()
+	return sb.String()
+}
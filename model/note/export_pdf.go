@@ -0,0 +1,505 @@
+package note
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/cozy/cozy-stack/pkg/pdf"
+)
+
+const (
+	pdfMargin     = 56.0
+	pdfFontSize   = 11.0
+	pdfCodeSize   = 9.0
+	pdfLineHeight = 1.4
+	pdfSpacing    = 6.0
+	pdfListIndent = 18.0
+	pdfPadding    = 6.0
+)
+
+var (
+	pdfTextColor   = pdf.Color{R: 0.196, G: 0.212, B: 0.247}
+	pdfLinkColor   = pdf.Color{R: 0.02, G: 0.388, B: 0.757}
+	pdfBorderColor = pdf.Color{R: 0.839, G: 0.847, B: 0.855}
+	pdfCodeColor   = pdf.Color{R: 0.961, G: 0.965, B: 0.969}
+)
+
+var pdfHeadingSizes = []float64{20, 16, 14, 12, 11, 10}
+
+// pdfItem is a slice of the document that can't be split between two pages,
+// like a line of text or an image. It is drawn with its top at y.
+type pdfItem struct {
+	height float64
+	draw   func(p *pdf.Page, y float64)
+}
+
+// pdfBox is the horizontal space where the blocks are laid out, with the
+// decorations (backgrounds, left bars) that are drawn behind each item.
+type pdfBox struct {
+	x, width    float64
+	decorations []func(p *pdf.Page, y, h float64)
+	bold        bool
+}
+
+func (b pdfBox) inset(left, right float64) pdfBox {
+	decorations := make([]func(p *pdf.Page, y, h float64), len(b.decorations))
+	copy(decorations, b.decorations)
+	return pdfBox{
+		x:           b.x + left,
+		width:       b.width - left - right,
+		decorations: decorations,
+		bold:        b.bold,
+	}
+}
+
+func (b pdfBox) withBackground(c pdf.Color) pdfBox {
+	x, width := b.x, b.width
+	nb := b.inset(pdfPadding, pdfPadding)
+	nb.decorations = append(nb.decorations, func(p *pdf.Page, y, h float64) {
+		p.Rect(x, y, width, h, c)
+	})
+	return nb
+}
+
+func (b pdfBox) withLeftBar(c pdf.Color) pdfBox {
+	x := b.x
+	nb := b.inset(3*pdfPadding, 0)
+	nb.decorations = append(nb.decorations, func(p *pdf.Page, y, h float64) {
+		p.Rect(x, y, 3, h, c)
+	})
+	return nb
+}
+
+// pdfLayout transforms the blocks to a list of items.
+type pdfLayout struct {
+	doc   *pdf.Document
+	items []pdfItem
+}
+
+func (l *pdfLayout) add(box pdfBox, height float64, draw func(p *pdf.Page, y float64)) {
+	decorations := box.decorations
+	l.items = append(l.items, pdfItem{
+		height: height,
+		draw: func(p *pdf.Page, y float64) {
+			for _, decorate := range decorations {
+				decorate(p, y, height)
+			}
+			if draw != nil {
+				draw(p, y)
+			}
+		},
+	})
+}
+
+// writePDF renders the note to a PDF document.
+func (e *exportDoc) writePDF(w io.Writer) error {
+	doc := pdf.New()
+	doc.Title = e.title
+	l := &pdfLayout{doc: doc}
+	box := pdfBox{x: pdfMargin, width: doc.Width - 2*pdfMargin}
+	if e.title != "" {
+		title := []exportInline{{text: e.title, bold: true}}
+		l.paragraph(box, title, 24, "")
+		l.add(box, 2*pdfSpacing, nil)
+	}
+	l.blocks(box, e.blocks)
+
+	var page *pdf.Page
+	y := 0.0
+	bottom := doc.Height - pdfMargin
+	for _, item := range l.items {
+		if page == nil || (y+item.height > bottom && y > pdfMargin) {
+			page = doc.AddPage()
+			y = pdfMargin
+		}
+		item.draw(page, y)
+		y += item.height
+	}
+	_, err := doc.WriteTo(w)
+	var charErr *pdf.UnsupportedCharError
+	if errors.As(err, &charErr) {
+		return fmt.Errorf("%w (%s)", ErrUnsupportedPDFCharacter, string(charErr.Char))
+	}
+	return err
+}
+
+func (l *pdfLayout) blocks(box pdfBox, blocks []*exportBlock) {
+	for i, block := range blocks {
+		if i > 0 {
+			l.add(box, pdfSpacing, nil)
+		}
+		l.block(box, block)
+	}
+}
+
+func (l *pdfLayout) block(box pdfBox, block *exportBlock) {
+	switch block.kind {
+	case blockParagraph:
+		if len(block.inlines) == 0 {
+			l.add(box, pdfFontSize*pdfLineHeight, nil)
+			return
+		}
+		l.paragraph(box, block.inlines, pdfFontSize, block.align)
+	case blockHeading:
+		size := pdfHeadingSizes[len(pdfHeadingSizes)-1]
+		if block.level >= 1 && block.level <= len(pdfHeadingSizes) {
+			size = pdfHeadingSizes[block.level-1]
+		}
+		l.add(box, pdfSpacing, nil)
+		inlines := make([]exportInline, len(block.inlines))
+		for i, inline := range block.inlines {
+			inline.bold = true
+			inlines[i] = inline
+		}
+		l.paragraph(box, inlines, size, block.align)
+	case blockQuote:
+		l.blocks(box.withLeftBar(pdfBorderColor), block.children)
+	case blockPanel:
+		inner := box.withBackground(pdf.ParseColor(panelColor(block.panel), pdf.White))
+		l.add(inner, pdfPadding, nil)
+		l.blocks(inner, block.children)
+		l.add(inner, pdfPadding, nil)
+	case blockBulletList, blockOrderedList:
+		inner := box.inset(pdfListIndent, 0)
+		for i, item := range block.items {
+			if i > 0 {
+				l.add(box, pdfSpacing/2, nil)
+			}
+			start := len(l.items)
+			if len(item) == 0 {
+				l.add(inner, pdfFontSize*pdfLineHeight, nil)
+			} else {
+				l.blocks(inner, item)
+			}
+			first := l.items[start]
+			marker := listMarker(block, i)
+			x := inner.x - pdf.TextWidth(pdf.Helvetica, pdfFontSize, marker) - 5
+			l.items[start].draw = func(p *pdf.Page, y float64) {
+				first.draw(p, y)
+				p.Text(x, y+pdfBaseline(pdfFontSize), pdf.Helvetica, pdfFontSize, pdfTextColor, marker)
+			}
+		}
+	case blockCode:
+		inner := box.withBackground(pdfCodeColor)
+		l.add(inner, pdfPadding, nil)
+		maxChars := int(inner.width / pdf.TextWidth(pdf.Courier, pdfCodeSize, " "))
+		if maxChars < 1 {
+			maxChars = 1
+		}
+		for _, line := range strings.Split(strings.TrimSuffix(block.code, "\n"), "\n") {
+			runes := []rune(line)
+			for {
+				chunk := runes
+				if len(chunk) > maxChars {
+					chunk = chunk[:maxChars]
+				}
+				text := string(chunk)
+				x := inner.x
+				l.add(inner, pdfCodeSize*pdfLineHeight, func(p *pdf.Page, y float64) {
+					p.Text(x, y+pdfBaseline(pdfCodeSize), pdf.Courier, pdfCodeSize, pdfTextColor, text)
+				})
+				runes = runes[len(chunk):]
+				if len(runes) == 0 {
+					break
+				}
+			}
+		}
+		l.add(inner, pdfPadding, nil)
+	case blockRule:
+		x, width := box.x, box.width
+		l.add(box, 2*pdfSpacing, func(p *pdf.Page, y float64) {
+			p.Line(x, y+pdfSpacing, x+width, y+pdfSpacing, 0.5, pdfBorderColor)
+		})
+	case blockTable:
+		l.table(box, block)
+	case blockImage:
+		l.image(box, block.image)
+	}
+}
+
+func (l *pdfLayout) image(box pdfBox, img *exportImage) {
+	if img.data == nil {
+		return
+	}
+	image, err := l.doc.AddImage(img.data)
+	if err != nil {
+		return
+	}
+	// The images are 96 dpi, and the PDF units are 72 per inch
+	width := float64(image.Width) * 0.75
+	height := float64(image.Height) * 0.75
+	if width > box.width {
+		height = height * box.width / width
+		width = box.width
+	}
+	maxHeight := l.doc.Height - 2*pdfMargin
+	if height > maxHeight {
+		width = width * maxHeight / height
+		height = maxHeight
+	}
+	x := box.x
+	l.add(box, height, func(p *pdf.Page, y float64) {
+		p.DrawImage(image, x, y, width, height)
+	})
+}
+
+// pdfRun is a piece of text with the same style, placed on a line.
+type pdfRun struct {
+	x, width  float64
+	text      string
+	font      pdf.Font
+	size      float64
+	rise      float64
+	color     pdf.Color
+	link      string
+	underline bool
+	strike    bool
+}
+
+// pdfBaseline returns the offset of the baseline from the top of a line.
+func pdfBaseline(size float64) float64 {
+	return size * (pdfLineHeight + 0.6) / 2
+}
+
+func pdfRunStyle(inline exportInline, size float64, bold bool) pdfRun {
+	run := pdfRun{size: size, color: pdfTextColor, link: inline.link}
+	switch {
+	case inline.code:
+		run.font = pdf.Courier
+	case (inline.bold || bold) && inline.italic:
+		run.font = pdf.HelveticaBoldOblique
+	case inline.bold || bold:
+		run.font = pdf.HelveticaBold
+	case inline.italic:
+		run.font = pdf.HelveticaOblique
+	default:
+		run.font = pdf.Helvetica
+	}
+	if inline.sub || inline.sup {
+		run.size = size * 0.7
+		run.rise = size * 0.3
+		if inline.sup {
+			run.rise = -size * 0.35
+		}
+	}
+	if inline.link != "" {
+		run.color = pdfLinkColor
+		run.underline = true
+	} else if inline.color != "" {
+		run.color = pdf.ParseColor(inline.color, pdfTextColor)
+	}
+	run.underline = run.underline || inline.underline
+	run.strike = inline.strike
+	return run
+}
+
+// paragraph wraps the inlines to lines that fit in the box.
+func (l *pdfLayout) paragraph(box pdfBox, inlines []exportInline, size float64, align string) {
+	var lines [][]pdfRun
+	var current []pdfRun
+	x := 0.0
+	newLine := func() {
+		lines = append(lines, current)
+		current = nil
+		x = 0
+	}
+	for _, inline := range inlines {
+		if inline.hardBreak {
+			newLine()
+			continue
+		}
+		style := pdfRunStyle(inline, size, box.bold)
+		for _, word := range strings.SplitAfter(inline.text, " ") {
+			if word == "" {
+				continue
+			}
+			trimmed := strings.TrimRight(word, " ")
+			if x > 0 && x+pdf.TextWidth(style.font, style.size, trimmed) > box.width {
+				newLine()
+			}
+			// A word longer than the line is cut on several lines
+			for x == 0 && pdf.TextWidth(style.font, style.size, trimmed) > box.width {
+				runes := []rune(word)
+				n := 1
+				for n < len(runes) && pdf.TextWidth(style.font, style.size, string(runes[:n+1])) <= box.width {
+					n++
+				}
+				run := style
+				run.text = string(runes[:n])
+				run.width = pdf.TextWidth(style.font, style.size, run.text)
+				current = append(current, run)
+				newLine()
+				word = string(runes[n:])
+				trimmed = strings.TrimRight(word, " ")
+			}
+			if word == "" {
+				continue
+			}
+			run := style
+			run.x = x
+			run.text = word
+			run.width = pdf.TextWidth(style.font, style.size, word)
+			current = append(current, run)
+			x += run.width
+		}
+	}
+	if len(current) > 0 || len(lines) == 0 {
+		newLine()
+	}
+
+	lineHeight := size * pdfLineHeight
+	for _, line := range lines {
+		offset := box.x
+		if align == "center" || align == "end" {
+			width := 0.0
+			if n := len(line); n > 0 {
+				last := line[n-1]
+				width = last.x + pdf.TextWidth(last.font, last.size, strings.TrimRight(last.text, " "))
+			}
+			if align == "center" {
+				offset += (box.width - width) / 2
+			} else {
+				offset += box.width - width
+			}
+		}
+		runs := line
+		l.add(box, lineHeight, func(p *pdf.Page, y float64) {
+			baseline := y + pdfBaseline(size)
+			for _, run := range runs {
+				rx := offset + run.x
+				ry := baseline + run.rise
+				p.Text(rx, ry, run.font, run.size, run.color, run.text)
+				width := pdf.TextWidth(run.font, run.size, strings.TrimRight(run.text, " "))
+				if run.underline {
+					p.Line(rx, ry+1.5, rx+width, ry+1.5, 0.5, run.color)
+				}
+				if run.strike {
+					p.Line(rx, ry-run.size*0.3, rx+width, ry-run.size*0.3, 0.5, run.color)
+				}
+				if run.link != "" {
+					p.Link(rx, y, run.width, lineHeight, run.link)
+				}
+			}
+		})
+	}
+}
+
+// table lays out the rows of a table. The rows that are linked by a cell
+// with a rowspan are kept together on the same page.
+func (l *pdfLayout) table(box pdfBox, block *exportBlock) {
+	nbCols, grid := tableGrid(block.rows)
+	if nbCols == 0 {
+		return
+	}
+	colWidth := box.width / float64(nbCols)
+
+	// Lay out the content of each cell
+	type cellLayout struct {
+		slot   gridSlot
+		row    int
+		items  []pdfItem
+		height float64
+	}
+	var cells []*cellLayout
+	for r, row := range grid {
+		for _, slot := range row {
+			if slot.continued {
+				continue
+			}
+			width := float64(slot.cell.colspan) * colWidth
+			inner := pdfBox{
+				x:     box.x + float64(slot.col)*colWidth + pdfPadding,
+				width: width - 2*pdfPadding,
+				bold:  slot.cell.header,
+			}
+			sub := &pdfLayout{doc: l.doc}
+			sub.blocks(inner, slot.cell.blocks)
+			cell := &cellLayout{slot: slot, row: r, items: sub.items, height: 2 * pdfPadding}
+			for _, item := range sub.items {
+				cell.height += item.height
+			}
+			cells = append(cells, cell)
+		}
+	}
+
+	// Compute the height of the rows
+	heights := make([]float64, len(grid))
+	for i := range heights {
+		heights[i] = pdfFontSize*pdfLineHeight + 2*pdfPadding
+	}
+	for _, cell := range cells {
+		if cell.slot.cell.rowspan <= 1 && cell.height > heights[cell.row] {
+			heights[cell.row] = cell.height
+		}
+	}
+	for _, cell := range cells {
+		span := cell.slot.cell.rowspan
+		if span <= 1 {
+			continue
+		}
+		last := cell.row + span - 1
+		if last >= len(grid) {
+			last = len(grid) - 1
+		}
+		total := 0.0
+		for r := cell.row; r <= last; r++ {
+			total += heights[r]
+		}
+		if total < cell.height {
+			heights[last] += cell.height - total
+		}
+	}
+
+	// Group the rows linked by a rowspan
+	end := 0
+	for start := 0; start < len(grid); start = end {
+		end = start + 1
+		for r := start; r < end; r++ {
+			for _, cell := range cells {
+				if cell.row == r && cell.row+cell.slot.cell.rowspan > end {
+					end = cell.row + cell.slot.cell.rowspan
+				}
+			}
+		}
+		if end > len(grid) {
+			end = len(grid)
+		}
+		offsets := make([]float64, end-start+1)
+		for r := start; r < end; r++ {
+			offsets[r-start+1] = offsets[r-start] + heights[r]
+		}
+		var group []*cellLayout
+		for _, cell := range cells {
+			if cell.row >= start && cell.row < end {
+				group = append(group, cell)
+			}
+		}
+		first := start
+		l.add(box, offsets[end-start], func(p *pdf.Page, y float64) {
+			for _, cell := range group {
+				last := cell.row + cell.slot.cell.rowspan
+				if last > end {
+					last = end
+				}
+				cx := box.x + float64(cell.slot.col)*colWidth
+				cy := y + offsets[cell.row-first]
+				cw := float64(cell.slot.cell.colspan) * colWidth
+				ch := offsets[last-first] - offsets[cell.row-first]
+				background := cell.slot.cell.background
+				if background == "" && cell.slot.cell.header {
+					p.Rect(cx, cy, cw, ch, pdfCodeColor)
+				} else if background != "" {
+					p.Rect(cx, cy, cw, ch, pdf.ParseColor(background, pdf.White))
+				}
+				p.StrokeRect(cx, cy, cw, ch, 0.5, pdfBorderColor)
+				iy := cy + pdfPadding
+				for _, item := range cell.items {
+					item.draw(p, iy)
+					iy += item.height
+				}
+			}
+		})
+	}
+}
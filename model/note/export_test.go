@@ -0,0 +1,166 @@
+package note
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/zlib"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"regexp"
+	"testing"
+
+	"github.com/cozy/prosemirror-go/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const exportContent = `{
+  "type": "doc",
+  "content": [
+    {
+      "type": "heading",
+      "attrs": { "level": 2 },
+      "content": [{ "type": "text", "text": "Groceries" }]
+    },
+    {
+      "type": "paragraph",
+      "content": [
+        { "type": "text", "text": "Buy some " },
+        { "type": "text", "text": "fresh", "marks": [{ "type": "strong" }] },
+        { "type": "text", "text": " fruits & café" }
+      ]
+    },
+    {
+      "type": "bulletList",
+      "content": [
+        {
+          "type": "listItem",
+          "content": [
+            { "type": "paragraph", "content": [{ "type": "text", "text": "Apples" }] }
+          ]
+        },
+        {
+          "type": "listItem",
+          "content": [
+            { "type": "paragraph", "content": [{ "type": "text", "text": "Pears" }] }
+          ]
+        }
+      ]
+    }
+  ]
+}`
+
+func newExportDoc(t *testing.T, content string) *exportDoc {
+	spec := model.SchemaSpecFromJSON(DefaultSchemaSpecs())
+	schema, err := model.NewSchema(&spec)
+	require.NoError(t, err)
+	var raw map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(content), &raw))
+	node, err := model.NodeFromJSON(schema, raw)
+	require.NoError(t, err)
+	exp := &exportDoc{title: "My list", images: make(map[string]*exportImage)}
+	exp.blocks = exp.convertBlocks(node)
+	return exp
+}
+
+func zipEntry(t *testing.T, data []byte, name string) string {
+	r, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	for _, f := range r.File {
+		if f.Name == name {
+			rc, err := f.Open()
+			require.NoError(t, err)
+			defer rc.Close()
+			content, err := ioutil.ReadAll(rc)
+			require.NoError(t, err)
+			return string(content)
+		}
+	}
+	t.Fatalf("%s not found in the archive", name)
+	return ""
+}
+
+func TestExportHTML(t *testing.T) {
+	exp := newExportDoc(t, exportContent)
+	var buf bytes.Buffer
+	require.NoError(t, exp.writeHTML(&buf))
+	html := buf.String()
+	assert.Contains(t, html, "<title>My list</title>")
+	assert.Contains(t, html, "<h1>My list</h1>\n<h2>Groceries</h2>\n")
+	assert.Contains(t, html, "<p>Buy some <strong>fresh</strong> fruits &amp; café</p>\n")
+	assert.Contains(t, html, "<ul>\n<li><p>Apples</p>\n</li>\n<li><p>Pears</p>\n</li>\n</ul>\n")
+}
+
+func TestExportPDF(t *testing.T) {
+	exp := newExportDoc(t, exportContent)
+	var buf bytes.Buffer
+	require.NoError(t, exp.writePDF(&buf))
+
+	// Extract the texts drawn on the page
+	var texts []string
+	streams := regexp.MustCompile(`(?s)stream\n(.*?)\nendstream`).FindAllSubmatch(buf.Bytes(), -1)
+	for _, stream := range streams {
+		r, err := zlib.NewReader(bytes.NewReader(stream[1]))
+		if err != nil {
+			continue
+		}
+		data, err := ioutil.ReadAll(r)
+		require.NoError(t, err)
+		for _, m := range regexp.MustCompile(`/F(\d) [\d.]+ Tf [\d.]+ [\d.]+ Td \((.*?)\) Tj`).FindAllSubmatch(data, -1) {
+			texts = append(texts, string(m[1])+":"+string(m[2]))
+		}
+	}
+	// The font 0 is Helvetica, and the font 1 is Helvetica-Bold. The text is
+	// drawn word by word, and the bullets after the text of the list items.
+	assert.Equal(t, []string{
+		"1:My ",
+		"1:list",
+		"1:Groceries",
+		"0:Buy ",
+		"0:some ",
+		"1:fresh",
+		"0: ",
+		"0:fruits ",
+		"0:& ",
+		"0:caf\xe9",
+		"0:Apples",
+		"0:\x95",
+		"0:Pears",
+		"0:\x95",
+	}, texts)
+
+	exp = newExportDoc(t, `{"type":"doc","content":[{"type":"paragraph","content":[{"type":"text","text":"Привет"}]}]}`)
+	err := exp.writePDF(ioutil.Discard)
+	assert.True(t, errors.Is(err, ErrUnsupportedPDFCharacter))
+	assert.Contains(t, err.Error(), "(П)")
+
+	buf.Reset()
+	format, err := exp.write(&buf, ExportPDF)
+	require.NoError(t, err)
+	assert.Equal(t, ExportDOCX, format)
+	assert.Contains(t, zipEntry(t, buf.Bytes(), "word/document.xml"), "Привет")
+}
+
+func TestExportDOCX(t *testing.T) {
+	exp := newExportDoc(t, exportContent)
+	var buf bytes.Buffer
+	require.NoError(t, exp.writeDOCX(&buf))
+	document := zipEntry(t, buf.Bytes(), "word/document.xml")
+	assert.Regexp(t, `<w:pStyle w:val="Heading2"/>.*<w:t xml:space="preserve">Groceries</w:t>`, document)
+	assert.Regexp(t, `<w:b/>.*<w:t xml:space="preserve">fresh</w:t>`, document)
+	assert.Contains(t, document, `<w:t xml:space="preserve"> fruits &amp; café</w:t>`)
+	assert.Contains(t, document, `<w:t xml:space="preserve">Apples</w:t>`)
+	zipEntry(t, buf.Bytes(), "[Content_Types].xml")
+}
+
+func TestExportODT(t *testing.T) {
+	exp := newExportDoc(t, exportContent)
+	var buf bytes.Buffer
+	require.NoError(t, exp.writeODT(&buf))
+	content := zipEntry(t, buf.Bytes(), "content.xml")
+	assert.Regexp(t, `<text:h [^>]*text:outline-level="2"[^>]*>Groceries</text:h>`, content)
+	assert.Contains(t, content, `fruits &amp; café`)
+	assert.Regexp(t, `<text:list[^>]*>\s*<text:list-item>\s*<text:p[^>]*>Apples</text:p>`, content)
+	assert.Equal(t, "application/vnd.oasis.opendocument.text", zipEntry(t, buf.Bytes(), "mimetype"))
+}
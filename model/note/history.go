@@ -0,0 +1,326 @@
+package note
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/cozy/cozy-stack/model/instance"
+	"github.com/cozy/cozy-stack/model/vfs"
+	"github.com/cozy/cozy-stack/pkg/consts"
+	"github.com/cozy/cozy-stack/pkg/couchdb"
+	"github.com/cozy/cozy-stack/pkg/jsonapi"
+	"github.com/cozy/cozy-stack/pkg/prefixer"
+	"github.com/cozy/prosemirror-go/model"
+	"github.com/cozy/prosemirror-go/transform"
+)
+
+// maxAutomaticVersions is the number of versions that are kept for a note,
+// without counting the named snapshots.
+const maxAutomaticVersions = 50
+
+// Version is a snapshot of the content of a note at a given version. The
+// snapshots are made automatically when the note is persisted to the VFS, or
+// explicitly by the user with a name.
+type Version struct {
+	DocID      string                 `json:"_id,omitempty"`
+	DocRev     string                 `json:"_rev,omitempty"`
+	NoteID     string                 `json:"note_id"`
+	Version    int64                  `json:"version"`
+	Name       string                 `json:"name,omitempty"`
+	Title      string                 `json:"title"`
+	Authors    []string               `json:"authors,omitempty"`
+	CreatedAt  time.Time              `json:"created_at"`
+	SchemaSpec map[string]interface{} `json:"schema,omitempty"`
+	RawContent map[string]interface{} `json:"content,omitempty"`
+}
+
+// ID returns the version qualified identifier
+func (v *Version) ID() string { return v.DocID }
+
+// Rev returns the version revision
+func (v *Version) Rev() string { return v.DocRev }
+
+// DocType returns the document type
+func (v *Version) DocType() string { return consts.NotesVersions }
+
+// Clone implements couchdb.Doc
+func (v *Version) Clone() couchdb.Doc {
+	cloned := *v
+	cloned.Authors = make([]string, len(v.Authors))
+	copy(cloned.Authors, v.Authors)
+	// XXX The schema and the content are immutable and are not cloned.
+	return &cloned
+}
+
+// SetID changes the version qualified identifier
+func (v *Version) SetID(id string) { v.DocID = id }
+
+// SetRev changes the version revision
+func (v *Version) SetRev(rev string) { v.DocRev = rev }
+
+// Included is part of the jsonapi.Object interface
+func (v *Version) Included() []jsonapi.Object { return nil }
+
+// Links is part of the jsonapi.Object interface
+func (v *Version) Links() *jsonapi.LinksList { return nil }
+
+// Relationships is part of the jsonapi.Object interface
+func (v *Version) Relationships() jsonapi.RelationshipMap { return nil }
+
+// WithoutContent returns a copy of the version without the schema and the
+// content, for listing the versions.
+func (v *Version) WithoutContent() *Version {
+	cloned := v.Clone().(*Version)
+	cloned.SchemaSpec = nil
+	cloned.RawContent = nil
+	return cloned
+}
+
+// Markdown returns a markdown serialization of the content of this version.
+func (v *Version) Markdown(images []*Image) ([]byte, error) {
+	doc := &Document{
+		DocID:      v.NoteID,
+		Title:      v.Title,
+		Version:    v.Version,
+		SchemaSpec: v.SchemaSpec,
+		RawContent: v.RawContent,
+	}
+	return doc.Markdown(images)
+}
+
+func versionID(noteID string, version int64) string {
+	return fmt.Sprintf("%s/%08d", noteID, version)
+}
+
+// ListVersions returns the versions of a note, the most recent first, without
+// their content.
+func ListVersions(inst *instance.Instance, file *vfs.FileDoc) ([]*Version, error) {
+	versions, err := getVersions(inst, file.ID())
+	if err != nil {
+		return nil, err
+	}
+	list := make([]*Version, len(versions))
+	for i, v := range versions {
+		list[len(versions)-1-i] = v.WithoutContent()
+	}
+	return list, nil
+}
+
+// GetVersion returns the snapshot of a note at the given version.
+func GetVersion(inst *instance.Instance, file *vfs.FileDoc, version int64) (*Version, error) {
+	var v Version
+	err := couchdb.GetDoc(inst, consts.NotesVersions, versionID(file.ID(), version), &v)
+	if couchdb.IsNotFoundError(err) || couchdb.IsNoDatabaseError(err) {
+		return nil, ErrVersionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// CreateSnapshot makes a named snapshot of the current version of the note.
+func CreateSnapshot(inst *instance.Instance, file *vfs.FileDoc, name string) (*Version, error) {
+	lock := inst.NotesLock()
+	if err := lock.Lock(); err != nil {
+		return nil, err
+	}
+	defer lock.Unlock()
+
+	doc, err := get(inst, file)
+	if err != nil {
+		return nil, err
+	}
+	return saveVersion(inst, doc, name)
+}
+
+// RestoreVersion replaces the content of the note with the content of a
+// previous version. It is done with a new step, sent to the live editors like
+// the other steps, so the history is kept.
+func RestoreVersion(inst *instance.Instance, file *vfs.FileDoc, version int64, sessionID string) (*vfs.FileDoc, error) {
+	lock := inst.NotesLock()
+	if err := lock.Lock(); err != nil {
+		return nil, err
+	}
+	defer lock.Unlock()
+
+	v, err := GetVersion(inst, file, version)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := get(inst, file)
+	if err != nil {
+		return nil, err
+	}
+	schema, err := doc.Schema()
+	if err != nil {
+		return nil, err
+	}
+	content, err := doc.Content()
+	if err != nil {
+		return nil, err
+	}
+	old, err := model.NodeFromJSON(schema, v.RawContent)
+	if err != nil {
+		inst.Logger().WithNamespace("notes").
+			Infof("Cannot restore version %d: %s", version, err)
+		return nil, ErrInvalidSchema
+	}
+
+	slice := model.NewSlice(old.Content, 0, 0)
+	replace := transform.NewReplaceStep(0, content.Content.Size, slice)
+	buf, err := json.Marshal(replace.ToJSON())
+	if err != nil {
+		return nil, err
+	}
+	var step Step
+	if err := json.Unmarshal(buf, &step); err != nil {
+		return nil, err
+	}
+	step["sessionID"] = sessionID
+	step["restored_from"] = version
+	steps := []Step{step}
+
+	if err := apply(inst, doc, steps); err != nil {
+		return nil, err
+	}
+	if err := saveSteps(inst, steps); err != nil {
+		return nil, err
+	}
+	publishSteps(inst, file.ID(), steps)
+	doc.Title = v.Title
+	publishUpdatedTitle(inst, file.ID(), v.Title, sessionID)
+	if err := saveToCache(inst, doc); err != nil {
+		return nil, err
+	}
+	return doc.asFile(inst, file), nil
+}
+
+// saveVersion makes a snapshot of the note. If there is already a snapshot
+// for this version, it is updated with the name (if any).
+func saveVersion(inst *instance.Instance, doc *Document, name string) (*Version, error) {
+	id := versionID(doc.ID(), doc.Version)
+	var v Version
+	err := couchdb.GetDoc(inst, consts.NotesVersions, id, &v)
+	if err == nil {
+		if name == "" || name == v.Name {
+			return &v, nil
+		}
+		v.Name = name
+		if err := couchdb.UpdateDoc(inst, &v); err != nil {
+			return nil, err
+		}
+		return &v, nil
+	}
+	if !couchdb.IsNotFoundError(err) && !couchdb.IsNoDatabaseError(err) {
+		return nil, err
+	}
+
+	v = Version{
+		DocID:      id,
+		NoteID:     doc.ID(),
+		Version:    doc.Version,
+		Name:       name,
+		Title:      doc.Title,
+		Authors:    authorsSinceLastVersion(inst, doc.ID(), doc.Version),
+		CreatedAt:  time.Now().UTC(),
+		SchemaSpec: doc.SchemaSpec,
+		RawContent: doc.RawContent,
+	}
+	if err := couchdb.CreateNamedDocWithDB(inst, &v); err != nil {
+		return nil, err
+	}
+	if name == "" {
+		purgeOldVersions(inst, doc.ID())
+	}
+	return &v, nil
+}
+
+// authorsSinceLastVersion returns the list of the authors of the steps made
+// after the previous snapshot.
+func authorsSinceLastVersion(inst *instance.Instance, noteID string, version int64) []string {
+	since := int64(0)
+	if versions, err := getVersions(inst, noteID); err == nil && len(versions) > 0 {
+		since = versions[len(versions)-1].Version
+	}
+	if since >= version {
+		return nil
+	}
+	steps, err := getSteps(inst, noteID, since)
+	if err != nil {
+		return nil
+	}
+	seen := make(map[string]struct{})
+	var authors []string
+	for _, s := range steps {
+		if s.version() > version {
+			break
+		}
+		author, _ := s["author"].(string)
+		if author == "" {
+			continue
+		}
+		if _, ok := seen[author]; !ok {
+			seen[author] = struct{}{}
+			authors = append(authors, author)
+		}
+	}
+	sort.Strings(authors)
+	return authors
+}
+
+// getVersions returns the versions of a note, sorted by ascending version.
+func getVersions(db prefixer.Prefixer, noteID string) ([]*Version, error) {
+	var versions []*Version
+	req := couchdb.AllDocsRequest{
+		Limit:    1000,
+		StartKey: startkey(noteID),
+		EndKey:   endkey(noteID),
+	}
+	err := couchdb.GetAllDocs(db, consts.NotesVersions, &req, &versions)
+	if couchdb.IsNoDatabaseError(err) {
+		return nil, nil
+	}
+	return versions, err
+}
+
+// purgeOldVersions removes the oldest automatic versions of a note when there
+// are too many of them. The named snapshots are kept.
+func purgeOldVersions(inst *instance.Instance, noteID string) {
+	versions, err := getVersions(inst, noteID)
+	if err != nil {
+		inst.Logger().WithNamespace("notes").
+			Warnf("Cannot purge old versions for file %s: %s", noteID, err)
+		return
+	}
+	var automatics []couchdb.Doc
+	for _, v := range versions {
+		if v.Name == "" {
+			automatics = append(automatics, v)
+		}
+	}
+	if len(automatics) <= maxAutomaticVersions {
+		return
+	}
+	docs := automatics[:len(automatics)-maxAutomaticVersions]
+	if err := couchdb.BulkDeleteDocs(inst, consts.NotesVersions, docs); err != nil {
+		inst.Logger().WithNamespace("notes").
+			Warnf("Cannot purge old versions for file %s: %s", noteID, err)
+	}
+}
+
+func purgeAllVersions(db prefixer.Prefixer, noteID string) {
+	versions, err := getVersions(db, noteID)
+	if err != nil || len(versions) == 0 {
+		return
+	}
+	docs := make([]couchdb.Doc, len(versions))
+	for i, v := range versions {
+		docs[i] = v
+	}
+	_ = couchdb.BulkDeleteDocs(db, consts.NotesVersions, docs)
+}
+
+var _ jsonapi.Object = &Version{}
@@ -700,6 +700,14 @@ func Update(inst *instance.Instance, fileID string) error {
 	if err != nil {
 		return err
 	}
+	if _, err := saveVersion(inst, doc, ""); err != nil {
+		inst.Logger().WithNamespace("notes").
+			Warnf("Cannot save version %d for file %s: %s", doc.Version, fileID, err)
+	}
+	// The anchors of the comments are mapped before the steps are purged
+	if comments, err := getComments(inst, fileID); err == nil {
+		mapComments(inst, doc, comments, true)
+	}
 	purgeOldSteps(inst, fileID)
 	return nil
 }
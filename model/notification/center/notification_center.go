@@ -25,6 +25,21 @@ const (
 	// NotificationDiskQuota category for sending alert when reaching 90% of disk
 	// usage quota.
 	NotificationDiskQuota = "disk-quota"
+	// NotificationNoteMention category for sending alert when the owner of the
+	// instance is mentioned in a comment of a note.
+	NotificationNoteMention = "note-mention"
+	// NotificationFileDrop category for sending alert when some files have
+	// been uploaded via a file drop link.
+	NotificationFileDrop = "file-drop"
+	// NotificationNewCountryLogin for sending a notification when a session
+	// is created from a new country.
+	NotificationNewCountryLogin = "new-country-login"
+	// NotificationLoginLockout for sending a notification when the login has
+	// been locked because of too many failed attempts.
+	NotificationLoginLockout = "login-lockout"
+	// NotificationKonnectorUserAction for sending a notification when a
+	// konnector can't run for an account until the user re-authenticates.
+	NotificationKonnectorUserAction = "konnector-user-action"
 )
 
 var (
@@ -36,6 +51,21 @@ var (
 			MailTemplate: "notifications_diskquota",
 			MinInterval:  7 * 24 * time.Hour,
 		},
+		NotificationNoteMention: {
+			Description: "Warn when the owner is mentioned in a comment of a note",
+		},
+		NotificationFileDrop: {
+			Description: "Warn when some files have been uploaded via a file drop link",
+		},
+		NotificationNewCountryLogin: {
+			Description: "Warn when a session is created from a new country",
+		},
+		NotificationLoginLockout: {
+			Description: "Warn when the login is locked after too many failed attempts",
+		},
+		NotificationKonnectorUserAction: {
+			Description: "Warn when a konnector needs the user to re-authenticate",
+		},
 	}
 )
 
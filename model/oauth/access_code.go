@@ -1,6 +1,12 @@
 package oauth
 
 import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"regexp"
+
 	"github.com/cozy/cozy-stack/model/instance"
 	"github.com/cozy/cozy-stack/pkg/consts"
 	"github.com/cozy/cozy-stack/pkg/couchdb"
@@ -16,6 +22,38 @@ type AccessCode struct {
 	ClientID string `json:"client_id"`
 	IssuedAt int64  `json:"issued_at"`
 	Scope    string `json:"scope"`
+
+	// Challenge is the code_challenge for PKCE (only the S256 method is
+	// supported). See https://tools.ietf.org/html/rfc7636
+	Challenge string `json:"code_challenge,omitempty"`
+
+	// Nonce is the value sent by an OpenID Connect client on the authorize
+	// page, that must be put in the ID token.
+	Nonce string `json:"nonce,omitempty"`
+}
+
+// ChallengeMethodS256 is the only code_challenge_method supported for PKCE.
+const ChallengeMethodS256 = "S256"
+
+// ErrInvalidCodeChallenge is used when the code_challenge or the
+// code_challenge_method parameters are not valid.
+var ErrInvalidCodeChallenge = errors.New("invalid code_challenge")
+
+var codeChallengeRegexp = regexp.MustCompile(`^[A-Za-z0-9_-]{43}$`)
+var codeVerifierRegexp = regexp.MustCompile(`^[A-Za-z0-9._~-]{43,128}$`)
+
+// CheckCodeChallenge returns an error if the code_challenge and
+// code_challenge_method parameters given on the authorize page are not
+// valid. An empty challenge is accepted, as PKCE is optional for the
+// confidential clients.
+func CheckCodeChallenge(challenge, method string) error {
+	if challenge == "" && method == "" {
+		return nil
+	}
+	if method != ChallengeMethodS256 || !codeChallengeRegexp.MatchString(challenge) {
+		return ErrInvalidCodeChallenge
+	}
+	return nil
 }
 
 // ID returns the access code qualified identifier
@@ -36,19 +74,33 @@ func (ac *AccessCode) SetID(id string) { ac.Code = id }
 // SetRev changes the access code revision
 func (ac *AccessCode) SetRev(rev string) { ac.CouchRev = rev }
 
-// CreateAccessCode an access code for the given clientID, persisted in CouchDB
-func CreateAccessCode(i *instance.Instance, client *Client, scope string) (*AccessCode, error) {
-	if client.Pending {
-		client.Pending = false
-		client.ClientID = ""
-		_ = couchdb.UpdateDoc(i, client)
-		client.ClientID = client.CouchID
+// CheckVerifier returns true if the code_verifier sent to obtain the tokens
+// matches the code_challenge, or if the access code was created without
+// PKCE and no verifier has been sent.
+func (ac *AccessCode) CheckVerifier(verifier string) bool {
+	if ac.Challenge == "" {
+		return verifier == ""
 	}
+	if !codeVerifierRegexp.MatchString(verifier) {
+		return false
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	computed := base64.RawURLEncoding.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(computed), []byte(ac.Challenge)) == 1
+}
+
+// CreateAccessCode an access code for the given clientID, persisted in
+// CouchDB. The challenge is the PKCE code_challenge, and the nonce is the one
+// from OpenID Connect. Both can be empty.
+func CreateAccessCode(i *instance.Instance, client *Client, scope, challenge, nonce string) (*AccessCode, error) {
+	client.clearPending(i)
 
 	ac := &AccessCode{
-		ClientID: client.ClientID,
-		IssuedAt: crypto.Timestamp(),
-		Scope:    scope,
+		ClientID:  client.ClientID,
+		IssuedAt:  crypto.Timestamp(),
+		Scope:     scope,
+		Challenge: challenge,
+		Nonce:     nonce,
 	}
 	if err := couchdb.CreateDoc(i, ac); err != nil {
 		return nil, err
@@ -38,6 +38,19 @@ const DocTypeVersion = "1"
 // ClientSecretLen is the number of random bytes used for generating the client secret
 const ClientSecretLen = 24
 
+// The authentication methods for the token endpoint
+const (
+	// AuthMethodNone is used by the public clients, they don't send their
+	// client_secret but must use PKCE
+	AuthMethodNone = "none"
+	// AuthMethodSecretPost is the default method, where the client_secret is
+	// sent in the body of the request
+	AuthMethodSecretPost = "client_secret_post"
+	// AuthMethodSecretBasic is when the client_secret is sent via HTTP Basic
+	// authentication
+	AuthMethodSecretBasic = "client_secret_basic"
+)
+
 // ScopeLogin is the special scope used by the manager or any other client
 // for login/authentication purposes.
 const ScopeLogin = "login"
@@ -77,6 +90,14 @@ type Client struct {
 	SoftwareID      string   `json:"software_id"`                // Declared by the client (mandatory)
 	SoftwareVersion string   `json:"software_version,omitempty"` // Declared by the client (optional)
 
+	// Declared by the client (optional, "none" for the public clients that
+	// can't keep a secret, and must use PKCE)
+	TokenEndpointAuthMethod string `json:"token_endpoint_auth_method,omitempty"`
+
+	// The tokens issued before this date (in milliseconds since the Unix
+	// epoch) have been revoked
+	RevokedAt int64 `json:"revoked_at,omitempty"`
+
 	// Notifications parameters
 	Notifications map[string]notification.Properties `json:"notifications,omitempty"`
 
@@ -288,6 +309,15 @@ func (c *Client) checkMandatoryFields(i *instance.Instance) *ClientRegistrationE
 			Description: "software_id is mandatory",
 		}
 	}
+	switch c.TokenEndpointAuthMethod {
+	case "", AuthMethodNone, AuthMethodSecretPost, AuthMethodSecretBasic:
+	default:
+		return &ClientRegistrationError{
+			Code:        http.StatusBadRequest,
+			Error:       "invalid_client_metadata",
+			Description: "token_endpoint_auth_method is not supported",
+		}
+	}
 	c.NotificationPlatform = strings.ToLower(c.NotificationPlatform)
 	switch c.NotificationPlatform {
 	case "", PlatformFirebase, PlatformAPNS:
@@ -502,6 +532,8 @@ func (c *Client) Update(i *instance.Instance, old *Client) *ClientRegistrationEr
 	c.GrantTypes = []string{"authorization_code", "refresh_token"}
 	c.ResponseTypes = []string{"code"}
 	c.AllowLoginScope = old.AllowLoginScope
+	c.TokenEndpointAuthMethod = old.TokenEndpointAuthMethod
+	c.RevokedAt = old.RevokedAt
 	c.OnboardingSecret = ""
 	c.OnboardingApp = ""
 	c.OnboardingPermissions = ""
@@ -550,6 +582,41 @@ func (c *Client) Delete(i *instance.Instance) *ClientRegistrationError {
 	return nil
 }
 
+// clearPending removes the pending flag, as the client has been authorized
+// by the user.
+func (c *Client) clearPending(i *instance.Instance) {
+	if c.Pending {
+		c.Pending = false
+		c.ClientID = ""
+		_ = couchdb.UpdateDoc(i, c)
+		c.ClientID = c.CouchID
+	}
+}
+
+// IsPublic returns true if the client can't keep a secret: it doesn't need
+// to send its client_secret to get tokens, but must use PKCE instead.
+func (c *Client) IsPublic() bool {
+	return c.TokenEndpointAuthMethod == AuthMethodNone
+}
+
+// Revoke revokes all the access and refresh tokens previously issued for
+// this client. The client will have to go through the authorization flow
+// again to get new tokens.
+func (c *Client) Revoke(i *instance.Instance) error {
+	c.RevokedAt = time.Now().UnixNano() / int64(time.Millisecond)
+	c.ClientID = ""
+	defer func() { c.ClientID = c.CouchID }()
+	return couchdb.UpdateDoc(i, c)
+}
+
+// IsRevoked returns true if the token with the given claims has been issued
+// before the tokens of the client have been revoked. The comparison is made
+// with the milliseconds, as a new token can be issued in the same second as
+// the revocation.
+func (c *Client) IsRevoked(claims permission.Claims) bool {
+	return c.RevokedAt > 0 && claims.IssuedAtMillis() < c.RevokedAt
+}
+
 // AcceptRedirectURI returns true if the given URI matches the registered
 // redirect_uris
 func (c *Client) AcceptRedirectURI(u string) bool {
@@ -563,14 +630,16 @@ func (c *Client) AcceptRedirectURI(u string) bool {
 
 // CreateJWT returns a new JSON Web Token for the given instance and audience
 func (c *Client) CreateJWT(i *instance.Instance, audience, scope string) (string, error) {
+	now := time.Now()
 	token, err := crypto.NewJWT(i.OAuthSecret, permission.Claims{
 		StandardClaims: crypto.StandardClaims{
 			Audience: audience,
 			Issuer:   i.Domain,
-			IssuedAt: crypto.Timestamp(),
+			IssuedAt: now.Unix(),
 			Subject:  c.CouchID,
 		},
-		Scope: scope,
+		IssuedAtMs: now.UnixNano() / int64(time.Millisecond),
+		Scope:      scope,
 	})
 	if err != nil {
 		i.Logger().WithNamespace("oauth").
@@ -647,6 +716,38 @@ func (c *Client) ValidToken(i *instance.Instance, audience, token string) (permi
 			Errorf("Expected %s subject for %s token, but was: %s", audience, c.CouchID, claims.Subject)
 		return claims, false
 	}
+	if c.IsRevoked(claims) {
+		i.Logger().WithNamespace("oauth").
+			Infof("The %s token for %s has been revoked", audience, c.CouchID)
+		return claims, false
+	}
+	return claims, true
+}
+
+// ParseToken checks that the JWT is an access token or a refresh token
+// issued for this client, and still valid. It returns the associated claims.
+func (c *Client) ParseToken(i *instance.Instance, token string) (permission.Claims, bool) {
+	claims := permission.Claims{}
+	if token == "" {
+		return claims, false
+	}
+	keyFunc := func(token *jwt.Token) (interface{}, error) {
+		return i.OAuthSecret, nil
+	}
+	if err := crypto.ParseJWT(token, keyFunc, &claims); err != nil {
+		return claims, false
+	}
+	switch claims.Audience {
+	case consts.AccessTokenAudience, consts.RefreshTokenAudience:
+	default:
+		return claims, false
+	}
+	if claims.Issuer != i.Domain || claims.Subject != c.CouchID {
+		return claims, false
+	}
+	if claims.Expired() || c.IsRevoked(claims) {
+		return claims, false
+	}
 	return claims, true
 }
 
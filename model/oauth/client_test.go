@@ -3,6 +3,7 @@ package oauth_test
 import (
 	"os"
 	"testing"
+	"time"
 
 	"github.com/cozy/cozy-stack/model/instance"
 	"github.com/cozy/cozy-stack/model/oauth"
@@ -58,6 +59,24 @@ func TestParseJWTInvalidAudience(t *testing.T) {
 	assert.False(t, ok, "The token should be invalid")
 }
 
+func TestIsRevoked(t *testing.T) {
+	revoked := &oauth.Client{CouchID: "my-revoked-client"}
+	before, err := revoked.CreateJWT(testInstance, consts.RefreshTokenAudience, "foo:read")
+	assert.NoError(t, err)
+	claims, ok := revoked.ValidToken(testInstance, consts.RefreshTokenAudience, before)
+	assert.True(t, ok)
+	revoked.RevokedAt = claims.IssuedAtMs + 1
+	_, ok = revoked.ValidToken(testInstance, consts.RefreshTokenAudience, before)
+	assert.False(t, ok, "The token issued before the revocation must be rejected")
+
+	// A token issued in the same second as the revocation is still valid
+	time.Sleep(2 * time.Millisecond)
+	after, err := revoked.CreateJWT(testInstance, consts.RefreshTokenAudience, "foo:read")
+	assert.NoError(t, err)
+	_, ok = revoked.ValidToken(testInstance, consts.RefreshTokenAudience, after)
+	assert.True(t, ok, "The token issued after the revocation must be accepted")
+}
+
 func TestCreateClient(t *testing.T) {
 	client := &oauth.Client{
 		ClientName:   "foo",
@@ -0,0 +1,228 @@
+package oauth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/cozy/cozy-stack/model/instance"
+	"github.com/cozy/cozy-stack/pkg/config/config"
+	"github.com/cozy/cozy-stack/pkg/crypto"
+)
+
+// DeviceCodeGrantType is the grant_type used by the devices to poll the
+// token endpoint with their device_code.
+// See https://tools.ietf.org/html/rfc8628
+const DeviceCodeGrantType = "urn:ietf:params:oauth:grant-type:device_code"
+
+// DeviceCodeTTL is the duration of validity for a device code.
+const DeviceCodeTTL = 10 * time.Minute
+
+// DeviceCodeInterval is the minimal duration between two polls of the token
+// endpoint by a device.
+const DeviceCodeInterval = 5 * time.Second
+
+// The user codes are made of consonants only, to avoid ambiguous characters
+// and forming words.
+const userCodeAlphabet = "BCDFGHJKLMNPQRSTVWXZ"
+const userCodeLen = 8
+
+// The status of a device code
+const (
+	DeviceCodePending  = "pending"
+	DeviceCodeApproved = "approved"
+	DeviceCodeDenied   = "denied"
+)
+
+var (
+	// ErrDeviceCodeNotFound is used when the device code is unknown or has
+	// expired.
+	ErrDeviceCodeNotFound = errors.New("expired_token")
+	// ErrAuthorizationPending is used when the user has not yet approved
+	// the device.
+	ErrAuthorizationPending = errors.New("authorization_pending")
+	// ErrSlowDown is used when the device polls too quickly.
+	ErrSlowDown = errors.New("slow_down")
+	// ErrAccessDenied is used when the user has denied the authorization.
+	ErrAccessDenied = errors.New("access_denied")
+)
+
+// DeviceCode is used for the OAuth 2.0 Device Authorization Grant: a device
+// with no browser or limited input capabilities (a TV, a CLI, etc.) displays
+// the user code, and polls the token endpoint with the device code until the
+// user has approved it from another device. It is kept in the cache storage
+// as it is only valid for a few minutes.
+type DeviceCode struct {
+	DeviceCode string    `json:"-"`
+	UserCode   string    `json:"user_code"`
+	ClientID   string    `json:"client_id"`
+	Scope      string    `json:"scope"`
+	Status     string    `json:"status"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	LastPollAt time.Time `json:"last_poll_at,omitempty"`
+}
+
+func deviceCodeKey(i *instance.Instance, deviceCode string) string {
+	return "oauth-device-code:" + i.Domain + ":" + deviceCode
+}
+
+func userCodeKey(i *instance.Instance, userCode string) string {
+	return "oauth-user-code:" + i.Domain + ":" + userCode
+}
+
+// CreateDeviceCode creates a new device code and its associated user code
+// for the given client.
+func CreateDeviceCode(i *instance.Instance, client *Client, scope string) (*DeviceCode, error) {
+	userCode, err := generateUserCode()
+	if err != nil {
+		return nil, err
+	}
+	dc := &DeviceCode{
+		DeviceCode: hex.EncodeToString(crypto.GenerateRandomBytes(32)),
+		UserCode:   userCode,
+		ClientID:   client.CouchID,
+		Scope:      scope,
+		Status:     DeviceCodePending,
+		ExpiresAt:  time.Now().Add(DeviceCodeTTL),
+	}
+	if err := dc.save(i); err != nil {
+		return nil, err
+	}
+	cache := config.GetConfig().CacheStorage
+	cache.Set(userCodeKey(i, dc.UserCode), []byte(dc.DeviceCode), DeviceCodeTTL)
+	return dc, nil
+}
+
+// FindDeviceCode returns the device code from the cache.
+func FindDeviceCode(i *instance.Instance, deviceCode string) (*DeviceCode, error) {
+	if deviceCode == "" {
+		return nil, ErrDeviceCodeNotFound
+	}
+	cache := config.GetConfig().CacheStorage
+	data, ok := cache.Get(deviceCodeKey(i, deviceCode))
+	if !ok {
+		return nil, ErrDeviceCodeNotFound
+	}
+	var dc DeviceCode
+	if err := json.Unmarshal(data, &dc); err != nil {
+		return nil, err
+	}
+	if time.Now().After(dc.ExpiresAt) {
+		return nil, ErrDeviceCodeNotFound
+	}
+	dc.DeviceCode = deviceCode
+	return &dc, nil
+}
+
+// FindDeviceCodeByUserCode returns the device code for the user code typed
+// by the user. The user code is normalized, so that the dash and the case
+// don't matter.
+func FindDeviceCodeByUserCode(i *instance.Instance, userCode string) (*DeviceCode, error) {
+	userCode = NormalizeUserCode(userCode)
+	if len(userCode) != userCodeLen+1 {
+		return nil, ErrDeviceCodeNotFound
+	}
+	cache := config.GetConfig().CacheStorage
+	deviceCode, ok := cache.Get(userCodeKey(i, userCode))
+	if !ok {
+		return nil, ErrDeviceCodeNotFound
+	}
+	dc, err := FindDeviceCode(i, string(deviceCode))
+	if err != nil {
+		return nil, err
+	}
+	if dc.Status != DeviceCodePending {
+		return nil, ErrDeviceCodeNotFound
+	}
+	return dc, nil
+}
+
+// NormalizeUserCode puts the user code in the XXXX-XXXX form.
+func NormalizeUserCode(userCode string) string {
+	userCode = strings.ToUpper(userCode)
+	var b strings.Builder
+	for _, r := range userCode {
+		if strings.ContainsRune(userCodeAlphabet, r) {
+			b.WriteRune(r)
+		}
+	}
+	code := b.String()
+	if len(code) != userCodeLen {
+		return code
+	}
+	return code[:userCodeLen/2] + "-" + code[userCodeLen/2:]
+}
+
+// Approve is called when the user has approved the device.
+func (dc *DeviceCode) Approve(i *instance.Instance, client *Client) error {
+	client.clearPending(i)
+	dc.Status = DeviceCodeApproved
+	return dc.save(i)
+}
+
+// Deny is called when the user has denied the authorization for the device.
+func (dc *DeviceCode) Deny(i *instance.Instance) error {
+	dc.Status = DeviceCodeDenied
+	return dc.save(i)
+}
+
+// Poll is called when the device asks for tokens. It returns nil only if the
+// user has approved the device, and the device code can then no longer be
+// used.
+func (dc *DeviceCode) Poll(i *instance.Instance) error {
+	now := time.Now()
+	tooFast := now.Sub(dc.LastPollAt) < DeviceCodeInterval
+	dc.LastPollAt = now
+	switch dc.Status {
+	case DeviceCodeApproved:
+		dc.delete(i)
+		return nil
+	case DeviceCodeDenied:
+		dc.delete(i)
+		return ErrAccessDenied
+	}
+	if err := dc.save(i); err != nil {
+		return err
+	}
+	if tooFast {
+		return ErrSlowDown
+	}
+	return ErrAuthorizationPending
+}
+
+func (dc *DeviceCode) save(i *instance.Instance) error {
+	ttl := time.Until(dc.ExpiresAt)
+	if ttl <= 0 {
+		return ErrDeviceCodeNotFound
+	}
+	data, err := json.Marshal(dc)
+	if err != nil {
+		return err
+	}
+	cache := config.GetConfig().CacheStorage
+	cache.Set(deviceCodeKey(i, dc.DeviceCode), data, ttl)
+	return nil
+}
+
+func (dc *DeviceCode) delete(i *instance.Instance) {
+	cache := config.GetConfig().CacheStorage
+	cache.Clear(deviceCodeKey(i, dc.DeviceCode))
+	cache.Clear(userCodeKey(i, dc.UserCode))
+}
+
+func generateUserCode() (string, error) {
+	max := big.NewInt(int64(len(userCodeAlphabet)))
+	code := make([]byte, userCodeLen)
+	for j := range code {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		code[j] = userCodeAlphabet[n.Int64()]
+	}
+	return NormalizeUserCode(string(code)), nil
+}
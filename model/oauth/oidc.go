@@ -0,0 +1,228 @@
+package oauth
+
+import (
+	"strings"
+	"time"
+
+	"github.com/cozy/cozy-stack/model/contact"
+	"github.com/cozy/cozy-stack/model/instance"
+	"github.com/cozy/cozy-stack/pkg/couchdb"
+	"github.com/cozy/cozy-stack/pkg/crypto"
+	jwt "github.com/golang-jwt/jwt/v4"
+)
+
+// The scopes defined by OpenID Connect. They are not permissions on the
+// doctypes, but they give access to the identity of the owner of the
+// instance, via the ID token and the userinfo endpoint.
+// See https://openid.net/specs/openid-connect-core-1_0.html#ScopeClaims
+const (
+	ScopeOpenID  = "openid"
+	ScopeProfile = "profile"
+	ScopeEmail   = "email"
+	ScopePhone   = "phone"
+	ScopeAddress = "address"
+)
+
+// OIDCScopes is the list of the OpenID Connect scopes supported by the stack.
+var OIDCScopes = []string{ScopeOpenID, ScopeProfile, ScopeEmail, ScopePhone, ScopeAddress}
+
+// IDTokenTTL is the duration of validity of an ID token.
+const IDTokenTTL = 1 * time.Hour
+
+// IsOIDCScope returns true if the given scope is one of the OpenID Connect
+// scopes.
+func IsOIDCScope(scope string) bool {
+	for _, s := range OIDCScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// SplitScope separates the OpenID Connect scopes from the permissions in the
+// scope requested by a client. The permissions are returned as a scope
+// string, and can be empty.
+func SplitScope(scope string) ([]string, string) {
+	var oidc, perms []string
+	for _, s := range strings.Fields(scope) {
+		if IsOIDCScope(s) {
+			oidc = append(oidc, s)
+		} else {
+			perms = append(perms, s)
+		}
+	}
+	return oidc, strings.Join(perms, " ")
+}
+
+// HasOpenIDScope returns true if the scope has been requested by a client
+// for OpenID Connect.
+func HasOpenIDScope(scope string) bool {
+	oidc, _ := SplitScope(scope)
+	for _, s := range oidc {
+		if s == ScopeOpenID {
+			return true
+		}
+	}
+	return false
+}
+
+// IDTokenClaims are the claims of an ID token.
+// See https://openid.net/specs/openid-connect-core-1_0.html#IDToken
+type IDTokenClaims struct {
+	crypto.StandardClaims
+	Nonce string `json:"nonce,omitempty"`
+	UserInfo
+}
+
+// UserInfo is the set of claims about the owner of the instance that can be
+// given to a client, depending on the scopes it has been granted.
+type UserInfo struct {
+	Name          string                 `json:"name,omitempty"`
+	GivenName     string                 `json:"given_name,omitempty"`
+	FamilyName    string                 `json:"family_name,omitempty"`
+	Locale        string                 `json:"locale,omitempty"`
+	Website       string                 `json:"website,omitempty"`
+	Email         string                 `json:"email,omitempty"`
+	EmailVerified *bool                  `json:"email_verified,omitempty"`
+	PhoneNumber   string                 `json:"phone_number,omitempty"`
+	Address       map[string]interface{} `json:"address,omitempty"`
+}
+
+// Issuer returns the issuer identifier of the instance for OpenID Connect.
+func Issuer(i *instance.Instance) string {
+	return strings.TrimSuffix(i.PageURL("", nil), "/")
+}
+
+// GetUserInfo returns the claims about the owner of the instance for the
+// given scope. They are built from the myself contact.
+func GetUserInfo(i *instance.Instance, scope string) (*UserInfo, error) {
+	info := &UserInfo{}
+	oidc, _ := SplitScope(scope)
+	if len(oidc) == 0 {
+		return info, nil
+	}
+
+	myself, err := contact.GetMyself(i)
+	if err != nil && err != contact.ErrNotFound && !couchdb.IsNoDatabaseError(err) {
+		return nil, err
+	}
+	if myself == nil {
+		myself = contact.New()
+	}
+
+	for _, s := range oidc {
+		switch s {
+		case ScopeProfile:
+			info.Name = myself.PrimaryName()
+			if name, ok := myself.Get("name").(map[string]interface{}); ok {
+				info.GivenName, _ = name["givenName"].(string)
+				info.FamilyName, _ = name["familyName"].(string)
+			}
+			if info.Name == "" {
+				info.Name, _ = i.PublicName()
+			}
+			info.Locale = i.Locale
+			info.Website = Issuer(i)
+		case ScopeEmail:
+			if addr, err := myself.ToMailAddress(); err == nil && addr.Email != "" {
+				info.Email = addr.Email
+				verified := emailVerified(i, addr.Email)
+				info.EmailVerified = &verified
+			}
+		case ScopePhone:
+			info.PhoneNumber = myself.PrimaryPhoneNumber()
+		case ScopeAddress:
+			info.Address = primaryAddress(myself)
+		}
+	}
+	return info, nil
+}
+
+// emailVerified returns true if the email address is the one where the
+// passcodes of the two factor authentication are sent: the user has proved
+// that they can read the emails sent to this address, when activating the
+// two factor authentication and each time they log in.
+func emailVerified(i *instance.Instance, email string) bool {
+	if !i.HasAuthMode(instance.TwoFactorMail) {
+		return false
+	}
+	settingsEmail, err := i.SettingsEMail()
+	return err == nil && strings.EqualFold(settingsEmail, email)
+}
+
+// primaryAddress returns the preferred postal address of the contact in the
+// format of the address claim of OpenID Connect.
+func primaryAddress(c *contact.Contact) map[string]interface{} {
+	addresses, ok := c.Get("address").([]interface{})
+	if !ok || len(addresses) == 0 {
+		return nil
+	}
+	var addr map[string]interface{}
+	for i := range addresses {
+		a, ok := addresses[i].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if primary, ok := a["primary"].(bool); ok && primary {
+			addr = a
+		}
+		if addr == nil {
+			addr = a
+		}
+	}
+	if addr == nil {
+		return nil
+	}
+	claim := make(map[string]interface{})
+	fields := map[string]string{
+		"formattedAddress": "formatted",
+		"street":           "street_address",
+		"city":             "locality",
+		"region":           "region",
+		"code":             "postal_code",
+		"country":          "country",
+	}
+	for from, to := range fields {
+		if v, ok := addr[from].(string); ok && v != "" {
+			claim[to] = v
+		}
+	}
+	if len(claim) == 0 {
+		return nil
+	}
+	return claim
+}
+
+// CreateIDToken returns an ID token for the given client, signed with the
+// RSA key of the instance.
+func (c *Client) CreateIDToken(i *instance.Instance, scope, nonce string) (string, error) {
+	key, err := i.OIDCSigningKey()
+	if err != nil {
+		return "", err
+	}
+	info, err := GetUserInfo(i, scope)
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+	claims := IDTokenClaims{
+		StandardClaims: crypto.StandardClaims{
+			Audience:  c.CouchID,
+			Issuer:    Issuer(i),
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(IDTokenTTL).Unix(),
+			Subject:   i.ID(),
+		},
+		Nonce:    nonce,
+		UserInfo: *info,
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = instance.OIDCKeyID(&key.PublicKey)
+	signed, err := token.SignedString(key)
+	if err != nil {
+		i.Logger().WithNamespace("oauth").
+			Errorf("Failed to create the ID token: %s", err)
+	}
+	return signed, err
+}
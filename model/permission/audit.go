@@ -0,0 +1,21 @@
+package permission
+
+import (
+	"github.com/cozy/cozy-stack/model/audit"
+	"github.com/cozy/cozy-stack/pkg/consts"
+	"github.com/cozy/cozy-stack/pkg/prefixer"
+)
+
+// recordAudit adds an entry to the audit log for the given permission doc.
+func recordAudit(db prefixer.Prefixer, action string, doc *Permission) {
+	details := map[string]interface{}{"type": doc.Type}
+	if scope, err := doc.Permissions.MarshalScopeString(); err == nil {
+		details["scope"] = scope
+	}
+	audit.Record(db, &audit.Entry{
+		Action:  action,
+		Actor:   doc.SourceID,
+		Target:  consts.Permissions + "/" + doc.ID(),
+		Details: details,
+	})
+}
@@ -13,6 +13,11 @@ type Claims struct {
 	Scope     string `json:"scope,omitempty"`
 	SessionID string `json:"session_id,omitempty"`
 	SStamp    string `json:"stamp,omitempty"`
+
+	// IssuedAtMs is the same date as IssuedAt, but with a precision in
+	// milliseconds. It is used to know if a token has been issued before or
+	// after the revocation of the tokens of an OAuth client.
+	IssuedAtMs int64 `json:"iat_ms,omitempty"`
 }
 
 // IssuedAtUTC returns a time.Time struct of the IssuedAt field in UTC
@@ -21,6 +26,16 @@ func (claims *Claims) IssuedAtUTC() time.Time {
 	return time.Unix(claims.IssuedAt, 0).UTC()
 }
 
+// IssuedAtMillis returns the date of issuance of the token, in milliseconds
+// since the Unix epoch. For the tokens that don't have the iat_ms claim, it
+// is derived from the IssuedAt field.
+func (claims *Claims) IssuedAtMillis() int64 {
+	if claims.IssuedAtMs > 0 {
+		return claims.IssuedAtMs
+	}
+	return claims.IssuedAt * 1000
+}
+
 // Expired returns true if a Claim is expired
 func (claims *Claims) Expired() bool {
 	var validityDuration time.Duration
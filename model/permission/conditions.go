@@ -0,0 +1,399 @@
+package permission
+
+import (
+	"errors"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ErrInvalidConditions is used when the conditions of a rule are malformed.
+var ErrInvalidConditions = echo.NewHTTPError(http.StatusBadRequest,
+	"Permission conditions are malformed")
+
+// Conditions are optional restrictions on a rule, that are evaluated in
+// addition to the verbs, the selector and the values.
+type Conditions struct {
+	// NotBefore and NotAfter restrict the period when the rule can be used
+	NotBefore *time.Time `json:"not_before,omitempty"`
+	NotAfter  *time.Time `json:"not_after,omitempty"`
+
+	// TimeWindows restricts the rule to some days and hours. The rule can be
+	// used if the current time is in at least one of the windows.
+	TimeWindows []TimeWindow `json:"time_windows,omitempty"`
+
+	// PathPrefixes restricts a rule on io.cozy.files to the files and
+	// directories inside the given directories (like /Documents/Invoices).
+	PathPrefixes []string `json:"path_prefixes,omitempty"`
+
+	// MaxFileSize is the maximal size in bytes for the files of a rule on
+	// io.cozy.files.
+	MaxFileSize int64 `json:"max_file_size,omitempty"`
+
+	// Fields is the list of the fields that can be read for the documents.
+	// The other fields are stripped by the data API.
+	Fields []string `json:"fields,omitempty"`
+}
+
+// TimeWindow is a range of hours in a day, like from 09:00 to 18:00, on some
+// days of the week.
+type TimeWindow struct {
+	// Days are the days of the week, with 0 for Sunday, 1 for Monday, etc.
+	// An empty list means every day.
+	Days []time.Weekday `json:"days,omitempty"`
+	// From and To are in the HH:MM format. To is excluded.
+	From string `json:"from"`
+	To   string `json:"to"`
+	// Timezone is the name of a location from the IANA Time Zone database,
+	// like Europe/Paris. UTC is used by default.
+	Timezone string `json:"timezone,omitempty"`
+}
+
+// alwaysAllowedFields are the fields that are never stripped from the
+// documents.
+var alwaysAllowedFields = []string{"_id", "_rev", "_type", "id", "type"}
+
+// Validate returns an error if the conditions are malformed.
+func (c *Conditions) Validate() error {
+	if c == nil {
+		return nil
+	}
+	if c.NotBefore != nil && c.NotAfter != nil && !c.NotBefore.Before(*c.NotAfter) {
+		return ErrInvalidConditions
+	}
+	for _, w := range c.TimeWindows {
+		if err := w.validate(); err != nil {
+			return ErrInvalidConditions
+		}
+	}
+	for _, p := range c.PathPrefixes {
+		if !strings.HasPrefix(p, "/") || path.Clean(p) != p {
+			return ErrInvalidConditions
+		}
+	}
+	if c.MaxFileSize < 0 {
+		return ErrInvalidConditions
+	}
+	for _, f := range c.Fields {
+		if f == "" {
+			return ErrInvalidConditions
+		}
+	}
+	return nil
+}
+
+// AllowNow returns true if the rule can be used at the given time.
+func (c *Conditions) AllowNow(now time.Time) bool {
+	if c == nil {
+		return true
+	}
+	if c.NotBefore != nil && now.Before(*c.NotBefore) {
+		return false
+	}
+	if c.NotAfter != nil && now.After(*c.NotAfter) {
+		return false
+	}
+	if len(c.TimeWindows) == 0 {
+		return true
+	}
+	for _, w := range c.TimeWindows {
+		if w.contains(now) {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowPath returns true if the given path of a file or directory is inside
+// one of the path prefixes.
+func (c *Conditions) AllowPath(fullpath string) bool {
+	if c == nil || len(c.PathPrefixes) == 0 {
+		return true
+	}
+	for _, prefix := range c.PathPrefixes {
+		if prefix == "/" || fullpath == prefix || strings.HasPrefix(fullpath, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowSize returns true if a file with the given size is accepted.
+func (c *Conditions) AllowSize(size int64) bool {
+	if c == nil || c.MaxFileSize == 0 {
+		return true
+	}
+	return size <= c.MaxFileSize
+}
+
+// RestrictsDocuments returns true if the conditions can't be evaluated
+// without knowing the document, ie for a path prefix or a maximal file size.
+func (c *Conditions) RestrictsDocuments() bool {
+	return c != nil && (len(c.PathPrefixes) > 0 || c.MaxFileSize > 0)
+}
+
+// Contains returns true if all the documents allowed by other are also
+// allowed by c. It is used to check that a permission set is a subset of its
+// parent.
+func (c *Conditions) Contains(other *Conditions) bool {
+	if c == nil {
+		return true
+	}
+	if other == nil {
+		return false
+	}
+	if c.NotBefore != nil && (other.NotBefore == nil || other.NotBefore.Before(*c.NotBefore)) {
+		return false
+	}
+	if c.NotAfter != nil && (other.NotAfter == nil || other.NotAfter.After(*c.NotAfter)) {
+		return false
+	}
+	if len(c.TimeWindows) > 0 {
+		for _, w := range other.TimeWindows {
+			if !c.hasTimeWindow(w) {
+				return false
+			}
+		}
+		if len(other.TimeWindows) == 0 {
+			return false
+		}
+	}
+	if len(c.PathPrefixes) > 0 {
+		if len(other.PathPrefixes) == 0 {
+			return false
+		}
+		for _, p := range other.PathPrefixes {
+			if !c.AllowPath(p) {
+				return false
+			}
+		}
+	}
+	if c.MaxFileSize > 0 && (other.MaxFileSize == 0 || other.MaxFileSize > c.MaxFileSize) {
+		return false
+	}
+	if len(c.Fields) > 0 {
+		if len(other.Fields) == 0 {
+			return false
+		}
+		for _, f := range other.Fields {
+			if !contains(c.Fields, f) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (c *Conditions) hasTimeWindow(w TimeWindow) bool {
+	for _, cw := range c.TimeWindows {
+		if cw.From == w.From && cw.To == w.To && cw.Timezone == w.Timezone &&
+			sameDays(cw.Days, w.Days) {
+			return true
+		}
+	}
+	return false
+}
+
+func sameDays(a, b []time.Weekday) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (w TimeWindow) validate() error {
+	from, err := parseClock(w.From)
+	if err != nil {
+		return err
+	}
+	to, err := parseClock(w.To)
+	if err != nil {
+		return err
+	}
+	if from == to {
+		return errors.New("empty time window")
+	}
+	for _, d := range w.Days {
+		if d < time.Sunday || d > time.Saturday {
+			return errors.New("invalid day")
+		}
+	}
+	if w.Timezone != "" {
+		if _, err := time.LoadLocation(w.Timezone); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w TimeWindow) contains(now time.Time) bool {
+	loc := time.UTC
+	if w.Timezone != "" {
+		l, err := time.LoadLocation(w.Timezone)
+		if err != nil {
+			return false
+		}
+		loc = l
+	}
+	from, err := parseClock(w.From)
+	if err != nil {
+		return false
+	}
+	to, err := parseClock(w.To)
+	if err != nil {
+		return false
+	}
+	local := now.In(loc)
+	minutes := local.Hour()*60 + local.Minute()
+	day := local.Weekday()
+	// A window like 22:00-06:00 goes over midnight, and the early hours are
+	// attached to the day when the window has started.
+	if from > to && minutes < to {
+		day = (day + 6) % 7
+	}
+	if len(w.Days) > 0 {
+		found := false
+		for _, d := range w.Days {
+			if d == day {
+				found = true
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if from < to {
+		return from <= minutes && minutes < to
+	}
+	return minutes >= from || minutes < to
+}
+
+// parseClock returns the number of minutes since midnight for a HH:MM time.
+func parseClock(clock string) (int, error) {
+	parts := strings.SplitN(clock, ":", 2)
+	if len(parts) != 2 || len(parts[0]) != 2 || len(parts[1]) != 2 {
+		return 0, errors.New("invalid time")
+	}
+	h, err := strconv.Atoi(parts[0])
+	if err != nil || h < 0 || h > 24 {
+		return 0, errors.New("invalid time")
+	}
+	m, err := strconv.Atoi(parts[1])
+	if err != nil || m < 0 || m > 59 || (h == 24 && m != 0) {
+		return 0, errors.New("invalid time")
+	}
+	return h*60 + m, nil
+}
+
+// AllowedFields returns the list of the fields that can be read with the
+// verb on the documents of the given doctype. It returns nil if there is no
+// restriction on the fields.
+func (s Set) AllowedFields(v Verb, doctype string) []string {
+	var fields []string
+	now := time.Now()
+	for _, r := range s {
+		if !matchVerb(r, v) || !MatchType(r, doctype) || !r.Conditions.AllowNow(now) {
+			continue
+		}
+		if r.Conditions == nil || len(r.Conditions.Fields) == 0 {
+			return nil
+		}
+		for _, f := range r.Conditions.Fields {
+			if !contains(fields, f) {
+				fields = append(fields, f)
+			}
+		}
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	return append(fields, alwaysAllowedFields...)
+}
+
+// StripFields removes from the document the fields that are not in the list
+// of allowed fields. A nil list means that all the fields are allowed.
+func StripFields(doc map[string]interface{}, allowed []string) {
+	if allowed == nil {
+		return
+	}
+	for k := range doc {
+		if !contains(allowed, k) {
+			delete(doc, k)
+		}
+	}
+}
+
+// AllowQueryOnFields returns true if a mango query (for _find) or an index
+// definition (for _index) uses only the allowed fields in its selector, its
+// sort and its index fields. Else, the results of the query could be used to
+// guess the values of the fields that are stripped. A nil list means that all
+// the fields are allowed.
+func AllowQueryOnFields(query map[string]interface{}, allowed []string) bool {
+	if allowed == nil {
+		return true
+	}
+	var fields []string
+	selectorFields(query["selector"], &fields)
+	sortFields(query["sort"], &fields)
+	if index, ok := query["index"].(map[string]interface{}); ok {
+		sortFields(index["fields"], &fields)
+		selectorFields(index["partial_filter_selector"], &fields)
+	}
+	for _, f := range fields {
+		// The restriction is made on the top-level fields, like for
+		// StripFields
+		f = strings.SplitN(f, ".", 2)[0]
+		if !contains(allowed, f) {
+			return false
+		}
+	}
+	return true
+}
+
+// selectorFields adds to the list the fields used in a mango selector. The
+// keys starting with a $ are operators, and the other keys are fields. The
+// value of a field is not explored, as it can only reference its sub-fields.
+func selectorFields(selector interface{}, fields *[]string) {
+	switch s := selector.(type) {
+	case map[string]interface{}:
+		for k, v := range s {
+			if strings.HasPrefix(k, "$") {
+				selectorFields(v, fields)
+			} else {
+				*fields = append(*fields, k)
+			}
+		}
+	case []interface{}:
+		for _, v := range s {
+			selectorFields(v, fields)
+		}
+	}
+}
+
+// sortFields adds to the list the fields used in a mango sort, like
+// ["name", {"date": "desc"}].
+func sortFields(sort interface{}, fields *[]string) {
+	list, ok := sort.([]interface{})
+	if !ok {
+		return
+	}
+	for _, item := range list {
+		switch f := item.(type) {
+		case string:
+			*fields = append(*fields, f)
+		case map[string]interface{}:
+			for k := range f {
+				*fields = append(*fields, k)
+			}
+		}
+	}
+}
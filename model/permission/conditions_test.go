@@ -0,0 +1,131 @@
+package permission
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConditionsValidate(t *testing.T) {
+	var s Set
+	err := json.Unmarshal([]byte(`{
+		"files": {
+			"type": "io.cozy.files",
+			"conditions": {
+				"path_prefixes": ["/Documents"],
+				"time_windows": [{"from": "09:00", "to": "18:00", "timezone": "Europe/Paris"}]
+			}
+		}
+	}`), &s)
+	assert.NoError(t, err)
+	assert.Len(t, s, 1)
+	assert.Equal(t, []string{"/Documents"}, s[0].Conditions.PathPrefixes)
+
+	for _, invalid := range []string{
+		`{"path_prefixes": ["Documents"]}`,
+		`{"path_prefixes": ["/Documents/"]}`,
+		`{"max_file_size": -1}`,
+		`{"time_windows": [{"from": "9:00", "to": "18:00"}]}`,
+		`{"time_windows": [{"from": "09:00", "to": "18:00", "days": [7]}]}`,
+		`{"time_windows": [{"from": "09:00", "to": "18:00", "timezone": "Nowhere/Foo"}]}`,
+		`{"not_before": "2022-10-18T00:00:00Z", "not_after": "2022-10-17T00:00:00Z"}`,
+	} {
+		err = json.Unmarshal([]byte(`{"r": {"type": "io.cozy.files", "conditions": `+invalid+`}}`), &s)
+		assert.Equal(t, ErrInvalidConditions, err, invalid)
+	}
+}
+
+func TestConditionsTimeWindows(t *testing.T) {
+	c := &Conditions{
+		TimeWindows: []TimeWindow{
+			{Days: []time.Weekday{time.Monday}, From: "09:00", To: "18:00"},
+			{Days: []time.Weekday{time.Friday}, From: "22:00", To: "06:00"},
+		},
+	}
+	// 2022-10-17 is a Monday
+	assert.True(t, c.AllowNow(time.Date(2022, 10, 17, 9, 0, 0, 0, time.UTC)))
+	assert.False(t, c.AllowNow(time.Date(2022, 10, 17, 18, 0, 0, 0, time.UTC)))
+	assert.False(t, c.AllowNow(time.Date(2022, 10, 18, 10, 0, 0, 0, time.UTC)))
+	assert.True(t, c.AllowNow(time.Date(2022, 10, 21, 23, 0, 0, 0, time.UTC)))
+	assert.True(t, c.AllowNow(time.Date(2022, 10, 22, 5, 59, 0, 0, time.UTC)))
+	assert.False(t, c.AllowNow(time.Date(2022, 10, 21, 5, 0, 0, 0, time.UTC)))
+
+	past := time.Now().Add(-time.Hour)
+	assert.False(t, (&Conditions{NotAfter: &past}).AllowNow(time.Now()))
+	assert.True(t, (&Conditions{NotBefore: &past}).AllowNow(time.Now()))
+}
+
+func TestConditionsInSetMatching(t *testing.T) {
+	s := Set{
+		Rule{
+			Type:       "io.cozy.files",
+			Verbs:      Verbs(POST),
+			Conditions: &Conditions{MaxFileSize: 1000},
+		},
+		Rule{
+			Type:       "io.cozy.contacts",
+			Verbs:      Verbs(GET),
+			Conditions: &Conditions{Fields: []string{"fullname"}},
+		},
+		Rule{
+			Type:       "io.cozy.events",
+			Verbs:      Verbs(GET),
+			Conditions: &Conditions{PathPrefixes: []string{"/Documents"}},
+		},
+	}
+	assert.True(t, s.Allow(POST, &validable{doctype: "io.cozy.files", values: map[string]string{"size": "1000"}}))
+	assert.False(t, s.Allow(POST, &validable{doctype: "io.cozy.files", values: map[string]string{"size": "1001"}}))
+	assert.False(t, s.AllowWholeType(POST, "io.cozy.files"))
+	assert.True(t, s.AllowWholeType(GET, "io.cozy.contacts"))
+	assert.False(t, s.Allow(GET, &validable{doctype: "io.cozy.events"}))
+
+	fields := s.AllowedFields(GET, "io.cozy.contacts")
+	assert.Contains(t, fields, "fullname")
+	assert.Contains(t, fields, "_id")
+	doc := map[string]interface{}{"_id": "1", "_rev": "1-a", "fullname": "Alice", "email": "a@b.c"}
+	StripFields(doc, fields)
+	assert.Equal(t, map[string]interface{}{"_id": "1", "_rev": "1-a", "fullname": "Alice"}, doc)
+	assert.Nil(t, s.AllowedFields(POST, "io.cozy.files"))
+}
+
+func TestConditionsSubset(t *testing.T) {
+	parent := Set{Rule{
+		Type:       "io.cozy.files",
+		Verbs:      ALL,
+		Conditions: &Conditions{PathPrefixes: []string{"/Documents"}},
+	}}
+	child := Set{Rule{
+		Type:       "io.cozy.files",
+		Verbs:      Verbs(GET),
+		Conditions: &Conditions{PathPrefixes: []string{"/Documents/Invoices"}},
+	}}
+	assert.True(t, child.IsSubSetOf(parent))
+
+	child[0].Conditions.PathPrefixes = []string{"/Photos"}
+	assert.False(t, child.IsSubSetOf(parent))
+
+	child[0].Conditions = nil
+	assert.False(t, child.IsSubSetOf(parent))
+}
+
+func TestAllowQueryOnFields(t *testing.T) {
+	allowed := []string{"fullname", "metadata", "_id"}
+	query := func(s string) map[string]interface{} {
+		var q map[string]interface{}
+		assert.NoError(t, json.Unmarshal([]byte(s), &q))
+		return q
+	}
+
+	assert.True(t, AllowQueryOnFields(query(`{"selector": {"email": "a@b.c"}}`), nil))
+	assert.True(t, AllowQueryOnFields(query(`{"selector": {"fullname": {"$gt": null}}, "sort": ["fullname"]}`), allowed))
+	assert.True(t, AllowQueryOnFields(query(`{"selector": {"$or": [{"fullname": "Alice"}, {"metadata.version": 2}]}}`), allowed))
+	assert.True(t, AllowQueryOnFields(query(`{"selector": {"metadata": {"email": "a@b.c"}}}`), allowed))
+	assert.False(t, AllowQueryOnFields(query(`{"selector": {"email": "a@b.c"}}`), allowed))
+	assert.False(t, AllowQueryOnFields(query(`{"selector": {"$and": [{"fullname": "Alice"}, {"email": {"$regex": "^a"}}]}}`), allowed))
+	assert.False(t, AllowQueryOnFields(query(`{"selector": {"fullname": "Alice"}, "sort": [{"email": "desc"}]}`), allowed))
+	assert.True(t, AllowQueryOnFields(query(`{"index": {"fields": ["fullname"]}}`), allowed))
+	assert.False(t, AllowQueryOnFields(query(`{"index": {"fields": ["fullname", "email"]}}`), allowed))
+	assert.False(t, AllowQueryOnFields(query(`{"index": {"fields": ["fullname"], "partial_filter_selector": {"email": {"$exists": true}}}}`), allowed))
+}
@@ -18,13 +18,17 @@ var blockList = map[string]bool{
 	consts.Instances:             none,
 	consts.AccountTypes:          none,
 	consts.KonnectorsMaintenance: none,
+	consts.AppsRollouts:          none,
+	consts.AppsRolloutInstances:  none,
 	consts.RemoteSecrets:         none,
+	consts.RegistryVersions:      none,
 
 	// Only stack can manipulate them
 	consts.Sessions:         none,
 	consts.Permissions:      none,
 	consts.Intents:          none,
 	consts.OAuthClients:     none,
+	consts.AppPasswords:     none,
 	consts.OAuthAccessCodes: none,
 	consts.Archives:         none,
 	consts.Sharings:         none,
@@ -59,8 +63,11 @@ var blockList = map[string]bool{
 	consts.Notifications:     readable,
 	consts.RemoteRequests:    readable,
 	consts.SessionsLogins:    readable,
+	consts.AuditLogs:         readable,
 	consts.NotesSteps:        readable,
 	consts.NotesImages:       readable,
+	consts.NotesVersions:     readable,
+	consts.NotesComments:     readable,
 	consts.BitwardenContacts: readable,
 }
 
@@ -38,4 +38,25 @@ var (
 	// ErrNotParent is used when the permissions should have a specific parent.
 	ErrNotParent = echo.NewHTTPError(http.StatusForbidden,
 		"Permissions can be updated only by its parent")
+
+	// ErrSharePasswordRequired is used when a share by link is protected by a
+	// password and the link has not been unlocked.
+	ErrSharePasswordRequired = echo.NewHTTPError(http.StatusUnauthorized,
+		"A password is required for this link")
+
+	// ErrSharePasswordInvalid is used when the password given to unlock a
+	// share by link is not the good one.
+	ErrSharePasswordInvalid = echo.NewHTTPError(http.StatusForbidden,
+		"Invalid password for this link")
+
+	// ErrShareLinkExhausted is used when the maximal number of views or
+	// downloads for a share by link has been reached.
+	ErrShareLinkExhausted = echo.NewHTTPError(http.StatusGone,
+		"This link can no longer be used")
+
+	// ErrShareLinkConflict is used when the counters of a share by link can't
+	// be updated, as the link is used by many visitors at the same time. The
+	// request can be retried.
+	ErrShareLinkConflict = echo.NewHTTPError(http.StatusServiceUnavailable,
+		"This link is used by too many visitors, please try again")
 )
@@ -0,0 +1,134 @@
+package permission
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cozy/cozy-stack/model/audit"
+	"github.com/cozy/cozy-stack/pkg/consts"
+	"github.com/cozy/cozy-stack/pkg/couchdb"
+	"github.com/cozy/cozy-stack/pkg/prefixer"
+	"github.com/labstack/echo/v4"
+)
+
+// ErrInvalidFileDrop is used when the permission set or the options of a file
+// drop link are not valid.
+var ErrInvalidFileDrop = echo.NewHTTPError(http.StatusBadRequest,
+	"A file drop link must have only the POST verb on one directory")
+
+// FileDropOptions are the limits for the files uploaded via a file drop link.
+type FileDropOptions struct {
+	// MaxFileSize is the maximal size in bytes for an uploaded file
+	MaxFileSize int64 `json:"max_file_size,omitempty"`
+	// MaxFiles is the maximal number of files that can be uploaded with
+	// this link
+	MaxFiles int `json:"max_files,omitempty"`
+	// Files is the number of files already uploaded with this link
+	Files int `json:"files,omitempty"`
+	// AllowedTypes is a list of mime types (like application/pdf or image/*)
+	// for the uploaded files. An empty list means any type.
+	AllowedTypes []string `json:"allowed_types,omitempty"`
+	// RequireName can be set to force the visitors to give their name
+	RequireName bool `json:"require_name,omitempty"`
+}
+
+// Clone returns a copy of the options
+func (o *FileDropOptions) Clone() *FileDropOptions {
+	cloned := *o
+	cloned.AllowedTypes = make([]string, len(o.AllowedTypes))
+	copy(cloned.AllowedTypes, o.AllowedTypes)
+	return &cloned
+}
+
+// Validate returns an error if the options are not valid.
+func (o *FileDropOptions) Validate() error {
+	if o.MaxFileSize < 0 || o.MaxFiles < 0 {
+		return ErrInvalidFileDrop
+	}
+	for _, typ := range o.AllowedTypes {
+		if !strings.Contains(typ, "/") {
+			return ErrInvalidFileDrop
+		}
+	}
+	return nil
+}
+
+// Exhausted returns true if the maximal number of files has been reached.
+func (o *FileDropOptions) Exhausted() bool {
+	return o != nil && o.MaxFiles > 0 && o.Files >= o.MaxFiles
+}
+
+// AllowSize returns true if a file with this size can be uploaded.
+func (o *FileDropOptions) AllowSize(size int64) bool {
+	return o == nil || o.MaxFileSize == 0 || size <= o.MaxFileSize
+}
+
+// AllowMime returns true if a file with this mime type can be uploaded.
+func (o *FileDropOptions) AllowMime(mime string) bool {
+	if o == nil || len(o.AllowedTypes) == 0 {
+		return true
+	}
+	for _, typ := range o.AllowedTypes {
+		if typ == mime {
+			return true
+		}
+		if strings.HasSuffix(typ, "/*") && strings.HasPrefix(mime, strings.TrimSuffix(typ, "*")) {
+			return true
+		}
+	}
+	return false
+}
+
+// FileDropDirID returns the identifier of the directory where the files are
+// uploaded for a file drop link.
+func (p *Permission) FileDropDirID() string {
+	if p.Type != TypeFileDrop || len(p.Permissions) != 1 || len(p.Permissions[0].Values) != 1 {
+		return ""
+	}
+	return p.Permissions[0].Values[0]
+}
+
+// CreateFileDropSet creates a Permission doc for a file drop link. The
+// permission set must have a single rule, with only the POST verb on one
+// directory.
+func CreateFileDropSet(db prefixer.Prefixer, parent *Permission, sourceID string, codes, shortcodes map[string]string, subdoc Permission, expiresAt *time.Time) (*Permission, error) {
+	set := subdoc.Permissions
+	if len(set) != 1 {
+		return nil, ErrInvalidFileDrop
+	}
+	r := set[0]
+	if r.Type != consts.Files || r.Selector != "" || len(r.Values) != 1 ||
+		!r.Verbs.Contains(POST) || len(r.Verbs) != 1 {
+		return nil, ErrInvalidFileDrop
+	}
+	if err := checkSetPermissions(set, parent); err != nil {
+		return nil, err
+	}
+
+	opts := subdoc.FileDrop
+	if opts == nil {
+		opts = &FileDropOptions{}
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	opts.Files = 0
+
+	doc := &Permission{
+		Type:        TypeFileDrop,
+		SourceID:    sourceID,
+		Permissions: set,
+		Codes:       codes,
+		ShortCodes:  shortcodes,
+		ExpiresAt:   expiresAt,
+		Metadata:    subdoc.Metadata,
+		Password:    subdoc.Password,
+		FileDrop:    opts,
+	}
+	if err := couchdb.CreateDoc(db, doc); err != nil {
+		return nil, err
+	}
+	recordAudit(db, audit.ActionShareLinkCreated, doc)
+	return doc, nil
+}
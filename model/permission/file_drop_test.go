@@ -0,0 +1,46 @@
+package permission
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileDropOptions(t *testing.T) {
+	var opts *FileDropOptions
+	assert.True(t, opts.AllowMime("application/pdf"))
+	assert.True(t, opts.AllowSize(1<<40))
+	assert.False(t, opts.Exhausted())
+
+	opts = &FileDropOptions{
+		MaxFileSize:  1000,
+		MaxFiles:     2,
+		AllowedTypes: []string{"application/pdf", "image/*"},
+	}
+	assert.NoError(t, opts.Validate())
+	assert.True(t, opts.AllowMime("application/pdf"))
+	assert.True(t, opts.AllowMime("image/png"))
+	assert.False(t, opts.AllowMime("text/plain"))
+	assert.True(t, opts.AllowSize(1000))
+	assert.False(t, opts.AllowSize(1001))
+	assert.False(t, opts.Exhausted())
+	opts.Files = 2
+	assert.True(t, opts.Exhausted())
+
+	assert.Equal(t, ErrInvalidFileDrop, (&FileDropOptions{MaxFiles: -1}).Validate())
+	assert.Equal(t, ErrInvalidFileDrop, (&FileDropOptions{AllowedTypes: []string{"pdf"}}).Validate())
+}
+
+func TestFileDropDirID(t *testing.T) {
+	p := &Permission{
+		Type: TypeFileDrop,
+		Permissions: Set{Rule{
+			Type:   "io.cozy.files",
+			Verbs:  Verbs(POST),
+			Values: []string{"dir-id"},
+		}},
+	}
+	assert.Equal(t, "dir-id", p.FileDropDirID())
+	p.Type = TypeShareByLink
+	assert.Equal(t, "", p.FileDropDirID())
+}
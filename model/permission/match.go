@@ -1,6 +1,12 @@
 package permission
 
-import "strings"
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cozy/cozy-stack/pkg/consts"
+)
 
 // Fetcher is an interface for an object to see if it matches a rule.
 type Fetcher interface {
@@ -39,6 +45,32 @@ func matchOnFields(r Rule, o Fetcher, fields ...string) bool {
 	return r.ValuesMatch(o)
 }
 
+// matchConditions returns true if the conditions of the rule are fulfilled
+// for the given document. The path prefixes can't be evaluated here, as they
+// need a FilePather: they are checked by vfs.Allows, and a rule with path
+// prefixes never matches here.
+func matchConditions(r Rule, o Fetcher) bool {
+	c := r.Conditions
+	if c == nil {
+		return true
+	}
+	if !c.AllowNow(time.Now()) {
+		return false
+	}
+	if len(c.PathPrefixes) > 0 {
+		return false
+	}
+	if c.MaxFileSize > 0 && o != nil && o.DocType() == consts.Files {
+		for _, size := range o.Fetch("size") {
+			n, err := strconv.ParseInt(size, 10, 64)
+			if err != nil || !c.AllowSize(n) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
 func matchVerb(r Rule, v Verb) bool {
 	return r.Verbs.Contains(v)
 }
@@ -56,7 +88,8 @@ func MatchType(r Rule, doctype string) bool {
 }
 
 func matchWholeType(r Rule) bool {
-	return len(r.Values) == 0
+	return len(r.Values) == 0 && !r.Conditions.RestrictsDocuments() &&
+		r.Conditions.AllowNow(time.Now())
 }
 
 func matchID(r Rule, id string) bool {
@@ -78,7 +111,7 @@ func (s Set) AllowID(v Verb, doctype, id string) bool {
 	return s.Some(func(r Rule) bool {
 		return matchVerb(r, v) &&
 			MatchType(r, doctype) &&
-			(matchWholeType(r) || matchID(r, id))
+			(matchWholeType(r) || (matchID(r, id) && matchConditions(r, nil)))
 	})
 }
 
@@ -87,7 +120,8 @@ func (s Set) Allow(v Verb, o Fetcher) bool {
 	return s.Some(func(r Rule) bool {
 		return matchVerb(r, v) &&
 			MatchType(r, o.DocType()) &&
-			matchValues(r, o)
+			matchValues(r, o) &&
+			matchConditions(r, o)
 	})
 }
 
@@ -97,6 +131,7 @@ func (s Set) AllowOnFields(v Verb, o Fetcher, fields ...string) bool {
 	return s.Some(func(r Rule) bool {
 		return matchVerb(r, v) &&
 			MatchType(r, o.DocType()) &&
-			matchOnFields(r, o, fields...)
+			matchOnFields(r, o, fields...) &&
+			matchConditions(r, o)
 	})
 }
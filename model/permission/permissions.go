@@ -7,6 +7,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/cozy/cozy-stack/model/audit"
 	build "github.com/cozy/cozy-stack/pkg/config"
 	"github.com/cozy/cozy-stack/pkg/consts"
 	"github.com/cozy/cozy-stack/pkg/couchdb"
@@ -31,6 +32,17 @@ type Permission struct {
 	Codes       map[string]string `json:"codes,omitempty"`
 	ShortCodes  map[string]string `json:"shortcodes,omitempty"`
 
+	// Options for a share by link, see share_link.go
+	Password     []byte        `json:"password,omitempty"` // scrypt hash
+	MaxViews     int           `json:"max_views,omitempty"`
+	Views        int           `json:"views,omitempty"`
+	MaxDownloads int           `json:"max_downloads,omitempty"`
+	Downloads    int           `json:"downloads,omitempty"`
+	AccessLog    []ShareAccess `json:"access_log,omitempty"`
+
+	// Options for a file drop link, see file_drop.go
+	FileDrop *FileDropOptions `json:"file_drop,omitempty"`
+
 	Client   interface{}            `json:"-"` // Contains the *oauth.Client client pointer for Oauth permission type
 	Metadata *metadata.CozyMetadata `json:"cozyMetadata,omitempty"`
 }
@@ -62,6 +74,15 @@ const (
 	// TypeShareInteract is the value of Permission.Type for reading and
 	// writing a note in a shared folder.
 	TypeShareInteract = "share-interact"
+
+	// TypeFileDrop is the value of Permission.Type for a link that allows
+	// anonymous visitors to upload files in a directory, without reading it.
+	TypeFileDrop = "share-drop"
+
+	// TypeAppPassword is the value of Permission.Type for the permissions
+	// given to a request authenticated with an app password (they are not
+	// persisted)
+	TypeAppPassword = "app-password"
 )
 
 // ID implements jsonapi.Doc
@@ -94,6 +115,17 @@ func (p *Permission) Clone() couchdb.Doc {
 		copy(r.Values, vals)
 		cloned.Permissions[i] = r
 	}
+	if p.Password != nil {
+		cloned.Password = make([]byte, len(p.Password))
+		copy(cloned.Password, p.Password)
+	}
+	if p.AccessLog != nil {
+		cloned.AccessLog = make([]ShareAccess, len(p.AccessLog))
+		copy(cloned.AccessLog, p.AccessLog)
+	}
+	if p.FileDrop != nil {
+		cloned.FileDrop = p.FileDrop.Clone()
+	}
 	return &cloned
 }
 
@@ -155,7 +187,7 @@ func (p *Permission) Revoke(db prefixer.Prefixer) error {
 // CanUpdateShareByLink check if the child permissions can be updated by p
 // (p can be the parent or it has a superset of the permissions).
 func (p *Permission) CanUpdateShareByLink(child *Permission) bool {
-	if child.Type != TypeShareByLink {
+	if child.Type != TypeShareByLink && child.Type != TypeFileDrop {
 		return false
 	}
 	if p.Type != TypeWebapp && p.Type != TypeOauth {
@@ -384,6 +416,7 @@ func createAppSet(db prefixer.Prefixer, typ, docType, slug string, set Set, md *
 	if err != nil {
 		return nil, err
 	}
+	recordAudit(db, audit.ActionPermissionGranted, doc)
 	return doc, nil
 }
 
@@ -457,6 +490,7 @@ func updateAppSet(db prefixer.Prefixer, doc *Permission, typ, docType, slug stri
 	if err != nil {
 		return nil, err
 	}
+	recordAudit(db, audit.ActionPermissionGranted, doc)
 	return doc, nil
 }
 
@@ -494,12 +528,17 @@ func CreateShareSet(db prefixer.Prefixer, parent *Permission, sourceID string, c
 		ShortCodes:  shortcodes,
 		ExpiresAt:   expiresAt,
 		Metadata:    subdoc.Metadata,
+
+		Password:     subdoc.Password,
+		MaxViews:     subdoc.MaxViews,
+		MaxDownloads: subdoc.MaxDownloads,
 	}
 
 	err := couchdb.CreateDoc(db, doc)
 	if err != nil {
 		return nil, err
 	}
+	recordAudit(db, audit.ActionShareLinkCreated, doc)
 
 	return doc, nil
 }
@@ -33,6 +33,10 @@ type Rule struct {
 	// Selector is the field which must be one of Values.
 	Selector string   `json:"selector,omitempty"`
 	Values   []string `json:"values,omitempty"`
+
+	// Conditions are optional restrictions on this rule (time windows, path
+	// prefixes, etc.). They can't be expressed in a scope string.
+	Conditions *Conditions `json:"conditions,omitempty"`
 }
 
 // MarshalScopeString transform a Rule into a string of the shape
@@ -104,6 +104,9 @@ func (s *Set) UnmarshalJSON(j []byte) error {
 		if err != nil {
 			return err
 		}
+		if err := r.Conditions.Validate(); err != nil {
+			return err
+		}
 		r.Title = title
 		*s = append(*s, r)
 	}
@@ -157,6 +160,10 @@ func (s *Set) RuleInSubset(r2 Rule) bool {
 			continue
 		}
 
+		if !r.Conditions.Contains(r2.Conditions) {
+			continue
+		}
+
 		if r.Selector == "" && len(r.Values) == 0 {
 			return true
 		}
@@ -198,7 +205,8 @@ func (s Set) HasSameRules(other Set) bool {
 				rule.Selector == otherRule.Selector &&
 				rule.Verbs.ContainsAll(otherRule.Verbs) &&
 				otherRule.Verbs.ContainsAll(rule.Verbs) &&
-				reflect.DeepEqual(otherRule.Type, rule.Type) {
+				reflect.DeepEqual(otherRule.Type, rule.Type) &&
+				reflect.DeepEqual(otherRule.Conditions, rule.Conditions) {
 				match = true
 				break
 			}
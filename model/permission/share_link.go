@@ -0,0 +1,167 @@
+package permission
+
+import (
+	"time"
+
+	"github.com/cozy/cozy-stack/pkg/consts"
+	"github.com/cozy/cozy-stack/pkg/couchdb"
+	"github.com/cozy/cozy-stack/pkg/crypto"
+	"github.com/cozy/cozy-stack/pkg/prefixer"
+)
+
+const (
+	// AccessView is the kind of access when a shared webapp is opened
+	AccessView = "view"
+	// AccessDownload is the kind of access when a shared file is downloaded
+	AccessDownload = "download"
+	// AccessUnlock is the kind of access when the password of a link is
+	// given. It is counted as a view, as the shared webapp can't be used
+	// before.
+	AccessUnlock = "unlock"
+	// AccessDrop is the kind of access when a file is uploaded via a file
+	// drop link
+	AccessDrop = "drop"
+
+	// maxAccessLogLength is the maximal number of entries kept in the access
+	// log of a share by link (the oldest ones are removed first)
+	maxAccessLogLength = 50
+)
+
+// ShareAccess is an entry in the access log of a share by link.
+type ShareAccess struct {
+	Kind string    `json:"kind"`
+	At   time.Time `json:"at"`
+	IP   string    `json:"ip,omitempty"`
+}
+
+var unlockMACConfig = crypto.MACConfig{
+	Name:   "share-unlock",
+	MaxAge: 24 * time.Hour,
+	MaxLen: 256,
+}
+
+// SetPassword sets the password that protects a share by link. An empty
+// password removes the protection.
+func (p *Permission) SetPassword(password string) error {
+	if password == "" {
+		p.Password = nil
+		return nil
+	}
+	hash, err := crypto.GenerateFromPassphrase([]byte(password))
+	if err != nil {
+		return err
+	}
+	p.Password = hash
+	return nil
+}
+
+// HasPassword returns true if the share by link is protected by a password.
+func (p *Permission) HasPassword() bool {
+	return len(p.Password) > 0
+}
+
+// CheckPassword returns true if the given password is the one that protects
+// the share by link.
+func (p *Permission) CheckPassword(password string) bool {
+	if !p.HasPassword() {
+		return true
+	}
+	_, err := crypto.CompareHashAndPassphrase(p.Password, []byte(password))
+	return err == nil
+}
+
+// CreateUnlockToken returns a token that can be used to access a share by
+// link protected by a password, once the password has been checked.
+func (p *Permission) CreateUnlockToken(secret []byte) (string, error) {
+	token, err := crypto.EncodeAuthMessage(unlockMACConfig, secret, nil, p.unlockAdditionalData())
+	if err != nil {
+		return "", err
+	}
+	return string(token), nil
+}
+
+// CheckUnlockToken returns true if the token has been created for this share
+// by link and is still valid. The tokens created before a change of the
+// password are no longer valid.
+func (p *Permission) CheckUnlockToken(secret []byte, token string) bool {
+	if token == "" {
+		return false
+	}
+	_, err := crypto.DecodeAuthMessage(unlockMACConfig, secret, []byte(token), p.unlockAdditionalData())
+	return err == nil
+}
+
+// unlockAdditionalData binds the unlock tokens to the share by link and to
+// its password: the hash of the password changes each time it is set, even
+// for the same password, as a new salt is used.
+func (p *Permission) unlockAdditionalData() []byte {
+	data := make([]byte, 0, len(p.PID)+len(p.Password))
+	data = append(data, p.PID...)
+	return append(data, p.Password...)
+}
+
+// ViewsExhausted returns true if the maximal number of views has been reached.
+func (p *Permission) ViewsExhausted() bool {
+	return p.MaxViews > 0 && p.Views >= p.MaxViews
+}
+
+// DownloadsExhausted returns true if the maximal number of downloads has been
+// reached.
+func (p *Permission) DownloadsExhausted() bool {
+	return p.MaxDownloads > 0 && p.Downloads >= p.MaxDownloads
+}
+
+// RecordAccess increments the counter for the given kind of access and adds
+// an entry to the access log of the share by link. It returns
+// ErrShareLinkExhausted if the maximal number of views, downloads or dropped
+// files has already been reached, and ErrShareLinkConflict if the document
+// can't be updated because of concurrent accesses.
+func (p *Permission) RecordAccess(db prefixer.Prefixer, kind, ip string) error {
+	if p.Type != TypeShareByLink && p.Type != TypeFileDrop {
+		return nil
+	}
+	doc := p
+	for i := 0; i < 3; i++ {
+		switch kind {
+		case AccessView, AccessUnlock:
+			if doc.ViewsExhausted() {
+				return ErrShareLinkExhausted
+			}
+			doc.Views++
+		case AccessDownload:
+			if doc.DownloadsExhausted() {
+				return ErrShareLinkExhausted
+			}
+			doc.Downloads++
+		case AccessDrop:
+			if doc.FileDrop.Exhausted() {
+				return ErrShareLinkExhausted
+			}
+			if doc.FileDrop != nil {
+				doc.FileDrop.Files++
+			}
+		}
+		doc.AccessLog = append(doc.AccessLog, ShareAccess{
+			Kind: kind,
+			At:   time.Now().UTC(),
+			IP:   ip,
+		})
+		if len(doc.AccessLog) > maxAccessLogLength {
+			doc.AccessLog = doc.AccessLog[len(doc.AccessLog)-maxAccessLogLength:]
+		}
+		err := couchdb.UpdateDoc(db, doc)
+		if !couchdb.IsConflictError(err) {
+			if err == nil && doc != p {
+				*p = *doc
+			}
+			return err
+		}
+		// Someone else has used the link at the same time, let's reload the
+		// document and try again
+		doc = &Permission{}
+		if err := couchdb.GetDoc(db, consts.Permissions, p.PID, doc); err != nil {
+			return err
+		}
+	}
+	return ErrShareLinkConflict
+}
@@ -0,0 +1,53 @@
+package permission
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShareLinkPassword(t *testing.T) {
+	p := &Permission{PID: "perm1", Type: TypeShareByLink}
+	assert.False(t, p.HasPassword())
+	assert.True(t, p.CheckPassword(""))
+
+	assert.NoError(t, p.SetPassword("s3cr3t"))
+	assert.True(t, p.HasPassword())
+	assert.True(t, p.CheckPassword("s3cr3t"))
+	assert.False(t, p.CheckPassword("wrong"))
+
+	assert.NoError(t, p.SetPassword(""))
+	assert.False(t, p.HasPassword())
+}
+
+func TestShareLinkUnlockToken(t *testing.T) {
+	secret := []byte("0123456789abcdef0123456789abcdef")
+	p := &Permission{PID: "perm1", Type: TypeShareByLink}
+	token, err := p.CreateUnlockToken(secret)
+	assert.NoError(t, err)
+	assert.True(t, p.CheckUnlockToken(secret, token))
+	assert.False(t, p.CheckUnlockToken(secret, ""))
+	assert.False(t, p.CheckUnlockToken([]byte("another secret"), token))
+
+	other := &Permission{PID: "perm2", Type: TypeShareByLink}
+	assert.False(t, other.CheckUnlockToken(secret, token))
+
+	// The tokens are revoked when the password is changed
+	assert.NoError(t, p.SetPassword("s3cr3t"))
+	token, err = p.CreateUnlockToken(secret)
+	assert.NoError(t, err)
+	assert.True(t, p.CheckUnlockToken(secret, token))
+	assert.NoError(t, p.SetPassword("s3cr3t"))
+	assert.False(t, p.CheckUnlockToken(secret, token))
+}
+
+func TestShareLinkExhausted(t *testing.T) {
+	p := &Permission{Type: TypeShareByLink}
+	assert.False(t, p.ViewsExhausted())
+	assert.False(t, p.DownloadsExhausted())
+
+	p.MaxViews, p.Views = 2, 1
+	p.MaxDownloads, p.Downloads = 1, 1
+	assert.False(t, p.ViewsExhausted())
+	assert.True(t, p.DownloadsExhausted())
+}
@@ -0,0 +1,87 @@
+package session
+
+import (
+	"errors"
+	"fmt"
+	"html"
+
+	"github.com/cozy/cozy-stack/model/instance"
+	"github.com/cozy/cozy-stack/model/instance/lifecycle"
+	"github.com/cozy/cozy-stack/model/notification"
+	"github.com/cozy/cozy-stack/model/notification/center"
+	"github.com/cozy/cozy-stack/pkg/limits"
+)
+
+// ErrTooManyAttempts is returned by CheckPassphrase when the attempt is
+// rejected by the protection against brute-force attacks.
+var ErrTooManyAttempts = errors.New("Too many failed attempts, please retry later")
+
+// CheckPassphrase checks the passphrase of the owner of the instance, for the
+// actions that must be confirmed with it outside of the login form. It has
+// the same protection against brute-force attacks than the login: the attempt
+// is rejected with ErrTooManyAttempts if the IP address is delayed or banned,
+// and a wrong passphrase is counted as a failed login attempt.
+func CheckPassphrase(inst *instance.Instance, ip string, pass []byte) error {
+	if err := limits.CheckLoginAttempt(inst.Domain, ip); err != nil {
+		return ErrTooManyAttempts
+	}
+	if err := lifecycle.CheckPassphrase(inst, pass); err != nil {
+		LoginFailed(inst, ip)
+		err := limits.CheckRateLimit(inst, limits.AuthType)
+		if limits.IsLimitReachedOrExceeded(err) {
+			if err = LoginRateExceeded(inst); err != nil {
+				inst.Logger().WithNamespace("auth").Warn(err.Error())
+			}
+		}
+		return instance.ErrInvalidPassphrase
+	}
+	limits.LoginSucceeded(inst.Domain, ip)
+	return nil
+}
+
+// LoginRateExceeded blocks the instance after too many failed attempts to
+// login
+func LoginRateExceeded(i *instance.Instance) error {
+	err := fmt.Errorf("Instance was blocked because of too many login failed attempts")
+	i.Logger().WithNamespace("rate_limiting").Warn(err.Error())
+	return lifecycle.Block(i, instance.BlockedLoginFailed.Code)
+}
+
+// LoginFailed records a failed login attempt for the protection against
+// brute-force attacks, and notifies the user if an IP address has been
+// banned.
+func LoginFailed(i *instance.Instance, ip string) {
+	events, err := limits.LoginFailed(i.Domain, ip)
+	if err != nil {
+		i.Logger().WithNamespace("rate_limiting").
+			Warnf("Cannot record the failed login attempt: %s", err)
+		return
+	}
+	if events.GlobalIPBanned {
+		i.Logger().WithNamespace("rate_limiting").
+			Warnf("IP %s was banned because of too many failed login attempts on all the instances", ip)
+	}
+	if !events.IPBanned {
+		return
+	}
+
+	i.Logger().WithNamespace("rate_limiting").
+		Warnf("Login was locked for %s because of too many failed attempts", ip)
+	duration := limits.BruteForce.BanDuration.String()
+	title := i.Translate("Notification Login Lockout Title")
+	message := i.Translate("Notification Login Lockout Message", ip, duration)
+	n := &notification.Notification{
+		Title:       title,
+		Message:     message,
+		Content:     title + "\n\n" + message,
+		ContentHTML: "<p>" + html.EscapeString(message) + "</p>",
+		Data: map[string]interface{}{
+			"kind": "ip",
+			"ip":   ip,
+		},
+	}
+	if err := center.PushStack(i.Domain, center.NotificationLoginLockout, n); err != nil {
+		i.Logger().WithNamespace("rate_limiting").
+			Warnf("Cannot send the lockout notification: %s", err)
+	}
+}
@@ -1,6 +1,8 @@
 package session
 
 import (
+	"fmt"
+	"html"
 	"net"
 	"net/http"
 	"net/url"
@@ -9,6 +11,8 @@ import (
 
 	"github.com/cozy/cozy-stack/model/instance"
 	"github.com/cozy/cozy-stack/model/instance/lifecycle"
+	"github.com/cozy/cozy-stack/model/notification"
+	"github.com/cozy/cozy-stack/model/notification/center"
 	"github.com/cozy/cozy-stack/pkg/config/config"
 	"github.com/cozy/cozy-stack/pkg/consts"
 	"github.com/cozy/cozy-stack/pkg/couchdb"
@@ -160,6 +164,16 @@ func StoreNewLoginEntry(i *instance.Instance, sessionID, clientID string,
 		return err
 	}
 
+	if err := setSessionDevice(i, sessionID, l, deviceType(ua, rawUserAgent)); err != nil {
+		i.Logger().WithNamespace("sessions").
+			Infof("Could not save the device of session %q: %s", sessionID, err)
+	}
+	if notifEnabled {
+		if err := sendNewCountryNotification(i, l); err != nil {
+			i.Logger().Errorf("Could not send new country notification: %s", err)
+		}
+	}
+
 	if clientID != "" {
 		if err := PushLoginRegistration(i, l, clientID); err != nil {
 			i.Logger().Errorf("Could not push login in registration queue: %s", err)
@@ -173,6 +187,82 @@ func StoreNewLoginEntry(i *instance.Instance, sessionID, clientID string,
 	return nil
 }
 
+// deviceType returns the kind of device (desktop, mobile or tablet) from its
+// user-agent.
+func deviceType(ua *user_agent.UserAgent, rawUserAgent string) string {
+	if strings.Contains(rawUserAgent, "iPad") || strings.Contains(rawUserAgent, "Tablet") {
+		return "tablet"
+	}
+	if ua.Mobile() {
+		return "mobile"
+	}
+	return "desktop"
+}
+
+// setSessionDevice saves the informations about the device and its location
+// in the session document.
+func setSessionDevice(i *instance.Instance, sessionID string, l *LoginEntry, device string) error {
+	if sessionID == "" {
+		return nil
+	}
+	s := &Session{}
+	if err := couchdb.GetDoc(i, consts.Sessions, sessionID, s); err != nil {
+		return err
+	}
+	s.IP = l.IP
+	s.UserAgent = l.UA
+	s.Device = device
+	s.OS = l.OS
+	s.Browser = l.Browser
+	s.City = l.City
+	s.Country = l.Country
+	return couchdb.UpdateDoc(i, s)
+}
+
+// sendNewCountryNotification sends a notification to the user if the login
+// comes from a country where they have never logged in before. Nothing is
+// sent for the first login on the instance.
+func sendNewCountryNotification(i *instance.Instance, l *LoginEntry) error {
+	if l.Country == "" {
+		return nil
+	}
+	var results []*LoginEntry
+	r := &couchdb.FindRequest{
+		UseIndex: "by-country",
+		Selector: mango.And(
+			mango.Equal("country", l.Country),
+			mango.NotEqual("_id", l.ID()),
+		),
+		Limit: 1,
+	}
+	if err := couchdb.FindDocs(i, consts.SessionsLogins, r, &results); err != nil || len(results) > 0 {
+		return err
+	}
+	count, err := couchdb.CountNormalDocs(i, consts.SessionsLogins)
+	if err != nil || count <= 1 {
+		return err
+	}
+
+	settingsURL := i.SubDomain(consts.SettingsSlug)
+	settingsURL.Fragment = "/connectedDevices"
+	title := i.Translate("Notification New Country Title", l.Country)
+	message := i.Translate("Notification New Country Message", l.Country, l.IP, l.Browser, l.OS)
+	n := &notification.Notification{
+		Title:   title,
+		Message: message,
+		Content: fmt.Sprintf("%s\n\n%s\n\n%s", title, message, settingsURL.String()),
+		ContentHTML: fmt.Sprintf(`<p>%s</p><p><a href="%s">%s</a></p>`,
+			html.EscapeString(message), html.EscapeString(settingsURL.String()),
+			html.EscapeString(i.Translate("Notification New Country Link"))),
+		Data: map[string]interface{}{
+			"session_id": l.SessionID,
+			"country":    l.Country,
+			"ip":         l.IP,
+		},
+	}
+	return center.PushStack(i.Domain, center.NotificationNewCountryLogin, n)
+}
+
 func sendLoginNotification(i *instance.Instance, l *LoginEntry) error {
 	var results []*LoginEntry
 	r := &couchdb.FindRequest{
@@ -196,7 +286,7 @@ func sendLoginNotification(i *instance.Instance, l *LoginEntry) error {
 		changePassphraseLink = i.ChangePasswordURL()
 	}
 	var activateTwoFALink string
-	if !i.HasAuthMode(instance.TwoFactorMail) {
+	if !i.HasTwoFactor() {
 		settingsURL := i.SubDomain(consts.SettingsSlug)
 		settingsURL.Fragment = "/profile"
 		activateTwoFALink = settingsURL.String()
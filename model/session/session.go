@@ -14,6 +14,7 @@ import (
 	"github.com/cozy/cozy-stack/pkg/couchdb"
 	"github.com/cozy/cozy-stack/pkg/crypto"
 	"github.com/cozy/cozy-stack/pkg/utils"
+	"github.com/justincampbell/bigduration"
 	"github.com/labstack/echo/v4"
 )
 
@@ -40,6 +41,15 @@ type Session struct {
 	CreatedAt time.Time `json:"created_at"`
 	LastSeen  time.Time `json:"last_seen"`
 	LongRun   bool      `json:"long_run"`
+
+	// Informations about the device, filled when the session is created
+	IP        string `json:"ip,omitempty"`
+	UserAgent string `json:"user_agent,omitempty"`
+	Device    string `json:"device,omitempty"`
+	OS        string `json:"os,omitempty"`
+	Browser   string `json:"browser,omitempty"`
+	City      string `json:"city,omitempty"`
+	Country   string `json:"country,omitempty"`
 }
 
 // DocType implements couchdb.Doc
@@ -75,6 +85,62 @@ func (s *Session) OlderThan(t time.Duration) bool {
 	return time.Now().After(s.LastSeen.Add(t))
 }
 
+// Expired returns true if the session has been idle for too long, or if it
+// has been created for too long, according to the timeouts of the context of
+// the instance.
+func (s *Session) Expired(i *instance.Instance) bool {
+	idle, absolute := Timeouts(i)
+	if s.OlderThan(idle) {
+		return true
+	}
+	return absolute > 0 && time.Now().After(s.CreatedAt.Add(absolute))
+}
+
+// Timeouts returns the idle and absolute timeouts for the sessions of the
+// given instance. They can be configured per context, via the
+// session_idle_timeout and session_absolute_timeout parameters. An absolute
+// timeout of 0 means that there is no limit.
+func Timeouts(i *instance.Instance) (idle, absolute time.Duration) {
+	idle = SessionMaxAge
+	ctxSettings, ok := i.SettingsContext()
+	if !ok {
+		return
+	}
+	if d, ok := parseTimeout(ctxSettings["session_idle_timeout"]); ok && d < idle {
+		idle = d
+	}
+	if d, ok := parseTimeout(ctxSettings["session_absolute_timeout"]); ok {
+		absolute = d
+	}
+	return
+}
+
+func parseTimeout(value interface{}) (time.Duration, bool) {
+	str, ok := value.(string)
+	if !ok || str == "" {
+		return 0, false
+	}
+	d, err := bigduration.ParseDuration(str)
+	if err != nil || d <= 0 {
+		return 0, false
+	}
+	return d, true
+}
+
+// lastSeenPeriod returns the minimal delay between two updates of the
+// last_seen field. It is a day by default, which is a good enough
+// granularity, but it can be shorter if an idle timeout is configured.
+func lastSeenPeriod(idle time.Duration) time.Duration {
+	period := 24 * time.Hour
+	if p := idle / 10; p < period {
+		period = p
+	}
+	if period < time.Minute {
+		period = time.Minute
+	}
+	return period
+}
+
 // New creates a session in couchdb for the given instance
 func New(i *instance.Instance, longRun bool) (*Session, error) {
 	now := time.Now()
@@ -102,9 +168,9 @@ func Get(i *instance.Instance, sessionID string) (*Session, error) {
 	}
 	s.instance = i
 
-	// If the session is older than the session max age, it has expired and
-	// should be deleted.
-	if s.OlderThan(SessionMaxAge) {
+	// If the session has been idle for too long, or if it is too old, it has
+	// expired and should be deleted.
+	if s.Expired(i) {
 		err := couchdb.DeleteDoc(i, s)
 		if err != nil {
 			i.Logger().WithNamespace("loginaudit").
@@ -117,9 +183,9 @@ func Get(i *instance.Instance, sessionID string) (*Session, error) {
 	}
 
 	// In order to avoid too many updates of the session document, we have an
-	// update period of one day for the `last_seen` date, which is a good enough
-	// granularity.
-	if s.OlderThan(24 * time.Hour) {
+	// update period for the `last_seen` date (see lastSeenPeriod).
+	idle, _ := Timeouts(i)
+	if s.OlderThan(lastSeenPeriod(idle)) {
 		lastSeen := s.LastSeen
 		s.LastSeen = time.Now()
 		err := couchdb.UpdateDoc(i, s)
@@ -184,7 +250,7 @@ func GetAll(inst *instance.Instance) ([]*Session, error) {
 	kept := sessions[:0]
 	for _, sess := range sessions {
 		sess.instance = inst
-		if sess.OlderThan(SessionMaxAge) {
+		if sess.Expired(inst) {
 			expired = append(expired, sess)
 		} else {
 			kept = append(kept, sess)
@@ -244,6 +310,21 @@ func (s *Session) ToCookie() (*http.Cookie, error) {
 	}, nil
 }
 
+// DeleteByID removes the session with the given identifier. It returns
+// ErrInvalidID if there is no such session.
+func DeleteByID(i *instance.Instance, sessionID string) error {
+	s := &Session{}
+	err := couchdb.GetDoc(i, consts.Sessions, sessionID, s)
+	if couchdb.IsNotFoundError(err) {
+		return ErrInvalidID
+	}
+	if err != nil {
+		return err
+	}
+	s.Delete(i)
+	return nil
+}
+
 // DeleteOthers will remove all sessions except the one given in parameter.
 func DeleteOthers(i *instance.Instance, selfSessionID string) error {
 	var sessions []*Session
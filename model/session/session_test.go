@@ -4,9 +4,12 @@ import (
 	"encoding/base64"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/cozy/cozy-stack/model/instance"
 	"github.com/cozy/cozy-stack/pkg/config/config"
+	"github.com/mssola/user_agent"
+	"github.com/stretchr/testify/assert"
 )
 
 var JWTSecret = []byte("foobar")
@@ -22,3 +25,49 @@ func TestMain(m *testing.M) {
 	delegatedInst = &instance.Instance{Domain: "external.notmycozy.net"}
 	os.Exit(m.Run())
 }
+
+func TestTimeouts(t *testing.T) {
+	conf := config.GetConfig()
+	backup := conf.Contexts
+	defer func() { conf.Contexts = backup }()
+
+	inst := &instance.Instance{Domain: "timeouts.example.net", ContextName: "strict"}
+	conf.Contexts = map[string]interface{}{}
+	idle, absolute := Timeouts(inst)
+	assert.Equal(t, SessionMaxAge, idle)
+	assert.Equal(t, time.Duration(0), absolute)
+
+	conf.Contexts = map[string]interface{}{
+		"strict": map[string]interface{}{
+			"session_idle_timeout":     "30m",
+			"session_absolute_timeout": "12h",
+		},
+	}
+	idle, absolute = Timeouts(inst)
+	assert.Equal(t, 30*time.Minute, idle)
+	assert.Equal(t, 12*time.Hour, absolute)
+
+	s := &Session{CreatedAt: time.Now(), LastSeen: time.Now()}
+	assert.False(t, s.Expired(inst))
+	s.LastSeen = time.Now().Add(-time.Hour)
+	assert.True(t, s.Expired(inst))
+	s.LastSeen = time.Now()
+	s.CreatedAt = time.Now().Add(-13 * time.Hour)
+	assert.True(t, s.Expired(inst))
+}
+
+func TestLastSeenPeriod(t *testing.T) {
+	assert.Equal(t, 24*time.Hour, lastSeenPeriod(SessionMaxAge))
+	assert.Equal(t, 3*time.Minute, lastSeenPeriod(30*time.Minute))
+	assert.Equal(t, time.Minute, lastSeenPeriod(time.Minute))
+}
+
+func TestDeviceType(t *testing.T) {
+	for ua, expected := range map[string]string{
+		"Mozilla/5.0 (X11; Linux x86_64; rv:91.0) Gecko/20100101 Firefox/91.0":                                                                      "desktop",
+		"Mozilla/5.0 (iPhone; CPU iPhone OS 14_7 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/14.1.2 Mobile/15E148 Safari/604.1": "mobile",
+		"Mozilla/5.0 (iPad; CPU OS 14_7 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/14.1.2 Mobile/15E148 Safari/604.1":          "tablet",
+	} {
+		assert.Equal(t, expected, deviceType(user_agent.New(ua), ua))
+	}
+}
@@ -11,6 +11,7 @@ import (
 
 	"github.com/cozy/cozy-stack/client/auth"
 	"github.com/cozy/cozy-stack/client/request"
+	"github.com/cozy/cozy-stack/model/audit"
 	"github.com/cozy/cozy-stack/model/bitwarden/settings"
 	"github.com/cozy/cozy-stack/model/contact"
 	"github.com/cozy/cozy-stack/model/instance"
@@ -392,7 +393,19 @@ func (s *Sharing) SendAnswer(inst *instance.Instance, state string) error {
 	s.Credentials[0].Client = creds.Client
 	s.Active = true
 	s.Initial = s.NbFiles > 0
-	return couchdb.UpdateDoc(inst, s)
+	if err := couchdb.UpdateDoc(inst, s); err != nil {
+		return err
+	}
+	audit.Record(inst, &audit.Entry{
+		Action: audit.ActionSharingAccepted,
+		Actor:  "owner",
+		Target: consts.Sharings + "/" + s.SID,
+		Details: map[string]interface{}{
+			"sharer":      s.Members[0].Instance,
+			"description": s.Description,
+		},
+	})
+	return nil
 }
 
 // ProcessAnswer takes somes credentials and update the sharing with those.
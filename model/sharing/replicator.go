@@ -50,6 +50,7 @@ func (s *Sharing) Replicate(inst *instance.Instance, errors int) error {
 	var err error
 	if !s.Owner {
 		pending, err = s.ReplicateTo(inst, &s.Members[0], false)
+		s.recordResult(inst, &s.Members[0], "replicator", err, errors)
 	} else {
 		g, _ := errgroup.WithContext(context.Background())
 		for i := range s.Members {
@@ -60,6 +61,7 @@ func (s *Sharing) Replicate(inst *instance.Instance, errors int) error {
 			g.Go(func() error {
 				if m.Status == MemberStatusReady {
 					p, err := s.ReplicateTo(inst, m, false)
+					s.recordResult(inst, m, "replicator", err, errors)
 					if err != nil {
 						return err
 					}
@@ -169,6 +171,7 @@ func (s *Sharing) ReplicateTo(inst *instance.Instance, m *Member, initial bool)
 		return false, err
 	}
 	if feed.Seq == lastSeq {
+		s.recordProgress(inst, m, "replicator", feed.Remaining, 0)
 		return false, nil
 	}
 	inst.Logger().WithNamespace("replicator").Debugf("changes = %#v", feed.Changes)
@@ -199,6 +202,9 @@ func (s *Sharing) ReplicateTo(inst *instance.Instance, m *Member, initial bool)
 	}
 
 	err = s.UpdateLastSequenceNumber(inst, m, "replicator", feed.Seq)
+	if err == nil {
+		s.recordProgress(inst, m, "replicator", feed.Remaining, 0)
+	}
 	return feed.Pending, err
 }
 
@@ -338,6 +344,8 @@ type changesResponse struct {
 	Seq string
 	// Pending is true if there are some other changes in the feed after those
 	Pending bool
+	// Remaining is the number of changes in the feed after those
+	Remaining int
 }
 
 // errRevokeSharing is a sentinel value that can be returned by callChangesFeed.
@@ -366,6 +374,7 @@ func (s *Sharing) callChangesFeed(inst *instance.Instance, since string) (*chang
 		RuleIndexes: make(map[string]int),
 		Seq:         response.LastSeq,
 		Pending:     response.Pending > 0,
+		Remaining:   response.Pending,
 	}
 	for _, r := range response.Results {
 		infos, ok := r.Doc.Get("infos").(map[string]interface{})
@@ -86,6 +86,61 @@ func TestSequenceNumber(t *testing.T) {
 	assert.Equal(t, feed.Seq, seq3)
 }
 
+func TestReplicationStatus(t *testing.T) {
+	s := &Sharing{SID: uuidv4(), Active: true, Owner: true, Members: []Member{
+		{Status: MemberStatusOwner, Name: "Alice"},
+		{Status: MemberStatusReady, Name: "Bob", Instance: "https://bob.cozy.tools"},
+	}}
+	m := &s.Members[1]
+
+	statuses, err := s.GetReplicationStatus(inst)
+	assert.NoError(t, err)
+	if assert.Len(t, statuses, 1) {
+		assert.Equal(t, 1, statuses[0].Index)
+		assert.Equal(t, "https://bob.cozy.tools", statuses[0].Instance)
+		assert.Empty(t, statuses[0].Replicator.LastSeq)
+		assert.Nil(t, statuses[0].Replicator.UpdatedAt)
+		assert.Nil(t, statuses[0].Upload)
+	}
+
+	err = s.UpdateLastSequenceNumber(inst, m, "replicator", "3-abc")
+	assert.NoError(t, err)
+	s.recordProgress(inst, m, "replicator", 7, 0)
+	s.recordResult(inst, m, "replicator", ErrInternalServerError, 1)
+
+	statuses, err = s.GetReplicationStatus(inst)
+	assert.NoError(t, err)
+	if assert.Len(t, statuses, 1) {
+		rep := statuses[0].Replicator
+		assert.Equal(t, "3-abc", rep.LastSeq)
+		assert.Equal(t, 7, rep.PendingDocs)
+		assert.Equal(t, ErrInternalServerError.Error(), rep.LastError)
+		assert.Equal(t, 2, rep.Retries)
+		assert.NotNil(t, rep.UpdatedAt)
+	}
+
+	s.recordResult(inst, m, "replicator", nil, 2)
+	statuses, err = s.GetReplicationStatus(inst)
+	assert.NoError(t, err)
+	var updatedAt *time.Time
+	if assert.Len(t, statuses, 1) {
+		rep := statuses[0].Replicator
+		assert.Equal(t, "3-abc", rep.LastSeq)
+		assert.Empty(t, rep.LastError)
+		assert.Equal(t, 0, rep.Retries)
+		updatedAt = rep.UpdatedAt
+	}
+
+	// A run without changes doesn't update the status
+	s.recordResult(inst, m, "replicator", nil, 0)
+	s.recordProgress(inst, m, "replicator", 7, 0)
+	statuses, err = s.GetReplicationStatus(inst)
+	assert.NoError(t, err)
+	if assert.Len(t, statuses, 1) {
+		assert.Equal(t, updatedAt, statuses[0].Replicator.UpdatedAt)
+	}
+}
+
 func createDoc(t *testing.T, doctype, id string, attrs map[string]interface{}) *couchdb.JSONDoc {
 	attrs["_id"] = id
 	doc := couchdb.JSONDoc{
@@ -0,0 +1,244 @@
+package sharing
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/cozy/cozy-stack/model/instance"
+	"github.com/cozy/cozy-stack/pkg/consts"
+	"github.com/cozy/cozy-stack/pkg/couchdb"
+	"github.com/cozy/cozy-stack/pkg/realtime"
+)
+
+// ReplicationStatus gives some informations about the progress and the health
+// of a worker (replicator or upload) for a member of a sharing. It is
+// persisted in the same local document as the last sequence number.
+type ReplicationStatus struct {
+	// LastSeq is the last sequence number of the io.cozy.shared changes feed
+	// that has been replicated to the member
+	LastSeq string `json:"last_seq,omitempty"`
+	// PendingDocs is the number of items in the changes feed after LastSeq
+	PendingDocs int `json:"pending_docs"`
+	// PendingBytes is the size of the file waiting to be uploaded (only
+	// for the upload worker)
+	PendingBytes int64 `json:"pending_bytes,omitempty"`
+	// LastError is the error of the last run of the worker, if any
+	LastError string `json:"last_error,omitempty"`
+	// Retries is the number of retries already scheduled by retryWorker
+	Retries   int        `json:"retries"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// MemberReplicationStatus is the status of the replication for a member of
+// a sharing.
+type MemberReplicationStatus struct {
+	Index      int                `json:"index"`
+	Instance   string             `json:"instance,omitempty"`
+	Status     string             `json:"status"`
+	Replicator ReplicationStatus  `json:"replicator"`
+	Upload     *ReplicationStatus `json:"upload,omitempty"`
+}
+
+// GetReplicationStatus returns the status of the replication for the members
+// of this sharing. On the sharer, all the recipients are listed, and on a
+// recipient, only the sharer is listed.
+func (s *Sharing) GetReplicationStatus(inst *instance.Instance) ([]MemberReplicationStatus, error) {
+	var members []int
+	if s.Owner {
+		for i := range s.Members {
+			if i > 0 {
+				members = append(members, i)
+			}
+		}
+	} else if len(s.Members) > 0 {
+		members = append(members, 0)
+	}
+
+	statuses := make([]MemberReplicationStatus, 0, len(members))
+	for _, i := range members {
+		m := &s.Members[i]
+		status := MemberReplicationStatus{
+			Index:    i,
+			Instance: m.Instance,
+			Status:   m.Status,
+		}
+		rep, err := s.getReplicationStatus(inst, m, "replicator")
+		if err != nil {
+			return nil, err
+		}
+		status.Replicator = *rep
+		if s.FirstFilesRule() != nil {
+			up, err := s.getReplicationStatus(inst, m, "upload")
+			if err != nil {
+				return nil, err
+			}
+			status.Upload = up
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// getReplicationStatus loads the status of the given worker for a member from
+// the local document where the last sequence number is stored.
+func (s *Sharing) getReplicationStatus(inst *instance.Instance, m *Member, worker string) (*ReplicationStatus, error) {
+	id, err := s.replicationID(m)
+	if err != nil {
+		return nil, err
+	}
+	result, err := couchdb.GetLocal(inst, consts.Shared, id+"/"+worker)
+	if couchdb.IsNotFoundError(err) {
+		return &ReplicationStatus{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return statusFromLocalDoc(result), nil
+}
+
+func statusFromLocalDoc(doc map[string]interface{}) *ReplicationStatus {
+	status := &ReplicationStatus{}
+	status.LastSeq, _ = doc["last_seq"].(string)
+	status.PendingDocs = int(numberFromLocalDoc(doc["pending_docs"]))
+	status.PendingBytes = numberFromLocalDoc(doc["pending_bytes"])
+	status.LastError, _ = doc["last_error"].(string)
+	status.Retries = int(numberFromLocalDoc(doc["retries"]))
+	if updated, ok := doc["updated_at"].(string); ok {
+		if at, err := time.Parse(time.RFC3339Nano, updated); err == nil {
+			status.UpdatedAt = &at
+		}
+	}
+	return status
+}
+
+// numberFromLocalDoc accepts the numbers as they are read from CouchDB
+// (float64) or as they have been set before saving the local document.
+func numberFromLocalDoc(v interface{}) int64 {
+	switch n := v.(type) {
+	case float64:
+		return int64(n)
+	case int:
+		return int64(n)
+	case int64:
+		return n
+	}
+	return 0
+}
+
+// updateReplicationStatus loads the local document for the worker of this
+// member, calls fn to modify it, saves it, and sends a real-time event with
+// the new status. Nothing is saved or sent if the status has not changed.
+func (s *Sharing) updateReplicationStatus(inst *instance.Instance, m *Member, worker string, fn func(doc map[string]interface{})) {
+	if !s.Active {
+		return
+	}
+	log := inst.Logger().WithNamespace("replicator")
+	id, err := s.replicationID(m)
+	if err != nil {
+		log.Warnf("Cannot update status for %s: %s", s.SID, err)
+		return
+	}
+	doc, err := couchdb.GetLocal(inst, consts.Shared, id+"/"+worker)
+	if err != nil {
+		if !couchdb.IsNotFoundError(err) {
+			log.Warnf("Cannot update status for %s: %s", s.SID, err)
+			return
+		}
+		doc = make(map[string]interface{})
+	}
+	before := statusFromLocalDoc(doc)
+	fn(doc)
+	after := statusFromLocalDoc(doc)
+	before.UpdatedAt, after.UpdatedAt = nil, nil
+	if *before == *after {
+		return
+	}
+	doc["updated_at"] = time.Now().UTC().Format(time.RFC3339Nano)
+	if err := couchdb.PutLocal(inst, consts.Shared, id+"/"+worker, doc); err != nil {
+		log.Warnf("Cannot update status for %s: %s", s.SID, err)
+		return
+	}
+
+	var index int
+	for i := range s.Members {
+		if &s.Members[i] == m {
+			index = i
+		}
+	}
+	status := statusFromLocalDoc(doc)
+	event := couchdb.JSONDoc{
+		Type: consts.SharingsStatus,
+		M: map[string]interface{}{
+			"_id":           s.SID,
+			"member_index":  index,
+			"worker":        worker,
+			"last_seq":      status.LastSeq,
+			"pending_docs":  status.PendingDocs,
+			"pending_bytes": status.PendingBytes,
+			"last_error":    status.LastError,
+			"retries":       status.Retries,
+			"updated_at":    status.UpdatedAt,
+		},
+	}
+	realtime.GetHub().Publish(inst, realtime.EventUpdate, &event, nil)
+}
+
+// recordProgress saves the number of pending documents (and bytes) after a
+// successful run of a worker for the given member.
+func (s *Sharing) recordProgress(inst *instance.Instance, m *Member, worker string, pendingDocs int, pendingBytes int64) {
+	s.updateReplicationStatus(inst, m, worker, func(doc map[string]interface{}) {
+		doc["pending_docs"] = pendingDocs
+		doc["pending_bytes"] = pendingBytes
+	})
+}
+
+// recordResult saves the error (if any) of a run of a worker for the given
+// member, and the number of retries that retryWorker will have scheduled.
+func (s *Sharing) recordResult(inst *instance.Instance, m *Member, worker string, err error, errors int) {
+	s.updateReplicationStatus(inst, m, worker, func(doc map[string]interface{}) {
+		if err == nil {
+			delete(doc, "last_error")
+			doc["retries"] = 0
+			return
+		}
+		doc["last_error"] = err.Error()
+		doc["retries"] = errors + 1
+	})
+}
+
+// SharingReplicationStatus is the status of the replication for all the
+// members of a sharing.
+type SharingReplicationStatus struct {
+	SharingID   string                    `json:"id"`
+	Description string                    `json:"description,omitempty"`
+	Members     []MemberReplicationStatus `json:"members"`
+}
+
+// ListReplicationStatuses returns the status of the replication for all the
+// active sharings of the instance.
+func ListReplicationStatuses(inst *instance.Instance) ([]SharingReplicationStatus, error) {
+	list := []SharingReplicationStatus{}
+	err := couchdb.ForeachDocs(inst, consts.Sharings, func(_ string, data json.RawMessage) error {
+		s := &Sharing{}
+		if err := json.Unmarshal(data, s); err != nil {
+			return err
+		}
+		if !s.Active {
+			return nil
+		}
+		members, err := s.GetReplicationStatus(inst)
+		if err != nil {
+			return err
+		}
+		list = append(list, SharingReplicationStatus{
+			SharingID:   s.SID,
+			Description: s.Description,
+			Members:     members,
+		})
+		return nil
+	})
+	if couchdb.IsNoDatabaseError(err) {
+		return list, nil
+	}
+	return list, err
+}
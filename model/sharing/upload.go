@@ -17,6 +17,7 @@ import (
 	"github.com/cozy/cozy-stack/pkg/couchdb"
 	"github.com/cozy/cozy-stack/pkg/lock"
 	"github.com/cozy/cozy-stack/pkg/realtime"
+	"github.com/cozy/cozy-stack/pkg/rsync"
 	multierror "github.com/hashicorp/go-multierror"
 )
 
@@ -50,6 +51,7 @@ func (s *Sharing) Upload(inst *instance.Instance, errors int) error {
 	}
 
 	lastTry := errors+1 == MaxRetries
+	results := make(map[*Member]error)
 	for i := 0; i < BatchSize; i++ {
 		if len(members) == 0 {
 			break
@@ -57,6 +59,7 @@ func (s *Sharing) Upload(inst *instance.Instance, errors int) error {
 		m := members[0]
 		members = members[1:]
 		more, err := s.UploadTo(inst, m, lastTry)
+		results[m] = err
 		if err != nil {
 			errm = multierror.Append(errm, err)
 		}
@@ -64,6 +67,9 @@ func (s *Sharing) Upload(inst *instance.Instance, errors int) error {
 			members = append(members, m)
 		}
 	}
+	for m, err := range results {
+		s.recordResult(inst, m, "upload", err, errors)
+	}
 
 	if errm != nil {
 		s.retryWorker(inst, "share-upload", errors)
@@ -141,7 +147,7 @@ func (s *Sharing) UploadTo(inst *instance.Instance, m *Member, lastTry bool) (bo
 	}
 	inst.Logger().WithNamespace("upload").Debugf("lastSeq = %s", lastSeq)
 
-	file, ruleIndex, seq, err := s.findNextFileToUpload(inst, lastSeq)
+	file, ruleIndex, seq, pending, err := s.findNextFileToUpload(inst, lastSeq)
 	if err != nil {
 		return false, err
 	}
@@ -149,23 +155,35 @@ func (s *Sharing) UploadTo(inst *instance.Instance, m *Member, lastTry bool) (bo
 		if seq != lastSeq {
 			err = s.UpdateLastSequenceNumber(inst, m, "upload", seq)
 		}
+		if err == nil {
+			s.recordProgress(inst, m, "upload", 0, 0)
+		}
 		return false, err
 	}
 
 	if err = s.uploadFile(inst, m, file, ruleIndex); err != nil {
 		if lastTry {
 			_ = s.UpdateLastSequenceNumber(inst, m, "upload", seq)
+			s.recordProgress(inst, m, "upload", pending, 0)
+		} else {
+			size, _ := file["size"].(float64)
+			s.recordProgress(inst, m, "upload", pending+1, int64(size))
 		}
 		return false, err
 	}
 
-	return true, s.UpdateLastSequenceNumber(inst, m, "upload", seq)
+	if err = s.UpdateLastSequenceNumber(inst, m, "upload", seq); err != nil {
+		return false, err
+	}
+	s.recordProgress(inst, m, "upload", pending, 0)
+	return true, nil
 }
 
 // findNextFileToUpload uses the changes feed to find the next file that needs
 // to be uploaded. It returns a file document if there is one file to upload,
-// and the sequence number where it is in the changes feed.
-func (s *Sharing) findNextFileToUpload(inst *instance.Instance, since string) (map[string]interface{}, int, string, error) {
+// the sequence number where it is in the changes feed, and the number of
+// changes remaining in the feed after it.
+func (s *Sharing) findNextFileToUpload(inst *instance.Instance, since string) (map[string]interface{}, int, string, int, error) {
 	for {
 		response, err := couchdb.GetChanges(inst, &couchdb.ChangesRequest{
 			DocType:     consts.Shared,
@@ -174,7 +192,7 @@ func (s *Sharing) findNextFileToUpload(inst *instance.Instance, since string) (m
 			Limit:       1,
 		})
 		if err != nil {
-			return nil, 0, since, err
+			return nil, 0, since, 0, err
 		}
 		since = response.LastSeq
 		if len(response.Results) == 0 {
@@ -208,14 +226,14 @@ func (s *Sharing) findNextFileToUpload(inst *instance.Instance, since string) (m
 		query := []couchdb.IDRev{ir}
 		results, err := couchdb.BulkGetDocs(inst, consts.Files, query)
 		if err != nil {
-			return nil, 0, since, err
+			return nil, 0, since, 0, err
 		}
 		if len(results) == 0 {
-			return nil, 0, since, ErrInternalServerError
+			return nil, 0, since, 0, ErrInternalServerError
 		}
-		return results[0], int(idx), since, nil
+		return results[0], int(idx), since, response.Pending, nil
 	}
-	return nil, 0, since, nil
+	return nil, 0, since, 0, nil
 }
 
 // uploadFile uploads one file to the given member. It first try to just send
@@ -284,6 +302,16 @@ func (s *Sharing) uploadFile(inst *instance.Instance, m *Member, file map[string
 	if err != nil {
 		return err
 	}
+
+	if resBody.Delta {
+		err = s.uploadFileDelta(inst, u, creds, resBody.Key, fileDoc)
+		if err == nil {
+			return nil
+		}
+		inst.Logger().WithNamespace("upload").
+			Infof("Cannot upload a delta, fallback to the full content: %s", err)
+	}
+
 	content, err := fs.OpenFile(fileDoc)
 	if err != nil {
 		return err
@@ -313,6 +341,66 @@ func (s *Sharing) uploadFile(inst *instance.Instance, m *Member, file map[string
 	return nil
 }
 
+// uploadFileDelta fetches the signatures of the blocks of the current version
+// of the file on the other cozy, and sends only the delta with our version.
+func (s *Sharing) uploadFileDelta(inst *instance.Instance, u *url.URL, creds *Credentials, key string, fileDoc *vfs.FileDoc) error {
+	res, err := request.Req(&request.Options{
+		Method: http.MethodGet,
+		Scheme: u.Scheme,
+		Domain: u.Host,
+		Path:   "/sharings/" + s.SID + "/io.cozy.files/" + key + "/signatures",
+		Headers: request.Headers{
+			"Accept":        "application/json",
+			"Authorization": "Bearer " + creds.AccessToken.AccessToken,
+		},
+		ParseError: ParseRequestError,
+	})
+	if err != nil {
+		return err
+	}
+	var sig rsync.Signature
+	err = json.NewDecoder(res.Body).Decode(&sig)
+	res.Body.Close()
+	if err != nil {
+		return err
+	}
+
+	content, err := inst.VFS().OpenFile(fileDoc)
+	if err != nil {
+		return err
+	}
+	pr, pw := io.Pipe()
+	go func() {
+		// The content is closed by this goroutine, as the request may return
+		// before the delta has been fully computed. Closing the reader side
+		// of the pipe unblocks ComputeDelta in this case.
+		err := rsync.ComputeDelta(&sig, content, pw)
+		content.Close()
+		pw.CloseWithError(err)
+	}()
+	defer pr.Close()
+
+	res, err = request.Req(&request.Options{
+		Method:  http.MethodPut,
+		Scheme:  u.Scheme,
+		Domain:  u.Host,
+		Path:    "/sharings/" + s.SID + "/io.cozy.files/" + key + "/delta",
+		Queries: url.Values{"from": {inst.ContextualDomain()}},
+		Headers: request.Headers{
+			"Authorization": "Bearer " + creds.AccessToken.AccessToken,
+			"Content-Type":  "application/octet-stream",
+		},
+		Body:       pr,
+		Client:     http.DefaultClient,
+		ParseError: ParseRequestError,
+	})
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	return nil
+}
+
 // FileDocWithRevisions is the struct of the payload for synchronizing a file
 type FileDocWithRevisions struct {
 	*vfs.FileDoc
@@ -325,11 +413,18 @@ func (f *FileDocWithRevisions) Clone() couchdb.Doc {
 }
 
 // KeyToUpload contains the key for uploading a file (when syncing metadata is
-// not enough)
+// not enough). If Delta is true, the other cozy can also fetch the signatures
+// of the current version of the file, and send only a delta.
 type KeyToUpload struct {
-	Key string `json:"key"`
+	Key   string `json:"key"`
+	Delta bool   `json:"delta,omitempty"`
 }
 
+// deltaMinSize is the minimal size of the current version of a file for
+// accepting a delta for its new version: for smaller files, it is simpler to
+// just send the whole content.
+const deltaMinSize = 1 << 20 // 1 MiB
+
 func (s *Sharing) createUploadKey(inst *instance.Instance, target *FileDocWithRevisions) (*KeyToUpload, error) {
 	key, err := getStore().Save(inst, target)
 	if err != nil {
@@ -378,7 +473,12 @@ func (s *Sharing) SyncFile(inst *instance.Instance, target *FileDocWithRevisions
 		return nil, nil
 	}
 	if !bytes.Equal(target.MD5Sum, current.MD5Sum) {
-		return s.createUploadKey(inst, target)
+		key, err := s.createUploadKey(inst, target)
+		if err != nil {
+			return nil, err
+		}
+		key.Delta = current.ByteSize >= deltaMinSize
+		return key, nil
 	}
 	return nil, s.updateFileMetadata(inst, target, current, &ref)
 }
@@ -500,6 +600,74 @@ func (s *Sharing) HandleFileUpload(inst *instance.Instance, key string, body io.
 	return s.UploadExistingFile(inst, target, current, body)
 }
 
+// FileSignatures returns the signatures of the blocks of the current version
+// of the file that will be updated with the upload for the given key.
+func (s *Sharing) FileSignatures(inst *instance.Instance, key string) (*rsync.Signature, error) {
+	target, err := getStore().Get(inst, key)
+	if err != nil {
+		return nil, err
+	}
+	if target == nil {
+		return nil, ErrMissingFileMetadata
+	}
+	fs := inst.VFS()
+	current, err := fs.FileByID(target.DocID)
+	if err != nil {
+		if err == os.ErrNotExist {
+			return nil, ErrMissingFileMetadata
+		}
+		return nil, err
+	}
+	content, err := fs.OpenFile(current)
+	if err != nil {
+		return nil, err
+	}
+	defer content.Close()
+	return rsync.ComputeSignature(content, rsync.BlockSizeFor(current.ByteSize))
+}
+
+// HandleFileDelta is used to receive a delta for the content of a file, when
+// synchronizing just the metadata was not enough. The new content is rebuilt
+// from the current version of the file and the delta.
+func (s *Sharing) HandleFileDelta(inst *instance.Instance, key string, delta io.ReadCloser) error {
+	defer delta.Close()
+	target, err := getStore().Get(inst, key)
+	if err != nil {
+		return err
+	}
+	if target == nil {
+		return ErrMissingFileMetadata
+	}
+	inst.Logger().WithNamespace("upload").Debugf("HandleFileDelta %#v %#v", target.FileDoc, target.Revisions)
+	sid := consts.Files + "/" + target.DocID
+	mu := lock.ReadWrite(inst, "shared/"+sid)
+	if err = mu.Lock(); err != nil {
+		return err
+	}
+	defer mu.Unlock()
+
+	fs := inst.VFS()
+	current, err := fs.FileByID(target.DocID)
+	if err != nil {
+		if err == os.ErrNotExist {
+			return ErrMissingFileMetadata
+		}
+		return err
+	}
+	base, err := fs.OpenFile(current)
+	if err != nil {
+		return err
+	}
+	pr, pw := io.Pipe()
+	go func() {
+		err := rsync.ApplyDelta(base, delta, pw)
+		base.Close()
+		pw.CloseWithError(err)
+	}()
+	defer pr.Close()
+	return s.UploadExistingFile(inst, target, current, pr)
+}
+
 // UploadNewFile is used to receive a new file.
 func (s *Sharing) UploadNewFile(inst *instance.Instance, target *FileDocWithRevisions, body io.ReadCloser) error {
 	inst.Logger().WithNamespace("upload").Debugf("UploadNewFile")
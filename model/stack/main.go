@@ -6,6 +6,7 @@ import (
 	"os"
 	"time"
 
+	"github.com/cozy/cozy-stack/model/app"
 	"github.com/cozy/cozy-stack/model/job"
 	"github.com/cozy/cozy-stack/model/session"
 	"github.com/cozy/cozy-stack/pkg/assets/dynamic"
@@ -130,6 +131,9 @@ security features. Please do not use this binary as your production server.
 	sessionSweeper := session.SweepLoginRegistrations()
 	shutdowners = append(shutdowners, sessionSweeper)
 
+	rolloutsSweeper := app.SweepRollouts()
+	shutdowners = append(shutdowners, rolloutsSweeper)
+
 	// Global shutdowner that composes all the running processes of the stack
 	processes = utils.NewGroupShutdown(shutdowners...)
 	return
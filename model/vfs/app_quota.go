@@ -0,0 +1,96 @@
+package vfs
+
+import (
+	"github.com/cozy/cozy-stack/pkg/couchdb"
+	"github.com/cozy/cozy-stack/pkg/prefixer"
+)
+
+// AppDiskThresholder is an interface that can be implemented by a
+// DiskThresholder to limit the size of the files created by an application.
+type AppDiskThresholder interface {
+	// AppDiskQuota returns the total number of bytes allowed to the files
+	// created by the given application. If minus or equal to zero, it is
+	// considered without limit.
+	AppDiskQuota(slug string) int64
+}
+
+// AppDiskUsage returns the total size of the files created by the given
+// application (via their cozyMetadata.createdByApp field).
+func AppDiskUsage(db prefixer.Prefixer, slug string) (int64, error) {
+	var res couchdb.ViewResponse
+	err := couchdb.ExecView(db, couchdb.DiskUsageByAppView, &couchdb.ViewRequest{
+		Key:    slug,
+		Reduce: true,
+	}, &res)
+	if err != nil {
+		return 0, err
+	}
+	if len(res.Rows) == 0 {
+		return 0, nil
+	}
+	used, ok := res.Rows[0].Value.(float64)
+	if !ok {
+		return 0, ErrWrongCouchdbState
+	}
+	return int64(used), nil
+}
+
+// AppsDiskUsage returns the total size of the files created by each
+// application, by slug.
+func AppsDiskUsage(db prefixer.Prefixer) (map[string]int64, error) {
+	var res couchdb.ViewResponse
+	err := couchdb.ExecView(db, couchdb.DiskUsageByAppView, &couchdb.ViewRequest{
+		Reduce: true,
+		Group:  true,
+	}, &res)
+	if err != nil {
+		return nil, err
+	}
+	usage := make(map[string]int64, len(res.Rows))
+	for _, row := range res.Rows {
+		slug, ok := row.Key.(string)
+		if !ok {
+			continue
+		}
+		used, ok := row.Value.(float64)
+		if !ok {
+			return nil, ErrWrongCouchdbState
+		}
+		usage[slug] = int64(used)
+	}
+	return usage, nil
+}
+
+// AppMaxSize returns the maximal size allowed for the content of the new file,
+// according to the disk quota of the application that has created it, or -1
+// if there is no limit. The quota is only enforced with the EnforceAppQuota
+// option.
+func AppMaxSize(db prefixer.Prefixer, disk DiskThresholder, newdoc, olddoc *FileDoc, opts []CreateOptions) (int64, error) {
+	if !OptionsEnforceAppQuota(opts) {
+		return -1, nil
+	}
+	if newdoc.CozyMetadata == nil || newdoc.CozyMetadata.CreatedByApp == "" {
+		return -1, nil
+	}
+	thresholder, ok := disk.(AppDiskThresholder)
+	if !ok {
+		return -1, nil
+	}
+	slug := newdoc.CozyMetadata.CreatedByApp
+	quota := thresholder.AppDiskQuota(slug)
+	if quota <= 0 {
+		return -1, nil
+	}
+	used, err := AppDiskUsage(db, slug)
+	if err != nil {
+		return 0, err
+	}
+	// The content of the old file will be replaced
+	if olddoc != nil && olddoc.CozyMetadata != nil && olddoc.CozyMetadata.CreatedByApp == slug {
+		used -= olddoc.ByteSize
+	}
+	if used >= quota {
+		return 0, nil
+	}
+	return quota - used, nil
+}
@@ -3,7 +3,9 @@ package vfs
 import (
 	"errors"
 	"fmt"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/cozy/cozy-stack/model/permission"
 	"github.com/cozy/cozy-stack/pkg/consts"
@@ -25,6 +27,8 @@ var _ Fetcher = (*DirDoc)(nil)
 func Allows(fs VFS, pset permission.Set, v permission.Verb, fd Fetcher) error {
 	allowedIDs := []string{}
 	otherRules := []permission.Rule{}
+	now := time.Now()
+	var selfPath string
 
 	// First pass, we iterate over the rules, check if we have an easy match
 	// keep a short list of useful rules and allowed IDs.
@@ -37,6 +41,27 @@ func Allows(fs VFS, pset permission.Set, v permission.Verb, fd Fetcher) error {
 			continue
 		}
 
+		// the conditions of the rule are evaluated on the file or directory
+		// itself, the rule is ignored if they are not fulfilled
+		if !r.Conditions.AllowNow(now) {
+			continue
+		}
+		if r.Conditions.RestrictsDocuments() {
+			if selfPath == "" {
+				p, err := fd.Path(fs)
+				if err != nil {
+					return err
+				}
+				selfPath = p
+			}
+			if !r.Conditions.AllowPath(selfPath) {
+				continue
+			}
+			if file, ok := fd.(*FileDoc); ok && !r.Conditions.AllowSize(file.ByteSize) {
+				continue
+			}
+		}
+
 		// permission on whole io.cozy.files doctype
 		if len(r.Values) == 0 {
 			return nil
@@ -75,9 +100,12 @@ func Allows(fs VFS, pset permission.Set, v permission.Verb, fd Fetcher) error {
 	// We have some rules on IDs, let's fetch their paths and check if they are
 	// ancestors of current object
 	if len(allowedIDs) > 0 {
-		selfPath, err := fd.Path(fs)
-		if err != nil {
-			return err
+		if selfPath == "" {
+			p, err := fd.Path(fs)
+			if err != nil {
+				return err
+			}
+			selfPath = p
 		}
 
 		for _, id := range allowedIDs {
@@ -145,6 +173,8 @@ func (f *FileDoc) Fetch(field string) []string {
 		return []string{f.Mime}
 	case "class":
 		return []string{f.Class}
+	case "size":
+		return []string{strconv.FormatInt(f.ByteSize, 10)}
 	case "tags":
 		return f.Tags
 	case "referenced_by":
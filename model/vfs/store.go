@@ -24,6 +24,9 @@ type Store interface {
 	AddVersion(db prefixer.Prefixer, versionID string) (string, error)
 	AddArchive(db prefixer.Prefixer, archive *Archive) (string, error)
 	AddMetadata(db prefixer.Prefixer, metadata *Metadata) (string, error)
+	// AddShareLink keeps the ID of the share by link used to create the
+	// download link with the given key, to count the downloads of this link.
+	AddShareLink(db prefixer.Prefixer, key, permissionID string) error
 	GetFile(db prefixer.Prefixer, key string) (string, error)
 	GetThumb(db prefixer.Prefixer, key string) (string, error)
 	GetIcon(db prefixer.Prefixer, key string) (string, error)
@@ -31,6 +34,7 @@ type Store interface {
 	GetVersion(db prefixer.Prefixer, key string) (string, error)
 	GetArchive(db prefixer.Prefixer, key string) (*Archive, error)
 	GetMetadata(db prefixer.Prefixer, key string) (*Metadata, error)
+	GetShareLink(db prefixer.Prefixer, key string) (string, error)
 }
 
 // storeTTL is time after which the data in the store will be considered stale.
@@ -162,6 +166,16 @@ func (s *memStore) AddMetadata(db prefixer.Prefixer, metadata *Metadata) (string
 	return key, nil
 }
 
+func (s *memStore) AddShareLink(db prefixer.Prefixer, key, permissionID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.vals[db.DBPrefix()+":"+key+":share-link"] = &memRef{
+		val: permissionID,
+		exp: time.Now().Add(storeTTL),
+	}
+	return nil
+}
+
 func (s *memStore) GetFile(db prefixer.Prefixer, key string) (string, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -295,6 +309,10 @@ func (s *memStore) GetMetadata(db prefixer.Prefixer, key string) (*Metadata, err
 	return m, nil
 }
 
+func (s *memStore) GetShareLink(db prefixer.Prefixer, key string) (string, error) {
+	return s.GetFile(db, key+":share-link")
+}
+
 type redisStore struct {
 	c   redis.UniversalClient
 	ctx context.Context
@@ -357,6 +375,10 @@ func (s *redisStore) AddArchive(db prefixer.Prefixer, archive *Archive) (string,
 	return key, nil
 }
 
+func (s *redisStore) AddShareLink(db prefixer.Prefixer, key, permissionID string) error {
+	return s.c.Set(s.ctx, db.DBPrefix()+":"+key+":share-link", permissionID, storeTTL).Err()
+}
+
 func (s *redisStore) AddMetadata(db prefixer.Prefixer, metadata *Metadata) (string, error) {
 	v, err := json.Marshal(metadata)
 	if err != nil {
@@ -454,6 +476,10 @@ func (s *redisStore) GetMetadata(db prefixer.Prefixer, key string) (*Metadata, e
 	return meta, nil
 }
 
+func (s *redisStore) GetShareLink(db prefixer.Prefixer, key string) (string, error) {
+	return s.GetFile(db, key+":share-link")
+}
+
 func makeSecret() string {
 	return hex.EncodeToString(crypto.GenerateRandomBytes(8))
 }
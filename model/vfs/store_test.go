@@ -69,6 +69,17 @@ func TestStoreInMemory(t *testing.T) {
 	m3, err := store.GetArchive(dbA, key3)
 	assert.Equal(t, ErrWrongToken, err)
 	assert.Nil(t, m3, "no expiration")
+
+	key4, err := store.AddFile(dbA, path)
+	assert.NoError(t, err)
+	_, err = store.GetShareLink(dbA, key4)
+	assert.Equal(t, ErrWrongToken, err)
+	assert.NoError(t, store.AddShareLink(dbA, key4, "perm1"))
+	permID, err := store.GetShareLink(dbA, key4)
+	assert.NoError(t, err)
+	assert.Equal(t, "perm1", permID)
+	_, err = store.GetShareLink(dbB, key4)
+	assert.Equal(t, ErrWrongToken, err)
 }
 
 func TestStoreInRedis(t *testing.T) {
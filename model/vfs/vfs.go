@@ -62,6 +62,10 @@ const (
 	// AllowCreationInTrash is an option to allow bypassing the rule that
 	// forbids the creation of file in the trash.
 	AllowCreationInTrash CreateOptions = 1 + iota
+	// EnforceAppQuota is an option to limit the size of the file with the
+	// disk quota of the application that has created it. It is used for the
+	// files uploaded with an application token.
+	EnforceAppQuota
 )
 
 // Fs is an interface providing a set of high-level methods to interact with
@@ -852,3 +856,13 @@ func OptionsAllowCreationInTrash(opts []CreateOptions) bool {
 	}
 	return false
 }
+
+// OptionsEnforceAppQuota returns true if one of the given option says so.
+func OptionsEnforceAppQuota(opts []CreateOptions) bool {
+	for _, opt := range opts {
+		if opt == EnforceAppQuota {
+			return true
+		}
+	}
+	return false
+}
@@ -27,12 +27,14 @@ import (
 	"github.com/cozy/cozy-stack/pkg/prefixer"
 	"github.com/ncw/swift/v2/swifttest"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"golang.org/x/sync/errgroup"
 )
 
 var fs vfs.VFS
 var mutex lock.ErrorRWLocker
 var diskQuota int64
+var appDiskQuota int64
 
 type diskImpl struct{}
 
@@ -40,6 +42,13 @@ func (d *diskImpl) DiskQuota() int64 {
 	return diskQuota
 }
 
+func (d *diskImpl) AppDiskQuota(slug string) int64 {
+	if slug == "greedy" {
+		return appDiskQuota
+	}
+	return 0
+}
+
 type H map[string]H
 
 func (h H) String() string {
@@ -668,6 +677,59 @@ func TestCreateFileTooBig(t *testing.T) {
 	assert.NoError(t, fs.DestroyDirContent(root, fs.EnsureErased))
 }
 
+func TestCreateFileTooBigForApp(t *testing.T) {
+	appDiskQuota = 1 << (1 * 10) // 1KB
+	defer func() { appDiskQuota = 0 }()
+
+	newDoc := func(name, slug string, size int64) *vfs.FileDoc {
+		doc, err := vfs.NewFileDoc(name, consts.RootDirID, size, nil, "", "",
+			time.Now(), false, false, nil)
+		require.NoError(t, err)
+		doc.CozyMetadata = vfs.NewCozyMetadata("")
+		doc.CozyMetadata.CreatedByApp = slug
+		return doc
+	}
+	write := func(doc *vfs.FileDoc, size int, opts ...vfs.CreateOptions) error {
+		f, err := fs.CreateFile(doc, nil, opts...)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(f, bytes.NewReader(crypto.GenerateRandomBytes(size)))
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		return err
+	}
+
+	_, err := fs.CreateFile(newDoc("greedy-too-big", "greedy", appDiskQuota+1), nil, vfs.EnforceAppQuota)
+	assert.Equal(t, vfs.ErrFileTooBig, err)
+
+	assert.NoError(t, write(newDoc("greedy-1", "greedy", appDiskQuota/2), int(appDiskQuota/2), vfs.EnforceAppQuota))
+	used, err := vfs.AppDiskUsage(fs, "greedy")
+	assert.NoError(t, err)
+	assert.Equal(t, appDiskQuota/2, used)
+
+	err = write(newDoc("greedy-2", "greedy", -1), int(appDiskQuota/2+1), vfs.EnforceAppQuota)
+	assert.Equal(t, vfs.ErrFileTooBig, err)
+	_, err = fs.FileByPath("/greedy-2")
+	assert.True(t, os.IsNotExist(err))
+
+	// The quota is not enforced without the option (e.g. for the user)
+	assert.NoError(t, write(newDoc("greedy-3", "greedy", appDiskQuota), int(appDiskQuota)))
+
+	// The files created by other applications are not limited
+	assert.NoError(t, write(newDoc("frugal-1", "frugal", appDiskQuota), int(appDiskQuota), vfs.EnforceAppQuota))
+
+	usage, err := vfs.AppsDiskUsage(fs)
+	assert.NoError(t, err)
+	assert.Equal(t, appDiskQuota/2+appDiskQuota, usage["greedy"])
+	assert.Equal(t, appDiskQuota, usage["frugal"])
+
+	root, err := fs.DirByPath("/")
+	require.NoError(t, err)
+	assert.NoError(t, fs.DestroyDirContent(root, fs.EnsureErased))
+}
+
 func TestMain(m *testing.M) {
 	config.UseTestFile()
 
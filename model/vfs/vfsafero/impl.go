@@ -194,6 +194,19 @@ func (afs *aferoVFS) CreateFile(newdoc, olddoc *vfs.FileDoc, opts ...vfs.CreateO
 		}
 	}
 
+	appsize, err := vfs.AppMaxSize(afs, afs.DiskThresholder, newdoc, olddoc, opts)
+	if err != nil {
+		return nil, err
+	}
+	if appsize >= 0 {
+		if newsize > appsize {
+			return nil, vfs.ErrFileTooBig
+		}
+		if maxsize < 0 || appsize < maxsize {
+			maxsize = appsize
+		}
+	}
+
 	if olddoc != nil {
 		newdoc.SetID(olddoc.ID())
 		newdoc.SetRev(olddoc.Rev())
@@ -232,6 +232,19 @@ func (sfs *swiftVFSV2) CreateFile(newdoc, olddoc *vfs.FileDoc, opts ...vfs.Creat
 		return nil, vfs.ErrFileTooBig
 	}
 
+	appsize, err := vfs.AppMaxSize(sfs, sfs.DiskThresholder, newdoc, olddoc, opts)
+	if err != nil {
+		return nil, err
+	}
+	if appsize >= 0 {
+		if newsize > appsize {
+			return nil, vfs.ErrFileTooBig
+		}
+		if maxsize < 0 || appsize < maxsize {
+			maxsize = appsize
+		}
+	}
+
 	if olddoc != nil {
 		newdoc.SetID(olddoc.ID())
 		newdoc.SetRev(olddoc.Rev())
@@ -28,6 +28,7 @@ import (
 	"github.com/cozy/cozy-stack/pkg/tlsclient"
 	"github.com/cozy/cozy-stack/pkg/utils"
 	"github.com/cozy/gomail"
+	humanize "github.com/dustin/go-humanize"
 	"github.com/go-redis/redis/v8"
 	"github.com/spf13/viper"
 )
@@ -104,6 +105,12 @@ type Config struct {
 	Hooks                 string
 	GeoDB                 string
 	PasswordResetInterval time.Duration
+	AuditRetention        map[string]string
+
+	// BitwardenWebAuthnOrigins are the origins of the bitwarden clients
+	// (browser extension, mobile and desktop apps) that can send a WebAuthn
+	// assertion for the two-factor authentication
+	BitwardenWebAuthnOrigins []string
 
 	CredentialsEncryptorKey string
 	CredentialsDecryptorKey string
@@ -134,6 +141,7 @@ type Config struct {
 	Authentication map[string]interface{}
 	Office         map[string]Office
 	Registries     map[string][]*url.URL
+	RegistryServer RegistryServer
 	Clouderies     map[string]interface{}
 
 	RemoteAllowCustomPort bool
@@ -205,6 +213,66 @@ type Jobs struct {
 // Konnectors contains the configuration values for the konnectors
 type Konnectors struct {
 	Cmd string
+	// Limits are the resource limits for the execution of the konnectors and
+	// services, indexed by worker type
+	Limits map[string]ExecLimits
+	// Cgroup is the path of a cgroup v2, delegated to the stack, where a
+	// sub-cgroup is created for each execution (Linux only)
+	Cgroup string
+	// EgressProxy gives the konnectors an HTTP proxy that only forwards the
+	// requests to the hosts declared in the allowed_hosts of their manifest.
+	// It is not enforced: the direct connections must be blocked by the
+	// sandbox.
+	EgressProxy bool
+	// ServiceRoutes are the limits for the services executed for the HTTP
+	// routes declared in the manifests of the webapps
+	ServiceRoutes ServiceRoutes
+}
+
+// ServiceRoutes contains the limits for the services called via HTTP.
+type ServiceRoutes struct {
+	// Timeout is the maximal duration of a request
+	Timeout time.Duration
+	// MaxConcurrency is the maximal number of services running in parallel
+	// for the HTTP routes, on this stack
+	MaxConcurrency int
+	// MaxConcurrencyPerApp is the same limit for a webapp of an instance
+	MaxConcurrencyPerApp int
+}
+
+// ExecLimits are the resource limits for a konnector or service execution.
+// A zero value means no limit.
+type ExecLimits struct {
+	// Memory is the maximal memory, in bytes
+	Memory int64
+	// CPUTime is the maximal CPU time
+	CPUTime time.Duration
+	// OpenFiles is the maximal number of open files
+	OpenFiles int64
+	// Disk is the maximal size of the work directory, in bytes
+	Disk int64
+}
+
+// RegistryServer contains the configuration for using the stack as an apps
+// registry, for the deployments that can't access the official registries.
+type RegistryServer struct {
+	Enabled bool
+	// URL is the URL used by the stacks to reach the registry server. It is
+	// used to build the URL of the tarballs (the admin server by default).
+	URL *url.URL
+	// Storage is the directory where the tarballs are stored, as a file://
+	// URL. If empty, the tarballs are stored on the VFS backend.
+	Storage *url.URL
+	// SigningKeys are the public keys of the publishers, with their
+	// endorsements by a root key, served on /registry/signing-keys.
+	SigningKeys []RegistrySigningKey
+}
+
+// RegistrySigningKey is a base64-encoded ed25519 public key of a publisher,
+// with the base64-encoded signature of this key by a root key.
+type RegistrySigningKey struct {
+	Key       string
+	Signature string
 }
 
 // Matomo contains the configuration for the JS tracking
@@ -449,6 +517,9 @@ func applyDefaults(v *viper.Viper) {
 	v.SetDefault("assets_polling_interval", 2*time.Minute)
 	v.SetDefault("fs.versioning.max_number_of_versions_to_keep", 20)
 	v.SetDefault("fs.versioning.min_delay_between_two_versions", 15*time.Minute)
+	v.SetDefault("konnectors.service_routes.timeout", 30*time.Second)
+	v.SetDefault("konnectors.service_routes.max_concurrency", 50)
+	v.SetDefault("konnectors.service_routes.max_concurrency_per_app", 5)
 }
 
 func envMap() map[string]string {
@@ -526,11 +597,21 @@ func UseViper(v *viper.Viper) error {
 		return err
 	}
 
+	registryServer, err := makeRegistryServer(v)
+	if err != nil {
+		return err
+	}
+
 	office, err := makeOffice(v)
 	if err != nil {
 		return err
 	}
 
+	execLimits, err := makeExecLimits(v)
+	if err != nil {
+		return err
+	}
+
 	var subdomains SubdomainType
 	if subs := v.GetString("subdomains"); subs != "" {
 		switch subs {
@@ -735,6 +816,9 @@ func UseViper(v *viper.Viper) error {
 		Hooks:                 v.GetString("hooks"),
 		GeoDB:                 v.GetString("geodb"),
 		PasswordResetInterval: v.GetDuration("password_reset_interval"),
+		AuditRetention:        v.GetStringMapString("audit.retention"),
+
+		BitwardenWebAuthnOrigins: v.GetStringSlice("bitwarden.webauthn_origins"),
 
 		RemoteAssets: v.GetStringMapString("remote_assets"),
 
@@ -759,7 +843,15 @@ func UseViper(v *viper.Viper) error {
 		},
 		Jobs: jobs,
 		Konnectors: Konnectors{
-			Cmd: v.GetString("konnectors.cmd"),
+			Cmd:         v.GetString("konnectors.cmd"),
+			Limits:      execLimits,
+			Cgroup:      v.GetString("konnectors.cgroup"),
+			EgressProxy: v.GetBool("konnectors.egress_proxy"),
+			ServiceRoutes: ServiceRoutes{
+				Timeout:              v.GetDuration("konnectors.service_routes.timeout"),
+				MaxConcurrency:       v.GetInt("konnectors.service_routes.max_concurrency"),
+				MaxConcurrencyPerApp: v.GetInt("konnectors.service_routes.max_concurrency_per_app"),
+			},
 		},
 		Matomo: Matomo{
 			URL:             v.GetString("matomo.url"),
@@ -807,6 +899,7 @@ func UseViper(v *viper.Viper) error {
 		Authentication: v.GetStringMap("authentication"),
 		Office:         office,
 		Registries:     regs,
+		RegistryServer: registryServer,
 		Clouderies:     v.GetStringMap("clouderies"),
 
 		CSPAllowList:  cspAllowList,
@@ -951,6 +1044,80 @@ func makeRegistries(v *viper.Viper) (map[string][]*url.URL, error) {
 	return regs, nil
 }
 
+func makeRegistryServer(v *viper.Viper) (RegistryServer, error) {
+	server := RegistryServer{Enabled: v.GetBool("registry_server.enabled")}
+	if u := v.GetString("registry_server.url"); u != "" {
+		parsed, err := url.Parse(u)
+		if err != nil {
+			return server, fmt.Errorf("config: could not parse registry_server.url: %s", err)
+		}
+		server.URL = parsed
+	}
+	if s := v.GetString("registry_server.storage"); s != "" {
+		parsed, err := url.Parse(s)
+		if err != nil || parsed.Scheme != SchemeFile {
+			return server, fmt.Errorf("config: registry_server.storage must be a file:// URL, not %q", s)
+		}
+		server.Storage = parsed
+	}
+	keys, _ := v.Get("registry_server.signing_keys").([]interface{})
+	for _, k := range keys {
+		var key, sig string
+		switch m := k.(type) {
+		case map[interface{}]interface{}:
+			key, _ = m["key"].(string)
+			sig, _ = m["signature"].(string)
+		case map[string]interface{}:
+			key, _ = m["key"].(string)
+			sig, _ = m["signature"].(string)
+		}
+		if key == "" || sig == "" {
+			return server, errors.New("config: registry_server.signing_keys must be a list of key and signature")
+		}
+		server.SigningKeys = append(server.SigningKeys, RegistrySigningKey{Key: key, Signature: sig})
+	}
+	return server, nil
+}
+
+func makeExecLimits(v *viper.Viper) (map[string]ExecLimits, error) {
+	limits := make(map[string]ExecLimits)
+	for workerType, m := range v.GetStringMap("konnectors.limits") {
+		if _, ok := m.(map[string]interface{}); !ok {
+			return nil, fmt.Errorf("config: expecting a map in the key %q",
+				"konnectors.limits."+workerType)
+		}
+		prefix := "konnectors.limits." + workerType + "."
+		var l ExecLimits
+		if mem := v.GetString(prefix + "memory"); mem != "" {
+			size, err := humanize.ParseBytes(mem)
+			if err != nil {
+				return nil, fmt.Errorf("config: could not parse the memory limit for %q: %s",
+					workerType, err)
+			}
+			l.Memory = int64(size)
+		}
+		if cpu := v.GetString(prefix + "cpu_time"); cpu != "" {
+			d, err := time.ParseDuration(cpu)
+			if err != nil {
+				return nil, fmt.Errorf("config: could not parse the cpu_time limit for %q: %s",
+					workerType, err)
+			}
+			l.CPUTime = d
+		}
+		l.OpenFiles = v.GetInt64(prefix + "open_files")
+		if disk := v.GetString(prefix + "disk"); disk != "" {
+			size, err := humanize.ParseBytes(disk)
+			if err != nil {
+				return nil, fmt.Errorf("config: could not parse the disk limit for %q: %s",
+					workerType, err)
+			}
+			l.Disk = int64(size)
+		}
+		limits[workerType] = l
+	}
+	return limits, nil
+}
+
 func makeOffice(v *viper.Viper) (map[string]Office, error) {
 	office := make(map[string]Office)
 	for k, v := range v.GetStringMap("office") {
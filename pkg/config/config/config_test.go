@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
@@ -18,6 +19,52 @@ func TestUseViper(t *testing.T) {
 	assert.Equal(t, "http://db:1234/", CouchURL().String())
 }
 
+func TestKonnectorsLimits(t *testing.T) {
+	cfg := viper.New()
+	cfg.Set("konnectors.limits", map[string]interface{}{
+		"konnector": map[string]interface{}{
+			"memory":     "512MiB",
+			"cpu_time":   "2m",
+			"open_files": 256,
+			"disk":       "1GB",
+		},
+	})
+	cfg.Set("konnectors.egress_proxy", true)
+	assert.NoError(t, UseViper(cfg))
+	konn := GetConfig().Konnectors
+	assert.True(t, konn.EgressProxy)
+	limits := konn.Limits["konnector"]
+	assert.EqualValues(t, 512<<20, limits.Memory)
+	assert.Equal(t, 2*time.Minute, limits.CPUTime)
+	assert.EqualValues(t, 256, limits.OpenFiles)
+	assert.EqualValues(t, 1000*1000*1000, limits.Disk)
+	assert.Equal(t, ExecLimits{}, konn.Limits["service"])
+
+	cfg.Set("konnectors.limits", map[string]interface{}{
+		"konnector": map[string]interface{}{"memory": "lots"},
+	})
+	assert.Error(t, UseViper(cfg))
+}
+
+func TestRegistryServerSigningKeys(t *testing.T) {
+	cfg := viper.New()
+	cfg.Set("registry_server.enabled", true)
+	cfg.Set("registry_server.signing_keys", []interface{}{
+		map[string]interface{}{"key": "a2V5", "signature": "c2lnbmF0dXJl"},
+	})
+	assert.NoError(t, UseViper(cfg))
+	server := GetConfig().RegistryServer
+	assert.True(t, server.Enabled)
+	assert.Equal(t, []RegistrySigningKey{
+		{Key: "a2V5", Signature: "c2lnbmF0dXJl"},
+	}, server.SigningKeys)
+
+	cfg.Set("registry_server.signing_keys", []interface{}{
+		map[string]interface{}{"key": "a2V5"},
+	})
+	assert.Error(t, UseViper(cfg))
+}
+
 func TestSetup(t *testing.T) {
 	tmpdir := os.TempDir()
 	tmpfile, err := os.OpenFile(filepath.Join(tmpdir, "cozy.yaml"), os.O_RDWR|os.O_CREATE|os.O_EXCL, 0600)
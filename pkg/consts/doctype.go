@@ -9,12 +9,22 @@ const Configs = "configs"
 const (
 	// Apps doc type for client-side application manifests
 	Apps = "io.cozy.apps"
+	// AppsRollouts doc type for the staged rollouts of the app updates
+	AppsRollouts = "io.cozy.apps.rollouts"
+	// AppsRolloutInstances doc type for the instances updated by a staged
+	// rollout
+	AppsRolloutInstances = "io.cozy.apps.rollouts.instances"
 	// AppsSuggestion doc type for suggesting apps to the user
 	AppsSuggestion = "io.cozy.apps.suggestions"
+	// AppPasswords doc type for the passwords that can be used with HTTP Basic
+	// auth by the clients that cannot do OAuth
+	AppPasswords = "io.cozy.app_passwords"
 	// Konnectors doc type for konnector application manifests
 	Konnectors = "io.cozy.konnectors"
 	// KonnectorsMaintenance doc type for maintenance of konnectors.
 	KonnectorsMaintenance = "io.cozy.konnectors.maintenance"
+	// AuditLogs doc type for the audit log of security-sensitive actions
+	AuditLogs = "io.cozy.audit"
 	// Archives doc type for zip archives with files and directories
 	Archives = "io.cozy.files.archives"
 	// Exports doc type for global exports archives
@@ -66,6 +76,9 @@ const (
 	RemoteRequests = "io.cozy.remote.requests"
 	// RemoteSecrets doc type for secrets used by remote doctypes
 	RemoteSecrets = "io.cozy.remote.secrets"
+	// RegistryVersions doc type for the versions published on the local
+	// registry server
+	RegistryVersions = "io.cozy.registry.versions"
 	// Sessions doc type for sessions identifying a connection
 	Sessions = "io.cozy.sessions"
 	// SessionsLogins doc type for sessions identifying a connection
@@ -85,6 +98,9 @@ const (
 	// SharingsInitialSync doc type for real-time events for initial sync of a
 	// sharing
 	SharingsInitialSync = "io.cozy.sharings.initial_sync"
+	// SharingsStatus doc type for real-time events about the progress and
+	// health of the replication of a sharing
+	SharingsStatus = "io.cozy.sharings.status"
 	// Triggers doc type for triggers, jobs launchers
 	Triggers = "io.cozy.triggers"
 	// TriggersState doc type for triggers current state, jobs launchers
@@ -119,6 +135,11 @@ const (
 	NotesURL = "io.cozy.notes.url"
 	// NotesImages doc type used for images used by a note
 	NotesImages = "io.cozy.notes.images"
+	// NotesVersions doc type used for the snapshots of the versions of a note
+	NotesVersions = "io.cozy.notes.versions"
+	// NotesComments doc type used for the comment threads and the suggestions
+	// on a note
+	NotesComments = "io.cozy.notes.comments"
 	// OfficeURL doc type is used to return the URL where an office document can be edited.
 	OfficeURL = "io.cozy.office.url"
 	// AuthConfirmations doc type used for realtime events when confirming
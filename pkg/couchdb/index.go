@@ -10,7 +10,7 @@ import (
 
 // IndexViewsVersion is the version of current definition of views & indexes.
 // This number should be incremented when this file changes.
-const IndexViewsVersion int = 32
+const IndexViewsVersion int = 35
 
 // Indexes is the index list required by an instance to run properly.
 var Indexes = []*mango.Index{
@@ -45,6 +45,14 @@ var Indexes = []*mango.Index{
 	// Used to lookup login history by OS, browser, and IP
 	mango.IndexOnFields(consts.SessionsLogins, "by-os-browser-ip", []string{"os", "browser", "ip"}),
 
+	// Used to know if a login comes from a new country
+	mango.IndexOnFields(consts.SessionsLogins, "by-country", []string{"country"}),
+
+	// Used to lookup the audit log by action, ordered by their creation date,
+	// and to find the old entries that must be deleted
+	mango.IndexOnFields(consts.AuditLogs, "by-action-created-at", []string{"action", "created_at"}),
+	mango.IndexOnFields(consts.AuditLogs, "by-created-at", []string{"created_at"}),
+
 	// Used to lookup notifications by their source, ordered by their creation
 	// date
 	mango.IndexOnFields(consts.Notifications, "by-source-id", []string{"source_id", "created_at"}),
@@ -71,6 +79,21 @@ function(doc) {
 	Reduce: "_sum",
 }
 
+// DiskUsageByAppView is the view used for computing the disk usage for the
+// files created by each application
+var DiskUsageByAppView = &View{
+	Name:    "disk-usage-by-app",
+	Doctype: consts.Files,
+	Map: `
+function(doc) {
+  if (doc.type === 'file' && doc.cozyMetadata && doc.cozyMetadata.createdByApp) {
+    emit(doc.cozyMetadata.createdByApp, +doc.size);
+  }
+}
+`,
+	Reduce: "_sum",
+}
+
 // OldVersionsDiskUsageView is the view used for computing the disk usage for
 // the old versions of file contents.
 var OldVersionsDiskUsageView = &View{
@@ -260,6 +283,7 @@ function(doc) {
 // Views is the list of all views that are created by the stack.
 var Views = []*View{
 	DiskUsageView,
+	DiskUsageByAppView,
 	OldVersionsDiskUsageView,
 	DirNotSynchronizedOnView,
 	FilesReferencedByView,
@@ -300,6 +324,9 @@ func IndexesByDoctype(doctype string) []*mango.Index {
 // properly.
 var globalIndexes = []*mango.Index{
 	mango.IndexOnFields(consts.Exports, "by-domain", []string{"domain", "created_at"}),
+	mango.IndexOnFields(consts.AppsRollouts, "by-status", []string{"status", "created_at"}),
+	mango.IndexOnFields(consts.AppsRollouts, "by-created-at", []string{"created_at"}),
+	mango.IndexOnFields(consts.AppsRolloutInstances, "by-rollout", []string{"rollout_id", "domain"}),
 }
 
 // secretIndexes is the index list required on the secret databases to run
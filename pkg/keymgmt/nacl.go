@@ -17,8 +17,16 @@ const (
 	naclKeyLen = 32
 )
 
+const (
+	naclCipherHeader = "nacl"
+	naclNonceLen     = 24
+)
+
 var errNACLBadKey = errors.New("keymgmt: bad nacl key")
 
+// ErrNACLBadCipher is used when a buffer can't be decrypted.
+var ErrNACLBadCipher = errors.New("keymgmt: bad nacl cipher")
+
 // NACLKey contains a NACL crypto box keypair.
 type NACLKey struct {
 	publicKey  *[32]byte
@@ -116,3 +124,34 @@ func unmarshalPEMBlock(keyBytes []byte, blockType string) ([]byte, error) {
 	}
 	return block.Bytes, nil
 }
+
+// EncryptBuffer encrypts the buffer with the nacl crypto box API. The output
+// starts with a header and the random nonce.
+func EncryptBuffer(encryptorKey *NACLKey, buf []byte) ([]byte, error) {
+	var nonce [naclNonceLen]byte
+	if _, err := io.ReadFull(rand.Reader, nonce[:]); err != nil {
+		return nil, err
+	}
+	out := make([]byte, len(naclCipherHeader)+len(nonce))
+	copy(out[0:], naclCipherHeader)
+	copy(out[len(naclCipherHeader):], nonce[:])
+	return box.Seal(out, buf, &nonce, encryptorKey.PublicKey(), encryptorKey.PrivateKey()), nil
+}
+
+// DecryptBuffer decrypts a buffer encrypted with EncryptBuffer.
+func DecryptBuffer(decryptorKey *NACLKey, encrypted []byte) ([]byte, error) {
+	if !bytes.HasPrefix(encrypted, []byte(naclCipherHeader)) {
+		return nil, ErrNACLBadCipher
+	}
+	encrypted = encrypted[len(naclCipherHeader):]
+	if len(encrypted) < naclNonceLen {
+		return nil, ErrNACLBadCipher
+	}
+	var nonce [naclNonceLen]byte
+	copy(nonce[:], encrypted[:naclNonceLen])
+	plain, ok := box.Open(nil, encrypted[naclNonceLen:], &nonce, decryptorKey.PublicKey(), decryptorKey.PrivateKey())
+	if !ok {
+		return nil, ErrNACLBadCipher
+	}
+	return plain, nil
+}
@@ -0,0 +1,198 @@
+package limits
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrLoginDelayed is returned when a login attempt is made too soon after
+// some failed attempts.
+var ErrLoginDelayed = errors.New("Too many failed login attempts, please retry later")
+
+// ErrLoginBanned is returned when a login attempt is made by an IP address
+// that has been temporarily banned.
+var ErrLoginBanned = errors.New("Temporarily banned because of too many failed login attempts")
+
+// BruteForceConfig is the configuration of the protection against brute-force
+// attacks on the login form.
+type BruteForceConfig struct {
+	// Window is the period during which the failed attempts are counted
+	Window time.Duration
+	// FreeAttempts is the number of failed attempts that can be made without
+	// delay
+	FreeAttempts int64
+	// BaseDelay is the delay after the first failed attempt past the free
+	// ones. It doubles for each new failed attempt, until MaxDelay.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// IPBanThreshold is the number of failed attempts from an IP address on
+	// an instance before the IP address is banned from this instance
+	IPBanThreshold int64
+	// GlobalIPBanThreshold is the number of failed attempts from an IP
+	// address, on all the instances, before the IP address is banned from
+	// all the instances (credential stuffing)
+	GlobalIPBanThreshold int64
+	// BanDuration is the duration of a temporary ban
+	BanDuration time.Duration
+}
+
+// BruteForce is the configuration used for the login attempts.
+var BruteForce = BruteForceConfig{
+	Window:               time.Hour,
+	FreeAttempts:         3,
+	BaseDelay:            time.Second,
+	MaxDelay:             time.Minute,
+	IPBanThreshold:       20,
+	GlobalIPBanThreshold: 100,
+	BanDuration:          time.Hour,
+}
+
+// BanEvents tells which bans have started with a failed login attempt.
+type BanEvents struct {
+	IPBanned       bool
+	GlobalIPBanned bool
+}
+
+// BanStatus is the state of the protection against brute-force attacks for
+// an IP address.
+type BanStatus struct {
+	Failures int64 `json:"failures"`
+	Delayed  bool  `json:"delayed"`
+	Banned   bool  `json:"banned"`
+}
+
+func ipKey(domain, ip string) string { return "bf-ip:" + domain + ":" + ip }
+func globalIPKey(ip string) string   { return "bf-global-ip:" + ip }
+func banKey(key string) string       { return "ban:" + key }
+func delayKey(key string) string     { return "delay:" + key }
+
+// isSet returns true if the counter for the key exists and has not expired.
+func isSet(c Counter, key string) bool {
+	n, err := c.Get(key)
+	return err == nil && n > 0
+}
+
+// setFor creates the counter for the key, with the given TTL, if it doesn't
+// exist.
+func setFor(c Counter, key string, d time.Duration) {
+	_, _ = c.Increment(key, d)
+}
+
+// ban starts a temporary ban for the key, and returns true if the key was not
+// already banned.
+func ban(c Counter, key string, d time.Duration) bool {
+	if isSet(c, banKey(key)) {
+		return false
+	}
+	setFor(c, banKey(key), d)
+	return true
+}
+
+// CheckLoginAttempt returns an error if a login attempt on the instance with
+// the given domain, from the given IP address, must be rejected without even
+// checking the passphrase. Only the IP addresses are delayed and banned, not
+// the account, else an attacker could lock out the owner of the instance.
+func CheckLoginAttempt(domain, ip string) error {
+	c := getCounter()
+	if isSet(c, banKey(globalIPKey(ip))) || isSet(c, banKey(ipKey(domain, ip))) {
+		return ErrLoginBanned
+	}
+	if isSet(c, delayKey(ipKey(domain, ip))) {
+		return ErrLoginDelayed
+	}
+	return nil
+}
+
+// LoginFailed records a failed login attempt. It adds a progressive delay
+// before the next attempt for the IP address, and bans it temporarily when
+// there are too many failed attempts. The returned events can be used to
+// notify the user.
+func LoginFailed(domain, ip string) (BanEvents, error) {
+	var events BanEvents
+	c := getCounter()
+	cfg := BruteForce
+
+	n, err := c.Increment(globalIPKey(ip), cfg.Window)
+	if err != nil {
+		return events, err
+	}
+	if n >= cfg.GlobalIPBanThreshold {
+		events.GlobalIPBanned = ban(c, globalIPKey(ip), cfg.BanDuration)
+	}
+
+	key := ipKey(domain, ip)
+	n, err = c.Increment(key, cfg.Window)
+	if err != nil {
+		return events, err
+	}
+	if n >= cfg.IPBanThreshold {
+		events.IPBanned = ban(c, key, cfg.BanDuration)
+	} else if d := cfg.delay(n); d > 0 {
+		setFor(c, delayKey(key), d)
+	}
+	return events, nil
+}
+
+// delay returns the delay to wait after the n-th failed attempt.
+func (cfg BruteForceConfig) delay(n int64) time.Duration {
+	if n <= cfg.FreeAttempts {
+		return 0
+	}
+	d := cfg.BaseDelay
+	for i := cfg.FreeAttempts + 1; i < n; i++ {
+		d *= 2
+		if d >= cfg.MaxDelay {
+			return cfg.MaxDelay
+		}
+	}
+	return d
+}
+
+// LoginSucceeded resets the counters of failed attempts for the IP address
+// on this instance (but not the global counter for the IP address).
+func LoginSucceeded(domain, ip string) {
+	c := getCounter()
+	key := ipKey(domain, ip)
+	_ = c.Reset(key)
+	_ = c.Reset(delayKey(key))
+}
+
+// IPBanStatus returns the state of the protection against brute-force attacks
+// for an IP address on the given instance, or on all the instances if the
+// domain is empty.
+func IPBanStatus(domain, ip string) BanStatus {
+	if domain == "" {
+		return banStatus(globalIPKey(ip))
+	}
+	return banStatus(ipKey(domain, ip))
+}
+
+func banStatus(key string) BanStatus {
+	c := getCounter()
+	n, _ := c.Get(key)
+	return BanStatus{
+		Failures: n,
+		Delayed:  isSet(c, delayKey(key)),
+		Banned:   isSet(c, banKey(key)),
+	}
+}
+
+// UnbanIP removes the ban and the counters of failed attempts for an IP
+// address on the given instance, or on all the instances if the domain is
+// empty.
+func UnbanIP(domain, ip string) error {
+	if domain == "" {
+		return unban(globalIPKey(ip))
+	}
+	return unban(ipKey(domain, ip))
+}
+
+func unban(key string) error {
+	c := getCounter()
+	for _, k := range []string{key, delayKey(key), banKey(key)} {
+		if err := c.Reset(k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
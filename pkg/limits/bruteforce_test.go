@@ -0,0 +1,98 @@
+package limits
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBruteForceDelay(t *testing.T) {
+	cfg := BruteForceConfig{FreeAttempts: 3, BaseDelay: time.Second, MaxDelay: 10 * time.Second}
+	assert.Equal(t, time.Duration(0), cfg.delay(1))
+	assert.Equal(t, time.Duration(0), cfg.delay(3))
+	assert.Equal(t, time.Second, cfg.delay(4))
+	assert.Equal(t, 2*time.Second, cfg.delay(5))
+	assert.Equal(t, 8*time.Second, cfg.delay(7))
+	assert.Equal(t, 10*time.Second, cfg.delay(8))
+	assert.Equal(t, 10*time.Second, cfg.delay(100))
+}
+
+func TestBruteForceMem(t *testing.T) {
+	globalCounter = NewMemCounter()
+	backup := BruteForce
+	defer func() { BruteForce = backup }()
+	BruteForce = BruteForceConfig{
+		Window:               time.Hour,
+		FreeAttempts:         2,
+		BaseDelay:            time.Hour,
+		MaxDelay:             time.Hour,
+		IPBanThreshold:       4,
+		GlobalIPBanThreshold: 10,
+		BanDuration:          time.Hour,
+	}
+	domain := "bruteforce.example.net"
+	ip := "203.0.113.12"
+
+	assert.NoError(t, CheckLoginAttempt(domain, ip))
+	for i := 0; i < 2; i++ {
+		events, err := LoginFailed(domain, ip)
+		assert.NoError(t, err)
+		assert.False(t, events.IPBanned)
+		assert.NoError(t, CheckLoginAttempt(domain, ip))
+	}
+
+	// Progressive delay
+	_, err := LoginFailed(domain, ip)
+	assert.NoError(t, err)
+	assert.Equal(t, ErrLoginDelayed, CheckLoginAttempt(domain, ip))
+	assert.True(t, IPBanStatus(domain, ip).Delayed)
+	LoginSucceeded(domain, ip)
+	assert.NoError(t, CheckLoginAttempt(domain, ip))
+
+	// Ban of the IP address on this instance
+	var events BanEvents
+	for i := 0; i < 4; i++ {
+		events, err = LoginFailed(domain, ip)
+		assert.NoError(t, err)
+	}
+	assert.True(t, events.IPBanned)
+	assert.Equal(t, ErrLoginBanned, CheckLoginAttempt(domain, ip))
+	assert.NoError(t, CheckLoginAttempt("other.example.net", ip))
+	assert.True(t, IPBanStatus(domain, ip).Banned)
+
+	// The ban is not started again for the next attempts
+	events, err = LoginFailed(domain, ip)
+	assert.NoError(t, err)
+	assert.False(t, events.IPBanned)
+	assert.True(t, IPBanStatus(domain, ip).Banned)
+
+	// The IP address is banned again if the ban expires while the counter
+	// is still over the threshold
+	assert.NoError(t, getCounter().Reset(banKey(ipKey(domain, ip))))
+	events, err = LoginFailed(domain, ip)
+	assert.NoError(t, err)
+	assert.True(t, events.IPBanned)
+
+	assert.NoError(t, UnbanIP(domain, ip))
+	assert.False(t, IPBanStatus(domain, ip).Banned)
+	assert.NoError(t, CheckLoginAttempt(domain, ip))
+
+	// The failed attempts from several IP addresses don't lock the account
+	for i := 0; i < 10; i++ {
+		_, err = LoginFailed(domain, "198.51.100."+string(rune('0'+i)))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, CheckLoginAttempt(domain, "192.0.2.1"))
+
+	// Global ban of the IP address (credential stuffing)
+	other := "192.0.2.200"
+	for i := 0; i < 10; i++ {
+		events, err = LoginFailed("stuffing"+string(rune('a'+i))+".example.net", other)
+		assert.NoError(t, err)
+	}
+	assert.True(t, events.GlobalIPBanned)
+	assert.Equal(t, ErrLoginBanned, CheckLoginAttempt("fresh.example.net", other))
+	assert.NoError(t, UnbanIP("", other))
+	assert.NoError(t, CheckLoginAttempt("fresh.example.net", other))
+}
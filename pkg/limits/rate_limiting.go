@@ -73,6 +73,9 @@ const (
 	WebhookTriggerType
 	// JobCleanClientType is used for cleaning unused OAuth clients
 	JobCleanClientType
+	// SharingPublicLinkPasswordType is used for counting the number of
+	// attempts to unlock a share by link protected by a password
+	SharingPublicLinkPasswordType
 )
 
 type counterConfig struct {
@@ -208,6 +211,12 @@ var configs = []counterConfig{
 		Limit:  100,
 		Period: 1 * time.Hour,
 	},
+	// SharingPublicLinkPasswordType
+	{
+		Prefix: "sharing-public-link-password",
+		Limit:  10,
+		Period: 15 * time.Minute,
+	},
 }
 
 // Counter is an interface for counting number of attempts that can be used to
@@ -215,6 +224,9 @@ var configs = []counterConfig{
 // attacks.
 type Counter interface {
 	Increment(key string, timeLimit time.Duration) (int64, error)
+	// Get returns the current value of the counter, or 0 if it doesn't exist
+	// or has expired.
+	Get(key string) (int64, error)
 	Reset(key string) error
 }
 
@@ -269,7 +281,7 @@ func (c *memCounter) Increment(key string, timeLimit time.Duration) (int64, erro
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	if _, ok := c.vals[key]; !ok {
+	if ref, ok := c.vals[key]; !ok || time.Now().After(ref.exp) {
 		c.vals[key] = &memRef{
 			val: 0,
 			exp: time.Now().Add(timeLimit),
@@ -279,7 +291,20 @@ func (c *memCounter) Increment(key string, timeLimit time.Duration) (int64, erro
 	return c.vals[key].val, nil
 }
 
+func (c *memCounter) Get(key string) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ref, ok := c.vals[key]
+	if !ok || time.Now().After(ref.exp) {
+		return 0, nil
+	}
+	return ref.val, nil
+}
+
 func (c *memCounter) Reset(key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	delete(c.vals, key)
 	return nil
 }
@@ -314,6 +339,14 @@ func (r *redisCounter) Increment(key string, timeLimit time.Duration) (int64, er
 	return count.(int64), nil
 }
 
+func (r *redisCounter) Get(key string) (int64, error) {
+	n, err := r.Client.Get(r.ctx, key).Int64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	return n, err
+}
+
 func (r *redisCounter) Reset(key string) error {
 	_, err := r.Client.Del(r.ctx, key).Result()
 	return err
@@ -0,0 +1,109 @@
+package pdf
+
+import "fmt"
+
+// The widths of the characters, in thousandths of the font size, for the
+// printable ASCII characters (from 32 to 126). They come from the Adobe Font
+// Metrics files of the standard fonts. The oblique variants have the same
+// widths as their upright counterparts.
+var helveticaWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+var helveticaBoldWidths = [95]int{
+	278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+	975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+	333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+	611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+}
+
+// winAnsi maps the characters of the 0x80-0x9F range of WinAnsiEncoding to
+// their unicode code points.
+var winAnsi = map[rune]byte{
+	'€': 0x80, '‚': 0x82, 'ƒ': 0x83, '„': 0x84, '…': 0x85, '†': 0x86, '‡': 0x87,
+	'ˆ': 0x88, '‰': 0x89, 'Š': 0x8a, '‹': 0x8b, 'Œ': 0x8c, 'Ž': 0x8e, '‘': 0x91,
+	'’': 0x92, '“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97, '˜': 0x98,
+	'™': 0x99, 'š': 0x9a, '›': 0x9b, 'œ': 0x9c, 'ž': 0x9e, 'Ÿ': 0x9f,
+}
+
+// UnsupportedCharError is used when a text has a character that can't be
+// written with the standard fonts, as they only have the characters of
+// WinAnsiEncoding (latin-1 and a few more).
+type UnsupportedCharError struct {
+	Char rune
+}
+
+func (e *UnsupportedCharError) Error() string {
+	return fmt.Sprintf("pdf: the character %q (%U) is not supported", e.Char, e.Char)
+}
+
+// CheckText returns an UnsupportedCharError for the first character of the
+// text that can't be encoded, or nil if all the characters are supported.
+func CheckText(text string) error {
+	for _, r := range text {
+		if r < 0x7f || (r >= 0xa0 && r <= 0xff) {
+			continue
+		}
+		if _, ok := winAnsi[r]; !ok {
+			return &UnsupportedCharError{Char: r}
+		}
+	}
+	return nil
+}
+
+// Encode converts a text to WinAnsiEncoding. The characters that can't be
+// encoded are replaced by a question mark (see CheckText).
+func Encode(text string) []byte {
+	encoded := make([]byte, 0, len(text))
+	for _, r := range text {
+		switch {
+		case r == '\t':
+			encoded = append(encoded, ' ')
+		case r < 0x20:
+			continue
+		case r < 0x7f || (r >= 0xa0 && r <= 0xff):
+			encoded = append(encoded, byte(r))
+		default:
+			if b, ok := winAnsi[r]; ok {
+				encoded = append(encoded, b)
+			} else {
+				encoded = append(encoded, '?')
+			}
+		}
+	}
+	return encoded
+}
+
+// TextWidth returns the width of the text in points, for the given font and
+// size.
+func TextWidth(font Font, size float64, text string) float64 {
+	total := 0
+	for _, b := range Encode(text) {
+		total += charWidth(font, b)
+	}
+	return float64(total) * size / 1000
+}
+
+func charWidth(font Font, b byte) int {
+	switch font {
+	case Courier:
+		return 600
+	case HelveticaBold, HelveticaBoldOblique:
+		if b >= 32 && b <= 126 {
+			return helveticaBoldWidths[b-32]
+		}
+		return 556
+	default:
+		if b >= 32 && b <= 126 {
+			return helveticaWidths[b-32]
+		}
+		return 556
+	}
+}
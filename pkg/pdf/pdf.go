@@ -0,0 +1,403 @@
+// Package pdf is a small PDF writer. It supports what is needed to export
+// documents from the stack: pages with text in the standard fonts (that don't
+// need to be embedded), lines, rectangles, images and links.
+//
+// The coordinates are in points (1/72 inch), with the origin at the top left
+// corner of the page, and the y axis going down.
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"io"
+	"strings"
+	"time"
+
+	// Registers the decoders for the images
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+)
+
+const (
+	// A4Width is the width of an A4 page in points
+	A4Width = 595.28
+	// A4Height is the height of an A4 page in points
+	A4Height = 841.89
+)
+
+// Font is one of the standard fonts of PDF readers.
+type Font int
+
+const (
+	// Helvetica is the regular sans-serif font
+	Helvetica Font = iota
+	// HelveticaBold is the bold sans-serif font
+	HelveticaBold
+	// HelveticaOblique is the italic sans-serif font
+	HelveticaOblique
+	// HelveticaBoldOblique is the bold and italic sans-serif font
+	HelveticaBoldOblique
+	// Courier is the monospace font
+	Courier
+	nbFonts
+)
+
+var fontNames = [nbFonts]string{
+	"Helvetica",
+	"Helvetica-Bold",
+	"Helvetica-Oblique",
+	"Helvetica-BoldOblique",
+	"Courier",
+}
+
+// Color is a RGB color, with components between 0 and 1.
+type Color struct {
+	R, G, B float64
+}
+
+var (
+	// Black is the default color for the text
+	Black = Color{0, 0, 0}
+	// White is the color of the page
+	White = Color{1, 1, 1}
+)
+
+// ParseColor parses a color in the #rrggbb format. It returns the default
+// color if the string can't be parsed.
+func ParseColor(s string, def Color) Color {
+	var r, g, b uint8
+	if len(s) != 7 || s[0] != '#' {
+		return def
+	}
+	if _, err := fmt.Sscanf(s[1:], "%02x%02x%02x", &r, &g, &b); err != nil {
+		return def
+	}
+	return Color{float64(r) / 255, float64(g) / 255, float64(b) / 255}
+}
+
+// Document is a PDF document that is built in memory.
+type Document struct {
+	Title  string
+	Width  float64
+	Height float64
+	pages  []*Page
+	images []*Image
+	err    error
+}
+
+// Page is a page of a PDF document.
+type Page struct {
+	doc     *Document
+	content bytes.Buffer
+	links   []link
+	fonts   map[Font]bool
+	images  map[int]bool
+}
+
+type link struct {
+	x, y, w, h float64
+	uri        string
+}
+
+// Image is an image that has been added to the document, and can be drawn on
+// its pages.
+type Image struct {
+	index  int
+	Width  int
+	Height int
+	filter string
+	data   []byte
+	smask  []byte
+}
+
+// New returns a new document with A4 pages.
+func New() *Document {
+	return &Document{Width: A4Width, Height: A4Height}
+}
+
+// AddPage adds a new page at the end of the document.
+func (d *Document) AddPage() *Page {
+	p := &Page{
+		doc:    d,
+		fonts:  make(map[Font]bool),
+		images: make(map[int]bool),
+	}
+	d.pages = append(d.pages, p)
+	return p
+}
+
+// NbPages returns the number of pages of the document.
+func (d *Document) NbPages() int {
+	return len(d.pages)
+}
+
+// AddImage decodes an image (JPEG, PNG or GIF) and adds it to the document.
+// The JPEG images are embedded as is, the other formats are converted.
+func (d *Document) AddImage(data []byte) (*Image, error) {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	img := &Image{index: len(d.images), Width: cfg.Width, Height: cfg.Height}
+	if format == "jpeg" && cfg.ColorModel == color.YCbCrModel {
+		img.filter = "DCTDecode"
+		img.data = data
+	} else {
+		decoded, _, err := image.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		img.filter = "FlateDecode"
+		img.data, img.smask = encodeRGB(decoded)
+	}
+	d.images = append(d.images, img)
+	return img, nil
+}
+
+// encodeRGB returns the compressed RGB pixels of the image, and the
+// compressed alpha channel if the image is not opaque.
+func encodeRGB(img image.Image) ([]byte, []byte) {
+	bounds := img.Bounds()
+	rgba := image.NewNRGBA(bounds)
+	draw.Draw(rgba, bounds, img, bounds.Min, draw.Src)
+	pixels := make([]byte, 0, bounds.Dx()*bounds.Dy()*3)
+	alpha := make([]byte, 0, bounds.Dx()*bounds.Dy())
+	opaque := true
+	for i := 0; i < len(rgba.Pix); i += 4 {
+		pixels = append(pixels, rgba.Pix[i], rgba.Pix[i+1], rgba.Pix[i+2])
+		alpha = append(alpha, rgba.Pix[i+3])
+		if rgba.Pix[i+3] != 0xff {
+			opaque = false
+		}
+	}
+	if opaque {
+		return compress(pixels), nil
+	}
+	return compress(pixels), compress(alpha)
+}
+
+func compress(data []byte) []byte {
+	var buf bytes.Buffer
+	w := zlib.NewWriter(&buf)
+	_, _ = w.Write(data)
+	_ = w.Close()
+	return buf.Bytes()
+}
+
+// Text draws a text, with y being the baseline. If the text has a character
+// that is not supported, the error is returned by WriteTo.
+func (p *Page) Text(x, y float64, font Font, size float64, c Color, text string) {
+	if err := CheckText(text); err != nil && p.doc.err == nil {
+		p.doc.err = err
+	}
+	p.fonts[font] = true
+	fmt.Fprintf(&p.content, "BT %s rg /F%d %s Tf %s %s Td (%s) Tj ET\n",
+		c.rgb(), font, num(size), num(x), num(p.doc.Height-y), escape(text))
+}
+
+// Line draws a line from (x1, y1) to (x2, y2).
+func (p *Page) Line(x1, y1, x2, y2, width float64, c Color) {
+	fmt.Fprintf(&p.content, "%s RG %s w %s %s m %s %s l S\n",
+		c.rgb(), num(width), num(x1), num(p.doc.Height-y1), num(x2), num(p.doc.Height-y2))
+}
+
+// Rect fills a rectangle with the given color.
+func (p *Page) Rect(x, y, w, h float64, c Color) {
+	fmt.Fprintf(&p.content, "%s rg %s %s %s %s re f\n",
+		c.rgb(), num(x), num(p.doc.Height-y-h), num(w), num(h))
+}
+
+// StrokeRect draws the border of a rectangle.
+func (p *Page) StrokeRect(x, y, w, h, width float64, c Color) {
+	fmt.Fprintf(&p.content, "%s RG %s w %s %s %s %s re S\n",
+		c.rgb(), num(width), num(x), num(p.doc.Height-y-h), num(w), num(h))
+}
+
+// DrawImage draws an image in the given rectangle.
+func (p *Page) DrawImage(img *Image, x, y, w, h float64) {
+	p.images[img.index] = true
+	fmt.Fprintf(&p.content, "q %s 0 0 %s %s %s cm /Im%d Do Q\n",
+		num(w), num(h), num(x), num(p.doc.Height-y-h), img.index)
+}
+
+// Link makes a rectangle of the page a link to the given URI.
+func (p *Page) Link(x, y, w, h float64, uri string) {
+	p.links = append(p.links, link{x: x, y: y, w: w, h: h, uri: uri})
+}
+
+// WriteTo writes the PDF document to w.
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	if d.err != nil {
+		return 0, d.err
+	}
+	if len(d.pages) == 0 {
+		d.AddPage()
+	}
+	pw := &writer{}
+	pw.buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// Objects 1 and 2 are the catalog and the pages tree, then the fonts,
+	// the images, and the pages.
+	catalog := pw.reserve()
+	pagesRoot := pw.reserve()
+	var fonts [nbFonts]int
+	for f := Font(0); f < nbFonts; f++ {
+		fonts[f] = pw.object(fmt.Sprintf(
+			"<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>",
+			fontNames[f]))
+	}
+	images := make([]int, len(d.images))
+	for i, img := range d.images {
+		smask := ""
+		if img.smask != nil {
+			ref := pw.stream(fmt.Sprintf(
+				"/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceGray /BitsPerComponent 8 /Filter /FlateDecode",
+				img.Width, img.Height), img.smask)
+			smask = fmt.Sprintf(" /SMask %d 0 R", ref)
+		}
+		images[i] = pw.stream(fmt.Sprintf(
+			"/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceRGB /BitsPerComponent 8 /Filter /%s%s",
+			img.Width, img.Height, img.filter, smask), img.data)
+	}
+
+	kids := make([]string, len(d.pages))
+	for i, p := range d.pages {
+		content := pw.stream("/Filter /FlateDecode", compress(p.content.Bytes()))
+		var res strings.Builder
+		res.WriteString("<< /Font <<")
+		for f := Font(0); f < nbFonts; f++ {
+			if p.fonts[f] {
+				fmt.Fprintf(&res, " /F%d %d 0 R", f, fonts[f])
+			}
+		}
+		res.WriteString(" >> /XObject <<")
+		for idx := range d.images {
+			if p.images[idx] {
+				fmt.Fprintf(&res, " /Im%d %d 0 R", idx, images[idx])
+			}
+		}
+		res.WriteString(" >> >>")
+		var annots strings.Builder
+		if len(p.links) > 0 {
+			annots.WriteString(" /Annots [")
+			for _, l := range p.links {
+				ref := pw.object(fmt.Sprintf(
+					"<< /Type /Annot /Subtype /Link /Border [0 0 0] /Rect [%s %s %s %s] /A << /S /URI /URI (%s) >> >>",
+					num(l.x), num(d.Height-l.y-l.h), num(l.x+l.w), num(d.Height-l.y), escapeBytes([]byte(l.uri))))
+				fmt.Fprintf(&annots, " %d 0 R", ref)
+			}
+			annots.WriteString(" ]")
+		}
+		page := pw.object(fmt.Sprintf(
+			"<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %s %s] /Contents %d 0 R /Resources %s%s >>",
+			pagesRoot, num(d.Width), num(d.Height), content, res.String(), annots.String()))
+		kids[i] = fmt.Sprintf("%d 0 R", page)
+	}
+	pw.set(pagesRoot, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>",
+		strings.Join(kids, " "), len(kids)))
+	pw.set(catalog, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pagesRoot))
+	info := pw.object(fmt.Sprintf("<< /Title %s /Producer (Cozy) /CreationDate (D:%s) >>",
+		textString(d.Title), time.Now().UTC().Format("20060102150405Z")))
+
+	xref := pw.buf.Len()
+	fmt.Fprintf(&pw.buf, "xref\n0 %d\n0000000000 65535 f \n", len(pw.offsets)+1)
+	for _, off := range pw.offsets {
+		fmt.Fprintf(&pw.buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&pw.buf, "trailer\n<< /Size %d /Root %d 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n",
+		len(pw.offsets)+1, catalog, info, xref)
+	return pw.buf.WriteTo(w)
+}
+
+// writer serializes the objects of a PDF file, and keeps their offsets for
+// the cross-reference table. An object number can be reserved, to reference
+// the object before it is written.
+type writer struct {
+	buf     bytes.Buffer
+	offsets []int
+}
+
+func (pw *writer) reserve() int {
+	pw.offsets = append(pw.offsets, -1)
+	return len(pw.offsets)
+}
+
+func (pw *writer) set(ref int, body string) {
+	pw.offsets[ref-1] = pw.buf.Len()
+	fmt.Fprintf(&pw.buf, "%d 0 obj\n%s\nendobj\n", ref, body)
+}
+
+func (pw *writer) object(body string) int {
+	ref := pw.reserve()
+	pw.set(ref, body)
+	return ref
+}
+
+func (pw *writer) stream(dict string, data []byte) int {
+	ref := pw.reserve()
+	pw.offsets[ref-1] = pw.buf.Len()
+	fmt.Fprintf(&pw.buf, "%d 0 obj\n<< %s /Length %d >>\nstream\n", ref, dict, len(data))
+	pw.buf.Write(data)
+	pw.buf.WriteString("\nendstream\nendobj\n")
+	return ref
+}
+
+func (c Color) rgb() string {
+	return fmt.Sprintf("%s %s %s", num(c.R), num(c.G), num(c.B))
+}
+
+func num(f float64) string {
+	s := fmt.Sprintf("%.2f", f)
+	s = strings.TrimRight(s, "0")
+	s = strings.TrimSuffix(s, ".")
+	if s == "-0" {
+		s = "0"
+	}
+	return s
+}
+
+// escape encodes the text in WinAnsiEncoding, and escapes the special
+// characters for a PDF string.
+func escape(text string) string {
+	return escapeBytes(Encode(text))
+}
+
+func escapeBytes(data []byte) string {
+	var sb strings.Builder
+	for _, b := range data {
+		switch b {
+		case '(', ')', '\\':
+			sb.WriteByte('\\')
+			sb.WriteByte(b)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\r':
+			sb.WriteString(`\r`)
+		default:
+			sb.WriteByte(b)
+		}
+	}
+	return sb.String()
+}
+
+// textString encodes a text string (like the title in the document
+// information) in UTF-16BE.
+func textString(s string) string {
+	var sb strings.Builder
+	sb.WriteString("<FEFF")
+	for _, r := range s {
+		if r > 0xffff {
+			r -= 0x10000
+			fmt.Fprintf(&sb, "%04X%04X", 0xd800+(r>>10), 0xdc00+(r&0x3ff))
+		} else {
+			fmt.Fprintf(&sb, "%04X", r)
+		}
+	}
+	sb.WriteString(">")
+	return sb.String()
+}
@@ -0,0 +1,114 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"image"
+	"image/color"
+	"image/png"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTextWidth(t *testing.T) {
+	assert.Equal(t, 0.0, TextWidth(Helvetica, 12, ""))
+	assert.InDelta(t, 6.672, TextWidth(Helvetica, 12, "a"), 0.001)
+	assert.InDelta(t, 7.332, TextWidth(HelveticaBold, 12, "b"), 0.001)
+	assert.InDelta(t, 36, TextWidth(Courier, 10, "foobar"), 0.001)
+}
+
+func TestEncode(t *testing.T) {
+	assert.Equal(t, []byte("abc"), Encode("abc"))
+	assert.Equal(t, []byte{'c', 0xe9, 0x80}, Encode("cé€"))
+	assert.Equal(t, []byte("a?b"), Encode("a☃b"))
+}
+
+func TestCheckText(t *testing.T) {
+	assert.NoError(t, CheckText("Café à 5€ – «ok»\t"))
+	assert.Equal(t, &UnsupportedCharError{Char: '☃'}, CheckText("a☃b"))
+	assert.Equal(t, &UnsupportedCharError{Char: 'ж'}, CheckText("жук"))
+
+	doc := New()
+	doc.AddPage().Text(50, 50, Helvetica, 12, Black, "Hello 世界")
+	_, err := doc.WriteTo(ioutil.Discard)
+	assert.Equal(t, &UnsupportedCharError{Char: '世'}, err)
+}
+
+func TestParseColor(t *testing.T) {
+	assert.Equal(t, Color{1, 0, 0}, ParseColor("#ff0000", Black))
+	assert.Equal(t, Black, ParseColor("red", Black))
+}
+
+func TestWriteDocument(t *testing.T) {
+	doc := New()
+	doc.Title = "Test (1)"
+	page := doc.AddPage()
+	page.Text(50, 50, Helvetica, 12, Black, "Hello (world)")
+	page.Line(50, 60, 200, 60, 1, Black)
+	page.Rect(50, 70, 100, 20, ParseColor("#eeeeee", White))
+	page.Link(50, 40, 100, 12, "https://cozy.io/")
+
+	img := image.NewNRGBA(image.Rect(0, 0, 4, 4))
+	img.Set(1, 1, color.NRGBA{R: 255, A: 128})
+	var buf bytes.Buffer
+	assert.NoError(t, png.Encode(&buf, img))
+	pic, err := doc.AddImage(buf.Bytes())
+	assert.NoError(t, err)
+	assert.Equal(t, 4, pic.Width)
+	page.DrawImage(pic, 50, 100, 40, 40)
+	doc.AddPage().Text(50, 50, HelveticaBold, 16, Black, "Page 2")
+	assert.Equal(t, 2, doc.NbPages())
+
+	var out bytes.Buffer
+	_, err = doc.WriteTo(&out)
+	assert.NoError(t, err)
+	pdf := out.Bytes()
+	assert.True(t, bytes.HasPrefix(pdf, []byte("%PDF-1.4")))
+	assert.True(t, bytes.HasSuffix(pdf, []byte("%%EOF\n")))
+	assert.Contains(t, string(pdf), "/Count 2")
+	assert.Contains(t, string(pdf), "/SMask")
+	assert.Contains(t, string(pdf), "/URI (https://cozy.io/)")
+	assert.Contains(t, string(pdf), "/Title <FEFF00540065007300740020002800310029>")
+	assert.Contains(t, string(pdf), "/BaseFont /Helvetica-Bold")
+
+	// Check the content of the pages
+	streams := regexp.MustCompile(`(?s)/Filter /FlateDecode /Length \d+ >>\nstream\n(.*?)\nendstream`).FindAllSubmatch(pdf, -1)
+	var contents []string
+	for _, stream := range streams {
+		r, err := zlib.NewReader(bytes.NewReader(stream[1]))
+		if err != nil {
+			continue
+		}
+		data, err := ioutil.ReadAll(r)
+		assert.NoError(t, err)
+		if bytes.Contains(data, []byte(" Tj ")) {
+			contents = append(contents, string(data))
+		}
+	}
+	if assert.Len(t, contents, 2) {
+		assert.Contains(t, contents[0], "/F0 12 Tf 50 791.89 Td (Hello \\(world\\)) Tj")
+		assert.Contains(t, contents[0], "re f")
+		assert.Contains(t, contents[0], "cm /Im0 Do")
+		assert.Contains(t, contents[1], "/F1 16 Tf 50 791.89 Td (Page 2) Tj")
+	}
+
+	// Check that the offsets of the cross-reference table are correct
+	xref := regexp.MustCompile(`startxref\n(\d+)`).FindSubmatch(pdf)
+	if assert.NotNil(t, xref) {
+		offset, _ := strconv.Atoi(string(xref[1]))
+		assert.True(t, bytes.HasPrefix(pdf[offset:], []byte("xref\n")))
+		entries := regexp.MustCompile(`(\d{10}) 00000 n`).FindAllSubmatch(pdf[offset:], -1)
+		for i, entry := range entries {
+			off, _ := strconv.Atoi(string(entry[1]))
+			prefix := []byte(strconv.Itoa(i+1) + " 0 obj")
+			assert.True(t, bytes.HasPrefix(pdf[off:], prefix), "object %d", i+1)
+		}
+	}
+
+	_, err = doc.AddImage([]byte("not an image"))
+	assert.Error(t, err)
+}
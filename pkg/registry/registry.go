@@ -32,6 +32,9 @@ type Version struct {
 	Size      string          `json:"size"`
 	Manifest  json.RawMessage `json:"manifest"`
 	TarPrefix string          `json:"tar_prefix"`
+	// Signature is the base64-encoded ed25519 signature of the tarball by
+	// its publisher
+	Signature string `json:"signature,omitempty"`
 }
 
 // A MaintenanceOptions defines options about a maintenance
@@ -51,6 +54,7 @@ type Application struct {
 
 var errVersionNotFound = errors.New("registry: version not found")
 var errApplicationNotFound = errors.New("registry: application not found")
+var errSigningKeysNotFound = errors.New("registry: signing keys not found")
 
 var (
 	proxyClient = &http.Client{
@@ -145,6 +149,40 @@ func GetApplication(slug string, registries []*url.URL) (*Application, error) {
 	return app, nil
 }
 
+// SigningKey is an ed25519 public key of a publisher, with its endorsement:
+// the signature of the key by a root key of the registry. Both are
+// base64-encoded.
+type SigningKey struct {
+	Key       string `json:"key"`
+	Signature string `json:"signature"`
+}
+
+// EndorsementMessage returns the message signed by a root key to endorse the
+// base64-encoded public key of a publisher.
+func EndorsementMessage(key string) []byte {
+	return []byte("cozy-signing-key:v1:" + key)
+}
+
+// GetSigningKeys returns the public keys of the publishers, with their
+// endorsements, as published by the registries.
+func GetSigningKeys(registries []*url.URL) ([]SigningKey, error) {
+	resp, ok, err := fetchUntilFound(appClient, registries, "/registry/signing-keys", WithCache)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errSigningKeysNotFound
+	}
+	defer resp.Body.Close()
+	var doc struct {
+		Keys []SigningKey `json:"keys"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return nil, err
+	}
+	return doc.Keys, nil
+}
+
 // Proxy will proxy the given request to the registries in sequence and return
 // the response as io.ReadCloser when finding a registry returning a HTTP 200OK
 // response.
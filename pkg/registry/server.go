@@ -0,0 +1,530 @@
+package registry
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cozy/cozy-stack/pkg/config/config"
+	"github.com/cozy/cozy-stack/pkg/consts"
+	"github.com/cozy/cozy-stack/pkg/couchdb"
+)
+
+// This file contains the local registry server: the stack can be used as a
+// registry, with the same JSON shapes as the official registries, for the
+// deployments that can't access them.
+
+const (
+	webappManifestName    = "manifest.webapp"
+	konnectorManifestName = "manifest.konnector"
+
+	// maxTarballSize is the maximal size of a tarball published on the local
+	// registry.
+	maxTarballSize = 512 << 20
+)
+
+var (
+	// ErrVersionExists is used when publishing a version that has already
+	// been published.
+	ErrVersionExists = errors.New("registry: this version has already been published")
+	// ErrInvalidTarball is used when publishing a tarball that is not a
+	// tar.gz archive of an application.
+	ErrInvalidTarball = errors.New("registry: the tarball must be a tar.gz archive with a manifest")
+	// ErrInvalidSlug is used when the slug of the application is invalid.
+	ErrInvalidSlug = errors.New("registry: invalid slug")
+	// ErrInvalidVersion is used when the version of the application does not
+	// follow the format of the channels.
+	ErrInvalidVersion = errors.New("registry: invalid version number")
+	// ErrTarballTooLarge is used when the tarball exceeds the maximal size.
+	ErrTarballTooLarge = errors.New("registry: the tarball is too large")
+	// ErrNotFound is used when the application or version is not published on
+	// the local registry.
+	ErrNotFound = errors.New("registry: not found")
+)
+
+var (
+	slugReg    = regexp.MustCompile(`^[a-z0-9\-]+$`)
+	stableReg  = regexp.MustCompile(`^(\d+)\.(\d+)\.(\d+)$`)
+	betaReg    = regexp.MustCompile(`^(\d+)\.(\d+)\.(\d+)-beta\.(\d+)$`)
+	devReg     = regexp.MustCompile(`^(\d+)\.(\d+)\.(\d+)-dev\.[A-Za-z0-9.\-]+$`)
+	channelSet = []string{"stable", "beta", "dev"}
+)
+
+// PublishedVersion is a version of an application published on the local
+// registry server.
+type PublishedVersion struct {
+	DocID  string `json:"_id,omitempty"`
+	DocRev string `json:"_rev,omitempty"`
+	Version
+	Type    string `json:"type"`
+	Channel string `json:"channel"`
+	Editor  string `json:"editor,omitempty"`
+}
+
+// ID implements couchdb.Doc
+func (v *PublishedVersion) ID() string { return v.DocID }
+
+// Rev implements couchdb.Doc
+func (v *PublishedVersion) Rev() string { return v.DocRev }
+
+// DocType implements couchdb.Doc
+func (v *PublishedVersion) DocType() string { return consts.RegistryVersions }
+
+// SetID implements couchdb.Doc
+func (v *PublishedVersion) SetID(id string) { v.DocID = id }
+
+// SetRev implements couchdb.Doc
+func (v *PublishedVersion) SetRev(rev string) { v.DocRev = rev }
+
+// Clone implements couchdb.Doc
+func (v *PublishedVersion) Clone() couchdb.Doc {
+	cloned := *v
+	cloned.Manifest = make(json.RawMessage, len(v.Manifest))
+	copy(cloned.Manifest, v.Manifest)
+	return &cloned
+}
+
+// Public returns a copy of the version without the CouchDB fields, as it is
+// served by the registry.
+func (v *PublishedVersion) Public() *PublishedVersion {
+	cloned := *v
+	cloned.DocID = ""
+	cloned.DocRev = ""
+	return &cloned
+}
+
+// PublishedApp is an application published on the local registry server.
+type PublishedApp struct {
+	Slug          string              `json:"slug"`
+	Type          string              `json:"type"`
+	Editor        string              `json:"editor"`
+	Versions      map[string][]string `json:"versions"`
+	LatestVersion *PublishedVersion   `json:"latest_version"`
+}
+
+func versionDocID(slug, version string) string {
+	return slug + "@" + version
+}
+
+// VersionChannel returns the channel of a version number (stable, beta or
+// dev), or an empty string if the version number is invalid.
+func VersionChannel(version string) string {
+	switch {
+	case stableReg.MatchString(version):
+		return "stable"
+	case betaReg.MatchString(version):
+		return "beta"
+	case devReg.MatchString(version):
+		return "dev"
+	}
+	return ""
+}
+
+// versionLess tells if the version a is older than the version b:
+// 1.0.0-dev.* < 1.0.0 and 1.0.0-beta.* < 1.0.0, and the beta and dev releases
+// of the same version are ordered by their creation date.
+func versionLess(a, b *PublishedVersion) bool {
+	na, nb := versionNumbers(a.Version.Version), versionNumbers(b.Version.Version)
+	for i := range na {
+		if na[i] != nb[i] {
+			return na[i] < nb[i]
+		}
+	}
+	aStable, bStable := a.Channel == "stable", b.Channel == "stable"
+	if aStable != bStable {
+		return bStable
+	}
+	return a.CreatedAt.Before(b.CreatedAt)
+}
+
+func versionNumbers(version string) [3]int {
+	var numbers [3]int
+	if i := strings.IndexByte(version, '-'); i >= 0 {
+		version = version[:i]
+	}
+	for i, part := range strings.SplitN(version, ".", 3) {
+		numbers[i], _ = strconv.Atoi(part)
+	}
+	return numbers
+}
+
+// channelAccepts tells if a version of the given channel can be used as the
+// latest version of the requested channel: the dev channel includes all the
+// versions, and the beta channel includes the stable versions.
+func channelAccepts(requested, channel string) bool {
+	switch requested {
+	case "dev":
+		return true
+	case "beta":
+		return channel == "beta" || channel == "stable"
+	default:
+		return channel == "stable"
+	}
+}
+
+// Publish adds a version of an application to the local registry. The
+// tarball is stored, and the manifest is extracted from it for the slug and
+// version number. The signature, if not empty, is the base64-encoded ed25519
+// signature of the tarball by the publisher.
+func Publish(tarball io.Reader, signature string) (*PublishedVersion, error) {
+	tmp, err := ioutil.TempFile("", "cozy-registry-")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		tmp.Close()
+		os.Remove(tmp.Name())
+	}()
+
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(tmp, h), io.LimitReader(tarball, maxTarballSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if n > maxTarballSize {
+		return nil, ErrTarballTooLarge
+	}
+	if _, err = tmp.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	v, err := inspectTarball(tmp)
+	if err != nil {
+		return nil, err
+	}
+	v.Sha256 = hex.EncodeToString(h.Sum(nil))
+	v.Signature = signature
+	v.CreatedAt = time.Now().UTC()
+
+	var existing PublishedVersion
+	err = couchdb.GetDoc(couchdb.GlobalDB, consts.RegistryVersions, v.DocID, &existing)
+	if err == nil {
+		return nil, ErrVersionExists
+	}
+	if !couchdb.IsNotFoundError(err) && !couchdb.IsNoDatabaseError(err) {
+		return nil, err
+	}
+
+	if _, err = tmp.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	if err = saveTarball(v.Slug, v.Version.Version, tmp); err != nil {
+		return nil, err
+	}
+	if err = couchdb.CreateNamedDocWithDB(couchdb.GlobalDB, v); err != nil {
+		_ = tarballStore().Remove(tarballName(v.Slug, v.Version.Version))
+		return nil, err
+	}
+	return v, nil
+}
+
+// inspectTarball reads the tarball to find the manifest, and returns the
+// version described by this manifest.
+func inspectTarball(r io.Reader) (*PublishedVersion, error) {
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, ErrInvalidTarball
+	}
+	defer gr.Close()
+
+	var manifest []byte
+	var manifestName, prefix string
+	var size int64
+	tr := tar.NewReader(gr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, ErrInvalidTarball
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		size += hdr.Size
+		name := strings.TrimPrefix(hdr.Name, "./")
+		base := path.Base(name)
+		if base != webappManifestName && base != konnectorManifestName {
+			continue
+		}
+		dir := path.Dir(name)
+		if strings.Contains(dir, "/") || (manifest != nil && dir != ".") {
+			continue
+		}
+		manifest, err = ioutil.ReadAll(io.LimitReader(tr, 1<<20))
+		if err != nil {
+			return nil, ErrInvalidTarball
+		}
+		manifestName = base
+		prefix = ""
+		if dir != "." {
+			// Same format as the prefix computed by the http fetcher
+			prefix = path.Dir(hdr.Name) + "/"
+		}
+	}
+	if manifest == nil {
+		return nil, ErrInvalidTarball
+	}
+
+	var man struct {
+		Slug    string `json:"slug"`
+		Version string `json:"version"`
+		Editor  string `json:"editor"`
+	}
+	if err := json.Unmarshal(manifest, &man); err != nil {
+		return nil, ErrInvalidTarball
+	}
+	if !slugReg.MatchString(man.Slug) {
+		return nil, ErrInvalidSlug
+	}
+	channel := VersionChannel(man.Version)
+	if channel == "" {
+		return nil, ErrInvalidVersion
+	}
+
+	appType := "webapp"
+	if manifestName == konnectorManifestName {
+		appType = "konnector"
+	}
+	v := &PublishedVersion{
+		DocID:   versionDocID(man.Slug, man.Version),
+		Type:    appType,
+		Channel: channel,
+		Editor:  man.Editor,
+	}
+	v.Slug = man.Slug
+	v.Version.Version = man.Version
+	v.URL = serverURL() + "/registry/" + man.Slug + "/" + man.Version + "/tarball"
+	v.Size = strconv.FormatInt(size, 10)
+	v.Manifest = manifest
+	v.TarPrefix = prefix
+	return v, nil
+}
+
+// serverURL returns the URL used by the stacks to reach the local registry.
+func serverURL() string {
+	if u := config.GetConfig().RegistryServer.URL; u != nil {
+		return strings.TrimSuffix(u.String(), "/")
+	}
+	return "http://" + config.AdminServerAddr()
+}
+
+// PublishedSigningKeys returns the endorsed public keys of the publishers,
+// from the configuration of the registry server.
+func PublishedSigningKeys() []SigningKey {
+	configured := config.GetConfig().RegistryServer.SigningKeys
+	keys := make([]SigningKey, len(configured))
+	for i, k := range configured {
+		keys[i] = SigningKey{Key: k.Key, Signature: k.Signature}
+	}
+	return keys
+}
+
+// GetPublishedVersion returns a version of an application published on the
+// local registry.
+func GetPublishedVersion(slug, version string) (*PublishedVersion, error) {
+	var v PublishedVersion
+	err := couchdb.GetDoc(couchdb.GlobalDB, consts.RegistryVersions, versionDocID(slug, version), &v)
+	if couchdb.IsNotFoundError(err) || couchdb.IsNoDatabaseError(err) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// listPublishedVersions returns the versions of an application (or all the
+// versions if the slug is empty), sorted by slug.
+func listPublishedVersions(slug string) ([]*PublishedVersion, error) {
+	var versions []*PublishedVersion
+	req := &couchdb.AllDocsRequest{}
+	if slug != "" {
+		req.StartKey = slug + "@"
+		req.EndKey = slug + "@￿"
+	}
+	err := couchdb.GetAllDocs(couchdb.GlobalDB, consts.RegistryVersions, req, &versions)
+	if err != nil && !couchdb.IsNoDatabaseError(err) {
+		return nil, err
+	}
+	return versions, nil
+}
+
+// LatestPublishedVersion returns the latest version of an application for
+// the given channel.
+func LatestPublishedVersion(slug, channel string) (*PublishedVersion, error) {
+	versions, err := listPublishedVersions(slug)
+	if err != nil {
+		return nil, err
+	}
+	latest := latestVersion(versions, channel)
+	if latest == nil {
+		return nil, ErrNotFound
+	}
+	return latest, nil
+}
+
+func latestVersion(versions []*PublishedVersion, channel string) *PublishedVersion {
+	var latest *PublishedVersion
+	for _, v := range versions {
+		if !channelAccepts(channel, v.Channel) {
+			continue
+		}
+		if latest == nil || versionLess(latest, v) {
+			latest = v
+		}
+	}
+	return latest
+}
+
+func makePublishedApp(versions []*PublishedVersion) *PublishedApp {
+	sort.Slice(versions, func(i, j int) bool {
+		return versionLess(versions[i], versions[j])
+	})
+	app := &PublishedApp{Versions: make(map[string][]string)}
+	for _, c := range channelSet {
+		app.Versions[c] = []string{}
+	}
+	for _, v := range versions {
+		app.Versions[v.Channel] = append(app.Versions[v.Channel], v.Version.Version)
+	}
+	latest := latestVersion(versions, "stable")
+	if latest == nil {
+		latest = latestVersion(versions, "dev")
+	}
+	app.Slug = latest.Slug
+	app.Type = latest.Type
+	app.Editor = latest.Editor
+	app.LatestVersion = latest.Public()
+	return app
+}
+
+// GetPublishedApp returns an application published on the local registry.
+func GetPublishedApp(slug string) (*PublishedApp, error) {
+	versions, err := listPublishedVersions(slug)
+	if err != nil {
+		return nil, err
+	}
+	if len(versions) == 0 {
+		return nil, ErrNotFound
+	}
+	return makePublishedApp(versions), nil
+}
+
+// ListPublishedApps returns the applications published on the local
+// registry, with pagination.
+func ListPublishedApps(cursor, limit int) (*AppsPaginated, error) {
+	versions, err := listPublishedVersions("")
+	if err != nil {
+		return nil, err
+	}
+	bySlug := make(map[string][]*PublishedVersion)
+	var slugs []string
+	for _, v := range versions {
+		if _, ok := bySlug[v.Slug]; !ok {
+			slugs = append(slugs, v.Slug)
+		}
+		bySlug[v.Slug] = append(bySlug[v.Slug], v)
+	}
+	sort.Strings(slugs)
+
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+	if cursor < 0 {
+		cursor = 0
+	}
+	list := make([]map[string]interface{}, 0, limit)
+	for i := cursor; i < len(slugs) && len(list) < limit; i++ {
+		app := makePublishedApp(bySlug[slugs[i]])
+		// The apps are sent as maps, like the apps proxied from the
+		// official registries
+		raw, err := json.Marshal(app)
+		if err != nil {
+			return nil, err
+		}
+		var obj map[string]interface{}
+		if err := json.Unmarshal(raw, &obj); err != nil {
+			return nil, err
+		}
+		list = append(list, obj)
+	}
+	page := &AppsPaginated{Apps: list, PageInfo: PageInfo{Count: len(list)}}
+	if next := cursor + len(list); next < len(slugs) {
+		page.PageInfo.NextCursor = strconv.Itoa(next)
+	}
+	return page, nil
+}
+
+// OpenTarball returns the tarball of a version published on the local
+// registry.
+func OpenTarball(slug, version string) (io.ReadCloser, error) {
+	if _, err := GetPublishedVersion(slug, version); err != nil {
+		return nil, err
+	}
+	return tarballStore().Open(tarballName(slug, version))
+}
+
+// OpenTarballFile returns a file from the tarball of a version published on
+// the local registry, like the icon of the application.
+func OpenTarballFile(slug, version, name string) (io.ReadCloser, error) {
+	v, err := GetPublishedVersion(slug, version)
+	if err != nil {
+		return nil, err
+	}
+	f, err := tarballStore().Open(tarballName(slug, version))
+	if err != nil {
+		return nil, err
+	}
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	name = strings.TrimPrefix(v.TarPrefix, "./") + path.Clean("/" + name)[1:]
+	tr := tar.NewReader(gr)
+	for {
+		hdr, err := tr.Next()
+		if err != nil {
+			f.Close()
+			if err == io.EOF {
+				return nil, ErrNotFound
+			}
+			return nil, err
+		}
+		if hdr.Typeflag == tar.TypeReg && strings.TrimPrefix(hdr.Name, "./") == name {
+			return struct {
+				io.Reader
+				io.Closer
+			}{tr, f}, nil
+		}
+	}
+}
+
+func saveTarball(slug, version string, r io.Reader) error {
+	w, err := tarballStore().Create(tarballName(slug, version))
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(w, r); err != nil {
+		_ = w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+func tarballName(slug, version string) string {
+	return slug + "/" + version + ".tar.gz"
+}
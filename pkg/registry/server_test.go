@@ -0,0 +1,85 @@
+package registry
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"testing"
+	"time"
+
+	"github.com/cozy/cozy-stack/pkg/config/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVersionChannel(t *testing.T) {
+	assert.Equal(t, "stable", VersionChannel("1.2.3"))
+	assert.Equal(t, "beta", VersionChannel("1.2.3-beta.4"))
+	assert.Equal(t, "dev", VersionChannel("1.2.3-dev.7a8354f74b50d7beead7719252a18ed45f55d070"))
+	assert.Equal(t, "", VersionChannel("1.2"))
+	assert.Equal(t, "", VersionChannel("1.2.3-alpha.1"))
+	assert.Equal(t, "", VersionChannel("../1.2.3"))
+}
+
+func TestLatestVersion(t *testing.T) {
+	now := time.Now()
+	newVersion := func(version string, createdAt time.Time) *PublishedVersion {
+		v := &PublishedVersion{Channel: VersionChannel(version)}
+		v.Version.Version = version
+		v.CreatedAt = createdAt
+		return v
+	}
+	versions := []*PublishedVersion{
+		newVersion("1.0.0", now.Add(-5*time.Hour)),
+		newVersion("1.1.0-beta.1", now.Add(-4*time.Hour)),
+		newVersion("1.1.0-dev.abc", now.Add(-3*time.Hour)),
+		newVersion("1.0.10", now.Add(-2*time.Hour)),
+		newVersion("1.1.0-beta.2", now.Add(-1*time.Hour)),
+	}
+	assert.Equal(t, "1.0.10", latestVersion(versions, "stable").Version.Version)
+	assert.Equal(t, "1.1.0-beta.2", latestVersion(versions, "beta").Version.Version)
+	assert.Equal(t, "1.1.0-beta.2", latestVersion(versions, "dev").Version.Version)
+
+	versions = append(versions, newVersion("1.1.0", now))
+	assert.Equal(t, "1.1.0", latestVersion(versions, "stable").Version.Version)
+	assert.Equal(t, "1.1.0", latestVersion(versions, "beta").Version.Version)
+	assert.Equal(t, "1.1.0", latestVersion(versions, "dev").Version.Version)
+}
+
+func TestInspectTarball(t *testing.T) {
+	config.UseTestFile()
+
+	buf := new(bytes.Buffer)
+	gw := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gw)
+	files := map[string]string{
+		"drive/manifest.webapp": `{"slug": "drive", "version": "1.2.3", "editor": "Cozy"}`,
+		"drive/index.html":      `<html></html>`,
+	}
+	for name, content := range files {
+		assert.NoError(t, tw.WriteHeader(&tar.Header{
+			Name:     name,
+			Typeflag: tar.TypeReg,
+			Mode:     0644,
+			Size:     int64(len(content)),
+		}))
+		_, err := tw.Write([]byte(content))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, tw.Close())
+	assert.NoError(t, gw.Close())
+
+	v, err := inspectTarball(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, "drive@1.2.3", v.ID())
+	assert.Equal(t, "drive", v.Slug)
+	assert.Equal(t, "1.2.3", v.Version.Version)
+	assert.Equal(t, "webapp", v.Type)
+	assert.Equal(t, "stable", v.Channel)
+	assert.Equal(t, "Cozy", v.Editor)
+	assert.Equal(t, "drive/", v.TarPrefix)
+	assert.Equal(t, "68", v.Size)
+	assert.Contains(t, v.URL, "/registry/drive/1.2.3/tarball")
+
+	_, err = inspectTarball(bytes.NewReader([]byte("not a tarball")))
+	assert.Equal(t, ErrInvalidTarball, err)
+}
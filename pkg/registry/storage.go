@@ -0,0 +1,103 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+
+	"github.com/cozy/cozy-stack/pkg/config/config"
+	"github.com/ncw/swift/v2"
+	"github.com/spf13/afero"
+)
+
+// storage is an abstraction for storing the tarballs of the local registry.
+type storage interface {
+	Open(name string) (io.ReadCloser, error)
+	Create(name string) (io.WriteCloser, error)
+	Remove(name string) error
+}
+
+// tarballStore returns the storage for the tarballs, corresponding to the
+// configuration of the registry server.
+func tarballStore() storage {
+	if u := config.GetConfig().RegistryServer.Storage; u != nil {
+		return aferoStorage{afero.NewBasePathFs(afero.NewOsFs(), u.Path)}
+	}
+	fsURL := config.FsURL()
+	switch fsURL.Scheme {
+	case config.SchemeFile, config.SchemeMem:
+		fs := afero.NewBasePathFs(afero.NewOsFs(), path.Join(fsURL.Path, "registry"))
+		return aferoStorage{fs}
+	case config.SchemeSwift, config.SchemeSwiftSecure:
+		return &swiftStorage{
+			c:         config.GetSwiftConnection(),
+			container: "registry",
+			ctx:       context.Background(),
+		}
+	default:
+		panic(fmt.Errorf("registry: unknown storage provider %s", fsURL.Scheme))
+	}
+}
+
+type aferoStorage struct {
+	fs afero.Fs
+}
+
+func (s aferoStorage) Open(name string) (io.ReadCloser, error) {
+	f, err := s.fs.Open(name)
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	return f, err
+}
+
+func (s aferoStorage) Create(name string) (io.WriteCloser, error) {
+	if err := s.fs.MkdirAll(path.Dir(path.Join("/", name)), 0700); err != nil {
+		return nil, err
+	}
+	return s.fs.OpenFile(name, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+}
+
+func (s aferoStorage) Remove(name string) error {
+	return s.fs.Remove(name)
+}
+
+type swiftStorage struct {
+	c         *swift.Connection
+	container string
+	ctx       context.Context
+}
+
+func (s *swiftStorage) init() error {
+	if _, _, err := s.c.Container(s.ctx, s.container); err == swift.ContainerNotFound {
+		if err = s.c.ContainerCreate(s.ctx, s.container, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *swiftStorage) Open(name string) (io.ReadCloser, error) {
+	f, _, err := s.c.ObjectOpen(s.ctx, s.container, name, false, nil)
+	if err == swift.ObjectNotFound {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (s *swiftStorage) Create(name string) (io.WriteCloser, error) {
+	if err := s.init(); err != nil {
+		return nil, err
+	}
+	return s.c.ObjectCreate(s.ctx, s.container, name, true, "",
+		"application/gzip", nil)
+}
+
+func (s *swiftStorage) Remove(name string) error {
+	return s.c.ObjectDelete(s.ctx, s.container, name)
+}
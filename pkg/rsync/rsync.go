@@ -0,0 +1,336 @@
+// Package rsync implements the rsync algorithm for transferring only the
+// changed parts of a file: the receiver computes the signatures of the blocks
+// of its current version, the sender uses them to compute a delta with the new
+// version, and the receiver applies this delta on its current version to
+// rebuild the new one.
+//
+// https://rsync.samba.org/tech_report/
+package rsync
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/md5"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"io"
+)
+
+const (
+	// MinBlockSize is the minimal size of a block
+	MinBlockSize = 2 << 10 // 2 KiB
+	// MaxBlockSize is the maximal size of a block
+	MaxBlockSize = 1 << 20 // 1 MiB
+	// targetBlocks is the number of blocks that we try to have for a file
+	targetBlocks = 10000
+	// maxLiteral is the maximal size of the data for a single operation
+	maxLiteral = 64 << 10 // 64 KiB
+
+	magic   = "CZD1"
+	opBlock = 'B'
+	opData  = 'D'
+	mod     = 1 << 16
+)
+
+var (
+	// ErrInvalidDelta is used when the delta cannot be parsed
+	ErrInvalidDelta = errors.New("rsync: invalid delta")
+	// ErrInvalidBlockSize is used when the block size is not acceptable
+	ErrInvalidBlockSize = errors.New("rsync: invalid block size")
+)
+
+// BlockSignature is the signature of a block: a weak rolling checksum, and a
+// strong hash (md5) to confirm the match.
+type BlockSignature struct {
+	Weak   uint32 `json:"weak"`
+	Strong string `json:"strong"`
+}
+
+// Signature is the list of the block signatures for a file.
+type Signature struct {
+	BlockSize int              `json:"block_size"`
+	Blocks    []BlockSignature `json:"blocks"`
+}
+
+// BlockSizeFor returns the block size to use for a file of the given size.
+func BlockSizeFor(size int64) int {
+	bs := MinBlockSize
+	for bs < MaxBlockSize && int64(bs)*targetBlocks < size {
+		bs <<= 1
+	}
+	return bs
+}
+
+// ComputeSignature reads the content and returns the signatures of its
+// blocks.
+func ComputeSignature(r io.Reader, blockSize int) (*Signature, error) {
+	if blockSize < MinBlockSize || blockSize > MaxBlockSize {
+		return nil, ErrInvalidBlockSize
+	}
+	sig := &Signature{BlockSize: blockSize, Blocks: []BlockSignature{}}
+	buf := make([]byte, blockSize)
+	for {
+		n, err := io.ReadFull(r, buf)
+		if n > 0 {
+			sig.Blocks = append(sig.Blocks, BlockSignature{
+				Weak:   weakChecksum(buf[:n]),
+				Strong: strongHash(buf[:n]),
+			})
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return sig, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// ComputeDelta reads the new version of a file and writes to w the delta
+// between the version described by the signature and this new version.
+func ComputeDelta(sig *Signature, r io.Reader, w io.Writer) error {
+	if sig.BlockSize < MinBlockSize || sig.BlockSize > MaxBlockSize {
+		return ErrInvalidBlockSize
+	}
+	enc := &encoder{w: bufio.NewWriter(w)}
+	if err := enc.header(sig.BlockSize); err != nil {
+		return err
+	}
+
+	lookup := make(map[uint32][]int, len(sig.Blocks))
+	for i, b := range sig.Blocks {
+		lookup[b.Weak] = append(lookup[b.Weak], i)
+	}
+	match := func(weak uint32, window []byte) (int, bool) {
+		candidates, ok := lookup[weak]
+		if !ok {
+			return 0, false
+		}
+		strong := strongHash(window)
+		for _, i := range candidates {
+			if sig.Blocks[i].Strong == strong {
+				return i, true
+			}
+		}
+		return 0, false
+	}
+
+	br := bufio.NewReaderSize(r, 4*sig.BlockSize)
+	bs := sig.BlockSize
+	// buf[start:] is the current window, and it is compacted when start
+	// becomes too large.
+	buf := make([]byte, 0, 2*bs)
+	start := 0
+	eof := false
+	fill := func() error {
+		for !eof && len(buf)-start < bs {
+			c, err := br.ReadByte()
+			if err == io.EOF {
+				eof = true
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			buf = append(buf, c)
+		}
+		return nil
+	}
+
+	if err := fill(); err != nil {
+		return err
+	}
+	a, b := checksumParts(buf[start:])
+	for len(buf)-start > 0 {
+		window := buf[start:]
+		if len(window) == bs || eof {
+			if idx, ok := match(a|b<<16, window); ok {
+				if err := enc.block(idx); err != nil {
+					return err
+				}
+				buf = buf[:0]
+				start = 0
+				if err := fill(); err != nil {
+					return err
+				}
+				a, b = checksumParts(buf)
+				continue
+			}
+		}
+		if eof {
+			// There is no more data to roll the checksum, the rest of the
+			// window is sent as literal data.
+			if err := enc.data(window); err != nil {
+				return err
+			}
+			break
+		}
+
+		// Roll the window by one byte
+		out := window[0]
+		if err := enc.data([]byte{out}); err != nil {
+			return err
+		}
+		start++
+		if start >= bs {
+			n := copy(buf, buf[start:])
+			buf = buf[:n]
+			start = 0
+		}
+		if err := fill(); err != nil {
+			return err
+		}
+		l := uint32(bs)
+		a = (a - uint32(out)) % mod
+		b = (b - l*uint32(out)) % mod
+		if !eof {
+			in := buf[len(buf)-1]
+			a = (a + uint32(in)) % mod
+			b = (b + a) % mod
+		} else {
+			a, b = checksumParts(buf[start:])
+		}
+	}
+	return enc.flush()
+}
+
+// ApplyDelta reads a delta, and writes to w the new version of the file
+// rebuilt from the base version and this delta.
+func ApplyDelta(base io.ReaderAt, delta io.Reader, w io.Writer) error {
+	br := bufio.NewReader(delta)
+	header := make([]byte, len(magic))
+	if _, err := io.ReadFull(br, header); err != nil || string(header) != magic {
+		return ErrInvalidDelta
+	}
+	size, err := binary.ReadUvarint(br)
+	if err != nil {
+		return ErrInvalidDelta
+	}
+	bs := int(size)
+	if bs < MinBlockSize || bs > MaxBlockSize {
+		return ErrInvalidBlockSize
+	}
+
+	buf := make([]byte, bs)
+	for {
+		op, err := br.ReadByte()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		switch op {
+		case opBlock:
+			idx, err := binary.ReadUvarint(br)
+			if err != nil {
+				return ErrInvalidDelta
+			}
+			n, err := base.ReadAt(buf, int64(idx)*int64(bs))
+			if n == 0 || (err != nil && err != io.EOF) {
+				return ErrInvalidDelta
+			}
+			if _, err = w.Write(buf[:n]); err != nil {
+				return err
+			}
+		case opData:
+			length, err := binary.ReadUvarint(br)
+			if err != nil || length > maxLiteral {
+				return ErrInvalidDelta
+			}
+			if _, err = io.CopyN(w, br, int64(length)); err != nil {
+				return ErrInvalidDelta
+			}
+		default:
+			return ErrInvalidDelta
+		}
+	}
+}
+
+type encoder struct {
+	w       *bufio.Writer
+	literal bytes.Buffer
+	tmp     [binary.MaxVarintLen64]byte
+}
+
+func (e *encoder) header(blockSize int) error {
+	if _, err := e.w.WriteString(magic); err != nil {
+		return err
+	}
+	return e.uvarint(uint64(blockSize))
+}
+
+func (e *encoder) uvarint(x uint64) error {
+	n := binary.PutUvarint(e.tmp[:], x)
+	_, err := e.w.Write(e.tmp[:n])
+	return err
+}
+
+func (e *encoder) block(idx int) error {
+	if err := e.flushLiteral(); err != nil {
+		return err
+	}
+	if err := e.w.WriteByte(opBlock); err != nil {
+		return err
+	}
+	return e.uvarint(uint64(idx))
+}
+
+func (e *encoder) data(data []byte) error {
+	for len(data) > 0 {
+		n := maxLiteral - e.literal.Len()
+		if n > len(data) {
+			n = len(data)
+		}
+		e.literal.Write(data[:n])
+		data = data[n:]
+		if e.literal.Len() >= maxLiteral {
+			if err := e.flushLiteral(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (e *encoder) flushLiteral() error {
+	if e.literal.Len() == 0 {
+		return nil
+	}
+	if err := e.w.WriteByte(opData); err != nil {
+		return err
+	}
+	if err := e.uvarint(uint64(e.literal.Len())); err != nil {
+		return err
+	}
+	_, err := e.literal.WriteTo(e.w)
+	return err
+}
+
+func (e *encoder) flush() error {
+	if err := e.flushLiteral(); err != nil {
+		return err
+	}
+	return e.w.Flush()
+}
+
+func checksumParts(data []byte) (uint32, uint32) {
+	var a, b uint32
+	l := uint32(len(data))
+	for i, c := range data {
+		a += uint32(c)
+		b += (l - uint32(i)) * uint32(c)
+	}
+	return a % mod, b % mod
+}
+
+func weakChecksum(data []byte) uint32 {
+	a, b := checksumParts(data)
+	return a | b<<16
+}
+
+func strongHash(data []byte) string {
+	sum := md5.Sum(data)
+	return hex.EncodeToString(sum[:])
+}
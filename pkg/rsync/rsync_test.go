@@ -0,0 +1,94 @@
+package rsync
+
+import (
+	"bufio"
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func randomBytes(n int) []byte {
+	buf := make([]byte, n)
+	rand.Read(buf)
+	return buf
+}
+
+func roundTrip(t *testing.T, base, target []byte, blockSize int) int {
+	sig, err := ComputeSignature(bytes.NewReader(base), blockSize)
+	assert.NoError(t, err)
+	var delta bytes.Buffer
+	err = ComputeDelta(sig, bytes.NewReader(target), &delta)
+	assert.NoError(t, err)
+	size := delta.Len()
+	var out bytes.Buffer
+	err = ApplyDelta(bytes.NewReader(base), &delta, &out)
+	assert.NoError(t, err)
+	assert.True(t, bytes.Equal(target, out.Bytes()))
+	return size
+}
+
+func TestBlockSizeFor(t *testing.T) {
+	assert.Equal(t, MinBlockSize, BlockSizeFor(0))
+	assert.Equal(t, MinBlockSize, BlockSizeFor(1000))
+	assert.Equal(t, 16<<10, BlockSizeFor(100<<20))
+	assert.Equal(t, MaxBlockSize, BlockSizeFor(1<<40))
+}
+
+func TestComputeSignature(t *testing.T) {
+	data := randomBytes(5000)
+	sig, err := ComputeSignature(bytes.NewReader(data), MinBlockSize)
+	assert.NoError(t, err)
+	assert.Equal(t, MinBlockSize, sig.BlockSize)
+	assert.Len(t, sig.Blocks, 3)
+	assert.Equal(t, weakChecksum(data[4096:]), sig.Blocks[2].Weak)
+
+	_, err = ComputeSignature(bytes.NewReader(data), 10)
+	assert.Equal(t, ErrInvalidBlockSize, err)
+}
+
+func TestSameContent(t *testing.T) {
+	data := randomBytes(100000)
+	delta := roundTrip(t, data, data, MinBlockSize)
+	assert.Less(t, delta, 200)
+}
+
+func TestSmallEdits(t *testing.T) {
+	base := randomBytes(200000)
+	target := make([]byte, 0, len(base)+100)
+	target = append(target, base[:50000]...)
+	target = append(target, []byte("some inserted text")...)
+	target = append(target, base[50000:120000]...)
+	target = append(target, base[120100:]...)
+	delta := roundTrip(t, base, target, MinBlockSize)
+	assert.Less(t, delta, 3*MinBlockSize)
+}
+
+func TestEmptyFiles(t *testing.T) {
+	data := randomBytes(10000)
+	roundTrip(t, []byte{}, data, MinBlockSize)
+	roundTrip(t, data, []byte{}, MinBlockSize)
+	roundTrip(t, []byte{}, []byte{}, MinBlockSize)
+}
+
+func TestDifferentContent(t *testing.T) {
+	base := randomBytes(30000)
+	target := randomBytes(70001)
+	delta := roundTrip(t, base, target, MinBlockSize)
+	assert.Greater(t, delta, len(target))
+}
+
+func TestInvalidDelta(t *testing.T) {
+	var out bytes.Buffer
+	err := ApplyDelta(bytes.NewReader(nil), bytes.NewReader([]byte("foo")), &out)
+	assert.Equal(t, ErrInvalidDelta, err)
+
+	var delta bytes.Buffer
+	enc := &encoder{w: bufio.NewWriter(&delta)}
+	assert.NoError(t, enc.header(MinBlockSize))
+	assert.NoError(t, enc.block(3))
+	assert.NoError(t, enc.flush())
+	err = ApplyDelta(bytes.NewReader(randomBytes(100)), &delta, &out)
+	assert.Equal(t, ErrInvalidDelta, err)
+}
@@ -0,0 +1,397 @@
+package wasi
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/go-interpreter/wagon/exec"
+)
+
+// The errno values of WASI
+const (
+	errnoSuccess     = 0
+	errnoAcces       = 2
+	errnoBadf        = 8
+	errnoExist       = 20
+	errnoFault       = 21
+	errnoInval       = 28
+	errnoIO          = 29
+	errnoIsdir       = 31
+	errnoLoop        = 32
+	errnoNametoolong = 37
+	errnoNoent       = 44
+	errnoNosys       = 52
+	errnoNotdir      = 54
+	errnoNotempty    = 55
+	errnoPerm        = 63
+	errnoSpipe       = 70
+)
+
+// The file types of WASI
+const (
+	filetypeCharacterDevice = 2
+	filetypeDirectory       = 3
+	filetypeRegularFile     = 4
+)
+
+const (
+	rightFdRead  = 1 << 1
+	rightFdWrite = 1 << 6
+	allRights    = 1<<29 - 1
+)
+
+// preopenFD is the file descriptor of the pre-opened directory, and the
+// files opened by the module start after it.
+const (
+	preopenFD   = 3
+	firstFreeFD = 4
+)
+
+// openFile is an entry of the table of the file descriptors.
+type openFile struct {
+	// For the standard input and outputs
+	reader io.Reader
+	writer io.Writer
+	// For the files and directories, the path on the host and the path seen
+	// by the module
+	file   *os.File
+	path   string
+	vpath  string
+	dir    bool
+	append bool
+}
+
+func (r *runtime) openStdio() {
+	stdin := r.cfg.Stdin
+	if stdin == nil {
+		stdin = strings.NewReader("")
+	}
+	stdout, stderr := r.cfg.Stdout, r.cfg.Stderr
+	if stdout == nil {
+		stdout = ioutil.Discard
+	}
+	if stderr == nil {
+		stderr = ioutil.Discard
+	}
+	r.files[0] = &openFile{reader: stdin}
+	r.files[1] = &openFile{writer: stdout}
+	r.files[2] = &openFile{writer: stderr}
+	if r.root != "" {
+		r.files[preopenFD] = &openFile{path: r.root, vpath: "/", dir: true}
+	}
+}
+
+func (r *runtime) closeFiles() {
+	for fd, f := range r.files {
+		if f.file != nil {
+			f.file.Close()
+		}
+		delete(r.files, fd)
+	}
+}
+
+// errno returns the WASI errno for an error of the file system.
+func errno(err error) int32 {
+	var e syscall.Errno
+	if !errors.As(err, &e) {
+		if os.IsNotExist(err) {
+			return errnoNoent
+		}
+		if os.IsExist(err) {
+			return errnoExist
+		}
+		if os.IsPermission(err) {
+			return errnoAcces
+		}
+		return errnoIO
+	}
+	switch e {
+	case syscall.EACCES:
+		return errnoAcces
+	case syscall.EBADF:
+		return errnoBadf
+	case syscall.EEXIST:
+		return errnoExist
+	case syscall.EINVAL:
+		return errnoInval
+	case syscall.EISDIR:
+		return errnoIsdir
+	case syscall.ELOOP:
+		return errnoLoop
+	case syscall.ENAMETOOLONG:
+		return errnoNametoolong
+	case syscall.ENOENT:
+		return errnoNoent
+	case syscall.ENOTDIR:
+		return errnoNotdir
+	case syscall.ENOTEMPTY:
+		return errnoNotempty
+	case syscall.EPERM:
+		return errnoPerm
+	}
+	return errnoIO
+}
+
+// within returns true if the file is the root directory or inside it.
+func within(root, file string) bool {
+	return file == root || strings.HasPrefix(file, root+string(filepath.Separator))
+}
+
+// resolvePath returns the path on the host for a path given by the module,
+// relative to a directory file descriptor. The module can't access the files
+// outside of the pre-opened directory, even with the symbolic links.
+func (r *runtime) resolvePath(proc *exec.Process, dirfd, ptr, length uint32) (*openFile, string, int32) {
+	dir, ok := r.files[dirfd]
+	if !ok || dir.path == "" {
+		return nil, "", errnoBadf
+	}
+	if !dir.dir {
+		return nil, "", errnoNotdir
+	}
+	raw, ok := readBytes(proc, ptr, length)
+	if !ok {
+		return nil, "", errnoFault
+	}
+	name := string(raw)
+	if strings.IndexByte(name, 0) >= 0 {
+		return nil, "", errnoInval
+	}
+	vpath := path.Join(dir.vpath, name)
+	if vpath == "/" {
+		return &openFile{path: r.root, vpath: vpath}, r.root, errnoSuccess
+	}
+	full := filepath.Join(r.root, filepath.FromSlash(vpath))
+
+	parent, err := filepath.EvalSymlinks(filepath.Dir(full))
+	if err != nil {
+		return nil, "", errno(err)
+	}
+	if !within(r.root, parent) {
+		return nil, "", errnoPerm
+	}
+	full = filepath.Join(parent, filepath.Base(full))
+	if info, err := os.Lstat(full); err == nil && info.Mode()&os.ModeSymlink != 0 {
+		target, err := filepath.EvalSymlinks(full)
+		if err != nil {
+			return nil, "", errno(err)
+		}
+		if !within(r.root, target) {
+			return nil, "", errnoPerm
+		}
+		full = target
+	}
+	return &openFile{path: full, vpath: vpath}, full, errnoSuccess
+}
+
+func filetype(info os.FileInfo) byte {
+	if info.IsDir() {
+		return filetypeDirectory
+	}
+	return filetypeRegularFile
+}
+
+// writeFilestat writes the filestat structure of WASI in the memory.
+func writeFilestat(proc *exec.Process, ptr uint32, info os.FileInfo) int32 {
+	buf := make([]byte, 64)
+	if info == nil {
+		buf[16] = filetypeCharacterDevice
+	} else {
+		buf[16] = filetype(info)
+		binary.LittleEndian.PutUint64(buf[24:], 1)
+		binary.LittleEndian.PutUint64(buf[32:], uint64(info.Size()))
+		mtime := uint64(info.ModTime().UnixNano())
+		binary.LittleEndian.PutUint64(buf[40:], mtime)
+		binary.LittleEndian.PutUint64(buf[48:], mtime)
+		binary.LittleEndian.PutUint64(buf[56:], mtime)
+	}
+	if !writeBytes(proc, ptr, buf) {
+		return errnoFault
+	}
+	return errnoSuccess
+}
+
+func (r *runtime) pathOpen(proc *exec.Process, dirfd, dirflags, pathPtr, pathLen, oflags uint32, rightsBase, rightsInheriting uint64, fdflags, fdPtr uint32) int32 {
+	f, full, e := r.resolvePath(proc, dirfd, pathPtr, pathLen)
+	if e != errnoSuccess {
+		return e
+	}
+
+	write := rightsBase&rightFdWrite != 0
+	flags := os.O_RDONLY
+	if write && rightsBase&rightFdRead != 0 {
+		flags = os.O_RDWR
+	} else if write {
+		flags = os.O_WRONLY
+	}
+	if oflags&1 != 0 {
+		flags |= os.O_CREATE
+	}
+	if oflags&4 != 0 {
+		flags |= os.O_EXCL
+	}
+	if oflags&8 != 0 {
+		flags |= os.O_TRUNC
+	}
+	if fdflags&1 != 0 {
+		flags |= os.O_APPEND
+		f.append = true
+	}
+	if info, err := os.Stat(full); err == nil && info.IsDir() {
+		flags = os.O_RDONLY
+		if oflags&(1|4|8) != 0 {
+			return errnoIsdir
+		}
+	}
+
+	file, err := os.OpenFile(full, flags, 0644)
+	if err != nil {
+		return errno(err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return errno(err)
+	}
+	if oflags&2 != 0 && !info.IsDir() {
+		file.Close()
+		return errnoNotdir
+	}
+	f.file = file
+	f.dir = info.IsDir()
+
+	fd := r.nextFD
+	if !writeUint32(proc, fdPtr, fd) {
+		file.Close()
+		return errnoFault
+	}
+	r.nextFD++
+	r.files[fd] = f
+	return errnoSuccess
+}
+
+func (r *runtime) pathFilestatGet(proc *exec.Process, dirfd, flags, pathPtr, pathLen, bufPtr uint32) int32 {
+	_, full, e := r.resolvePath(proc, dirfd, pathPtr, pathLen)
+	if e != errnoSuccess {
+		return e
+	}
+	info, err := os.Stat(full)
+	if err != nil {
+		return errno(err)
+	}
+	return writeFilestat(proc, bufPtr, info)
+}
+
+func (r *runtime) pathCreateDirectory(proc *exec.Process, dirfd, pathPtr, pathLen uint32) int32 {
+	_, full, e := r.resolvePath(proc, dirfd, pathPtr, pathLen)
+	if e != errnoSuccess {
+		return e
+	}
+	if err := os.Mkdir(full, 0755); err != nil {
+		return errno(err)
+	}
+	return errnoSuccess
+}
+
+func (r *runtime) pathRemoveDirectory(proc *exec.Process, dirfd, pathPtr, pathLen uint32) int32 {
+	_, full, e := r.resolvePath(proc, dirfd, pathPtr, pathLen)
+	if e != errnoSuccess {
+		return e
+	}
+	info, err := os.Lstat(full)
+	if err != nil {
+		return errno(err)
+	}
+	if !info.IsDir() {
+		return errnoNotdir
+	}
+	if full == r.root {
+		return errnoPerm
+	}
+	if err := os.Remove(full); err != nil {
+		return errno(err)
+	}
+	return errnoSuccess
+}
+
+func (r *runtime) pathUnlinkFile(proc *exec.Process, dirfd, pathPtr, pathLen uint32) int32 {
+	_, full, e := r.resolvePath(proc, dirfd, pathPtr, pathLen)
+	if e != errnoSuccess {
+		return e
+	}
+	info, err := os.Lstat(full)
+	if err != nil {
+		return errno(err)
+	}
+	if info.IsDir() {
+		return errnoIsdir
+	}
+	if err := os.Remove(full); err != nil {
+		return errno(err)
+	}
+	return errnoSuccess
+}
+
+func (r *runtime) pathRename(proc *exec.Process, oldfd, oldPtr, oldLen, newfd, newPtr, newLen uint32) int32 {
+	_, oldpath, e := r.resolvePath(proc, oldfd, oldPtr, oldLen)
+	if e != errnoSuccess {
+		return e
+	}
+	_, newpath, e := r.resolvePath(proc, newfd, newPtr, newLen)
+	if e != errnoSuccess {
+		return e
+	}
+	if oldpath == r.root || newpath == r.root {
+		return errnoPerm
+	}
+	if err := os.Rename(oldpath, newpath); err != nil {
+		return errno(err)
+	}
+	return errnoSuccess
+}
+
+// fdReaddir writes the entries of a directory in the buffer, starting at the
+// cookie (the index of the entry). If the buffer is full, the module calls it
+// again with the cookie of the next entry.
+func (r *runtime) fdReaddir(proc *exec.Process, fd, buf, bufLen uint32, cookie uint64, bufusedPtr uint32) int32 {
+	f, ok := r.files[fd]
+	if !ok {
+		return errnoBadf
+	}
+	if !f.dir {
+		return errnoNotdir
+	}
+	infos, err := ioutil.ReadDir(f.path)
+	if err != nil {
+		return errno(err)
+	}
+	names := []string{".", ".."}
+	types := []byte{filetypeDirectory, filetypeDirectory}
+	for _, info := range infos {
+		names = append(names, info.Name())
+		types = append(types, filetype(info))
+	}
+
+	var out []byte
+	for i := cookie; i < uint64(len(names)) && uint64(len(out)) < uint64(bufLen); i++ {
+		dirent := make([]byte, 24)
+		binary.LittleEndian.PutUint64(dirent[0:], i+1)
+		binary.LittleEndian.PutUint32(dirent[16:], uint32(len(names[i])))
+		dirent[20] = types[i]
+		out = append(out, dirent...)
+		out = append(out, names[i]...)
+	}
+	if uint64(len(out)) > uint64(bufLen) {
+		out = out[:bufLen]
+	}
+	if !writeBytes(proc, buf, out) || !writeUint32(proc, bufusedPtr, uint32(len(out))) {
+		return errnoFault
+	}
+	return errnoSuccess
+}
@@ -0,0 +1,144 @@
+package wasi
+
+import (
+	"encoding/binary"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/go-interpreter/wagon/exec"
+	"github.com/go-interpreter/wagon/wasm"
+	ops "github.com/go-interpreter/wagon/wasm/operators"
+)
+
+var processType = reflect.TypeOf(&exec.Process{})
+
+// hostFunction returns a function for the index space of a module, that
+// calls the given Go function. Its first parameter must be a *exec.Process,
+// and the other parameters and the results must be 32 or 64 bits integers.
+func hostFunction(fn interface{}) wasm.Function {
+	val := reflect.ValueOf(fn)
+	typ := val.Type()
+	if typ.NumIn() == 0 || typ.In(0) != processType {
+		panic(fmt.Sprintf("wasi: invalid host function %s", typ))
+	}
+	sig := &wasm.FunctionSig{Form: 0x60}
+	for i := 1; i < typ.NumIn(); i++ {
+		sig.ParamTypes = append(sig.ParamTypes, valueType(typ.In(i)))
+	}
+	for i := 0; i < typ.NumOut(); i++ {
+		sig.ReturnTypes = append(sig.ReturnTypes, valueType(typ.Out(i)))
+	}
+	return wasm.Function{Sig: sig, Host: val, Body: placeholderBody(sig)}
+}
+
+func valueType(typ reflect.Type) wasm.ValueType {
+	switch typ.Kind() {
+	case reflect.Int32, reflect.Uint32:
+		return wasm.ValueTypeI32
+	case reflect.Int64, reflect.Uint64:
+		return wasm.ValueTypeI64
+	}
+	panic(fmt.Sprintf("wasi: invalid type %s for a host function", typ))
+}
+
+// placeholderBody returns a body that is never executed, but that is valid
+// for the signature, as the host functions are also checked by the validation
+// of the module that imports them.
+func placeholderBody(sig *wasm.FunctionSig) *wasm.FunctionBody {
+	body := &wasm.FunctionBody{}
+	for _, typ := range sig.ReturnTypes {
+		if typ == wasm.ValueTypeI32 {
+			body.Code = append(body.Code, ops.I32Const, 0)
+		} else {
+			body.Code = append(body.Code, ops.I64Const, 0)
+		}
+	}
+	return body
+}
+
+// hostModule returns a module that exports the given host functions, to
+// resolve the imports of another module.
+func hostModule(funcs map[string]interface{}) *wasm.Module {
+	names := make([]string, 0, len(funcs))
+	for name := range funcs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	m := wasm.NewModule()
+	m.Export.Entries = make(map[string]wasm.ExportEntry, len(names))
+	for i, name := range names {
+		m.FunctionIndexSpace = append(m.FunctionIndexSpace, hostFunction(funcs[name]))
+		m.Export.Entries[name] = wasm.ExportEntry{
+			FieldStr: name,
+			Kind:     wasm.ExternalFunction,
+			Index:    uint32(i),
+		}
+	}
+	return m
+}
+
+// stringsModule returns a module with a host function for each string (see
+// Config.Strings).
+func stringsModule(strings map[string]string) *wasm.Module {
+	funcs := make(map[string]interface{}, len(strings))
+	for name, value := range strings {
+		value := value
+		funcs[name] = func(proc *exec.Process, buf, bufLen uint32) int32 {
+			n := uint32(len(value))
+			if n > bufLen {
+				n = bufLen
+			}
+			if !writeBytes(proc, buf, []byte(value[:n])) {
+				return -1
+			}
+			return int32(len(value))
+		}
+	}
+	return hostModule(funcs)
+}
+
+// inBounds returns true if the memory has size bytes at the given offset.
+func inBounds(proc *exec.Process, offset, size uint32) bool {
+	return uint64(offset)+uint64(size) <= uint64(proc.MemSize())
+}
+
+func readBytes(proc *exec.Process, offset, size uint32) ([]byte, bool) {
+	if !inBounds(proc, offset, size) {
+		return nil, false
+	}
+	buf := make([]byte, size)
+	if _, err := proc.ReadAt(buf, int64(offset)); err != nil {
+		return nil, false
+	}
+	return buf, true
+}
+
+func writeBytes(proc *exec.Process, offset uint32, data []byte) bool {
+	if !inBounds(proc, offset, uint32(len(data))) {
+		return false
+	}
+	_, err := proc.WriteAt(data, int64(offset))
+	return err == nil
+}
+
+func readUint32(proc *exec.Process, offset uint32) (uint32, bool) {
+	buf, ok := readBytes(proc, offset, 4)
+	if !ok {
+		return 0, false
+	}
+	return binary.LittleEndian.Uint32(buf), true
+}
+
+func writeUint32(proc *exec.Process, offset, value uint32) bool {
+	var buf [4]byte
+	binary.LittleEndian.PutUint32(buf[:], value)
+	return writeBytes(proc, offset, buf[:])
+}
+
+func writeUint64(proc *exec.Process, offset uint32, value uint64) bool {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], value)
+	return writeBytes(proc, offset, buf[:])
+}
@@ -0,0 +1,413 @@
+package wasi
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/go-interpreter/wagon/exec"
+	"github.com/go-interpreter/wagon/wasm"
+	ops "github.com/go-interpreter/wagon/wasm/operators"
+)
+
+// The interpreter can't be interrupted from the outside, so the code of the
+// module is rewritten with calls to some host functions, the hooks, that can
+// stop the execution:
+//   - on entry of a function (and on exit, to know the depth of the calls)
+//   - at the beginning of each iteration of a loop
+//   - before a memory.grow instruction.
+//
+// The interpreter only knows the WebAssembly 1.0 instructions, so the
+// instructions of the sign extension, non-trapping float-to-int conversion
+// and bulk memory (only memory.copy and memory.fill) proposals, that are used
+// by most compilers, are also rewritten to MVP instructions or calls to hooks.
+const (
+	hookEnter = iota
+	hookLeave
+	hookTick
+	hookGrow
+	hookMemoryCopy
+	hookMemoryFill
+	hookTruncSat // and the 7 next ones, in the order of the opcodes
+)
+
+// ticksBetweenChecks is the number of ticks between two checks of the context.
+const ticksBetweenChecks = 1024
+
+var errOutOfBounds = errors.New("wasi: trap: out of bounds memory access")
+
+// instrument adds the hooks to the function index space of the module (after
+// the other functions, so that their indexes are not changed), and rewrites
+// the code of the functions.
+func (r *runtime) instrument(m *wasm.Module) error {
+	base := uint32(len(m.FunctionIndexSpace))
+	for i := range m.FunctionIndexSpace {
+		fn := &m.FunctionIndexSpace[i]
+		if fn.IsHost() {
+			continue
+		}
+		code, err := rewrite(fn.Body.Code, fn.Sig.ReturnTypes, base)
+		if err != nil {
+			return fmt.Errorf("function %d: %w", i, err)
+		}
+		fn.Body.Code = code
+	}
+
+	hooks := []interface{}{
+		r.enter, r.leave, r.tick, r.grow, r.memoryCopy, r.memoryFill,
+		truncSatF32ToI32, truncSatF32ToU32, truncSatF64ToI32, truncSatF64ToU32,
+		truncSatF32ToI64, truncSatF32ToU64, truncSatF64ToI64, truncSatF64ToU64,
+	}
+	if m.Types == nil {
+		m.Types = &wasm.SectionTypes{}
+	}
+	if m.Function == nil {
+		m.Function = &wasm.SectionFunctions{}
+	}
+	for _, hook := range hooks {
+		fn := hostFunction(hook)
+		m.FunctionIndexSpace = append(m.FunctionIndexSpace, fn)
+		// The signatures are also needed by the compiler for the calls
+		m.Function.Types = append(m.Function.Types, uint32(len(m.Types.Entries)))
+		m.Types.Entries = append(m.Types.Entries, *fn.Sig)
+	}
+	return nil
+}
+
+// rewrite returns the code of a function with the calls to the hooks. The
+// body is wrapped in a block, so that the branches to the outermost label
+// also go through the call to the leave hook.
+func rewrite(code []byte, results []wasm.ValueType, base uint32) ([]byte, error) {
+	if len(results) > 1 {
+		return nil, errors.New("multi-value is not supported")
+	}
+	sig := byte(wasm.BlockTypeEmpty)
+	if len(results) == 1 {
+		sig = byte(results[0])
+	}
+	call := func(out []byte, hook uint32) []byte {
+		return appendULEB(append(out, ops.Call), uint64(base+hook))
+	}
+
+	out := make([]byte, 0, len(code)+len(code)/8+16)
+	out = call(out, hookEnter)
+	out = append(out, ops.Block, sig)
+
+	var err error
+	for pc := 0; pc < len(code); {
+		start := pc
+		op := code[pc]
+		pc++
+		switch {
+		case op == ops.Block || op == ops.Loop || op == ops.If:
+			if pc >= len(code) || !isBlockType(code[pc]) {
+				return nil, errors.New("unsupported block type")
+			}
+			pc++
+			out = append(out, code[start:pc]...)
+			if op == ops.Loop {
+				out = call(out, hookTick)
+			}
+
+		case op == ops.Br || op == ops.BrIf || op == ops.Call ||
+			(op >= ops.GetLocal && op <= ops.SetGlobal):
+			if pc, err = skipLEB(code, pc); err != nil {
+				return nil, err
+			}
+			out = append(out, code[start:pc]...)
+
+		case op == ops.BrTable:
+			var n uint64
+			if n, pc, err = readULEB(code, pc); err != nil {
+				return nil, err
+			}
+			for i := uint64(0); i <= n; i++ {
+				if pc, err = skipLEB(code, pc); err != nil {
+					return nil, err
+				}
+			}
+			out = append(out, code[start:pc]...)
+
+		case op == ops.CallIndirect:
+			if pc, err = skipLEB(code, pc); err != nil {
+				return nil, err
+			}
+			out = append(out, code[start:pc]...)
+			if pc, err = readZeroIndex(code, pc); err != nil {
+				return nil, err
+			}
+			out = append(out, 0)
+
+		case op >= ops.I32Load && op <= ops.I64Store32:
+			if pc, err = skipLEB(code, pc); err != nil {
+				return nil, err
+			}
+			if pc, err = skipLEB(code, pc); err != nil {
+				return nil, err
+			}
+			out = append(out, code[start:pc]...)
+
+		case op == ops.CurrentMemory || op == ops.GrowMemory:
+			if pc, err = readZeroIndex(code, pc); err != nil {
+				return nil, err
+			}
+			if op == ops.GrowMemory {
+				out = call(out, hookGrow)
+			}
+			out = append(out, op, 0)
+
+		case op == ops.I32Const || op == ops.I64Const:
+			if pc, err = skipLEB(code, pc); err != nil {
+				return nil, err
+			}
+			out = append(out, code[start:pc]...)
+
+		case op == ops.F32Const || op == ops.F64Const:
+			pc += 4
+			if op == ops.F64Const {
+				pc += 4
+			}
+			if pc > len(code) {
+				return nil, errors.New("unexpected end of the code")
+			}
+			out = append(out, code[start:pc]...)
+
+		case op == ops.Return:
+			out = call(out, hookLeave)
+			out = append(out, op)
+
+		case op == ops.Unreachable || op == ops.Nop || op == ops.Else || op == ops.End ||
+			op == ops.Drop || op == ops.Select ||
+			(op >= ops.I32Eqz && op <= ops.F64ReinterpretI64):
+			out = append(out, op)
+
+		case op >= 0xc0 && op <= 0xc4:
+			out = append(out, signExtension[op-0xc0]...)
+
+		case op == 0xfc:
+			var sub uint64
+			if sub, pc, err = readULEB(code, pc); err != nil {
+				return nil, err
+			}
+			switch {
+			case sub <= 7:
+				// The float is given to the hook as an integer with the same bits
+				if sub == 0 || sub == 1 || sub == 4 || sub == 5 {
+					out = append(out, ops.I32ReinterpretF32)
+				} else {
+					out = append(out, ops.I64ReinterpretF64)
+				}
+				out = call(out, hookTruncSat+uint32(sub))
+			case sub == 10:
+				if pc, err = readZeroIndex(code, pc); err != nil {
+					return nil, err
+				}
+				if pc, err = readZeroIndex(code, pc); err != nil {
+					return nil, err
+				}
+				out = call(out, hookMemoryCopy)
+			case sub == 11:
+				if pc, err = readZeroIndex(code, pc); err != nil {
+					return nil, err
+				}
+				out = call(out, hookMemoryFill)
+			default:
+				return nil, fmt.Errorf("unsupported instruction 0xfc %d", sub)
+			}
+
+		default:
+			return nil, fmt.Errorf("unsupported instruction 0x%02x", op)
+		}
+	}
+
+	out = append(out, ops.End)
+	out = call(out, hookLeave)
+	return out, nil
+}
+
+// signExtension are the MVP instructions for the sign extension operators
+// (0xc0 to 0xc4), with shifts.
+var signExtension = [][]byte{
+	{ops.I32Const, 24, ops.I32Shl, ops.I32Const, 24, ops.I32ShrS},
+	{ops.I32Const, 16, ops.I32Shl, ops.I32Const, 16, ops.I32ShrS},
+	{ops.I64Const, 56, ops.I64Shl, ops.I64Const, 56, ops.I64ShrS},
+	{ops.I64Const, 48, ops.I64Shl, ops.I64Const, 48, ops.I64ShrS},
+	{ops.I32WrapI64, ops.I64ExtendSI32},
+}
+
+func isBlockType(b byte) bool {
+	switch wasm.BlockType(b) {
+	case wasm.BlockTypeEmpty, wasm.BlockType(wasm.ValueTypeI32), wasm.BlockType(wasm.ValueTypeI64),
+		wasm.BlockType(wasm.ValueTypeF32), wasm.BlockType(wasm.ValueTypeF64):
+		return true
+	}
+	return false
+}
+
+func readULEB(code []byte, pc int) (uint64, int, error) {
+	var value uint64
+	for shift := uint(0); shift < 64; shift += 7 {
+		if pc >= len(code) {
+			break
+		}
+		b := code[pc]
+		pc++
+		value |= uint64(b&0x7f) << shift
+		if b&0x80 == 0 {
+			return value, pc, nil
+		}
+	}
+	return 0, pc, errors.New("invalid LEB128 integer")
+}
+
+func skipLEB(code []byte, pc int) (int, error) {
+	_, pc, err := readULEB(code, pc)
+	return pc, err
+}
+
+// readZeroIndex reads the index of a memory or table, that must be 0.
+func readZeroIndex(code []byte, pc int) (int, error) {
+	index, pc, err := readULEB(code, pc)
+	if err == nil && index != 0 {
+		err = errors.New("multiple memories or tables are not supported")
+	}
+	return pc, err
+}
+
+func appendULEB(out []byte, value uint64) []byte {
+	for {
+		b := byte(value & 0x7f)
+		value >>= 7
+		if value == 0 {
+			return append(out, b)
+		}
+		out = append(out, b|0x80)
+	}
+}
+
+func (r *runtime) enter(proc *exec.Process) {
+	r.depth++
+	if r.depth > maxCallDepth {
+		r.stop(proc, ErrCallStackExhausted)
+		return
+	}
+	r.tick(proc)
+}
+
+func (r *runtime) leave(proc *exec.Process) {
+	r.depth--
+}
+
+// tick stops the module when the context has been canceled. It is called
+// often, so the context is only checked from time to time.
+func (r *runtime) tick(proc *exec.Process) {
+	r.ticks++
+	if r.ticks%ticksBetweenChecks != 0 {
+		return
+	}
+	select {
+	case <-r.ctx.Done():
+		r.stop(proc, r.ctx.Err())
+	default:
+	}
+}
+
+// grow is called with the number of pages to add to the memory, and returns
+// it for the memory.grow instruction, or stops the module if the memory would
+// be over the limit.
+func (r *runtime) grow(proc *exec.Process, delta int32) int32 {
+	pages := proc.MemSize() / pageSize
+	if delta < 0 || pages+int(delta) > r.maxPages {
+		r.stop(proc, ErrMemoryLimit)
+	}
+	return delta
+}
+
+func (r *runtime) memoryCopy(proc *exec.Process, dst, src, n uint32) {
+	data, ok := readBytes(proc, src, n)
+	if !ok || !writeBytes(proc, dst, data) {
+		r.stop(proc, errOutOfBounds)
+	}
+}
+
+func (r *runtime) memoryFill(proc *exec.Process, dst, val, n uint32) {
+	if !inBounds(proc, dst, n) {
+		r.stop(proc, errOutOfBounds)
+		return
+	}
+	data := make([]byte, n)
+	for i := range data {
+		data[i] = byte(val)
+	}
+	writeBytes(proc, dst, data)
+}
+
+// truncSat is the saturating truncation of a float to an integer between min
+// and max (NaN gives 0).
+func truncSat(f, min, max float64) float64 {
+	switch {
+	case math.IsNaN(f):
+		return 0
+	case f <= min:
+		return min
+	case f >= max:
+		return max
+	}
+	return math.Trunc(f)
+}
+
+func truncSatF32ToI32(_ *exec.Process, bits uint32) int32 {
+	return int32(truncSat(float64(math.Float32frombits(bits)), math.MinInt32, math.MaxInt32))
+}
+
+func truncSatF32ToU32(_ *exec.Process, bits uint32) uint32 {
+	return uint32(truncSat(float64(math.Float32frombits(bits)), 0, math.MaxUint32))
+}
+
+func truncSatF64ToI32(_ *exec.Process, bits uint64) int32 {
+	return int32(truncSat(math.Float64frombits(bits), math.MinInt32, math.MaxInt32))
+}
+
+func truncSatF64ToU32(_ *exec.Process, bits uint64) uint32 {
+	return uint32(truncSat(math.Float64frombits(bits), 0, math.MaxUint32))
+}
+
+// The limits of the 64 bits integers can't be represented exactly as
+// float64, so they are checked before the conversion.
+func truncSatToI64(f float64) int64 {
+	switch {
+	case math.IsNaN(f):
+		return 0
+	case f >= math.MaxInt64:
+		return math.MaxInt64
+	case f <= math.MinInt64:
+		return math.MinInt64
+	}
+	return int64(f)
+}
+
+func truncSatToU64(f float64) uint64 {
+	switch {
+	case math.IsNaN(f), f <= 0:
+		return 0
+	case f >= math.MaxUint64:
+		return math.MaxUint64
+	}
+	return uint64(f)
+}
+
+func truncSatF32ToI64(_ *exec.Process, bits uint32) int64 {
+	return truncSatToI64(float64(math.Float32frombits(bits)))
+}
+
+func truncSatF32ToU64(_ *exec.Process, bits uint32) uint64 {
+	return truncSatToU64(float64(math.Float32frombits(bits)))
+}
+
+func truncSatF64ToI64(_ *exec.Process, bits uint64) int64 {
+	return truncSatToI64(math.Float64frombits(bits))
+}
+
+func truncSatF64ToU64(_ *exec.Process, bits uint64) uint64 {
+	return truncSatToU64(math.Float64frombits(bits))
+}
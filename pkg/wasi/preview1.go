@@ -0,0 +1,583 @@
+package wasi
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"io"
+	"os"
+	"time"
+
+	"github.com/go-interpreter/wagon/exec"
+	"github.com/go-interpreter/wagon/wasm"
+)
+
+// wasiModuleName is the name of the module for the WASI host functions.
+const wasiModuleName = "wasi_snapshot_preview1"
+
+// maxIOSize is the maximal number of bytes read or written by a single call.
+const maxIOSize = 1 << 20
+
+// wasiModule returns the module with the WASI host functions. The functions
+// for the sockets, the links, and the changes of rights or times are not
+// supported (they return ENOSYS).
+func (r *runtime) wasiModule() *wasm.Module {
+	return hostModule(map[string]interface{}{
+		"args_get":              r.argsGet,
+		"args_sizes_get":        r.argsSizesGet,
+		"environ_get":           r.environGet,
+		"environ_sizes_get":     r.environSizesGet,
+		"clock_res_get":         r.clockResGet,
+		"clock_time_get":        r.clockTimeGet,
+		"fd_close":              r.fdClose,
+		"fd_datasync":           r.fdSync,
+		"fd_fdstat_get":         r.fdFdstatGet,
+		"fd_filestat_get":       r.fdFilestatGet,
+		"fd_filestat_set_size":  r.fdFilestatSetSize,
+		"fd_pread":              r.fdPread,
+		"fd_prestat_dir_name":   r.fdPrestatDirName,
+		"fd_prestat_get":        r.fdPrestatGet,
+		"fd_pwrite":             r.fdPwrite,
+		"fd_read":               r.fdRead,
+		"fd_readdir":            r.fdReaddir,
+		"fd_seek":               r.fdSeek,
+		"fd_sync":               r.fdSync,
+		"fd_tell":               r.fdTell,
+		"fd_write":              r.fdWrite,
+		"path_create_directory": r.pathCreateDirectory,
+		"path_filestat_get":     r.pathFilestatGet,
+		"path_open":             r.pathOpen,
+		"path_remove_directory": r.pathRemoveDirectory,
+		"path_rename":           r.pathRename,
+		"path_unlink_file":      r.pathUnlinkFile,
+		"poll_oneoff":           r.pollOneoff,
+		"proc_exit":             r.procExit,
+		"random_get":            randomGet,
+		"sched_yield":           schedYield,
+
+		"fd_advise": func(_ *exec.Process, fd uint32, offset, length uint64, advice uint32) int32 {
+			return errnoNosys
+		},
+		"fd_allocate": func(_ *exec.Process, fd uint32, offset, length uint64) int32 {
+			return errnoNosys
+		},
+		"fd_fdstat_set_flags": func(_ *exec.Process, fd, flags uint32) int32 {
+			return errnoNosys
+		},
+		"fd_fdstat_set_rights": func(_ *exec.Process, fd uint32, base, inheriting uint64) int32 {
+			return errnoNosys
+		},
+		"fd_filestat_set_times": func(_ *exec.Process, fd uint32, atim, mtim uint64, flags uint32) int32 {
+			return errnoNosys
+		},
+		"fd_renumber": func(_ *exec.Process, fd, to uint32) int32 {
+			return errnoNosys
+		},
+		"path_filestat_set_times": func(_ *exec.Process, fd, flags, ptr, length uint32, atim, mtim uint64, fstflags uint32) int32 {
+			return errnoNosys
+		},
+		"path_link": func(_ *exec.Process, oldfd, oldflags, oldPtr, oldLen, newfd, newPtr, newLen uint32) int32 {
+			return errnoNosys
+		},
+		"path_readlink": func(_ *exec.Process, fd, ptr, length, buf, bufLen, bufusedPtr uint32) int32 {
+			return errnoNosys
+		},
+		"path_symlink": func(_ *exec.Process, oldPtr, oldLen, fd, newPtr, newLen uint32) int32 {
+			return errnoNosys
+		},
+		"proc_raise": func(_ *exec.Process, sig uint32) int32 {
+			return errnoNosys
+		},
+		"sock_accept": func(_ *exec.Process, fd, flags, fdPtr uint32) int32 {
+			return errnoNosys
+		},
+		"sock_recv": func(_ *exec.Process, fd, iovs, iovsLen, flags, nreadPtr, oflagsPtr uint32) int32 {
+			return errnoNosys
+		},
+		"sock_send": func(_ *exec.Process, fd, iovs, iovsLen, flags, nwrittenPtr uint32) int32 {
+			return errnoNosys
+		},
+		"sock_shutdown": func(_ *exec.Process, fd, how uint32) int32 {
+			return errnoNosys
+		},
+	})
+}
+
+// sizesGet writes the number of strings and the size of the buffer for them
+// (with a NUL byte after each string).
+func sizesGet(proc *exec.Process, list []string, countPtr, sizePtr uint32) int32 {
+	size := 0
+	for _, s := range list {
+		size += len(s) + 1
+	}
+	if !writeUint32(proc, countPtr, uint32(len(list))) || !writeUint32(proc, sizePtr, uint32(size)) {
+		return errnoFault
+	}
+	return errnoSuccess
+}
+
+// listGet writes the pointers to the strings, and the NUL-terminated strings
+// in the buffer.
+func listGet(proc *exec.Process, list []string, ptrs, buf uint32) int32 {
+	for i, s := range list {
+		if !writeUint32(proc, ptrs+uint32(4*i), buf) {
+			return errnoFault
+		}
+		if !writeBytes(proc, buf, append([]byte(s), 0)) {
+			return errnoFault
+		}
+		buf += uint32(len(s) + 1)
+	}
+	return errnoSuccess
+}
+
+func (r *runtime) argsSizesGet(proc *exec.Process, countPtr, sizePtr uint32) int32 {
+	return sizesGet(proc, r.cfg.Args, countPtr, sizePtr)
+}
+
+func (r *runtime) argsGet(proc *exec.Process, ptrs, buf uint32) int32 {
+	return listGet(proc, r.cfg.Args, ptrs, buf)
+}
+
+func (r *runtime) environSizesGet(proc *exec.Process, countPtr, sizePtr uint32) int32 {
+	return sizesGet(proc, r.cfg.Env, countPtr, sizePtr)
+}
+
+func (r *runtime) environGet(proc *exec.Process, ptrs, buf uint32) int32 {
+	return listGet(proc, r.cfg.Env, ptrs, buf)
+}
+
+// now returns the time for a clock: the realtime clock, or the time since the
+// start of the module for the monotonic and CPU time clocks.
+func (r *runtime) now(id uint32) (uint64, bool) {
+	switch id {
+	case 0:
+		return uint64(time.Now().UnixNano()), true
+	case 1, 2, 3:
+		return uint64(time.Since(r.started)), true
+	}
+	return 0, false
+}
+
+func (r *runtime) clockResGet(proc *exec.Process, id, resPtr uint32) int32 {
+	if _, ok := r.now(id); !ok {
+		return errnoInval
+	}
+	if !writeUint64(proc, resPtr, 1000) {
+		return errnoFault
+	}
+	return errnoSuccess
+}
+
+func (r *runtime) clockTimeGet(proc *exec.Process, id uint32, precision uint64, timePtr uint32) int32 {
+	now, ok := r.now(id)
+	if !ok {
+		return errnoInval
+	}
+	if !writeUint64(proc, timePtr, now) {
+		return errnoFault
+	}
+	return errnoSuccess
+}
+
+// iovecs reads the list of the buffers (pointer and length) for the fd_read
+// and fd_write functions.
+func iovecs(proc *exec.Process, iovs, iovsLen uint32) ([][2]uint32, bool) {
+	if iovsLen > 1024 {
+		return nil, false
+	}
+	raw, ok := readBytes(proc, iovs, 8*iovsLen)
+	if !ok {
+		return nil, false
+	}
+	vecs := make([][2]uint32, iovsLen)
+	total := uint32(0)
+	for i := range vecs {
+		vecs[i][0] = binary.LittleEndian.Uint32(raw[8*i:])
+		vecs[i][1] = binary.LittleEndian.Uint32(raw[8*i+4:])
+		if !inBounds(proc, vecs[i][0], vecs[i][1]) {
+			return nil, false
+		}
+		// The module will call the function again for the rest
+		if total+vecs[i][1] > maxIOSize {
+			vecs[i][1] = maxIOSize - total
+			return vecs[:i+1], true
+		}
+		total += vecs[i][1]
+	}
+	return vecs, true
+}
+
+// gather concatenates the buffers of the iovecs.
+func gather(proc *exec.Process, vecs [][2]uint32) []byte {
+	var data []byte
+	for _, vec := range vecs {
+		buf, _ := readBytes(proc, vec[0], vec[1])
+		data = append(data, buf...)
+	}
+	return data
+}
+
+// scatter copies the data in the buffers of the iovecs.
+func scatter(proc *exec.Process, vecs [][2]uint32, data []byte) {
+	for _, vec := range vecs {
+		if len(data) == 0 {
+			return
+		}
+		n := vec[1]
+		if int(n) > len(data) {
+			n = uint32(len(data))
+		}
+		writeBytes(proc, vec[0], data[:n])
+		data = data[n:]
+	}
+}
+
+func bufferSize(vecs [][2]uint32) int {
+	size := 0
+	for _, vec := range vecs {
+		size += int(vec[1])
+	}
+	return size
+}
+
+func (r *runtime) fdWrite(proc *exec.Process, fd, iovs, iovsLen, nwrittenPtr uint32) int32 {
+	f, ok := r.files[fd]
+	if !ok || f.dir || f.reader != nil {
+		return errnoBadf
+	}
+	vecs, ok := iovecs(proc, iovs, iovsLen)
+	if !ok {
+		return errnoFault
+	}
+	var n int
+	var err error
+	if f.writer != nil {
+		n, err = f.writer.Write(gather(proc, vecs))
+	} else {
+		n, err = f.file.Write(gather(proc, vecs))
+	}
+	if err != nil {
+		return errno(err)
+	}
+	if !writeUint32(proc, nwrittenPtr, uint32(n)) {
+		return errnoFault
+	}
+	return errnoSuccess
+}
+
+func (r *runtime) fdRead(proc *exec.Process, fd, iovs, iovsLen, nreadPtr uint32) int32 {
+	f, ok := r.files[fd]
+	if !ok || f.writer != nil {
+		return errnoBadf
+	}
+	if f.dir {
+		return errnoIsdir
+	}
+	vecs, ok := iovecs(proc, iovs, iovsLen)
+	if !ok {
+		return errnoFault
+	}
+	buf := make([]byte, bufferSize(vecs))
+	var n int
+	var err error
+	if f.reader != nil {
+		n, err = f.reader.Read(buf)
+	} else {
+		n, err = f.file.Read(buf)
+	}
+	if err != nil && err != io.EOF {
+		return errno(err)
+	}
+	scatter(proc, vecs, buf[:n])
+	if !writeUint32(proc, nreadPtr, uint32(n)) {
+		return errnoFault
+	}
+	return errnoSuccess
+}
+
+func (r *runtime) fdPread(proc *exec.Process, fd, iovs, iovsLen uint32, offset uint64, nreadPtr uint32) int32 {
+	f, ok := r.files[fd]
+	if !ok {
+		return errnoBadf
+	}
+	if f.file == nil {
+		return errnoSpipe
+	}
+	if f.dir {
+		return errnoIsdir
+	}
+	vecs, ok := iovecs(proc, iovs, iovsLen)
+	if !ok {
+		return errnoFault
+	}
+	buf := make([]byte, bufferSize(vecs))
+	n, err := f.file.ReadAt(buf, int64(offset))
+	if err != nil && err != io.EOF {
+		return errno(err)
+	}
+	scatter(proc, vecs, buf[:n])
+	if !writeUint32(proc, nreadPtr, uint32(n)) {
+		return errnoFault
+	}
+	return errnoSuccess
+}
+
+func (r *runtime) fdPwrite(proc *exec.Process, fd, iovs, iovsLen uint32, offset uint64, nwrittenPtr uint32) int32 {
+	f, ok := r.files[fd]
+	if !ok {
+		return errnoBadf
+	}
+	if f.file == nil {
+		return errnoSpipe
+	}
+	if f.dir {
+		return errnoIsdir
+	}
+	vecs, ok := iovecs(proc, iovs, iovsLen)
+	if !ok {
+		return errnoFault
+	}
+	n, err := f.file.WriteAt(gather(proc, vecs), int64(offset))
+	if err != nil {
+		return errno(err)
+	}
+	if !writeUint32(proc, nwrittenPtr, uint32(n)) {
+		return errnoFault
+	}
+	return errnoSuccess
+}
+
+func (r *runtime) fdClose(proc *exec.Process, fd uint32) int32 {
+	f, ok := r.files[fd]
+	if !ok {
+		return errnoBadf
+	}
+	delete(r.files, fd)
+	if f.file != nil {
+		if err := f.file.Close(); err != nil {
+			return errno(err)
+		}
+	}
+	return errnoSuccess
+}
+
+func (r *runtime) fdSync(proc *exec.Process, fd uint32) int32 {
+	f, ok := r.files[fd]
+	if !ok {
+		return errnoBadf
+	}
+	if f.file != nil && !f.dir {
+		if err := f.file.Sync(); err != nil {
+			return errno(err)
+		}
+	}
+	return errnoSuccess
+}
+
+func (r *runtime) fdSeek(proc *exec.Process, fd uint32, offset int64, whence, newOffsetPtr uint32) int32 {
+	f, ok := r.files[fd]
+	if !ok {
+		return errnoBadf
+	}
+	if f.file == nil {
+		return errnoSpipe
+	}
+	if f.dir {
+		return errnoIsdir
+	}
+	if whence > 2 {
+		return errnoInval
+	}
+	pos, err := f.file.Seek(offset, int(whence))
+	if err != nil {
+		return errno(err)
+	}
+	if !writeUint64(proc, newOffsetPtr, uint64(pos)) {
+		return errnoFault
+	}
+	return errnoSuccess
+}
+
+func (r *runtime) fdTell(proc *exec.Process, fd, offsetPtr uint32) int32 {
+	return r.fdSeek(proc, fd, 0, 1, offsetPtr)
+}
+
+func (r *runtime) fdFdstatGet(proc *exec.Process, fd, ptr uint32) int32 {
+	f, ok := r.files[fd]
+	if !ok {
+		return errnoBadf
+	}
+	buf := make([]byte, 24)
+	switch {
+	case f.dir:
+		buf[0] = filetypeDirectory
+	case f.file != nil:
+		buf[0] = filetypeRegularFile
+	default:
+		buf[0] = filetypeCharacterDevice
+	}
+	if f.append {
+		buf[2] = 1
+	}
+	binary.LittleEndian.PutUint64(buf[8:], allRights)
+	binary.LittleEndian.PutUint64(buf[16:], allRights)
+	if !writeBytes(proc, ptr, buf) {
+		return errnoFault
+	}
+	return errnoSuccess
+}
+
+func (r *runtime) fdFilestatGet(proc *exec.Process, fd, ptr uint32) int32 {
+	f, ok := r.files[fd]
+	if !ok {
+		return errnoBadf
+	}
+	if f.path == "" {
+		return writeFilestat(proc, ptr, nil)
+	}
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return errno(err)
+	}
+	return writeFilestat(proc, ptr, info)
+}
+
+func (r *runtime) fdFilestatSetSize(proc *exec.Process, fd uint32, size uint64) int32 {
+	f, ok := r.files[fd]
+	if !ok {
+		return errnoBadf
+	}
+	if f.file == nil || f.dir {
+		return errnoInval
+	}
+	if err := f.file.Truncate(int64(size)); err != nil {
+		return errno(err)
+	}
+	return errnoSuccess
+}
+
+func (r *runtime) fdPrestatGet(proc *exec.Process, fd, ptr uint32) int32 {
+	if _, ok := r.files[fd]; !ok || fd != preopenFD {
+		return errnoBadf
+	}
+	buf := make([]byte, 8)
+	binary.LittleEndian.PutUint32(buf[4:], 1) // len("/")
+	if !writeBytes(proc, ptr, buf) {
+		return errnoFault
+	}
+	return errnoSuccess
+}
+
+func (r *runtime) fdPrestatDirName(proc *exec.Process, fd, ptr, length uint32) int32 {
+	if _, ok := r.files[fd]; !ok || fd != preopenFD {
+		return errnoBadf
+	}
+	if length < 1 {
+		return errnoNametoolong
+	}
+	if !writeBytes(proc, ptr, []byte("/")) {
+		return errnoFault
+	}
+	return errnoSuccess
+}
+
+// pollOneoff waits for the subscriptions: the reads and writes are always
+// ready, and the clocks are waited for.
+func (r *runtime) pollOneoff(proc *exec.Process, in, out, nsubs, neventsPtr uint32) int32 {
+	if nsubs == 0 || nsubs > 1024 {
+		return errnoInval
+	}
+	subs, ok := readBytes(proc, in, 48*nsubs)
+	if !ok {
+		return errnoFault
+	}
+
+	var events []byte
+	var clocks []int
+	var timeouts []time.Duration
+	wait := time.Duration(-1)
+	for i := 0; i < int(nsubs); i++ {
+		sub := subs[48*i : 48*(i+1)]
+		event := make([]byte, 32)
+		copy(event[0:8], sub[0:8]) // userdata
+		event[10] = sub[8]         // type
+		switch sub[8] {
+		case 0: // clock
+			id := binary.LittleEndian.Uint32(sub[16:])
+			timeout := binary.LittleEndian.Uint64(sub[24:])
+			flags := binary.LittleEndian.Uint16(sub[40:])
+			now, ok := r.now(id)
+			if !ok {
+				binary.LittleEndian.PutUint16(event[8:], errnoInval)
+				events = append(events, event...)
+				continue
+			}
+			d := time.Duration(timeout)
+			if flags&1 == 1 { // absolute time
+				d = time.Duration(timeout - now)
+				if timeout < now {
+					d = 0
+				}
+			}
+			clocks = append(clocks, i)
+			timeouts = append(timeouts, d)
+			if wait < 0 || d < wait {
+				wait = d
+			}
+		case 1, 2: // fd_read, fd_write
+			fd := binary.LittleEndian.Uint32(sub[16:])
+			if _, ok := r.files[fd]; !ok {
+				binary.LittleEndian.PutUint16(event[8:], errnoBadf)
+			}
+			events = append(events, event...)
+		default:
+			return errnoInval
+		}
+	}
+
+	if len(events) == 0 && wait >= 0 {
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-r.ctx.Done():
+			timer.Stop()
+			r.stop(proc, r.ctx.Err())
+		}
+		for j, i := range clocks {
+			if timeouts[j] > wait {
+				continue
+			}
+			event := make([]byte, 32)
+			copy(event[0:8], subs[48*i:48*i+8])
+			events = append(events, event...)
+		}
+	}
+
+	if !writeBytes(proc, out, events) || !writeUint32(proc, neventsPtr, uint32(len(events)/32)) {
+		return errnoFault
+	}
+	return errnoSuccess
+}
+
+func (r *runtime) procExit(proc *exec.Process, code uint32) {
+	if r.exitCode == nil && r.stopErr == nil {
+		r.exitCode = &code
+	}
+	proc.Terminate()
+}
+
+func randomGet(proc *exec.Process, buf, length uint32) int32 {
+	if length > maxIOSize || !inBounds(proc, buf, length) {
+		return errnoFault
+	}
+	data := make([]byte, length)
+	if _, err := rand.Read(data); err != nil {
+		return errnoIO
+	}
+	writeBytes(proc, buf, data)
+	return errnoSuccess
+}
+
+func schedYield(proc *exec.Process) int32 {
+	return errnoSuccess
+}
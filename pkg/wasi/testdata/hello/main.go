@@ -0,0 +1,35 @@
+// This program is compiled to a WASI module for the tests of the runtime.
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"unsafe"
+)
+
+//go:wasmimport cozy url
+func cozyURL(buf unsafe.Pointer, length uint32) int32
+
+func main() {
+	buf := make([]byte, 64)
+	n := cozyURL(unsafe.Pointer(&buf[0]), uint32(len(buf)))
+	fmt.Printf("url=%s arg=%s env=%s\n", buf[:n], os.Args[1], os.Getenv("COZY_TEST"))
+
+	input, err := ioutil.ReadFile("/input.txt")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	output := strings.ToUpper(string(input))
+	if err := ioutil.WriteFile("/output.txt", []byte(output), 0644); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if _, err := ioutil.ReadFile("/link"); err == nil {
+		fmt.Println("outside of the directory")
+	}
+	os.Exit(3)
+}
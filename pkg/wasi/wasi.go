@@ -0,0 +1,298 @@
+// Package wasi is a runtime for the WASI WebAssembly modules, written in pure
+// Go. It uses the interpreter of wagon, and adds the WASI host functions
+// (wasi_snapshot_preview1) and some limits on the execution: the module is
+// stopped when the context is canceled, when its memory grows over the limit,
+// or when its call stack is too deep.
+//
+// The WebAssembly 1.0 instruction set (MVP) is supported, with the sign
+// extension and non-trapping float-to-int conversion operators, and the
+// memory.copy and memory.fill instructions of bulk memory, that are emitted
+// by the Go and Rust compilers. The other post-MVP features, like SIMD,
+// reference types or multi-value, are not supported.
+package wasi
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/go-interpreter/wagon/exec"
+	"github.com/go-interpreter/wagon/validate"
+	"github.com/go-interpreter/wagon/wasm"
+)
+
+var (
+	// ErrNoStart is used when the module has no _start function.
+	ErrNoStart = errors.New("wasi: the module has no _start function")
+	// ErrMemoryLimit is used when the module has tried to use more memory
+	// than allowed.
+	ErrMemoryLimit = errors.New("wasi: memory limit exceeded")
+	// ErrCallStackExhausted is used when the module has too many nested calls.
+	ErrCallStackExhausted = errors.New("wasi: call stack exhausted")
+)
+
+// ExitError is used when the module has called proc_exit with a non-zero
+// code.
+type ExitError struct {
+	Code uint32
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+// pageSize is the size of a page of the linear memory of a module.
+const pageSize = 65536
+
+// maxPages is the maximal number of pages for a 32 bits memory (4GiB).
+const maxPages = 65536
+
+// maxCallDepth is the maximal number of nested calls of a module. The
+// interpreter uses the Go stack for the calls, so it must be bounded.
+const maxCallDepth = 10000
+
+// Config is the configuration for the execution of a module.
+type Config struct {
+	// Args are the arguments of the program, starting with its name
+	Args []string
+	// Env is the list of the environment variables, in the KEY=value format
+	Env []string
+	// Dir is the directory pre-opened as / for the module (no file system
+	// access if empty)
+	Dir string
+	// Stdin is the standard input of the module (nil for an empty input)
+	Stdin io.Reader
+	// Stdout and Stderr are where the outputs of the module are written (nil
+	// to discard them)
+	Stdout io.Writer
+	Stderr io.Writer
+	// MaxMemory is the maximal size in bytes of the linear memory of the
+	// module (0 for the 4GiB of the 32 bits memories)
+	MaxMemory int64
+	// Strings are values given to the module by host functions, by module
+	// name and function name. Such a function takes the pointer and the
+	// length of a buffer, copies the string in it (truncated if the buffer is
+	// too small), and returns the length of the string.
+	Strings map[string]map[string]string
+}
+
+// RunFile executes the WASI module in the given file.
+func RunFile(ctx context.Context, file string, cfg *Config) error {
+	code, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	return Run(ctx, code, cfg)
+}
+
+// Run executes the _start function of the WASI module. It returns nil if the
+// module has returned from this function or exited with a zero code.
+func Run(ctx context.Context, code []byte, cfg *Config) (err error) {
+	// wagon can panic on some malformed modules, when they are decoded,
+	// instrumented or validated, so the panics are turned into errors.
+	defer func() {
+		if rec := recover(); rec != nil {
+			err = fmt.Errorf("wasi: invalid module: %v", rec)
+		}
+	}()
+	return run(ctx, code, cfg)
+}
+
+func run(ctx context.Context, code []byte, cfg *Config) error {
+	r, err := newRuntime(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer r.closeFiles()
+
+	code, err = stripDataCount(code)
+	if err != nil {
+		return fmt.Errorf("wasi: invalid module: %w", err)
+	}
+
+	// The memory is checked before the module is read, as reading it
+	// allocates the initial memory
+	decoded, err := wasm.DecodeModule(bytes.NewReader(code))
+	if err != nil {
+		return fmt.Errorf("wasi: invalid module: %w", err)
+	}
+	if err := r.checkMemory(decoded); err != nil {
+		return err
+	}
+	m, err := wasm.ReadModule(bytes.NewReader(code), r.resolve)
+	if err != nil {
+		return fmt.Errorf("wasi: invalid module: %w", err)
+	}
+	if m.Export == nil {
+		return ErrNoStart
+	}
+	export, ok := m.Export.Entries["_start"]
+	if !ok || export.Kind != wasm.ExternalFunction {
+		return ErrNoStart
+	}
+	if err := r.instrument(m); err != nil {
+		return fmt.Errorf("wasi: invalid module: %w", err)
+	}
+	if err := validate.VerifyModule(m); err != nil {
+		return fmt.Errorf("wasi: invalid module: %w", err)
+	}
+
+	vm, err := newVM(m)
+	if err == nil {
+		vm.RecoverPanic = true
+		_, err = vm.ExecCode(int64(export.Index))
+	}
+	return r.result(err)
+}
+
+// sectionDataCount is the id of the section added by the bulk memory
+// proposal, before the code section. It is only useful for the memory.init
+// and data.drop instructions, that are not supported.
+const sectionDataCount = 12
+
+// stripDataCount returns the code of the module without its data count
+// section, as wagon rejects the unknown sections.
+func stripDataCount(code []byte) ([]byte, error) {
+	if len(code) < 8 {
+		return nil, errors.New("unexpected end of the module")
+	}
+	out := append([]byte{}, code[:8]...)
+	for pc := 8; pc < len(code); {
+		id := code[pc]
+		size, next, err := readULEB(code, pc+1)
+		if err != nil {
+			return nil, err
+		}
+		end := uint64(next) + size
+		if end > uint64(len(code)) {
+			return nil, errors.New("unexpected end of the module")
+		}
+		if id != sectionDataCount {
+			out = append(out, code[pc:end]...)
+		}
+		pc = int(end)
+	}
+	return out, nil
+}
+
+// newVM compiles the module, and executes its start function if any (that's
+// why the panics must be recovered).
+func newVM(m *wasm.Module) (vm *exec.VM, err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			err = fmt.Errorf("%v", rec)
+		}
+	}()
+	return exec.NewVM(m)
+}
+
+// runtime is the state of the execution of a module.
+type runtime struct {
+	ctx     context.Context
+	cfg     *Config
+	started time.Time
+	root    string
+	files   map[uint32]*openFile
+	nextFD  uint32
+
+	maxPages int
+	depth    int
+	ticks    uint
+	stopErr  error
+	exitCode *uint32
+}
+
+func newRuntime(ctx context.Context, cfg *Config) (*runtime, error) {
+	r := &runtime{
+		ctx:      ctx,
+		cfg:      cfg,
+		started:  time.Now(),
+		files:    make(map[uint32]*openFile),
+		nextFD:   firstFreeFD,
+		maxPages: maxPages,
+	}
+	if cfg.MaxMemory > 0 && cfg.MaxMemory/pageSize < maxPages {
+		r.maxPages = int(cfg.MaxMemory / pageSize)
+	}
+	if cfg.Dir != "" {
+		root, err := filepath.EvalSymlinks(cfg.Dir)
+		if err != nil {
+			return nil, err
+		}
+		if info, err := os.Stat(root); err != nil || !info.IsDir() {
+			return nil, fmt.Errorf("wasi: %s is not a directory", cfg.Dir)
+		}
+		r.root = root
+	}
+	r.openStdio()
+	return r, nil
+}
+
+func (r *runtime) resolve(name string) (*wasm.Module, error) {
+	if name == wasiModuleName {
+		return r.wasiModule(), nil
+	}
+	if strings, ok := r.cfg.Strings[name]; ok {
+		return stringsModule(strings), nil
+	}
+	return nil, fmt.Errorf("wasi: unknown module %q for the imports", name)
+}
+
+// checkMemory checks that the initial memory of the module, and the data
+// segments copied in it, are under the limit.
+func (r *runtime) checkMemory(m *wasm.Module) error {
+	if m.Memory != nil {
+		for _, mem := range m.Memory.Entries {
+			if int(mem.Limits.Initial) > r.maxPages {
+				return ErrMemoryLimit
+			}
+			if mem.Limits.Flags&1 == 1 && int(mem.Limits.Maximum) < r.maxPages {
+				r.maxPages = int(mem.Limits.Maximum)
+			}
+		}
+	}
+	if m.Data != nil {
+		limit := uint64(r.maxPages) * pageSize
+		for _, entry := range m.Data.Entries {
+			val, err := m.ExecInitExpr(entry.Offset)
+			if err != nil {
+				return fmt.Errorf("wasi: invalid module: %w", err)
+			}
+			off, ok := val.(int32)
+			if ok && uint64(uint32(off))+uint64(len(entry.Data)) > limit {
+				return ErrMemoryLimit
+			}
+		}
+	}
+	return nil
+}
+
+// stop terminates the execution of the module. The first reason is kept.
+func (r *runtime) stop(proc *exec.Process, err error) {
+	if r.stopErr == nil && r.exitCode == nil {
+		r.stopErr = err
+	}
+	proc.Terminate()
+}
+
+func (r *runtime) result(err error) error {
+	if r.exitCode != nil {
+		if *r.exitCode == 0 {
+			return nil
+		}
+		return &ExitError{Code: *r.exitCode}
+	}
+	if r.stopErr != nil {
+		return r.stopErr
+	}
+	if err != nil {
+		return fmt.Errorf("wasi: trap: %w", err)
+	}
+	return nil
+}
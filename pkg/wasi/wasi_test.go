@@ -0,0 +1,219 @@
+package wasi
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io/ioutil"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testModule returns a module that imports proc_exit as the function 0, and
+// exports a _start function (the function 1) with the given code, and a
+// memory of one page.
+func testModule(code ...byte) []byte {
+	section := func(id byte, content ...byte) []byte {
+		return append(appendULEB([]byte{id}, uint64(len(content))), content...)
+	}
+	str := func(s string) []byte {
+		return append([]byte{byte(len(s))}, s...)
+	}
+
+	mod := []byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00}
+	// The types: () -> () and (i32) -> ()
+	mod = append(mod, section(1, 2, 0x60, 0, 0, 0x60, 1, 0x7f, 0)...)
+	imports := []byte{1}
+	imports = append(imports, str(wasiModuleName)...)
+	imports = append(imports, str("proc_exit")...)
+	imports = append(imports, 0x00, 1)
+	mod = append(mod, section(2, imports...)...)
+	mod = append(mod, section(3, 1, 0)...)
+	mod = append(mod, section(5, 1, 0, 1)...)
+	exports := []byte{2}
+	exports = append(exports, str("_start")...)
+	exports = append(exports, 0x00, 1)
+	exports = append(exports, str("memory")...)
+	exports = append(exports, 0x02, 0)
+	mod = append(mod, section(7, exports...)...)
+	body := append([]byte{0}, code...)
+	body = append(body, 0x0b)
+	codes := appendULEB([]byte{1}, uint64(len(body)))
+	codes = append(codes, body...)
+	return append(mod, section(10, codes...)...)
+}
+
+func f32(f float32) []byte {
+	buf := []byte{0x43, 0, 0, 0, 0}
+	binary.LittleEndian.PutUint32(buf[1:], math.Float32bits(f))
+	return buf
+}
+
+func f64(f float64) []byte {
+	buf := []byte{0x44, 0, 0, 0, 0, 0, 0, 0, 0}
+	binary.LittleEndian.PutUint64(buf[1:], math.Float64bits(f))
+	return buf
+}
+
+func concat(parts ...[]byte) []byte {
+	return bytes.Join(parts, nil)
+}
+
+func TestRunExit(t *testing.T) {
+	// (call $proc_exit (i32.const 7))
+	err := Run(context.Background(), testModule(0x41, 7, 0x10, 0), &Config{})
+	assert.Equal(t, &ExitError{Code: 7}, err)
+
+	// (call $proc_exit (i32.const 0))
+	err = Run(context.Background(), testModule(0x41, 0, 0x10, 0), &Config{})
+	assert.NoError(t, err)
+
+	err = Run(context.Background(), testModule(), &Config{})
+	assert.NoError(t, err)
+
+	err = Run(context.Background(), testModule(0x00), &Config{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "wasi: trap")
+
+	err = Run(context.Background(), []byte("not a module"), &Config{})
+	assert.Error(t, err)
+}
+
+func TestRunTimeout(t *testing.T) {
+	// (loop (br 0))
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := Run(ctx, testModule(0x03, 0x40, 0x0c, 0, 0x0b), &Config{})
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.True(t, time.Since(start) < 5*time.Second)
+}
+
+func TestRunMemoryLimit(t *testing.T) {
+	// (call $proc_exit (memory.grow (i32.const 1)))
+	cfg := &Config{MaxMemory: 4 * pageSize}
+	err := Run(context.Background(), testModule(0x41, 1, 0x40, 0, 0x10, 0), cfg)
+	assert.Equal(t, &ExitError{Code: 1}, err)
+
+	// (drop (memory.grow (i32.const 10)))
+	err = Run(context.Background(), testModule(0x41, 10, 0x40, 0, 0x1a), cfg)
+	assert.Equal(t, ErrMemoryLimit, err)
+
+	// The initial memory is over the limit
+	cfg = &Config{MaxMemory: pageSize / 2}
+	err = Run(context.Background(), testModule(), cfg)
+	assert.Equal(t, ErrMemoryLimit, err)
+}
+
+func TestRunCallStack(t *testing.T) {
+	// (call $_start)
+	err := Run(context.Background(), testModule(0x10, 1), &Config{})
+	assert.Equal(t, ErrCallStackExhausted, err)
+}
+
+func TestRunPostMVP(t *testing.T) {
+	// (memory.fill (i32.const 0) (i32.const 255) (i32.const 4))
+	// (memory.copy (i32.const 8) (i32.const 0) (i32.const 4))
+	// (call $proc_exit (i32.sub (i32.const 0)
+	//   (i32.extend8_s (i32.load8_u (i32.const 11)))))
+	code := []byte{
+		0x41, 0, 0x41, 0xff, 0x01, 0x41, 4, 0xfc, 11, 0,
+		0x41, 8, 0x41, 0, 0x41, 4, 0xfc, 10, 0, 0,
+		0x41, 0, 0x41, 11, 0x2d, 0, 0, 0xc0, 0x6b, 0x10, 0,
+	}
+	err := Run(context.Background(), testModule(code...), &Config{})
+	assert.Equal(t, &ExitError{Code: 1}, err)
+
+	// (memory.copy (i32.const 65535) (i32.const 0) (i32.const 2))
+	code = []byte{0x41, 0xff, 0xff, 0x03, 0x41, 0, 0x41, 2, 0xfc, 10, 0, 0}
+	err = Run(context.Background(), testModule(code...), &Config{})
+	assert.Equal(t, errOutOfBounds, err)
+
+	// (call $proc_exit (i32.add (i32.trunc_sat_f64_u (f64.const 42.9))
+	//   (i32.trunc_sat_f32_s (f32.const nan))))
+	code = concat(f64(42.9), []byte{0xfc, 3}, f32(float32(math.NaN())), []byte{0xfc, 0, 0x6a, 0x10, 0})
+	err = Run(context.Background(), testModule(code...), &Config{})
+	assert.Equal(t, &ExitError{Code: 42}, err)
+
+	// SIMD is not supported: (v128.const ...)
+	err = Run(context.Background(), testModule(0xfd, 12), &Config{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported instruction")
+}
+
+func TestTruncSat(t *testing.T) {
+	assert.Equal(t, int32(math.MaxInt32), truncSatF64ToI32(nil, math.Float64bits(1e20)))
+	assert.Equal(t, int32(math.MinInt32), truncSatF64ToI32(nil, math.Float64bits(-1e20)))
+	assert.Equal(t, int32(-3), truncSatF64ToI32(nil, math.Float64bits(-3.7)))
+	assert.Equal(t, uint32(0), truncSatF32ToU32(nil, math.Float32bits(-3.7)))
+	assert.Equal(t, uint32(math.MaxUint32), truncSatF32ToU32(nil, math.Float32bits(1e20)))
+	assert.Equal(t, int64(math.MaxInt64), truncSatF64ToI64(nil, math.Float64bits(math.Inf(1))))
+	assert.Equal(t, int64(math.MinInt64), truncSatF32ToI64(nil, math.Float32bits(float32(math.Inf(-1)))))
+	assert.Equal(t, int64(0), truncSatF64ToI64(nil, math.Float64bits(math.NaN())))
+	assert.Equal(t, uint64(math.MaxUint64), truncSatF64ToU64(nil, math.Float64bits(1e30)))
+	assert.Equal(t, uint64(12), truncSatF32ToU64(nil, math.Float32bits(12.5)))
+}
+
+// TestRunGoProgram runs a program compiled by the Go toolchain, if it can
+// target WASI (Go 1.21+).
+func TestRunGoProgram(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "wasi")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	wasm := filepath.Join(tmp, "hello.wasm")
+	cmd := exec.Command("go", "build", "-o", wasm, "./testdata/hello")
+	cmd.Env = append(os.Environ(), "GOOS=wasip1", "GOARCH=wasm")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Skipf("Cannot compile the Go program to WASI: %s", out)
+	}
+
+	dir := filepath.Join(tmp, "dir")
+	require.NoError(t, os.Mkdir(dir, 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "input.txt"), []byte("some input"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(tmp, "secret.txt"), []byte("secret"), 0644))
+	require.NoError(t, os.Symlink(filepath.Join(tmp, "secret.txt"), filepath.Join(dir, "link")))
+
+	var stdout, stderr bytes.Buffer
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	err = RunFile(ctx, wasm, &Config{
+		Args:    []string{"hello", "foo"},
+		Env:     []string{"COZY_TEST=bar"},
+		Dir:     dir,
+		Stdout:  &stdout,
+		Stderr:  &stderr,
+		Strings: map[string]map[string]string{"cozy": {"url": "https://alice.cozy.example/"}},
+	})
+	assert.Equal(t, &ExitError{Code: 3}, err, stderr.String())
+	assert.Equal(t, "url=https://alice.cozy.example/ arg=foo env=bar\n", stdout.String())
+	output, err := ioutil.ReadFile(filepath.Join(dir, "output.txt"))
+	assert.NoError(t, err)
+	assert.Equal(t, "SOME INPUT", string(output))
+}
+
+func TestRunNoStart(t *testing.T) {
+	mod := testModule()
+	// Rename the _start export
+	i := bytes.Index(mod, []byte("_start"))
+	mod[i+1] = 'S'
+	err := Run(context.Background(), mod, &Config{})
+	assert.True(t, errors.Is(err, ErrNoStart))
+}
+
+func TestRunMalformedModule(t *testing.T) {
+	mod := testModule()
+	// The proc_exit import references a type that doesn't exist
+	i := bytes.Index(mod, []byte("proc_exit"))
+	mod[i+len("proc_exit")+1] = 21
+	err := Run(context.Background(), mod, &Config{})
+	assert.Error(t, err)
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/cozy/cozy-stack/model/account"
 	"github.com/cozy/cozy-stack/model/instance/lifecycle"
+	"github.com/cozy/cozy-stack/model/job"
 	"github.com/cozy/cozy-stack/model/permission"
 	"github.com/cozy/cozy-stack/pkg/consts"
 	"github.com/cozy/cozy-stack/pkg/couchdb"
@@ -225,6 +226,45 @@ func reconnect(c echo.Context) error {
 	return c.Redirect(http.StatusSeeOther, url)
 }
 
+// resume can be used when the user has updated the credentials of an account
+// that needed an action: the triggers of the account are no longer paused,
+// and the konnector is run again.
+func resume(c echo.Context) error {
+	instance := middlewares.GetInstance(c)
+	accountid := c.Param("accountid")
+
+	var acc account.Account
+	if err := couchdb.GetDoc(instance, consts.Accounts, accountid, &acc); err != nil {
+		return err
+	}
+	if acc.AccountType != c.Param("accountType") {
+		return jsonapi.NotFound(errors.New("account not found"))
+	}
+
+	if err := middlewares.Allow(c, permission.PUT, &acc); err != nil {
+		return err
+	}
+
+	if _, err := account.ClearUserAction(instance, acc.ID()); err != nil {
+		return err
+	}
+
+	jobsSystem := job.System()
+	triggers, err := account.GetTriggers(jobsSystem, instance, acc.ID())
+	if err != nil {
+		return err
+	}
+	for _, t := range triggers {
+		req := t.Infos().JobRequest()
+		req.Manual = true
+		if _, err := jobsSystem.PushJob(instance, req); err != nil {
+			return err
+		}
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
 // Routes setups routing for cozy-as-oauth-client routes
 // Careful, the normal middlewares NeedInstance and LoadSession are not applied
 // to this group in web/routing
@@ -233,4 +273,5 @@ func Routes(router *echo.Group) {
 	router.GET("/:accountType/redirect", redirect)
 	router.POST("/:accountType/:accountid/refresh", refresh, middlewares.NeedInstance)
 	router.GET("/:accountType/:accountid/reconnect", reconnect, middlewares.NeedInstance)
+	router.POST("/:accountType/:accountid/resume", resume, middlewares.NeedInstance)
 }
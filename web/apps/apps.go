@@ -5,6 +5,7 @@ package apps
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -84,6 +85,33 @@ func (man *apiApp) Included() []jsonapi.Object {
 // apiApp is a jsonapi.Object
 var _ jsonapi.Object = (*apiApp)(nil)
 
+// apiInstallingApp is an application being installed, with the report of
+// the checks of its dependencies.
+type apiInstallingApp struct {
+	*apiApp
+	dependencies *app.DependencyReport
+}
+
+func (man *apiInstallingApp) MarshalJSON() ([]byte, error) {
+	b, err := json.Marshal(man.Manifest)
+	if err != nil {
+		return nil, err
+	}
+	var attrs map[string]interface{}
+	if err := json.Unmarshal(b, &attrs); err != nil {
+		return nil, err
+	}
+	attrs["dependencies_check"] = man.dependencies
+	return json.Marshal(attrs)
+}
+
+func newAPIInstallingApp(man app.Manifest, report *app.DependencyReport) jsonapi.Object {
+	if report == nil {
+		return &apiApp{man}
+	}
+	return &apiInstallingApp{&apiApp{man}, report}
+}
+
 func getHandler(appType consts.AppType) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		instance := middlewares.GetInstance(c)
@@ -124,7 +152,8 @@ func installHandler(installerType consts.AppType) echo.HandlerFunc {
 		}
 
 		var w http.ResponseWriter
-		isEventStream := c.Request().Header.Get("Accept") == typeTextEventStream
+		dryRun, _ := strconv.ParseBool(c.QueryParam("DryRun"))
+		isEventStream := c.Request().Header.Get("Accept") == typeTextEventStream && !dryRun
 		if isEventStream {
 			w = c.Response().Writer
 			w.Header().Set("Content-Type", typeTextEventStream)
@@ -140,6 +169,7 @@ func installHandler(installerType consts.AppType) echo.HandlerFunc {
 				Deactivated: c.QueryParam("Deactivated") == "true",
 				Registries:  instance.Registries(),
 
+				InstallDependencies: c.QueryParam("InstallDependencies") == "true",
 				OverridenParameters: overridenParameters,
 			},
 		)
@@ -153,6 +183,14 @@ func installHandler(installerType consts.AppType) echo.HandlerFunc {
 			return wrapAppsError(err)
 		}
 
+		if dryRun {
+			man, report, err := inst.CheckDependencies()
+			if err != nil {
+				return wrapAppsError(err)
+			}
+			return jsonapi.Data(c, http.StatusOK, newAPIInstallingApp(man, report), nil)
+		}
+
 		go inst.Run()
 		return pollInstaller(c, instance, isEventStream, w, slug, inst)
 	}
@@ -372,7 +410,7 @@ func pollInstaller(c echo.Context, instance *instance.Instance, isEventStream bo
 				}
 			}
 		}()
-		return jsonapi.Data(c, http.StatusAccepted, &apiApp{man}, nil)
+		return jsonapi.Data(c, http.StatusAccepted, newAPIInstallingApp(man, inst.Dependencies()), nil)
 	}
 
 	manc := inst.ManifestChannel()
@@ -389,7 +427,7 @@ func pollInstaller(c echo.Context, instance *instance.Instance, isEventStream bo
 				return nil
 			}
 			buf := new(bytes.Buffer)
-			if err := jsonapi.WriteData(buf, &apiApp{man}, nil); err == nil {
+			if err := jsonapi.WriteData(buf, newAPIInstallingApp(man, inst.Dependencies()), nil); err == nil {
 				writeStream(w, "state", strings.TrimSuffix(buf.String(), "\n"))
 			}
 			if s := man.State(); s == app.Ready || s == app.Installed || s == app.Errored {
@@ -592,6 +630,7 @@ func WebappsRoutes(router *echo.Group) {
 	router.DELETE("/:slug", deleteHandler(consts.WebappType))
 	router.GET("/:slug/icon", iconHandler(consts.WebappType))
 	router.GET("/:slug/icon/:version", iconHandler(consts.WebappType))
+	router.Any("/:slug/routes/*", serviceRouteHandler, middlewares.CheckInstanceBlocked)
 }
 
 // KonnectorRoutes sets the routing for the konnectors service
@@ -607,6 +646,9 @@ func KonnectorRoutes(router *echo.Group) {
 }
 
 func wrapAppsError(err error) error {
+	if errors.Is(err, app.ErrUnmetDependencies) {
+		return jsonapi.NewError(http.StatusPreconditionFailed, err.Error())
+	}
 	switch err {
 	case app.ErrInvalidSlugName:
 		return jsonapi.InvalidParameter("slug", err)
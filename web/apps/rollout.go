@@ -0,0 +1,199 @@
+package apps
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/cozy/cozy-stack/model/app"
+	"github.com/cozy/cozy-stack/pkg/consts"
+	"github.com/cozy/cozy-stack/pkg/couchdb"
+	"github.com/cozy/cozy-stack/pkg/jsonapi"
+	"github.com/cozy/cozy-stack/worker/updates"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	defaultRolloutsLimit = 100
+	maxRolloutsLimit     = 1000
+)
+
+// rolloutsPageLimit returns the number of items per page, from the
+// page[limit] parameter.
+func rolloutsPageLimit(c echo.Context) (int, error) {
+	limit := defaultRolloutsLimit
+	if l := c.QueryParam("page[limit]"); l != "" {
+		n, err := strconv.Atoi(l)
+		if err != nil || n <= 0 {
+			return 0, jsonapi.InvalidParameter("page[limit]", errors.New("Invalid limit"))
+		}
+		limit = n
+	}
+	if limit > maxRolloutsLimit {
+		limit = maxRolloutsLimit
+	}
+	return limit, nil
+}
+
+func listRollouts(c echo.Context) error {
+	limit, err := rolloutsPageLimit(c)
+	if err != nil {
+		return err
+	}
+	status := app.RolloutStatus(c.QueryParam("status"))
+	rollouts, next, err := app.ListRollouts(status, limit, c.QueryParam("page[cursor]"))
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, echo.Map{
+		"rollouts":    rollouts,
+		"next_cursor": next,
+	})
+}
+
+func createRollout(c echo.Context) error {
+	var body struct {
+		Slug          string   `json:"slug"`
+		Type          string   `json:"type"`
+		Source        string   `json:"source"`
+		Percentage    int      `json:"percentage"`
+		Contexts      []string `json:"contexts"`
+		Force         bool     `json:"force"`
+		MaxErrorRate  float64  `json:"max_error_rate"`
+		MinJobs       int      `json:"min_jobs"`
+		Watch         string   `json:"watch"`
+		CheckInterval string   `json:"check_interval"`
+	}
+	if err := c.Bind(&body); err != nil {
+		return jsonapi.BadJSON()
+	}
+
+	r := &app.Rollout{
+		Slug:         body.Slug,
+		AppType:      consts.WebappType,
+		Source:       body.Source,
+		Percentage:   body.Percentage,
+		Contexts:     body.Contexts,
+		Force:        body.Force,
+		MaxErrorRate: body.MaxErrorRate,
+		MinJobs:      body.MinJobs,
+	}
+	if body.Type == "konnector" {
+		r.AppType = consts.KonnectorType
+	} else if body.Type != "" && body.Type != "webapp" {
+		return jsonapi.InvalidParameter("type", app.ErrInvalidAppType)
+	}
+	if body.Watch != "" {
+		d, err := time.ParseDuration(body.Watch)
+		if err != nil {
+			return jsonapi.InvalidParameter("watch", err)
+		}
+		r.Watch = d
+	}
+	if body.CheckInterval != "" {
+		d, err := time.ParseDuration(body.CheckInterval)
+		if err != nil {
+			return jsonapi.InvalidParameter("check_interval", err)
+		}
+		r.CheckInterval = d
+	}
+
+	if _, err := updates.PushRollout(r); err != nil {
+		if r.ID() == "" {
+			return jsonapi.BadRequest(err)
+		}
+		return err
+	}
+	return c.JSON(http.StatusCreated, r)
+}
+
+func getRollout(c echo.Context) error {
+	r, err := app.GetRollout(c.Param("id"))
+	if err != nil {
+		return wrapRolloutError(err)
+	}
+	return c.JSON(http.StatusOK, r)
+}
+
+func listRolloutInstances(c echo.Context) error {
+	r, err := app.GetRollout(c.Param("id"))
+	if err != nil {
+		return wrapRolloutError(err)
+	}
+	limit, err := rolloutsPageLimit(c)
+	if err != nil {
+		return err
+	}
+	instances, next, err := app.ListRolloutInstances(r.ID(), limit, c.QueryParam("page[cursor]"))
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, echo.Map{
+		"instances":   instances,
+		"next_cursor": next,
+	})
+}
+
+// rollbackRollout marks the rollout as rolling back, and pushes a job to roll
+// back the instances: it can take a long time.
+func rollbackRollout(c echo.Context) error {
+	r, err := app.GetRollout(c.Param("id"))
+	if err != nil {
+		return wrapRolloutError(err)
+	}
+	if _, err := r.StartRollback("Manual rollback"); err != nil {
+		return wrapRolloutError(err)
+	}
+	return c.JSON(http.StatusAccepted, r)
+}
+
+func cancelRollout(c echo.Context) error {
+	r, err := app.GetRollout(c.Param("id"))
+	if err != nil {
+		return wrapRolloutError(err)
+	}
+	if r.Status != app.RolloutRunning {
+		return wrapRolloutError(app.ErrRolloutNotRunning)
+	}
+	r.Status = app.RolloutCanceled
+	r.Reason = "Canceled"
+	if err := r.Save(); err != nil {
+		return wrapRolloutError(err)
+	}
+	return c.JSON(http.StatusOK, r)
+}
+
+func unpinRollout(c echo.Context) error {
+	r, err := app.GetRollout(c.Param("id"))
+	if err != nil {
+		return wrapRolloutError(err)
+	}
+	if err := updates.Unpin(r); err != nil {
+		return wrapRolloutError(err)
+	}
+	return c.JSON(http.StatusOK, r)
+}
+
+func wrapRolloutError(err error) error {
+	if couchdb.IsNotFoundError(err) || couchdb.IsNoDatabaseError(err) {
+		return jsonapi.NotFound(err)
+	}
+	if err == app.ErrRolloutNotRunning || err == app.ErrRolloutNotRolledBack ||
+		couchdb.IsConflictError(err) {
+		return jsonapi.Conflict(err)
+	}
+	return err
+}
+
+// RolloutsAdminRoutes sets the routing for the admin interface to manage the
+// staged rollouts of the applications updates.
+func RolloutsAdminRoutes(router *echo.Group) {
+	router.GET("/rollouts", listRollouts)
+	router.POST("/rollouts", createRollout)
+	router.GET("/rollouts/:id", getRollout)
+	router.GET("/rollouts/:id/instances", listRolloutInstances)
+	router.POST("/rollouts/:id/rollback", rollbackRollout)
+	router.POST("/rollouts/:id/unpin", unpinRollout)
+	router.DELETE("/rollouts/:id", cancelRollout)
+}
@@ -272,6 +272,11 @@ func ServeAppFile(c echo.Context, i *instance.Instance, fs appfs.FileServer, web
 			}
 		}
 	}
+	if token != "" {
+		if err := recordShareLinkView(c, i, token); err != nil {
+			return err
+		}
+	}
 
 	tracking := false
 	settings, err := i.SettingsDocument()
@@ -302,6 +307,36 @@ func ServeAppFile(c echo.Context, i *instance.Instance, fs appfs.FileServer, web
 	})
 }
 
+// recordShareLinkView counts a view for a share by link, and refuses it if the
+// maximal number of views has been reached. An invalid sharecode is ignored,
+// as the webapp will show the error to the user. For a share by link
+// protected by a password, the view is counted only when the password is
+// given, in the unlock route.
+func recordShareLinkView(c echo.Context, i *instance.Instance, sharecode string) error {
+	if !strings.Contains(sharecode, ".") {
+		token, err := permission.GetTokenFromShortcode(i, strings.TrimSuffix(sharecode, "."))
+		if err != nil {
+			return nil
+		}
+		sharecode = token
+	}
+	pdoc, err := permission.GetForShareCode(i, sharecode)
+	if err != nil || pdoc.Type != permission.TypeShareByLink {
+		return nil
+	}
+	if pdoc.HasPassword() {
+		if pdoc.ViewsExhausted() {
+			return permission.ErrShareLinkExhausted
+		}
+		return nil
+	}
+	err = pdoc.RecordAccess(i, permission.AccessView, c.RealIP())
+	if err == permission.ErrShareLinkExhausted {
+		return err
+	}
+	return nil
+}
+
 func renderMovedLink(c echo.Context, i *instance.Instance, to, subdomainType string) error {
 	name, _ := i.PublicName()
 	link := *c.Request().URL
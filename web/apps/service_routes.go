@@ -0,0 +1,67 @@
+package apps
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/cozy/cozy-stack/model/app"
+	"github.com/cozy/cozy-stack/model/permission"
+	"github.com/cozy/cozy-stack/pkg/consts"
+	"github.com/cozy/cozy-stack/pkg/jsonapi"
+	"github.com/cozy/cozy-stack/web/middlewares"
+	"github.com/cozy/cozy-stack/worker/exec"
+	"github.com/labstack/echo/v4"
+)
+
+// maxServiceRouteBody is the maximal size of the body of a request sent to a
+// service.
+const maxServiceRouteBody = 10 << 20
+
+// serviceRouteHandler executes the service that serves the route declared in
+// the manifest of a webapp, and streams its response.
+func serviceRouteHandler(c echo.Context) error {
+	inst := middlewares.GetInstance(c)
+	slug := c.Param("slug")
+	man, err := app.GetWebappBySlug(inst, slug)
+	if err != nil {
+		return wrapAppsError(err)
+	}
+	name, service, rest := man.FindServiceRoute(c.Param("*"))
+	if service == nil {
+		return jsonapi.NotFound(app.ErrNotFound)
+	}
+	if !service.Public {
+		if err := allowServiceRoute(c, slug); err != nil {
+			return err
+		}
+	}
+	if man.State() != app.Ready && man.State() != app.Installed {
+		return jsonapi.NewError(http.StatusServiceUnavailable, "Application is not ready")
+	}
+
+	req := c.Request()
+	req.Body = http.MaxBytesReader(c.Response(), req.Body, maxServiceRouteBody)
+	err = exec.ServeRoute(inst, man, name, c.Response(), req, rest)
+	switch err {
+	case nil:
+		return nil
+	case exec.ErrTooManyRouteRequests:
+		return jsonapi.NewError(http.StatusTooManyRequests, err.Error())
+	case context.DeadlineExceeded:
+		return jsonapi.NewError(http.StatusGatewayTimeout, "The service has timed out")
+	}
+	return jsonapi.NewError(http.StatusBadGateway, err.Error())
+}
+
+// allowServiceRoute checks that the request for a route that is not public
+// has been made with a token of the webapp.
+func allowServiceRoute(c echo.Context, slug string) error {
+	pdoc, err := middlewares.GetPermission(c)
+	if err != nil {
+		return err
+	}
+	if pdoc.Type != permission.TypeWebapp || pdoc.SourceID != consts.Apps+"/"+slug {
+		return middlewares.ErrForbidden
+	}
+	return nil
+}
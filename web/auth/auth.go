@@ -32,6 +32,10 @@ const (
 	// TwoFactorExceededErrorKey is the key for translating the message showed to the
 	// user when there were too many attempts
 	TwoFactorExceededErrorKey = "Login Two factor attempts error"
+	// TwoFactorTooManyAttemptsKey is the key for translating the message
+	// showed to the user when there were too many attempts with TOTP or a
+	// security key
+	TwoFactorTooManyAttemptsKey = "Login Two factor too many attempts"
 )
 
 func wantsJSON(c echo.Context) bool {
@@ -330,7 +334,15 @@ func login(c echo.Context) error {
 	sess, ok := middlewares.GetSession(c)
 	if ok { // The user was already logged-in
 		sessionID = sess.ID()
+	} else if errorMessage := loginAttemptError(c, inst); errorMessage != "" {
+		if wantsJSON(c) {
+			return c.JSON(http.StatusTooManyRequests, echo.Map{
+				"error": errorMessage,
+			})
+		}
+		return renderLoginForm(c, inst, http.StatusTooManyRequests, errorMessage, redirect)
 	} else if lifecycle.CheckPassphrase(inst, passphrase) == nil {
+		limits.LoginSucceeded(inst.Domain, c.RealIP())
 		ua := user_agent.New(c.Request().UserAgent())
 		browser, _ := ua.Browser()
 		iterations := crypto.DefaultPBKDF2Iterations
@@ -343,12 +355,11 @@ func login(c echo.Context) error {
 			migrateToHashedPassphrase(inst, settings, passphrase, iterations)
 		}
 
-		// In case the second factor authentication mode is "mail", we also
-		// check that the mail has been confirmed. If not, 2FA is not
-		// activated.
+		// In case a second factor is required (mail, TOTP or security key),
+		// the user is redirected to the 2FA form.
 		// If device is trusted, skip the 2FA.
-		if inst.HasAuthMode(instance.TwoFactorMail) && !isTrustedDevice(c, inst) {
-			twoFactorToken, err := lifecycle.SendTwoFactorPasscode(inst)
+		if inst.HasTwoFactor() && !isTrustedDevice(c, inst) {
+			twoFactorToken, err := lifecycle.StartTwoFactor(inst)
 			if err != nil {
 				return err
 			}
@@ -368,9 +379,10 @@ func login(c echo.Context) error {
 		}
 	} else { // Bad login passphrase
 		errorMessage := inst.Translate(CredentialsErrorKey)
+		session.LoginFailed(inst, c.RealIP())
 		err := limits.CheckRateLimit(inst, limits.AuthType)
 		if limits.IsLimitReachedOrExceeded(err) {
-			if err = LoginRateExceeded(inst); err != nil {
+			if err = session.LoginRateExceeded(inst); err != nil {
 				inst.Logger().WithNamespace("auth").Warn(err.Error())
 			}
 		}
@@ -610,7 +622,15 @@ func Routes(router *echo.Group) {
 	authorizeGroup.GET("/move", authorizeMoveForm)
 	authorizeGroup.POST("/move", authorizeMove)
 
+	router.GET("/device", deviceForm, noCSRF)
+	router.POST("/device", authorizeDevice, noCSRF)
+
 	router.POST("/access_token", accessToken)
+	router.POST("/device_authorization", deviceAuthorization)
+	router.POST("/introspect", introspectToken)
+	router.POST("/revoke", revokeToken)
+	router.GET("/userinfo", userinfo)
+	router.POST("/userinfo", userinfo)
 	router.POST("/secret_exchange", secretExchange)
 
 	// 2FA
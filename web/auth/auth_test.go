@@ -5,17 +5,20 @@ package auth_test
 
 import (
 	"bytes"
+	"crypto/rsa"
 	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"math/big"
 	"net/http"
 	"net/http/cookiejar"
 	"net/http/httptest"
 	"net/url"
 	"os"
 	"regexp"
+	"strings"
 	"testing"
 	"time"
 
@@ -58,6 +61,8 @@ var altRegistrationToken string
 var csrfToken string
 var code string
 var refreshToken string
+var publicClientID string
+var publicRefreshToken string
 var linkedClientID string
 var linkedClientSecret string
 var linkedCode string
@@ -1256,6 +1261,310 @@ func TestRefreshTokenSuccess(t *testing.T) {
 	assertValidToken(t, response["access_token"], "access", clientID, "files:read")
 }
 
+func TestAuthorizeWithPKCE(t *testing.T) {
+	publicClient := &oauth.Client{
+		RedirectURIs:            []string{"https://example.org/oauth/callback"},
+		ClientName:              "public-client",
+		SoftwareID:              "github.com/example/public-client",
+		TokenEndpointAuthMethod: oauth.AuthMethodNone,
+	}
+	regErr := publicClient.Create(testInstance)
+	assert.Nil(t, regErr)
+	publicClientID = publicClient.ClientID
+
+	// A public client must use PKCE
+	res, err := postForm("/auth/authorize", &url.Values{
+		"state":         {"123456"},
+		"client_id":     {publicClientID},
+		"redirect_uri":  {"https://example.org/oauth/callback"},
+		"scope":         {"files:read"},
+		"csrf_token":    {csrfToken},
+		"response_type": {"code"},
+	})
+	assert.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, "400 Bad Request", res.Status)
+
+	verifier := "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	challenge := "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+	res, err = postForm("/auth/authorize", &url.Values{
+		"state":                 {"123456"},
+		"client_id":             {publicClientID},
+		"redirect_uri":          {"https://example.org/oauth/callback"},
+		"scope":                 {"files:read"},
+		"csrf_token":            {csrfToken},
+		"response_type":         {"code"},
+		"code_challenge":        {challenge},
+		"code_challenge_method": {"S256"},
+	})
+	assert.NoError(t, err)
+	res.Body.Close()
+	if !assert.Equal(t, "302 Found", res.Status) {
+		return
+	}
+	location, err := url.Parse(res.Header.Get("Location"))
+	assert.NoError(t, err)
+	publicCode := location.Query().Get("code")
+	assert.NotEmpty(t, publicCode)
+
+	res, err = postForm("/auth/access_token", &url.Values{
+		"grant_type":    {"authorization_code"},
+		"client_id":     {publicClientID},
+		"code":          {publicCode},
+		"code_verifier": {"wrong-verifier-wrong-verifier-wrong-verifier"},
+	})
+	assert.NoError(t, err)
+	assertJSONError(t, res, "invalid code_verifier")
+
+	res, err = postForm("/auth/access_token", &url.Values{
+		"grant_type":    {"authorization_code"},
+		"client_id":     {publicClientID},
+		"code":          {publicCode},
+		"code_verifier": {verifier},
+	})
+	assert.NoError(t, err)
+	defer res.Body.Close()
+	assert.Equal(t, "200 OK", res.Status)
+	var response map[string]string
+	err = json.NewDecoder(res.Body).Decode(&response)
+	assert.NoError(t, err)
+	assertValidToken(t, response["access_token"], "access", publicClientID, "files:read")
+	assertValidToken(t, response["refresh_token"], "refresh", publicClientID, "files:read")
+	publicRefreshToken = response["refresh_token"]
+}
+
+func TestDeviceAuthorizationGrant(t *testing.T) {
+	res, err := postForm("/auth/device_authorization", &url.Values{
+		"client_id":     {clientID},
+		"client_secret": {clientSecret},
+		"scope":         {"files:read"},
+	})
+	assert.NoError(t, err)
+	defer res.Body.Close()
+	if !assert.Equal(t, "200 OK", res.Status) {
+		return
+	}
+	var device map[string]interface{}
+	err = json.NewDecoder(res.Body).Decode(&device)
+	assert.NoError(t, err)
+	deviceCode, _ := device["device_code"].(string)
+	userCode, _ := device["user_code"].(string)
+	assert.NotEmpty(t, deviceCode)
+	assert.Len(t, userCode, 9)
+	assert.Equal(t, "https://"+domain+"/auth/device", device["verification_uri"])
+
+	poll := func() (*http.Response, error) {
+		return postForm("/auth/access_token", &url.Values{
+			"grant_type":    {oauth.DeviceCodeGrantType},
+			"client_id":     {clientID},
+			"client_secret": {clientSecret},
+			"device_code":   {deviceCode},
+		})
+	}
+	res2, err := poll()
+	assert.NoError(t, err)
+	assertJSONError(t, res2, "authorization_pending")
+	res3, err := poll()
+	assert.NoError(t, err)
+	assertJSONError(t, res3, "slow_down")
+
+	req, _ := http.NewRequest("GET", ts.URL+"/auth/device?user_code="+strings.ToLower(userCode), nil)
+	req.Host = domain
+	res4, err := client.Do(req)
+	assert.NoError(t, err)
+	defer res4.Body.Close()
+	assert.Equal(t, "200 OK", res4.Status)
+	body, _ := ioutil.ReadAll(res4.Body)
+	assert.Contains(t, string(body), userCode)
+	assert.Contains(t, string(body), `value="approve"`)
+
+	res5, err := postForm("/auth/device", &url.Values{
+		"user_code":  {userCode},
+		"action":     {"approve"},
+		"csrf_token": {csrfToken},
+	})
+	assert.NoError(t, err)
+	res5.Body.Close()
+	assert.Equal(t, "200 OK", res5.Status)
+
+	res6, err := poll()
+	assert.NoError(t, err)
+	defer res6.Body.Close()
+	assert.Equal(t, "200 OK", res6.Status)
+	var response map[string]string
+	err = json.NewDecoder(res6.Body).Decode(&response)
+	assert.NoError(t, err)
+	assert.Equal(t, "files:read", response["scope"])
+	assertValidToken(t, response["access_token"], "access", clientID, "files:read")
+	assertValidToken(t, response["refresh_token"], "refresh", clientID, "files:read")
+
+	// The device code can be used only once
+	res7, err := poll()
+	assert.NoError(t, err)
+	assertJSONError(t, res7, "expired_token")
+}
+
+func TestIntrospectAndRevokeToken(t *testing.T) {
+	introspect := func(clientID, token string) map[string]interface{} {
+		res, err := postForm("/auth/introspect", &url.Values{
+			"client_id": {clientID},
+			"token":     {token},
+		})
+		assert.NoError(t, err)
+		defer res.Body.Close()
+		assert.Equal(t, "200 OK", res.Status)
+		var response map[string]interface{}
+		err = json.NewDecoder(res.Body).Decode(&response)
+		assert.NoError(t, err)
+		return response
+	}
+
+	response := introspect(publicClientID, publicRefreshToken)
+	assert.Equal(t, true, response["active"])
+	assert.Equal(t, "files:read", response["scope"])
+	assert.Equal(t, publicClientID, response["client_id"])
+
+	// A confidential client must be authenticated
+	res, err := postForm("/auth/introspect", &url.Values{
+		"client_id": {clientID},
+		"token":     {publicRefreshToken},
+	})
+	assert.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, "401 Unauthorized", res.Status)
+
+	res, err = postForm("/auth/revoke", &url.Values{
+		"client_id": {publicClientID},
+		"token":     {publicRefreshToken},
+	})
+	assert.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, "200 OK", res.Status)
+
+	response = introspect(publicClientID, publicRefreshToken)
+	assert.Equal(t, false, response["active"])
+
+	res, err = postForm("/auth/access_token", &url.Values{
+		"grant_type":    {"refresh_token"},
+		"client_id":     {publicClientID},
+		"refresh_token": {publicRefreshToken},
+	})
+	assert.NoError(t, err)
+	assertJSONError(t, res, "invalid refresh token")
+}
+
+func TestOpenIDConnect(t *testing.T) {
+	req, _ := http.NewRequest("GET", ts.URL+"/.well-known/openid-configuration", nil)
+	req.Host = domain
+	res, err := client.Do(req)
+	assert.NoError(t, err)
+	defer res.Body.Close()
+	assert.Equal(t, "200 OK", res.Status)
+	var discovery map[string]interface{}
+	err = json.NewDecoder(res.Body).Decode(&discovery)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://"+domain, discovery["issuer"])
+	assert.Equal(t, "https://"+domain+"/auth/userinfo", discovery["userinfo_endpoint"])
+
+	req, _ = http.NewRequest("GET", ts.URL+"/.well-known/jwks.json", nil)
+	req.Host = domain
+	res2, err := client.Do(req)
+	assert.NoError(t, err)
+	defer res2.Body.Close()
+	assert.Equal(t, "200 OK", res2.Status)
+	var jwks struct {
+		Keys []struct {
+			Kid string `json:"kid"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	err = json.NewDecoder(res2.Body).Decode(&jwks)
+	assert.NoError(t, err)
+	if !assert.Len(t, jwks.Keys, 1) {
+		return
+	}
+	n, _ := base64.RawURLEncoding.DecodeString(jwks.Keys[0].N)
+	e, _ := base64.RawURLEncoding.DecodeString(jwks.Keys[0].E)
+	publicKey := &rsa.PublicKey{
+		N: new(big.Int).SetBytes(n),
+		E: int(new(big.Int).SetBytes(e).Int64()),
+	}
+
+	res3, err := postForm("/auth/authorize", &url.Values{
+		"state":         {"123456"},
+		"client_id":     {clientID},
+		"redirect_uri":  {"https://example.org/oauth/callback"},
+		"scope":         {"openid profile files:read"},
+		"nonce":         {"n-0S6_WzA2Mj"},
+		"csrf_token":    {csrfToken},
+		"response_type": {"code"},
+	})
+	assert.NoError(t, err)
+	res3.Body.Close()
+	if !assert.Equal(t, "302 Found", res3.Status) {
+		return
+	}
+	location, err := url.Parse(res3.Header.Get("Location"))
+	assert.NoError(t, err)
+
+	res4, err := postForm("/auth/access_token", &url.Values{
+		"grant_type":    {"authorization_code"},
+		"client_id":     {clientID},
+		"client_secret": {clientSecret},
+		"code":          {location.Query().Get("code")},
+	})
+	assert.NoError(t, err)
+	defer res4.Body.Close()
+	assert.Equal(t, "200 OK", res4.Status)
+	var response map[string]string
+	err = json.NewDecoder(res4.Body).Decode(&response)
+	assert.NoError(t, err)
+	assert.Equal(t, "openid profile files:read", response["scope"])
+
+	claims := jwt.MapClaims{}
+	token, err := jwt.ParseWithClaims(response["id_token"], claims, func(token *jwt.Token) (interface{}, error) {
+		assert.Equal(t, "RS256", token.Header["alg"])
+		assert.Equal(t, jwks.Keys[0].Kid, token.Header["kid"])
+		return publicKey, nil
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.True(t, token.Valid)
+	assert.Equal(t, "https://"+domain, claims["iss"])
+	assert.Equal(t, clientID, claims["aud"])
+	assert.Equal(t, testInstance.ID(), claims["sub"])
+	assert.Equal(t, "n-0S6_WzA2Mj", claims["nonce"])
+	assert.NotEmpty(t, claims["name"])
+
+	// The OpenID Connect scopes don't prevent to use the permissions
+	req, _ = http.NewRequest("GET", ts.URL+"/auth/userinfo", nil)
+	req.Host = domain
+	req.Header.Add("Authorization", "Bearer "+response["access_token"])
+	res5, err := client.Do(req)
+	assert.NoError(t, err)
+	defer res5.Body.Close()
+	assert.Equal(t, "200 OK", res5.Status)
+	var info map[string]interface{}
+	err = json.NewDecoder(res5.Body).Decode(&info)
+	assert.NoError(t, err)
+	assert.Equal(t, testInstance.ID(), info["sub"])
+	assert.Equal(t, claims["name"], info["name"])
+	assert.Nil(t, info["email"])
+
+	// Without the openid scope, the userinfo endpoint is forbidden
+	accessToken, err := testInstance.MakeJWT(consts.AccessTokenAudience, clientID, "files:read", "", time.Now())
+	assert.NoError(t, err)
+	req, _ = http.NewRequest("GET", ts.URL+"/auth/userinfo", nil)
+	req.Host = domain
+	req.Header.Add("Authorization", "Bearer "+accessToken)
+	res6, err := client.Do(req)
+	assert.NoError(t, err)
+	res6.Body.Close()
+	assert.Equal(t, "403 Forbidden", res6.Status)
+}
+
 func TestAppRedirectionOnLogin(t *testing.T) {
 	req, _ := http.NewRequest("GET", ts.URL+"/auth/login?redirect=drive/%23/foobar", nil)
 	req.Host = domain
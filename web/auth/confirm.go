@@ -7,6 +7,7 @@ import (
 	"github.com/cozy/cozy-stack/model/bitwarden/settings"
 	"github.com/cozy/cozy-stack/model/instance"
 	"github.com/cozy/cozy-stack/model/instance/lifecycle"
+	"github.com/cozy/cozy-stack/model/session"
 	"github.com/cozy/cozy-stack/pkg/consts"
 	"github.com/cozy/cozy-stack/pkg/couchdb"
 	"github.com/cozy/cozy-stack/pkg/limits"
@@ -56,11 +57,17 @@ func confirmAuth(c echo.Context) error {
 
 	// Check passphrase
 	passphrase := []byte(c.FormValue("passphrase"))
+	if errorMessage := loginAttemptError(c, inst); errorMessage != "" {
+		return c.JSON(http.StatusTooManyRequests, echo.Map{
+			"error": errorMessage,
+		})
+	}
 	if lifecycle.CheckPassphrase(inst, passphrase) != nil {
 		errorMessage := inst.Translate(CredentialsErrorKey)
+		session.LoginFailed(inst, c.RealIP())
 		err := limits.CheckRateLimit(inst, limits.AuthType)
 		if limits.IsLimitReachedOrExceeded(err) {
-			if err = LoginRateExceeded(inst); err != nil {
+			if err = session.LoginRateExceeded(inst); err != nil {
 				inst.Logger().WithNamespace("auth").Warn(err.Error())
 			}
 		}
@@ -68,9 +75,10 @@ func confirmAuth(c echo.Context) error {
 			"error": errorMessage,
 		})
 	}
+	limits.LoginSucceeded(inst.Domain, c.RealIP())
 
-	if inst.HasAuthMode(instance.TwoFactorMail) && !isTrustedDevice(c, inst) {
-		twoFactorToken, err := lifecycle.SendTwoFactorPasscode(inst)
+	if inst.HasTwoFactor() && !isTrustedDevice(c, inst) {
+		twoFactorToken, err := lifecycle.StartTwoFactor(inst)
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,141 @@
+package auth
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/cozy/cozy-stack/model/instance"
+	"github.com/cozy/cozy-stack/model/oauth"
+	"github.com/cozy/cozy-stack/model/permission"
+	"github.com/cozy/cozy-stack/web/middlewares"
+	"github.com/labstack/echo/v4"
+)
+
+// deviceAuthorization is the endpoint where a device asks for a device code
+// and a user code.
+// See https://tools.ietf.org/html/rfc8628#section-3.1
+func deviceAuthorization(c echo.Context) error {
+	inst := middlewares.GetInstance(c)
+	client, err := authenticateClient(c, inst)
+	if client == nil {
+		return err
+	}
+
+	scope := c.FormValue("scope")
+	if scope == "" || scope == oauth.ScopeLogin {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"error": "invalid_scope",
+		})
+	}
+	if _, err := permission.UnmarshalScopeString(scope); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"error": "invalid_scope",
+		})
+	}
+
+	deviceCode, err := oauth.CreateDeviceCode(inst, client, scope)
+	if err != nil {
+		return err
+	}
+	verificationURI := inst.PageURL("/auth/device", nil)
+	return c.JSON(http.StatusOK, echo.Map{
+		"device_code":               deviceCode.DeviceCode,
+		"user_code":                 deviceCode.UserCode,
+		"verification_uri":          verificationURI,
+		"verification_uri_complete": inst.PageURL("/auth/device", url.Values{"user_code": {deviceCode.UserCode}}),
+		"expires_in":                int(oauth.DeviceCodeTTL.Seconds()),
+		"interval":                  int(oauth.DeviceCodeInterval.Seconds()),
+	})
+}
+
+func renderDeviceForm(c echo.Context, inst *instance.Instance, code int, params echo.Map) error {
+	params["Domain"] = inst.ContextualDomain()
+	params["ContextName"] = inst.ContextName
+	params["Locale"] = inst.Locale
+	params["Title"] = inst.TemplateTitle()
+	params["Favicon"] = middlewares.Favicon(inst)
+	return c.Render(code, "authorize_device.html", params)
+}
+
+// deviceForm is the page where the user types the user code displayed by the
+// device, and then approves the permissions asked by the device.
+func deviceForm(c echo.Context) error {
+	inst := middlewares.GetInstance(c)
+	if !middlewares.IsLoggedIn(c) {
+		u := inst.PageURL("/auth/login", url.Values{
+			"redirect": {inst.FromURL(c.Request().URL)},
+		})
+		return c.Redirect(http.StatusSeeOther, u)
+	}
+
+	userCode := strings.TrimSpace(c.QueryParam("user_code"))
+	if userCode == "" {
+		return renderDeviceForm(c, inst, http.StatusOK, echo.Map{})
+	}
+
+	deviceCode, err := oauth.FindDeviceCodeByUserCode(inst, userCode)
+	if err != nil {
+		return renderDeviceForm(c, inst, http.StatusBadRequest, echo.Map{
+			"UserCode": userCode,
+			"Error":    inst.Translate("Authorize Device Invalid code"),
+		})
+	}
+	client, err := oauth.FindClient(inst, deviceCode.ClientID)
+	if err != nil {
+		return renderDeviceForm(c, inst, http.StatusBadRequest, echo.Map{
+			"UserCode": userCode,
+			"Error":    inst.Translate("Authorize Device Invalid code"),
+		})
+	}
+	permissions, err := permission.UnmarshalScopeString(deviceCode.Scope)
+	if err != nil {
+		return renderError(c, http.StatusBadRequest, "Error Invalid scope")
+	}
+
+	return renderDeviceForm(c, inst, http.StatusOK, echo.Map{
+		"UserCode":    deviceCode.UserCode,
+		"Client":      client,
+		"Permissions": permissions,
+		"CSRF":        c.Get("csrf"),
+	})
+}
+
+// authorizeDevice is called when the user has approved or denied the
+// permissions for the device.
+func authorizeDevice(c echo.Context) error {
+	inst := middlewares.GetInstance(c)
+	if !middlewares.IsLoggedIn(c) {
+		return renderError(c, http.StatusUnauthorized, "Error Must be authenticated")
+	}
+
+	deviceCode, err := oauth.FindDeviceCodeByUserCode(inst, c.FormValue("user_code"))
+	if err != nil {
+		return renderDeviceForm(c, inst, http.StatusBadRequest, echo.Map{
+			"UserCode": c.FormValue("user_code"),
+			"Error":    inst.Translate("Authorize Device Invalid code"),
+		})
+	}
+
+	if c.FormValue("action") != "approve" {
+		if err := deviceCode.Deny(inst); err != nil {
+			return err
+		}
+		return renderDeviceForm(c, inst, http.StatusOK, echo.Map{
+			"Done": inst.Translate("Authorize Device Denied"),
+		})
+	}
+
+	client, err := oauth.FindClient(inst, deviceCode.ClientID)
+	if err != nil {
+		return renderError(c, http.StatusBadRequest, "Error No registered client")
+	}
+	if err := deviceCode.Approve(inst, client); err != nil {
+		return err
+	}
+	inst.Logger().WithNamespace("loginaudit").
+		Infof("Device code approved for %s with scope %s", client.CouchID, deviceCode.Scope)
+	return renderDeviceForm(c, inst, http.StatusOK, echo.Map{
+		"Done": inst.Translate("Authorize Device Approved"),
+	})
+}
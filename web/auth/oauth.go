@@ -36,14 +36,17 @@ type webappParams struct {
 }
 
 type authorizeParams struct {
-	instance    *instance.Instance
-	state       string
-	clientID    string
-	redirectURI string
-	scope       string
-	resType     string
-	client      *oauth.Client
-	webapp      *webappParams
+	instance        *instance.Instance
+	state           string
+	clientID        string
+	redirectURI     string
+	scope           string
+	resType         string
+	challenge       string
+	challengeMethod string
+	nonce           string
+	client          *oauth.Client
+	webapp          *webappParams
 }
 
 func checkAuthorizeParams(c echo.Context, params *authorizeParams) (bool, error) {
@@ -68,6 +71,12 @@ func checkAuthorizeParams(c echo.Context, params *authorizeParams) (bool, error)
 	if !params.client.AcceptRedirectURI(params.redirectURI) {
 		return true, renderError(c, http.StatusBadRequest, "Error Incorrect redirect_uri")
 	}
+	if err := oauth.CheckCodeChallenge(params.challenge, params.challengeMethod); err != nil {
+		return true, renderError(c, http.StatusBadRequest, "Error Invalid code_challenge")
+	}
+	if params.challenge == "" && params.client.IsPublic() {
+		return true, renderError(c, http.StatusBadRequest, "Error No code_challenge parameter")
+	}
 
 	if appSlug := oauth.GetLinkedAppSlug(params.client.SoftwareID); appSlug != "" {
 		webapp, err := registry.GetLatestVersion(appSlug, "stable", params.instance.Registries())
@@ -110,12 +119,15 @@ func checkAuthorizeParams(c echo.Context, params *authorizeParams) (bool, error)
 func authorizeForm(c echo.Context) error {
 	instance := middlewares.GetInstance(c)
 	params := authorizeParams{
-		instance:    instance,
-		state:       c.QueryParam("state"),
-		clientID:    c.QueryParam("client_id"),
-		redirectURI: c.QueryParam("redirect_uri"),
-		scope:       c.QueryParam("scope"),
-		resType:     c.QueryParam("response_type"),
+		instance:        instance,
+		state:           c.QueryParam("state"),
+		clientID:        c.QueryParam("client_id"),
+		redirectURI:     c.QueryParam("redirect_uri"),
+		scope:           c.QueryParam("scope"),
+		resType:         c.QueryParam("response_type"),
+		challenge:       c.QueryParam("code_challenge"),
+		challengeMethod: c.QueryParam("code_challenge_method"),
+		nonce:           c.QueryParam("nonce"),
 	}
 
 	if hasError, err := checkAuthorizeParams(c, &params); hasError {
@@ -133,7 +145,7 @@ func authorizeForm(c echo.Context) error {
 	// for the manager. It does not require any authorization from the user, and
 	// generate a code without asking any permission.
 	if params.scope == oauth.ScopeLogin {
-		access, err := oauth.CreateAccessCode(params.instance, params.client, "" /* = scope */)
+		access, err := oauth.CreateAccessCode(params.instance, params.client, "" /* = scope */, params.challenge, "")
 		if err != nil {
 			return err
 		}
@@ -155,9 +167,16 @@ func authorizeForm(c echo.Context) error {
 		return c.Redirect(http.StatusFound, u.String()+"#")
 	}
 
-	permissions, err := permission.UnmarshalScopeString(params.scope)
-	if err != nil {
-		return renderError(c, http.StatusBadRequest, "Error Invalid scope")
+	// The OpenID Connect scopes are not permissions on doctypes, they are
+	// displayed separately on the consent screen.
+	identity, perms := oauth.SplitScope(params.scope)
+	var permissions permission.Set
+	if perms != "" {
+		var err error
+		permissions, err = permission.UnmarshalScopeString(perms)
+		if err != nil {
+			return renderError(c, http.StatusBadRequest, "Error Invalid scope")
+		}
 	}
 	readOnly := true
 	for _, p := range permissions {
@@ -204,6 +223,10 @@ func authorizeForm(c echo.Context) error {
 		"State":            params.state,
 		"RedirectURI":      params.redirectURI,
 		"Scope":            params.scope,
+		"Challenge":        params.challenge,
+		"ChallengeMethod":  params.challengeMethod,
+		"Nonce":            params.nonce,
+		"Identity":         identity,
 		"Permissions":      permissions,
 		"ReadOnly":         readOnly,
 		"CSRF":             c.Get("csrf"),
@@ -215,12 +238,15 @@ func authorizeForm(c echo.Context) error {
 func authorize(c echo.Context) error {
 	instance := middlewares.GetInstance(c)
 	params := authorizeParams{
-		instance:    instance,
-		state:       c.FormValue("state"),
-		clientID:    c.FormValue("client_id"),
-		redirectURI: c.FormValue("redirect_uri"),
-		scope:       c.FormValue("scope"),
-		resType:     c.FormValue("response_type"),
+		instance:        instance,
+		state:           c.FormValue("state"),
+		clientID:        c.FormValue("client_id"),
+		redirectURI:     c.FormValue("redirect_uri"),
+		scope:           c.FormValue("scope"),
+		resType:         c.FormValue("response_type"),
+		challenge:       c.FormValue("code_challenge"),
+		challengeMethod: c.FormValue("code_challenge_method"),
+		nonce:           c.FormValue("nonce"),
 	}
 
 	if hasError, err := checkAuthorizeParams(c, &params); hasError {
@@ -269,7 +295,7 @@ func authorize(c echo.Context) error {
 		}
 	}
 
-	access, err := oauth.CreateAccessCode(params.instance, params.client, params.scope)
+	access, err := oauth.CreateAccessCode(params.instance, params.client, params.scope, params.challenge, params.nonce)
 	if err != nil {
 		return err
 	}
@@ -554,11 +580,17 @@ func authorizeMove(c echo.Context) error {
 
 	// Check passphrase
 	passphrase := []byte(c.FormValue("passphrase"))
+	if errorMessage := loginAttemptError(c, inst); errorMessage != "" {
+		return c.JSON(http.StatusTooManyRequests, echo.Map{
+			"error": errorMessage,
+		})
+	}
 	if lifecycle.CheckPassphrase(inst, passphrase) != nil {
 		errorMessage := inst.Translate(CredentialsErrorKey)
+		session.LoginFailed(inst, c.RealIP())
 		err := limits.CheckRateLimit(inst, limits.AuthType)
 		if limits.IsLimitReachedOrExceeded(err) {
-			if err = LoginRateExceeded(inst); err != nil {
+			if err = session.LoginRateExceeded(inst); err != nil {
 				inst.Logger().WithNamespace("auth").Warn(err.Error())
 			}
 		}
@@ -566,9 +598,10 @@ func authorizeMove(c echo.Context) error {
 			"error": errorMessage,
 		})
 	}
+	limits.LoginSucceeded(inst.Domain, c.RealIP())
 
-	if inst.HasAuthMode(instance.TwoFactorMail) && !isTrustedDevice(c, inst) {
-		twoFactorToken, err := lifecycle.SendTwoFactorPasscode(inst)
+	if inst.HasTwoFactor() && !isTrustedDevice(c, inst) {
+		twoFactorToken, err := lifecycle.StartTwoFactor(inst)
 		if err != nil {
 			return err
 		}
@@ -610,7 +643,7 @@ func moveSuccessURI(c echo.Context) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	access, err := oauth.CreateAccessCode(inst, client, move.MoveScope)
+	access, err := oauth.CreateAccessCode(inst, client, move.MoveScope, "", "")
 	if err != nil {
 		return "", err
 	}
@@ -653,12 +686,56 @@ type AccessTokenReponse struct {
 	Scope   string `json:"scope"`
 	Access  string `json:"access_token"`
 	Refresh string `json:"refresh_token,omitempty"`
+	IDToken string `json:"id_token,omitempty"`
 }
 
-func accessToken(c echo.Context) error {
-	grant := c.FormValue("grant_type")
+// clientCredentials returns the client_id and client_secret sent by the
+// client, in the body of the request or via HTTP Basic authentication.
+func clientCredentials(c echo.Context) (string, string) {
 	clientID := c.FormValue("client_id")
 	clientSecret := c.FormValue("client_secret")
+	if id, secret, ok := c.Request().BasicAuth(); ok {
+		if clientID == "" {
+			clientID, _ = url.QueryUnescape(id)
+		}
+		if clientSecret == "" {
+			clientSecret, _ = url.QueryUnescape(secret)
+		}
+	}
+	return clientID, clientSecret
+}
+
+// authenticateClient checks the credentials of the client for the
+// introspection and revocation endpoints. The public clients can be
+// authenticated with just their client_id.
+func authenticateClient(c echo.Context, inst *instance.Instance) (*oauth.Client, error) {
+	clientID, clientSecret := clientCredentials(c)
+	if clientID == "" {
+		return nil, c.JSON(http.StatusUnauthorized, echo.Map{
+			"error": "invalid_client",
+		})
+	}
+	client, err := oauth.FindClient(inst, clientID)
+	if err != nil {
+		if couchErr, isCouchErr := couchdb.IsCouchError(err); isCouchErr && couchErr.StatusCode >= 500 {
+			return nil, err
+		}
+		return nil, c.JSON(http.StatusUnauthorized, echo.Map{
+			"error": "invalid_client",
+		})
+	}
+	if !client.IsPublic() &&
+		subtle.ConstantTimeCompare([]byte(clientSecret), []byte(client.ClientSecret)) == 0 {
+		return nil, c.JSON(http.StatusUnauthorized, echo.Map{
+			"error": "invalid_client",
+		})
+	}
+	return client, nil
+}
+
+func accessToken(c echo.Context) error {
+	grant := c.FormValue("grant_type")
+	clientID, clientSecret := clientCredentials(c)
 	instance := middlewares.GetInstance(c)
 
 	if grant == "" {
@@ -671,11 +748,6 @@ func accessToken(c echo.Context) error {
 			"error": "the client_id parameter is mandatory",
 		})
 	}
-	if clientSecret == "" {
-		return c.JSON(http.StatusBadRequest, echo.Map{
-			"error": "the client_secret parameter is mandatory",
-		})
-	}
 
 	client, err := oauth.FindClient(instance, clientID)
 	if err != nil {
@@ -686,14 +758,23 @@ func accessToken(c echo.Context) error {
 			"error": "the client must be registered",
 		})
 	}
-	if subtle.ConstantTimeCompare([]byte(clientSecret), []byte(client.ClientSecret)) == 0 {
-		return c.JSON(http.StatusBadRequest, echo.Map{
-			"error": "invalid client_secret",
-		})
+	// The public clients can't keep a secret, and they use PKCE instead
+	if !client.IsPublic() {
+		if clientSecret == "" {
+			return c.JSON(http.StatusBadRequest, echo.Map{
+				"error": "the client_secret parameter is mandatory",
+			})
+		}
+		if subtle.ConstantTimeCompare([]byte(clientSecret), []byte(client.ClientSecret)) == 0 {
+			return c.JSON(http.StatusBadRequest, echo.Map{
+				"error": "invalid client_secret",
+			})
+		}
 	}
 	out := AccessTokenReponse{
 		Type: "bearer",
 	}
+	var nonce string
 
 	slug := oauth.GetLinkedAppSlug(client.SoftwareID)
 	if slug != "" {
@@ -716,7 +797,18 @@ func accessToken(c echo.Context) error {
 				"error": "invalid code",
 			})
 		}
+		if client.IsPublic() && accessCode.Challenge == "" {
+			return c.JSON(http.StatusBadRequest, echo.Map{
+				"error": "invalid code",
+			})
+		}
+		if !accessCode.CheckVerifier(c.FormValue("code_verifier")) {
+			return c.JSON(http.StatusBadRequest, echo.Map{
+				"error": "invalid code_verifier",
+			})
+		}
 		out.Scope = accessCode.Scope
+		nonce = accessCode.Nonce
 		out.Refresh, err = client.CreateJWT(instance, consts.RefreshTokenAudience, out.Scope)
 		if err != nil {
 			return c.JSON(http.StatusInternalServerError, echo.Map{
@@ -749,6 +841,28 @@ func accessToken(c echo.Context) error {
 			out.Scope = claims.Scope
 		}
 
+	case oauth.DeviceCodeGrantType:
+		// The errors for this grant are described in
+		// https://tools.ietf.org/html/rfc8628#section-3.5
+		deviceCode, err := oauth.FindDeviceCode(instance, c.FormValue("device_code"))
+		if err != nil || deviceCode.ClientID != client.CouchID {
+			return c.JSON(http.StatusBadRequest, echo.Map{
+				"error": oauth.ErrDeviceCodeNotFound.Error(),
+			})
+		}
+		if err := deviceCode.Poll(instance); err != nil {
+			return c.JSON(http.StatusBadRequest, echo.Map{
+				"error": err.Error(),
+			})
+		}
+		out.Scope = deviceCode.Scope
+		out.Refresh, err = client.CreateJWT(instance, consts.RefreshTokenAudience, out.Scope)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, echo.Map{
+				"error": "Can't generate refresh token",
+			})
+		}
+
 	default:
 		return c.JSON(http.StatusBadRequest, echo.Map{
 			"error": "invalid grant type",
@@ -761,11 +875,80 @@ func accessToken(c echo.Context) error {
 			"error": "Can't generate access token",
 		})
 	}
+	if oauth.HasOpenIDScope(out.Scope) {
+		out.IDToken, err = client.CreateIDToken(instance, out.Scope, nonce)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, echo.Map{
+				"error": "Can't generate ID token",
+			})
+		}
+	}
 
 	_ = session.RemoveLoginRegistration(instance.ContextualDomain(), clientID)
 	return c.JSON(http.StatusOK, out)
 }
 
+// introspectToken returns the informations about an access or refresh token
+// of the authenticated client.
+// See https://tools.ietf.org/html/rfc7662
+func introspectToken(c echo.Context) error {
+	inst := middlewares.GetInstance(c)
+	client, err := authenticateClient(c, inst)
+	if client == nil {
+		return err
+	}
+
+	claims, ok := client.ParseToken(inst, c.FormValue("token"))
+	if !ok {
+		return c.JSON(http.StatusOK, echo.Map{"active": false})
+	}
+	res := echo.Map{
+		"active":     true,
+		"scope":      claims.Scope,
+		"client_id":  client.CouchID,
+		"token_type": "bearer",
+		"iat":        claims.IssuedAt,
+		"sub":        claims.Subject,
+		"aud":        claims.Audience,
+		"iss":        claims.Issuer,
+	}
+	// The refresh tokens don't expire
+	if claims.Audience == consts.AccessTokenAudience {
+		res["exp"] = claims.IssuedAtUTC().Add(consts.AccessTokenValidityDuration).Unix()
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+// revokeToken revokes the tokens of the authenticated client. As the tokens
+// are not persisted, revoking a token also revokes all the other tokens
+// issued for this client.
+// See https://tools.ietf.org/html/rfc7009
+func revokeToken(c echo.Context) error {
+	inst := middlewares.GetInstance(c)
+	client, err := authenticateClient(c, inst)
+	if client == nil {
+		return err
+	}
+
+	token := c.FormValue("token")
+	if token == "" {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"error": "invalid_request",
+		})
+	}
+
+	// An invalid token, or a token for another client, is not an error for
+	// the revocation endpoint.
+	if _, ok := client.ParseToken(inst, token); ok {
+		if err := client.Revoke(inst); err != nil {
+			return err
+		}
+		inst.Logger().WithNamespace("oauth").
+			Infof("Tokens revoked for the client %s", client.CouchID)
+	}
+	return c.NoContent(http.StatusOK)
+}
+
 // Used to trade a secret for OAuth client informations
 func secretExchange(c echo.Context) error {
 	type exchange struct {
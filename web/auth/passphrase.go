@@ -6,6 +6,7 @@ import (
 	"net/url"
 	"strconv"
 
+	"github.com/cozy/cozy-stack/model/audit"
 	"github.com/cozy/cozy-stack/model/bitwarden"
 	"github.com/cozy/cozy-stack/model/bitwarden/settings"
 	"github.com/cozy/cozy-stack/model/instance"
@@ -236,6 +237,12 @@ func passphraseRenew(c echo.Context) error {
 			"error": "invalid_token",
 		})
 	}
+	audit.Record(inst, &audit.Entry{
+		Action:  audit.ActionPassphraseChanged,
+		Actor:   "owner",
+		IP:      c.RealIP(),
+		Details: map[string]interface{}{"method": "reset"},
+	})
 	if err := bitwarden.DeleteUnrecoverableCiphers(inst); err != nil {
 		inst.Logger().WithNamespace("bitwarden").
 			Warnf("Error on ciphers deletion after password reset: %s", err)
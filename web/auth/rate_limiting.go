@@ -6,16 +6,9 @@ import (
 	"github.com/cozy/cozy-stack/model/instance"
 	"github.com/cozy/cozy-stack/model/instance/lifecycle"
 	"github.com/cozy/cozy-stack/pkg/limits"
+	"github.com/labstack/echo/v4"
 )
 
-// LoginRateExceeded blocks the instance after too many failed attempts to
-// login
-func LoginRateExceeded(i *instance.Instance) error {
-	err := fmt.Errorf("Instance was blocked because of too many login failed attempts")
-	i.Logger().WithNamespace("rate_limiting").Warn(err.Error())
-	return lifecycle.Block(i, instance.BlockedLoginFailed.Code)
-}
-
 // TwoFactorRateExceeded regenerates a new 2FA passcode after too many failed
 // attempts to login
 func TwoFactorRateExceeded(i *instance.Instance) error {
@@ -29,6 +22,21 @@ func TwoFactorRateExceeded(i *instance.Instance) error {
 	return err
 }
 
+// TwoFactorAttemptsExceeded counts an attempt to validate a second factor
+// that is not sent by mail (TOTP or security key), and returns true if there
+// were too many attempts recently.
+func TwoFactorAttemptsExceeded(i *instance.Instance) bool {
+	if !i.HasTwoFactor() || i.HasAuthMode(instance.TwoFactorMail) {
+		return false
+	}
+	err := limits.CheckRateLimit(i, limits.TwoFactorType)
+	if err == limits.ErrRateLimitReached {
+		i.Logger().WithNamespace("rate_limiting").
+			Warn("Too many attempts for the second factor")
+	}
+	return limits.IsLimitReachedOrExceeded(err)
+}
+
 // TwoFactorGenerationExceeded checks if there was too many attempts to
 // regenerate a 2FA code within an hour
 func TwoFactorGenerationExceeded(i *instance.Instance) error {
@@ -37,3 +45,16 @@ func TwoFactorGenerationExceeded(i *instance.Instance) error {
 
 	return lifecycle.Block(i, instance.BlockedLoginFailed.Code)
 }
+
+// loginAttemptError returns a translated error message if the login attempt
+// must be rejected by the protection against brute-force attacks, before even
+// checking the passphrase.
+func loginAttemptError(c echo.Context, i *instance.Instance) string {
+	switch limits.CheckLoginAttempt(i.Domain, c.RealIP()) {
+	case limits.ErrLoginBanned:
+		return i.Translate("Login Banned error")
+	case limits.ErrLoginDelayed:
+		return i.Translate("Login Delayed error")
+	}
+	return ""
+}
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/cozy/cozy-stack/model/audit"
 	"github.com/cozy/cozy-stack/model/oauth"
 	"github.com/cozy/cozy-stack/model/permission"
 	"github.com/cozy/cozy-stack/pkg/consts"
@@ -37,6 +38,16 @@ func registerClient(c echo.Context) error {
 	if err := client.Create(instance); err != nil {
 		return c.JSON(err.Code, err)
 	}
+	audit.Record(instance, &audit.Entry{
+		Action: audit.ActionOAuthClientRegistered,
+		IP:     c.RealIP(),
+		Target: consts.OAuthClients + "/" + client.ClientID,
+		Details: map[string]interface{}{
+			"client_name": client.ClientName,
+			"client_kind": client.ClientKind,
+			"software_id": client.SoftwareID,
+		},
+	})
 	return c.JSON(http.StatusCreated, client)
 }
 
@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"encoding/json"
 	"net/http"
 	"net/url"
 	"strconv"
@@ -40,6 +41,23 @@ func renderTwoFactorForm(c echo.Context, i *instance.Instance, code int, credsEr
 	oauth := i.HasDomain(redirect.Host) && redirect.Path == "/auth/authorize" && clientScope != oauth.ScopeLogin
 	trustedCheckbox := !oauth && trustedDeviceCheckBox
 
+	help := i.Translate("Login Two factor help")
+	field := i.Translate("Login Two factor field")
+	var webauthnOptions string
+	switch i.AuthMode {
+	case instance.TwoFactorTOTP:
+		help = i.Translate("Login Two factor TOTP help")
+		field = i.Translate("Login Two factor TOTP field")
+	case instance.TwoFactorWebAuthn:
+		help = i.Translate("Login Two factor WebAuthn help")
+		field = i.Translate("Login Two factor recovery field")
+		if options, err := i.WebAuthnAssertionOptions(twoFactorToken); err == nil {
+			if encoded, err := json.Marshal(options); err == nil {
+				webauthnOptions = string(encoded)
+			}
+		}
+	}
+
 	return c.Render(code, "twofactor.html", echo.Map{
 		"Domain":                i.ContextualDomain(),
 		"ContextName":           i.ContextName,
@@ -54,6 +72,10 @@ func renderTwoFactorForm(c echo.Context, i *instance.Instance, code int, credsEr
 		"LongRunSession":        longRunSession,
 		"TwoFactorToken":        string(twoFactorToken),
 		"TrustedDeviceCheckBox": trustedCheckbox,
+		"TwoFactorMode":         instance.AuthModeToString(i.AuthMode),
+		"TwoFactorHelp":         help,
+		"TwoFactorField":        field,
+		"WebAuthnOptions":       webauthnOptions,
 	})
 }
 
@@ -97,8 +119,14 @@ func twoFactor(c echo.Context) error {
 	passcode := c.FormValue("two-factor-passcode")
 	generateTrustedDeviceToken, _ := strconv.ParseBool(c.FormValue("two-factor-generate-trusted-device-token"))
 
+	// The attempts with TOTP or a security key are counted before the
+	// validation, as a new passcode cannot be sent when the limit is reached
+	if TwoFactorAttemptsExceeded(inst) {
+		return twoFactorRefused(c, inst, token, inst.Translate(TwoFactorTooManyAttemptsKey))
+	}
+
 	// Handle 2FA failed
-	correctPasscode := inst.ValidateTwoFactorPasscode(token, passcode)
+	correctPasscode := inst.ValidateTwoFactor(token, passcode)
 	if !correctPasscode {
 		return twoFactorFailed(c, inst, token)
 	}
@@ -153,14 +181,20 @@ func twoFactor(c echo.Context) error {
 // twoFactorFailed returns the 2FA form with an error message
 func twoFactorFailed(c echo.Context, inst *instance.Instance, token []byte) error {
 	errorMessage := inst.Translate(TwoFactorErrorKey)
-	errCheckRateLimit := limits.CheckRateLimit(inst, limits.TwoFactorType)
-	if errCheckRateLimit == limits.ErrRateLimitExceeded {
-		if err := TwoFactorRateExceeded(inst); err != nil {
-			inst.Logger().WithNamespace("auth").Warn(err.Error())
-			errorMessage = inst.Translate(TwoFactorExceededErrorKey)
+	if inst.HasAuthMode(instance.TwoFactorMail) {
+		errCheckRateLimit := limits.CheckRateLimit(inst, limits.TwoFactorType)
+		if errCheckRateLimit == limits.ErrRateLimitExceeded {
+			if err := TwoFactorRateExceeded(inst); err != nil {
+				inst.Logger().WithNamespace("auth").Warn(err.Error())
+				errorMessage = inst.Translate(TwoFactorExceededErrorKey)
+			}
 		}
 	}
+	return twoFactorRefused(c, inst, token, errorMessage)
+}
 
+// twoFactorRefused returns the 2FA form with the given error message
+func twoFactorRefused(c echo.Context, inst *instance.Instance, token []byte, errorMessage string) error {
 	// Render either the passcode page or a JSON message
 	if wantsJSON(c) {
 		return c.JSON(http.StatusUnauthorized, echo.Map{
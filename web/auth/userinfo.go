@@ -0,0 +1,40 @@
+package auth
+
+import (
+	"net/http"
+
+	"github.com/cozy/cozy-stack/model/oauth"
+	"github.com/cozy/cozy-stack/model/permission"
+	"github.com/cozy/cozy-stack/web/middlewares"
+	"github.com/labstack/echo/v4"
+)
+
+// userinfo returns the claims about the owner of the instance, for an OAuth
+// client that has been granted the openid scope.
+// See https://openid.net/specs/openid-connect-core-1_0.html#UserInfo
+func userinfo(c echo.Context) error {
+	inst := middlewares.GetInstance(c)
+	pdoc, err := middlewares.GetPermission(c)
+	if err != nil || pdoc.Type != permission.TypeOauth {
+		c.Response().Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"error": "invalid_token",
+		})
+	}
+	claims, ok := c.Get("claims").(permission.Claims)
+	if !ok || !oauth.HasOpenIDScope(claims.Scope) {
+		c.Response().Header().Set("WWW-Authenticate", `Bearer error="insufficient_scope"`)
+		return c.JSON(http.StatusForbidden, echo.Map{
+			"error": "insufficient_scope",
+		})
+	}
+
+	info, err := oauth.GetUserInfo(inst, claims.Scope)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, struct {
+		Subject string `json:"sub"`
+		*oauth.UserInfo
+	}{inst.ID(), info})
+}
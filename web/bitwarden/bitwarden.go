@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
@@ -19,6 +20,7 @@ import (
 	"github.com/cozy/cozy-stack/pkg/config/config"
 	"github.com/cozy/cozy-stack/pkg/consts"
 	"github.com/cozy/cozy-stack/pkg/couchdb"
+	"github.com/cozy/cozy-stack/pkg/limits"
 	"github.com/cozy/cozy-stack/web/middlewares"
 	"github.com/labstack/echo/v4"
 )
@@ -157,7 +159,12 @@ func ChangeSecurityStamp(c echo.Context) error {
 		})
 	}
 
-	if err := lifecycle.CheckPassphrase(inst, []byte(data.Hashed)); err != nil {
+	if err := session.CheckPassphrase(inst, c.RealIP(), []byte(data.Hashed)); err != nil {
+		if err == session.ErrTooManyAttempts {
+			return c.JSON(http.StatusTooManyRequests, echo.Map{
+				"error": err.Error(),
+			})
+		}
 		return c.JSON(http.StatusUnauthorized, echo.Map{
 			"error": "invalid masterPasswordHash",
 		})
@@ -236,13 +243,18 @@ func getInitialCredentials(c echo.Context) error {
 	pass := []byte(c.FormValue("password"))
 
 	// Authentication
-	if err := lifecycle.CheckPassphrase(inst, pass); err != nil {
+	if err := session.CheckPassphrase(inst, c.RealIP(), pass); err != nil {
+		if err == session.ErrTooManyAttempts {
+			return c.JSON(http.StatusTooManyRequests, echo.Map{
+				"error": err.Error(),
+			})
+		}
 		return c.JSON(http.StatusUnauthorized, echo.Map{
 			"error": "invalid password",
 		})
 	}
 
-	if inst.HasAuthMode(instance.TwoFactorMail) {
+	if inst.HasTwoFactor() {
 		if !checkTwoFactor(c, inst) {
 			return nil
 		}
@@ -348,7 +360,17 @@ func checkTwoFactor(c echo.Context, inst *instance.Instance) bool {
 
 	if passcode := c.FormValue("twoFactorToken"); passcode != "" {
 		if token, ok := cache.Get(key); ok {
-			if inst.ValidateTwoFactorPasscode(token, passcode) {
+			if !inst.HasAuthMode(instance.TwoFactorMail) {
+				err := limits.CheckRateLimit(inst, limits.TwoFactorType)
+				if limits.IsLimitReachedOrExceeded(err) {
+					_ = c.JSON(http.StatusTooManyRequests, echo.Map{
+						"error":             "invalid_grant",
+						"error_description": "Too many attempts for the second factor",
+					})
+					return false
+				}
+			}
+			if inst.ValidateBitwardenTwoFactor(token, passcode) {
 				return true
 			}
 		}
@@ -361,37 +383,61 @@ func checkTwoFactor(c echo.Context, inst *instance.Instance) bool {
 		return true
 	}
 
-	email, err := inst.SettingsEMail()
+	token, err := lifecycle.StartTwoFactor(inst)
 	if err != nil {
 		_ = c.JSON(http.StatusInternalServerError, echo.Map{
 			"error": err.Error(),
 		})
 		return false
 	}
-	var obscured string
-	if parts := strings.SplitN(email, "@", 2); len(parts) == 2 {
-		s := strings.Map(func(_ rune) rune { return '*' }, parts[0])
-		obscured = s + "@" + parts[1]
-	}
+	cache.Set(key, token, 5*time.Minute)
 
-	token, err := lifecycle.SendTwoFactorPasscode(inst)
-	if err != nil {
-		_ = c.JSON(http.StatusInternalServerError, echo.Map{
-			"error": err.Error(),
-		})
-		return false
+	// The providers are numbered like in bitwarden:
+	// https://github.com/bitwarden/jslib/blob/master/common/src/enums/twoFactorProviderType.ts
+	var providers map[string]interface{}
+	switch inst.AuthMode {
+	case instance.TwoFactorTOTP:
+		// 0 means authenticator
+		providers = map[string]interface{}{"0": nil}
+	case instance.TwoFactorWebAuthn:
+		// 7 means WebAuthn
+		options, err := inst.WebAuthnAssertionOptions(token)
+		if err != nil {
+			_ = c.JSON(http.StatusInternalServerError, echo.Map{
+				"error": err.Error(),
+			})
+			return false
+		}
+		providers = map[string]interface{}{"7": options}
+	default:
+		email, err := inst.SettingsEMail()
+		if err != nil {
+			_ = c.JSON(http.StatusInternalServerError, echo.Map{
+				"error": err.Error(),
+			})
+			return false
+		}
+		var obscured string
+		if parts := strings.SplitN(email, "@", 2); len(parts) == 2 {
+			s := strings.Map(func(_ rune) rune { return '*' }, parts[0])
+			obscured = s + "@" + parts[1]
+		}
+		// 1 means email
+		providers = map[string]interface{}{
+			"1": map[string]string{"Email": obscured},
+		}
+	}
+	types := make([]int, 0, len(providers))
+	for k := range providers {
+		n, _ := strconv.Atoi(k)
+		types = append(types, n)
 	}
-	cache.Set(key, token, 5*time.Minute)
 
 	_ = c.JSON(http.StatusBadRequest, echo.Map{
-		"error":             "invalid_grant",
-		"error_description": "Two factor required.",
-		// 1 means email
-		// https://github.com/bitwarden/jslib/blob/master/common/src/enums/twoFactorProviderType.ts
-		"TwoFactorProviders": []int{1},
-		"TwoFactorProviders2": map[string]map[string]string{
-			"1": {"Email": obscured},
-		},
+		"error":               "invalid_grant",
+		"error_description":   "Two factor required.",
+		"TwoFactorProviders":  types,
+		"TwoFactorProviders2": providers,
 	})
 	return false
 }
@@ -10,8 +10,8 @@ import (
 	"github.com/cozy/cozy-stack/model/bitwarden/settings"
 	"github.com/cozy/cozy-stack/model/contact"
 	"github.com/cozy/cozy-stack/model/instance"
-	"github.com/cozy/cozy-stack/model/instance/lifecycle"
 	"github.com/cozy/cozy-stack/model/permission"
+	"github.com/cozy/cozy-stack/model/session"
 	"github.com/cozy/cozy-stack/pkg/consts"
 	"github.com/cozy/cozy-stack/pkg/couchdb"
 	"github.com/cozy/cozy-stack/pkg/metadata"
@@ -284,7 +284,12 @@ func DeleteOrganization(c echo.Context) error {
 			"error": "invalid JSON",
 		})
 	}
-	if err := lifecycle.CheckPassphrase(inst, []byte(verification.Hash)); err != nil {
+	if err := session.CheckPassphrase(inst, c.RealIP(), []byte(verification.Hash)); err != nil {
+		if err == session.ErrTooManyAttempts {
+			return c.JSON(http.StatusTooManyRequests, echo.Map{
+				"error": err.Error(),
+			})
+		}
 		return c.JSON(http.StatusUnauthorized, echo.Map{
 			"error": "invalid password",
 		})
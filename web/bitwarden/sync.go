@@ -53,7 +53,7 @@ func newProfileResponse(inst *instance.Instance, setting *settings.Settings) (*p
 		Premium:       true,
 		Hint:          nil,
 		Culture:       inst.Locale,
-		TwoFactor:     false,
+		TwoFactor:     inst.HasTwoFactor(),
 		Key:           setting.Key,
 		SStamp:        setting.SecurityStamp,
 		Organizations: organizations,
@@ -3,9 +3,12 @@ package data
 import (
 	"encoding/json"
 	"net/http"
+	"reflect"
 	"strings"
+	"time"
 
 	"github.com/cozy/cozy-stack/model/account"
+	"github.com/cozy/cozy-stack/model/instance"
 	"github.com/cozy/cozy-stack/model/permission"
 	"github.com/cozy/cozy-stack/pkg/config/config"
 	"github.com/cozy/cozy-stack/pkg/consts"
@@ -58,6 +61,7 @@ func getAccount(c echo.Context) error {
 		(c.QueryParam("include") == "credentials" && perm.Type == permission.TypeWebapp) {
 		// The account decryption is allowed for konnectors or for apps services
 		DecryptAccount(out)
+		replaceTOTPSeed(out)
 	}
 
 	return c.JSON(http.StatusOK, out.ToMapWithType())
@@ -110,6 +114,12 @@ func updateAccount(c echo.Context) error {
 		}
 	}
 
+	if err := keepTOTPSeed(instance, doc); err != nil {
+		return err
+	}
+	if err := clearUserActionOnNewAuth(instance, doc); err != nil {
+		return err
+	}
 	EncryptAccount(doc)
 
 	errUpdate := couchdb.UpdateDoc(instance, &doc)
@@ -123,6 +133,7 @@ func updateAccount(c echo.Context) error {
 	}
 	if perm.Type == permission.TypeKonnector {
 		DecryptAccount(doc)
+		replaceTOTPSeed(doc)
 	}
 
 	return c.JSON(http.StatusOK, echo.Map{
@@ -152,6 +163,78 @@ func DecryptAccount(doc couchdb.JSONDoc) bool {
 	return false
 }
 
+// replaceTOTPSeed replaces the TOTP seed of the two-factor authentication of
+// the account by the current one-time password: the seed is never sent back
+// by the stack, and the code changes each time the account is fetched.
+func replaceTOTPSeed(doc couchdb.JSONDoc) {
+	auth, ok := doc.M["auth"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	seed, ok := auth["totp_seed"].(string)
+	if !ok {
+		return
+	}
+	delete(auth, "totp_seed")
+	if code, err := account.TOTPCode(seed, time.Now()); err == nil {
+		auth["totp_code"] = code
+	}
+}
+
+// keepTOTPSeed ensures that the TOTP seed is not lost when a konnector updates
+// the account, as it has only received the one-time password.
+func keepTOTPSeed(inst *instance.Instance, doc couchdb.JSONDoc) error {
+	auth, ok := doc.M["auth"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	if _, ok := auth["totp_code"]; !ok {
+		return nil
+	}
+	delete(auth, "totp_code")
+	var old couchdb.JSONDoc
+	if err := couchdb.GetDoc(inst, consts.Accounts, doc.ID(), &old); err != nil {
+		return err
+	}
+	oldAuth, _ := old.M["auth"].(map[string]interface{})
+	for _, k := range []string{"totp_seed", "totp_seed_encrypted"} {
+		if _, ok := auth[k]; ok {
+			continue
+		}
+		if v, ok := oldAuth[k]; ok {
+			auth[k] = v
+		}
+	}
+	return nil
+}
+
+// clearUserActionOnNewAuth removes the mark of the account that needed an
+// action of the user when the auth of the account is changed, as the
+// konnector can try again with the new credentials.
+func clearUserActionOnNewAuth(inst *instance.Instance, doc couchdb.JSONDoc) error {
+	if _, ok := doc.M["user_action"]; !ok {
+		return nil
+	}
+	var old couchdb.JSONDoc
+	if err := couchdb.GetDoc(inst, consts.Accounts, doc.ID(), &old); err != nil {
+		return err
+	}
+	if !reflect.DeepEqual(decryptedAuth(old), decryptedAuth(doc)) {
+		delete(doc.M, "user_action")
+	}
+	return nil
+}
+
+// decryptedAuth returns the auth of the account with the sensitive fields
+// decrypted, without modifying the document.
+func decryptedAuth(doc couchdb.JSONDoc) interface{} {
+	m := map[string]interface{}{"auth": doc.M["auth"]}
+	if config.GetVault().CredentialsDecryptorKey() != nil {
+		decryptMap(m)
+	}
+	return m["auth"]
+}
+
 func encryptMap(m map[string]interface{}) (encrypted bool) {
 	auth, ok := m["auth"].(map[string]interface{})
 	if !ok {
@@ -169,7 +252,7 @@ func encryptMap(m map[string]interface{}) (encrypted bool) {
 			if err == nil {
 				encrypted = true
 			}
-		case "secret", "dob", "code", "answer", "access_token", "refresh_token", "appSecret", "session":
+		case "secret", "dob", "code", "answer", "access_token", "refresh_token", "appSecret", "session", "totp_seed":
 			cloned[k+"_encrypted"], err = account.EncryptCredentialsData(v)
 			if err == nil {
 				encrypted = true
@@ -6,7 +6,9 @@ import (
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
 
+	"github.com/cozy/cozy-stack/model/app"
 	"github.com/cozy/cozy-stack/model/permission"
 	"github.com/cozy/cozy-stack/pkg/consts"
 	"github.com/cozy/cozy-stack/pkg/couchdb"
@@ -86,6 +88,9 @@ func getDoc(c echo.Context) error {
 	}
 
 	if paramIsTrue(c, "revs") {
+		if err := middlewares.AllowAllFields(c, permission.GET, doctype); err != nil {
+			return err
+		}
 		return proxy(c, docid)
 	}
 
@@ -101,7 +106,9 @@ func getDoc(c echo.Context) error {
 		return err
 	}
 
-	return c.JSON(http.StatusOK, out.ToMapWithType())
+	doc := out.ToMapWithType()
+	permission.StripFields(doc, middlewares.AllowedFields(c, permission.GET, doctype))
+	return c.JSON(http.StatusOK, doc)
 }
 
 // CreateDoc create doc from the json passed as body
@@ -127,6 +134,10 @@ func createDoc(c echo.Context) error {
 		return err
 	}
 
+	if err := checkAppDocsQuota(c, &doc); err != nil {
+		return err
+	}
+
 	if err := couchdb.CreateDoc(instance, &doc); err != nil {
 		return err
 	}
@@ -148,6 +159,10 @@ func createNamedDoc(c echo.Context, doc couchdb.JSONDoc) error {
 		return err
 	}
 
+	if err = checkAppDocsQuota(c, &doc); err != nil {
+		return err
+	}
+
 	err = couchdb.CreateNamedDocWithDB(instance, &doc)
 	if err != nil {
 		return fixErrorNoDatabaseIsWrongDoctype(err)
@@ -162,6 +177,72 @@ func createNamedDoc(c echo.Context, doc couchdb.JSONDoc) error {
 	})
 }
 
+// appSlugFromPermission returns the slug of the application (webapp or
+// konnector) that makes the request, or an empty string if the request is not
+// made with an application token.
+func appSlugFromPermission(c echo.Context) string {
+	pdoc, err := middlewares.GetPermission(c)
+	if err != nil {
+		return ""
+	}
+	switch pdoc.Type {
+	case permission.TypeWebapp:
+		return strings.TrimPrefix(pdoc.SourceID, consts.Apps+"/")
+	case permission.TypeKonnector:
+		return strings.TrimPrefix(pdoc.SourceID, consts.Konnectors+"/")
+	}
+	return ""
+}
+
+// checkAppDocsQuota checks that the application (webapp or konnector) that
+// makes the request can create a new document, according to its quota. The
+// document is attributed to the application, via the cozyMetadata.createdByApp
+// field, to count it in the quota: the value sent by the client is always
+// overwritten, so that an application can't escape its quota.
+func checkAppDocsQuota(c echo.Context, doc *couchdb.JSONDoc) error {
+	slug := appSlugFromPermission(c)
+	if slug == "" {
+		return nil
+	}
+
+	instance := middlewares.GetInstance(c)
+	if instance.AppDocsQuota(slug) > 0 {
+		if err := app.CheckDocsQuota(instance, slug, doc.DocType()); err != nil {
+			if err == app.ErrDocsQuotaExceeded {
+				return jsonapi.NewError(http.StatusRequestEntityTooLarge, err.Error())
+			}
+			return err
+		}
+	}
+
+	setCreatedByApp(doc, slug)
+	return nil
+}
+
+// keepCreatedByApp restores the cozyMetadata.createdByApp field of the old
+// document on an update made by an application, as this field is used to count
+// the documents in the quota of the application that has created them.
+func keepCreatedByApp(doc, old *couchdb.JSONDoc) {
+	var slug string
+	if meta, ok := old.M["cozyMetadata"].(map[string]interface{}); ok {
+		slug, _ = meta["createdByApp"].(string)
+	}
+	if slug != "" {
+		setCreatedByApp(doc, slug)
+	} else if meta, ok := doc.M["cozyMetadata"].(map[string]interface{}); ok {
+		delete(meta, "createdByApp")
+	}
+}
+
+func setCreatedByApp(doc *couchdb.JSONDoc, slug string) {
+	meta, ok := doc.M["cozyMetadata"].(map[string]interface{})
+	if !ok {
+		meta = make(map[string]interface{})
+		doc.M["cozyMetadata"] = meta
+	}
+	meta["createdByApp"] = slug
+}
+
 // UpdateDoc updates the document given in the request or creates a new one with
 // the given id.
 func UpdateDoc(c echo.Context) error {
@@ -199,17 +280,28 @@ func UpdateDoc(c echo.Context) error {
 		return createNamedDoc(c, doc)
 	}
 
+	var old *couchdb.JSONDoc
+	fetchOld := func() error {
+		if old != nil {
+			return nil
+		}
+		old = &couchdb.JSONDoc{}
+		if err := couchdb.GetDoc(instance, doc.DocType(), doc.ID(), old); err != nil {
+			return err
+		}
+		old.Type = doc.DocType()
+		return nil
+	}
+
 	errWhole := middlewares.AllowWholeType(c, permission.PUT, doc.DocType())
 	if errWhole != nil {
 		// we cant apply to whole type, let's fetch old doc and see if it applies there
-		var old couchdb.JSONDoc
-		errFetch := couchdb.GetDoc(instance, doc.DocType(), doc.ID(), &old)
+		errFetch := fetchOld()
 		if errFetch != nil {
 			return errFetch
 		}
-		old.Type = doc.DocType()
 		// check if permissions set allows manipulating old doc
-		errOld := middlewares.Allow(c, permission.PUT, &old)
+		errOld := middlewares.Allow(c, permission.PUT, old)
 		if errOld != nil {
 			return errOld
 		}
@@ -221,6 +313,13 @@ func UpdateDoc(c echo.Context) error {
 		}
 	}
 
+	if appSlugFromPermission(c) != "" {
+		if err := fetchOld(); err != nil {
+			return err
+		}
+		keepCreatedByApp(&doc, old)
+	}
+
 	errUpdate := couchdb.UpdateDoc(instance, &doc)
 	if errUpdate != nil {
 		return fixErrorNoDatabaseIsWrongDoctype(errUpdate)
@@ -323,6 +422,10 @@ func defineIndex(c echo.Context) error {
 		return err
 	}
 
+	if err := middlewares.AllowQueryOnFields(c, permission.GET, doctype, definitionRequest); err != nil {
+		return err
+	}
+
 	result, err := couchdb.DefineIndexRaw(instance, doctype, &definitionRequest)
 	if err != nil {
 		return err
@@ -348,6 +451,10 @@ func findDocuments(c echo.Context) error {
 		return err
 	}
 
+	if err := middlewares.AllowQueryOnFields(c, permission.GET, doctype, findRequest); err != nil {
+		return err
+	}
+
 	limit, hasLimit := findRequest["limit"].(float64)
 	if !hasLimit || limit > consts.MaxItemsPerPageForMango {
 		limit = 100
@@ -359,6 +466,11 @@ func findDocuments(c echo.Context) error {
 	if err != nil {
 		return err
 	}
+	if allowed := middlewares.AllowedFields(c, permission.GET, doctype); allowed != nil {
+		for i := range results {
+			permission.StripFields(results[i].M, allowed)
+		}
+	}
 	// There might be more docs next when the returned docs reached the limit
 	next := len(results) >= int(limit)
 	out := echo.Map{
@@ -381,6 +493,9 @@ func allDocs(c echo.Context) error {
 	if err := middlewares.AllowWholeType(c, permission.GET, doctype); err != nil {
 		return err
 	}
+	if err := middlewares.AllowAllFields(c, permission.GET, doctype); err != nil {
+		return err
+	}
 	return proxy(c, "_all_docs")
 }
 
@@ -410,6 +525,18 @@ func normalDocs(c echo.Context) error {
 	if err != nil {
 		return err
 	}
+	if allowed := middlewares.AllowedFields(c, permission.GET, doctype); allowed != nil {
+		for i, row := range res.Rows {
+			var doc map[string]interface{}
+			if err := json.Unmarshal(row, &doc); err != nil {
+				return err
+			}
+			permission.StripFields(doc, allowed)
+			if res.Rows[i], err = json.Marshal(doc); err != nil {
+				return err
+			}
+		}
+	}
 	return c.JSON(http.StatusOK, res)
 }
 
@@ -14,7 +14,10 @@ import (
 	"testing"
 
 	"github.com/cozy/cozy-stack/model/instance"
+	"github.com/cozy/cozy-stack/model/instance/lifecycle"
+	"github.com/cozy/cozy-stack/model/permission"
 	"github.com/cozy/cozy-stack/pkg/config/config"
+	"github.com/cozy/cozy-stack/pkg/consts"
 	"github.com/cozy/cozy-stack/pkg/couchdb"
 	"github.com/cozy/cozy-stack/tests/testutils"
 	"github.com/stretchr/testify/assert"
@@ -859,6 +862,36 @@ func TestAccountsEncryptDecrypt(t *testing.T) {
 	}
 }
 
+func TestClearUserActionOnNewAuth(t *testing.T) {
+	acc := couchdb.JSONDoc{Type: consts.Accounts, M: map[string]interface{}{
+		"account_type": "toto",
+		"auth":         map[string]interface{}{"login": "me", "password": "old"},
+		"user_action":  map[string]interface{}{"reason": "LOGIN_FAILED"},
+	}}
+	EncryptAccount(acc)
+	assert.NoError(t, couchdb.CreateDoc(testInstance, &acc))
+
+	// The same auth keeps the mark
+	same := couchdb.JSONDoc{Type: consts.Accounts, M: map[string]interface{}{
+		"_id":          acc.ID(),
+		"account_type": "toto",
+		"auth":         map[string]interface{}{"login": "me", "password": "old"},
+		"user_action":  map[string]interface{}{"reason": "LOGIN_FAILED"},
+	}}
+	assert.NoError(t, clearUserActionOnNewAuth(testInstance, same))
+	assert.Contains(t, same.M, "user_action")
+
+	// New credentials remove it
+	changed := couchdb.JSONDoc{Type: consts.Accounts, M: map[string]interface{}{
+		"_id":          acc.ID(),
+		"account_type": "toto",
+		"auth":         map[string]interface{}{"login": "me", "password": "new"},
+		"user_action":  map[string]interface{}{"reason": "LOGIN_FAILED"},
+	}}
+	assert.NoError(t, clearUserActionOnNewAuth(testInstance, changed))
+	assert.NotContains(t, changed.M, "user_action")
+}
+
 func TestGetAllDocs(t *testing.T) {
 	url := ts.URL + "/data/" + Type + "/_all_docs?include_docs=true"
 	req, _ := http.NewRequest("GET", url, nil)
@@ -1154,3 +1187,68 @@ func TestDeleteDatabaseNoPermission(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "403 Forbidden", res.Status)
 }
+
+func TestAppDocsQuota(t *testing.T) {
+	doctype := "io.cozy.quotatests"
+	_ = couchdb.ResetDB(testInstance, doctype)
+	err := lifecycle.Patch(testInstance, &lifecycle.Options{
+		AppQuotaSlug: "quota-app",
+		AppQuota:     &instance.AppQuota{Docs: 2},
+	})
+	assert.NoError(t, err)
+	rules := permission.Set{
+		permission.Rule{Type: doctype, Verbs: permission.ALL},
+	}
+	_, err = permission.CreateWebappSet(testInstance, "quota-app", rules, "1.0.0")
+	assert.NoError(t, err)
+	appToken := testInstance.BuildAppToken("quota-app", "")
+
+	create := func(tok string) (*stackUpdateResponse, *http.Response) {
+		in := jsonReader(&map[string]interface{}{
+			"somefield":    "avalue",
+			"cozyMetadata": map[string]interface{}{"createdByApp": "other-app"},
+		})
+		req, _ := http.NewRequest("POST", ts.URL+"/data/"+doctype+"/", in)
+		req.Header.Add("Authorization", "Bearer "+tok)
+		req.Header.Set("Content-Type", "application/json")
+		var out stackUpdateResponse
+		_, res, err := doRequest(req, &out)
+		assert.NoError(t, err)
+		return &out, res
+	}
+	createdByApp := func(doc couchdb.JSONDoc) interface{} {
+		meta, _ := doc.Get("cozyMetadata").(map[string]interface{})
+		return meta["createdByApp"]
+	}
+
+	// The createdByApp field sent by the application is overwritten
+	out, res := create(appToken)
+	assert.Equal(t, "201 Created", res.Status)
+	assert.Equal(t, "quota-app", createdByApp(out.Data))
+
+	// And it is kept on updates
+	in := jsonReader(&map[string]interface{}{
+		"_id":          out.ID,
+		"_rev":         out.Rev,
+		"somefield":    "anewvalue",
+		"cozyMetadata": map[string]interface{}{"createdByApp": "other-app"},
+	})
+	req, _ := http.NewRequest("PUT", ts.URL+"/data/"+doctype+"/"+out.ID, in)
+	req.Header.Add("Authorization", "Bearer "+appToken)
+	req.Header.Set("Content-Type", "application/json")
+	var updated stackUpdateResponse
+	_, res, err = doRequest(req, &updated)
+	assert.NoError(t, err)
+	assert.Equal(t, "200 OK", res.Status)
+	assert.Equal(t, "quota-app", createdByApp(updated.Data))
+
+	_, res = create(appToken)
+	assert.Equal(t, "201 Created", res.Status)
+	_, res = create(appToken)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, res.StatusCode)
+
+	// The quota is not enforced for the other tokens
+	out, res = create(token)
+	assert.Equal(t, "201 Created", res.Status)
+	assert.Equal(t, "other-app", createdByApp(out.Data))
+}
@@ -67,6 +67,10 @@ func bulkGet(c echo.Context) error {
 		return err
 	}
 
+	if err := middlewares.AllowAllFields(c, permission.GET, doctype); err != nil {
+		return err
+	}
+
 	return proxy(c, "_bulk_get")
 }
 
@@ -213,6 +217,12 @@ func changesFeed(c echo.Context) error {
 		return err
 	}
 
+	if includeDocs {
+		if err = middlewares.AllowAllFields(c, permission.GET, doctype); err != nil {
+			return err
+		}
+	}
+
 	// Use the VFS lock for the files to avoid sending the changed feed while
 	// the VFS is moving a directory.
 	if doctype == consts.Files {
@@ -118,7 +118,7 @@ func createFileHandler(c echo.Context, fs vfs.VFS) (*file, error) {
 		return NewFile(doc, inst), nil
 	}
 
-	file, err := fs.CreateFile(doc, nil)
+	file, err := fs.CreateFile(doc, nil, appQuotaOptions(c)...)
 	if err != nil {
 		return nil, err
 	}
@@ -253,7 +253,7 @@ func OverwriteFileContentHandler(c echo.Context) error {
 		return FileData(c, http.StatusOK, newdoc, true, nil)
 	}
 
-	file, err := instance.VFS().CreateFile(newdoc, olddoc)
+	file, err := instance.VFS().CreateFile(newdoc, olddoc, appQuotaOptions(c)...)
 	if err != nil {
 		return WrapVfsError(err)
 	}
@@ -440,7 +440,7 @@ func CopyVersionHandler(c echo.Context) error {
 	}
 	defer content.Close()
 
-	file, err := fs.CreateFile(newdoc, olddoc)
+	file, err := fs.CreateFile(newdoc, olddoc, appQuotaOptions(c)...)
 	if err != nil {
 		return WrapVfsError(err)
 	}
@@ -721,6 +721,9 @@ func ReadFileContentFromIDHandler(c echo.Context) error {
 	if err != nil {
 		return err
 	}
+	if err = recordShareLinkDownload(c); err != nil {
+		return err
+	}
 
 	disposition := "inline"
 	if c.QueryParam("Dl") == "1" {
@@ -748,6 +751,9 @@ func ReadFileContentFromVersion(c echo.Context) error {
 	if err != nil {
 		return err
 	}
+	if err = recordShareLinkDownload(c); err != nil {
+		return err
+	}
 
 	version, err := vfs.FindVersion(instance, doc.DocID+"/"+c.Param("version-id"))
 	if err != nil {
@@ -912,6 +918,9 @@ func sendFileFromPath(c echo.Context, path string, checkPermission bool) error {
 		if err != nil {
 			return err
 		}
+		if err = recordShareLinkDownload(c); err != nil {
+			return err
+		}
 	}
 
 	disposition := "inline"
@@ -974,13 +983,25 @@ func ArchiveDownloadCreateHandler(c echo.Context) error {
 
 	// if accept header is application/zip, send the archive immediately
 	if c.Request().Header.Get("Accept") == "application/zip" {
+		if err = recordShareLinkDownload(c); err != nil {
+			return err
+		}
 		return archive.Serve(instance.VFS(), c.Response())
 	}
 
+	pdoc, err := checkShareLinkDownloads(c)
+	if err != nil {
+		return err
+	}
 	secret, err := vfs.GetStore().AddArchive(instance, archive)
 	if err != nil {
 		return WrapVfsError(err)
 	}
+	if pdoc != nil {
+		if err = vfs.GetStore().AddShareLink(instance, secret, pdoc.ID()); err != nil {
+			return WrapVfsError(err)
+		}
+	}
 	archive.Secret = secret
 
 	fakeName := url.PathEscape(archive.Name)
@@ -1023,17 +1044,26 @@ func FileDownloadCreateHandler(c echo.Context) error {
 	if err != nil {
 		return err
 	}
+	pdoc, err := checkShareLinkDownloads(c)
+	if err != nil {
+		return err
+	}
 
 	var secret string
 	if versionID == "" {
 		secret, err = vfs.GetStore().AddFile(instance, path)
 	} else {
 		secret, err = vfs.GetStore().AddVersion(instance, versionID)
-		secret = "v-" + secret
+	}
+	if err == nil && pdoc != nil {
+		err = vfs.GetStore().AddShareLink(instance, secret, pdoc.ID())
 	}
 	if err != nil {
 		return WrapVfsError(err)
 	}
+	if versionID != "" {
+		secret = "v-" + secret
+	}
 
 	filename := c.QueryParam("Filename")
 	if filename == "" {
@@ -1055,6 +1085,9 @@ func ArchiveDownloadHandler(c echo.Context) error {
 	if err != nil {
 		return WrapVfsError(err)
 	}
+	if err := recordShareLinkDownloadBySecret(c, secret); err != nil {
+		return err
+	}
 	if err := archive.Serve(instance.VFS(), c.Response()); err != nil {
 		return WrapVfsError(err)
 	}
@@ -1073,6 +1106,9 @@ func FileDownloadHandler(c echo.Context) error {
 	if err != nil {
 		return WrapVfsError(err)
 	}
+	if err := recordShareLinkDownloadBySecret(c, secret); err != nil {
+		return err
+	}
 	return sendFileFromPath(c, path, false)
 }
 
@@ -1082,6 +1118,9 @@ func versionDownloadHandler(c echo.Context, secret string) error {
 	if err != nil {
 		return WrapVfsError(err)
 	}
+	if err := recordShareLinkDownloadBySecret(c, secret); err != nil {
+		return err
+	}
 
 	fileID := strings.Split(versionID, "/")[0]
 	doc, err := instance.VFS().FileByID(fileID)
@@ -1881,6 +1920,70 @@ func checkPerm(c echo.Context, v permission.Verb, d *vfs.DirDoc, f *vfs.FileDoc)
 	return middlewares.AllowVFS(c, v, f)
 }
 
+// shareLinkPermission returns the permission of the request if it is a share
+// by link, or nil.
+func shareLinkPermission(c echo.Context) *permission.Permission {
+	pdoc, err := middlewares.GetPermission(c)
+	if err != nil || pdoc.Type != permission.TypeShareByLink {
+		return nil
+	}
+	return pdoc
+}
+
+// recordShareLinkDownload counts a download for a share by link, and refuses
+// it if the maximal number of downloads has been reached.
+func recordShareLinkDownload(c echo.Context) error {
+	pdoc := shareLinkPermission(c)
+	if pdoc == nil {
+		return nil
+	}
+	return recordDownload(c, pdoc)
+}
+
+// recordDownload counts a download for the given share by link. Only the full
+// fetches are counted: the requests with a Range header are not, so that a
+// video player or a resumed download doesn't use several downloads.
+func recordDownload(c echo.Context, pdoc *permission.Permission) error {
+	if pdoc.DownloadsExhausted() {
+		return permission.ErrShareLinkExhausted
+	}
+	req := c.Request()
+	if req.Method == http.MethodHead || req.Header.Get("Range") != "" {
+		return nil
+	}
+	inst := middlewares.GetInstance(c)
+	return pdoc.RecordAccess(inst, permission.AccessDownload, c.RealIP())
+}
+
+// checkShareLinkDownloads is used when a download link is created with a
+// share by link: the download is counted when the link is used, not when it
+// is created, but the link is refused if the downloads are exhausted.
+func checkShareLinkDownloads(c echo.Context) (*permission.Permission, error) {
+	pdoc := shareLinkPermission(c)
+	if pdoc != nil && pdoc.DownloadsExhausted() {
+		return nil, permission.ErrShareLinkExhausted
+	}
+	return pdoc, nil
+}
+
+// recordShareLinkDownloadBySecret counts a download made with a download
+// link, if this link has been created with a share by link.
+func recordShareLinkDownloadBySecret(c echo.Context, secret string) error {
+	inst := middlewares.GetInstance(c)
+	permID, err := vfs.GetStore().GetShareLink(inst, secret)
+	if err == vfs.ErrWrongToken {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	pdoc, err := permission.GetByID(inst, permID)
+	if err != nil {
+		return err
+	}
+	return recordDownload(c, pdoc)
+}
+
 func parseMD5Hash(md5B64 string) ([]byte, error) {
 	// Encoded md5 hash in base64 should at least have 22 caracters in
 	// base64: 16*3/4 = 21+1/3
@@ -2000,6 +2103,19 @@ func updateFileCozyMetadata(c echo.Context, file *vfs.FileDoc, setUploadFields b
 	}
 }
 
+// appQuotaOptions returns the options for creating a file with the disk quota
+// of the application enforced, if the request is made with the token of a
+// webapp or a konnector.
+func appQuotaOptions(c echo.Context) []vfs.CreateOptions {
+	if claims := c.Get("claims"); claims != nil {
+		switch claims.(permission.Claims).Audience {
+		case consts.AppAudience, consts.KonnectorAudience:
+			return []vfs.CreateOptions{vfs.EnforceAppQuota}
+		}
+	}
+	return nil
+}
+
 // CozyMetadataFromClaims returns a FilesCozyMetadata struct, with the app
 // fields filled with information from the permission claims.
 func CozyMetadataFromClaims(c echo.Context, setUploadFields bool) (*vfs.FilesCozyMetadata, string) {
@@ -0,0 +1,46 @@
+package instances
+
+import (
+	"net/http"
+
+	"github.com/cozy/cozy-stack/pkg/limits"
+	"github.com/labstack/echo/v4"
+)
+
+// getBans returns the state of the protection against brute-force attacks for
+// an IP address on the instance.
+func getBans(c echo.Context) error {
+	ip := c.QueryParam("ip")
+	if ip == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "The ip parameter is mandatory")
+	}
+	res := echo.Map{"ip": limits.IPBanStatus(c.Param("domain"), ip)}
+	return c.JSON(http.StatusOK, res)
+}
+
+// deleteBans removes the temporary ban on an IP address for the instance.
+func deleteBans(c echo.Context) error {
+	ip := c.QueryParam("ip")
+	if ip == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "The ip parameter is mandatory")
+	}
+	if err := limits.UnbanIP(c.Param("domain"), ip); err != nil {
+		return wrapError(err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// getGlobalIPBan returns the state of the protection against brute-force
+// attacks for an IP address, on all the instances.
+func getGlobalIPBan(c echo.Context) error {
+	return c.JSON(http.StatusOK, limits.IPBanStatus("", c.Param("ip")))
+}
+
+// deleteGlobalIPBan removes the temporary ban on an IP address for all the
+// instances.
+func deleteGlobalIPBan(c echo.Context) error {
+	if err := limits.UnbanIP("", c.Param("ip")); err != nil {
+		return wrapError(err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
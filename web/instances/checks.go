@@ -243,3 +243,17 @@ func checkSharings(c echo.Context) error {
 	}
 	return c.JSON(http.StatusOK, results)
 }
+
+func sharingsStatus(c echo.Context) error {
+	domain := c.Param("domain")
+	i, err := lifecycle.GetInstance(domain)
+	if err != nil {
+		return wrapError(err)
+	}
+
+	results, err := sharing.ListReplicationStatuses(i)
+	if err != nil {
+		return wrapError(err)
+	}
+	return c.JSON(http.StatusOK, results)
+}
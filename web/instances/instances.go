@@ -155,6 +155,24 @@ func modifyHandler(c echo.Context) error {
 		}
 		opts.DiskQuota = i
 	}
+	if slug := c.QueryParam("AppQuotaSlug"); slug != "" {
+		opts.AppQuotaSlug = slug
+		opts.AppQuota = &instance.AppQuota{}
+		if quota := c.QueryParam("AppDiskQuota"); quota != "" {
+			i, err := strconv.ParseInt(quota, 10, 64)
+			if err != nil {
+				return wrapError(err)
+			}
+			opts.AppQuota.Disk = i
+		}
+		if quota := c.QueryParam("AppDocsQuota"); quota != "" {
+			i, err := strconv.ParseInt(quota, 10, 64)
+			if err != nil {
+				return wrapError(err)
+			}
+			opts.AppQuota.Docs = i
+		}
+	}
 	if onboardingFinished, err := strconv.ParseBool(c.QueryParam("OnboardingFinished")); err == nil {
 		opts.OnboardingFinished = &onboardingFinished
 	}
@@ -488,6 +506,10 @@ func Routes(router *echo.Group) {
 	router.GET("/:domain/prefix", showPrefix)
 	router.GET("/:domain/swift-prefix", getSwiftBucketName)
 	router.POST("/:domain/auth-mode", setAuthMode)
+	router.GET("/:domain/bans", getBans)
+	router.DELETE("/:domain/bans", deleteBans)
+	router.GET("/bans/ips/:ip", getGlobalIPBan)
+	router.DELETE("/bans/ips/:ip", deleteGlobalIPBan)
 
 	// Config
 	router.POST("/redis", rebuildRedis)
@@ -503,6 +525,7 @@ func Routes(router *echo.Group) {
 	router.POST("/:domain/checks/triggers", checkTriggers)
 	router.POST("/:domain/checks/shared", checkShared)
 	router.POST("/:domain/checks/sharings", checkSharings)
+	router.GET("/:domain/checks/sharings/status", sharingsStatus)
 
 	// Fixers
 	router.POST("/:domain/fixers/content-mismatch", contentMismatchFixer)
@@ -9,15 +9,18 @@ import (
 	"strings"
 
 	"github.com/cozy/cozy-stack/model/app"
+	"github.com/cozy/cozy-stack/model/apppassword"
 	"github.com/cozy/cozy-stack/model/bitwarden/settings"
 	"github.com/cozy/cozy-stack/model/instance"
 	"github.com/cozy/cozy-stack/model/oauth"
 	"github.com/cozy/cozy-stack/model/permission"
+	"github.com/cozy/cozy-stack/model/session"
 	"github.com/cozy/cozy-stack/model/sharing"
 	"github.com/cozy/cozy-stack/model/vfs"
 	"github.com/cozy/cozy-stack/pkg/consts"
 	"github.com/cozy/cozy-stack/pkg/couchdb"
 	"github.com/cozy/cozy-stack/pkg/crypto"
+	"github.com/cozy/cozy-stack/pkg/limits"
 	jwt "github.com/golang-jwt/jwt/v4"
 	"github.com/labstack/echo/v4"
 )
@@ -25,6 +28,11 @@ import (
 const bearerAuthScheme = "Bearer "
 const basicAuthScheme = "Basic "
 const contextPermissionDoc = "permissions_doc"
+const contextAppPasswordFailed = "app_password_failed"
+
+// ShareUnlockHeader is the HTTP header used to send the token that unlocks a
+// share by link protected by a password.
+const ShareUnlockHeader = "X-Cozy-Share-Unlock"
 
 // ErrForbidden is used to send a forbidden response when the request does not
 // have the right permissions.
@@ -98,11 +106,16 @@ func GetForOauth(instance *instance.Instance, claims *permission.Claims, c inter
 			return nil, err
 		}
 		set = manifest.Permissions()
-	} else {
-		set, err = permission.UnmarshalScopeString(claims.Scope)
+	} else if _, scope := oauth.SplitScope(claims.Scope); scope != "" {
+		// The OpenID Connect scopes don't give any permission on the doctypes
+		set, err = permission.UnmarshalScopeString(scope)
 		if err != nil {
 			return nil, err
 		}
+	} else if claims.Scope == "" {
+		return nil, permission.ErrBadScope
+	} else {
+		set = permission.Set{}
 	}
 
 	pdoc := &permission.Permission{
@@ -209,6 +222,11 @@ func ParseJWT(c echo.Context, instance *instance.Instance, token string) (*permi
 			c.Response().Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
 			return nil, permission.ErrInvalidToken
 		}
+		// or if the token has not been revoked
+		if client.IsRevoked(*claims) {
+			c.Response().Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			return nil, permission.ErrInvalidToken
+		}
 		return GetForOauth(instance, claims, client)
 
 	case consts.CLIAudience:
@@ -235,6 +253,22 @@ func ParseJWT(c echo.Context, instance *instance.Instance, token string) (*permi
 			return nil, err
 		}
 
+		// A file drop link can only be used on its public upload page
+		if pdoc.Type == permission.TypeFileDrop {
+			return nil, permission.ErrInvalidToken
+		}
+
+		// A share by link protected by a password must have been unlocked
+		if pdoc.HasPassword() {
+			unlock := c.Request().Header.Get(ShareUnlockHeader)
+			if unlock == "" {
+				unlock = c.QueryParam("unlock")
+			}
+			if !pdoc.CheckUnlockToken(instance.SessionSecret(), unlock) {
+				return nil, permission.ErrSharePasswordRequired
+			}
+		}
+
 		// A share token is only valid if the user has not been revoked
 		if pdoc.Type == permission.TypeSharePreview || pdoc.Type == permission.TypeShareInteract {
 			sharingID := strings.Split(pdoc.SourceID, "/")
@@ -285,6 +319,11 @@ func GetPermission(c echo.Context) (*permission.Permission, error) {
 		return permission.GetForRegisterToken(), nil
 	}
 
+	if pdoc = getForAppPassword(c, inst); pdoc != nil {
+		c.Set(contextPermissionDoc, pdoc)
+		return pdoc, nil
+	}
+
 	tok := GetRequestToken(c)
 	if tok == "" {
 		return nil, errNoToken
@@ -299,6 +338,43 @@ func GetPermission(c echo.Context) (*permission.Permission, error) {
 	return pdoc, nil
 }
 
+// getForAppPassword returns the permissions for a request authenticated with
+// an app password via HTTP Basic auth, or nil if the request doesn't use a
+// valid app password (the password can also be a token, like a JWT). The
+// failed attempts are counted like for the login, to protect the app
+// passwords against brute-force attacks.
+func getForAppPassword(c echo.Context, inst *instance.Instance) *permission.Permission {
+	login, password, ok := c.Request().BasicAuth()
+	if !ok || login == "" || strings.Count(password, ".") == 2 {
+		return nil
+	}
+	// GetPermission can be called several times for a request, but a failed
+	// attempt must be counted only once
+	if failed, _ := c.Get(contextAppPasswordFailed).(bool); failed {
+		return nil
+	}
+	if err := limits.CheckLoginAttempt(inst.Domain, c.RealIP()); err != nil {
+		c.Set(contextAppPasswordFailed, true)
+		return nil
+	}
+	ap, err := apppassword.Check(inst, login, password)
+	if err != nil {
+		c.Set(contextAppPasswordFailed, true)
+		if err == apppassword.ErrInvalidCredentials {
+			session.LoginFailed(inst, c.RealIP())
+		} else {
+			inst.Logger().WithNamespace("app-password").
+				Warnf("Cannot check app password: %s", err)
+		}
+		return nil
+	}
+	pdoc, err := ap.PermissionDoc()
+	if err != nil {
+		return nil
+	}
+	return pdoc
+}
+
 // AllowWholeType validates that the context permission set can use a verb on
 // the whold doctype
 func AllowWholeType(c echo.Context, v permission.Verb, doctype string) error {
@@ -337,6 +413,36 @@ func AllowOnFields(c echo.Context, v permission.Verb, o permission.Fetcher, fiel
 	return nil
 }
 
+// AllowedFields returns the list of the fields that can be read on the
+// documents of the given doctype with the context permission set, or nil if
+// all the fields can be read.
+func AllowedFields(c echo.Context, v permission.Verb, doctype string) []string {
+	pdoc, err := GetPermission(c)
+	if err != nil {
+		return nil
+	}
+	return pdoc.Permissions.AllowedFields(v, doctype)
+}
+
+// AllowAllFields checks that the context permission set has no field-level
+// restriction on the doctype. It is used for the routes where the documents
+// are sent as is, without being able to strip the non-allowed fields.
+func AllowAllFields(c echo.Context, v permission.Verb, doctype string) error {
+	if AllowedFields(c, v, doctype) != nil {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// AllowQueryOnFields checks that a mango query or an index definition uses
+// only the fields that can be read with the context permission set.
+func AllowQueryOnFields(c echo.Context, v permission.Verb, doctype string, query map[string]interface{}) error {
+	if !permission.AllowQueryOnFields(query, AllowedFields(c, v, doctype)) {
+		return ErrForbidden
+	}
+	return nil
+}
+
 // AllowTypeAndID validates a type & ID against the context permission set
 func AllowTypeAndID(c echo.Context, v permission.Verb, doctype, id string) error {
 	pdoc, err := GetPermission(c)
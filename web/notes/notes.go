@@ -190,6 +190,11 @@ func PatchNote(c echo.Context) error {
 			return wrapError(err)
 		}
 	}
+	if author := getAuthor(c); author != "" {
+		for i := range steps {
+			steps[i]["author"] = author
+		}
+	}
 
 	ifMatch := c.Request().Header.Get("If-Match")
 	if file, err = note.ApplySteps(inst, file, ifMatch, steps); err != nil {
@@ -423,6 +428,368 @@ func GetImage(c echo.Context) error {
 	return inst.ThumbsFS().ServeNoteThumbContent(c.Response(), c.Request(), imageID)
 }
 
+// ExportNote is the API handler for GET /notes/:id/export?format=xxx. It
+// renders the note to the given format (html, pdf, docx or odt), and sends it
+// as an attachment.
+func ExportNote(c echo.Context) error {
+	inst := middlewares.GetInstance(c)
+	file, err := inst.VFS().FileByID(c.Param("id"))
+	if err != nil {
+		return wrapError(err)
+	}
+
+	if err := middlewares.AllowVFS(c, permission.GET, file); err != nil {
+		return err
+	}
+
+	format := c.QueryParam("format")
+	if format == "" {
+		format = note.ExportHTML
+	}
+	exported, err := note.Export(inst, file, format)
+	if err != nil {
+		return wrapError(err)
+	}
+	disposition := vfs.ContentDisposition("attachment", exported.Filename)
+	c.Response().Header().Set(echo.HeaderContentDisposition, disposition)
+	return c.Blob(http.StatusOK, exported.Mime, exported.Content)
+}
+
+// ListHistory is the API handler for GET /notes/:id/history. It returns the
+// list of the versions of the note, the most recent first.
+func ListHistory(c echo.Context) error {
+	inst := middlewares.GetInstance(c)
+	file, err := inst.VFS().FileByID(c.Param("id"))
+	if err != nil {
+		return wrapError(err)
+	}
+
+	if err := middlewares.AllowVFS(c, permission.GET, file); err != nil {
+		return err
+	}
+
+	versions, err := note.ListVersions(inst, file)
+	if err != nil {
+		return wrapError(err)
+	}
+
+	objs := make([]jsonapi.Object, len(versions))
+	for i, version := range versions {
+		objs[i] = version
+	}
+	return jsonapi.DataList(c, http.StatusOK, objs, nil)
+}
+
+// CreateSnapshot is the API handler for POST /notes/:id/history. It makes a
+// named snapshot of the current version of the note.
+func CreateSnapshot(c echo.Context) error {
+	inst := middlewares.GetInstance(c)
+	file, err := inst.VFS().FileByID(c.Param("id"))
+	if err != nil {
+		return wrapError(err)
+	}
+
+	if err := middlewares.AllowVFS(c, permission.PUT, file); err != nil {
+		return err
+	}
+
+	event := note.Event{}
+	if _, err := jsonapi.Bind(c.Request().Body, &event); err != nil {
+		return err
+	}
+	name, _ := event["name"].(string)
+	if name == "" {
+		return jsonapi.InvalidAttribute("name", errors.New("The name is mandatory"))
+	}
+
+	version, err := note.CreateSnapshot(inst, file, name)
+	if err != nil {
+		return wrapError(err)
+	}
+	return jsonapi.Data(c, http.StatusCreated, version.WithoutContent(), nil)
+}
+
+// GetVersion is the API handler for GET /notes/:id/history/:version. It
+// returns the snapshot of the note for this version, with its content.
+func GetVersion(c echo.Context) error {
+	version, err := getVersionFromParams(c)
+	if err != nil {
+		return err
+	}
+	return jsonapi.Data(c, http.StatusOK, version, nil)
+}
+
+// GetVersionMarkdown is the API handler for GET
+// /notes/:id/history/:version/markdown. It renders the snapshot of the note
+// for this version to markdown.
+func GetVersionMarkdown(c echo.Context) error {
+	inst := middlewares.GetInstance(c)
+	version, err := getVersionFromParams(c)
+	if err != nil {
+		return err
+	}
+	images, _ := note.GetImages(inst, version.NoteID)
+	md, err := version.Markdown(images)
+	if err != nil {
+		return wrapError(err)
+	}
+	return c.Blob(http.StatusOK, "text/markdown; charset=utf-8", md)
+}
+
+// RestoreVersion is the API handler for POST
+// /notes/:id/history/:version/restore. It replaces the content of the note by
+// the content of this version, with a new step.
+func RestoreVersion(c echo.Context) error {
+	inst := middlewares.GetInstance(c)
+	file, err := inst.VFS().FileByID(c.Param("id"))
+	if err != nil {
+		return wrapError(err)
+	}
+
+	if err := middlewares.AllowVFS(c, permission.PATCH, file); err != nil {
+		return err
+	}
+
+	v, err := strconv.ParseInt(c.Param("version"), 10, 64)
+	if err != nil {
+		return jsonapi.InvalidParameter("version", err)
+	}
+	sessID := c.QueryParam("SessionID")
+	if file, err = note.RestoreVersion(inst, file, v, sessID); err != nil {
+		return wrapError(err)
+	}
+
+	return files.FileData(c, http.StatusOK, file, false, nil)
+}
+
+func getVersionFromParams(c echo.Context) (*note.Version, error) {
+	inst := middlewares.GetInstance(c)
+	file, err := inst.VFS().FileByID(c.Param("id"))
+	if err != nil {
+		return nil, wrapError(err)
+	}
+
+	if err := middlewares.AllowVFS(c, permission.GET, file); err != nil {
+		return nil, err
+	}
+
+	v, err := strconv.ParseInt(c.Param("version"), 10, 64)
+	if err != nil {
+		return nil, jsonapi.InvalidParameter("version", err)
+	}
+	version, err := note.GetVersion(inst, file, v)
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	return version, nil
+}
+
+// commentAttributes are the attributes sent by the client for creating a
+// comment thread or replying to it.
+type commentAttributes struct {
+	Version  int64       `json:"version"`
+	From     int         `json:"from"`
+	To       int         `json:"to"`
+	Text     string      `json:"text"`
+	Mentions []string    `json:"mentions"`
+	Steps    []note.Step `json:"steps"`
+}
+
+// ListComments is the API handler for GET /notes/:id/comments. It returns the
+// comment threads of the note, with their anchors mapped to the current
+// version.
+func ListComments(c echo.Context) error {
+	inst := middlewares.GetInstance(c)
+	file, err := inst.VFS().FileByID(c.Param("id"))
+	if err != nil {
+		return wrapError(err)
+	}
+
+	if err := middlewares.AllowVFS(c, permission.GET, file); err != nil {
+		return err
+	}
+
+	comments, err := note.ListComments(inst, file)
+	if err != nil {
+		return wrapError(err)
+	}
+
+	objs := make([]jsonapi.Object, len(comments))
+	for i, comment := range comments {
+		objs[i] = comment
+	}
+	return jsonapi.DataList(c, http.StatusOK, objs, nil)
+}
+
+// GetComment is the API handler for GET /notes/:id/comments/:comment-id. It
+// returns a comment thread with its messages.
+func GetComment(c echo.Context) error {
+	inst := middlewares.GetInstance(c)
+	file, err := inst.VFS().FileByID(c.Param("id"))
+	if err != nil {
+		return wrapError(err)
+	}
+
+	if err := middlewares.AllowVFS(c, permission.GET, file); err != nil {
+		return err
+	}
+
+	comment, err := note.GetComment(inst, file, c.Param("comment-id"))
+	if err != nil {
+		return wrapError(err)
+	}
+	return jsonapi.Data(c, http.StatusOK, comment, nil)
+}
+
+// CreateComment is the API handler for POST /notes/:id/comments. It starts a
+// new comment thread, anchored on a range of the note. When steps are given,
+// the comment is a suggestion that the owner of the note can accept or reject.
+func CreateComment(c echo.Context) error {
+	inst := middlewares.GetInstance(c)
+	file, err := inst.VFS().FileByID(c.Param("id"))
+	if err != nil {
+		return wrapError(err)
+	}
+
+	if err := middlewares.AllowVFS(c, permission.POST, file); err != nil {
+		return err
+	}
+
+	var attrs commentAttributes
+	if _, err := jsonapi.Bind(c.Request().Body, &attrs); err != nil {
+		return err
+	}
+	comment, err := note.CreateComment(inst, file, note.NewComment{
+		Version:  attrs.Version,
+		From:     attrs.From,
+		To:       attrs.To,
+		Author:   getAuthor(c),
+		Text:     attrs.Text,
+		Mentions: attrs.Mentions,
+		Steps:    attrs.Steps,
+	})
+	if err != nil {
+		return wrapError(err)
+	}
+	return jsonapi.Data(c, http.StatusCreated, comment, nil)
+}
+
+// AddReply is the API handler for POST
+// /notes/:id/comments/:comment-id/replies. It adds a message to a comment
+// thread.
+func AddReply(c echo.Context) error {
+	inst := middlewares.GetInstance(c)
+	file, err := inst.VFS().FileByID(c.Param("id"))
+	if err != nil {
+		return wrapError(err)
+	}
+
+	if err := middlewares.AllowVFS(c, permission.POST, file); err != nil {
+		return err
+	}
+
+	var attrs commentAttributes
+	if _, err := jsonapi.Bind(c.Request().Body, &attrs); err != nil {
+		return err
+	}
+	comment, err := note.AddReply(inst, file, c.Param("comment-id"),
+		getAuthor(c), attrs.Text, attrs.Mentions)
+	if err != nil {
+		return wrapError(err)
+	}
+	return jsonapi.Data(c, http.StatusOK, comment, nil)
+}
+
+// ResolveComment is the API handler for POST
+// /notes/:id/comments/:comment-id/resolve. It marks the thread as resolved.
+func ResolveComment(c echo.Context) error {
+	inst := middlewares.GetInstance(c)
+	file, err := inst.VFS().FileByID(c.Param("id"))
+	if err != nil {
+		return wrapError(err)
+	}
+
+	if err := middlewares.AllowVFS(c, permission.PATCH, file); err != nil {
+		return err
+	}
+
+	comment, err := note.ResolveComment(inst, file, c.Param("comment-id"), getAuthor(c))
+	if err != nil {
+		return wrapError(err)
+	}
+	return jsonapi.Data(c, http.StatusOK, comment, nil)
+}
+
+// ReopenComment is the API handler for POST
+// /notes/:id/comments/:comment-id/reopen. It marks a resolved thread as open
+// again.
+func ReopenComment(c echo.Context) error {
+	inst := middlewares.GetInstance(c)
+	file, err := inst.VFS().FileByID(c.Param("id"))
+	if err != nil {
+		return wrapError(err)
+	}
+
+	if err := middlewares.AllowVFS(c, permission.PATCH, file); err != nil {
+		return err
+	}
+
+	comment, err := note.ReopenComment(inst, file, c.Param("comment-id"))
+	if err != nil {
+		return wrapError(err)
+	}
+	return jsonapi.Data(c, http.StatusOK, comment, nil)
+}
+
+// AcceptSuggestion is the API handler for POST
+// /notes/:id/comments/:comment-id/accept. It applies the steps of the
+// suggestion on the note. Only the owner of the note can accept it.
+func AcceptSuggestion(c echo.Context) error {
+	inst := middlewares.GetInstance(c)
+	file, err := inst.VFS().FileByID(c.Param("id"))
+	if err != nil {
+		return wrapError(err)
+	}
+
+	if err := middlewares.AllowVFS(c, permission.PATCH, file); err != nil {
+		return err
+	}
+	if !isOwner(c) {
+		return jsonapi.Forbidden(errors.New("Only the owner can accept a suggestion"))
+	}
+
+	sessID := c.QueryParam("SessionID")
+	file, _, err = note.AcceptSuggestion(inst, file, c.Param("comment-id"), getAuthor(c), sessID)
+	if err != nil {
+		return wrapError(err)
+	}
+	return files.FileData(c, http.StatusOK, file, false, nil)
+}
+
+// RejectSuggestion is the API handler for POST
+// /notes/:id/comments/:comment-id/reject. It refuses the steps of the
+// suggestion. Only the owner of the note can reject it.
+func RejectSuggestion(c echo.Context) error {
+	inst := middlewares.GetInstance(c)
+	file, err := inst.VFS().FileByID(c.Param("id"))
+	if err != nil {
+		return wrapError(err)
+	}
+
+	if err := middlewares.AllowVFS(c, permission.PATCH, file); err != nil {
+		return err
+	}
+	if !isOwner(c) {
+		return jsonapi.Forbidden(errors.New("Only the owner can reject a suggestion"))
+	}
+
+	comment, err := note.RejectSuggestion(inst, file, c.Param("comment-id"), getAuthor(c))
+	if err != nil {
+		return wrapError(err)
+	}
+	return jsonapi.Data(c, http.StatusOK, comment, nil)
+}
+
 // Routes sets the routing for the collaborative edition of notes.
 func Routes(router *echo.Group) {
 	router.POST("", CreateNote)
@@ -437,6 +804,20 @@ func Routes(router *echo.Group) {
 	router.PUT("/:id/schema", UpdateNoteSchema)
 	router.POST("/:id/images", UploadImage)
 	router.GET("/:id/images/:image-id/:secret", GetImage)
+	router.GET("/:id/export", ExportNote)
+	router.GET("/:id/history", ListHistory)
+	router.POST("/:id/history", CreateSnapshot)
+	router.GET("/:id/history/:version", GetVersion)
+	router.GET("/:id/history/:version/markdown", GetVersionMarkdown)
+	router.POST("/:id/history/:version/restore", RestoreVersion)
+	router.GET("/:id/comments", ListComments)
+	router.POST("/:id/comments", CreateComment)
+	router.GET("/:id/comments/:comment-id", GetComment)
+	router.POST("/:id/comments/:comment-id/replies", AddReply)
+	router.POST("/:id/comments/:comment-id/resolve", ResolveComment)
+	router.POST("/:id/comments/:comment-id/reopen", ReopenComment)
+	router.POST("/:id/comments/:comment-id/accept", AcceptSuggestion)
+	router.POST("/:id/comments/:comment-id/reject", RejectSuggestion)
 }
 
 func wrapError(err error) *jsonapi.Error {
@@ -453,12 +834,63 @@ func wrapError(err error) *jsonapi.Error {
 		return jsonapi.NotFound(err)
 	case vfs.ErrFileTooBig:
 		return jsonapi.Errorf(http.StatusRequestEntityTooLarge, "%s", err)
-	case sharing.ErrMemberNotFound:
+	case sharing.ErrMemberNotFound, note.ErrVersionNotFound, note.ErrCommentNotFound:
 		return jsonapi.NotFound(err)
+	case note.ErrInvalidAnchor:
+		return jsonapi.InvalidAttribute("from", err)
+	case note.ErrEmptyComment:
+		return jsonapi.InvalidAttribute("text", err)
+	case note.ErrNoPendingSuggestion:
+		return jsonapi.BadRequest(err)
+	case note.ErrTooOld:
+		return jsonapi.Conflict(err)
+	case note.ErrUnknownExportFormat:
+		return jsonapi.InvalidParameter("format", err)
 	}
 	return jsonapi.InternalServerError(err)
 }
 
+// getAuthor returns the name of the person that makes changes on a note, to
+// show it in the history of the note.
+func getAuthor(c echo.Context) string {
+	inst := middlewares.GetInstance(c)
+	pdoc, err := middlewares.GetPermission(c)
+	if err != nil {
+		return ""
+	}
+	switch pdoc.Type {
+	case permission.TypeSharePreview, permission.TypeShareInteract:
+		sharingID := strings.TrimPrefix(pdoc.SourceID, consts.Sharings+"/")
+		s, err := sharing.FindSharing(inst, sharingID)
+		if err != nil {
+			return ""
+		}
+		m, err := s.FindMemberByCode(pdoc, middlewares.GetRequestToken(c))
+		if err != nil {
+			return ""
+		}
+		return m.PrimaryName()
+	case permission.TypeShareByLink:
+		return ""
+	}
+	name, _ := inst.PublicName()
+	return name
+}
+
+// isOwner returns true if the request is made by the owner of the instance,
+// and not by a member of a sharing or via a share by link.
+func isOwner(c echo.Context) bool {
+	pdoc, err := middlewares.GetPermission(c)
+	if err != nil {
+		return false
+	}
+	switch pdoc.Type {
+	case permission.TypeSharePreview, permission.TypeShareInteract, permission.TypeShareByLink:
+		return false
+	}
+	return true
+}
+
 func getCreatedBy(c echo.Context) string {
 	if claims, ok := c.Get("claims").(permission.Claims); ok {
 		switch claims.Audience {
@@ -851,6 +851,244 @@ Text with **bold** and [underlined]{.underlined}.
 	assert.Equal(t, inst.Domain, attrs["instance"])
 }
 
+func TestNoteHistory(t *testing.T) {
+	// Persisting the note to the VFS makes an automatic snapshot
+	req, _ := http.NewRequest("POST", ts.URL+"/notes/"+noteID+"/sync", nil)
+	req.Header.Add("Authorization", "Bearer "+token)
+	res, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 204, res.StatusCode)
+
+	body := `{
+  "data": {
+    "type": "io.cozy.notes.versions",
+    "attributes": {
+      "name": "Before the big rewrite"
+    }
+  }
+}`
+	req, _ = http.NewRequest("POST", ts.URL+"/notes/"+noteID+"/history", bytes.NewBufferString(body))
+	req.Header.Add("Content-Type", "application/vnd.api+json")
+	req.Header.Add("Authorization", "Bearer "+token)
+	res, err = http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 201, res.StatusCode)
+	var result map[string]interface{}
+	err = json.NewDecoder(res.Body).Decode(&result)
+	assert.NoError(t, err)
+	data, _ := result["data"].(map[string]interface{})
+	assert.Equal(t, consts.NotesVersions, data["type"])
+	attrs, _ := data["attributes"].(map[string]interface{})
+	assert.Equal(t, "Before the big rewrite", attrs["name"])
+	assert.Equal(t, noteID, attrs["note_id"])
+	assert.Nil(t, attrs["content"])
+	v, _ := attrs["version"].(float64)
+	snapshot := int64(v)
+
+	req, _ = http.NewRequest("GET", ts.URL+"/notes/"+noteID+"/history", nil)
+	req.Header.Add("Authorization", "Bearer "+token)
+	res, err = http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, res.StatusCode)
+	var list map[string]interface{}
+	err = json.NewDecoder(res.Body).Decode(&list)
+	assert.NoError(t, err)
+	items, _ := list["data"].([]interface{})
+	if assert.NotEmpty(t, items) {
+		first, _ := items[0].(map[string]interface{})
+		attrs, _ := first["attributes"].(map[string]interface{})
+		assert.EqualValues(t, snapshot, attrs["version"])
+		assert.Equal(t, "Before the big rewrite", attrs["name"])
+	}
+
+	path := fmt.Sprintf("/notes/%s/history/%d", noteID, snapshot)
+	req, _ = http.NewRequest("GET", ts.URL+path+"/markdown", nil)
+	req.Header.Add("Authorization", "Bearer "+token)
+	res, err = http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, res.StatusCode)
+	md, err := ioutil.ReadAll(res.Body)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, md)
+
+	req, _ = http.NewRequest("POST", ts.URL+path+"/restore?SessionID=543781490137", nil)
+	req.Header.Add("Authorization", "Bearer "+token)
+	res, err = http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, res.StatusCode)
+	var restored map[string]interface{}
+	err = json.NewDecoder(res.Body).Decode(&restored)
+	assert.NoError(t, err)
+	data, _ = restored["data"].(map[string]interface{})
+	attrs, _ = data["attributes"].(map[string]interface{})
+	meta, _ := attrs["metadata"].(map[string]interface{})
+	assert.EqualValues(t, snapshot+1, meta["version"])
+
+	req, _ = http.NewRequest("GET", ts.URL+"/notes/"+noteID+"/history/999999", nil)
+	req.Header.Add("Authorization", "Bearer "+token)
+	res, err = http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 404, res.StatusCode)
+}
+
+func TestNoteComments(t *testing.T) {
+	body := `{
+  "data": {
+    "type": "io.cozy.notes.comments",
+    "attributes": {
+      "from": 1,
+      "to": 2,
+      "text": "Is it the right word?"
+    }
+  }
+}`
+	req, _ := http.NewRequest("POST", ts.URL+"/notes/"+noteID+"/comments", bytes.NewBufferString(body))
+	req.Header.Add("Content-Type", "application/vnd.api+json")
+	req.Header.Add("Authorization", "Bearer "+token)
+	res, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 201, res.StatusCode)
+	var result map[string]interface{}
+	err = json.NewDecoder(res.Body).Decode(&result)
+	assert.NoError(t, err)
+	data, _ := result["data"].(map[string]interface{})
+	assert.Equal(t, consts.NotesComments, data["type"])
+	id, _ := data["id"].(string)
+	commentID := strings.TrimPrefix(id, noteID+"/")
+	attrs, _ := data["attributes"].(map[string]interface{})
+	assert.EqualValues(t, 1, attrs["from"])
+	assert.EqualValues(t, 2, attrs["to"])
+	messages, _ := attrs["messages"].([]interface{})
+	assert.Len(t, messages, 1)
+
+	path := "/notes/" + noteID + "/comments/" + commentID
+	body = `{
+  "data": {
+    "type": "io.cozy.notes.comments",
+    "attributes": { "text": "Yes, it is." }
+  }
+}`
+	req, _ = http.NewRequest("POST", ts.URL+path+"/replies", bytes.NewBufferString(body))
+	req.Header.Add("Content-Type", "application/vnd.api+json")
+	req.Header.Add("Authorization", "Bearer "+token)
+	res, err = http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, res.StatusCode)
+
+	req, _ = http.NewRequest("POST", ts.URL+path+"/resolve", nil)
+	req.Header.Add("Authorization", "Bearer "+token)
+	res, err = http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, res.StatusCode)
+	err = json.NewDecoder(res.Body).Decode(&result)
+	assert.NoError(t, err)
+	data, _ = result["data"].(map[string]interface{})
+	attrs, _ = data["attributes"].(map[string]interface{})
+	assert.Equal(t, true, attrs["resolved"])
+	messages, _ = attrs["messages"].([]interface{})
+	assert.Len(t, messages, 2)
+
+	req, _ = http.NewRequest("POST", ts.URL+path+"/reopen", nil)
+	req.Header.Add("Authorization", "Bearer "+token)
+	res, err = http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, res.StatusCode)
+
+	// A suggestion is not applied until it is accepted
+	body = `{
+  "data": {
+    "type": "io.cozy.notes.comments",
+    "attributes": {
+      "from": 1,
+      "to": 1,
+      "text": "A better start",
+      "steps": [{
+        "stepType": "replace",
+        "from": 1,
+        "to": 1,
+        "slice": {
+          "content": [{ "type": "text", "text": "Oh! " }]
+        }
+      }]
+    }
+  }
+}`
+	req, _ = http.NewRequest("POST", ts.URL+"/notes/"+noteID+"/comments", bytes.NewBufferString(body))
+	req.Header.Add("Content-Type", "application/vnd.api+json")
+	req.Header.Add("Authorization", "Bearer "+token)
+	res, err = http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 201, res.StatusCode)
+	err = json.NewDecoder(res.Body).Decode(&result)
+	assert.NoError(t, err)
+	data, _ = result["data"].(map[string]interface{})
+	id, _ = data["id"].(string)
+	suggestionID := strings.TrimPrefix(id, noteID+"/")
+	attrs, _ = data["attributes"].(map[string]interface{})
+	suggestion, _ := attrs["suggestion"].(map[string]interface{})
+	assert.Equal(t, "pending", suggestion["state"])
+	version, _ := attrs["version"].(float64)
+
+	path = "/notes/" + noteID + "/comments/" + suggestionID
+	req, _ = http.NewRequest("POST", ts.URL+path+"/accept?SessionID=543781490137", nil)
+	req.Header.Add("Authorization", "Bearer "+token)
+	res, err = http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, res.StatusCode)
+	err = json.NewDecoder(res.Body).Decode(&result)
+	assert.NoError(t, err)
+	data, _ = result["data"].(map[string]interface{})
+	attrs, _ = data["attributes"].(map[string]interface{})
+	meta, _ := attrs["metadata"].(map[string]interface{})
+	assert.EqualValues(t, version+1, meta["version"])
+
+	req, _ = http.NewRequest("POST", ts.URL+path+"/reject", nil)
+	req.Header.Add("Authorization", "Bearer "+token)
+	res, err = http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 400, res.StatusCode)
+
+	// The anchor of the first comment has been mapped after the insertion
+	req, _ = http.NewRequest("GET", ts.URL+"/notes/"+noteID+"/comments/"+commentID, nil)
+	req.Header.Add("Authorization", "Bearer "+token)
+	res, err = http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, res.StatusCode)
+	err = json.NewDecoder(res.Body).Decode(&result)
+	assert.NoError(t, err)
+	data, _ = result["data"].(map[string]interface{})
+	attrs, _ = data["attributes"].(map[string]interface{})
+	assert.EqualValues(t, 5, attrs["from"])
+	assert.EqualValues(t, 6, attrs["to"])
+}
+
+func TestNoteExport(t *testing.T) {
+	formats := map[string]string{
+		"html": "text/html; charset=utf-8",
+		"pdf":  "application/pdf",
+		"docx": "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+		"odt":  "application/vnd.oasis.opendocument.text",
+	}
+	for format, mime := range formats {
+		req, _ := http.NewRequest("GET", ts.URL+"/notes/"+noteID+"/export?format="+format, nil)
+		req.Header.Add("Authorization", "Bearer "+token)
+		res, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		assert.Equal(t, 200, res.StatusCode)
+		assert.Equal(t, mime, res.Header.Get("Content-Type"))
+		assert.Contains(t, res.Header.Get("Content-Disposition"), "attachment")
+		content, err := ioutil.ReadAll(res.Body)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, content)
+	}
+
+	req, _ := http.NewRequest("GET", ts.URL+"/notes/"+noteID+"/export?format=rtf", nil)
+	req.Header.Add("Authorization", "Bearer "+token)
+	res, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 400, res.StatusCode)
+}
+
 func TestMain(m *testing.M) {
 	config.UseTestFile()
 	testutils.NeedCouchdb()
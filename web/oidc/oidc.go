@@ -126,8 +126,8 @@ func Login(c echo.Context) error {
 	}
 
 	// Check 2FA if enabled
-	if inst.HasAuthMode(instance.TwoFactorMail) {
-		twoFactorToken, err := lifecycle.SendTwoFactorPasscode(inst)
+	if inst.HasTwoFactor() {
+		twoFactorToken, err := lifecycle.StartTwoFactor(inst)
 		if err != nil {
 			return err
 		}
@@ -2,12 +2,14 @@ package permissions
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/cozy/cozy-stack/model/audit"
 	"github.com/cozy/cozy-stack/model/oauth"
 	"github.com/cozy/cozy-stack/model/permission"
 	"github.com/cozy/cozy-stack/model/sharing"
@@ -42,7 +44,11 @@ type APIPermission struct {
 
 // MarshalJSON implements jsonapi.Doc
 func (p *APIPermission) MarshalJSON() ([]byte, error) {
-	return json.Marshal(p.Permission)
+	// The password hash is never sent, only the fact that there is one
+	return json.Marshal(struct {
+		*permission.Permission
+		Password bool `json:"password,omitempty"`
+	}{p.Permission, p.HasPassword()})
 }
 
 // Relationships implements jsonapi.Doc
@@ -75,6 +81,49 @@ func (m *apiMember) Relationships() jsonapi.RelationshipMap { return nil }
 func (m *apiMember) Included() []jsonapi.Object             { return nil }
 func (m *apiMember) Links() *jsonapi.LinksList              { return nil }
 
+// shareLinkOptions are the options for a share by link that can be given when
+// the permission is created or patched.
+type shareLinkOptions struct {
+	permission.Permission
+	Password     *string `json:"password,omitempty"`
+	MaxViews     *int    `json:"max_views,omitempty"`
+	MaxDownloads *int    `json:"max_downloads,omitempty"`
+}
+
+func (o *shareLinkOptions) hasOptions() bool {
+	return o.Password != nil || o.MaxViews != nil || o.MaxDownloads != nil ||
+		o.FileDrop != nil
+}
+
+// apply sets the options on the given permission doc.
+func (o *shareLinkOptions) apply(doc *permission.Permission) error {
+	if o.MaxViews != nil {
+		if *o.MaxViews < 0 {
+			return jsonapi.InvalidAttribute("max_views", errors.New("must be positive"))
+		}
+		doc.MaxViews = *o.MaxViews
+	}
+	if o.MaxDownloads != nil {
+		if *o.MaxDownloads < 0 {
+			return jsonapi.InvalidAttribute("max_downloads", errors.New("must be positive"))
+		}
+		doc.MaxDownloads = *o.MaxDownloads
+	}
+	if o.FileDrop != nil && doc.Type == permission.TypeFileDrop {
+		if err := o.FileDrop.Validate(); err != nil {
+			return err
+		}
+		if doc.FileDrop != nil {
+			o.FileDrop.Files = doc.FileDrop.Files
+		}
+		doc.FileDrop = o.FileDrop
+	}
+	if o.Password != nil {
+		return doc.SetPassword(*o.Password)
+	}
+	return nil
+}
+
 type getPermsFunc func(db prefixer.Prefixer, id string) (*permission.Permission, error)
 
 func displayPermissions(c echo.Context) error {
@@ -96,9 +145,10 @@ func displayPermissions(c echo.Context) error {
 		}
 	}
 
-	// XXX hides the codes in the response
+	// XXX hides the codes and the access log in the response
 	doc.Codes = nil
 	doc.ShortCodes = nil
+	doc.AccessLog = nil
 	return jsonapi.Data(c, http.StatusOK, &APIPermission{doc, included}, nil)
 }
 
@@ -125,8 +175,14 @@ func createPermission(c echo.Context) error {
 		}
 	}
 
-	var subdoc permission.Permission
-	if _, err = jsonapi.Bind(c.Request().Body, &subdoc); err != nil {
+	var opts shareLinkOptions
+	if _, err = jsonapi.Bind(c.Request().Body, &opts); err != nil {
+		return err
+	}
+	subdoc := opts.Permission
+	subdoc.Views, subdoc.Downloads, subdoc.AccessLog = 0, 0, nil
+	subdoc.Password = nil
+	if err = opts.apply(&subdoc); err != nil {
 		return err
 	}
 
@@ -184,7 +240,12 @@ func createPermission(c echo.Context) error {
 		subdoc.Metadata.EnsureCreatedFields(md)
 	}
 
-	pdoc, err := permission.CreateShareSet(instance, parent, sourceID, codes, shortcodes, subdoc, expiresAt)
+	var pdoc *permission.Permission
+	if subdoc.FileDrop != nil {
+		pdoc, err = permission.CreateFileDropSet(instance, parent, sourceID, codes, shortcodes, subdoc, expiresAt)
+	} else {
+		pdoc, err = permission.CreateShareSet(instance, parent, sourceID, codes, shortcodes, subdoc, expiresAt)
+	}
 	if err != nil {
 		return err
 	}
@@ -307,15 +368,17 @@ func patchPermission(getPerms getPermsFunc, paramName string) echo.HandlerFunc {
 			return err
 		}
 
-		var patch permission.Permission
-		if _, err = jsonapi.Bind(c.Request().Body, &patch); err != nil {
+		var opts shareLinkOptions
+		if _, err = jsonapi.Bind(c.Request().Body, &opts); err != nil {
 			return err
 		}
+		patch := opts.Permission
 
 		patchSet := patch.Permissions != nil && len(patch.Permissions) > 0
 		patchCodes := len(patch.Codes) > 0
+		patchOptions := opts.hasOptions()
 
-		if patchCodes == patchSet {
+		if patchCodes && patchSet || (!patchCodes && !patchSet && !patchOptions) {
 			return ErrPatchCodeOrSet
 		}
 
@@ -324,6 +387,15 @@ func patchPermission(getPerms getPermsFunc, paramName string) echo.HandlerFunc {
 			return err
 		}
 
+		if patchOptions {
+			if !current.CanUpdateShareByLink(toPatch) {
+				return permission.ErrNotParent
+			}
+			if err = opts.apply(toPatch); err != nil {
+				return err
+			}
+		}
+
 		if patchCodes {
 			if !current.CanUpdateShareByLink(toPatch) {
 				return permission.ErrNotParent
@@ -331,6 +403,7 @@ func patchPermission(getPerms getPermsFunc, paramName string) echo.HandlerFunc {
 			toPatch.PatchCodes(patch.Codes)
 		}
 
+		var added []permission.Rule
 		if patchSet {
 			for _, r := range patch.Permissions {
 				if r.Type == "" {
@@ -339,6 +412,7 @@ func patchPermission(getPerms getPermsFunc, paramName string) echo.HandlerFunc {
 					return err
 				} else if current.Permissions.RuleInSubset(r) {
 					toPatch.AddRules(r)
+					added = append(added, r)
 				} else {
 					return permission.ErrNotSubset
 				}
@@ -364,6 +438,20 @@ func patchPermission(getPerms getPermsFunc, paramName string) echo.HandlerFunc {
 			return err
 		}
 
+		if len(added) > 0 {
+			details := map[string]interface{}{"type": toPatch.Type}
+			if scope, err := permission.Set(added).MarshalScopeString(); err == nil {
+				details["scope"] = scope
+			}
+			audit.Record(instance, &audit.Entry{
+				Action:  audit.ActionPermissionGranted,
+				Actor:   current.SourceID,
+				IP:      c.RealIP(),
+				Target:  consts.Permissions + "/" + toPatch.ID(),
+				Details: details,
+			})
+		}
+
 		return jsonapi.Data(c, http.StatusOK, &APIPermission{toPatch, nil}, nil)
 	}
 }
@@ -0,0 +1,333 @@
+package public
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/cozy/cozy-stack/model/instance"
+	"github.com/cozy/cozy-stack/model/notification"
+	"github.com/cozy/cozy-stack/model/notification/center"
+	"github.com/cozy/cozy-stack/model/permission"
+	"github.com/cozy/cozy-stack/model/vfs"
+	"github.com/cozy/cozy-stack/pkg/consts"
+	"github.com/cozy/cozy-stack/pkg/limits"
+	"github.com/cozy/cozy-stack/web/middlewares"
+	"github.com/labstack/echo/v4"
+)
+
+// fileDropError is an error on a file drop, with a translation key for the
+// message that is displayed to the visitor.
+type fileDropError struct {
+	code int
+	key  string
+}
+
+func (e *fileDropError) Error() string { return e.key }
+
+var (
+	errFileDropInvalid   = &fileDropError{http.StatusBadRequest, "File Drop Invalid request"}
+	errFileDropNoFile    = &fileDropError{http.StatusBadRequest, "File Drop No file"}
+	errFileDropName      = &fileDropError{http.StatusBadRequest, "File Drop Name required"}
+	errFileDropPassword  = &fileDropError{http.StatusForbidden, "File Drop Invalid password"}
+	errFileDropTooBig    = &fileDropError{http.StatusRequestEntityTooLarge, "File Drop Too big"}
+	errFileDropType      = &fileDropError{http.StatusUnsupportedMediaType, "File Drop Type not allowed"}
+	errFileDropExhausted = &fileDropError{http.StatusGone, "File Drop Exhausted"}
+	errFileDropTooMany   = &fileDropError{http.StatusTooManyRequests, "File Drop Too many requests"}
+	errFileDropServer    = &fileDropError{http.StatusInternalServerError, "File Drop Server error"}
+	errFileDropBusy      = &fileDropError{http.StatusServiceUnavailable, "File Drop Server error"}
+)
+
+// FileDropPage shows the page where anonymous visitors can upload files via
+// a file drop link.
+func FileDropPage(c echo.Context) error {
+	pdoc, err := getFileDrop(c)
+	if err != nil {
+		return err
+	}
+	return renderFileDrop(c, pdoc, http.StatusOK, nil, 0)
+}
+
+// FileDropUpload receives the files uploaded by an anonymous visitor via a
+// file drop link. The request body is a multipart form, where the uploader
+// and password fields must come before the files.
+func FileDropUpload(c echo.Context) error {
+	inst := middlewares.GetInstance(c)
+	pdoc, err := getFileDrop(c)
+	if err != nil {
+		return err
+	}
+
+	key := inst.Domain + ":" + pdoc.ID()
+	if err := limits.CheckRateLimitKey(key, limits.SharingPublicLinkType); limits.IsLimitReachedOrExceeded(err) {
+		return renderFileDrop(c, pdoc, 0, errFileDropTooMany, 0)
+	}
+
+	fs := inst.VFS()
+	dir, err := fs.DirByID(pdoc.FileDropDirID())
+	if err != nil || strings.HasPrefix(dir.Fullpath, vfs.TrashDirName) {
+		return renderFileDrop(c, pdoc, 0, errFileDropExhausted, 0)
+	}
+
+	reader, err := c.Request().MultipartReader()
+	if err != nil {
+		return renderFileDrop(c, pdoc, 0, errFileDropInvalid, 0)
+	}
+
+	var uploader, password string
+	var names []string
+	checked := false
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return renderFileDrop(c, pdoc, 0, errFileDropInvalid, len(names))
+		}
+
+		switch part.FormName() {
+		case "uploader":
+			uploader = readFormField(part)
+		case "password":
+			password = readFormField(part)
+		case "files":
+			if part.FileName() == "" {
+				continue
+			}
+			if !checked {
+				if err := checkFileDropVisitor(inst, pdoc, uploader, password); err != nil {
+					return renderFileDrop(c, pdoc, 0, err, 0)
+				}
+				checked = true
+			}
+			name, err := dropFile(inst, pdoc, dir, part, uploader, c.RealIP())
+			if err != nil {
+				notifyFileDrop(inst, dir, uploader, names)
+				return renderFileDrop(c, pdoc, 0, err, len(names))
+			}
+			names = append(names, name)
+		}
+	}
+
+	if len(names) == 0 {
+		return renderFileDrop(c, pdoc, 0, errFileDropNoFile, 0)
+	}
+	notifyFileDrop(inst, dir, uploader, names)
+	return renderFileDrop(c, pdoc, http.StatusCreated, nil, len(names))
+}
+
+// getFileDrop returns the permission document for the code in the URL, that
+// can be a sharecode or a shortcode.
+func getFileDrop(c echo.Context) (*permission.Permission, error) {
+	inst := middlewares.GetInstance(c)
+	code := c.Param("code")
+	if !strings.Contains(code, ".") {
+		if token, err := permission.GetTokenFromShortcode(inst, code); err == nil {
+			code = token
+		}
+	}
+	pdoc, err := permission.GetForShareCode(inst, code)
+	if err != nil {
+		return nil, err
+	}
+	if pdoc.Type != permission.TypeFileDrop {
+		return nil, permission.ErrInvalidToken
+	}
+	return pdoc, nil
+}
+
+func checkFileDropVisitor(inst *instance.Instance, pdoc *permission.Permission, uploader, password string) error {
+	if pdoc.FileDrop != nil && pdoc.FileDrop.RequireName && uploader == "" {
+		return errFileDropName
+	}
+	if pdoc.HasPassword() {
+		key := inst.Domain + ":" + pdoc.ID()
+		if err := limits.CheckRateLimitKey(key, limits.SharingPublicLinkPasswordType); limits.IsLimitReachedOrExceeded(err) {
+			return errFileDropTooMany
+		}
+		if !pdoc.CheckPassword(password) {
+			return errFileDropPassword
+		}
+	}
+	if pdoc.FileDrop.Exhausted() {
+		return errFileDropExhausted
+	}
+	return nil
+}
+
+func readFormField(part *multipart.Part) string {
+	b, err := ioutil.ReadAll(io.LimitReader(part, 256))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(b))
+}
+
+// dropFile saves a file uploaded via a file drop link in the directory, and
+// returns its name (a suffix is added in case of conflict).
+func dropFile(inst *instance.Instance, pdoc *permission.Permission, dir *vfs.DirDoc, part *multipart.Part, uploader, ip string) (string, error) {
+	opts := pdoc.FileDrop
+	if opts.Exhausted() {
+		return "", errFileDropExhausted
+	}
+
+	name := path.Base(strings.ReplaceAll(part.FileName(), "\\", "/"))
+	mime, class := vfs.ExtractMimeAndClassFromFilename(name)
+	if !opts.AllowMime(mime) {
+		return "", errFileDropType
+	}
+
+	fs := inst.VFS()
+	name, err := availableName(fs, dir.DocID, name)
+	if err != nil {
+		return "", errFileDropServer
+	}
+	doc, err := vfs.NewFileDoc(name, dir.DocID, -1, nil, mime, class, time.Now(), false, false, nil)
+	if err != nil {
+		return "", errFileDropInvalid
+	}
+	doc.CozyMetadata = vfs.NewCozyMetadata(inst.PageURL("/", nil))
+	uploadedAt := doc.CozyMetadata.CreatedAt
+	doc.CozyMetadata.UploadedAt = &uploadedAt
+	doc.CozyMetadata.UploadedOn = doc.CozyMetadata.CreatedOn
+	doc.CozyMetadata.UploadedBy = &vfs.UploadedByEntry{
+		Client: map[string]string{"kind": "file-drop", "name": uploader},
+	}
+
+	file, err := fs.CreateFile(doc, nil)
+	if err != nil {
+		if err == vfs.ErrFileTooBig {
+			return "", errFileDropTooBig
+		}
+		return "", errFileDropServer
+	}
+	var src io.Reader = part
+	if opts != nil && opts.MaxFileSize > 0 {
+		src = io.LimitReader(part, opts.MaxFileSize+1)
+	}
+	n, err := io.Copy(file, src)
+	if cerr := file.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		inst.Logger().WithNamespace("file-drop").
+			Infof("Cannot upload %s: %s", name, err)
+		if err == vfs.ErrFileTooBig {
+			return "", errFileDropTooBig
+		}
+		return "", errFileDropServer
+	}
+	if !opts.AllowSize(n) {
+		_ = fs.DestroyFile(doc)
+		return "", errFileDropTooBig
+	}
+
+	if err := pdoc.RecordAccess(inst, permission.AccessDrop, ip); err != nil {
+		_ = fs.DestroyFile(doc)
+		if errors.Is(err, permission.ErrShareLinkExhausted) {
+			return "", errFileDropExhausted
+		}
+		if errors.Is(err, permission.ErrShareLinkConflict) {
+			return "", errFileDropBusy
+		}
+		return "", errFileDropServer
+	}
+	return name, nil
+}
+
+// availableName returns a name for a new file in the directory, by adding a
+// suffix like " (2)" if a file already exists with the given name.
+func availableName(fs vfs.VFS, dirID, name string) (string, error) {
+	ext := path.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	candidate := name
+	for i := 2; i < 1000; i++ {
+		exists, err := fs.DirChildExists(dirID, candidate)
+		if err != nil {
+			return "", err
+		}
+		if !exists {
+			return candidate, nil
+		}
+		candidate = fmt.Sprintf("%s (%d)%s", base, i, ext)
+	}
+	return "", errors.New("too many files with the same name")
+}
+
+func notifyFileDrop(inst *instance.Instance, dir *vfs.DirDoc, uploader string, names []string) {
+	if len(names) == 0 {
+		return
+	}
+	if uploader == "" {
+		uploader = inst.Translate("Notification File Drop Someone")
+	}
+	link := inst.SubDomain(consts.DriveSlug)
+	link.Fragment = "/folder/" + dir.ID()
+	title := inst.Translate("Notification File Drop Title", uploader, len(names), dir.DocName)
+	list := strings.Join(names, "\n")
+	var items strings.Builder
+	for _, name := range names {
+		items.WriteString("<li>" + html.EscapeString(name) + "</li>")
+	}
+	n := &notification.Notification{
+		Title:   title,
+		Message: list,
+		Content: fmt.Sprintf("%s\n\n%s\n\n%s", title, list, link.String()),
+		ContentHTML: fmt.Sprintf(`<p>%s</p><ul>%s</ul><p><a href="%s">%s</a></p>`,
+			html.EscapeString(title), items.String(),
+			html.EscapeString(link.String()),
+			html.EscapeString(inst.Translate("Notification File Drop Link"))),
+		Data: map[string]interface{}{
+			"dir_id": dir.ID(),
+			"files":  names,
+		},
+	}
+	if err := center.PushStack(inst.Domain, center.NotificationFileDrop, n); err != nil {
+		inst.Logger().WithNamespace("file-drop").
+			Warnf("Cannot send notification for file drop: %s", err)
+	}
+}
+
+func renderFileDrop(c echo.Context, pdoc *permission.Permission, code int, err error, sent int) error {
+	inst := middlewares.GetInstance(c)
+	var errorKey string
+	if e, ok := err.(*fileDropError); ok {
+		code = e.code
+		errorKey = e.key
+	}
+	if c.Request().Header.Get(echo.HeaderAccept) == echo.MIMEApplicationJSON {
+		if errorKey != "" {
+			return c.JSON(code, echo.Map{"error": inst.Translate(errorKey), "sent": sent})
+		}
+		return c.JSON(code, echo.Map{"sent": sent})
+	}
+
+	publicName, _ := inst.PublicName()
+	opts := pdoc.FileDrop
+	if opts == nil {
+		opts = &permission.FileDropOptions{}
+	}
+	return c.Render(code, "file_drop.html", echo.Map{
+		"Domain":       inst.ContextualDomain(),
+		"ContextName":  inst.ContextName,
+		"Locale":       inst.Locale,
+		"Title":        inst.Translate("File Drop Title", publicName),
+		"ThemeCSS":     middlewares.ThemeCSS(inst),
+		"Favicon":      middlewares.Favicon(inst),
+		"Action":       c.Request().URL.Path,
+		"RequireName":  opts.RequireName,
+		"HasPassword":  pdoc.HasPassword(),
+		"AllowedTypes": strings.Join(opts.AllowedTypes, ","),
+		"MaxFileSize":  opts.MaxFileSize,
+		"Error":        errorKey,
+		"Sent":         sent,
+	})
+}
@@ -0,0 +1,211 @@
+package public
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/cozy/cozy-stack/model/instance"
+	"github.com/cozy/cozy-stack/model/instance/lifecycle"
+	"github.com/cozy/cozy-stack/model/notification"
+	"github.com/cozy/cozy-stack/model/notification/center"
+	"github.com/cozy/cozy-stack/model/permission"
+	"github.com/cozy/cozy-stack/model/vfs"
+	"github.com/cozy/cozy-stack/pkg/config/config"
+	"github.com/cozy/cozy-stack/pkg/consts"
+	"github.com/cozy/cozy-stack/pkg/couchdb"
+	"github.com/cozy/cozy-stack/pkg/crypto"
+	"github.com/cozy/cozy-stack/tests/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var ts *httptest.Server
+var inst *instance.Instance
+
+func createFileDrop(t *testing.T, dirName, code string, opts *permission.FileDropOptions, password string) *vfs.DirDoc {
+	fs := inst.VFS()
+	dir, err := vfs.NewDirDoc(fs, dirName, consts.RootDirID, nil)
+	require.NoError(t, err)
+	require.NoError(t, fs.CreateDir(dir))
+
+	parent := &permission.Permission{
+		Type: permission.TypeWebapp,
+		Permissions: permission.Set{
+			permission.Rule{Type: consts.Files, Verbs: permission.ALL},
+		},
+	}
+	subdoc := permission.Permission{
+		Permissions: permission.Set{
+			permission.Rule{
+				Type:   consts.Files,
+				Verbs:  permission.Verbs(permission.POST),
+				Values: []string{dir.ID()},
+			},
+		},
+		FileDrop: opts,
+	}
+	if password != "" {
+		subdoc.Password, err = crypto.GenerateFromPassphrase([]byte(password))
+		require.NoError(t, err)
+	}
+	codes := map[string]string{"email": code}
+	_, err = permission.CreateFileDropSet(inst, parent, "io.cozy.apps/drive", codes, nil, subdoc, nil)
+	require.NoError(t, err)
+	return dir
+}
+
+type dropPart struct {
+	field, filename, content string
+}
+
+func uploadToFileDrop(t *testing.T, code string, parts ...dropPart) (int, map[string]interface{}) {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	for _, p := range parts {
+		if p.filename == "" {
+			require.NoError(t, w.WriteField(p.field, p.content))
+			continue
+		}
+		fw, err := w.CreateFormFile(p.field, p.filename)
+		require.NoError(t, err)
+		_, err = fw.Write([]byte(p.content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+
+	req, _ := http.NewRequest("POST", ts.URL+"/public/drop/"+code, &body)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	req.Header.Set("Accept", "application/json")
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	var result map[string]interface{}
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&result))
+	return res.StatusCode, result
+}
+
+func fileDropNotifications(t *testing.T) []*notification.Notification {
+	var all []*notification.Notification
+	err := couchdb.GetAllDocs(inst, consts.Notifications, &couchdb.AllDocsRequest{}, &all)
+	if couchdb.IsNoDatabaseError(err) {
+		return nil
+	}
+	require.NoError(t, err)
+	var notifs []*notification.Notification
+	for _, n := range all {
+		if n.Category == center.NotificationFileDrop {
+			notifs = append(notifs, n)
+		}
+	}
+	return notifs
+}
+
+func TestFileDropUpload(t *testing.T) {
+	dir := createFileDrop(t, "Drop box", "dropcode", &permission.FileDropOptions{
+		MaxFiles:    3,
+		RequireName: true,
+	}, "")
+
+	status, result := uploadToFileDrop(t, "dropcode",
+		dropPart{field: "files", filename: "foo.txt", content: "foo"})
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Equal(t, "Please give your name.", result["error"])
+
+	status, result = uploadToFileDrop(t, "dropcode",
+		dropPart{field: "uploader", content: "Bob"},
+		dropPart{field: "files", filename: "foo.txt", content: "foo"},
+		dropPart{field: "files", filename: "foo.txt", content: "bar"})
+	assert.Equal(t, http.StatusCreated, status)
+	assert.EqualValues(t, 2, result["sent"])
+
+	fs := inst.VFS()
+	file, err := fs.FileByPath("/Drop box/foo.txt")
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, file.ByteSize)
+	assert.Equal(t, "file-drop", file.CozyMetadata.UploadedBy.Client["kind"])
+	assert.Equal(t, "Bob", file.CozyMetadata.UploadedBy.Client["name"])
+	_, err = fs.FileByPath("/Drop box/foo (2).txt")
+	assert.NoError(t, err)
+
+	notifs := fileDropNotifications(t)
+	require.Len(t, notifs, 1)
+	n := notifs[0]
+	assert.Equal(t, "Bob has sent 2 file(s) in Drop box", n.Title)
+	assert.Equal(t, "foo.txt\nfoo (2).txt", n.Message)
+	assert.Contains(t, n.ContentHTML, "<li>foo (2).txt</li>")
+	assert.Contains(t, n.ContentHTML, "/folder/"+dir.ID())
+	assert.Contains(t, n.ContentHTML, "Open the folder")
+
+	// The link can be used for 3 files
+	status, result = uploadToFileDrop(t, "dropcode",
+		dropPart{field: "uploader", content: "Bob"},
+		dropPart{field: "files", filename: "bar.txt", content: "bar"},
+		dropPart{field: "files", filename: "baz.txt", content: "baz"})
+	assert.Equal(t, http.StatusGone, status)
+	assert.EqualValues(t, 1, result["sent"])
+	assert.Equal(t, "This link can no longer be used to send files.", result["error"])
+	assert.Len(t, fileDropNotifications(t), 2)
+}
+
+func TestFileDropPasswordAndTypes(t *testing.T) {
+	createFileDrop(t, "Invoices", "invoicescode", &permission.FileDropOptions{
+		AllowedTypes: []string{"application/pdf"},
+		MaxFileSize:  5,
+	}, "secret")
+	before := len(fileDropNotifications(t))
+
+	status, _ := uploadToFileDrop(t, "invoicescode",
+		dropPart{field: "password", content: "wrong"},
+		dropPart{field: "files", filename: "invoice.pdf", content: "pdf"})
+	assert.Equal(t, http.StatusForbidden, status)
+
+	status, _ = uploadToFileDrop(t, "invoicescode",
+		dropPart{field: "password", content: "secret"},
+		dropPart{field: "files", filename: "invoice.txt", content: "txt"})
+	assert.Equal(t, http.StatusUnsupportedMediaType, status)
+
+	status, _ = uploadToFileDrop(t, "invoicescode",
+		dropPart{field: "password", content: "secret"},
+		dropPart{field: "files", filename: "invoice.pdf", content: "too big"})
+	assert.Equal(t, http.StatusRequestEntityTooLarge, status)
+	_, err := inst.VFS().FileByPath("/Invoices/invoice.pdf")
+	assert.Error(t, err)
+	assert.Len(t, fileDropNotifications(t), before)
+
+	// The notification is in the locale of the instance
+	inst.Locale = "fr"
+	defer func() { inst.Locale = "en" }()
+	status, _ = uploadToFileDrop(t, "invoicescode",
+		dropPart{field: "password", content: "secret"},
+		dropPart{field: "files", filename: "invoice.pdf", content: "pdf"})
+	assert.Equal(t, http.StatusCreated, status)
+	notifs := fileDropNotifications(t)
+	require.Len(t, notifs, before+1)
+	titles := make([]string, len(notifs))
+	for i, n := range notifs {
+		titles[i] = n.Title
+	}
+	assert.Contains(t, titles, "Quelqu'un a envoyé 1 fichier(s) dans Invoices")
+}
+
+func TestFileDropUnknownCode(t *testing.T) {
+	req, _ := http.NewRequest("POST", ts.URL+"/public/drop/unknown", nil)
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	assert.NotEqual(t, http.StatusCreated, res.StatusCode)
+}
+
+func TestMain(m *testing.M) {
+	config.UseTestFile()
+	testutils.NeedCouchdb()
+	setup := testutils.NewSetup(m, "public_test")
+	inst = setup.GetTestInstance(&lifecycle.Options{Locale: "en"})
+	ts = setup.GetTestServer("/public", Routes)
+	os.Exit(setup.Run())
+}
@@ -8,8 +8,11 @@ import (
 	"strings"
 	"time"
 
+	"github.com/cozy/cozy-stack/model/permission"
 	"github.com/cozy/cozy-stack/pkg/assets"
 	"github.com/cozy/cozy-stack/pkg/initials"
+	"github.com/cozy/cozy-stack/pkg/jsonapi"
+	"github.com/cozy/cozy-stack/pkg/limits"
 	"github.com/cozy/cozy-stack/web/middlewares"
 	"github.com/cozy/cozy-stack/web/statik"
 	"github.com/labstack/echo/v4"
@@ -41,10 +44,66 @@ func Avatar(c echo.Context) error {
 	return echo.NewHTTPError(http.StatusNotFound, "Page not found")
 }
 
+// UnlockShareLink checks the password of a share by link, and returns a token
+// that can be used to access the shared documents.
+func UnlockShareLink(c echo.Context) error {
+	inst := middlewares.GetInstance(c)
+	var args struct {
+		ShareCode string `json:"sharecode"`
+		Password  string `json:"password"`
+	}
+	if err := c.Bind(&args); err != nil {
+		return jsonapi.BadJSON()
+	}
+	if args.ShareCode == "" {
+		return jsonapi.InvalidParameter("sharecode", permission.ErrInvalidToken)
+	}
+
+	code := args.ShareCode
+	if !strings.Contains(code, ".") {
+		token, err := permission.GetTokenFromShortcode(inst, code)
+		if err == nil {
+			code = token
+		}
+	}
+	pdoc, err := permission.GetForShareCode(inst, code)
+	if err != nil {
+		return err
+	}
+	if pdoc.Type != permission.TypeShareByLink || !pdoc.HasPassword() {
+		return jsonapi.BadRequest(permission.ErrInvalidToken)
+	}
+
+	key := inst.Domain + ":" + pdoc.ID()
+	if err := limits.CheckRateLimitKey(key, limits.SharingPublicLinkPasswordType); limits.IsLimitReachedOrExceeded(err) {
+		return echo.NewHTTPError(http.StatusTooManyRequests, err.Error())
+	}
+	if !pdoc.CheckPassword(args.Password) {
+		return permission.ErrSharePasswordInvalid
+	}
+
+	// The view is counted here, once the password has been checked
+	if err := pdoc.RecordAccess(inst, permission.AccessUnlock, c.RealIP()); err != nil {
+		if err == permission.ErrShareLinkExhausted {
+			return err
+		}
+		inst.Logger().WithNamespace("public").
+			Warnf("Cannot record access to %s: %s", pdoc.ID(), err)
+	}
+	token, err := pdoc.CreateUnlockToken(inst.SessionSecret())
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, echo.Map{"token": token})
+}
+
 // Routes sets the routing for the public service
 func Routes(router *echo.Group) {
 	cacheControl := middlewares.CacheControl(middlewares.CacheOptions{
 		MaxAge: 24 * time.Hour,
 	})
 	router.GET("/avatar", Avatar, cacheControl, middlewares.NeedInstance)
+	router.POST("/share/unlock", UnlockShareLink, middlewares.NeedInstance)
+	router.GET("/drop/:code", FileDropPage, middlewares.NeedInstance)
+	router.POST("/drop/:code", FileDropUpload, middlewares.NeedInstance)
 }
@@ -2,9 +2,11 @@ package realtime
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/cozy/cozy-stack/model/instance"
@@ -148,8 +150,42 @@ func authorized(i *instance.Instance, perms permission.Set, permType, id string)
 	}
 }
 
+// fieldsFilter keeps the permissions of the websocket, to strip the fields
+// that can't be read from the documents sent with the events.
+type fieldsFilter struct {
+	mu    sync.RWMutex
+	perms permission.Set
+}
+
+func (f *fieldsFilter) setPermissions(perms permission.Set) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.perms = perms
+}
+
+func (f *fieldsFilter) filter(doc realtime.Doc) interface{} {
+	f.mu.RLock()
+	perms := f.perms
+	f.mu.RUnlock()
+	allowed := perms.AllowedFields(permission.GET, doc.DocType())
+	if allowed == nil {
+		return doc
+	}
+	// The document is not sent if it can't be filtered
+	raw, err := json.Marshal(doc)
+	if err != nil {
+		return nil
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return nil
+	}
+	permission.StripFields(m, allowed)
+	return m
+}
+
 func readPump(ctx context.Context, c echo.Context, i *instance.Instance, ws *websocket.Conn,
-	ds *realtime.DynamicSubscriber, errc chan *wsError, withAuthentication bool) {
+	ds *realtime.DynamicSubscriber, errc chan *wsError, ff *fieldsFilter, withAuthentication bool) {
 	defer close(errc)
 
 	var err error
@@ -174,6 +210,7 @@ func readPump(ctx context.Context, c echo.Context, i *instance.Instance, ws *web
 			sendErr(ctx, errc, unauthorized(auth))
 			return
 		}
+		ff.setPermissions(pdoc.Permissions)
 	}
 
 	for {
@@ -269,7 +306,8 @@ func Ws(c echo.Context) error {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 	errc := make(chan *wsError)
-	go readPump(ctx, c, inst, ws, ds, errc, withAuthentication)
+	ff := &fieldsFilter{}
+	go readPump(ctx, c, inst, ws, ds, errc, ff, withAuthentication)
 
 	ticker := time.NewTicker(pingPeriod)
 	defer ticker.Stop()
@@ -295,7 +333,7 @@ func Ws(c echo.Context) error {
 				Payload: wsResponsePayload{
 					Type: e.Doc.DocType(),
 					ID:   e.Doc.ID(),
-					Doc:  e.Doc,
+					Doc:  ff.filter(e.Doc),
 				},
 			}
 			if err := ws.WriteJSON(res); err != nil {
@@ -0,0 +1,140 @@
+package registry
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+
+	"github.com/cozy/cozy-stack/pkg/jsonapi"
+	"github.com/cozy/cozy-stack/pkg/registry"
+	"github.com/labstack/echo/v4"
+)
+
+// The handlers in this file are used when the stack acts as a registry
+// server. They are served on the admin server.
+
+func serverList(c echo.Context) error {
+	cursor, _ := strconv.Atoi(c.QueryParam("cursor"))
+	limit, _ := strconv.Atoi(c.QueryParam("limit"))
+	list, err := registry.ListPublishedApps(cursor, limit)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, list)
+}
+
+func serverMaintenance(c echo.Context) error {
+	return c.JSON(http.StatusOK, []interface{}{})
+}
+
+func serverSigningKeys(c echo.Context) error {
+	return c.JSON(http.StatusOK, echo.Map{"keys": registry.PublishedSigningKeys()})
+}
+
+func serverApp(c echo.Context) error {
+	app, err := registry.GetPublishedApp(c.Param("app"))
+	if err != nil {
+		return wrapServerError(err)
+	}
+	return c.JSON(http.StatusOK, app)
+}
+
+func serverVersion(c echo.Context) error {
+	v, err := registry.GetPublishedVersion(c.Param("app"), c.Param("version"))
+	if err != nil {
+		return wrapServerError(err)
+	}
+	return c.JSON(http.StatusOK, v.Public())
+}
+
+func serverLatest(c echo.Context) error {
+	v, err := registry.LatestPublishedVersion(c.Param("app"), c.Param("channel"))
+	if err != nil {
+		return wrapServerError(err)
+	}
+	return c.JSON(http.StatusOK, v.Public())
+}
+
+func serverTarball(c echo.Context) error {
+	f, err := registry.OpenTarball(c.Param("app"), c.Param("version"))
+	if err != nil {
+		return wrapServerError(err)
+	}
+	defer f.Close()
+	c.Response().Header().Set("Cache-Control", "max-age=31536000, immutable")
+	return c.Stream(http.StatusOK, "application/gzip", f)
+}
+
+func serverIcon(c echo.Context) error {
+	slug := c.Param("app")
+	version := c.Param("version")
+	if version == "" {
+		app, err := registry.GetPublishedApp(slug)
+		if err != nil {
+			return wrapServerError(err)
+		}
+		version = app.LatestVersion.Version.Version
+	}
+	v, err := registry.GetPublishedVersion(slug, version)
+	if err != nil {
+		return wrapServerError(err)
+	}
+	var man struct {
+		Icon string `json:"icon"`
+	}
+	if err := json.Unmarshal(v.Manifest, &man); err != nil || man.Icon == "" {
+		return jsonapi.NotFound(registry.ErrNotFound)
+	}
+	f, err := registry.OpenTarballFile(slug, version, man.Icon)
+	if err != nil {
+		return wrapServerError(err)
+	}
+	defer f.Close()
+	contentType := mime.TypeByExtension(path.Ext(man.Icon))
+	if contentType == "" {
+		contentType = echo.MIMEOctetStream
+	}
+	return c.Stream(http.StatusOK, contentType, f)
+}
+
+func serverPublish(c echo.Context) error {
+	v, err := registry.Publish(c.Request().Body, c.QueryParam("signature"))
+	if err != nil {
+		return wrapServerError(err)
+	}
+	return c.JSON(http.StatusCreated, v.Public())
+}
+
+func wrapServerError(err error) error {
+	switch err {
+	case registry.ErrNotFound:
+		return jsonapi.NotFound(err)
+	case registry.ErrVersionExists:
+		return jsonapi.Conflict(err)
+	case registry.ErrInvalidTarball, registry.ErrInvalidSlug, registry.ErrInvalidVersion:
+		return jsonapi.BadRequest(err)
+	case registry.ErrTarballTooLarge:
+		return jsonapi.NewError(http.StatusRequestEntityTooLarge, err.Error())
+	}
+	return err
+}
+
+// ServerRoutes sets the routing for the local registry server. The versions
+// can be read without authentication, as the stacks don't send credentials
+// to their registries, but the publication requires the given middlewares
+// (the admin authentication).
+func ServerRoutes(router *echo.Group, publishMws ...echo.MiddlewareFunc) {
+	router.POST("/versions", serverPublish, publishMws...)
+	router.GET("", serverList)
+	router.GET("/", serverList)
+	router.GET("/maintenance", serverMaintenance)
+	router.GET("/signing-keys", serverSigningKeys)
+	router.GET("/:app", serverApp)
+	router.GET("/:app/icon", serverIcon)
+	router.GET("/:app/:version", serverVersion)
+	router.GET("/:app/:version/icon", serverIcon)
+	router.GET("/:app/:version/tarball", serverTarball)
+	router.GET("/:app/:channel/latest", serverLatest)
+}
@@ -281,6 +281,10 @@ func SetupAdminRoutes(router *echo.Echo) error {
 
 	instances.Routes(router.Group("/instances", mws...))
 	apps.AdminRoutes(router.Group("/konnectors", mws...))
+	apps.RolloutsAdminRoutes(router.Group("/apps", mws...))
+	if config.GetConfig().RegistryServer.Enabled {
+		registry.ServerRoutes(router.Group("/registry"), mws...)
+	}
 	version.Routes(router.Group("/version", mws...))
 	metrics.Routes(router.Group("/metrics", mws...))
 	oauth.Routes(router.Group("/oauth", mws...))
@@ -0,0 +1,171 @@
+package settings
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/cozy/cozy-stack/model/apppassword"
+	"github.com/cozy/cozy-stack/model/audit"
+	"github.com/cozy/cozy-stack/model/permission"
+	"github.com/cozy/cozy-stack/pkg/consts"
+	"github.com/cozy/cozy-stack/pkg/couchdb"
+	"github.com/cozy/cozy-stack/pkg/jsonapi"
+	"github.com/cozy/cozy-stack/web/middlewares"
+	"github.com/labstack/echo/v4"
+)
+
+type apiAppPassword struct{ *apppassword.AppPassword }
+
+// MarshalJSON hides the hash, and adds the login (and the password, just
+// after its creation) for the client.
+func (a *apiAppPassword) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Name       string     `json:"name"`
+		Login      string     `json:"login"`
+		Password   string     `json:"password,omitempty"`
+		Scope      string     `json:"scope,omitempty"`
+		CreatedAt  time.Time  `json:"created_at"`
+		ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+		LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	}{
+		Name:       a.Name,
+		Login:      a.ID(),
+		Password:   a.Password,
+		Scope:      a.Scope,
+		CreatedAt:  a.CreatedAt,
+		ExpiresAt:  a.ExpiresAt,
+		LastUsedAt: a.LastUsedAt,
+	})
+}
+
+func (a *apiAppPassword) Links() *jsonapi.LinksList {
+	return &jsonapi.LinksList{Self: "/settings/app-passwords/" + a.ID()}
+}
+func (a *apiAppPassword) Relationships() jsonapi.RelationshipMap { return nil }
+func (a *apiAppPassword) Included() []jsonapi.Object             { return nil }
+
+// allowAppPasswords checks the permissions for managing the app passwords. A
+// request authenticated with an app password can't manage them.
+func allowAppPasswords(c echo.Context, v permission.Verb) error {
+	if err := middlewares.AllowWholeType(c, v, consts.AppPasswords); err != nil {
+		return err
+	}
+	pdoc, err := middlewares.GetPermission(c)
+	if err != nil {
+		return err
+	}
+	if pdoc.Type == permission.TypeAppPassword {
+		return middlewares.ErrForbidden
+	}
+	return nil
+}
+
+func listAppPasswords(c echo.Context) error {
+	inst := middlewares.GetInstance(c)
+
+	if err := allowAppPasswords(c, permission.GET); err != nil {
+		return err
+	}
+
+	bookmark := c.QueryParam("page[cursor]")
+	limit, err := strconv.ParseInt(c.QueryParam("page[limit]"), 10, 64)
+	if err != nil || limit < 0 || limit > consts.MaxItemsPerPageForMango {
+		limit = 100
+	}
+	passwords, bookmark, err := apppassword.GetAll(inst, int(limit), bookmark)
+	if err != nil {
+		return err
+	}
+
+	objs := make([]jsonapi.Object, len(passwords))
+	for i, a := range passwords {
+		objs[i] = &apiAppPassword{a}
+	}
+
+	links := &jsonapi.LinksList{}
+	if bookmark != "" && len(objs) == int(limit) {
+		v := url.Values{}
+		v.Set("page[cursor]", bookmark)
+		if limit != 100 {
+			v.Set("page[limit]", fmt.Sprintf("%d", limit))
+		}
+		links.Next = "/settings/app-passwords?" + v.Encode()
+	}
+	return jsonapi.DataList(c, http.StatusOK, objs, links)
+}
+
+func createAppPassword(c echo.Context) error {
+	inst := middlewares.GetInstance(c)
+
+	if err := allowAppPasswords(c, permission.POST); err != nil {
+		return err
+	}
+
+	var attrs struct {
+		Name      string     `json:"name"`
+		Scope     string     `json:"scope"`
+		ExpiresAt *time.Time `json:"expires_at"`
+	}
+	if _, err := jsonapi.Bind(c.Request().Body, &attrs); err != nil {
+		return jsonapi.BadJSON()
+	}
+	var set permission.Set
+	if attrs.Scope != "" {
+		var err error
+		set, err = permission.UnmarshalScopeString(attrs.Scope)
+		if err != nil {
+			return jsonapi.InvalidAttribute("scope", err)
+		}
+		for _, rule := range set {
+			if err := permission.CheckDoctypeName(rule.Type, true); err != nil {
+				return jsonapi.InvalidAttribute("scope", err)
+			}
+			if rule.Type == consts.AppPasswords {
+				return jsonapi.InvalidAttribute("scope", permission.ErrBadScope)
+			}
+		}
+	}
+	if attrs.ExpiresAt != nil && attrs.ExpiresAt.Before(time.Now()) {
+		return jsonapi.InvalidAttribute("expires_at", fmt.Errorf("the date is in the past"))
+	}
+
+	a, err := apppassword.Create(inst, attrs.Name, set, attrs.ExpiresAt)
+	if err != nil {
+		return err
+	}
+	audit.Record(inst, &audit.Entry{
+		Action: audit.ActionAppPasswordCreated,
+		Actor:  "owner",
+		IP:     c.RealIP(),
+		Target: consts.AppPasswords + "/" + a.ID(),
+		Details: map[string]interface{}{
+			"name":  a.Name,
+			"scope": a.Scope,
+		},
+	})
+	return jsonapi.Data(c, http.StatusCreated, &apiAppPassword{a}, nil)
+}
+
+func revokeAppPassword(c echo.Context) error {
+	inst := middlewares.GetInstance(c)
+
+	if err := allowAppPasswords(c, permission.DELETE); err != nil {
+		return err
+	}
+
+	a, err := apppassword.Get(inst, c.Param("id"))
+	if err != nil {
+		if couchdb.IsNotFoundError(err) || couchdb.IsNoDatabaseError(err) {
+			return jsonapi.NotFound(err)
+		}
+		return err
+	}
+	if err := a.Delete(inst); err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusNoContent)
+}
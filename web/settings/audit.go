@@ -0,0 +1,130 @@
+package settings
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/cozy/cozy-stack/model/audit"
+	"github.com/cozy/cozy-stack/model/permission"
+	"github.com/cozy/cozy-stack/pkg/consts"
+	"github.com/cozy/cozy-stack/pkg/jsonapi"
+	"github.com/cozy/cozy-stack/web/middlewares"
+	"github.com/labstack/echo/v4"
+)
+
+type apiAuditEntry struct{ *audit.Entry }
+
+func (e *apiAuditEntry) MarshalJSON() ([]byte, error) {
+	return json.Marshal(e.Entry)
+}
+
+func (e *apiAuditEntry) Links() *jsonapi.LinksList              { return nil }
+func (e *apiAuditEntry) Relationships() jsonapi.RelationshipMap { return nil }
+func (e *apiAuditEntry) Included() []jsonapi.Object             { return nil }
+
+// auditFilter reads the filters for the audit log from the query string.
+func auditFilter(c echo.Context) (audit.Filter, error) {
+	filter := audit.Filter{
+		Action: c.QueryParam("filter[action]"),
+		Actor:  c.QueryParam("filter[actor]"),
+	}
+	if since := c.QueryParam("filter[since]"); since != "" {
+		t, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			return filter, jsonapi.InvalidParameter("filter[since]", err)
+		}
+		filter.Since = &t
+	}
+	if until := c.QueryParam("filter[until]"); until != "" {
+		t, err := time.Parse(time.RFC3339, until)
+		if err != nil {
+			return filter, jsonapi.InvalidParameter("filter[until]", err)
+		}
+		filter.Until = &t
+	}
+	return filter, nil
+}
+
+func listAuditLogs(c echo.Context) error {
+	inst := middlewares.GetInstance(c)
+
+	if err := middlewares.AllowWholeType(c, permission.GET, consts.AuditLogs); err != nil {
+		return err
+	}
+
+	filter, err := auditFilter(c)
+	if err != nil {
+		return err
+	}
+	bookmark := c.QueryParam("page[cursor]")
+	limit, err := strconv.ParseInt(c.QueryParam("page[limit]"), 10, 64)
+	if err != nil || limit <= 0 || limit > consts.MaxItemsPerPageForMango {
+		limit = 100
+	}
+	entries, bookmark, err := audit.Find(inst, filter, int(limit), bookmark)
+	if err != nil {
+		return err
+	}
+
+	objs := make([]jsonapi.Object, len(entries))
+	for i, e := range entries {
+		objs[i] = &apiAuditEntry{e}
+	}
+
+	links := &jsonapi.LinksList{}
+	if bookmark != "" && len(objs) == int(limit) {
+		v := c.QueryParams()
+		v.Set("page[cursor]", bookmark)
+		if limit != 100 {
+			v.Set("page[limit]", fmt.Sprintf("%d", limit))
+		}
+		links.Next = "/settings/audit?" + v.Encode()
+	}
+	return jsonapi.DataList(c, http.StatusOK, objs, links)
+}
+
+// exportAuditLogs sends all the entries of the audit log that match the
+// filters, as JSON lines.
+func exportAuditLogs(c echo.Context) error {
+	inst := middlewares.GetInstance(c)
+
+	if err := middlewares.AllowWholeType(c, permission.GET, consts.AuditLogs); err != nil {
+		return err
+	}
+
+	filter, err := auditFilter(c)
+	if err != nil {
+		return err
+	}
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "application/x-ndjson")
+	res.Header().Set(echo.HeaderContentDisposition, `attachment; filename="audit.jsonl"`)
+	res.WriteHeader(http.StatusOK)
+
+	enc := json.NewEncoder(res)
+	bookmark := ""
+	for {
+		entries, next, err := audit.Find(inst, filter, consts.MaxItemsPerPageForMango, bookmark)
+		if err != nil {
+			// The headers have already been sent, we can only log the error
+			inst.Logger().WithNamespace("audit").
+				Errorf("Cannot export the audit log: %s", err)
+			return nil
+		}
+		for _, e := range entries {
+			e.DocRev = ""
+			if err := enc.Encode(e); err != nil {
+				return nil
+			}
+		}
+		res.Flush()
+		if next == "" || len(entries) < consts.MaxItemsPerPageForMango {
+			return nil
+		}
+		bookmark = next
+	}
+}
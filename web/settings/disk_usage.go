@@ -6,6 +6,7 @@ import (
 	"net/http"
 
 	"github.com/cozy/cozy-stack/model/permission"
+	"github.com/cozy/cozy-stack/model/vfs"
 	"github.com/cozy/cozy-stack/pkg/consts"
 	"github.com/cozy/cozy-stack/pkg/couchdb"
 	"github.com/cozy/cozy-stack/pkg/jsonapi"
@@ -19,6 +20,16 @@ type apiDiskUsage struct {
 	Files    int64  `json:"files,string"`
 	Trash    *int64 `json:"trash,string,omitempty"`
 	Versions int64  `json:"versions,string"`
+
+	Apps map[string]*apiAppDiskUsage `json:"apps,omitempty"`
+}
+
+// apiAppDiskUsage is the storage used by the files created by an application,
+// with its quotas.
+type apiAppDiskUsage struct {
+	Used      int64 `json:"used,string"`
+	Quota     int64 `json:"quota,string,omitempty"`
+	DocsQuota int64 `json:"docs_quota,omitempty"`
 }
 
 func (j *apiDiskUsage) ID() string                             { return consts.DiskUsageID }
@@ -84,5 +95,23 @@ func diskUsage(c echo.Context) error {
 	result.Quota = quota
 	result.Files = files
 	result.Versions = versions
+
+	apps, err := vfs.AppsDiskUsage(instance)
+	if err != nil {
+		return err
+	}
+	result.Apps = make(map[string]*apiAppDiskUsage, len(apps))
+	for slug, used := range apps {
+		result.Apps[slug] = &apiAppDiskUsage{Used: used}
+	}
+	for slug, quota := range instance.AppsQuotas {
+		usage, ok := result.Apps[slug]
+		if !ok {
+			usage = &apiAppDiskUsage{}
+			result.Apps[slug] = usage
+		}
+		usage.Quota = quota.Disk
+		usage.DocsQuota = quota.Docs
+	}
 	return jsonapi.Data(c, http.StatusOK, &result, nil)
 }
@@ -159,6 +159,14 @@ func updateInstanceAuthMode(c echo.Context) error {
 		if ok := inst.ValidateMailConfirmationCode(args.TwoFactorActivationCode); !ok {
 			return c.NoContent(http.StatusUnprocessableEntity)
 		}
+	case instance.TwoFactorTOTP:
+		if !inst.HasTOTP() {
+			return jsonapi.InvalidParameter("auth_mode", instance.ErrSecondFactorNotEnrolled)
+		}
+	case instance.TwoFactorWebAuthn:
+		if len(inst.WebAuthnCredentials) == 0 {
+			return jsonapi.InvalidParameter("auth_mode", instance.ErrSecondFactorNotEnrolled)
+		}
 	}
 
 	err = lifecycle.Patch(inst, &lifecycle.Options{AuthMode: args.AuthMode})
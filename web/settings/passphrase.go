@@ -9,6 +9,7 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/cozy/cozy-stack/model/audit"
 	"github.com/cozy/cozy-stack/model/bitwarden/settings"
 	"github.com/cozy/cozy-stack/model/instance"
 	"github.com/cozy/cozy-stack/model/instance/lifecycle"
@@ -130,7 +131,7 @@ func registerPassphrase(c echo.Context) error {
 
 func updatePassphrase(c echo.Context) error {
 	inst := middlewares.GetInstance(c)
-	session, hasSession := middlewares.GetSession(c)
+	sess, hasSession := middlewares.GetSession(c)
 
 	// Even if the current passphrase is needed for this request to work, we
 	// enforce a valid permission to avoid having an unauthorized enpoint that
@@ -192,26 +193,36 @@ func updatePassphrase(c echo.Context) error {
 		if err != nil {
 			return err
 		}
+		recordPassphraseChange(c, "force")
 		go func() {
 			_ = sharing.SendPublicKey(inst, params.PublicKey)
 		}()
 		if hasSession {
-			_, _ = auth.SetCookieForNewSession(c, session.LongRun)
+			_, _ = auth.SetCookieForNewSession(c, sess.LongRun)
 		}
 		return c.NoContent(http.StatusNoContent)
 	}
 
 	// Else, we keep going on the standard checks (2FA, current passphrase, ...)
-	if inst.HasAuthMode(instance.TwoFactorMail) && len(args.TwoFactorToken) == 0 {
-		if lifecycle.CheckPassphrase(inst, currentPassphrase) == nil {
+	if inst.HasTwoFactor() && len(args.TwoFactorToken) == 0 {
+		err := session.CheckPassphrase(inst, c.RealIP(), currentPassphrase)
+		if err == session.ErrTooManyAttempts {
+			return jsonapi.Errorf(http.StatusTooManyRequests, "%s", err)
+		}
+		if err == nil {
 			var twoFactorToken []byte
-			twoFactorToken, err = lifecycle.SendTwoFactorPasscode(inst)
+			twoFactorToken, err = lifecycle.StartTwoFactor(inst)
 			if err != nil {
 				return err
 			}
-			return c.JSON(http.StatusOK, echo.Map{
+			result := echo.Map{
 				"two_factor_token": twoFactorToken,
-			})
+				"two_factor_mode":  instance.AuthModeToString(inst.AuthMode),
+			}
+			if inst.HasAuthMode(instance.TwoFactorWebAuthn) {
+				result["webauthn"], _ = inst.WebAuthnAssertionOptions(twoFactorToken)
+			}
+			return c.JSON(http.StatusOK, result)
 		}
 		return instance.ErrInvalidPassphrase
 	}
@@ -225,6 +236,18 @@ func updatePassphrase(c echo.Context) error {
 		return jsonapi.InvalidParameter("KdfIterations", err)
 	}
 
+	// Without two factor authentication, the current passphrase is checked
+	// here too, to count the failed attempts like for the login
+	if !inst.HasTwoFactor() {
+		err := session.CheckPassphrase(inst, c.RealIP(), currentPassphrase)
+		if err == session.ErrTooManyAttempts {
+			return jsonapi.Errorf(http.StatusTooManyRequests, "%s", err)
+		}
+		if err != nil {
+			return jsonapi.BadRequest(instance.ErrInvalidPassphrase)
+		}
+	}
+
 	err = lifecycle.UpdatePassphrase(inst, currentPassphrase,
 		args.TwoFactorPasscode, args.TwoFactorToken,
 		lifecycle.PassParameters{
@@ -235,10 +258,11 @@ func updatePassphrase(c echo.Context) error {
 	if err != nil {
 		return jsonapi.BadRequest(err)
 	}
+	recordPassphraseChange(c, "update")
 
 	longRunSession := true
 	if hasSession {
-		longRunSession = session.LongRun
+		longRunSession = sess.LongRun
 	}
 	if _, err = auth.SetCookieForNewSession(c, longRunSession); err != nil {
 		return err
@@ -247,6 +271,15 @@ func updatePassphrase(c echo.Context) error {
 	return c.NoContent(http.StatusNoContent)
 }
 
+func recordPassphraseChange(c echo.Context, method string) {
+	audit.Record(middlewares.GetInstance(c), &audit.Entry{
+		Action:  audit.ActionPassphraseChanged,
+		Actor:   "owner",
+		IP:      c.RealIP(),
+		Details: map[string]interface{}{"method": method},
+	})
+}
+
 func getHint(c echo.Context) error {
 	inst := middlewares.GetInstance(c)
 
@@ -50,6 +50,27 @@ func getSessions(c echo.Context) error {
 	return jsonapi.DataList(c, http.StatusOK, objs, nil)
 }
 
+func deleteSession(c echo.Context) error {
+	inst := middlewares.GetInstance(c)
+
+	if err := middlewares.AllowWholeType(c, permission.DELETE, consts.Sessions); err != nil {
+		return err
+	}
+
+	id := c.Param("id")
+	if current, ok := middlewares.GetSession(c); ok && current.ID() == id {
+		c.SetCookie(current.Delete(inst))
+		return c.NoContent(http.StatusNoContent)
+	}
+	if err := session.DeleteByID(inst, id); err != nil {
+		if err == session.ErrInvalidID {
+			return jsonapi.NotFound(err)
+		}
+		return err
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
 func warnings(c echo.Context) error {
 	inst := middlewares.GetInstance(c)
 
@@ -94,15 +115,31 @@ func Routes(router *echo.Group) {
 	router.GET("/instance", getInstance)
 	router.PUT("/instance", updateInstance)
 	router.PUT("/instance/auth_mode", updateInstanceAuthMode)
+	router.GET("/two-factor", getTwoFactor)
+	router.POST("/two-factor/totp", beginTOTPEnrolment)
+	router.PUT("/two-factor/totp", confirmTOTPEnrolment)
+	router.DELETE("/two-factor/totp", removeTOTP)
+	router.POST("/two-factor/webauthn", beginWebAuthnRegistration)
+	router.PUT("/two-factor/webauthn", finishWebAuthnRegistration)
+	router.DELETE("/two-factor/webauthn/:id", removeWebAuthnCredential)
+	router.POST("/two-factor/recovery-codes", regenerateRecoveryCodes)
 	router.PUT("/instance/sign_tos", updateInstanceTOS)
 	router.DELETE("/instance/moved_from", clearMovedFrom)
 
 	router.GET("/flags", getFlags)
 
 	router.GET("/sessions", getSessions)
+	router.DELETE("/sessions/:id", deleteSession)
+
+	router.GET("/audit", listAuditLogs)
+	router.GET("/audit/export", exportAuditLogs)
 
 	router.GET("/clients", listClients)
 	router.DELETE("/clients/:id", revokeClient)
+
+	router.GET("/app-passwords", listAppPasswords)
+	router.POST("/app-passwords", createAppPassword)
+	router.DELETE("/app-passwords/:id", revokeAppPassword)
 	router.POST("/synchronized", synchronized)
 
 	router.GET("/onboarded", onboarded)
@@ -17,13 +17,16 @@ import (
 	"github.com/cozy/cozy-stack/model/instance/lifecycle"
 	"github.com/cozy/cozy-stack/model/oauth"
 	"github.com/cozy/cozy-stack/model/session"
+	"github.com/cozy/cozy-stack/model/vfs"
 	"github.com/cozy/cozy-stack/pkg/config/config"
 	"github.com/cozy/cozy-stack/pkg/consts"
 	"github.com/cozy/cozy-stack/pkg/couchdb"
+	"github.com/cozy/cozy-stack/pkg/limits"
 	"github.com/cozy/cozy-stack/tests/testutils"
 	"github.com/cozy/cozy-stack/web/auth"
 	"github.com/cozy/cozy-stack/web/errors"
 	"github.com/labstack/echo/v4"
+	"github.com/pquerna/otp/totp"
 	"github.com/stretchr/testify/assert"
 
 	_ "github.com/cozy/cozy-stack/worker/mails"
@@ -190,6 +193,65 @@ func TestDiskUsage(t *testing.T) {
 	assert.Equal(t, "0", trash)
 }
 
+func TestDiskUsageApps(t *testing.T) {
+	err := lifecycle.Patch(testInstance, &lifecycle.Options{
+		AppQuotaSlug: "quota-app",
+		AppQuota:     &instance.AppQuota{Disk: 1000, Docs: 10},
+	})
+	assert.NoError(t, err)
+	defer func() {
+		_ = lifecycle.Patch(testInstance, &lifecycle.Options{
+			AppQuotaSlug: "quota-app",
+			AppQuota:     &instance.AppQuota{},
+		})
+	}()
+
+	fs := testInstance.VFS()
+	doc, err := vfs.NewFileDoc("quota-app-file", consts.RootDirID, 4, nil,
+		"text/plain", "text", time.Now(), false, false, nil)
+	assert.NoError(t, err)
+	doc.CozyMetadata = vfs.NewCozyMetadata("")
+	doc.CozyMetadata.CreatedByApp = "other-app"
+	file, err := fs.CreateFile(doc, nil)
+	assert.NoError(t, err)
+	_, err = file.Write([]byte("test"))
+	assert.NoError(t, err)
+	assert.NoError(t, file.Close())
+	defer func() {
+		_ = fs.DestroyFile(doc)
+	}()
+
+	req, err := http.NewRequest(http.MethodGet, ts.URL+"/settings/disk-usage", nil)
+	assert.NoError(t, err)
+	req.Header.Add("Authorization", "Bearer "+token)
+	res, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, res.StatusCode)
+	var result map[string]interface{}
+	err = json.NewDecoder(res.Body).Decode(&result)
+	assert.NoError(t, err)
+	data, _ := result["data"].(map[string]interface{})
+	attrs, _ := data["attributes"].(map[string]interface{})
+	apps, ok := attrs["apps"].(map[string]interface{})
+	if !assert.True(t, ok) {
+		return
+	}
+
+	// An application with a quota, but no files
+	quotaApp, ok := apps["quota-app"].(map[string]interface{})
+	assert.True(t, ok)
+	assert.Equal(t, "0", quotaApp["used"])
+	assert.Equal(t, "1000", quotaApp["quota"])
+	assert.EqualValues(t, 10, quotaApp["docs_quota"])
+
+	// An application with files, but no quota
+	otherApp, ok := apps["other-app"].(map[string]interface{})
+	assert.True(t, ok)
+	assert.Equal(t, "4", otherApp["used"])
+	assert.Nil(t, otherApp["quota"])
+	assert.Nil(t, otherApp["docs_quota"])
+}
+
 func TestRegisterPassphraseWrongToken(t *testing.T) {
 	args, _ := json.Marshal(&echo.Map{
 		"passphrase":     "MyFirstPassphrase",
@@ -242,6 +304,20 @@ func TestUpdatePassphraseWithWrongPassphrase(t *testing.T) {
 	assert.NoError(t, err)
 	defer res.Body.Close()
 	assert.Equal(t, "400 Bad Request", res.Status)
+
+	// The failed attempts are counted like for the login
+	defer limits.LoginSucceeded(testInstance.Domain, "127.0.0.1")
+	status := 0
+	for i := 0; i < 5 && status != http.StatusTooManyRequests; i++ {
+		req, _ = http.NewRequest("PUT", ts.URL+"/settings/passphrase", bytes.NewReader(args))
+		req.Header.Add("Content-Type", "application/json")
+		req.Header.Add("Authorization", "Bearer "+token)
+		res, err = http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		res.Body.Close()
+		status = res.StatusCode
+	}
+	assert.Equal(t, http.StatusTooManyRequests, status)
 }
 
 func TestUpdatePassphraseSuccess(t *testing.T) {
@@ -906,6 +982,119 @@ func TestFeatureFlags(t *testing.T) {
 	assert.Equal(t, "context", attrs2["ratio_1"])
 }
 
+func TestTwoFactorTOTP(t *testing.T) {
+	doRequest := func(method, path string, body interface{}) (*http.Response, map[string]interface{}) {
+		var reader *bytes.Reader
+		if body == nil {
+			reader = bytes.NewReader(nil)
+		} else {
+			buf, _ := json.Marshal(body)
+			reader = bytes.NewReader(buf)
+		}
+		req, _ := http.NewRequest(method, ts.URL+path, reader)
+		req.Header.Add("Content-Type", "application/json")
+		req.Header.Add("Accept", "application/json")
+		req.Header.Add("Authorization", "Bearer "+token)
+		res, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		defer res.Body.Close()
+		var result map[string]interface{}
+		_ = json.NewDecoder(res.Body).Decode(&result)
+		return res, result
+	}
+
+	res, _ := doRequest("PUT", "/settings/instance/auth_mode", echo.Map{
+		"auth_mode": "two_factor_totp",
+	})
+	assert.Equal(t, 422, res.StatusCode)
+
+	res, enrolment := doRequest("POST", "/settings/two-factor/totp", nil)
+	assert.Equal(t, 200, res.StatusCode)
+	secret, _ := enrolment["secret"].(string)
+	assert.NotEmpty(t, secret)
+	assert.Contains(t, enrolment["url"], "otpauth://totp/")
+	assert.Contains(t, enrolment["qr_code"], "data:image/png;base64,")
+
+	res, _ = doRequest("PUT", "/settings/two-factor/totp", echo.Map{
+		"token":    enrolment["token"],
+		"passcode": "000000x",
+	})
+	assert.Equal(t, 422, res.StatusCode)
+
+	passcode, err := totp.GenerateCode(secret, time.Now())
+	assert.NoError(t, err)
+	res, result := doRequest("PUT", "/settings/two-factor/totp", echo.Map{
+		"token":    enrolment["token"],
+		"passcode": passcode,
+	})
+	assert.Equal(t, 200, res.StatusCode)
+	codes, _ := result["recovery_codes"].([]interface{})
+	assert.Len(t, codes, instance.NbRecoveryCodes)
+
+	res, result = doRequest("GET", "/settings/two-factor", nil)
+	assert.Equal(t, 200, res.StatusCode)
+	assert.Equal(t, "two_factor_totp", result["auth_mode"])
+	assert.Equal(t, true, result["totp"])
+	assert.EqualValues(t, instance.NbRecoveryCodes, result["recovery_codes_left"])
+
+	// A permission on the settings is not enough to weaken the two-factor
+	// authentication
+	res, _ = doRequest("DELETE", "/settings/two-factor/webauthn/unknown", nil)
+	assert.Equal(t, 403, res.StatusCode)
+	res, _ = doRequest("DELETE", "/settings/two-factor/totp", nil)
+	assert.Equal(t, 403, res.StatusCode)
+	res, _ = doRequest("POST", "/settings/two-factor/recovery-codes", nil)
+	assert.Equal(t, 403, res.StatusCode)
+	res, _ = doRequest("DELETE", "/settings/two-factor/totp", echo.Map{
+		"current_passphrase": "BADBEEF",
+	})
+	assert.Equal(t, 403, res.StatusCode)
+
+	// The TOTP code used for the enrolment can't be used again
+	res, _ = doRequest("DELETE", "/settings/two-factor/totp", echo.Map{
+		"two_factor_passcode": passcode,
+	})
+	assert.Equal(t, 403, res.StatusCode)
+
+	// A recovery code can be used only once
+	recoveryCode, _ := codes[0].(string)
+	res, _ = doRequest("DELETE", "/settings/two-factor/webauthn/unknown", echo.Map{
+		"two_factor_passcode": recoveryCode,
+	})
+	assert.Equal(t, 404, res.StatusCode)
+	res, result = doRequest("GET", "/settings/two-factor", nil)
+	assert.Equal(t, 200, res.StatusCode)
+	assert.EqualValues(t, instance.NbRecoveryCodes-1, result["recovery_codes_left"])
+	res, _ = doRequest("DELETE", "/settings/two-factor/webauthn/unknown", echo.Map{
+		"two_factor_passcode": recoveryCode,
+	})
+	assert.Equal(t, 403, res.StatusCode)
+
+	// The old recovery codes can't be used after a regeneration
+	oldCode, _ := codes[1].(string)
+	res, result = doRequest("POST", "/settings/two-factor/recovery-codes", echo.Map{
+		"two_factor_passcode": oldCode,
+	})
+	assert.Equal(t, 200, res.StatusCode)
+	newCodes, _ := result["recovery_codes"].([]interface{})
+	assert.Len(t, newCodes, instance.NbRecoveryCodes)
+	res, _ = doRequest("POST", "/settings/two-factor/recovery-codes", echo.Map{
+		"two_factor_passcode": codes[2],
+	})
+	assert.Equal(t, 403, res.StatusCode)
+
+	res, _ = doRequest("DELETE", "/settings/two-factor/totp", echo.Map{
+		"current_passphrase": "MyLastPassphrase",
+	})
+	assert.Equal(t, 204, res.StatusCode)
+
+	res, result = doRequest("GET", "/settings/two-factor", nil)
+	assert.Equal(t, 200, res.StatusCode)
+	assert.Equal(t, "basic", result["auth_mode"])
+	assert.Equal(t, false, result["totp"])
+	assert.EqualValues(t, 0, result["recovery_codes_left"])
+}
+
 func TestMain(m *testing.M) {
 	config.UseTestFile()
 	testutils.NeedCouchdb()
@@ -0,0 +1,229 @@
+package settings
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/cozy/cozy-stack/model/instance"
+	"github.com/cozy/cozy-stack/model/permission"
+	"github.com/cozy/cozy-stack/model/session"
+	"github.com/cozy/cozy-stack/pkg/consts"
+	"github.com/cozy/cozy-stack/pkg/jsonapi"
+	"github.com/cozy/cozy-stack/web/auth"
+	"github.com/cozy/cozy-stack/web/middlewares"
+	"github.com/labstack/echo/v4"
+)
+
+type apiWebAuthnCredential struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+func newAPIWebAuthnCredential(cred *instance.WebAuthnCredential) *apiWebAuthnCredential {
+	return &apiWebAuthnCredential{
+		ID:         cred.ID,
+		Name:       cred.Name,
+		CreatedAt:  cred.CreatedAt,
+		LastUsedAt: cred.LastUsedAt,
+	}
+}
+
+func getTwoFactor(c echo.Context) error {
+	inst := middlewares.GetInstance(c)
+
+	if err := middlewares.AllowWholeType(c, permission.GET, consts.Settings); err != nil {
+		return err
+	}
+
+	creds := make([]*apiWebAuthnCredential, len(inst.WebAuthnCredentials))
+	for i, cred := range inst.WebAuthnCredentials {
+		creds[i] = newAPIWebAuthnCredential(cred)
+	}
+	return c.JSON(http.StatusOK, echo.Map{
+		"auth_mode":           instance.AuthModeToString(inst.AuthMode),
+		"totp":                inst.HasTOTP(),
+		"webauthn":            creds,
+		"recovery_codes_left": len(inst.RecoveryCodes),
+	})
+}
+
+func beginTOTPEnrolment(c echo.Context) error {
+	inst := middlewares.GetInstance(c)
+
+	if err := middlewares.AllowWholeType(c, permission.PUT, consts.Settings); err != nil {
+		return err
+	}
+
+	enrolment, err := inst.BeginTOTPEnrolment()
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, enrolment)
+}
+
+func confirmTOTPEnrolment(c echo.Context) error {
+	inst := middlewares.GetInstance(c)
+
+	if err := middlewares.AllowWholeType(c, permission.PUT, consts.Settings); err != nil {
+		return err
+	}
+
+	args := struct {
+		Token    string `json:"token"`
+		Passcode string `json:"passcode"`
+	}{}
+	if err := c.Bind(&args); err != nil {
+		return jsonapi.BadRequest(err)
+	}
+
+	codes, err := inst.ConfirmTOTPEnrolment([]byte(args.Token), args.Passcode)
+	if err != nil {
+		return wrapTwoFactorError(err)
+	}
+	return c.JSON(http.StatusOK, echo.Map{"recovery_codes": codes})
+}
+
+func removeTOTP(c echo.Context) error {
+	inst := middlewares.GetInstance(c)
+
+	if err := middlewares.AllowWholeType(c, permission.PUT, consts.Settings); err != nil {
+		return err
+	}
+	if err := confirmTwoFactorChange(c, inst); err != nil {
+		return err
+	}
+
+	if err := inst.RemoveTOTP(); err != nil {
+		return wrapTwoFactorError(err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func beginWebAuthnRegistration(c echo.Context) error {
+	inst := middlewares.GetInstance(c)
+
+	if err := middlewares.AllowWholeType(c, permission.PUT, consts.Settings); err != nil {
+		return err
+	}
+
+	registration, err := inst.BeginWebAuthnRegistration()
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, registration)
+}
+
+func finishWebAuthnRegistration(c echo.Context) error {
+	inst := middlewares.GetInstance(c)
+
+	if err := middlewares.AllowWholeType(c, permission.PUT, consts.Settings); err != nil {
+		return err
+	}
+
+	args := struct {
+		Token      string                     `json:"token"`
+		Name       string                     `json:"name"`
+		Credential *instance.WebAuthnResponse `json:"credential"`
+	}{}
+	if err := c.Bind(&args); err != nil {
+		return jsonapi.BadRequest(err)
+	}
+	if args.Credential == nil {
+		return jsonapi.InvalidParameter("credential", instance.ErrInvalidWebAuthnCredential)
+	}
+
+	cred, codes, err := inst.FinishWebAuthnRegistration([]byte(args.Token), args.Name, args.Credential)
+	if err != nil {
+		return wrapTwoFactorError(err)
+	}
+	res := echo.Map{"credential": newAPIWebAuthnCredential(cred)}
+	if len(codes) > 0 {
+		res["recovery_codes"] = codes
+	}
+	return c.JSON(http.StatusCreated, res)
+}
+
+func removeWebAuthnCredential(c echo.Context) error {
+	inst := middlewares.GetInstance(c)
+
+	if err := middlewares.AllowWholeType(c, permission.PUT, consts.Settings); err != nil {
+		return err
+	}
+	if err := confirmTwoFactorChange(c, inst); err != nil {
+		return err
+	}
+
+	if err := inst.RemoveWebAuthnCredential(c.Param("id")); err != nil {
+		return wrapTwoFactorError(err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func regenerateRecoveryCodes(c echo.Context) error {
+	inst := middlewares.GetInstance(c)
+
+	if err := middlewares.AllowWholeType(c, permission.PUT, consts.Settings); err != nil {
+		return err
+	}
+	if err := confirmTwoFactorChange(c, inst); err != nil {
+		return err
+	}
+
+	codes, err := inst.RegenerateRecoveryCodes()
+	if err != nil {
+		return wrapTwoFactorError(err)
+	}
+	return c.JSON(http.StatusOK, echo.Map{"recovery_codes": codes})
+}
+
+// confirmTwoFactorChange checks that a change that weakens the two-factor
+// authentication is confirmed by the user, like for the passphrase: a
+// permission on the settings is not enough. The request must have the current
+// passphrase, or a code from the authenticator application, or one of the
+// recovery codes (which is then consumed).
+func confirmTwoFactorChange(c echo.Context, inst *instance.Instance) error {
+	args := struct {
+		Current  string `json:"current_passphrase"`
+		Passcode string `json:"two_factor_passcode"`
+	}{}
+	if err := c.Bind(&args); err != nil {
+		return jsonapi.BadRequest(err)
+	}
+
+	if args.Current != "" {
+		err := session.CheckPassphrase(inst, c.RealIP(), []byte(args.Current))
+		if err == session.ErrTooManyAttempts {
+			return jsonapi.Errorf(http.StatusTooManyRequests, "%s", err)
+		}
+		if err != nil {
+			return jsonapi.Forbidden(instance.ErrInvalidPassphrase)
+		}
+		return nil
+	}
+	if args.Passcode != "" {
+		if auth.TwoFactorAttemptsExceeded(inst) {
+			return jsonapi.Errorf(http.StatusTooManyRequests, "%s", instance.ErrInvalidTwoFactor)
+		}
+		if !inst.ValidateTOTPOrRecoveryCode(args.Passcode) {
+			return jsonapi.Forbidden(instance.ErrInvalidTwoFactor)
+		}
+		return nil
+	}
+	return jsonapi.Forbidden(instance.ErrMissingPassphrase)
+}
+
+func wrapTwoFactorError(err error) error {
+	switch err {
+	case instance.ErrInvalidToken:
+		return jsonapi.InvalidParameter("token", err)
+	case instance.ErrInvalidTwoFactor:
+		return jsonapi.InvalidParameter("passcode", err)
+	case instance.ErrInvalidWebAuthnCredential:
+		return jsonapi.InvalidParameter("credential", err)
+	case instance.ErrWebAuthnCredentialNotFound, instance.ErrSecondFactorNotEnrolled:
+		return jsonapi.NotFound(err)
+	}
+	return err
+}
@@ -133,6 +133,40 @@ func FileHandler(c echo.Context) error {
 	return c.NoContent(http.StatusNoContent)
 }
 
+// FileSignatures returns the signatures of the blocks of the current version
+// of a file, to let the other cozy send just a delta
+func FileSignatures(c echo.Context) error {
+	inst := middlewares.GetInstance(c)
+	sharingID := c.Param("sharing-id")
+	s, err := sharing.FindSharing(inst, sharingID)
+	if err != nil {
+		inst.Logger().WithNamespace("replicator").Infof("Sharing was not found: %s", err)
+		return wrapErrors(err)
+	}
+	sig, err := s.FileSignatures(inst, c.Param("id"))
+	if err != nil {
+		inst.Logger().WithNamespace("replicator").Infof("Error on file signatures: %s", err)
+		return wrapErrors(err)
+	}
+	return c.JSON(http.StatusOK, sig)
+}
+
+// FileDeltaHandler is used to receive a delta for the content of a file
+func FileDeltaHandler(c echo.Context) error {
+	inst := middlewares.GetInstance(c)
+	sharingID := c.Param("sharing-id")
+	s, err := sharing.FindSharing(inst, sharingID)
+	if err != nil {
+		inst.Logger().WithNamespace("replicator").Infof("Sharing was not found: %s", err)
+		return wrapErrors(err)
+	}
+	if err := s.HandleFileDelta(inst, c.Param("id"), c.Request().Body); err != nil {
+		inst.Logger().WithNamespace("replicator").Infof("Error on file delta: %s", err)
+		return wrapErrors(err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
 // ReuploadHandler is used to try sending again files
 func ReuploadHandler(c echo.Context) error {
 	inst := middlewares.GetInstance(c)
@@ -167,6 +201,8 @@ func replicatorRoutes(router *echo.Group) {
 	group.GET("/:sharing-id/io.cozy.files/:id", GetFolder, checkSharingReadPermissions)
 	group.PUT("/:sharing-id/io.cozy.files/:id/metadata", SyncFile, checkSharingWritePermissions)
 	group.PUT("/:sharing-id/io.cozy.files/:id", FileHandler, checkSharingWritePermissions)
+	group.GET("/:sharing-id/io.cozy.files/:id/signatures", FileSignatures, checkSharingWritePermissions)
+	group.PUT("/:sharing-id/io.cozy.files/:id/delta", FileDeltaHandler, checkSharingWritePermissions)
 	group.POST("/:sharing-id/reupload", ReuploadHandler, checkSharingReadPermissions)
 	group.DELETE("/:sharing-id/initial", EndInitial, checkSharingWritePermissions)
 }
@@ -19,6 +19,7 @@ import (
 	"github.com/cozy/cozy-stack/pkg/initials"
 	"github.com/cozy/cozy-stack/pkg/jsonapi"
 	"github.com/cozy/cozy-stack/pkg/logger"
+	"github.com/cozy/cozy-stack/pkg/rsync"
 	"github.com/cozy/cozy-stack/web/middlewares"
 	"github.com/hashicorp/go-multierror"
 	"github.com/labstack/echo/v4"
@@ -147,6 +148,35 @@ func GetSharing(c echo.Context) error {
 	return jsonapiSharingWithDocs(c, s)
 }
 
+// GetSharingStatus returns the progress and health of the replication for
+// the members of the sharing.
+func GetSharingStatus(c echo.Context) error {
+	inst := middlewares.GetInstance(c)
+	sharingID := c.Param("sharing-id")
+	s, err := sharing.FindSharing(inst, sharingID)
+	if err != nil {
+		return wrapErrors(err)
+	}
+	if err = checkGetPermissions(c, s); err != nil {
+		return wrapErrors(err)
+	}
+	statuses, err := s.GetReplicationStatus(inst)
+	if err != nil {
+		return wrapErrors(err)
+	}
+	body := echo.Map{
+		"data": echo.Map{
+			"type": consts.SharingsStatus,
+			"id":   s.SID,
+			"attributes": echo.Map{
+				"active":  s.Active,
+				"members": statuses,
+			},
+		},
+	}
+	return c.JSON(http.StatusOK, body)
+}
+
 // CountNewShortcuts returns the number of shortcuts to a sharing that have not
 // been seen.
 func CountNewShortcuts(c echo.Context) error {
@@ -707,6 +737,7 @@ func Routes(router *echo.Group) {
 	router.POST("/", CreateSharing)        // On the sharer
 	router.PUT("/:sharing-id", PutSharing) // On a recipient
 	router.GET("/:sharing-id", GetSharing)
+	router.GET("/:sharing-id/status", GetSharingStatus)
 	router.POST("/:sharing-id/answer", AnswerSharing)
 
 	// Managing recipients
@@ -882,6 +913,8 @@ func wrapErrors(err error) error {
 		return jsonapi.Conflict(err)
 	case vfs.ErrInvalidHash:
 		return jsonapi.InvalidParameter("md5sum", err)
+	case rsync.ErrInvalidDelta, rsync.ErrInvalidBlockSize:
+		return jsonapi.BadRequest(err)
 	case vfs.ErrContentLengthMismatch:
 		return jsonapi.PreconditionFailed("Content-Length", err)
 	case vfs.ErrConflict:
@@ -31,11 +31,13 @@ import (
 var (
 	templatesList = []string{
 		"authorize.html",
+		"authorize_device.html",
 		"authorize_move.html",
 		"authorize_sharing.html",
 		"compat.html",
 		"confirm_auth.html",
 		"error.html",
+		"file_drop.html",
 		"import.html",
 		"instance_blocked.html",
 		"login.html",
@@ -36250,134 +36250,156 @@ r97Yrf58Hodv9f3nvQqNrz/1YnpdTuYATAU=
 -----END COZY ASSET-----
 -----BEGIN COZY ASSET-----
 Name: /locales/en.po
-Size: 29182
+Size: 34370
 
-G/1xAKwHeEMs9YtRfbEjvfpM6OkRENqK9AXnnOX7bZayH8U2wultGHeqZuEzZFt4
-ZK6McPYCBBxywHrhr7ao/9Vpe5bJBRXYao+mBkiXsHwEX4bq30VfftZXYNjWxpzb
-2Lriy1VDoiI1VZtWZso3Xfbv0+qYSpUgHQhX6LuUr+vw9b9onxhuCRObi1ir99Uy
-PRlXuaLcucy4bCMl8Wlm+k0VP02pQOAMwaWMNWPegCAW1K4vcC3ujitvI2sqV5Yq
-iBRGvNtGGjY+XyvJvxeEKE2osHb+3uNJ5jg+brknRV4+v7h1vLy+qaVJ0g/Fnu7r
-h+Mk7/o7TxfA0adxb+QHcCiGYomYWI8M3N/DE+1jAImTdf7W1Pblyftzm4GU2iUz
-P8Jj3qOoODIe2m3eIXNhmWfc5D+F1YIa13mHwFcouvgKfxEJCf0quUcyy7wlR/2n
-3w3D+Scd9lGYSJ5bQbgzV5y7u9IviYO52wBWSeGrt6prIvNQL8Nlx7w0hjhtXaR8
-GN/cIf7BXtOJbEMJ6JNTKJNsKaGiSot7Kn+MKItiSWuPu9xLPSYYamiVOJYF8cGe
-e4HL2VziSEDzlYGNG5c5OD5hFB9XFJ+N8kZ9dauTNnHdoeU+IaxwdX88kqBmTtpu
-TdrW9LShHwwVbdk2H/gXo3wwxoecQOSGE3M5wWfVUl6Y1hngssvSMBVk3zM3MebS
-jvX5ocbIPBNp8li1v3NsOYmQ+hIRDRSK1cxzo55e0fEqnaFxTmOEpGxBPdwLEGU2
-+QUbGDfZPCXHI7PFzbSBw/2VJDP3NDcsKpBhZoJd7p0BZMBKVxDaJe1UYe+4ddwn
-0UQTAxnA90WBnUPp3eb+Yyhh7blsE+uxn/x9ir61ouox+eNf9DVSsIRJtfe6LVjG
-2IYawJtrCKM/XTO+hSmH7Ajw7YMQJRSzdG8cDPHT88Fx5ozfQx12bqblDnZ+MwZn
-/Vo82Rh7rozqzEgm438P+ixQXAdiBwt7Octt6s61n0DsfQTu3ASArqpunm9KLsCm
-2edrIlH4X9qJmohso+qmQfKAdILp6kXewr0781CsZaAwuPlvITzKNqrLgYnj8HdD
-0EmIBR60XWKXOQvzWtn7G7Z+tMbyP3jxFkVdTbH2HOUOLXdR4SEj7THDHeZVghU9
-nxbG9U0ISzytjXBkXnepyuJ0FrAilB/qThclAMUitoX+B2wnasFg9vTmOefi8mRm
-MOZjd0GPYsr+XJnye5eKvteFIL8x+leHpu+qwpSmmLPcQQYQRz289IDvSwWtOMsK
-8RkDUMzg4cAamluQkC9j9xsc9eJF/Nu0bD4+jzpNGhAlcuNZwREWwXZyP+cBCkN6
-bhiIQ2cQpbMoUj3IRAvS659WIB9jC3miwS0AHSD0pD+Qh1M7mg36xhhr47ZCTM1M
-oh9bcJG8JDeHj5WUaYV7KDhi9BGlFiO+5TYLeWPcJDtmwP4DXfhQccBh6qhQ7coL
-JuSjgrborf7nedRPnK0YhFK3doCfTIbR23HHP6i0AlojVC8qKUS7jKCtqCAHiKWe
-4dJR8SH0fzOjr+U0z96/9TVTyVVBKl13DtFtcqa8lxCj0RkFagvYYQcfX4plyA50
-+Ui90MB/nnaycpLDeve4kKN07Gw4GyMwxvg4BBCmKYyfJDl9Va2A4cw0n+ufAs3a
-IK8pme3TYccH0s1zgw9CVp5X0kQsHgxPFrASjmxsnB3x5mTP4EjzBeJSQHD3aVFL
-T5qICcjLKuc5zI4zwOEKHEGVxc06YExwQfo79hqcZlDcYhdq1oC/FJcLpikNygrw
-4lZJGkCspxmzJRVU4yooqtfT+kKyeEHLTGq5dbrmkWX8KFrciWAnPTQUYw8TdjFQ
-BwwmqikWomk8fBtqnc/v3IXPFxnJceAhaZ2meUd26gbHnjptZPp3qxC8vhnnyes2
-8ARO0qujkJfRqRBxTRT8si+vphABtNDuFLPiUbAL+tT2evIsTbnmBp/KyTWhps0F
-LJ4ysyKPxuFnCs68Om7E0/6wE8TZbsARVtisFbGL2wYSDg6nWzKm1ndLghaWRd8F
-5/QoTcV8lt7lceF4QtcWBnTyfSSnsbRlih6QcYRxrP0JZt/NaTtRxsErCqIjVipS
-SBDVZIjvMT1PQ9TzO3R7gPhHRm6aySLI1U4xIHkHxQAAIgTqlnPMmz2LicvqSTqK
-IltDDaty5hVLF8sbn3ZpmdeDfKsziPKvvZ7XTbTB1g4qYEai3yT+UllM8h2wX60H
-o4xG7oaEIHqyxDDAkaCIrX5dAGGPd4pR8Nid6DIKRWZ5gYIrPVgvObQyhLpQK5NF
-MHE57PY29CJtvOC27E8EhtsyqXmBIDHxGeyiFIua9U1exwQ/P8khm2lbZ5DchZrg
-bm3JRCB2aFNPfAullpQ/hoQreXrw/8rsUu4shmjVWVarVUhVkZ5+QG9q796Z1QE9
-GwHLUcNaGh3EyiqSKC1ecXuoR7OfOPuesA/Kq8jQRw2oUQaxHWsCvQB+5BfO2mfk
-LiXsCEl2vnm7hk94NP2QCtEgAoc4vcllL7SoN4rYZlYhblBlxCJljrXrpyERX/2H
-CgqvJLLHgUlT+sEZOjoOrwAhQHXoUGo9kVaH68BVV+x8KyTKCzw+emSCkOWLfdm2
-MvQ7NaRkmbpsQzza4hvqNUv9yXBStgM0BkyW0pkUySfJIy3TbAlGCWWxztKYkLOu
-ev2vdF1fSZThK68kNlcJ+/86/+LkURTwywlxZi7EtdH9mo/OMGUXJk/UGC1FTYi0
-B5xPpHGHlvPiVQI7kJr0jG8fjnnibX2jhO6hFmLPnU0SSsI90lG8P1n/O7PEk032
-fTEA2P2fsJZfOjn/c99Lf/fJpe+xkXYgTKp5odABti0+zVa0lhU+BRbHSekGWOWU
-r/cFG9h1HzJsi3MHloQ0ffX8g9OAT87kyuSn99LWGrlwEUAJ3W7UYBel7vndU9yL
-qFAbiCliaPYEDs2i8sR7dHiseCXMjzuxGjL+SWl/XqxxWv1Xp6dJb60vDXtqqMXy
-zAQpTEpymXWFciJjJU5/pgsKTWmnZhgSzX0E7ksHsTqCTFzUhSGU07Qx9DOfC4fX
-PLeN6B7xRbJxKtEYp6Cly1OyP7aqMaa/ObZ3OkvjHBeSO5tL0SAxq23mY3GadAOu
-jU54CyQXwqN+kgbqYcOT0PoX3SMzw98jlqA7v17hUekY7/3JqyvZsfE9MoG1UZ1y
-c/XzGOArPIkqPmE6F840vv7dbulXORC4+BeONeQTN8hxABGc5nvkQublDa4c09wS
-co1mmZI6TbJ0or3MmzSQiHdFmWuDLaaCijwKobXyelK9PJsJEqSUnmdls3tjAm9Y
-KT6k5VL6JsOHPZSumxHiEEwTTxTVQDnwn//C9/jn/pNlpT3F2DueoUAOqLWEtx/5
-zkUkTmsS/IkQdv7k+hVQOCU5PR2mm5MVpSb6OSVFAwt/YRCM0lbQUd5K5q4sAvYR
-1d5QuVNxh1ww9znuclEekVL8HnUVm7WEEjniN1WyCgt4A4Jf642o2ehMRe70ABWr
-kXEyWcFO+lBm2TC1QscrB9QBzgZynFzSy9rTy4zD8AP2Ujzy3I6nCODRRj7XoeZL
-pDsMe8ThCUAJS7jslN0BOejjxGQSlhZfRwFVOUkJkbo7i8iLsU7z8sGRxp7VTx1O
-TKKFVrxFnGMxxFa+25J/3ALqUfpCkoSQcr4E3DVp26ClZL5zfjsAwWoSYBBoMJAN
-iK3d6g/kwUm6gsyhJNBRHHovygKiI0J1F8Hj0unJr+xNWN4N0IJT/dMmCsobmeSa
-scqe3yWwsvp/GsOT0OGVR6o3EBjkzd7a5vXAtd8LSmel2c9jMYCT1t5PmcIVZ5Q5
-AFi+7UADp8M+BgjJC19/mLOjuVJ0Rv2/Bxw6oDB+OxcMKmeBJ+ICt/OJDjNyVIe7
-1UgAnOoz0j5id/U/loboAqsO6vCMVKTEM4u7LmVbHxk4UHs7U9K7qn9tK9ZDn1rl
-dNReiNW+2Og+gQcjPJY4me0rVyHx3wXW2rZYmZkvuZMeOJK3oX8cCp+g0tI2alED
-ejVzzrrjMKKUfPlUCTiUOaE998RefwP2HjxbVGydKQ5KmGMglKs81EFwrD/VhcSQ
-4Vl6AoWwKlgO8CyX5tsQb0b8ai5wRy9TpYH47f30y3KbZUj1J39BDXEDepbYVqkk
-OOMeRocUCAJpIKIsUTi6KoH0AjFKx6IxoSl2jMmRgJpJCAFskeu+SVWAeCX4IWgd
-fjyQuvrIJUHinH3mcKfD79m1MovFhunn6aHnUoZOA3e+R6Em048QDIsGKfKYpB2Q
-y13XcPkXdF5oODb8cEhyy0omlV9vIG3UzDRjkLGG31QwbUYwOPCd5mD+STLQi+L0
-A7Lc4oBF4ToA0N3gv/mfUX7FGXCgI/c8WO7C0I4wXOkFe+nGz3fr7YSHDXcbMU/y
-sGz8CD2V8tSCfT4Y+FgK4KO5VS7kFtzFbxcIoRyoDlJnVZA/4976RD5D/LX8LA1h
-9wWuyhcn4piBhRxe4XMXG720iX7amotbK34v7bO/JmaqM2Y326pCsztxt8XAHKAA
-ZgSWRGSz2EGbPI7ODNCpJbKCu3+ErUBFqjX5xrEiEJVwjQA5HZvVjyY720ErgYsy
-MJVDTmLW9IMYh3WozarYq6Een03CnbByx05u6vbVRMDj+fZs/aqmu+PnUKImjE0v
-FHzZs7q8SXbZX/O3fubeC/J84OmYCSwjh77+GfSSC0bfhhysxabthD+K1g6O9cMB
-D9aq5Ri66e0F/2mnt9N74q43mJoXQDpJ855a450ZrbYVZEVsnbfhH4p3D3akAoSW
-eOW0+H5MYnuEj6Wvp62KZE3B8XX1J4F944/MdxT8QJLfhfUJ50T/Lh8DABaSytxu
-eUeEY4GgGDcVYBs++sPdjxiXjzFdkqsCCSPsrBfUozmMT61aHnq//A/a11Azx2lg
-NfWLvxAXs9wv62chkZolyxtYCePE6BtNATp2ZvcHUtnJTJZlOTLgZYZAN0MZgo2b
-2JKY3Zvuse9yvS9Iye3HYCz/s2eS0ZDkwTCMHme3wExD5k/VXvLBwt9KfgqDAHES
-NhOVGNPZ0uCPscarTymx2DglKzWz1WDWBh17wKoiU7WrGHXdVZBDKPbIm8FopE/a
-SNdGzUwdVWHeyOVU11XHRzDPG0I5feBB6JpkNigjd2708IQD51FkK7B1mG7xvvP7
-g4xJCuWnUZQXt1Q0ecmdj1eQQjmRmbqqpeEAdV3REFqE/kOqkBJph94gjRDV4Qbe
-Lf6XYvG8RfQLTe/sBICGN9mvN1g/UimCNnGcvpL1lsDJg4e+SxnlRY0MXlOYr3Z7
-hSCC/WFbMdCRGQsEq4akc9DI+y+QaIKcZvne4zIlrjziFTiFUlX1fUQmOZODeBsv
-4EjxKEXpdU3P+hlLuGco1dJiWo9BXgqfxPEBncwaUsi4Lca88JGTHKK7aQ9xvCGo
-wO6AWYUeC5CS+kn6kUfBNKQNGSjPyTi1xuUueU2w6ZhEA6/ZOmVs9s8ZRg/Wm+LK
-09TOKbdy7rteZbynuKYE79/6ggB6U1kLFmMBFdxjOKv76IhSoTSA97IprxyQb7bd
-4wGB1iuifRcCQ2mYafpZPZ0KRvtQRZL29Ou5XKwesigIcFDB1Facv6TW+6cglqiS
-1WVPrlUeo0IohXJQ8rtARl02ViN9HpJRBCCOHnB2O0pdXsKqF4kHCCyPPlzzWigo
-0qJUciMvMaKhFuT8ApDH6dRzMML44dMR2NDYFoaXnJLPg4YEh5tute+5AuV+ns6S
-HkblHqmDQ1aVkj9s6EaaToTOoEnTN4cDzq+4CK+/OhSWuGwoL0MnYOUo1ebXAEXb
-kxc8uyf2/QA8vN0AAU14VuMvnj0/mDCuscEfEQUeUtREao/D15NmzDXAL9vFLUzy
-S1WltdnsXnYnhl5NSTBOyjcK4LoH+9x4g5QrAJcur7FcJTGql9knqEXvrF4853t8
-YSO3jD7tk2rdOfPv1OTSQa23xLlP/724IxkAq0aKlM2WXcH03x/h3kYYY0YNeIJi
-zeXNZgkndHsvBxa3sk4WIYNx6P0lw1/WX2aYSf0dJYd3aN8I58n9DUxm/w0QT5Xf
-ivPsvxVK6RsFpNwcK8j8W8Gd8cYXyJy5b8sXEX//qAUxsdVN6p0u1wlGqqR97eNi
-2MKd8gQMDbpPK1NFaE9sYhXBi7wvtccoTq9H58q0AO3M7ZML/5IRUvxPJ+OK5POC
-UZ0B8W2eEyROy7lvDK+IeSd/h3LB3CgRbpj4sNiu+uqqoX+99mFmRVEn5Puk+sXJ
-MlGxlK5xvaDUZnW6WPHI05fcmF3UIQULsHyaqRAa/SlfSDSwekTeyKVoI4T6vImN
-eVnRfh32NFh0l3aAOdAgr41O3KJvN36He7lwhAH3OkJixhbkX6DdNU4Fn2TP3zzf
-B3Ggn3OyqqRbKoFcqpalZXvJvl7xCHXmxjdurPxestC+ZO+OlWfo0bLkp7yzN/S7
-XeBpzkKE0GWloRpjL/fiDvt6ZXXqvmwJ/M8gtdudlG2H6pofJMhFYoWBQ1lzWJ4b
-pxPfkTKX92Xof1fDHQYQmylzjgrqxG6XSHXg1A4YiFELWW0htW5VsY+HTaWACnCj
-1g9baNgsZW83hhDHKNbldH98jL0pLk8PlWTeJbkarN0a0d3vkzMe3FbSQResfM75
-MRWXQh3EZyCdcbq4GfbtolPbr81xBar+6LryA+mEYnefuM5DiAinc/JxLAke+wct
-DPCF7w2pDk/tTJ+tjoN6oGHBlE0ZTNyEUQEFNfX980bsSkUtKqiA1GBXXeESkOTc
-pHAdj7CBkQMSVA4q0pmT9+rKEoyJdAVMPAC6n3L3D3+5hPJXCFyI9clKcB2VOTxm
-RdB1gDnc5E6rWby9M27UK6HLFg/OWTO4zB/rvvk018yLmI14KfpnkFhL6kxrMWF2
-jZk0GjdAdObD+eE8t3zrsabWGQWO7M7pBGUged5D3dLExsw/42hwVnSxlId22sfS
-MbPzUECkK4xUadtIhJQhLTNl/JGRkTpOhRyBYnOu4JANM02FskSPCuecPPKbbBEm
-5z4FljKdrMx8Nn6M7Lea+zNJiER45HPxwGvS0fvdZauCKpGK6hBeQaoAtL1rh6Xe
-K67HFrjhdEh6dTwyM/Jw5K4HG3YeUA8j68SHnuaOid1uYxH/PRkZ7hITgtdDiELW
-1ZkbTV09osEF0F2ta2fDeh0CKhnsd4bxDWxhHHeVtA6wmUcywJpERBmCGj+yL3pz
-9Ys4S200+JZ+n3kLDhu3sTAOntTcwE1YUwVlD2rGJiDYzHJgeAU7QO7ycMEi+38b
-/XJeLHjwqWZmwradGwzILesoOdChY04DY6foE9H2d8kITOzWRBr3N2cUVISZQTi7
-FnF8SNO5mk10X1R4X8XMGvrLSuy93rO6/jyFs3BeiB7oj2txHAhqpzygDgFxl6vC
-aVpw/mWJlpfSoNrnavF6ys8KXAMzCO5W5FLklIdO0anLzMd1cyP2efrdV2+TUvwE
-qJoFg/31gw8pxj+8IfhqIHH1wPc+f25NHPwh3xgnCVzMpefzCNk7zSjd+eHoU22L
-0a9oyOOWPiUWeA2V9Y0KfGb07Bu5FjJlaBXMetXM8bky4uWZkizA892xII8d+26E
-ZVlINtQDjbAmI+7j2M3KR0mwKNNaOB+rIzBWZ2v9pvrL5orWw28d/Zbrmz6eR63i
-WZRCtkD0FCo5JDZZ6Xdz98mzbJC+2YeJE2l84jqrNKMd7DhbtEIr9EmxN+wJh1Xz
-1I6lm/APoVOVWZD/ZNs73mLWJZ5MPs7QYljoN8Cartkvj11Q2PmyuXCYIFxc4QXq
-opzPJ/QJ+cbKWemT/+5flzvBQ4C9X7DFyLYSP71E80nfV/5O2RIX4LSxqt53NYdI
-mNLPfozWyONOe/y7NaUnZjx0ZbkqSNVj4aEIcPf7FZmBkAtzjhxzaYVV3XYWA3lY
-S8oCpn2+nOECY9GT0+qn2ZkMQw211NPODaZOrvss7nzwfq30xZLuKVQUb35MN3L/
-mkVlrW9/WvbTLeaU1i25ZkzcFgA=
+G0GGACwHeDILnhwUM+LXykRBcfeYrBur4MemD1mpmlV7qt6Jo97ORfehdaJ4JD8A
+C8ztQgAVINbOab2+DL7/ojwh224ZLbMok01V271Mju6Y0sXvU1waXeIifisQRZn4
+mIROhFyGbU2Rzfnm7BPRF5TJTqwU7xVcQIpONXlr61/KROphwGjBmm6Y5kMCPRAi
+1SJv9b5ppnImTJzLjMsYKYgVnmam31RhAWyVFrt0C0LGmjFvPtYAR/pakLwS7g6U
+t5F0mWzlyhIFscKId/7/yH117NYf4syuBZdYxBdA5+/lt5ojSVr+rZHnxze2aHX/
+Dk0lSj8Zz+H5+6l4k/3vX327BM5G6vvXYAc4FELRREykRwb3b3CjTQGQ2KzzD6c2
+PyXrL9oMuNSuufI9POYtiogjY6Hd5w0yFZa54i7/IaQWVLgueg98u8IvH6ANeSK0
+q8M9kljmNTnqP/xqGK680bGXoSJ5YUC4nSsu3Ezpp4TBPGwAm0H5V+9V0zTMQ7UM
+5x1zawRxWrpI/jC2uUJ8yn5NJ9LmFJAnpygzvYRQ0UmLRyp/isiLoknrMu/+KPUY
+Z6ig1cGxzIlP9OnWYXKYSxwISL4y2LjzKCfDJyzExg3FjzbyRnV1Oydt5LpDR/uM
+sMGt9rglQcWclN0ata3oaU0/KCrasWU+8A9a+SCMj+R+iBmKzKOJfzYd6cZjnYFf
+9tgxTIXsu3IRYy7tWJ8dKozMlYYmj1XZO8fVSQOprxKNgUKwmrm20dMbel6kMlTj
+dGQN8hbEw70MjcwmZkEGxkU2d8nxzGxxMW1wuL6SaOaa5o7FABkmJsjlPhBBAuu4
+goxd0koVLietpE26z8vEGAi+zwpsHELvtp/fRxbrss/RsZ5Ss/dFWaUZZY3J7/9i
+oAUFB02qtdftwDTGLpwBvLiGMPjzjV1ew5hDcgT+7UOIIopJurdOivjpev7uKvDP
+o45mM/N0Azm/aYOzfC3ubEwpZka1ZySd8b8H9RBTGommgsU+mqU2VedaT2jobQAe
+u4kAXae6ud412MHm+uBzIpH739xtakTaIrppJGlAOmG6apF38ebO3BWrGSh0bvG7
+C5fYMupykMf98Hcp1rVAB4F2CFeZs9Kclb0/YelHcyz7l++xw8NbjMlylDo03UWZ
+h5S0xwR36FeJr+h6RuSOVw2vRs6A0YIGKGnL3i/pc07ojapWm2LaPIhe6vs4ugKK
+wTzyQwWyP+Z0ZZAcYWPIswRiG8C9l0tf7SWrr3o4N/9M4rY+9Fe8cIAtPT4B1Mj3
++Seq2n2F7AN6bHpBOgPB6/V+Lp1Q6maYBo/GJsJlcJK7RiEOz5dydSBGB8EKYvV8
+b5o5Aiha1S36U9hBjEKO6WRc5LM/LpNML9i87y3xTLjsP5PUQX5QRV8vRXCCoAep
+Bk37p4op1TkXGw4ZIG4E+toTtq8VawkwvxnvOoFCBgsHVlBlYxriOnbNykFLsOr/
+1rVfbK6TbsQBjWZvPQnJwkTq2Ng5AMgNbv1BySAaFFE8izDegyRqoV77NIv9OFdk
+Z5VqET5A3pM2hTwl39GIgq/QQV9hC1UqLljAf9kvkpZk5vB9JXlacQ/Fj2jBRrHF
+Ht/xC868M36iHaMo9l2bgaOBihLDD4pqZ+8wqDMpKJnfY3+Re45EHS0dGS6XD/CL
+omHyNWk8jlRcwVsTVEtMYohWqkHLmUEKEFsG4fJRsSFzSGZUqJbSPAL0w1/SUp4K
+ctJ1pxDdJ7stvibQoxnIUZvBDtv5+FLMQ7ai4CP1TAP7edzJ7FuO9aFJkUE6tu4S
+xR6YIt8PAcIUhfEfiU5/qpZgVk9aC1ZjJc9LmiX4YdUQ0hTiAh+DoXlaSRGxetjE
+XREn4cRCCtwRbo72DEeKLwxQBpM2Pi5q8UmdeYG8rrSvzCRdIzhcwRGvMstiB8YM
+l8PHx5/gNIHiErv/zBrhD4Vlbe0WI6/AX1wqSQGIOVmj+qbi1fgUFPH96flC/JxB
+yUyi3bXL7z3L/qNgcSWC1RhRU4wtzNgJZW0wmKCmvhBp9eHrqJIb/oFD4nGTlhw7
+Hk7P0FDB0iyyckohF0mGEO4VYi/vxHnjuT9YAjXyzVGel9bpql7D2pOO8oQ8DaJ3
+lzvlpLgEdtnSHk8DMFyUa2rwdmBuCTX00u2LZ9CkyINx+ImC3XvHhXhaH3ZCPNwF
+ONwKi7Wi7+KygRjMw+6WtKn1zU5jCNmrr4LzMc1bhh7p6bEYxd1Cr3eqAzhLZpyu
+TXX1QMYBxjHzK1ou7m43elkP3lAcuMZsV4oEdl9G/LBlp4cy88Ubuj1ANEgTP8Vk
+EXKz67snH4PvABBGYjclaF7tTjiu69vwYxTYmtcws2teMP115NympU7iOUVf6oxE
+/tdaz8u32mJpBzFCE7HeJBplmZAMKmA/5Qtaaw3cHQnx6EmLvYEjThFrxmkRiD2d
+hevg+V/RqTgKzNGFF1zuwZzboeUhnAu1PFmEifNht7VRP9DGCu7LfoXpvE21BzHu
+tft/anaghNOaOXKeCwdPCM1lB922Tie5CjXO3dsxIoFI6s154kso1cb9WVRcs6VH
+/q/0LuUBh4hm7kXgoTI6q6fnn6A2tQ8RzuaMjRSFefGhcY8OYnYeUZRmr7g81KPZ
+d5x89x6ATCbiBqICVOeXMOjWa3vNZDohHX99KaGIuXo0OtxtQzp35F7Y4UPS7Rl9
+c2vI96N+vk0USEEiV5Y09A4am1AM52GJBrfqAvw9GxBivQnHifps8sTRHAT7Dpvb
+aTjbyvp66yg/nrAwJxb07JXOQIwKc/KtHsiBScrMu/08MKqEkYgbSSiI6mJ5IJx6
+WjLmsFqV+7hgSV3kOaW96rxJ7y4Akvvb/Hq3N+62HC/yV+Rr9lqHA+H32WWq6yI5
+JcpDRgTQbswEd/6lKYVNrUdxci2wDjBZ7+z95nzGI69LYjVE+CEuIclkL1pUnETE
+kpvAK6r0MaSWYJ0qqUvETfXJgsR9UPsJoKnFH4yhacru9SNEXh11CwM/NacdbPi1
+pWjQbhCRNd4/emSG4OCObdnWTWh3bqjRMvc5OmWnuJnFABN7yfkli8CaAjJ8OvZB
+XKny/Po0DbJRRFlfZ3FMnrOmeu1v9Gi8EAPbt1+z+HTdZv9f528cPeoF/HBEPDQX
+otXrfsn7UzjdhbQfZ4zmoiY6oAfOR9K0CyKOeG7Ydn1mNf3b58c88na+z11cxiiP
+vehqElHi7tI6R/WF2erXwiiYIz7aEOC7/xPWzK3N+dP4rW83by7L5hspB8KoWhRi
+fKDR6EtcuTie8ClYHCYd6QNti3x+NFrApHE+ZzG0YyAE0PjVXWenDp9cUNTqX1r7
+UikzcxGguG6X57GHEvL/4cZbn0nXrpN6DMWe4JAm59T5S6PumkLO/LrtNJHKZ4r7
+K/JS6Gv/q/PbGtV5L+xTTZ22XjnBFJsGaYu7XDmh7ZhO+nI7Ck3pJGAMCeY+Ao+g
+huiaQiKuWt2QuY60MPRjFevDap7ahmGbqOFZJaHICVVoqfJ0og4LlJnSXx2zE+3N
+O/cLcRsvpN4g5tpdpl508tMDWr1OvBWS9uwSNekdGO0o0Vr9oisjZ/zLge505+fr
+vNGRx4/8outrjuHpAxKBZRCe8en6VwL4Om9FAKuwGgk9Mv/9u73qr8ZO4PJ/OCbk
+E7cwfsC0FTXtyISMpDVcOaapJQOsmmQ6OWGipdPbR3kXBxLwriDz2WCzqXhFnnvW
+Wn49qfZHMUHiM+XkpkxqPnhKJjwpPpEZZ6+bDPPdyJpuqudDmMaULgLhcvDf/bLU
++L++/WQi+LISVgxp5gxGKKuKF536gWULbGYVXGngcf3C6ggobETxz0YFSlYFqTH8
+z4MatQ5/oAZSexEqUIdp6ovAGlBfGypPAh6GEeY5wgPtyi1SCt8lXy7RFaJDT8UT
+HAhFP9VXbBqB4fwTKlNhNzxABf1AHE1WnCe/K4tEGFuh4Y0TqgCHQY6jS2pZu6nY
+2A3fYC+FI07tvIsAzlykcxk1J2oY0+wRgycA3S2hrVYCJaSgDxMPbLKMkNMooAA/
+ySFy7g4ReFHRbG6fGGvsXq7qOPGkCmShruIUixFb/m5EOnEJqEfpE1ESIuWURHhA
+6nZBNtXiavx+AMJqfL9gYzPIBmJnnq+FFBDiqSMlWAk6skPvRYm2tEWo5iI8zp1+
+woStCaOTAS0Y1V8toiCylydJMgaJK4fQV1bqm9J0ysyZEoB2OgI17NlrW7IkeLV6
+obRXmn0/HgnstPZ+SBeu2KPMAaDvvMMbeBAEpgDRKMLNFabs5Jl4p43SFw8cGiA3
+/jIf7CqPjidMYvfzAx0zMlTH3an5vs70owl7j83Vf5kHrgtWDdTxDC+8hDMLuxKf
+WBsZHOZnemPSmqp/+kHf8T92Of8THfffOiI8vuRj/FQmLeP5+lJ+wTVToPrQi36I
+A8c5u1TE7yryep83yrlG61wWbWYT9ywEMnaHdc3GbBes1d+0MQdvuZEeHMnJ3r4e
+iu1BbLMtwm9EbzFmxrrDMKEEWQax4rlwOKI9ddfl/RvYfv4hcUqI9yB4QvmEAudY
+RrhziZFhWeo9RVCuAMsbIWRSxClpWIwXIgfQUYY4ELu9H55sYNu6VP/jLygPfPeF
+2ZJV8CaMcX2qDSg4gTgQds3IHZ01RXxh6E1b3vGwWmwYXUGBGsQFjy2iy+5SFog3
+AjW46/hxs/HmY0kWiLL/eaNxlx69i3IEk6Hz36enV00Jzg3uYn88ntQbg/k5apKh
+b4RpL12f+fVfrVwndce6HzbA7lkaUeVnV0jiGpXuxjNWuakyXw+EwY6P64PZB0n5
+XtV0tiCvRAwwu3cHAD3VC9svs4IbHoEDOfBXwIwJM1KGxlkv7LU7Pz6UfR54WFS+
+EWVAFo4a3zJ6HEzHQZfGBOxYDvAZY5bL4BIexr41ZDyFeDw1VoX8LS9WG+MKEQ/l
+qxSE3ReYKl8cG38GK9IC+697WEaHm3gDe+bi3orda+thr4lS0PEB1/ioorlNISiy
+lUF5p2h7IzEQWeigMQVHBwM6szAV7sERPwUVyQ3JF44VQVSKQwTk5EhX35t8iI6R
+IZgog6msjSRkTQaWMVhHbZoz3wj1+DARd8LSzXdyV7etJuYkHl0Y1q5Kcz2+QYmK
+MFYvVLBldy57l9VlDek//MN47Z7nA8/BIFhG1srpH1G3VDAypeRgLTRttxdRsMY4
+1o8DGtFNW9l00/sL9tNKb9z/w1VvNBDRD+mkqfScNd6Ykdxe8ayIZuGtZoQiTEZ2
+pAJCE9qy9ctLO8v8imPNPI6dIllRcPzs9CehNMYeqagq2IG0GufW512N/ju9jwBY
+iCpzv6VtE/oMQjFmKmBbpfWHh9gyzh9TOkVTBQkt7KwW1KM5xheXW7K+bP+D1u7V
+VE4brCZi+PficpGXbbrKgLCZoL2DmRg/tPnBuEGO3HCvGBg8mYy8LEdGuA0Iuk2B
+PzZsoi9puFddR+b1+jL9JvcfI0L5nymqbIYqmTR8OHdwwmvN0ncV7cwHC78b5hJy
+CKJ0bmqY0aazucEfY6mOX2Rx2tKilYrZqjOnXJcNWMVAqwRBI5OqCjmi8Kq8GIxa
++iRx+9S45joWrAIrIpPzDHc5VkE/bxQKWAILMopLqvGywd2tvj9hwFkU3i8sj6mb
+/fjK84kHLkX5TRTydEvFbxxxtvE6UkZOpKeuotfYQZ1FNQMtMv5D4v4SbqxeJw2T
+5+E63s2enPrixcvmp9U+0CVAiqmsSR+ELimXU+s4zl/XmV0KjDxy6CfcLPzslke4
+SYVT3fQyIII10Fs29Uk+WnY2DVWurpFps0KCieE0y5cT56k1m3BVcLpzVd32koxy
+Hg7ipSqB/sZ7KYqvW3qMV0xYP0yxlmXTegjyXPg0Dg/GyayyoIy2ZMrLP7KRL3oo
+EhHGO4Jy7L6ZwZRdQcrOT9IBMInGIS06RClsZqlJeenjJwdRkok0UNadJn2UNeKG
+wYOGwvjkaaJVlZsit10/Zbyl+EwJ3n54iwCywVnSI/sCaiamcBFp1ROk/twAStMm
+oHmEVMLtUX0IJDvS2HevY8gNQ8eflUWtMFqHqidp3dqepsfK2oycAL0Y1EnG6Uuq
+K36DQI+hPZfuh2uVoqrfld58ULa7QgZdFg+FOk+iURi0lk9+dqsmPrq4Vc8Szwog
+8PTumpf+jIISpZIaeY4RKewYzi+APEG3HkH245tPS9ATtSuUCzpB1gc1Ew4X3arD
+egPSbS/qEh9GrCyJPEVSlaI/LOgmGZUI7RJN4zfHAZ1bnIVPve5nmSXOG0q50Qus
+9LNa/FpQlD1pxrP7PtgPwFO4DghIe7VS7f1Vqd8+GFmzUjv+mPST1YSrmN3XjaHm
+Wk6OuoYtjPJr1aaZwKzQeRxNryYIH5PhYYre9nCkxtvKDgD3X96kHRIZ1cvwEWq9
+97BePJ1/fGFF7ux9Wgvcae+Vv2cO9hW1XhLnNv3Xmi4fxN183FOaLGBuuGUxwN2F
+MNqM6vAMRYjMC6oTuu/2VnUsLmU950WKcdj1JeMf1W8DJlJ/RcnuDf/l3Xmaf8En
+w38B4pnyS/08/JeilL6QQcrFsULmXwr3MBe+8MzD+GXpIuKvX7YEJ7a8EEuVvJjC
+yClpX/qoGHZwNVgBQYOuRc6cIrTuQ5H8H4jkOOKzjzS+VIY6qc+h1Sd+YeGP9thK
+uQ1tOBnR59nAOh3i+zwlSByXC9/JexHyTvoOpYK5U6K7YeRjVbLK4V52/dF6djPL
+itoh3w+qXpwtY4xLxzVuFxTbLDIeMx55/JIZs1JIxGA/FuIsZrm7BkkXEjWsLsrv
+YFIk7oKHfeaiQl00PIQ1DSbdpRxgCjTIk0Albr1vFzeJR3JygsS9jZCQ8SopXqY9
+/FsFm6ee7RzXBwicw845GjPpnooj16r73LJ7T6xfsQiVHcY27qz8XLN8siVyAiof
+kPNnh5/yyt6M3+1BbOVZGSZNUppRY+xXQsxh7cqsMsTnLcH/sqDmw8ajO0Rr/TwR
+7BIqNBzKkg3z1Dgb4Tdj5vqB7AuTmrw82/hI3XJQcE7srW0eFmxfhEGM2NpqCann
+VtX3YbOp5lA3blz6YZko25bTeGuEOEXXoKprwGTfm+zynFC+7b0gVYPVzS7qaV10
+xo3bSjzohJVPOd+m4lyojfgM0ilgjYthXy6qQA9bHJdQ9VvnlZ9VTyjmx313EVyE
+O52dj2OJ81gjb38DH3RviHVYartZtxId6o6GGVMWHjLz40YFCqpY+vuNWHmRag1S
+drCR/dUZLoEk4yaGB/BHFBo5IE6lUKFMr7xWV5JgdKQrMHED6EHSzT/Zy/mxv03E
+pThhQy58jkofHr0iSHZAH272EpoheQmDXKhXXJdljJyzsbNL/7Fum7dyaG6iGunV
+6O9BYi6pL65FS/ctahqj8Q1EpyOLn7J6S7ceS5IexnEkdz5OUAfJ0x7i4GYuS9lg
+Pxo/q3cxlafltAulJWaXpsC+P9SE0x4nY8lDmmfK/kdCRhKkFDmCYpXloJANE01Z
+0ERqDKecPLuprPUspz6FL6U7Wen5bP1s2e+Ux+xKBonw7B7jyUWlow96yDE4VRoq
+qiO8jlR2b/vwRct5r349ruDG3SGp1fHsA8lTbnw6WrC6jqobeSU+6sspmdndUk3x
+30yToS4xLng5qchkPZW5lSTYwwhdgO4qXTsL1tsQUUkprdP+ZrCFcNydpHXApgLQ
+gDWOiDKCKvizN727+kmUpTYYfE+/zbwEhx73mBkHf1Slzl1YE3xlD2rCxhC8kthA
+uRhWOd5lYW2R/H9JduI4jTzZVFVqYdnOBQa4tLWVHEgMMludGXfpKVr+HjUcI+kz
+oUaQ7cUIKtzMEB5erW/epfkzqTfRfVFRBbEEuKNK6FUuY12+p8dZ+ZyJnjWYjqI4
+EK+d8YRWD4i5XPBPk/nzLy0WPntBLU/l6fktPSu4BjNw7l7BnuWUhk69U5cQEJ+b
+W7GWYVqe2Xod8WNQ1XwNJeMHn4Jr//CmF6qOxKcHvvb59xUzgD7k++QNgotO8Lwf
+IeuDmqStfzj5XPUN+xkNeW7u54GO17xyeqfCPwNdfSHXXKYErcKcLjfX8NwYcXue
+pFVO8EOOIc+P/l4D7engbKg7GvmaFioxberSKcMoWKWlqi5ydgQKWe1Zv620toUu
+lfWHx4P7x091PLdaxbIIrW2O6DZh2SVWy+xXLPmFCzggTdYa5FganzwfKjlwBwcu
+WhtRCv3vTS3sU6GiUcgC041mhOh0yqzIX1navZc2RYhK5OOUCYeZfgs0xps1YdkJ
+hfHXBfRDze7qwV8YXZQ966r0JFtYOU208t+27dHOeHCw9xP6hlkf8JdalM9P59Le
+GZfADk6Lh+t9U5W/5FP63m+4KDIeL8d/UrFemfAqGcycgnR6rDxlAa5+v22NQMzG
+lCPDnFuhOb7t1CN364gyg2mdL7s4YV/0pLTaabqUY1cjUZi0OpG5N+Z9Vq82eE1y
+erND9+epZW9+3lJy+5pEZRl3/0GWpLhOadTgKidPRxnEWoGxBeZECqFqXebGyM+M
+gAiGll3c1Cvb1+B6aXKRTl9q9NJatENhYVNQ6ErjLrUWi1uVLxnCJIypiZY1eqhJ
+VK99WwKb6gpdKy6xQnM0DmM5vQVLbTFQme1c34sClVXQxvYuL2DoM/fx0rYhOtG0
+08H3gj22b3+hgHySDLu2DH2q7HJE57Z832lbpOGld6dHOPOD2inkLKw7AaiQA3Sy
+07S1SboOjeeOFBwRQMxFAHJgp5qcDfdlAx2/iuGm25L48P5qugOoUyu55ulWgaJ7
+kKP9OpqK5d3FlWmibrOJ/IOewT2ovo9goC6/wYlpjHvlmL96OJo2Jlg7mSIJoHj4
+Jsbx3O7waBP2hKywGLdQ/WeSC3j1WPkfyAB8jnWcRHLvFbisfE1WI4A1sLKnq276
+ErGrfMOoovRczZnSXzLrd/knt9z1I2MlXYX61Pa1FWlA8il2jMkXsVLZhPFczQiu
+TSbmNsymCgaPxS9I8/dadS+dejwT2L2EcMrUhl3RoW9sZmK3dzJJ5RM7FcpZTqqB
+00j2AzFHM0yZ0BSDpV8ie4ZZHiOwcc63imyxWOVX/8l6gIeLqpVI08Eur6FXLFvs
+R4jVGeZMaZrNf/xy9SYZj4tI437veRkxdefivGhWtxkGMBI95907j8t1U0wJ5CFR
+3uRMuxhVP+nS7dIgerFueUbGlmLN5gZ1MR4/h+1ZGfl3d8ZKLhJqMI+UdvJ2xDLb
+qTsMN3RpDq+xPOpTWh3r7tRlnffYC/QEGD+EoGp20O0dcZZzUqXe4RVTECwMYL7d
+rAVhwCiSVVJCS1UkEdEk8o0phF3psN/gbm4txRDRpEI0HmB1sptdKAuiFSc1uJsr
+NAd0mwJW7S9SELRH8woCw7ThMHxQN9VYMn0IADBjH9k76/ZgTmkx7ccYrvGTJUis
+Bg==
 -----END COZY ASSET-----
 -----BEGIN COZY ASSET-----
 Name: /locales/es.po
@@ -36513,169 +36535,194 @@ k9uSs+8F8OIcH/P29bR+jB9fhTI1+iNeAg==
 -----END COZY ASSET-----
 -----BEGIN COZY ASSET-----
 Name: /locales/fr.po
-Size: 33515
+Size: 39205
 
-G+qCADwNcHJL/Q44VEvH7OwKw9FquTyvo9Eiw2BHzadNNZ1W63N0K7LTlEKXUmY+
-ALWAEE2QOmdfo4v1zdd6fAkpdj/b90WHFoH7OaOR09lRWanq1inDaXj6ugHrB48u
-2glq36VZh2vro5TXhoa43f9jN1EsuYcQIDVCJEFUyuBT9pb8CKqJ0wFPpwF0dHrS
-n2uz8sUpHBuxjbkA0W1e7xCCOiRVhojXG39mrUxEm7pqZxycn6BQl262z5af1Xok
-3RMOI8Tyl6+arWOuXKq8pnSsnLvUuinMF3ZHwCfgI/CJORCk5hAuUioYGok3eu/t
-Lgh+gjwoDkjKupRj5VBd63FRhxCL1k0X4jaeWf91W2u1PWd+7SNACBBIEnDvrvwJ
-SXl9P93kX8+vqXLXJF7Fld+ocbvYQfwM7pr8Y1xvv5snidFbtp+kvLsD7uI6devc
-XP70P+39CpmfLyhurLe7QxfC/6njzmW/58P4Cc/FhHhs4WZDjFfT8jc+jVNnE3p2
-s0ze0VhCgpkeyOfp7VUMsINqXN8edurg6+m0x2ZHfqkmz/l655dzt7/2wsENM5NM
-03N39tVtL/Oz9zr8CVknDTduF45f7E/OL2ddMEt+aHrM9/ehBlphrPc1+KmPx7pi
-L121xCa//tfArKoStjEdXXlSJuSjfKx83+txt4t51z6ugOv8ueknF6vHlIymc9b5
-ejld5v1HzI/7/9i8/N05E8LlBDo20kABjTORs/1OoMSlOEL76pTQn8//Ze9tTcM2
-ceTgp/pd0RqO/FqJ491z+kBUvRNv/JS+kX4N9KijHphNKXr6a4h8PgWcpjmpfoqw
-pzSkqswjRgBkQlj+yGHfuEwx6qWxB84bBz0IjluOcqZMiL8sgotIkplbUgfHnb/y
-QWCGDxgMbDZUQv86B6nClAWu1VqI0KWtBQYU5ImaR80/Hb0PlygalLs9hlrSt35n
-ON6nY0wZ7uCEKqyGhoGODdS5vnyovz/FRFzX7fJB9dihC/bTpqGY89JKVvcQODjK
-/Hy/cAf59PGR8sy+0kScoKZ867yR2VEcvwWiQIfNj3XTtcDguYZcRtMON/5uKjUz
-w1UwtibvyPiOXs6zOmNyotcmYYzJSfgkEATZCRUd45JX7cL7dPB5FhqfFnxF7GnM
-yNoxLVMGssoRoIDo4XrSxlvGe9kW/I7OmSAYrtRcIzikQxFH3Bv2WuI0yGHGhYM+
-TjYo5dWitUeBk5QE4vNaLzxm0AWm29mHn8TIPNBUtSvhrTDCEvulQ3ljyUIdz24J
-xR9+MnQ0gcG5AhwN8r8TMGQQ9GfHc0SxZbZsP4mOAq6jBE+hZ4iUa+ZzGR3EN/so
-CFI8ry0ywe7Ecuqqvn2eSvN9dMCjvmef0BEAzMjBSENjca0Tu5MpUqvdED80ba9b
-S7pXxiepOEoV1LklmZgFgUpo1YDa8jeEkIl5K9GnegmUUFCrVh0UUHQwBsauXZgR
-e5Jt2X/Bt8MxFB6XudeJpzS919khzrNel52cwHAgyJiy0JOxL/x/xMAUyNkLkT42
-0PdbyCCSk7oC7Vj+CBHZZU1f4eoZCcgu3FfNwquVUKBhVvXSHRYCxPCJyuSlELop
-zZzXNzYG89Xrix5J8qUkuALkQYDx0juYczX1KijkLvMAdUDhOARQ98N1nwCu5oWz
-0zLwyq4zNtHl8nmZzWUBI64hlIv4bjQQ33r+a0JIK01TtIYggr5OASJKXMvUToMy
-e9ASuv40Os0DiDELBI1AkLyW+H5nNBRIvXPdZmyMPLUSZBcKw6mT2vZm8btVHRKG
-T3otfgwvkNTBeyUWMjy9WpLShXJoEOiaLnyLTaHZmYAU9Idy+Qvg0PV10ofgVgmj
-7dCswAjNkT7WlQ9Q4PLRnQFE9VWnnZqtIFwiIyWRMI5IzcSD5UzGKte+c7ACnQ4k
-Pk6SiJDtuj1TEk4amsKAORXcVlLrhM3PM/dO5bN9OxPZk+tw8Z8DSAkIcCjPBmQ1
-7JqUkbwy3K5ekSNr8U9d8t9YzOxtctnOm5TkgueKQXRV+ovgFRih6SerlgQLJQNI
-NBoLKLkfK/CKOwid0esYrzkWuJ30ya4NmzrAglv5/CoUuOYSLN4qNc1uuuVw2Qjg
-yy605u1MS1Tvwh1LSQ7lyQRku0SUwPLwOpDUoTOKw9xWCeV2ovoJAqgaXFxrBsPu
-Vo4zm53FrLCXxn+ZAF3Qs3YaSQp1MYoE1zgJkZweGiPAMX8vKSPArw+rkkZi/CyM
-9XgpWGPfwV8UPow/KeM88aVz7NVCdigExvwRXIvsA+QYHfCE7Tk+HyQla74de2Xd
-XJfQDs8UZ963CGP496K/g3//jU3KjLPDMHxcZbIIH0DrHsA9PwYuYzYnY1QiDsDf
-UPsiJvw2ZuLA2b2L+aMa5Jp5jjS6XD1ODobDwirkrkrjtV/JEKj6u0yQiCWRLOVk
-gSqlAC3+CcMyok27EGlW8gN8C/fpUQnA7Z4Z0t/Nex7B6CTlnT9XC45oqe3vozxs
-/JsCD0nDoIK3pcIZi/TrDzraiQRI0qIaMdzBBsRg0sciPOGYmiYoH0AqNBsdUz2o
-nhacsG2htqJFjgdKsyIwhEUVhsetbg8TyRM10esYzTFLdqyJhQC2rNTWwxy35HW7
-CvuAiqJOAUZJR/vYWEDTWHY6ddlT1wNUV7B25xNftw8kAmRgdbwR+ClfRrGe6ERC
-5VBpDzWGXXqt4OvCdfKWers1mHSsTLA+gmFx+sh/cKQD2U+20OcQ2P0VyLudc+aa
-gomh1RP0R+JEgH09H+pynByXTahL8nVdIIMYfxJ0yTFkb9wp3gbFlCMIQx/dXFIx
-/31O7vTtGp3Uf72IIB4rfyfUwK+N3e7vEviOSGBxnd3/t/5HiTCb+j4k3W4Z85Jq
-A4EJWDI0d2JaEe6MQSlGU7Hsjp7wPQY8zwbFBLVQ/VMqCbraSLNM7vDMnat1JNKB
-46JgEy7ip6FpHZqddDL9NVNxlw3hB6QBvCr9jv9iIbJhPkLu4en/tu2AwRmL/8Wk
-ayqgGnuORKDjB7ieO29Wmbp4KGT5r2T44uOgT2qZEplXY06zoxOHolobB8CnR6eT
-VTJMUSW1vbGBpWYh6gPoCljOa5CboYWBsN6eXpsCUWm2CPi+NzAwnwCHvOc6R21F
-UgPVaxZpEM4ea++pedYlxhy/uV48Pc3bEddZD/X3mdOlOFNFJS3mVB59Oe/2QA0j
-Sz2DYKaLo6NXZEWy01UlLqowieSaCCdIofFBb/RIHBzBEBvhE/mPcFoz9tyto44b
-ZYvgbRUAJcxgTQNO90Ecu/PM5FwQ0sbY2EFpRsS8477p8WAnI/Re7OlLYC7gDvfG
-as3NV35Edpl4Fv5F0ZQ42M2RyMu5y5NB5sJqvjcjqy00X5QSihpuW1l8Dk1qkPbs
-fzjh4ZNdqF+GYU2TqxWrBaYlxrYQiMphHcaNvPEi+/4+6rl0GEhZfkkfRaUDsj2I
-CiB6XaUq474nV2mqcwmDDJlwNMDg2EonjqAD0LTmdxjiBZusA5HBhySXGWtCqt39
-8Z/S4GTRtqjHapvx4DgXHrtenDAIgL3kwx4nstSMq9caw/OQYxydXv+bEkW2bcmD
-qJbXHLTSQaqCE8PMY0rI/U0ltt07GoxaDWfRAxKqJjlRqvmoSaQWPjPGnSVpvomc
-hUbGj5NJ1AmDE0Mz675WeSRPd1SBN6lfF45HRCyQ8cOcFxJ3PM5q+bvV6IN+jvwT
-idMCSGXVkAsoFY/VOZeApRMWiWjNmJqzPAPYyFggM6daQgTtOYUY+Br/uSfRByWB
-UBMaRrPk/dyjkFMQk4+4YkKEf0BKeZAqmBoutqwg8+M5w5f99IvyiBId12JLXu40
-hsKtE7/MzyapnvFiHqUWtUcjldTSmWgGhsbtCWy0VP8eAmVGgoDC+ZyEsXdZejEq
-n3bUl+xMxGIqcoTn0P+DbBaXFqpIweecPh8I2bXPI0T/qgEAFcP/EYa1I4W0nnuZ
-eqCt9vNaIPl9tAsIE3btM9GumfdsB45tw3i96dPPOnp5xEQ6/Ul+WSxQOHM9nqrJ
-e3/ea1Nd32obfrdbZstEryFX7jIqTnC8Z2CFAGJOeq0HYF8HJ2P0HKufDswofm41
-/KPIRkxShXDzf3UUPKL2IIS4qjFjJxjBHOseyK6UyrLSRfaR2JK1Qd3gTeGcW3xJ
-zMTsy2TesXk+Mqr0gYH3wM6tI+uYHJ3XFqe+nEWuy0uHjt7EVkhOkCX4a8psQ9/B
-mjB+Wp93scOjDewa/FGva1TLZZJo9gKDQ86Z/hFEZEI+ZLdn4AodcgOitdpqzkMP
-rqamsaWIy1g6C8faYPRWR/n94ynYK9+JsGRsUN6KmUzKSXmcYm4yp4L6gSSW+xfj
-IRIlI6dDWSAFOW6+CeW7lnYdf/GU0MjoIItI3FVrGgBYidzJIp0U7kvn/EM+F0Li
-HS7jkIfV2prQIbKHe3r92nrRbG0kPZep6eKqJe4oSjFr9ICYfK3GHfNxj/57SJos
-oaNkkGv2GzjnsYC8BYYjue3lc/f/c4cPi5wfsT+gbC93SqL5pno5owmiAnZuEffC
-FCvGpQhUdSj0XETLc7i9jBLQNi2sRMNlQRYrJ+8TO6Ev3eC8CHgCEUWzmuKPDXio
-pRby/tdl2Ei4gnuZPoGL4l6pWqYgh2qZIemk1FsjBREhK8KpYwYorpsvBFKdL4sZ
-Sdp4GqWhlJ27APisS/kXuKxB7nBDpUR0VJP6oC5oaYGrlo2bPX5HUc0iLT00udTJ
-JsNr1bBmEvU9JoK6Zd95g+rbS6ofCGh0TJNiX/Twq44aWuen4rSAeIYQcQPNFjMH
-PQpV17CxIp3d4qc3IDIRLg9Gzu9GicNEjqhP0G20Z4BQFcjr29lxmZNa0dmKpR9Q
-aFUHTzwniCcWbOo3Agx9G9nUC1lsLwyysh+TaD0AvElrE8/Dt33tQ1ZowXNtq9Gz
-0iYJgThv8zayx4Boy1R4jwHckGs0WEDZYREZop1okNIBRpUJxtYGo2GS2yLSTNWz
-7WLx/G0tGIXEwHW29WwqKmIxwFzs8rQybk6s7rBXdTVFYcbJpBV7WoagDlkFruqS
-Xvx4hNqBupDt+X6jY1DTtj370eqhc8iXLlp5ISLd6Jq6toukhDD+9EZUH761Zb/a
-KCnRZ51ct4EsGrWinXQdUaggW++7rhNkl7BILjZK3jmB/SH1Lk9D5ls5kBgLjXif
-7ZXnb8fU62mKWGi0c4GkqZdoKLxZgTRUUk/9e5xJRIWN6DiMW9Jb7Tb+D8TCTRp+
-NXDTfOiFyIH9aO1oIZBk6/k1nroFrD88FfRktOC8bKcs/dQhUh9o0JoGmQ5NBSmM
-2ymb+pueVro+FRKXHqlAZC36dKzy8an3yoX1l8ZXOk5Hhf7LyegvAtSZmXUcKZoQ
-9/eC56wDMBQBy+XKxv/vfKcnWUmfjkoFSYUdTwnxoNiHuWxVCymrm0cOThpwo2Tz
-S2exxnTrqi88I6L1Q9yMe1g/rcwGoj0U3DByPSU6dIzHJtnChMkyyE2NdYUDrrdN
-48RDgKnC/zah578azhdHxNSHrZgnksAyWVi5rBOSlFmhBmNVtFuvR4UCaQDAvDUS
-8vkDDOjBDGGI+R0Xxry2jT5IMD+GuiWCK2P82E+JXqEvnYr8ILJwvONjDoX3+c4D
-LhKboKKDDb0ZP0s3fQHyrK/N/npzEy4lOhgeVUhTX+BbkJ1GnZ8LlGBptkmz7unR
-RGjpIMSAmBkMOt50Wj2wQ4hnYrAWNeqct8vJ1gWNgglLnAX+Bdn5gDE7HkNDytKJ
-KQ5rQ44y+PiIV6I0KRhwyaJJs7qOVDAOrWL9k5JvI318iMorq0Naw9IVvx0+/DEC
-FPhWBO8I6j1f00PnNSs1GB588hfkAVNLz12bBmo8+maAQ6FqkNz8KAo83mBiHX8a
-znGT6x2de5Y2OITxt59+PExpElYzbfExjXJuuOLNxIUiYEdRXt+Nt6G4sE7of48B
-Rzz0tsKVRmQOunyzgI8tYASIfZCtv0w38l9SobL4x2dRb4bHOXe5SYhA2ujkCK7a
-vF+IY5xQO//7+QvXjDUrWvfVPymXHsFoJoN+0Cb4XO+ekg/n9USN/q7LtgcddHzf
-vR3KbK2h1hjw0/LJENXLxRA0Kn4x8dAxTE/5PAbqAs5iIjO7G4fcroaEaEgX6f4t
-8ORGJoG/YOOxk21AJOTwRQBHFzOkuNB3kN4VjASrHNH27Yt94ciLEk0cBlGzSQXq
-vfcoooYin6ekBGDnXn8TI8HTYlXe9YnQubiO8aBT3sY97mmFbnljmRznScmeXOlH
-2HiaSIdmtadZJYBJCShfJ/E2fek71i+dPCKpfHR37fj1phCa8O10lZNqJURQ6/2V
-vdZRQGYr8nv2nLkXmSLZNn4WG6SN1/llFdg1iqlKXy025TvYa1ehl95cf3ewlVMn
-VeVYub0Fx7c6tq+1kHxF4WtfkZcyfS7DEQlQuumNL0a1+eApJdSTQeh5Ci4JoOKb
-lpTg4DFLNm7dveAQyWTEOiX1DuxaS0W/51GIVnjb3ARYadspnJw51gO0OjAeYunJ
-S9PfNqYqKjbBdgLpRHrlMVyo63ZDmhggi+vum2L9P7APObd8viR7erKH5RuZSqVC
-WvvqeCfjE3v9g1JapXGyeYOn+viHndFdNKgHCdIsah/hZfZgpVR++dl+wrrpNO0p
-8YFjGfxFPMAXHnTuq3nuG1a+ml+MDrrNkzRJypxVYa8yH5sQxxjcdSnnGfdKW1qe
-eVO7q1kALDh/zqzgQ/Mq3aXKUs62ssB4kxMjvjSL2mC9FrnHLA+G3m+C9VhpoGU7
-6ZOiGR72xLaosMRA+6zZmy7biYh1LKzgeRHJFL2Po2Hn5107GKFpJhwcv6kBv5OA
-15FjSkcZMQKTLSJvESzBvv8qqM4xmjQh3dILiWnY8ZQy5Icww5S+QNysGYrhKGJr
-os30QlX6ogJWwqfnoqn3KoA5bJUCbWPd/7RoK+o+peHtd9nUVLcMp70h1rq+TJxh
-/OyDyalu6Or8qFIztWdJLTuJmJblneSokrUKGhmKm4e7aCs2iPPKLMBls00rvzSp
-Kk2mbHrLTNpjJ1RTwMYTX2L4dELMp2jZReD2ytYAJPdl9pBChrgpuNs4LukUm55o
-frzz428w+VCV8LH6Z57DlOunibtl8gmS1KppfrpMeA1maabWl5jXJg3LooF3CdvQ
-Lgmyr7ga/aShUXYRzGKHa68oDwxUEuFH+RZLrVAMaijPDoVq0QeRY2LKe1lUor5q
-mqjg1V7tilZ2bCXpQQmpuTXMsGGl165wsORK1Yk06gX87jMJyK4dCFhISRmaZSTk
-tX5YJ31fQuM88NGU0RFPglJ3HuOWiNoqkOGQN0lOicsTnY36DQvp0jc4g7NlXiqt
-IRUI8tZSQQPX7oHYqoNX+rXJhXxOVsR56EjBzmNb0SNz1gDxEcXTUnLooUrDE2oc
-1hq9dlrL2sDdNS6lRpEjDP8pCbEUZJvAZWinhFrqFzUM5fPoNCpqA/qGR4UbxL4U
-ZZgEB2kqvMAoHtTFHITdVdZ0nlF3T60EMH1i7di95lLKnZcX6DFzwlO8bX+CK7bm
-alH8w0liAJQUAjlt2o30UjOb6cZvbBgGP+A9Ag5J4+xacWL/MxWBtIBy+T0aNmv7
-h2k5lkIKnDLvY0BTTW/Acu2trWstQ7lckLtiMklkjxqYlUNzvB6p7XVKw7KbvYz2
-nIL1NKNNPazNoYn7eWsw6R3ArswVI5mDX2YszVmYRXuMJx/F8mnlJNjRp0/EuTEu
-GrNwlo/Us07k3+tJWznZp8wq7bmcdMeW6GO8ttYaIWNoG0Fqsx8Oa381PFker+tV
-qlNKX0b6VMERsktmLfGjNvhIauzdwAkHTdQ2wX3YuhB7q8aCzvnfJZ7EYY2WmEi7
-kY+zbAuB61u3b4JM7/91fOlf8WHtLzNlyUwAM90hZoBOsQ9K1D+5L05q/780oH/a
-Xz4P/f9yUKK/whSY3vNwBHFfNvD0Pmf1e3JO3t/jl1dJsr/Ix67WaAYCxWWQyM1k
-HDxqH9Sln8pNtj5WDWHazpuShX338gCVlmc5PBHpUFWibdyey7CFr9jvPRbCKmHq
-wakZ71CtLYUiY8HntKaWjfS1QS2f8DFPgcUbhjEnkXXcTAoCxURoZsfIWN7s24m6
-Rp+kxNLcrCoxJvrVHGhBXez+XDuy0aZefirCe89NLD+rfuB6HSQC1kVmr96hK4kv
-j3iAoXMLaOrEUnsLl3I6LKXNIRs452S7Mhw4k/0NgPGCRJ8oUjkhEKgxUWPjaZai
-/JBNaRqseg0iLsJ0sWSWqEJoyPbUgPgrc8enKSjFncwoCeZl6IUq2IVuuevtvri1
-tQuBfH7eszdepx4lxjV6cd85E9FMPJ8UktfWkjTiTZaTgh/h7kkcnBSOVsRS05/x
-zE8Vlg/IiX0+iUxamprOlZY1horL9t9owrQBUBppdZYLApwrxHl02ungRUO8zN8y
-q5XgRH8zbxm1WCc6Juh5Nr60Ll2YRtk+7l7nZPY2pipRw/KgSH4PFsW4G93MjVpJ
-6uThKbOpnJ7iPr1eS/HzcDpRtNyqSvO85l9bUMFUc+uJ+bMZTEpbH67xcVCLbfOb
-F3rmM6RMNVj+EfPVMgXXZLzemrnsg6/WHundwLz3RKIMk39CCSS9a0/1hwId5jR5
-Cnq0/NZlQbOfSN7V0NaGQKol2M1eIdQsB5vjYzDA7R5iV7WGs2ycBxZTexlDcY6e
-IvX9RtKDd8mdOBVfaSQdg/YBXPWo/dBee2ZKT4BJu1k3tlFPDEnQaI0ulZO+sxdT
-cD7aNFfhTBuE956BeWudnjArh2sNTHc1LqYDQU129hyDs2UoIZ0dy6UF5+965L+R
-JWTYwcjS/HLZvEUZv4on8u5DXv7Y4RK2eD0NoVDv96dKVBofO1sCo6a/OPMqs8dO
-AcBXfyreiYVMOqeM2q+NKxgotLD3UT5nVq8ojGLYEom4LFzo8vDqIt52jPu89CRQ
-wSdP99hgkjwvkY8jb61DLYBPtimSh4UNqLuT+XwrCPnqKCer2eDycKp8J21KT79t
-rbNuAG9AxC0P86LlVIb2FMESIcM+sivUxaccBIHntfoEjnyMKl5ZIZU5ry8S0hri
-5VFTBo6l2TBmsv2VoBU489Kuu1aEVhPEJCbHmDg/mA7jQ0rD8UdGAxBLap4KPEbU
-T0LrD0xOoQ5RwiuSl3Jz7UxjyA/V3nlzden+Rp2MMEtRBtchOTZ2bsEeZi8ZDk4l
-L+Vy2pOuXcxeQK9celFtifY9kRBVVGse1ZBPfCYAhTXz1eK0AjQbRnrTFn6r+ikZ
-vFuvWIGmZ5Mh+NWIxTsQSY4skBOhwB7hxDerpKcEDncfW0KiURd3DukY1xZSClN5
-lLqWznhsrlhsgLgT6q2izbzt1urjmd8Ng6yLBNKyo3akuAgq1vy0uhnL7BBZfPFC
-ucBMdE33Aih2bUocJq5bFVyM1Y4X3MKZC20NUAydiA8v/3irleoU5LcZOFmniSsc
-ostX6oFpY2DW7YtGEjmrTfeIbEMqvKXEQeX5xhY22gmgg7TjPndlb32wrPF+qT9b
-l0e7Lt+bf6TANVSge6ljtrRq2iFErpVTDy4qYcleXPQCAbCPc9ia4tDSan83LoPB
-wHdbBeosSmeBWm1u9BYTVy77i+ZfQZMtO+vSkkaPjeIv+h8d7ZImPJKiLdOt3EEl
-50F6cqRZEI6xTGKQvR5TD/dBWR5eNSWGf5ImnRBuP7XzbJ3BVHAXqZs9R1pB0+Xq
-9eVdoF+KmHGE5IDEx03p9q3sk5oYSl52I2ti9DLrdT+B6IUx7sm18k0Ji7rjpvW8
-iE+s7c/L2wtwevqO3dRe+fGnKuosygw85YbM3pji3aZnZw5EWxpU9Mtq/QexdCJK
-Fl9Oq0HrKxMMSuJGsZczZbI+t+3i7FKzcpa3R9fujTzm3uvmhwJZ7o30xfZj6JUH
-EkeeHrfdeIsW6mtxPPZRe0pclyMqo4z1qmKosRJDk+g1xpehlpixVzV2MM47+ca6
-yXOrt1AIjwzfSLz2bR7ZmQE7K+d9kRQXGeJ5Z3qV4ezjULe0au8A9F8j8HA/w+MY
-Y++8QwQwZIbmO6sDXcqj8D9k2Otl7NXv0ACSOfG9u33yvhOqx99fU6PlTDr63B07
-o55XT82z5PB0/t1mYlQbSlQFQPwJ9W1U1GLu9XU+EzranJpwUdBFm65zss/bdJTr
-97QWlE043eayqx1FxrtBSS1Hx/lhR2J5JS9Hp4sfU8PD1+Fhw+P+M+0l1UjiB1pp
-4UBGoab74+2UlTpovTPB0YLcKnbpiti8dWg+o83IJifOaTsuFw==
+GySZADwNcEM2Ak/WcZgjjXnTCcunc8jP9fPVjpguix01ZYQks/BfLf23+CfkOGTt
+yNiZGhCj2G/3SmqFbb3pnp849CBm65tvhmix3MfWfdGhIHbPOxo5nYzK2qpuRTuc
+Ls+bJ7AGvJTRv8GW1+msS3Pp741hB9uEvKbl5IBGErDAQohNuYXIqppNsw0IKd35
+6i9OKWXWkXR7ilZESUj2NcYniEoZfMlemseoT11KF44uQdjJ5SV/rjCXhaV4vcpd
+kc0FiLot1RWDQdw984ynKt3796dv7SJXW6ZMUW6J3FG7TbGeO/PmfOlJ2th61oks
++69BDtmBD6Hv7L8zd+6z9AxRHDtHhoD98wPEXsQKuagZqi3abTpAt40kvoGfSPQx
+/hW7ZG36Sdrskz5RERUB0XS7q36GHXh9Hw/lD/i0lbu68CJu+iGtt0ucwm//o33+
+zdvtt86t4uSa/RdS3mPjMaTL98nr47f7p9mvkPnhCcX1tfXi0H3hf/dx17LfM2F+
+w2OWELsLtxhmXszIn3AaB08n9OgembzVREICSQ/kc3x5EQ2bqMb70w+PKz/F8c89
+lO+oy2s+Pujp3J3be2H/OjKPmcbH7uqr3V/6h6Y6/P1GPRi4drto/ODcrPl19pVy
+/w/NrP76PFTHUhgL/RD8uEdtUuylu5VY5Yf/GphFVXJtzKRvuigT8lE+Vr4f97jr
+Uu6tjyvgJv977wf3V9dDNhabs8kf2tvLTP5c9T+e++dlHn/vZodIcgIdG1qggMWZ
+l7Nz1aDChThCe/cc91vrf//laS1lbmLPqQ/xs6ItHPq18o5PHpMHOtM774Uekp9o
+XwM9aq8DZlOqnv5aAp9vAUcxJ9ZPL+yxDemaZhDjAakQDn7gjB9aRYxyaeyO65OC
+HgbLfYzpjJnwflkE2ySpzB1Qp8ZdP+IgIOEDBsM1GyKhf51TdDEHrtXaiO+prRUW
+FMcTNQ9bf0z6Y6RGUaHcjTFmSV/4g5W8TUdOGXtqogq70bAY2ECdkoeV+vsrTMK6
+77QPqcUOXTCLTUMxZ6WVrO4lcHCY+eH1sh3k0yskyjP5SvPiBDPl2+atODqK49dB
+FGjF02MTcS0weElD7kczEe762WB6ZhZXw1gffSDjh9reVXdW12TUbmFysgsuCQIv
+p6Bikpe8eRdv08F31Wj8kOA74hymZG8usUwZyCpDgAKiF9eS1t8zXsu24DdNzQTB
+cGXINoJzdOjFYXtxvZZ36iSYtnDQx/kNSnmb0dqW4CRFgXir3qvWDLqAuJ18+PkY
+mQGaqnYl/CyMsMh+yVA8WbJQx5NbQu8PejB0NIJxsgI3GqR/J2COQZAfkzlGFVvq
+me2XXFLAZUpgCp0WKdfMjxZMiG/jY4KX4vnakgi7k+XYc/LZdDs1P0YDrv199ImO
+AFCygx0N9YW1TuxmlkhtcCF+17Q9bivpWs0PYnGUaqhjL2Ri9vFUolUDasvfECKR
+RTsZU70EJQpa1eZDAsWEMRind1Eye2v7Yf8F349kKjws86iLxzR/1NUQd9Xflp2a
+YLjiZeYwkrHv+3/FYA7kdEYkxwb2fvsyCGSnrkA7lj1ARGpZ0y1cPW2A9MKFNuvc
+1yhR4L7soIyz0JTZgR2IoXIrhKmUes7ruxqDmckH93okny9FxRUgDwL0T72DOUVT
+3wyF1GUeogYUrkMAdT9bwwRwNa+ajZaBV+bOWEEX5/OozaOAYWsI/UV8l1qIbz39
+DSWklqZBXkMQj76OBZEK1zF1yKDUHrSErj+VTvMQYvUJgkYgSOo4fwcODVodCMK6
+yBhJtBJkFwrDaTJ2HM3ij6o6LYxLZi3exQuSOvWoxEKGh1dLUrpRDg4Ck6bLvVhT
+qHYmoAX9mVz+Bjh9O3X5m+BaCbu5Q6OCEZohfWxSPkCBB2d3Fojqq8+7Wq0gXMwk
+MJiXcDhBq5eCgsDsEDIxPYj9M3Pya6j8SIyEDMCe0cl3zA1kT2OV8dE9fXcKlXu7
+ws3tpbQoJVg7isu2B0fUUT0SrcVGWKof1hibr7zVpqI91qv49OFsyyRcTznYd7WR
+Z/yNVCv3bCmZtX/wdOsK3Fg9seSdRXU8JjhtOPLX34BmQt9GqIlmJFhk8elCtYQT
+iqiQYPJWKpxMPLiqDb8MkGw5/CKJAVnvXkYS4iekaSyYg6WdTbVq7AEhNYNUvrrY
+aeQYjC/HXyJvAASY5I8EZDWcnlKwhUtxx4RG6qlL/kuzGb1Jrhw7aal1ayVahSx9
+1PE5GN/y62ghCpZTTrl2S2LBGY/0Fajv6AR+6HHs3jMscJv5i9yVo1nAQrL4/tUk
+uMRZGnsfm+ZE4leYWiPK/jFoG8sJDVEMDvE2bUnZnYXj/L0ogeumpUiJpyQUBxn+
+Esptq/4JAli77NSbNWZMFi9Ue779WeGc+I+DBOgJPV3TT1OoHRQZrp0cLDo9OEyF
+Y/5eUoaAH+/nI4009tAw1qZSjM6Yx9yOC+OPyjjJXmWO2TJpk0IYGz6KbTX6gFwD
+VNIZnOzyIVKyHDo6xlByDCWwAhtM7iX6+PzQP8nnWTj11DhbgRlKVCeX+gK07QXM
+fLrAZcxmpQbG3gHuJ9i+eDLnpZk4nIB5hz9Ug1xTPygylyvj5HA4I+wicUsSr3kl
+w0PV32WCvFgiydKXV4lSCtDiHCwj2r13eEdXym/ga7iPj8oDPJGbZYCwfE+DaJmk
+bPDHfMHxWmr9a5VniGCi4BWedcnMG7ki/RYGk34iAclazekYe6qBGCary4dXzBAO
+QilwwzJUiFiqB9bTguORQ6itaJHr7hQqFgy5ogrDo1a3jYlky+XWzMYm8et6rJGF
+AOaUrq0HNYt3nkGFc0yPUccAw6S7KdpWQMNY9nt21OnbN1D1guXOx163DyUCMlgd
+rwE/5ZsghYnOS6g/Wt9LjZG/XK76+nDrWlKvtwYVx8oE6yPMbY8XPnVmOvD7yRYF
+h3DdX7e9hnLOXJOeGFzAQ75FyqL09/OhLhdRclmGukS/LwgykPEvOhjOzPJxl3gb
+pynvQRh6c3NJxfx3KV/M7d2c1H+9iMCOlb8TaqCnZ6/3dxF8hxGYrbP7v9b/KBFq
+U59D0vWWMUtouRLQAaCvg8RSwAs3XZZiNGVld6TOYQ08CSQtQRnVrFr1xReefNEo
+WrHAJlzYNSG4FNJmPpX+oVBxlw3hBxRStDGCTf/LrxDJN4SD3Mbj/33nC50zFv5B
+xTUVUO16jkig/fu4nVvfowbNdijofEKYkXhvh62ptVtkXhWx+qROnLaSEzgAPt2c
+TrIKUrXl2PZaA/vGrc4kRVbA004PSiUBDITlDgknJIhK01XH/OPBwHwNnPY+ltqa
+UGQ1UDxtlobgb3TofRg/OovPvhz34tFp3oi4ST3Er9RnStJMFZa0J1N57EOz+wHU
+MNX50wKjsTBHL8iK1PfzSFxUYSLJNRJOkELtQW/yUFw1gjlshE/owiQx3FLVB0wd
+N8mWwDt7AEoYwZoGHK+dOHYnyeRYCQpva+xgt+XFvOlX8+NhqpZneqWLL4G5gCtc
+G6s1N+8aHbuMPAv9oGgqvfTmyMvLKYeJQeae44Z2yV53cb2dEkUNta1kwQFNapD2
+9G9KePikF+o7YdiG0amN2m2Tdtt9scTYCGKBM+XAy5jP1okGu8CJTNwYAkW1Lkq/
+ISCDiifOCqSI7SesSVst9dvQudggahF2QhTpcNRPXZgKFnQ9XSXLYC/do7AYEsds
+Pg6UfK0jl6aTVMfbpqXy/qx/HIGM0i6leh30+OpYimLWAbYD25CRKvgNQwZ/Z4O/
+x14PXtxTq7XGoSrLscvc/889un9KheoRtupW+GRHqaJoVrJDel/eDshiOyA0jZUX
+brnoAGsqNjmDl486+6QAQVxjHWm7k/Go6e1W+7hsAEHHXhqt4YKW6wRsNReZMEAP
+dgE7FHNFVPb97q52bZo6IPtDqAE6H99YeMlX5TVNVaYqaYFkBxgSo4jEEXQAGsdp
+LYZ4YQbwocjQ01KrcoqQqnK6rBL4Hce1L+qpXSxwSS4Yu6yk7QTAXM90kRDZaBA5
+dJnhDbphk+nln6wnomlM7oLJUuVTeJDoy3FsTOIjyP01RPZnNjG7teghASSGjRnR
+huYxU4htfB0x7qOP5kJyFhKCvY9EoU0YTgwq0bipZSQPD5xwpVK/LBxGhBVI/0Gu
+C2o237PaaZ/eruifjUCMOC2AVOYNuYBS7z4/5xKwZMKMiA6OQbl16sBGRgOZ2s4Y
+CZoHBRb4Ev+ZJ9EH7U6gJjRMO8bn66hCToElH7Zicgh/gZQyL8NjIRnqaTAdX3SO
+efzBaUvJwG2yZW93malQMk3rzFwkxQTvcG21GtkrqcSWTkUzjF2SHej1TtW/jUDH
+HUFA4XxWQMxWFoYVy2ei5l+zGr2luzxhDqP/p9ktLi3UkYKHU3k+FHJ4n0eItW8D
+AOpJ+nqYURyaP7fdy9QDbXXCGzjrtMdqgZ9wep+J5mY+GWE4MVYYrzd/8kFvnx8w
+kYm1Pj0JIyicseaviTCzf3wx18yS2iJpm1gSl0y0F+Q+kDTFCW5eFawgQJeT9jIC
+sI8jMSfgwSlL/lOKn1oNvhc1EaMCMdz8X5OEw7ArCMFWNUbsxJgvw5ZufleKZVkd
+IntprKnVoW7QFqROmXwQMzH6Npl31zxfOHX6wJ1Ix87npKyzD954bhL25WRd0T91
+vt/beM8WVYLfVWUb+g5ioFmDR3umIsGC6xr4qMctWMv9JKGg0gWHnBP9OxDGhLzY
+7rSBG/SmjrToQ6u5qj58TrXMliJ+PDrmEggFeqtV/p54Cr6U74AXp7aV92JGByY4
+VQljbkbO6VCHiiyPv4LfRMLmMJxA0j1DjpsLGXy1vOv4HSnRyOggjUiSd2lUBGB9
+5FaKdGK4L5nzF/taCIlPcGlDnqvWiuyWyB6u8fVr/Xm3t5H4WKqmi6qWd3VaU7VG
+dwg9Sy3sz5J69L9AUleJgZYlbqMv4BL/OOibNhzJ7S9fR/5fT/iwdcljFgNlf7lL
+Fs23q5cjmiCqRzFcPApz5swfiEBVp/noNh0mydpBlECHBkhYgZQFWcydvU8cgj5R
+kPMi4AkEzJqHFO+fwEMdGKFsf7YSIsQVXMv0AVwU9Uq1mnh4QR2EoZMx9b2Rgogc
+K0KpIwbI1s0XgtTEc9KIJD0KaqUhptbw2LeFlDebo/RygxssJaSjutSb64IGXttW
+G02oKqpzWtn9tPT05FIri7q+VcOaidS3K4K27XhqQfXtJR3bAxqZaWL2RRt+1VFS
+tKwuTguIZxGiWUTFEHHQvVDLLB0rvmt198HTVVWEi4N/RC9DXEPiiHKCrqM9BYSK
+QGzdrcelyq/N2bLSD0yfEAcPcB7xWMHGfjmAoS8lm3LZpI2NRVb6vULrAeBVWhvg
+4Ut/zyEruL0G0a+S6Mv11xDn7d7W0WNA1NWntKMNasglGjSg5B4Ppz1vtURFIKFK
+A6MbftIwKdmQ2MicB9vubdViwwaMQixwE08u5JjAi0UBs+Nh8tSPjFjd4ZkRCBGF
+GieRlvV0EII2ZBWoqvv0wuMRbAfqRrbnJ83Aknrsz9GP1k7zYrpM0MvueWXcuvU6
+tJQj8OO9tccFm1tbWsXBKUV9cFpyk+3K4ajVeeWoI7ZT6Fnk7uuCnBbWvlujpH16
+6G++ifXxSMxyINEIjXjIjZ3nr4Spl2IKKzTcJ0eU6MUMhbbGEYdK7Kn/ghOFoHCQ
+gWtwH/Q2dBNvO94ygRJ+zXPTdOhlyMXu5zaNEEj0LHAx/xlWsHx4LOhK8BdwdP8k
++YcMEXl33pPjINOhcSA9T8qUTfFFDwuzvipZRlzYBJHmbMVjlY6PvEr9Te1/lwum
+b3mkpt75dJeVGgILbuUZq/D4XvcTBU9HmPqvmdMAx2VQC6NmEsk+rp+pns+xlnQS
+T0u9Qr+dmCFtA+oqxMUY2flGKRFtAuwvCtr//6lXfJCmSuFRsSCRyO01wA6KVgrx
+e5NCmSPMIqcm7bPyzZrFIaXzdHkhSZA0gWNNQ9AEt8yGUSgi1Wid+1lyfMYThTW3
+F7GxzrHA9X3TKNPQZ3Di+5sw818Jx09TcbHvnrNIpIA1MXDu4S2RpEnDGszc3T1a
+q6JAGgDgT048339KHj2UZSxLJuY+aYyhow8SzGpcSyKoEbKPeYV1CfAQg5FvSBYJ
+z7zrVHibLx7YSGyCFaLr4LT42YCDCpAaHh7FBKYiUilxC9UApCkX+ApkNhpmGagP
+difoaMgSH43BENFBDFjSwJy1J53U9qda2Zk3V4sadckrM/IEqsaECU2NB158ycWP
+ohWw0JDUtCM0Vxsy1Jy2l3pHSvMUIpwsFBbWdbShNrR6q4yV8ska9K+m8krPkLpY
+q8SbznT/aAAFHozBB5F4zd7oBxu7wHB4cH1ZkAaKLT0Pg0Go8c4DEhwKVQNNg1Yx
+waNtnNb513D2h9LUZPaa2kwwbQzFriuUhM+SWbXH1Mv5dOvKDSyMgNwxskkvbfZ0
+37rQ/1kDLTv0HRRu9gMzCd9GfNF3EMSpkL29iFnpV0Ep/Oq4R51pII3vKrtZhGyj
+oyO4+urjIhw7QeL5389ve8rY0rh1n/+TcJwVjKbiTSCXwef68NL4JL7FxKj3+uzP
+koP2H89sh1JPSBMr+bA6IhqieFI2Ho16Pxj4MobhOR9rkC7gks2Y2T1I7lCrhWRI
+21r+Nnj+dmGBv6D5sZe0gUjQrZoH3DlyQ4ON3EGywzUfrHD3ntuNPM75F6GSWQGx
+JIwFyj7yBFFDkc/S0gJw4sVuGSvBw5217xcWQsfCOoZBx7z0e9zTCt3y0kZyEn+F
+ulApj7B2moiHprWnaap4ignoPxvxDfranhdG6R+/gyIfU4d3/HhZBM3zoHi1f1Sb
+IMLMhdl7G5W1GFSl2JwlT+XIZ9voUdcgLb3NL1n5dzmmqoXlf8xXsNeuQS+9rPnK
+sIXrRFHlaP5OC65f4thcayF6ZHTPMMm/lPljKUdEQJkq73rKx80zr40BCiGY8+8I
+lwhQ9qYlJjj4pfQbt6m911MVlRLrFNU7kANLEf3eayNa4WV5I2BhZShwcsJYT9Di
+QDvEUvDS+Ds9FUVF1qasiI+jVxrDBbpuHtJYgOzfdnsotr+B3T4mm9TwyR4fbLN8
+rdmgsZC6L+7tUhJo5geldNJC+s0bPJTjH+TydVtJDzog6XGO8NL0EylmNdCjOcLi
+bSdf0OKtkWYMbL5//EnndjLP7YGdp/BTvnLOaX2aRCPWmrFXFR9rj6MPLpp2d9oq
+YwMXxJvqXc0+YMH+z6kNm1CuMrXOuWt3J5QTjCc6NuLLs6mtrNd+9tmnJ0PPW01e
+qwy0nK5wfZUUDXusLSos5DNx2upNl+6qSzu29eing1zvlR0irctJ7zVKaNQAgHtV
+MeBPNP915JjSYUaUwDjdmjbiF+HC5FTUTWZLByHd2nOpHtjxR7bIl2EWUXncYqmZ
+Uz9ixJqgTfX1WHpj+lbCh8eiuY9IgLnYOgWqG4n808Jwlqml4em3SZ2Mo/mG4Lmm
+C7kyYd8fww95w1rS3cVgZeNNJNFQkx+cYVnPi+6gyZqikWWwutLNSrNBlCuT4pq9
+t4j8klDVnxWq01tq0m4VqMaAtRNfbPhoQszmWBcacHtVawAWC2V2AoW+BmJwt3Rc
+4ik2PtF0f/bHx0DyoSq5+/yfSYbpr1LqXbVZ7aBBroXFK2eCLLeFE7FcYu6OlqVF
+pQMdfS5xDGSuuBL9ZA9cdhHsxY4c1QknM1QSwXt5iWVoohhnmT06FCZVL0TO7lLe
+zkklylVTRQWtqa5XtLJjK9H+C/tXxSNQGFZ87QoHi69Uk4Eme4g/f6IAObwDAQsx
+acNzGgmxtRPZ4p+aMCgHXkUZHXYSFJM0Xdw+otYLmhvJXhdi4mKi01G/ZCHd98DP
+4FSdl0hryPQDHVJVsTzWeyCazULr6RO5qJ6dq+Kiu6cwv2UjMSPjWRD2iOy0FB16
+UdWEG2wcuo1ePa1lbeDuHpdSY9IjLP51EqwUpM/yTXF+lrPO32ZYRvf2NApqA/qG
+e5UbxFyKMkyCnTwVrDCyg9ph3z/TVE/m2dksivV2hmtWaJ81DymPhazQQ+YEQTof
+n4e6VGqLsD9UkyYGQHlKILYiXlIvNa2ZbvzV3I3Ol3g3wCFtnFortvZ/pSKg9VMm
+v5ph0yyHMC1bKaTAGjHvgqaaL2AtoCEdCGgTBDNB7+qS6fSzaHlaPS3m64HaXpcs
+S6dyTKO9xLB8mLEiX2yTQxX3M9Zg0tuB5z4gGMkMfjLPKLNQi3aNx+/F8kbkItj2
+0+viWhoXiVk4+/fos0jk3+tJzu7STxVrrEg5mcpt0cewlVqXkLHYJgJ7sx8VVt4a
+niwP27iV2pTiTUmfKDhEdsns2GHVJj6iGvte60nXrlVxo6bF1oXYW28syP5Nj1iL
+6yy8yETqjbxtRl8IXG7dvgoyvv+X8cXf7GH1m5qyaCaAmO4QM4An250S9c/uRknN
+/yYB/bO+cR7yvzEU8y1MgfE9D0Vgu+nA4/uc+e/JKXl/jzc2SeJbwKc3W3IDgbrF
+BtGTrg0etg/ikqdxk26PNUQQ2/EUurCHfBqg4iJoKwuRLqpOtI7bUyIc4Q77x49V
+aCTQ/dScEZCgWs6QMsbiHpOWWjrSu8N0BMTHjASLNwxjzkfWRWWmIFRMiGZyg+PK
+m9zXYdfISYoszeWqE6OiX8hAC+qi9+fSV5ec4saiCHYQHll+Wv1wq2KRCjhYZLxG
+lqwkXB52gKFzC2jmxL71lnORsyKtzcG5f8lSFtXhqwbnHfV2O8vhRKHJCYJwFhND
+7DzNgSTvtjunRIteA4kLMV0smUWq8Cxk22pA/NWFb+cYlLr9QgkN5lH0Qh3sMjc8
+9HJ/9B3tIiAfb/XkXdcrp6S4es/vOmckmgE+KiStYOnTCE/Vjgp+IbeP6uCocLju
+pBB/2pmfGizSkxH7fFSZtDw9nXMb1pgqbptsrGvWNaY0tOosFwQ4FotydNxP6PaQ
+bvLbhNWyL1WznEtGNdYJjwk617aX1uULh1F2KrnXJczewFIlajScxKK/h/1i3IM5
+vQ9bSezk4SG1qRyes0JEvZXh50o6UbTs5kbztLLuhFDBFLL1yPypDCalLYf3iDi4
+xZb85mVexyu0TEM0/KPLVytUXJPyrKbGRh98tVx0IxmYtydkxjDZJ5RA0qecQ/2h
+hw5VTB6DHtbfiixI9tORdy20tSGQYqNjeq8QapaDzfEaTOB6D3FMuobTbJw7Di4G
+/BiKU/QYKfcbUXfai36kKL7aaTo67ea8llHz0F56pIpPgEq7aRZbryOGGGi0EqbI
+Se7sxSDWDaXkKpKpDZ7XIuF+LzphWg67DczkXbmoPlKZ7IIyBp7LUEI6W8vRgOOr
+HPkv5RAy7FjlwNNj2XyGMnqubOjVCB2ZkqMp1+K1NcSgnneBTFRqjp1mQDtLf+9M
+a7mvXQLgnmOx94osZNQ5ZtR+HWzBhIK7Z1nlU6R6XWEUwxo4Ep+55aRXdhfxxjnu
+8+KTwAWvi3tiwdg9TqFvJ56tgy0AJ1tVycPaLdTdefl8Knj61V5NVrPB5cRZ+Ixa
+lpZ+fbbOggG8AtFtLJyWBqcy1EUEBwhZ8ZFdz1x80EIReN6oT+Aox6je8xfFMqcl
+lEJWQ7QIeczAsfQ8jKksv3i7Aidm0mXZHN9ugZjE6Bgj5YPxMF6sUhyepDQA0bTm
+scAtqn5UWuNyaTyrEimwLPISnvyTqTHof2t20dpSu79UJyPMUpTBZUqGjf3RkBPt
+I8OpaeQlvOoz6erFzAp64cqMaovV50ZEVKbW3NaQr8UECkvTix1geaBpYiRPbaGX
+Wp5iwSebAhOg6dFYCLzmv/cKRJKhmdeRULpP/LGSSXpM4HD3sS4kGnXvSiF5KdAs
+pjB9T1rXMRXP3QWNDtDtN15ZTbqsV2zc0pdhkAWRQFp21I4sUATllbWx3pv8vToW
+33s6ejBNtKt7P/T2Ruw4TFyaTyx5bosX3OGpawl2cAxtxYWXf7w1wWUKspsMnLTT
+QMEhsnx9O7AFsivfxTYkkT/ueKfvOqTCG0rsVB5/WNloSwAeJBwW8jt5KYTNA5wr
+9odlyGOz+u2Tf46Bq5hAt1Nnb2mF2CFErtUTzzUiYdHea5Q1UGC7VLAB1EVbq/2j
+2CwFi58cBZrMKB0FOmtzqUtMVLn0m5O/Oku29KpLB3R67hJ/kX90uBep8EgKNyY5
+dwe1XJTowRKlIBTj04gHp7x2P90mpWl41ZQY/iXT5YSorDcMzpQMxoLb1rZ6jqSB
+ZpKrx1d3g36bxIwjpAdEPm7Q9y8xP6nJVPqym3gj2avU6wEC0Yti3JOr5ZsSZrm6
+rWGyik/Z6fQKx+beQYrvyD3vVR++qfjMoozgKDfH7PUpfk4H6anj1JaGDP3SWuLG
+W6DYaRY/lXZD20kJg0q4VuyYUExZX7c/zckns3Kal8uk3eu5HjucnJ0KZL13lvib
+aZmIHUfi0MPlhruWaKG+Ftf9VO4cUtdliMkoYb2mGOqsxDgh+hDrppglVtmb3Owr
+UPbL7+omyVZvpfDXCp9QtMJ8Ftj/CK3HQLsPKi4yRHJnfC7/5NtRtrRihx743/zx
+4v6ARzFar/R7mGqGzs132ga6nEfhJ2TYUa31nfdBAskcuHuWQ7s7qR1/fm0LrWfR
+0Vse7PR6vjM1j5LLw/uPmkhWW53UBYD8CfZtWNSe7DX1ugu8BUTPRcEU2n0pp1Od
+LUe5cEtrQdWE4+fY6GpHHePdoKSW1Wm+6ZFo3tjL29POOTU8m61ME97t8lZfNRI1
+fkeOVUsgpVDj/RA/r6qEQetdCa7Fy0vFLt0Rmy0DmqeXkm0lZivLTvDaA0RFv3EE
+3dk0cWu0JfnWPrUaazZB0hOGCnHO0RAYPezj92muIm2oA8YV8BxAtAykiVdO2cMg
+vxwCoBV/F4ZjprVMUHeZCPDUKRMQfB/Kw2w7sv58bGWXybanSgliXh1MgNtpDnHg
++moVEYGSXVqGQlvWpGRQ8Vu5CIa9+rUudMGi90YhKsZdzxU9s+FFgxCJ3c1PGxjH
+1EqZqCA2Wy/FF+dwWDmsHT4JMPb1Z65CB8Zk4f0FCzDsigmFlmFqCJv9UBza5kn4
+JVh+KwXrklBgnkrBa915SLSRTrKLzBDMyYGbi8irmSoW3fIv16SjqxQ8BjaiEIZ5
+c095YeGn31NBC8hlbKHr+IZ/PZ+0ryEJitx9MUhOFItOw9d7f/x+sXJpdX7f8hSL
+hMXK/mmQ7ViImhJb1gfAXrXEZGzaCUIa14FbxSmSwYQcMUpbJORxWR3pVr1HVETw
+Ljp6K44O79N4kCsXXXGJTUG4hebkhPoKb0UJWQdjegy+5vzNwS3f7EvM1N1k/Nxs
+I2XJappCWrm0PkXB1D3mFgenpWQzyb7NFeFk+qESShw5NUp2FCA79k1/mzp64Vlu
+bmJ0IuRYoayD60A/9mScQttaXDXl/L/IbHNBSBndlo2dmk+rR9KOwYm4jRWH9QGN
+7lUq0/Le3ejaQwVZsOVxPcKBuMffgyp/LjX+tQjUQ/wk+SCtdl+u1OtRCzdq3Hy6
+mvIhdoKRW01MdjWzrd2ixoDcIvgEY0++bicYH6lO381iQNk7UyGWmmYAydnXIr8M
+y6yxQELikvRdjVi5VrUkWhb5xrVzpaNYbhLtFYbICEoSS0R6n4a/XHKns5ZUL32i
+oFM50qOqDvuECUuv6bqXYuOdY+UXExVWmLDZ1k7GLdwtkwPn+ovamEIcoYlVPGkn
+SfwLPoDH7m4cv44vG9YcP6sjy/uEskLOJpVyRCRu8ptW1dU8OPfWBXwA2h6BNJlz
+7nGXbhcjA0WaJiJy9p3sxKEBLpPHHjnzjtAJqfx4GtiqUKw8qS65OTQgpDNh4hS9
+8LKv2mV3atzu51+kvVD4PwomUW/bQnL85VKRW2RaZ3IWIquLbF7DAWwW
 -----END COZY ASSET-----
 -----BEGIN COZY ASSET-----
 Name: /locales/ja.po
@@ -38011,26 +38058,38 @@ IsyFlFXxcsgnSiulcC5hJ7UAdrHT+5LaUBwJ
 -----END COZY ASSET-----
 -----BEGIN COZY ASSET-----
 Name: /scripts/twofactor.js
-Size: 3118
+Size: 5248
 
-Gy0MAByFcWN9s7i0JoYn9vx+tuyvqX6MgZeb82qTiVZWWb0GicMK+zhtBZAUFgVj
-dLTWLEKohbF9OXmzhEmyPLOCeyhQGkQyMZJ4iJsdO+BOX3v2LzTIdzqNYY27EcDe
-L4/S5MPF+gv7PbbErW5vDPenEUAdfB8RU/gove9bAjs4sE0xBWZrjStxC4WLd0gk
-zQFQb/OuX/EujuBaoDQiciFyhSD6wPKPXDKQ3pMO3PPBzgnqef5t9yRQUsskZhC8
-3oKGgSxnkygIgt6jIaWvEFof0FLkfsuUCSJkrOWaDujn+18GvwS4He4Gz8axQMLN
-ObNeEqSJ0mevhQpKfOnkLYX39oI7vq4J9B5theZtoMBrSZ8YAHDb83ZaYm8tmjWZ
-QzKyTdrgxPLldgiHblWqmFvW4T9xDZqTGWhGAApyG6QP0BFoYEynMrkcBiOR09OU
-MikxlaNj+s5ZcB9yVU/WcI70xTggwhgOgeNifzt5f3n25/R+cyQqBOL0JEL/e3s1
-hORXuGK2exGCBpLNxARwiaFkF11nl0LGMNtPTPu23l5lDWpNp281cWDxFt/9B33R
-z8lcuQ/bzUOQ8kb6HeHznZMUk7AVcG6yt8AiNqpreSAmvjD2qPe9xQmQTes9dsTY
-i7UhRHfit/a4Cllf3lMKy2jdHxIRUOwGRxUmPGviJw6qSclP3q85WpJWSnd35JBe
-428I9mIpMG+tOaUUK1d46P6aRCuCFr01opcKJIqMxIkPxvGRpimPlqcxBV3s071L
-fytQC5QidukufJNFdkW6we+IQhZJ6W/qzlnn43f512egvvx3nAsxve4hYGhClULn
-MitGuaqIrrJAQIu3ZAZPuWyogpKUCNoh8oD2x0usAOWlLSwRRG5TqiMPIGPWvRxT
-yBmX53Y7n8/lNWgQtS+VpWTqYG8SIgK0JaEP5B0AOQYvbHrdfIgnTVl5WDhF5kcq
-p1R2ht+hhTLs3aHTNPn2aXLOCLdTbOauAQ7wBr8CCzRr458urdVk2QfvqI42nJov
-uWbzWRiahgt1fgN9bkStOILe+nxeRc92qYNSB8J/ccAa1OXeHmzItbElDhVnszEh
-TNBvN/S52I29gm0vs6hd1xlPHHWA6YaPcum5/0mKOeWT5zhPWjEc+txz+QAjWNcA
+G38UAJwFduPLgZETyNbWZrmyvYk826bp7mVSJ8OdRbY+ZEo99fumHxe5IQ/qCeE8
+AaLkmspm1PQJ1G4ZkHBzxI6wlcLi962VFwJj4nSs2oLfu3cDAWZFVdXTIZgQ6xMm
+/Hw0OwdoZR5Dtf9EIIiKaHqs28q5Bfkau+u2Fn4NcBrBnrzcSPq7hP8fR5HW8mIT
+7vuaBhvOReG+4zFo34cJ2+LAPuqOUazjyERPC5crJIrWBGiP9dWvXMWR3QPKIrIU
+Ou4YxF40/DtuFcieJQ7c4lVcC9QN38lDEqASLYuEIfD2SDGMwnJaEQUg2DMnpPId
+Q9sLpxTUW5YsGKHCWG7pUPx89OeatwS3H6VmTByWxDvwoiFUXWb5AWNwTms1zdcK
+r0YzVC8DDRQlsn86LR+yeeXLOo4kICSadUVlFaCshuBqEvmLouTQMaAdPG8xO7EE
+Js6pfQyUjg5ASexMksq6QB2/JEDhQaMkctQ65SAHH+0LyoMh6qG2H5SKwto7SVnP
+uztPkJU843AW9IzdkBadPOiyubbnUwXqB95Pn6AFv13spJ4jLdSc6I67heoQQVrq
+BsGON+5J7jJ8cIH8/VIg5FrkNQHnDXknyeiWSH/KOX8AiXWB0MZOUrV1f22MEHLe
+rV9E9seDchB4Y4enaLF0GqznRZHzvGRhiwUK6gmvrBxhRKx73fGH2hGfXpb8JnEk
+jfpRQcDLDBJmKsKCVzzBJ8sh6zm6fZdKSF7NFR144VVEP0hZ9BozWP/J5L61A2aE
+JpiSHNjuB3masrjAV5xPWgJc2qj0OA8mGlFexVdpiGUY1/ITsq+VoKd2vDshwKvd
+KGSAOamRXa9JYdnzrbkj/455vGIgtd47ZA8rPg2Rsvq4GRQhQjb/qAqxABwm5gRe
+l1PMOEDGCTsmZrP+FZ9qrFqSJJ/EckI+XpzK9+DJry3dPuDjYQo9vmdiGcAkMjaE
+JJOYAQYaXACMYINSADGYCl2v9nxjHE+2yHswZNiL49WCXaYp9iSGvKmzwhgsF+t+
+IUngXSq1DAueT/YXuKgpZQMoaTEmNVbCPV3V4h3HBrTdl3br3j3udjtTcjtW2fQY
+WAda8smhIRUqRwkS9DObuWsMZzLaNtMdx8WVbe+K1b3GswV1VeeCUTb+CdzoZ3ax
+iDD0QzZCkbKmsCmet7AlLCoovdbpdQqkFcaa+tstqM2IZRkQ1CE0lYdnFDuxYEIK
+AZ0QtR5qfwvKNAX1Wz+whYnUZ4yj1h9xoXSb7vhbcPV3up3/Pe97HGKQdL+WpwQe
+4lFSLkFdrRiBPAJAxRDClvMWIV7oDWvdxkRkLcmP2cILNzFHCqBHsxi6lEuRNSL0
+t8SsrhkJ6IFbiEO9PvlLhxgNI2bO1PLos1jXBjxf3KPKJnowgd8voX0M1o1gyM8k
+g2aMW5MpLQPJHwrYgqaiaRoaWxNSMM3uLw+b5YhyplIv94mjCcSq45mLyhYHQkYS
+9U+4F3S2WBJJp92oE0xsmW14K/TOm4fc0MEoKzXBhJzW8Y/rtZLcmzupOE4N/Jld
+GmfxMDoQU48gXkKlnIsbyIAA16a0vWYjNi7PusuXT+63bhv+k7T1NGnHS1g1N/FT
+MUs8yLh7Oll3ip5XBtqYog0xeSzX9tW8pTHDlEJTYXGZ+X0kwo4bOngfhiq5G8ex
+cSlhIdruuTQeTGwnPyjHVmPFfrXO+MF3UaoRg4Dev0WjvIQvL4lkktYPcG603dHa
+BUtY4HI0Q00cjCKm4o/RKNfd0l+tVSHrCdyT6XWTpo06+VIPSjoIqv8gQaekFr/2
+v2Y1zeVM3J1Z5XHUauCCCg6hIK5dYjdZcBte7jFWm4RoIm5AN+MBbaFHqKt6baPG
+Y4rd864JJ73lTksq8JWI7G0=
 -----END COZY ASSET-----
 -----BEGIN COZY ASSET-----
 Name: /security.txt
@@ -38086,55 +38145,85 @@ GyMA6I2Uq/VkqfIiVgp5MJEDhwJrl6cUUFb5zFS0yk6ACw==
 -----END COZY ASSET-----
 -----BEGIN COZY ASSET-----
 Name: /templates/authorize.html
-Size: 7434
+Size: 8101
 
-GwkdUZTs1WYUhaMAnQd2m4VDkWJKiiO+3BLrq7W2+u/n1fREO8jCmTgwWDIctt40
-QWr23FSvd/mXBhFRumaISavfu99bZZWuIldh+4Wqc71zk5yTAi6zI55k5mUBWDgU
-6p81auX6NQLYqiq59+51NvLXVZZ97CzGkqKuQl/CWKpVjDEux0zZCotQPByuWCS6
-lrGUR1qWsTT9t7ne4wwECSEGU+qqoxxYYNvErJ5LdF4sgjsdaog8A5JPg0n/yDiX
-4K6R74pqiCHyTOy7bTvIJAnI/PpTatxED4z0Jmb13KdbRY7XnsQDWyKqvHbECig9
-dwktnplp1+JF5mbVyy2BNUGQ1oglSw6LysmYQkiC1YGc7uJ19FbXnw8a5U3Tb8ct
-s3qNtL0iHQp2WcY8rCh1YIj5gteCEcXBCugCD5M7vG2/x3qxzgevkINuu4B4CppG
-EBXM9QzAnGfI0WWwYQ1F0pVeOQsJiFe/TF6JUB/D2dvBgjCls8oJBXxkeFxCmfnS
-9kKHyW88HPjDE4LvLHrkrRxWaHKgmq/o70MpGESRS3Ko/L0mLrFqZ6Voz+koB20z
-uQK+FEaaryUvMR2K2ib5ld2pKhLEzI7Dx4eT6UGBhY3i9vHfXA8CCKsP9PwIr4jJ
-Amd+3NgU02wjh1/77Tc5wtkH2It9fjjfuUmissLGpm08hORfB2nBgUBw1cZ/NOcJ
-qNc4DKpKpNcHtP6ZB5lLhYYiJM/APEc6wHHMPcB9fuG1/0VCZxxXMufDwdlSUJC1
-TPoyAqkz3CVM2VOOrskNmZGRJVedc4Yaj5V5Kiaw8B205xOoyo2BjCCbsIcTuZcx
-7t/qeorNGg91yaB77hlbzEQ/Qz2afipiyRCV0D5ZIhr9jqIlhwYJESW1IVAMsdKW
-pFJ/8j8+LCfjuQf70XaQ3taGgVCUhOa4POzqgwdc27YtV9eEdIdWsj4sRxvOssEG
-3wU/6awZGhduPecZU+766vGJi5sy3bshKFyfXGqNoLfx+hqYe2zkfulb3W8dcOhX
-9KsdyO3HEX/v2zGWbT88HIzwa/5C9BPRO/NhvU7H/MkX8+T+sF+4YP3Ea3nSDOvz
-a1L31z2Ms5sP80DUzV9+7lrpPs1XzIwg8WkaydTXKCle54v9qnaA/nS/YJj5C49V
-7bSyXy2hF/bFQdmH5p+8twb7/nnC9h77I77aGPvro3kH7N2vr5QnIsyvz/CdqjsY
-4pknZprBKRu7E88+n1PZNwk4e05PHlI8xQN/mlQ3JJD3zEgrtel2YsNRmC5+N92j
-0aU5PZ4K3OQTCsoT4XI5GWPPO2LTSYzMUMVEV1kjf6xSHj7s/sJLLqN1+kRtTQLK
-lJUlrq4M528/3PXImwQ2hyh73MhPve/7VH14/lJ5BRc7X6CXIX5fjsAMahmg9UO8
-bjSqnyWr6J72sgQAF1On9/luDX3/1k+hln/V2/R07WfquZf5ly0Ubupf0SQ+psCi
-WVWhgwDGJOPWy64yoU0Gx8MIgcnF8T3Z4sX9Rqtu4yAjpUBR95vt7Y4WwslmJHXf
-qnu75lFTokeasGFNe8hNju7QcMUtwnDpBPHEN8vhrwqMHE+oLU4+lEi5M8vyYIYr
-JjdgMwRlXbn0fK2HonfaVC8Xqe+nlQ3yRq7z7Ej6rS1B0jWP5Hbdq4bvAq9/3nNf
-AZ01RxfSd99YjVTVzF66tsaFBjq86bmPnG6UZFjTPf2SWV8vNqsGGwAm1NATnAqM
-yAvrHdLubMp7UkPJRTpt1U2Hy6S3OraDdrvf6Op0BE1sWJ1N2M1uwtsofqp7URr8
-u54j7vRHepgSt52/sQeOF15VR1Lb7s9MvXK+HZ50dz4hZOkGJltqfS2A9SpVWJYq
-6Nhxam+WQS5axzYEzkIglq8sZ6q6RhoToVBH+CXOAE1juUcmaYGr1ahAc8po35WO
-zUUz7VKxhlNgwZ3izmOrcWxVKKfo6b6XTF2Doj6OnW9Y4MGDusjKpefh1M0zzWmK
-hJEaRjbnhYVdyu6DloeBGKEc5ZyyWh5N4rqT7cHYglzMmr3tGrNTXIE99NwGG9jB
-TWurW8KqPffWnq6t9aJG1KBWbAUXoTNnmQSOWonxaTCBTvnp7nhbHDNu+YxxEA3e
-XfOQ8MoadvZyh2YMUZ3UaksM2cNMg9iuOfLAsqVWVIB1UDL9GM26eLs+udwKkRBS
-sm8PJqhrc+rEjEdjeFigqz0gi97mDnONkY+XZv+A5kg9bJlxGcIaGKcHSNmxvWnd
-FZLtQ2DO4sKVwFv3gbfPVb1kxoeYJ9+1F1D1hn7ZVCePVRah2QfFfP0wbJZLFZyn
-Xlqq7AHg1eJ76qJWoEODX5pPeTYp9/XeDRe+qJTOQQazDMiCZ/KACpBSYL5Kp1BV
-xpJFqTsze1bK/128tQ6RXf7Xg5eEVmoECwlmZwy4aj0wzwcn3iwvIKSDaDkYuiYY
-WhsPnHamA7jLGdMylfm4DK5gsUbEHuKgUmR3H3XXMbtTb6cx4u0eC/K27bja3lvf
-nHbQZ+Yy2eHs8xBAL2a62WmrVZ72/AbY2KbusJiRvj+Ty5M9WWDKk4klbA2zGY8h
-ZmVwXI8K/x+kg1Qi4FGioU0nstpdhFlRZXS3EPSC1bai/ZfxGGj0efsmeQJZ0D72
-kyQf7fSuRRThK02uDPXCcdoAcCRdJ/zEethL3IStqiCNGs4WaoBR+aHL4hTED8jk
-XHjvfSPjeMkGzE4xdFBTW5C1Owkkf44k3jm4+E0DAUSfsbWx4po2lEM3RmW3jgcn
-2Mh1iKgIr3Oe4yAVLaGVKi762RtT/8+ReRM//4yFJpPCkBzbJiNtoQb1lP21BEbb
-rvPArO2s9T2VbBrNcHP09IGv6pUV2RD/a7LhDcxfgnebWKrvNzAuyMUTDa7mIcXa
-77RBT7bVASZEPC8ClxPU7s2axqZ1iS64hF8+Acxn1V9W2WXjWdC+uAJCexAimMV4
-msk0C8ath6D4s2oVBQ==
+G6QfUZTs1WYUlXsmQOeB3YbsUGSYkUHAUf/eV4i6bKv+83l19Yp2kIEV9zssMATx
+0VQaZM+lr5vq9S7/0iAiStcMMWn1+9YyGeWjyEXoCBWjImTy63dPXgfwmB1x1VR3
+7+wBgAcU6rQ7tnYvl+1l5OGQpYWeRmnCocw7hN58qYrSerLUHN2iUEgGhwOL/EG+
+pVWP8EiLdLyG6Zk+6drtD4qCiBhj0jWjHWiw7ZCSbUt0VaiOBz1GiLwEsk/Ahui4
+4ziVNMqdGYYYiGdmP2jfQCZZQOHXbzLiJgYg0TuSbNunu9Xr65zMg1iCVB47YgOY
+nbuGmswstL/Fg8wtst0tQTTBkNqIpUiOCMrZlHxogsFATn/xOHqD9fnegWiaISd2
+Jdk+0g6r9xjYto15Ul5yLyPhC0GNIIqHDeANHmZ3+Lf9nulCXg7eIgO97AISKFga
+QVWw1DMCd14gT32wYBMF2pVBOQsLiFfvJs8i1HU4T360YIz11rJCAbcEHx0qzN32
+QIdN2Fgd5McnDN+fd2T9clLOyYBqvq6dBqsYxJBr9JB9XhMdbXRWQ/YSJzlYm40v
+4DNxcL4Z0WI6EbRN5OjuZigS1My+09eXi+vjAg0b1e2zjuBnAYRfH+v1GV5Rkwan
+bDdZFdNio4ffcYaN+Fx8gb3e95frxE0RlBUWVnWxCkmOh+aBisDDdU5SRMC2HH1M
+6nEB67JT5+ikm09E2xO0C44E+9i5izw/jn3JsID6xKaHmrgaoakIZFJBLNlx+5ev
+/gx9r6ou2mL4cWxBbFLOIffJ6sFfIiNr5na4AzwukHxyBVIFJrxsxMYtQ3VIS0x+
+S2SQSCQs0XZWxsKsp5syYyYx8liuJn9GOwm/LJMdxs6N0Dw5hyqRZaBpuIgJIHY7
+G/PpbtOyHDdZaKwH4/7QVJOS6Zew12w4UNeUtMm2HGUtTaZ9eUsGDxpErAodjW30
+di9YKgNUV//4tJxN5zboZ9vR4HWDCITb6mCad4z7Bvecqeu65utEyHcw+3r9crLA
+p4MOtgl21h03g0kLr+2nM2dub55fmLgoQS+OEVi0zy45R+D7eH8KzD02cr/0bbrf
+OuA5U0O/2oHsbkf8vW/HWLpMeDgY4df8Q/QT0TvzYb1Ox/zJF/Pk/rBfuGD9xGt5
+0ozr+zWp++sextnNh3kg6uYvP3etdJ/mK2ZGkJ00kqmvC6N4nWf2q9oB+tP9gmHm
+LzxWteOANMKM8++Lg7IPzR95bw32/fOE7T32R3y1MfbXR/MO2LtfX6M8EWF+fYbv
+VN3B4M48MdOMjtvYHXf2+ZxK2hjg7Nk5eUjxRAf8NaBuiCXvmURztem2Z9OjMI38
+brpHyeW5eTwVuCiUUbQ8ETwOZqPreUdsOotBGAqZ6LBodPwTlezwYfcXXnIZrddr
+amsSUF5jBYmry8L52w93PfIigoUhygHn81Pv/TxVH57fVF7Bxc4X6SGITssmmEE9
+Iry+iNeNhvezZPnuaS9LAOViauc+362h97f6KdTyr3qbnq79TD33Mv+KBcdN/Uva
+iI8pMGlUVeggoGOSbutlV5nYBoKTYYDQycXuPdnixf98q25pYJFSIKf7zfb2ZBbC
+yRYkft+qexvnUVOiE81Yu6Y95CLNd9pwRT+I6aUTwOPeLIe/KjLjeEIt0frhipQ7
+85gazEjFwAZiBqCgK5durvVQ9F4X1MtFGjoNKRvlsVIXEkej39pSJI3zSG7X3Wn4
+LvD65z33FdBFM7mQ1vvGaqQKZ/ZyaystNLTDm+b7yOlGMYY13dwvmfX1RLPqrALA
+hAJ6guOBEXl2vUPak0p5T1oouZgQpq26CT0GvDWhHbTb/3xXpwmUsWF1FmA3p35v
+o3it7kVp8O96jrjTH+lhSlw3dmOfMV54VZOR2na/5dUrd7TjY+7OJ4BM3sBsy1kf
+CUC9ShWWyQqaOkztzTLKCevobOAsBKL4ynJeVVeiiyd01jF+kjNAU1HukUxa4Ao1
+KlCeMtp3aEJz0YybLNZICl1wx995bDVOrDrLObqz7yVT1yCnj2PnO4/z4DO/yMol
+5uHUjTPNaaqERJ0TW/Lswi5l/1bLw0CkUI7anbJaITSe61R2AGkLciFr9naqyE5x
+GvbwcwlsIIEL1lY7g1V7Pil7urbQixqZBrWiI5yAzpIlEzhqGcangQza4ae74y3R
+wrjoM8aDM3h3zUOCK2vYxUtXzRAjP6nVFpmFPcwkGu2aowAoW2qRQlkHedOX0ayj
+W4O8i6ovTALks317MEPFTasTM0TzlR4WGABF9B53nJuMfuwmfUBzqh62TFqGsQYm
+6AFSdnxvWndGs30PzFlwuFN4W37w9YlV+yT5Hstkm/oKmt6wL6va5HFMKCz7YJhv
+HfvNo8vgXLdV02S3AN+Kn1Qfwy4zLPi15DrlKu2+yffDkUVaVn+QDWwD+k17BsAI
+U1kjcYNeo8pSyabU/aUMrIz/x2BttbodFnq5EbqgmtmngL96H5iXvTWbXhARG59p
+yTuXZhicjwdei/kA6cqk1OYyP/rgECGblOpwyuBSFPc87m5J+lP/r2TEW04FZdv3
+3OoebpitdtCrPhN1dPrrIYBe+kEX57sFl+Z7Sw/v7GNDp12qHy0TCuVQ7GXl6loL
+mxNXWWUfe7/hT5Kt+6Lk13KVxfI7v9yJ0xflMkZkf+b6DBZapO2mV5QV6CrOKZ97
+oEE413ni/yB9bZKyh5kxJtYX/l9FhoCEQKUUN12vIHcXQRet7sY8ehtOgS55wX6J
+iCD22tdhFIlJog+solIlUrMLz1gKK1wAB9+L8BPrSR9h47ds7D4G33uoAca4nJJH
+zyXDHjY8V7/yaCJDvgI/zqnNaUMMqvIiXUa8S3BcpgqqmyGj87HicEvU4Ldniqqw
+Wkf3rYkR22grneVX73GMXsvoK71h0ezPJep5x99/Y84pG8CYDF1nI7vpHe6pTQMs
+UPVH55k0qnu5PaVSTGMP8RJt2fJTXVmRDmHBSYf/rb+DL4Sy7FmiQr+XHNfTkWpW
+xl664wJz9TvE0LuNTXZwpEvj3xsK73Z80gcf4XlRQPi8ofWquGy/8tr9fiC2x6GO
+Wfr1E201AMFtj0HJZ2uHPQ==
+-----END COZY ASSET-----
+-----BEGIN COZY ASSET-----
+Name: /templates/authorize_device.html
+Size: 4576
+
+G98RAByHsXtk0hYc0KKVFxZZNjVuglaroJcmvcKHKTTnWs9OO22D7EHxTKkkI7/c
+mxJuZGxkQj1m3Py/fZLcKqodnWHd1Up355qGWkOtAJQJRuGBPATGxmi11wkGiIxb
+HwU40HBbNKY/LNE5X4T3FmOIvAPQy8cm0XHCcSpq1DszGmJIPIX9qN2ImYiAyq8P
+MsZNJGCiN4zp317cL1JflwgPaomkct4R89T3c2d5oDPj+hpnMjfRn9wSVPOFizBj
+KZL93PL3hfOf5HHBnN+L89H7t64/1Y2bptOb5Ux/Gmk7IhYH22LMlrz07AzKF0iN
+IsoX5skDPBR3+LX9Hmt9rxycxIjZTWyDKHgawVSw1DMAdzcOWGDeWIBrXUnKnWQB
++erJ5FmGug7nyg8WGWO/lmlQwH6ULl0ozN16RoeJNlYH5eFJhm/kLaZPWnIPI6p2
+V83isJGJA0G/XvgiH2ojtR9NRWMGLZQ7mJDQJ2HXj4xpiScythT//NmWg6XMcXyJ
+Rey2Ya/MjXsJEcKU7nPRHfcR53h/6mrZWFsbJz5pMqlHdtdGA0OTxDfpiS54zZN2
+WsAsl1fvwmLkd+jqJnjWQcmkNsvey1UvimnADXZ89GmMzQoDd2IAzXDDpqPCooBi
+ZvIpi7InM2pKIsCQqO+/WyRpD+ShcDPz73m4McSqsnvodJlHFPYjCi8Iqzl5ET0a
+fRTI3+OWXp4u1pQ2p1NVnSMRtayPmB+In9/8Sl2CSGiGC2gu9JaKw6UF14E9mtaE
+Tom7H19BUJo8GwVY0TZqFB4JtO76qVwEsEz0/PfRHvFXaL3TPxdXNg/JYoaexeyL
+7KxEznVZMAbQMMTRPvT6NfeZGEwYiRChJCPZVz6tCzbgHO+rSO4yqF1qr/nn/ftf
+ggjZH4kDimx97YIg/kR6849FVEFnF35ABniW+BxFpYo/ubY8/MbR/UHFMay3xxof
+YzCQmpObV/yMQqsvzM816LKy1NyAl3UxwEDxSfdYBLwyST/ZxYusD5JxjaMXd3M7
+T/M4CSCE139l7iEL6Y3RcEi5g7HlGb8G6yWcsetUpN/s+cV3T+Tg7SqvQ+RiF66f
+BSTkVsvldTFgXxvX/Aqstl513jnx/RJ/d+spR4xJYVXRd8mwGsc/vGUnsjXGLG8O
+Sr0GmbjAzvANrbzmDWoB8zL5E+1xMIBcBTO6IBJvqzmSj/x2CjseZ9KVn2UoNTbw
+aSj66BXXVBqiptgVZbeMpyIboQRH10uRUVyUSvoymYsLGUygJQb7ASOfMBAswm4a
+MKwPXuvSsw5ddULMlctz63ViCvcMud7SkqDa4tQawXKjIi2lYfLIbd27aSlMh26i
+ysdcMjy2YEyi5fX3bVlhSH7hYUiu0VN/qFcZ86kNuEzanQoQ2aS9TfHOvpG2PmBD
+6wualB+mIxVzR9JwgTm/kU4fTDSLjATyUEtTJw==
 -----END COZY ASSET-----
 -----BEGIN COZY ASSET-----
 Name: /templates/authorize_move.html
@@ -38253,6 +38342,27 @@ AodKJNyhKxeeAJijSvdbX3y6v8MsFsXbLaMo1C9zG+wAp0yDvMAzgIrcdtMgZvuF
 V5RbyFaDiY4qu8OLzNlCzC8IjJe8tzYez9+MrmQp
 -----END COZY ASSET-----
 -----BEGIN COZY ASSET-----
+Name: /templates/file_drop.html
+Size: 2800
+
+G+8KABwHdsyyJtwetsdBZs3la1Qmfu9K9lm/EQXATBoEpsZmIzVNUbForBFpkRZp
+8ZnZXcQtqdyeYBotWyV0CzW9LVHN3lqAHuSTcdJmtANdbKMipi/RsUyVnw4aQ+QF
+iNjHt12hzXFckDWROzcaohZemP3Uw4uMLBDhD/cc40YDaLlmRMxP7qvq6xzmQSxR
+VJl2RB8ZOR8htjLTtlxsInPtxt0SRPPNVTliVsk6yXBfiIEE1RG5fK7Vkqm6Yv7W
+pJrnbVmPixgfaQuqDgNbh0AjeV5UcQdKjTdqscQ+0goX4+3mcREGNd+barBst+0f
+4UuxdECIMIbHibq7JEurdRE1rhVeMbcse8GqSPjDb7gv+rXDlqYnmHHeZnHARudG
+H/H2oq+LtJ2kBrKWiTMuLEazPvE7SWhDrW+xwVtQWxuTH0gBsYIswJ0WwFMLPh+W
+29X09SnyNjvpQRtaBoTzVoBNB1Db0SxAq3/KkEofXUpdjIAMWCBf4ypzF0cAMeEc
+55Yw5MhKPLwUg1XxB8rAvP74dNaKVDmZrpStZNFoTPJRO1CNyWxwwKt2jst0NHmk
+0MEJRTtPuVxU3qtN4/lix8rydeNEGQrnGm9uPnISpIzIf9eDyZBVnp9Ji8lFou1H
+98ImdCnaQs5E7Qxks+q0q9c5z/v7gGGEaGfCqas8gQ/TSlrCR8hm2WmpxvSuesYV
+YyneaCUXl3j/xVm7fM66HjAAM9Oo4cN9nCvMygTR0ge3ploYZIr9FJlkIW1ur6U1
+vTEgm/IWSbzWWMGN1OVkM0HOWJUTh1h3ioShkaY7e8GMtUZCKEvVvSU7avepWp2C
+0P7NThbaUjEZMTpph17aOo/CYwgIq922Zikh4mQf/Uen210fLUEzm9Cas8a1NoQ9
+5T3RrYURMR1DDdtw34FmA56T3irbaXSXdgW3Er9TmpIlezUwnvEcylnn2hDOUd/y
+iUZL5ct6YdVOsY86mn4=
+-----END COZY ASSET-----
+-----BEGIN COZY ASSET-----
 Name: /templates/import.html
 Size: 1402
 
@@ -38504,29 +38614,31 @@ bZ3EGvigJLAh7XLV68v4SLVq
 -----END COZY ASSET-----
 -----BEGIN COZY ASSET-----
 Name: /templates/twofactor.html
-Size: 3763
+Size: 4345
 
-G7IOIJwHto1sm0wbQZgDm/cg/51q0/rFeNKDK6WuOW9XscEtAUxLQ2mQvj2FrSDC
-0ydgfGk/tVOk3ISwzUsupQGCUHy5/HYALPyEAhbWzMgdY8viK7dxnsIloa4yFAMN
-soFSeFeiNXqXtzCDyC1APcQ62DuCXsJaNHlPj06HYGoFNouBmUvNTIuSVcwaJ90c
-4ALBWFTiA+bPCfCcRcMvvE8Uekwd3mXl4/YxzwCvLEXGqBNx77idCU4rxw/Cquma
-+EKaiKGRjVXGC0Fu1LjtBvU/MYPFzWAlkVt/C/GzNkMIOW6GXviS1Y7dNaryJK5h
-XxKhPQr4jiiWjbk8LjtGxhvG/EAbTLst3DI3yA8BOJy0Speqvcm0cpD6Ir6cbBeH
-j6MiOidz6gU8BxWmbgWFJ8g3a8DDgvdc8ySxEEkIGzrp5cJJJHCxdrnF36DwKYTB
-WXr6+qk+nm9NQWH4QQHMsWn25UyvojKrFGQZa15IkgcK38XtAJpxTFQr9xpbrEKm
-Uel3tDsVsoX+ZeROlJ0E+04dLSdn2X6CJl4hG8IxkT6XbZPmugTQi0huIoV2odf0
-PIHgVg3wJdiHZhhaRRU354NfsUzJuFSTeypiiZPRry5zvpRErDqYoksU0cxEVe/W
-cACWnotYjHRwQOIhjgj5FWN1ko3PYSa3JS6QQjRYqKfYfY3VUpbe08aMFORTPjiR
-3EsTDjGnkpNu+nZUmNhlsr6TUDIom652ij55ctsNPS1cVuNj92F3Iv26yogdlyGG
-zGyzKzrE3sTL/TH1hzCKTI2j1n0dBoRFoWawWrl2DSwWtKMdR4O2eQq9psauwdRn
-xsmevAuEbSeSJ6UYrofmHOnbMv4sJBO9jHC/UA5cWOFgP1028a7nb1BKPYj5nBJz
-7aJjE0SuGTlBQdz9WPLs4FO5jczLrEYajAak/I9HLvvax8XPHqMJ7TNOhbAOm6ND
-4501n7KM7oxb5r1lyn/kKk5AkUD7+8vQEn8KWmmbBrmI/cHZ4Pqy2TFYKjShyEL7
-2gN/GIEdgAxevgprpaoD1QAjdYfV1rhahJNR0d4b7+KLtgrS32r2dFShjMkL30RZ
-YeDRrYd1b8xXBChCSm57Ce61z6jOoCVEBUMSeeZJc0dT1gEVDIf6ED4veWNnza5M
-5uNzCODyHcOXaGBlQvuqHJmdCFSw4qPNuewZwmnLdmpGtlaWc9aWczb6DKRb3Uh7
-kkwMtJXNvFBQTyWFy21pkyzFPHwKZRX0X81mgExSxduxpiRLKbMVjypBES2za9B1
-DwUvpSg2gInqsJvNjp/JWvJV3f2OMToOTUVVD4v61u4tWXoF
+G/gQIJwHdixnyLQzD3azb7qvat9Q3szCiWirQgJxIEDynQRR2GuRPh6FtdfnW/th
+Hki5CmGbl2wKjKqON5v9ZTrWwEIRCenPyJtbhlPrdnNCsBh+y6gGGmSDyyUfSrRJ
+p07HBJGHQOnB1cHeGfQKa1H0nt3aXV/3waUVyCwGZio1kxYlq5g0LjrZwIUCY1aJ
+7zA/R+AlF0Ys+ZgojJg6nWr5nCInYqYM4h0nuohbxx1MUFpT/CBsTdfMF2kign7s
+Kbxg5Bq5W8yR/SOmtrgZrFHk1t+F+IPmdQhjPA2/5CurnZsG1dSza7htFEdHFPCd
+UayNuTZOSkYynjDiB9qg2m3llqlBfgjAkUGrdKnYmxqmDlzfgC8n+8Vh4yiLzstx
+qAQ8H8pMPQoCTwQLyQQwT93zZektjRL8c2q5rAvibzq7GIfKaqeu1GPd1KVEwnMK
+DE9L2qo0+rt1EWJpP1cBiiuYHqgxDPB5KRK4WH49xF+t8CmEwdl49fVLPVy/NAeR
+5B8KYO6bH7+cdSlCeZsOWcXNO5IEAJXi4nYATRha0g3PjllBkSBeRhgtJDwjd51M
+FMw1DeWcehztE2jiEtwnNBlpDAFo1iFogSg1q3tSKDo9o/eTCG7axd8IDdQCVa6o
+4gED4AisWhnXm2rfNWBNy2hwBN0PDMQanMm5kRxamDDQV6uaAEvvG7BQdTBxYh9n
+hPyK0WtBpbZgGt0qF0ghGiwbKpaFumo9Tl/T6pJE8LO8diK5t3psYk4lJ9257ghJ
+0fyk36egZBBn3Smz6xtXbjGnq86NKjw2UA4n0q+bUhxYhphiZqug0F1sr/yk2qZq
+E0aRybBFglz9oGBRbRp6nrqeEyw82pkyTQ3alv7oMxluqk12DnZuDUfRqGVCtKc/
+csRoDBSs5BR/i4h16FXtJHzho0ahK2mKjfV1imngSMabKuaMLAjxayZv9iGqw+ur
+iPcUNSfTALtNb0lZNeFvajJZyQjbFZVofdh/K87vUBekPWmnBgqp7K/hz1hOxNqt
+bZmYSNuT+Zzzcl+WDjKHyfBU0eq6kjUNO9bt2F9jNYbaaEDK/9Xkmq91HPysMYoo
+K+Cwb9DZOreIm3eZyOb9/66LOr02KRdubJzOs+rS2Plu4JbdMCmATvDn0c0n+VXQ
+Stuhll2cVaABOhKns7bk2IyKL91WbPh+BPaNkCG4qUAuqh7QmDSaldj8yNUUnIxC
+txqun7E+nGYPpox0abWEK2qcD7h0M562lESAIoip8teITRo8anS8NsIpSKeofpc0
+aShmnVCHIUMf4iJxSkBe6Ky9lbJ8fHrOF5UMj+Z2FnDpYlGK9AvBZ2M952cI0pb3
+0IJsLZZz1l47pBDsGnk9Sa+kRAZi+4rsVJdx7T99lsYC57xiji0yg7oNMWYsmfpM
+rOZCykt3sGrpa2TjACbqCWmxqEOWRcTPWmnsbKJx2Hyn7cOiNTTvJdkECA==
 -----END COZY ASSET-----
 `
 	fs.Register(data)
@@ -3,6 +3,8 @@ package wellknown
 import (
 	"net/http"
 
+	"github.com/cozy/cozy-stack/model/instance"
+	"github.com/cozy/cozy-stack/model/oauth"
 	"github.com/cozy/cozy-stack/web/middlewares"
 	"github.com/labstack/echo/v4"
 )
@@ -15,8 +17,58 @@ func ChangePassword(c echo.Context) error {
 	return c.Redirect(http.StatusFound, inst.ChangePasswordURL())
 }
 
+// OpenIDConfiguration returns the discovery document of the instance as an
+// OpenID Connect provider.
+// See https://openid.net/specs/openid-connect-discovery-1_0.html
+func OpenIDConfiguration(c echo.Context) error {
+	inst := middlewares.GetInstance(c)
+	return c.JSON(http.StatusOK, echo.Map{
+		"issuer":                                oauth.Issuer(inst),
+		"authorization_endpoint":                inst.PageURL("/auth/authorize", nil),
+		"token_endpoint":                        inst.PageURL("/auth/access_token", nil),
+		"userinfo_endpoint":                     inst.PageURL("/auth/userinfo", nil),
+		"jwks_uri":                              inst.PageURL("/.well-known/jwks.json", nil),
+		"registration_endpoint":                 inst.PageURL("/auth/register", nil),
+		"introspection_endpoint":                inst.PageURL("/auth/introspect", nil),
+		"revocation_endpoint":                   inst.PageURL("/auth/revoke", nil),
+		"device_authorization_endpoint":         inst.PageURL("/auth/device_authorization", nil),
+		"scopes_supported":                      oauth.OIDCScopes,
+		"response_types_supported":              []string{"code"},
+		"grant_types_supported":                 []string{"authorization_code", "refresh_token", oauth.DeviceCodeGrantType},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{oauth.ChallengeMethodS256},
+		"token_endpoint_auth_methods_supported": []string{
+			oauth.AuthMethodSecretPost,
+			oauth.AuthMethodSecretBasic,
+			oauth.AuthMethodNone,
+		},
+		"claims_supported": []string{
+			"iss", "sub", "aud", "iat", "exp", "nonce",
+			"name", "given_name", "family_name", "locale", "website",
+			"email", "email_verified", "phone_number", "address",
+		},
+	})
+}
+
+// JWKS returns the JSON Web Key Set with the public key used to sign the ID
+// tokens.
+// See https://tools.ietf.org/html/rfc7517
+func JWKS(c echo.Context) error {
+	inst := middlewares.GetInstance(c)
+	key, err := inst.OIDCSigningKey()
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, echo.Map{
+		"keys": []interface{}{instance.OIDCJWK(&key.PublicKey)},
+	})
+}
+
 // Routes sets the routing for the status service
 func Routes(router *echo.Group) {
 	router.GET("/change-password", ChangePassword)
 	router.HEAD("/change-password", ChangePassword)
+	router.GET("/openid-configuration", OpenIDConfiguration)
+	router.GET("/jwks.json", JWKS)
 }
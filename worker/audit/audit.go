@@ -0,0 +1,40 @@
+package audit
+
+import (
+	"runtime"
+	"time"
+
+	"github.com/cozy/cozy-stack/model/audit"
+	"github.com/cozy/cozy-stack/model/job"
+)
+
+func init() {
+	job.AddWorker(&job.WorkerConfig{
+		WorkerType:   "clean-audit-logs",
+		Concurrency:  runtime.NumCPU(),
+		MaxExecCount: 2,
+		Reserved:     true,
+		Timeout:      30 * time.Minute,
+		WorkerFunc:   WorkerCleanAuditLogs,
+	})
+}
+
+// WorkerCleanAuditLogs is a worker used to delete the old entries of the
+// audit log. The retention is configurable per context in the config file,
+// via the audit.retention parameter.
+func WorkerCleanAuditLogs(ctx *job.WorkerContext) error {
+	retention, ok := audit.Retention(ctx.Instance.ContextName)
+	if !ok {
+		return nil
+	}
+	before := time.Now().Add(-retention)
+	for {
+		n, err := audit.Purge(ctx.Instance, before, 1000)
+		if err != nil {
+			return err
+		}
+		if n < 1000 {
+			return nil
+		}
+	}
+}
@@ -77,9 +77,18 @@ func worker(ctx *job.WorkerContext) (err error) {
 		return err
 	}
 
+	if w, ok := worker.(wasmWorker); ok && w.UseWasm() {
+		return runWasm(ctx, worker, workDir, env)
+	}
+
 	var stderrBuf bytes.Buffer
 	cmd := CreateCmd(cmdStr, workDir)
 	cmd.Env = env
+	if w, ok := worker.(stdinWorker); ok {
+		if stdin := w.Stdin(); stdin != nil {
+			cmd.Stdin = stdin
+		}
+	}
 
 	// set stderr writable with a bytes.Buffer limited total size of 256Ko
 	cmd.Stderr = utils.LimitWriterDiscard(&stderrBuf, 256*1024)
@@ -114,9 +123,13 @@ func worker(ctx *job.WorkerContext) (err error) {
 	}))
 	defer timer.ObserveDuration()
 
+	lim := newLimiter(ctx, workDir, log)
+	lim.prepare(cmd, ctx.ID())
 	if err = cmd.Start(); err != nil {
+		lim.stop()
 		return wrapErr(ctx, err)
 	}
+	lim.start(cmd)
 
 	waitDone := make(chan error)
 	go func() {
@@ -139,6 +152,10 @@ func worker(ctx *job.WorkerContext) (err error) {
 		_ = KillCmd(cmd)
 		<-waitDone
 	}
+	lim.stop()
+	if errl := lim.err(cmd); errl != nil {
+		err = errl
+	}
 
 	return worker.Error(ctx.Instance, err)
 }
@@ -0,0 +1,191 @@
+package exec
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cozy/cozy-stack/pkg/logger"
+)
+
+// ErrEgressDenied is used when a konnector has failed after trying to connect
+// to a host that is not in the allowed_hosts of its manifest.
+var ErrEgressDenied = errors.New("Network access denied")
+
+// egressProxy is an HTTP proxy, listening on localhost, that forwards the
+// requests of a konnector only for the allowed hosts. It is given to the
+// konnector via the HTTP_PROXY and HTTPS_PROXY environment variables, and so
+// it is only used by the HTTP clients that honor them: it doesn't prevent
+// the direct connections, which must be blocked by the sandbox.
+type egressProxy struct {
+	allowed []string
+	ln      net.Listener
+	srv     *http.Server
+	log     *logger.Entry
+
+	mu     sync.Mutex
+	denied []string
+}
+
+// startEgressProxy starts a proxy that allows the connections to the given
+// hosts.
+func startEgressProxy(allowed []string, log *logger.Entry) (*egressProxy, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	hosts := make([]string, len(allowed))
+	for i, host := range allowed {
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		hosts[i] = host
+	}
+	p := &egressProxy{allowed: hosts, ln: ln, log: log}
+	p.srv = &http.Server{
+		Handler:           p,
+		ReadHeaderTimeout: 30 * time.Second,
+	}
+	go func() { _ = p.srv.Serve(ln) }()
+	return p, nil
+}
+
+// URL returns the URL of the proxy.
+func (p *egressProxy) URL() string {
+	return "http://" + p.ln.Addr().String()
+}
+
+// Close stops the proxy.
+func (p *egressProxy) Close() error {
+	return p.srv.Close()
+}
+
+// Denied returns the list of the hosts for which a connection has been denied.
+func (p *egressProxy) Denied() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.denied
+}
+
+func (p *egressProxy) deny(w http.ResponseWriter, host string) {
+	p.mu.Lock()
+	found := false
+	for _, h := range p.denied {
+		if h == host {
+			found = true
+			break
+		}
+	}
+	if !found {
+		p.denied = append(p.denied, host)
+	}
+	p.mu.Unlock()
+	p.log.Infof("Network access denied to %s", host)
+	http.Error(w, fmt.Sprintf("Network access denied to %s", host), http.StatusForbidden)
+}
+
+func (p *egressProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	host := r.URL.Hostname()
+	if r.Method == http.MethodConnect {
+		host, _, _ = net.SplitHostPort(r.Host)
+	}
+	if !hostAllowed(p.allowed, host) {
+		p.deny(w, host)
+		return
+	}
+	if r.Method == http.MethodConnect {
+		p.tunnel(w, r)
+		return
+	}
+	p.forward(w, r)
+}
+
+// tunnel is used for the HTTPS requests (CONNECT method).
+func (p *egressProxy) tunnel(w http.ResponseWriter, r *http.Request) {
+	dst, err := net.DialTimeout("tcp", r.Host, 30*time.Second)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		dst.Close()
+		http.Error(w, "Hijacking not supported", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	src, _, err := hijacker.Hijack()
+	if err != nil {
+		dst.Close()
+		return
+	}
+	go func() {
+		_, _ = io.Copy(dst, src)
+		dst.Close()
+	}()
+	_, _ = io.Copy(src, dst)
+	src.Close()
+}
+
+// forward is used for the plain HTTP requests.
+func (p *egressProxy) forward(w http.ResponseWriter, r *http.Request) {
+	if !r.URL.IsAbs() {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+	req := r.Clone(r.Context())
+	req.RequestURI = ""
+	req.Header.Del("Proxy-Connection")
+	req.Header.Del("Proxy-Authorization")
+	res, err := http.DefaultTransport.RoundTrip(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer res.Body.Close()
+	for k, vv := range res.Header {
+		for _, v := range vv {
+			w.Header().Add(k, v)
+		}
+	}
+	w.WriteHeader(res.StatusCode)
+	_, _ = io.Copy(w, res.Body)
+}
+
+// hostAllowed returns true if the host matches one of the allowed hosts. An
+// allowed host can start with *. to match all of its subdomains.
+func hostAllowed(allowed []string, host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if host == "" {
+		return false
+	}
+	for _, a := range allowed {
+		a = strings.ToLower(a)
+		if strings.HasPrefix(a, "*.") {
+			if strings.HasSuffix(host, a[1:]) {
+				return true
+			}
+		} else if host == a {
+			return true
+		}
+	}
+	return false
+}
+
+// proxyEnv returns the environment variables to use the proxy.
+func (p *egressProxy) proxyEnv() []string {
+	u := p.URL()
+	return []string{
+		"HTTP_PROXY=" + u,
+		"HTTPS_PROXY=" + u,
+		"http_proxy=" + u,
+		"https_proxy=" + u,
+		"NO_PROXY=",
+		"no_proxy=",
+	}
+}
@@ -0,0 +1,54 @@
+package exec
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/cozy/cozy-stack/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHostAllowed(t *testing.T) {
+	allowed := []string{"alice.cozy.example", "*.vendor.example"}
+	assert.True(t, hostAllowed(allowed, "alice.cozy.example"))
+	assert.True(t, hostAllowed(allowed, "ALICE.cozy.example."))
+	assert.True(t, hostAllowed(allowed, "www.vendor.example"))
+	assert.True(t, hostAllowed(allowed, "api.eu.vendor.example"))
+	assert.False(t, hostAllowed(allowed, "vendor.example"))
+	assert.False(t, hostAllowed(allowed, "evilvendor.example"))
+	assert.False(t, hostAllowed(allowed, "bob.cozy.example"))
+	assert.False(t, hostAllowed(allowed, ""))
+}
+
+func TestEgressProxy(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "hello")
+	}))
+	defer ts.Close()
+
+	p, err := startEgressProxy([]string{"127.0.0.1:1234"}, logger.WithNamespace("test"))
+	require.NoError(t, err)
+	defer p.Close()
+
+	proxyURL, _ := url.Parse(p.URL())
+	client := &http.Client{Transport: &http.Transport{Proxy: http.ProxyURL(proxyURL)}}
+
+	res, err := client.Get(ts.URL)
+	require.NoError(t, err)
+	body, _ := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "hello", string(body))
+	assert.Empty(t, p.Denied())
+
+	res, err = client.Get("http://denied.example/")
+	require.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusForbidden, res.StatusCode)
+	assert.Equal(t, []string{"denied.example"}, p.Denied())
+}
@@ -0,0 +1,419 @@
+package exec
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cozy/cozy-stack/pkg/logger"
+)
+
+// ErrNoRecordedResponse is used when a replayed konnector has failed after
+// sending a request for which there was no recorded response.
+var ErrNoRecordedResponse = errors.New("No recorded response")
+
+// maxFixtureBody is the maximal size of a request or response body kept in a
+// recording. The larger bodies are still transmitted, but not recorded.
+const maxFixtureBody = 20 << 20
+
+// fixturesProxy is an HTTP proxy, listening on localhost, used to record the
+// HTTP traffic of a konnector, or to replay a recorded traffic without
+// accessing the network. The HTTPS connections are intercepted with a
+// certificate authority generated for the execution, and trusted by the
+// konnector via the NODE_EXTRA_CA_CERTS environment variable.
+//
+// The requests to the passthrough hosts (the instance) are forwarded without
+// being recorded, in both modes.
+type fixturesProxy struct {
+	replay      bool
+	passthrough []string
+	ln          net.Listener
+	srv         *http.Server
+	upstream    *http.Transport
+	log         *logger.Entry
+
+	caCert  *x509.Certificate
+	caKey   *ecdsa.PrivateKey
+	caPEM   []byte
+	leafKey *ecdsa.PrivateKey
+
+	mu        sync.Mutex
+	certs     map[string]*tls.Certificate
+	recording *harLog
+	replayed  map[string][]*harEntry
+	unmatched []string
+}
+
+// startRecordProxy starts a proxy that records the HTTP traffic. The
+// requests are sent via the upstream proxy if not nil (the egress proxy).
+func startRecordProxy(passthrough []string, upstream *url.URL, log *logger.Entry) (*fixturesProxy, error) {
+	p, err := newFixturesProxy(passthrough, log)
+	if err != nil {
+		return nil, err
+	}
+	if upstream != nil {
+		p.upstream.Proxy = http.ProxyURL(upstream)
+	}
+	p.recording = newHarLog()
+	return p, p.start()
+}
+
+// startReplayProxy starts a proxy that responds to the requests with the
+// responses of the recording.
+func startReplayProxy(passthrough []string, recording *harLog, log *logger.Entry) (*fixturesProxy, error) {
+	p, err := newFixturesProxy(passthrough, log)
+	if err != nil {
+		return nil, err
+	}
+	p.replay = true
+	p.replayed = make(map[string][]*harEntry)
+	for i := range recording.Entries {
+		e := &recording.Entries[i]
+		key := fixtureKey(e.Request.Method, e.Request.URL)
+		p.replayed[key] = append(p.replayed[key], e)
+	}
+	return p, p.start()
+}
+
+func newFixturesProxy(passthrough []string, log *logger.Entry) (*fixturesProxy, error) {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	leafKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	hosts := make([]string, len(passthrough))
+	for i, host := range passthrough {
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		hosts[i] = host
+	}
+	now := time.Now()
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(now.UnixNano()),
+		Subject:               pkix.Name{CommonName: "cozy-stack konnector fixtures"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &caKey.PublicKey, caKey)
+	if err != nil {
+		return nil, err
+	}
+	caCert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+	return &fixturesProxy{
+		passthrough: hosts,
+		upstream: &http.Transport{
+			DisableCompression:    true,
+			TLSHandshakeTimeout:   30 * time.Second,
+			ResponseHeaderTimeout: 2 * time.Minute,
+		},
+		log:     log,
+		caCert:  caCert,
+		caKey:   caKey,
+		caPEM:   pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		leafKey: leafKey,
+		certs:   make(map[string]*tls.Certificate),
+	}, nil
+}
+
+func (p *fixturesProxy) start() error {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return err
+	}
+	p.ln = ln
+	p.srv = &http.Server{
+		Handler:           p,
+		ReadHeaderTimeout: 30 * time.Second,
+	}
+	go func() { _ = p.srv.Serve(ln) }()
+	return nil
+}
+
+// URL returns the URL of the proxy.
+func (p *fixturesProxy) URL() string {
+	return "http://" + p.ln.Addr().String()
+}
+
+// CACert returns the PEM-encoded certificate of the authority used for
+// intercepting the HTTPS connections.
+func (p *fixturesProxy) CACert() []byte {
+	return p.caPEM
+}
+
+// Close stops the proxy.
+func (p *fixturesProxy) Close() error {
+	return p.srv.Close()
+}
+
+// Recording returns the recorded HTTP traffic.
+func (p *fixturesProxy) Recording() *harLog {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.recording
+}
+
+// Unmatched returns the requests for which there was no recorded response.
+func (p *fixturesProxy) Unmatched() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.unmatched
+}
+
+func (p *fixturesProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodConnect {
+		host, _, _ := net.SplitHostPort(r.Host)
+		if hostAllowed(p.passthrough, host) {
+			// The tunnel of the egress proxy doesn't depend on its allowlist
+			new(egressProxy).tunnel(w, r)
+		} else {
+			p.intercept(w, r)
+		}
+		return
+	}
+	if !r.URL.IsAbs() {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+	res := p.roundTrip(r)
+	defer res.Body.Close()
+	for k, vv := range res.Header {
+		for _, v := range vv {
+			w.Header().Add(k, v)
+		}
+	}
+	w.WriteHeader(res.StatusCode)
+	_, _ = io.Copy(w, res.Body)
+}
+
+// intercept terminates the TLS connection of a CONNECT request with a
+// certificate for the requested host, and serves the requests sent inside
+// it.
+func (p *fixturesProxy) intercept(w http.ResponseWriter, r *http.Request) {
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "Hijacking not supported", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	conn, _, err := hijacker.Hijack()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	host, port, _ := net.SplitHostPort(r.Host)
+	tlsConn := tls.Server(conn, &tls.Config{
+		GetCertificate: func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return p.certificate(host)
+		},
+	})
+	if err := tlsConn.Handshake(); err != nil {
+		p.log.Debugf("TLS handshake with the konnector failed for %s: %s", host, err)
+		return
+	}
+	defer tlsConn.Close()
+
+	reader := bufio.NewReader(tlsConn)
+	for {
+		req, err := http.ReadRequest(reader)
+		if err != nil {
+			return
+		}
+		req.URL.Scheme = "https"
+		req.URL.Host = host
+		if port != "" && port != "443" {
+			req.URL.Host = net.JoinHostPort(host, port)
+		}
+		res := p.roundTrip(req)
+		err = res.Write(tlsConn)
+		res.Body.Close()
+		if err != nil || req.Close || res.Close {
+			return
+		}
+	}
+}
+
+// certificate returns a certificate for the host, signed by the authority of
+// the proxy.
+func (p *fixturesProxy) certificate(host string) (*tls.Certificate, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if cert, ok := p.certs[host]; ok {
+		return cert, nil
+	}
+	now := time.Now()
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(now.UnixNano()),
+		Subject:      pkix.Name{CommonName: host},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		tmpl.IPAddresses = []net.IP{ip}
+	} else {
+		tmpl.DNSNames = []string{host}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, p.caCert, &p.leafKey.PublicKey, p.caKey)
+	if err != nil {
+		return nil, err
+	}
+	cert := &tls.Certificate{
+		Certificate: [][]byte{der},
+		PrivateKey:  p.leafKey,
+	}
+	p.certs[host] = cert
+	return cert, nil
+}
+
+// roundTrip sends the request to the website and records the exchange, or
+// responds with the recorded response in replay mode.
+func (p *fixturesProxy) roundTrip(r *http.Request) *http.Response {
+	body, _ := ioutil.ReadAll(io.LimitReader(r.Body, maxFixtureBody+1))
+	r.Body.Close()
+
+	passthrough := hostAllowed(p.passthrough, r.URL.Hostname())
+	if p.replay && !passthrough {
+		return p.replayResponse(r)
+	}
+
+	out, err := http.NewRequest(r.Method, r.URL.String(), bytes.NewReader(body))
+	if err != nil {
+		return errorResponse(r, http.StatusBadRequest, err.Error())
+	}
+	out.Header = r.Header.Clone()
+	out.Header.Del("Proxy-Connection")
+	out.Header.Del("Proxy-Authorization")
+	out.Host = r.Host
+	started := time.Now()
+	res, err := p.upstream.RoundTrip(out)
+	if err != nil {
+		return errorResponse(r, http.StatusBadGateway, err.Error())
+	}
+	if passthrough {
+		return res
+	}
+
+	resBody, err := ioutil.ReadAll(io.LimitReader(res.Body, maxFixtureBody+1))
+	if err != nil {
+		res.Body.Close()
+		return errorResponse(r, http.StatusBadGateway, err.Error())
+	}
+	entry := newHarEntry(r, body, res, resBody, started)
+	p.mu.Lock()
+	p.recording.Entries = append(p.recording.Entries, *entry)
+	p.mu.Unlock()
+
+	if len(resBody) > maxFixtureBody {
+		res.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(resBody), res.Body), res.Body}
+	} else {
+		res.Body.Close()
+		res.Body = ioutil.NopCloser(bytes.NewReader(resBody))
+		res.ContentLength = int64(len(resBody))
+		res.TransferEncoding = nil
+	}
+	return res
+}
+
+// replayResponse returns the recorded response for the request. The recorded
+// responses for the same method and URL are used in order, and the last one
+// is repeated.
+func (p *fixturesProxy) replayResponse(r *http.Request) *http.Response {
+	key := fixtureKey(r.Method, r.URL.String())
+	p.mu.Lock()
+	entries := p.replayed[key]
+	var entry *harEntry
+	if len(entries) > 0 {
+		entry = entries[0]
+		if len(entries) > 1 {
+			p.replayed[key] = entries[1:]
+		}
+	} else {
+		p.unmatched = append(p.unmatched, key)
+	}
+	p.mu.Unlock()
+
+	if entry == nil {
+		p.log.Infof("No recorded response for %s", key)
+		return errorResponse(r, http.StatusBadGateway, "No recorded response for "+key)
+	}
+	return entry.Response.toHTTP(r)
+}
+
+// fixtureKey returns the key used to match a request with the recorded
+// responses. The sensitive query-string parameters are redacted, like in the
+// recordings.
+func fixtureKey(method, rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return method + " " + rawURL
+	}
+	if (u.Scheme == "https" && u.Port() == "443") || (u.Scheme == "http" && u.Port() == "80") {
+		u.Host = u.Hostname()
+	}
+	u.Host = strings.ToLower(u.Host)
+	u.Fragment = ""
+	// The recordings are redacted, the requests must be too for matching
+	redactQuery(u)
+	return strings.ToUpper(method) + " " + u.String()
+}
+
+func errorResponse(r *http.Request, status int, msg string) *http.Response {
+	body := []byte(msg)
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": {"text/plain; charset=utf-8"}},
+		Body:          ioutil.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       r,
+	}
+}
+
+// fixturesEnv returns the environment variables to use the proxy.
+func (p *fixturesProxy) fixturesEnv(caFile string) []string {
+	u := p.URL()
+	return []string{
+		"HTTP_PROXY=" + u,
+		"HTTPS_PROXY=" + u,
+		"http_proxy=" + u,
+		"https_proxy=" + u,
+		"NO_PROXY=",
+		"no_proxy=",
+		"NODE_EXTRA_CA_CERTS=" + caFile,
+	}
+}
@@ -0,0 +1,93 @@
+package exec
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/cozy/cozy-stack/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFixtureKey(t *testing.T) {
+	assert.Equal(t, "GET https://example.org/path?q=1",
+		fixtureKey("get", "https://EXAMPLE.org:443/path?q=1#frag"))
+	assert.Equal(t, "POST http://example.org:8080/",
+		fixtureKey("POST", "http://example.org:8080/"))
+}
+
+func fixturesClient(t *testing.T, p *fixturesProxy) *http.Client {
+	pool := x509.NewCertPool()
+	require.True(t, pool.AppendCertsFromPEM(p.CACert()))
+	proxyURL, _ := url.Parse(p.URL())
+	return &http.Client{Transport: &http.Transport{
+		Proxy:             http.ProxyURL(proxyURL),
+		TLSClientConfig:   &tls.Config{RootCAs: pool},
+		DisableKeepAlives: true,
+	}}
+}
+
+func fixturesGet(t *testing.T, client *http.Client, u string) (int, string) {
+	res, err := client.Get(u)
+	require.NoError(t, err)
+	body, _ := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	return res.StatusCode, string(body)
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	calls := 0
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "text/plain")
+		fmt.Fprintf(w, "call %d on %s", calls, r.URL.Path)
+	}))
+	defer ts.Close()
+	log := logger.WithNamespace("test")
+
+	// Record
+	rec, err := startRecordProxy(nil, nil, log)
+	require.NoError(t, err)
+	rec.upstream.TLSClientConfig = ts.Client().Transport.(*http.Transport).TLSClientConfig
+	client := fixturesClient(t, rec)
+
+	status, body := fixturesGet(t, client, ts.URL+"/foo")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "call 1 on /foo", body)
+	status, body = fixturesGet(t, client, ts.URL+"/foo")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "call 2 on /foo", body)
+	require.NoError(t, rec.Close())
+
+	recording := rec.Recording()
+	require.Len(t, recording.Entries, 2)
+	assert.Equal(t, "GET", recording.Entries[0].Request.Method)
+	assert.Equal(t, ts.URL+"/foo", recording.Entries[0].Request.URL)
+	assert.Equal(t, 200, recording.Entries[0].Response.Status)
+
+	// Replay
+	rep, err := startReplayProxy(nil, recording, log)
+	require.NoError(t, err)
+	defer rep.Close()
+	client = fixturesClient(t, rep)
+
+	status, body = fixturesGet(t, client, ts.URL+"/foo")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "call 1 on /foo", body)
+	_, body = fixturesGet(t, client, ts.URL+"/foo")
+	assert.Equal(t, "call 2 on /foo", body)
+	_, body = fixturesGet(t, client, ts.URL+"/foo")
+	assert.Equal(t, "call 2 on /foo", body)
+	assert.Equal(t, 2, calls)
+	assert.Empty(t, rep.Unmatched())
+
+	status, _ = fixturesGet(t, client, ts.URL+"/bar")
+	assert.Equal(t, http.StatusBadGateway, status)
+	assert.Equal(t, []string{"GET " + ts.URL + "/bar"}, rep.Unmatched())
+}
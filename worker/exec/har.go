@@ -0,0 +1,427 @@
+package exec
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cozy/cozy-stack/model/instance"
+	"github.com/cozy/cozy-stack/model/vfs"
+	build "github.com/cozy/cozy-stack/pkg/config"
+)
+
+// RecordingsDirName is the directory where the recordings of the HTTP
+// traffic of the konnectors are saved, in a sub-directory for each konnector.
+const RecordingsDirName = "/Konnectors recordings"
+
+// ErrInvalidRecording is used when the file given for replaying a konnector
+// is not a recording.
+var ErrInvalidRecording = errors.New("The file is not a recording of a konnector")
+
+// The recordings use the HAR format (HTTP Archive), so that they can be
+// opened with the developer tools of the browsers. The bodies are always
+// base64-encoded.
+
+type harFile struct {
+	Log *harLog `json:"log"`
+}
+
+type harLog struct {
+	Version string     `json:"version"`
+	Creator harCreator `json:"creator"`
+	Entries []harEntry `json:"entries"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime time.Time   `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+}
+
+type harRequest struct {
+	Method      string       `json:"method"`
+	URL         string       `json:"url"`
+	HTTPVersion string       `json:"httpVersion"`
+	Headers     []harNameVal `json:"headers"`
+	QueryString []harNameVal `json:"queryString"`
+	Cookies     []harNameVal `json:"cookies"`
+	PostData    *harPostData `json:"postData,omitempty"`
+	HeadersSize int          `json:"headersSize"`
+	BodySize    int          `json:"bodySize"`
+}
+
+type harResponse struct {
+	Status      int          `json:"status"`
+	StatusText  string       `json:"statusText"`
+	HTTPVersion string       `json:"httpVersion"`
+	Headers     []harNameVal `json:"headers"`
+	Cookies     []harNameVal `json:"cookies"`
+	Content     harContent   `json:"content"`
+	RedirectURL string       `json:"redirectURL"`
+	HeadersSize int          `json:"headersSize"`
+	BodySize    int          `json:"bodySize"`
+}
+
+type harNameVal struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+type harContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+	Encoding string `json:"encoding,omitempty"`
+	// Truncated is true when the body was too large to be recorded
+	Truncated bool `json:"_truncated,omitempty"`
+}
+
+type harTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+func newHarLog() *harLog {
+	return &harLog{
+		Version: "1.2",
+		Creator: harCreator{Name: "cozy-stack", Version: build.Version},
+		Entries: []harEntry{},
+	}
+}
+
+func harHeaders(h http.Header) []harNameVal {
+	headers := []harNameVal{}
+	for k, vv := range h {
+		for _, v := range vv {
+			headers = append(headers, harNameVal{Name: k, Value: v})
+		}
+	}
+	return headers
+}
+
+func newHarEntry(r *http.Request, body []byte, res *http.Response, resBody []byte, started time.Time) *harEntry {
+	elapsed := float64(time.Since(started)) / float64(time.Millisecond)
+	e := &harEntry{
+		StartedDateTime: started,
+		Time:            elapsed,
+		Timings:         harTimings{Wait: elapsed},
+	}
+	e.Request = harRequest{
+		Method:      r.Method,
+		URL:         r.URL.String(),
+		HTTPVersion: "HTTP/1.1",
+		Headers:     harHeaders(r.Header),
+		QueryString: []harNameVal{},
+		Cookies:     []harNameVal{},
+		HeadersSize: -1,
+		BodySize:    len(body),
+	}
+	for k, vv := range r.URL.Query() {
+		for _, v := range vv {
+			e.Request.QueryString = append(e.Request.QueryString, harNameVal{Name: k, Value: v})
+		}
+	}
+	if len(body) > 0 {
+		e.Request.PostData = &harPostData{
+			MimeType: r.Header.Get("Content-Type"),
+			Text:     base64.StdEncoding.EncodeToString(body),
+			Encoding: "base64",
+		}
+	}
+	e.Response = harResponse{
+		Status:      res.StatusCode,
+		StatusText:  http.StatusText(res.StatusCode),
+		HTTPVersion: "HTTP/1.1",
+		Headers:     harHeaders(res.Header),
+		Cookies:     []harNameVal{},
+		RedirectURL: res.Header.Get("Location"),
+		HeadersSize: -1,
+		BodySize:    len(resBody),
+		Content: harContent{
+			Size:     len(resBody),
+			MimeType: res.Header.Get("Content-Type"),
+			Encoding: "base64",
+		},
+	}
+	if len(resBody) > maxFixtureBody {
+		e.Response.Content.Truncated = true
+	} else {
+		e.Response.Content.Text = base64.StdEncoding.EncodeToString(resBody)
+	}
+	return e
+}
+
+// toHTTP returns the recorded response as an HTTP response.
+func (res *harResponse) toHTTP(r *http.Request) *http.Response {
+	body, err := base64.StdEncoding.DecodeString(res.Content.Text)
+	if err != nil {
+		body = []byte(res.Content.Text)
+	}
+	header := make(http.Header)
+	for _, h := range res.Headers {
+		header.Add(h.Name, h.Value)
+	}
+	header.Del("Transfer-Encoding")
+	header.Set("Content-Length", strconv.Itoa(len(body)))
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", res.Status, http.StatusText(res.Status)),
+		StatusCode:    res.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       r,
+	}
+}
+
+// redactedValue replaces the credentials in the recordings.
+const redactedValue = "[REDACTED]"
+
+// sensitiveHeaders are the headers that are redacted in the recordings.
+var sensitiveHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"Cookie",
+	"Set-Cookie",
+}
+
+// isSensitiveField returns true for the name of a query-string parameter, a
+// form field or a JSON field that may contain a credential.
+func isSensitiveField(name string) bool {
+	name = strings.ToLower(name)
+	for _, word := range []string{"password", "passwd", "token", "secret"} {
+		if strings.Contains(name, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactRecording removes the credentials from a recording before it is
+// saved in the VFS, where it can be read by the support team: the
+// authorization and cookie headers, the password of the URLs, and the
+// query-string parameters and the form or JSON fields named like password,
+// token or secret (in the requests and in the responses).
+func redactRecording(recording *harLog) {
+	for i := range recording.Entries {
+		e := &recording.Entries[i]
+		redactHeaders(e.Request.Headers)
+		redactHeaders(e.Response.Headers)
+		e.Request.URL = redactURL(e.Request.URL)
+		for j := range e.Request.QueryString {
+			if isSensitiveField(e.Request.QueryString[j].Name) {
+				e.Request.QueryString[j].Value = redactedValue
+			}
+		}
+		if post := e.Request.PostData; post != nil {
+			if body, ok := redactBase64Body(post.MimeType, post.Text); ok {
+				post.Text = body
+			}
+		}
+		content := &e.Response.Content
+		if body, ok := redactBase64Body(content.MimeType, content.Text); ok {
+			content.Text = body
+		}
+	}
+}
+
+func redactHeaders(headers []harNameVal) {
+	for i, h := range headers {
+		for _, name := range sensitiveHeaders {
+			if strings.EqualFold(h.Name, name) {
+				headers[i].Value = redactedValue
+			}
+		}
+	}
+}
+
+// redactURL redacts the password and the sensitive query-string parameters
+// of a URL. It is also used for matching the requests with the recorded
+// responses in the replay mode.
+func redactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	redactQuery(u)
+	return u.String()
+}
+
+func redactQuery(u *url.URL) {
+	if _, ok := u.User.Password(); ok {
+		u.User = url.UserPassword(u.User.Username(), redactedValue)
+	}
+	query := u.Query()
+	changed := false
+	for k, vv := range query {
+		if isSensitiveField(k) {
+			for i := range vv {
+				vv[i] = redactedValue
+			}
+			changed = true
+		}
+	}
+	if changed {
+		u.RawQuery = query.Encode()
+	}
+}
+
+// redactBase64Body redacts the sensitive fields of a base64-encoded body,
+// for the form and JSON content types. It returns false if the body has not
+// been changed.
+func redactBase64Body(mimeType, text string) (string, bool) {
+	if text == "" {
+		return "", false
+	}
+	mediaType, _, _ := mime.ParseMediaType(mimeType)
+	isForm := mediaType == "application/x-www-form-urlencoded"
+	isJSON := mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+	if !isForm && !isJSON {
+		return "", false
+	}
+	body, err := base64.StdEncoding.DecodeString(text)
+	if err != nil {
+		return "", false
+	}
+	if isForm {
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			return "", false
+		}
+		changed := false
+		for k := range form {
+			if isSensitiveField(k) {
+				form.Set(k, redactedValue)
+				changed = true
+			}
+		}
+		if !changed {
+			return "", false
+		}
+		body = []byte(form.Encode())
+	} else {
+		var doc interface{}
+		if err := json.Unmarshal(body, &doc); err != nil {
+			return "", false
+		}
+		if !redactJSON(doc) {
+			return "", false
+		}
+		if body, err = json.Marshal(doc); err != nil {
+			return "", false
+		}
+	}
+	return base64.StdEncoding.EncodeToString(body), true
+}
+
+// redactJSON replaces the values of the sensitive fields in a JSON document,
+// at any depth, and returns true if the document has been changed.
+func redactJSON(doc interface{}) bool {
+	changed := false
+	switch v := doc.(type) {
+	case map[string]interface{}:
+		for k, val := range v {
+			if isSensitiveField(k) {
+				v[k] = redactedValue
+				changed = true
+			} else if redactJSON(val) {
+				changed = true
+			}
+		}
+	case []interface{}:
+		for _, val := range v {
+			if redactJSON(val) {
+				changed = true
+			}
+		}
+	}
+	return changed
+}
+
+// saveRecording saves the recorded HTTP traffic of a konnector in the VFS of
+// the instance, and returns the created file. The credentials are redacted
+// before.
+func saveRecording(inst *instance.Instance, slug string, recording *harLog) (*vfs.FileDoc, error) {
+	redactRecording(recording)
+	content, err := json.Marshal(&harFile{Log: recording})
+	if err != nil {
+		return nil, err
+	}
+	fs := inst.VFS()
+	dir, err := vfs.MkdirAll(fs, path.Join(RecordingsDirName, slug))
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	name := fmt.Sprintf("%s %s.har", slug, now.UTC().Format("2006-01-02 15-04-05"))
+	doc, err := vfs.NewFileDoc(name, dir.DocID, int64(len(content)), nil,
+		"application/json", "text", now, false, false, nil)
+	if err != nil {
+		return nil, err
+	}
+	doc.CozyMetadata = vfs.NewCozyMetadata(inst.PageURL("/", nil))
+	doc.CozyMetadata.UploadedAt = &now
+	f, err := fs.CreateFile(doc, nil)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = f.Write(content); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	if err = f.Close(); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// loadRecording loads a recording of the HTTP traffic of a konnector from the
+// VFS of the instance.
+func loadRecording(inst *instance.Instance, fileID string) (*harLog, error) {
+	fs := inst.VFS()
+	doc, err := fs.FileByID(fileID)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrInvalidRecording
+		}
+		return nil, err
+	}
+	f, err := fs.OpenFile(doc)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var har harFile
+	if err := json.NewDecoder(f).Decode(&har); err != nil || har.Log == nil {
+		return nil, ErrInvalidRecording
+	}
+	return har.Log, nil
+}
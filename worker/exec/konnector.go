@@ -5,7 +5,10 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"html"
 	"io"
+	"io/ioutil"
+	"net/url"
 	"os"
 	"path"
 	"strconv"
@@ -16,6 +19,8 @@ import (
 	"github.com/cozy/cozy-stack/model/instance"
 	"github.com/cozy/cozy-stack/model/instance/lifecycle"
 	"github.com/cozy/cozy-stack/model/job"
+	"github.com/cozy/cozy-stack/model/notification"
+	"github.com/cozy/cozy-stack/model/notification/center"
 	"github.com/cozy/cozy-stack/model/permission"
 	"github.com/cozy/cozy-stack/model/vfs"
 	"github.com/cozy/cozy-stack/pkg/appfs"
@@ -33,15 +38,22 @@ const (
 	konnErrorLoginFailed         = "LOGIN_FAILED"
 	konnErrorUserActionNeeded    = "USER_ACTION_NEEDED"
 	konnErrorUserActionNeededCgu = "USER_ACTION_NEEDED.CGU_FORM"
+	// konnErrorUserActionNeededOauth is used by the stack when the OAuth
+	// service has refused the refresh token of the account
+	konnErrorUserActionNeededOauth = "USER_ACTION_NEEDED.OAUTH_OUTDATED"
 )
 
 type konnectorWorker struct {
 	slug string
 	msg  *KonnectorMessage
 	man  *app.KonnManifest
+	acc  *account.Account
 
-	err     error
-	lastErr error
+	proxy    *egressProxy
+	fixtures *fixturesProxy
+	caFile   string
+	err      error
+	lastErr  error
 }
 
 const (
@@ -59,6 +71,10 @@ type KonnectorMessage struct {
 	FolderToSave   string `json:"folder_to_save"` // FolderToSave is the identifier of the folder
 	BIWebhook      bool   `json:"bi_webhook,omitempty"`
 	AccountDeleted bool   `json:"account_deleted,omitempty"`
+	// Record is used to record the HTTP traffic of the konnector, and Replay
+	// is the identifier of a recording to use instead of the network
+	Record bool   `json:"record,omitempty"`
+	Replay string `json:"replay,omitempty"`
 
 	// Data contains the original value of the message, even fields that are not
 	// part of our message definition.
@@ -153,6 +169,20 @@ func beforeHookKonnector(j *job.Job) (bool, error) {
 		if msg.BIWebhook {
 			return true, nil
 		}
+
+		// The triggers of an account are paused while it needs an action of
+		// the user, instead of failing again and again
+		if !j.Manual && msg.Account != "" && !msg.AccountDeleted {
+			var acc account.Account
+			err := couchdb.GetDoc(inst, consts.Accounts, msg.Account, &acc)
+			if err == nil && acc.UserAction != nil {
+				j.Logger().
+					WithField("job_id", j.ID()).
+					WithField("slug", slug).
+					Infof("Konnector paused until the user acts: %s", acc.UserAction.Reason)
+				return false, nil
+			}
+		}
 	}
 
 	if j.Manual || j.TriggerID == "" {
@@ -182,6 +212,7 @@ func (w *konnectorWorker) PrepareWorkDir(ctx *job.WorkerContext, i *instance.Ins
 	// Reset the errors from previous runs on retries
 	w.err = nil
 	w.lastErr = nil
+	w.acc = nil
 
 	var err error
 	var data json.RawMessage
@@ -213,6 +244,8 @@ func (w *konnectorWorker) PrepareWorkDir(ctx *job.WorkerContext, i *instance.Ins
 		err = couchdb.GetDoc(i, consts.Accounts, msg.Account, acc)
 		if couchdb.IsNotFoundError(err) {
 			return "", cleanDir, job.ErrBadTrigger{Err: err}
+		} else if err == nil {
+			w.acc = acc
 		}
 	}
 
@@ -226,6 +259,17 @@ func (w *konnectorWorker) PrepareWorkDir(ctx *job.WorkerContext, i *instance.Ins
 		return "", cleanDir, errors.New("Konnector is not ready")
 	}
 
+	// Refresh the OAuth access token of the account before it expires, so
+	// that the konnector does not have to do it
+	if w.acc != nil && w.acc.NeedsOauthRefresh() {
+		if err := account.RefreshOauth(i, w.acc); errors.Is(err, account.ErrInvalidGrant) {
+			ctx.SetNoRetry()
+			return "", cleanDir, errors.New(konnErrorUserActionNeededOauth)
+		} else if err != nil {
+			w.Logger(ctx).Warnf("Cannot refresh the OAuth access token: %s", err)
+		}
+	}
+
 	var workDir string
 	osFS := afero.NewOsFs()
 	workDir, err = afero.TempDir(osFS, "", "konnector-"+slug)
@@ -488,6 +532,11 @@ func (w *konnectorWorker) Slug() string {
 	return w.slug
 }
 
+// UseWasm returns true if the konnector has been compiled to WebAssembly.
+func (w *konnectorWorker) UseWasm() bool {
+	return w.man != nil && w.man.Language() == WasmLanguage
+}
+
 func (w *konnectorWorker) PrepareCmdEnv(ctx *job.WorkerContext, i *instance.Instance) (cmd string, env []string, err error) {
 	parameters := w.man.Parameters()
 
@@ -544,9 +593,74 @@ func (w *konnectorWorker) PrepareCmdEnv(ctx *job.WorkerContext, i *instance.Inst
 	if triggerID, ok := ctx.TriggerID(); ok {
 		env = append(env, "COZY_TRIGGER_ID="+triggerID)
 	}
+	proxyEnv, err := w.prepareProxies(ctx, i)
+	if err != nil {
+		return "", nil, err
+	}
+	env = append(env, proxyEnv...)
 	return
 }
 
+// prepareProxies starts the egress proxy if it is enabled,
+// and the fixtures proxy when the HTTP traffic is recorded or replayed. It
+// returns the environment variables to use them.
+func (w *konnectorWorker) prepareProxies(ctx *job.WorkerContext, i *instance.Instance) ([]string, error) {
+	log := w.Logger(ctx)
+	replay := w.msg.Replay != ""
+	if config.GetConfig().Konnectors.EgressProxy && !replay {
+		allowed := append([]string{i.ContextualDomain()}, w.man.AllowedHosts()...)
+		var err error
+		w.proxy, err = startEgressProxy(allowed, log)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !w.msg.Record && !replay {
+		if w.proxy != nil {
+			return w.proxy.proxyEnv(), nil
+		}
+		return nil, nil
+	}
+
+	// A recorded or replayed execution is not retried
+	ctx.SetNoRetry()
+	passthrough := []string{i.ContextualDomain()}
+	if replay {
+		recording, err := loadRecording(i, w.msg.Replay)
+		if err != nil {
+			return nil, err
+		}
+		w.fixtures, err = startReplayProxy(passthrough, recording, log)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		var upstream *url.URL
+		if w.proxy != nil {
+			upstream, _ = url.Parse(w.proxy.URL())
+		}
+		var err error
+		w.fixtures, err = startRecordProxy(passthrough, upstream, log)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	f, err := ioutil.TempFile("", "konnector-ca-")
+	if err != nil {
+		return nil, err
+	}
+	w.caFile = f.Name()
+	_, err = f.Write(w.fixtures.CACert())
+	if errc := f.Close(); err == nil {
+		err = errc
+	}
+	if err != nil {
+		return nil, err
+	}
+	return w.fixtures.fixturesEnv(w.caFile), nil
+}
+
 func (w *konnectorWorker) Logger(ctx *job.WorkerContext) *logger.Entry {
 	return ctx.Logger().WithField("slug", w.slug)
 }
@@ -597,6 +711,19 @@ func (w *konnectorWorker) ScanOutput(ctx *job.WorkerContext, i *instance.Instanc
 }
 
 func (w *konnectorWorker) Error(i *instance.Instance, err error) error {
+	if err == ErrMemoryLimit || err == ErrCPULimit || err == ErrDiskLimit {
+		return err
+	}
+	if w.proxy != nil && (err != nil || w.err != nil || w.lastErr != nil) {
+		if denied := w.proxy.Denied(); len(denied) > 0 {
+			return fmt.Errorf("%w: %s", ErrEgressDenied, strings.Join(denied, ", "))
+		}
+	}
+	if w.fixtures != nil && (err != nil || w.err != nil || w.lastErr != nil) {
+		if unmatched := w.fixtures.Unmatched(); len(unmatched) > 0 {
+			return fmt.Errorf("%w: %s", ErrNoRecordedResponse, strings.Join(unmatched, ", "))
+		}
+	}
 	if w.err != nil {
 		return w.err
 	}
@@ -607,7 +734,22 @@ func (w *konnectorWorker) Error(i *instance.Instance, err error) error {
 }
 
 func (w *konnectorWorker) Commit(ctx *job.WorkerContext, errjob error) error {
+	if w.proxy != nil {
+		_ = w.proxy.Close()
+	}
 	log := w.Logger(ctx)
+	if w.fixtures != nil {
+		_ = w.fixtures.Close()
+		_ = os.Remove(w.caFile)
+		if w.msg.Replay == "" && ctx.Instance != nil {
+			doc, err := saveRecording(ctx.Instance, w.slug, w.fixtures.Recording())
+			if err != nil {
+				log.Errorf("Cannot save the recording of the HTTP traffic: %s", err)
+			} else {
+				log.Infof("HTTP traffic recorded in %s (file id: %s)", doc.DocName, doc.ID())
+			}
+		}
+	}
 	if w.msg != nil {
 		log = log.WithField("account_id", w.msg.Account)
 		if w.msg.BIWebhook {
@@ -622,5 +764,86 @@ func (w *konnectorWorker) Commit(ctx *job.WorkerContext, errjob error) error {
 	} else {
 		log.Infof("Konnector failure: %s", errjob)
 	}
+	if w.acc != nil && !w.msg.AccountDeleted && ctx.Instance != nil {
+		w.updateUserAction(ctx, errjob)
+	}
 	return nil
 }
+
+// needsUserAction returns true if the konnector has failed with an error that
+// requires an action of the user, like updating their credentials.
+func needsUserAction(errjob error) bool {
+	msg := errjob.Error()
+	return strings.HasPrefix(msg, konnErrorLoginFailed) ||
+		strings.HasPrefix(msg, konnErrorUserActionNeeded)
+}
+
+// updateUserAction marks the account when the konnector can't run without an
+// action of the user, and sends a notification to the user. The mark is
+// removed when the konnector succeeds again.
+func (w *konnectorWorker) updateUserAction(ctx *job.WorkerContext, errjob error) {
+	inst := ctx.Instance
+	log := w.Logger(ctx)
+	if errjob == nil {
+		if w.acc.UserAction != nil {
+			if _, err := account.ClearUserAction(inst, w.acc.ID()); err != nil {
+				log.Warnf("Cannot clear the user action of the account: %s", err)
+			}
+		}
+		return
+	}
+	if !needsUserAction(errjob) {
+		return
+	}
+	marked, err := account.SetUserAction(inst, w.acc.ID(), errjob.Error())
+	if err != nil {
+		log.Warnf("Cannot mark the account as needing a user action: %s", err)
+		return
+	}
+	if marked {
+		if err := w.notifyUserAction(inst, errjob.Error()); err != nil {
+			log.Warnf("Cannot send the user action notification: %s", err)
+		}
+	}
+}
+
+// userActionReasonKey returns the key of the translated sentence that explains
+// the error code of the konnector to the user.
+func userActionReasonKey(reason string) string {
+	switch {
+	case strings.HasPrefix(reason, konnErrorLoginFailed):
+		return "Notification Konnector User Action Reason Login Failed"
+	case reason == konnErrorUserActionNeededOauth:
+		return "Notification Konnector User Action Reason OAuth Outdated"
+	case reason == konnErrorUserActionNeededCgu:
+		return "Notification Konnector User Action Reason Terms"
+	default:
+		return "Notification Konnector User Action Reason Other"
+	}
+}
+
+func (w *konnectorWorker) notifyUserAction(inst *instance.Instance, reason string) error {
+	name := w.slug
+	if w.man != nil && w.man.Name() != "" {
+		name = w.man.Name()
+	}
+	link := inst.SubDomain(consts.HomeSlug)
+	link.Fragment = "/connected/" + w.slug + "/accounts/" + w.acc.ID()
+	title := inst.Translate("Notification Konnector User Action Title", name)
+	message := inst.Translate("Notification Konnector User Action Message",
+		name, inst.Translate(userActionReasonKey(reason)))
+	n := &notification.Notification{
+		Title:   title,
+		Message: message,
+		Content: fmt.Sprintf("%s\n\n%s\n\n%s", title, message, link.String()),
+		ContentHTML: fmt.Sprintf(`<p>%s</p><p><a href="%s">%s</a></p>`,
+			html.EscapeString(message), html.EscapeString(link.String()),
+			html.EscapeString(inst.Translate("Notification Konnector User Action Link"))),
+		Data: map[string]interface{}{
+			"konnector": w.slug,
+			"account":   w.acc.ID(),
+			"reason":    reason,
+		},
+	}
+	return center.PushStack(inst.Domain, center.NotificationKonnectorUserAction, n)
+}
@@ -380,6 +380,19 @@ echo "{\"type\": \"toto\", \"message\": \"COZY_URL=${COZY_URL}\"}"
 	assert.Equal(t, dir.CozyMetadata.SourceAccount, acc.ID())
 }
 
+func TestUserActionReasonKey(t *testing.T) {
+	assert.Equal(t, "Notification Konnector User Action Reason Login Failed",
+		userActionReasonKey("LOGIN_FAILED.NEEDS_SECRET"))
+	assert.Equal(t, "Notification Konnector User Action Reason OAuth Outdated",
+		userActionReasonKey("USER_ACTION_NEEDED.OAUTH_OUTDATED"))
+	assert.Equal(t, "Notification Konnector User Action Reason Terms",
+		userActionReasonKey("USER_ACTION_NEEDED.CGU_FORM"))
+	assert.Equal(t, "Notification Konnector User Action Reason Other",
+		userActionReasonKey("USER_ACTION_NEEDED.ACCOUNT_REMOVED"))
+	key := userActionReasonKey("LOGIN_FAILED")
+	assert.Equal(t, "the login or the password is incorrect", inst.Translate(key))
+}
+
 func TestMain(m *testing.M) {
 	config.UseTestFile()
 	setup := testutils.NewSetup(m, "konnector_test")
@@ -0,0 +1,132 @@
+package exec
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/cozy/cozy-stack/model/job"
+	"github.com/cozy/cozy-stack/pkg/config/config"
+	"github.com/cozy/cozy-stack/pkg/logger"
+)
+
+var (
+	// ErrMemoryLimit is used when a konnector or service has been killed
+	// because it has used too much memory.
+	ErrMemoryLimit = errors.New("Memory limit exceeded")
+	// ErrCPULimit is used when a konnector or service has been killed
+	// because it has used too much CPU time.
+	ErrCPULimit = errors.New("CPU time limit exceeded")
+	// ErrDiskLimit is used when a konnector or service has been killed
+	// because its work directory has become too large.
+	ErrDiskLimit = errors.New("Disk usage limit exceeded")
+)
+
+// diskCheckInterval is the period between two checks of the size of the work
+// directory.
+var diskCheckInterval = 2 * time.Second
+
+// execLimits returns the resource limits for the given worker type.
+func execLimits(workerType string) config.ExecLimits {
+	return config.GetConfig().Konnectors.Limits[workerType]
+}
+
+// limiter enforces the resource limits of an execution. The limits on memory,
+// CPU time and open files are set by the OS before the command is executed
+// (see limits_linux.go), and the
+// disk usage of the work directory is checked periodically.
+//
+// The limiter must be prepared before the command is started, started just
+// after, and stopped when the command has exited.
+type limiter struct {
+	limits  config.ExecLimits
+	workDir string
+	log     *logger.Entry
+	cgroup  string
+
+	mu       sync.Mutex
+	diskHit  bool
+	stopDisk chan struct{}
+}
+
+func newLimiter(ctx *job.WorkerContext, workDir string, log *logger.Entry) *limiter {
+	return &limiter{
+		limits:   execLimits(ctx.WorkerType()),
+		workDir:  workDir,
+		log:      log,
+		stopDisk: make(chan struct{}),
+	}
+}
+
+// prepare must be called before the command is started.
+func (l *limiter) prepare(cmd *exec.Cmd, jobID string) {
+	if err := l.prepareCmd(cmd, jobID); err != nil {
+		l.log.Warnf("Cannot prepare the resource limits: %s", err)
+	}
+}
+
+// start must be called just after the command has been started.
+func (l *limiter) start(cmd *exec.Cmd) {
+	if l.limits.Disk > 0 && l.workDir != "" {
+		go l.watchDisk(cmd)
+	}
+}
+
+// stop must be called when the command has exited.
+func (l *limiter) stop() {
+	close(l.stopDisk)
+	l.cleanOSLimits()
+}
+
+// err returns the error for the limit that has been hit by the command, or
+// nil if no limit has been hit.
+func (l *limiter) err(cmd *exec.Cmd) error {
+	l.mu.Lock()
+	diskHit := l.diskHit
+	l.mu.Unlock()
+	if diskHit {
+		return ErrDiskLimit
+	}
+	if l.memoryHit() {
+		return ErrMemoryLimit
+	}
+	if cmd.ProcessState != nil && l.cpuHit(cmd.ProcessState) {
+		return ErrCPULimit
+	}
+	return nil
+}
+
+func (l *limiter) watchDisk(cmd *exec.Cmd) {
+	ticker := time.NewTicker(diskCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.stopDisk:
+			return
+		case <-ticker.C:
+			if dirSize(l.workDir) > l.limits.Disk {
+				l.mu.Lock()
+				l.diskHit = true
+				l.mu.Unlock()
+				l.log.Infof("Disk usage limit exceeded for %s", l.workDir)
+				_ = KillCmd(cmd)
+				return
+			}
+		}
+	}
+}
+
+// dirSize returns the total size of the files in the directory.
+func dirSize(dir string) int64 {
+	var size int64
+	_ = filepath.Walk(dir, func(_ string, info os.FileInfo, err error) error {
+		if err == nil && info.Mode().IsRegular() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
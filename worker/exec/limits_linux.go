@@ -0,0 +1,136 @@
+//go:build linux
+// +build linux
+
+package exec
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/cozy/cozy-stack/pkg/config/config"
+)
+
+// prepareCmd wraps the command in a shell that sets the limits on the
+// resources before executing the real command: the memory limit with a new
+// cgroup v2 for the job (if configured), and the other limits with ulimit.
+// This way, the process (and its children) never run without these limits,
+// even for a short time.
+//
+// For the memory without a cgroup, RLIMIT_DATA is used and not RLIMIT_AS: the
+// JavaScript engine of node reserves a lot of virtual address space at
+// startup (several GB, without using it), and it would not start with a
+// realistic RLIMIT_AS. Since Linux 4.7, RLIMIT_DATA counts the private
+// writable mappings (heap and anonymous mmap), but not these reservations
+// without access rights.
+func (l *limiter) prepareCmd(cmd *exec.Cmd, jobID string) error {
+	var err error
+	var procs string
+	root := config.GetConfig().Konnectors.Cgroup
+	if root != "" && l.limits.Memory > 0 {
+		if err = l.createCgroup(root, jobID); err == nil {
+			procs = path.Join(l.cgroup, "cgroup.procs")
+		}
+	}
+
+	var ulimits []string
+	if l.limits.Memory > 0 && l.cgroup == "" {
+		kb := (l.limits.Memory + 1023) / 1024
+		ulimits = append(ulimits, "ulimit -d "+strconv.FormatInt(kb, 10))
+	}
+	if l.limits.CPUTime > 0 {
+		secs := int64(l.limits.CPUTime.Seconds())
+		if secs == 0 {
+			secs = 1
+		}
+		ulimits = append(ulimits, "ulimit -t "+strconv.FormatInt(secs, 10))
+	}
+	if l.limits.OpenFiles > 0 {
+		ulimits = append(ulimits, "ulimit -n "+strconv.FormatInt(l.limits.OpenFiles, 10))
+	}
+
+	if procs != "" || len(ulimits) > 0 {
+		limitBeforeExec(cmd, procs, ulimits)
+	}
+	return err
+}
+
+// limitBeforeExec changes the command to a shell that writes its PID in the
+// given cgroup.procs file (if not empty), runs the ulimit commands, and then
+// replaces itself with the original command (same PID). If the cgroup cannot
+// be joined or a limit cannot be set, the command is not executed.
+func limitBeforeExec(cmd *exec.Cmd, procs string, ulimits []string) {
+	steps := ulimits
+	arg0 := "sh"
+	if procs != "" {
+		steps = append([]string{`echo $$ > "$0"`}, ulimits...)
+		arg0 = procs
+	}
+	script := strings.Join(append(steps, `exec "$@"`), " && ")
+	args := []string{"/bin/sh", "-c", script, arg0, cmd.Path}
+	cmd.Args = append(args, cmd.Args[1:]...)
+	cmd.Path = "/bin/sh"
+}
+
+func (l *limiter) createCgroup(root, jobID string) error {
+	dir := path.Join(root, "job-"+jobID)
+	if err := os.Mkdir(dir, 0755); err != nil && !os.IsExist(err) {
+		return fmt.Errorf("cgroup: %s", err)
+	}
+	mem := strconv.FormatInt(l.limits.Memory, 10)
+	if err := ioutil.WriteFile(path.Join(dir, "memory.max"), []byte(mem), 0644); err != nil {
+		_ = os.Remove(dir)
+		return fmt.Errorf("cgroup: %s", err)
+	}
+	// The swap would make the memory limit useless
+	_ = ioutil.WriteFile(path.Join(dir, "memory.swap.max"), []byte("0"), 0644)
+	l.cgroup = dir
+	return nil
+}
+
+func (l *limiter) cleanOSLimits() {
+	if l.cgroup != "" {
+		_ = os.Remove(l.cgroup)
+	}
+}
+
+// memoryHit returns true if a process of the cgroup has been killed by the
+// OOM killer.
+func (l *limiter) memoryHit() bool {
+	if l.cgroup == "" {
+		return false
+	}
+	events, err := ioutil.ReadFile(path.Join(l.cgroup, "memory.events"))
+	if err != nil {
+		return false
+	}
+	for _, line := range strings.Split(string(events), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[0] == "oom_kill" && fields[1] != "0" {
+			return true
+		}
+	}
+	return false
+}
+
+// cpuHit returns true if the process has been killed because of the CPU time
+// limit (SIGXCPU for the soft limit, SIGKILL for the hard limit).
+func (l *limiter) cpuHit(state *os.ProcessState) bool {
+	if l.limits.CPUTime <= 0 {
+		return false
+	}
+	status, ok := state.Sys().(syscall.WaitStatus)
+	if !ok || !status.Signaled() {
+		return false
+	}
+	if status.Signal() == syscall.SIGXCPU {
+		return true
+	}
+	cpu := state.UserTime() + state.SystemTime()
+	return status.Signal() == syscall.SIGKILL && cpu >= l.limits.CPUTime
+}
@@ -0,0 +1,90 @@
+//go:build linux
+// +build linux
+
+package exec
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/cozy/cozy-stack/pkg/config/config"
+	"github.com/cozy/cozy-stack/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimitBeforeExec(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "cgroup")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	// A regular file is used in place of the cgroup.procs file
+	procs := filepath.Join(tmp, "cgroup.procs")
+	cmd := exec.Command("sh", "-c", `echo "$$ $0"`, "with spaces")
+	limitBeforeExec(cmd, procs, []string{"ulimit -n 64"})
+	out, err := cmd.Output()
+	require.NoError(t, err)
+
+	// The PID written in the cgroup is the PID of the command
+	pid := strconv.Itoa(cmd.Process.Pid)
+	assert.Equal(t, pid+" with spaces\n", string(out))
+	written, err := ioutil.ReadFile(procs)
+	require.NoError(t, err)
+	assert.Equal(t, pid, strings.TrimSpace(string(written)))
+
+	// The limits are set before the command is executed
+	cmd = exec.Command("sh", "-c", "ulimit -n")
+	limitBeforeExec(cmd, "", []string{"ulimit -n 64"})
+	out, err = cmd.Output()
+	require.NoError(t, err)
+	assert.Equal(t, "64\n", string(out))
+
+	// The command is not executed if a limit cannot be set
+	cmd = exec.Command("echo", "executed")
+	limitBeforeExec(cmd, "", []string{"ulimit -x 1"})
+	out, err = cmd.Output()
+	assert.Error(t, err)
+	assert.Empty(t, out)
+
+	// The command is not executed if the cgroup cannot be joined
+	cmd = exec.Command("echo", "executed")
+	limitBeforeExec(cmd, filepath.Join(tmp, "no-such-dir", "cgroup.procs"), nil)
+	out, err = cmd.Output()
+	assert.Error(t, err)
+	assert.Empty(t, out)
+}
+
+// TestMemoryLimitWithoutCgroup checks that node can run with the memory limit
+// set by a rlimit, but not allocate more than this limit.
+func TestMemoryLimitWithoutCgroup(t *testing.T) {
+	if _, err := exec.LookPath("node"); err != nil {
+		t.Skip("node is not installed")
+	}
+
+	run := func(size int) (string, error) {
+		script := `const buf = Buffer.alloc(` + strconv.Itoa(size) + `, 1);
+			console.log('allocated ' + buf.length);`
+		cmd := exec.Command("node", "-e", script)
+		l := &limiter{
+			limits:   config.ExecLimits{Memory: 256 << 20},
+			log:      logger.WithNamespace("test"),
+			stopDisk: make(chan struct{}),
+		}
+		require.NoError(t, l.prepareCmd(cmd, "test"))
+		out, err := cmd.Output()
+		return string(out), err
+	}
+
+	out, err := run(16 << 20)
+	assert.NoError(t, err)
+	assert.Equal(t, "allocated 16777216\n", out)
+
+	out, err = run(512 << 20)
+	assert.Error(t, err)
+	assert.Empty(t, out)
+}
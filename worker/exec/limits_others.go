@@ -0,0 +1,19 @@
+//go:build !linux
+// +build !linux
+
+package exec
+
+import (
+	"os"
+	"os/exec"
+)
+
+// On other platforms than Linux, only the disk usage limit is enforced.
+
+func (l *limiter) prepareCmd(cmd *exec.Cmd, jobID string) error { return nil }
+
+func (l *limiter) cleanOSLimits() {}
+
+func (l *limiter) memoryHit() bool { return false }
+
+func (l *limiter) cpuHit(state *os.ProcessState) bool { return false }
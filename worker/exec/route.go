@@ -0,0 +1,192 @@
+package exec
+
+import (
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/cozy/cozy-stack/model/app"
+	"github.com/cozy/cozy-stack/model/instance"
+	"github.com/cozy/cozy-stack/model/job"
+	"github.com/cozy/cozy-stack/pkg/config/config"
+	"github.com/cozy/cozy-stack/pkg/utils"
+)
+
+// ErrTooManyRouteRequests is used when the limit of concurrent executions of
+// the services for the HTTP routes has been reached.
+var ErrTooManyRouteRequests = errors.New("Too many requests for the services")
+
+// The headers of the requests that are not given to the services, and the
+// headers of the responses that the services can't set.
+var (
+	routeRequestHiddenHeaders  = []string{"Authorization", "Cookie"}
+	routeResponseDeniedHeaders = []string{"Set-Cookie", "Connection",
+		"Content-Length", "Transfer-Encoding", "Content-Security-Policy"}
+)
+
+// routeRequest is the description of the HTTP request given to the service
+// in the COZY_REQUEST environment variable. The body is sent on stdin.
+type routeRequest struct {
+	Method  string              `json:"method"`
+	Path    string              `json:"path"`
+	Query   map[string][]string `json:"query"`
+	Headers map[string]string   `json:"headers"`
+}
+
+// routeExchange is used by the service worker to stream the response of the
+// service to the client.
+type routeExchange struct {
+	w       http.ResponseWriter
+	r       *http.Request
+	path    string
+	started bool
+}
+
+func (ex *routeExchange) request() *routeRequest {
+	headers := make(map[string]string)
+	for k := range ex.r.Header {
+		headers[k] = ex.r.Header.Get(k)
+	}
+	for _, k := range routeRequestHiddenHeaders {
+		delete(headers, k)
+	}
+	return &routeRequest{
+		Method:  ex.r.Method,
+		Path:    "/" + ex.path,
+		Query:   ex.r.URL.Query(),
+		Headers: headers,
+	}
+}
+
+// writeHeader sends the status and headers of the response. The response is
+// sandboxed, as it is served on the domain of the instance.
+func (ex *routeExchange) writeHeader(status int, headers map[string]string) {
+	if ex.started {
+		return
+	}
+	ex.started = true
+	h := ex.w.Header()
+	for k, v := range headers {
+		if !routeResponseDenied(k) {
+			h.Set(k, v)
+		}
+	}
+	h.Add("Content-Security-Policy", "sandbox")
+	h.Set("X-Content-Type-Options", "nosniff")
+	if status < 100 || status > 999 {
+		status = http.StatusOK
+	}
+	ex.w.WriteHeader(status)
+}
+
+func routeResponseDenied(header string) bool {
+	for _, k := range routeResponseDeniedHeaders {
+		if strings.EqualFold(k, header) {
+			return true
+		}
+	}
+	return false
+}
+
+func (ex *routeExchange) writeBody(data string) error {
+	body, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return err
+	}
+	ex.writeHeader(http.StatusOK, nil)
+	if _, err = ex.w.Write(body); err != nil {
+		return err
+	}
+	if f, ok := ex.w.(http.Flusher); ok {
+		f.Flush()
+	}
+	return nil
+}
+
+// routesLimiter limits the number of services executed in parallel for the
+// HTTP routes, globally and by webapp.
+type routesLimiter struct {
+	mu     sync.Mutex
+	total  int
+	perApp map[string]int
+}
+
+var routesLimits = &routesLimiter{perApp: make(map[string]int)}
+
+func (l *routesLimiter) acquire(key string, max, maxPerApp int) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if (max > 0 && l.total >= max) || (maxPerApp > 0 && l.perApp[key] >= maxPerApp) {
+		return false
+	}
+	l.total++
+	l.perApp[key]++
+	return true
+}
+
+func (l *routesLimiter) release(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.total--
+	if l.perApp[key] <= 1 {
+		delete(l.perApp, key)
+	} else {
+		l.perApp[key]--
+	}
+}
+
+// ServeRoute executes the service of the webapp for an HTTP request, and
+// streams its response. The service is executed directly, without a job in
+// the queue, with a per-request timeout. vpath is the path of the request,
+// relative to the route of the service.
+//
+// If an error is returned, nothing has been written on the response.
+func ServeRoute(inst *instance.Instance, man *app.WebappManifest, name string, w http.ResponseWriter, r *http.Request, vpath string) error {
+	conf := config.GetConfig().Konnectors.ServiceRoutes
+	key := inst.Domain + "/" + man.Slug()
+	if !routesLimits.acquire(key, conf.MaxConcurrency, conf.MaxConcurrencyPerApp) {
+		return ErrTooManyRouteRequests
+	}
+	defer routesLimits.release(key)
+
+	msg, err := job.NewMessage(&ServiceOptions{Slug: man.Slug(), Name: name})
+	if err != nil {
+		return err
+	}
+	j := &job.Job{
+		JobID:      utils.RandomString(16),
+		Domain:     inst.Domain,
+		WorkerType: "service",
+		Message:    msg,
+	}
+	ex := &routeExchange{w: w, r: r, path: strings.TrimPrefix(vpath, "/")}
+	ctx := job.NewWorkerContext("route", j, inst).
+		WithCookie(&serviceWorker{man: man, route: ex})
+	ctx.Context = r.Context()
+	timeout := conf.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	ctx, cancel := ctx.WithTimeout(timeout)
+	defer cancel()
+
+	err = worker(ctx)
+	_ = commit(ctx, err)
+	if ex.started {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	ex.writeHeader(http.StatusNoContent, nil)
+	return nil
+}
+
+// stdinWorker is implemented by the workers that send data to the standard
+// input of the executed process.
+type stdinWorker interface {
+	Stdin() io.Reader
+}
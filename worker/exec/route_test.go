@@ -0,0 +1,61 @@
+package exec
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cozy/cozy-stack/model/job"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoutesLimiter(t *testing.T) {
+	l := &routesLimiter{perApp: make(map[string]int)}
+	assert.True(t, l.acquire("alice/foo", 3, 2))
+	assert.True(t, l.acquire("alice/foo", 3, 2))
+	assert.False(t, l.acquire("alice/foo", 3, 2))
+	assert.True(t, l.acquire("bob/foo", 3, 2))
+	assert.False(t, l.acquire("bob/bar", 3, 2))
+	l.release("alice/foo")
+	assert.True(t, l.acquire("bob/bar", 3, 2))
+	l.release("alice/foo")
+	l.release("bob/foo")
+	l.release("bob/bar")
+	assert.Equal(t, 0, l.total)
+	assert.Empty(t, l.perApp)
+}
+
+func TestServiceRouteResponse(t *testing.T) {
+	r := httptest.NewRequest("POST", "/apps/foo/routes/hooks/github?ref=main", strings.NewReader("{}"))
+	r.Header.Set("Authorization", "Bearer secret")
+	r.Header.Set("X-Github-Event", "push")
+	rec := httptest.NewRecorder()
+	ex := &routeExchange{w: rec, r: r, path: "github"}
+
+	req := ex.request()
+	assert.Equal(t, "POST", req.Method)
+	assert.Equal(t, "/github", req.Path)
+	assert.Equal(t, []string{"main"}, req.Query["ref"])
+	assert.Equal(t, "push", req.Headers["X-Github-Event"])
+	assert.NotContains(t, req.Headers, "Authorization")
+
+	w := &serviceWorker{slug: "foo", route: ex}
+	ctx := job.NewWorkerContext("0", &job.Job{JobID: "1", Domain: "alice.cozy.example"}, nil)
+	lines := []string{
+		`{"type": "response", "status": 201, "headers": {"Content-Type": "text/plain", "Set-Cookie": "foo=bar"}}`,
+		`{"type": "body", "data": "aGVsbG8g"}`,
+		`{"type": "info", "message": "between the chunks"}`,
+		`{"type": "body", "data": "d29ybGQ="}`,
+	}
+	for _, line := range lines {
+		require.NoError(t, w.ScanOutput(ctx, nil, []byte(line)))
+	}
+	res := rec.Result()
+	assert.Equal(t, http.StatusCreated, res.StatusCode)
+	assert.Equal(t, "text/plain", res.Header.Get("Content-Type"))
+	assert.Empty(t, res.Header.Get("Set-Cookie"))
+	assert.Equal(t, "sandbox", res.Header.Get("Content-Security-Policy"))
+	assert.Equal(t, "hello world", rec.Body.String())
+}
@@ -28,10 +28,22 @@ type ServiceOptions struct {
 	Message *ServiceOptions `json:"message"`
 }
 
+// The types of the messages used by a service to send the response of an
+// HTTP route: a response message with the status and headers, then the body
+// in base64-encoded chunks.
+const (
+	serviceMsgTypeResponse = "response"
+	serviceMsgTypeBody     = "body"
+)
+
 type serviceWorker struct {
 	man  *app.WebappManifest
 	slug string
 	name string
+	wasm bool
+
+	// route is set when the service is executed for an HTTP request
+	route *routeExchange
 }
 
 func (w *serviceWorker) PrepareWorkDir(ctx *job.WorkerContext, i *instance.Instance) (workDir string, cleanDir func(), err error) {
@@ -47,13 +59,18 @@ func (w *serviceWorker) PrepareWorkDir(ctx *job.WorkerContext, i *instance.Insta
 	slug := opts.Slug
 	name := opts.Name
 
-	man, err := app.GetWebappBySlugAndUpdate(i, slug,
-		app.Copier(consts.WebappType, i), i.Registries())
-	if err != nil {
-		if err == app.ErrNotFound {
-			err = job.ErrBadTrigger{Err: err}
+	// The manifest has already been loaded for the HTTP routes, and the
+	// application is not updated during a request
+	man := w.man
+	if man == nil {
+		man, err = app.GetWebappBySlugAndUpdate(i, slug,
+			app.Copier(consts.WebappType, i), i.Registries())
+		if err != nil {
+			if err == app.ErrNotFound {
+				err = job.ErrBadTrigger{Err: err}
+			}
+			return
 		}
-		return
 	}
 
 	w.slug = slug
@@ -133,7 +150,12 @@ func (w *serviceWorker) PrepareWorkDir(ctx *job.WorkerContext, i *instance.Insta
 	}
 	defer src.Close()
 
-	dst, err := workFS.OpenFile("index.js", os.O_CREATE|os.O_WRONLY, 0640)
+	w.wasm = service.Type == WasmLanguage
+	dstName := "index.js"
+	if w.wasm {
+		dstName = wasmFileName
+	}
+	dst, err := workFS.OpenFile(dstName, os.O_CREATE|os.O_WRONLY, 0640)
 	if err != nil {
 		return
 	}
@@ -151,6 +173,11 @@ func (w *serviceWorker) Slug() string {
 	return w.slug
 }
 
+// UseWasm returns true if the service has been compiled to WebAssembly.
+func (w *serviceWorker) UseWasm() bool {
+	return w.wasm
+}
+
 func (w *serviceWorker) PrepareCmdEnv(ctx *job.WorkerContext, i *instance.Instance) (cmd string, env []string, err error) {
 	type serviceEvent struct {
 		Doc interface{} `json:"doc"`
@@ -188,9 +215,25 @@ func (w *serviceWorker) PrepareCmdEnv(ctx *job.WorkerContext, i *instance.Instan
 	if triggerID, ok := ctx.TriggerID(); ok {
 		env = append(env, "COZY_TRIGGER_ID="+triggerID)
 	}
+	if w.route != nil {
+		req, err := json.Marshal(w.route.request())
+		if err != nil {
+			return "", nil, err
+		}
+		env = append(env, "COZY_REQUEST="+string(req))
+	}
 	return
 }
 
+// Stdin returns the body of the HTTP request for the services executed for
+// an HTTP route.
+func (w *serviceWorker) Stdin() io.Reader {
+	if w.route == nil {
+		return nil
+	}
+	return w.route.r.Body
+}
+
 func (w *serviceWorker) Logger(ctx *job.WorkerContext) *logger.Entry {
 	log := ctx.Logger().WithField("slug", w.Slug())
 	if w.name != "" {
@@ -203,11 +246,26 @@ func (w *serviceWorker) ScanOutput(ctx *job.WorkerContext, i *instance.Instance,
 	var msg struct {
 		Type    string `json:"type"`
 		Message string `json:"message"`
+
+		// For the response of an HTTP route
+		Status  int               `json:"status"`
+		Headers map[string]string `json:"headers"`
+		Data    string            `json:"data"`
 	}
 	if err := json.Unmarshal(line, &msg); err != nil {
 		return fmt.Errorf("Could not parse stdout as JSON: %q", string(line))
 	}
 
+	if w.route != nil {
+		switch msg.Type {
+		case serviceMsgTypeResponse:
+			w.route.writeHeader(msg.Status, msg.Headers)
+			return nil
+		case serviceMsgTypeBody:
+			return w.route.writeBody(msg.Data)
+		}
+	}
+
 	// Truncate very long messages
 	if len(msg.Message) > 4000 {
 		msg.Message = msg.Message[:4000]
@@ -0,0 +1,236 @@
+package exec
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"path"
+	"sync"
+
+	"github.com/cozy/cozy-stack/model/job"
+	"github.com/cozy/cozy-stack/pkg/metrics"
+	"github.com/cozy/cozy-stack/pkg/utils"
+	"github.com/cozy/cozy-stack/pkg/wasi"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// WasmLanguage is the language (for konnectors) or the type (for services)
+// declared in the manifest of the apps compiled to WASI WebAssembly.
+const WasmLanguage = "wasm"
+
+// wasmFileName is the name of the WebAssembly module in the work directory.
+const wasmFileName = "index.wasm"
+
+// ErrNoWasmEngine is used when a konnector or a service compiled to
+// WebAssembly is executed, but the engine has been unregistered.
+var ErrNoWasmEngine = errors.New("No WebAssembly engine is available")
+
+// WasmHost is the interface for the host functions given to a WebAssembly
+// module. The engine must expose them in a "cozy" module, as the url and
+// credentials functions (in addition to the COZY_URL and COZY_CREDENTIALS
+// environment variables).
+type WasmHost interface {
+	URL() string
+	Credentials() string
+}
+
+// WasmModule describes how a WASI WebAssembly module must be executed.
+type WasmModule struct {
+	// File is the path of the .wasm file
+	File string
+	// Dir is the work directory, that must be pre-opened as / for the module
+	Dir string
+	// Env is the list of the environment variables, in the KEY=value format
+	Env []string
+	// Host gives the values for the host functions
+	Host WasmHost
+	// Stdin is the standard input of the module (nil for an empty input)
+	Stdin io.Reader
+	// Stdout and Stderr are where the outputs of the module are written. A
+	// line on stdout is a JSON message, like for the node konnectors.
+	Stdout io.Writer
+	Stderr io.Writer
+	// MaxMemory is the maximal memory in bytes for the module (0 means the
+	// default of the engine)
+	MaxMemory int64
+}
+
+// WasmEngine is an engine that can execute WASI WebAssembly modules inside
+// the cozy-stack process. The execution must stop when the context is
+// canceled, which is how the time limit is enforced.
+type WasmEngine interface {
+	Run(ctx context.Context, mod *WasmModule) error
+}
+
+// wasiEngine is the default engine: the pure-Go runtime of pkg/wasi.
+type wasiEngine struct{}
+
+func (wasiEngine) Run(ctx context.Context, mod *WasmModule) error {
+	cfg := &wasi.Config{
+		Args:      []string{wasmFileName},
+		Env:       mod.Env,
+		Dir:       mod.Dir,
+		Stdin:     mod.Stdin,
+		Stdout:    mod.Stdout,
+		Stderr:    mod.Stderr,
+		MaxMemory: mod.MaxMemory,
+	}
+	if mod.Host != nil {
+		cfg.Strings = map[string]map[string]string{
+			"cozy": {
+				"url":         mod.Host.URL(),
+				"credentials": mod.Host.Credentials(),
+			},
+		}
+	}
+	err := wasi.RunFile(ctx, mod.File, cfg)
+	if errors.Is(err, wasi.ErrMemoryLimit) {
+		return ErrMemoryLimit
+	}
+	return err
+}
+
+var (
+	wasmEngine   WasmEngine = wasiEngine{}
+	wasmEngineMu sync.RWMutex
+)
+
+// RegisterWasmEngine replaces the engine used to execute the konnectors and
+// services compiled to WebAssembly.
+func RegisterWasmEngine(engine WasmEngine) {
+	wasmEngineMu.Lock()
+	defer wasmEngineMu.Unlock()
+	wasmEngine = engine
+}
+
+func getWasmEngine() WasmEngine {
+	wasmEngineMu.RLock()
+	defer wasmEngineMu.RUnlock()
+	return wasmEngine
+}
+
+// wasmWorker is implemented by the exec workers that can run an app compiled
+// to WebAssembly. UseWasm must be called after PrepareWorkDir.
+type wasmWorker interface {
+	UseWasm() bool
+}
+
+type wasmHost struct {
+	url         string
+	credentials string
+}
+
+func (h *wasmHost) URL() string         { return h.url }
+func (h *wasmHost) Credentials() string { return h.credentials }
+
+// hostFromEnv extracts the values for the host functions from the
+// environment variables given to the module.
+func hostFromEnv(env []string) *wasmHost {
+	host := &wasmHost{}
+	for _, kv := range env {
+		if v, ok := cutPrefix(kv, "COZY_URL="); ok {
+			host.url = v
+		} else if v, ok := cutPrefix(kv, "COZY_CREDENTIALS="); ok {
+			host.credentials = v
+		}
+	}
+	return host
+}
+
+func cutPrefix(s, prefix string) (string, bool) {
+	if len(s) < len(prefix) || s[:len(prefix)] != prefix {
+		return s, false
+	}
+	return s[len(prefix):], true
+}
+
+// runWasm executes the WebAssembly module of the worker, with the same
+// protocol for the output as the node konnectors (see ScanOutput).
+func runWasm(ctx *job.WorkerContext, worker execWorker, workDir string, env []string) (err error) {
+	engine := getWasmEngine()
+	if engine == nil {
+		return ErrNoWasmEngine
+	}
+
+	log := worker.Logger(ctx)
+	var stderrBuf bytes.Buffer
+	defer func() {
+		if stderrBuf.Len() > 0 {
+			log.Errorf("Stderr: %s", stderrBuf.String())
+		}
+	}()
+
+	timer := prometheus.NewTimer(prometheus.ObserverFunc(func(v float64) {
+		var result string
+		if err != nil {
+			result = metrics.WorkerExecResultErrored
+		} else {
+			result = metrics.WorkerExecResultSuccess
+		}
+		metrics.WorkersKonnectorsExecDurations.
+			WithLabelValues(worker.Slug(), result).
+			Observe(v)
+	}))
+	defer timer.ObserveDuration()
+
+	pr, pw := io.Pipe()
+	scanDone := make(chan struct{})
+	go func() {
+		defer close(scanDone)
+		scanBuf := make([]byte, 16*1024)
+		scanOut := bufio.NewScanner(pr)
+		scanOut.Buffer(scanBuf, 64*1024)
+		for scanOut.Scan() {
+			if errOut := worker.ScanOutput(ctx, ctx.Instance, scanOut.Bytes()); errOut != nil {
+				log.Debug(errOut.Error())
+			}
+		}
+		if errs := scanOut.Err(); errs != nil {
+			log.Errorf("could not scan stdout: %s", errs)
+		}
+		// Drain the pipe to not block the module if the scanner has failed
+		_, _ = io.Copy(ioutil.Discard, pr)
+	}()
+
+	mod := &WasmModule{
+		File:   path.Join(workDir, wasmFileName),
+		Dir:    workDir,
+		Env:    env,
+		Host:   hostFromEnv(env),
+		Stdout: pw,
+		Stderr: utils.LimitWriterDiscard(&stderrBuf, 256*1024),
+	}
+	if w, ok := worker.(stdinWorker); ok {
+		mod.Stdin = w.Stdin()
+	}
+	if opts := ctx.Options(); opts != nil {
+		mod.MaxMemory = opts.MaxMemory
+	}
+	limits := execLimits(ctx.WorkerType())
+	if mod.MaxMemory == 0 {
+		mod.MaxMemory = limits.Memory
+	}
+
+	// The module is executed by a single goroutine, so the CPU time limit is
+	// enforced as a timeout
+	runCtx := context.Context(ctx)
+	if limits.CPUTime > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, limits.CPUTime)
+		defer cancel()
+	}
+	err = engine.Run(runCtx, mod)
+	_ = pw.Close()
+	<-scanDone
+
+	if err != nil {
+		err = wrapErr(ctx, err)
+		if err == context.DeadlineExceeded && ctx.Err() == nil {
+			err = ErrCPULimit
+		}
+	}
+	return worker.Error(ctx.Instance, err)
+}
@@ -0,0 +1,100 @@
+package exec
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cozy/cozy-stack/model/instance"
+	"github.com/cozy/cozy-stack/model/job"
+	"github.com/cozy/cozy-stack/pkg/config/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeWasmEngine struct {
+	mod *WasmModule
+}
+
+func (e *fakeWasmEngine) Run(ctx context.Context, mod *WasmModule) error {
+	e.mod = mod
+	fmt.Fprintln(mod.Stdout, `{"type":"info","message":"foo"}`)
+	fmt.Fprintln(mod.Stdout, `{"type":"info","message":"bar"}`)
+	return nil
+}
+
+type fakeWasmWorker struct {
+	*serviceWorker
+	lines []string
+}
+
+func (w *fakeWasmWorker) ScanOutput(ctx *job.WorkerContext, i *instance.Instance, line []byte) error {
+	w.lines = append(w.lines, string(line))
+	return nil
+}
+
+func TestRunWasm(t *testing.T) {
+	j := job.NewJob(inst, &job.JobRequest{
+		WorkerType: "service",
+		Options:    &job.JobOptions{MaxMemory: 64 << 20},
+	})
+	ctx := job.NewWorkerContext("id", j, inst)
+	w := &fakeWasmWorker{serviceWorker: &serviceWorker{slug: "wasm-app", wasm: true}}
+	env := []string{
+		"COZY_URL=https://alice.cozy.example/",
+		"COZY_CREDENTIALS=token",
+	}
+
+	RegisterWasmEngine(nil)
+	err := runWasm(ctx, w, "/tmp/workdir", env)
+	assert.Equal(t, ErrNoWasmEngine, err)
+
+	engine := &fakeWasmEngine{}
+	RegisterWasmEngine(engine)
+	defer RegisterWasmEngine(wasiEngine{})
+	err = runWasm(ctx, w, "/tmp/workdir", env)
+	require.NoError(t, err)
+
+	require.NotNil(t, engine.mod)
+	assert.Equal(t, "/tmp/workdir/index.wasm", engine.mod.File)
+	assert.Equal(t, "/tmp/workdir", engine.mod.Dir)
+	assert.EqualValues(t, 64<<20, engine.mod.MaxMemory)
+	assert.Equal(t, "https://alice.cozy.example/", engine.mod.Host.URL())
+	assert.Equal(t, "token", engine.mod.Host.Credentials())
+	assert.Equal(t, []string{
+		`{"type":"info","message":"foo"}`,
+		`{"type":"info","message":"bar"}`,
+	}, w.lines)
+}
+
+func TestRunWasmCPULimit(t *testing.T) {
+	workDir, err := ioutil.TempDir("", "wasm")
+	require.NoError(t, err)
+	defer os.RemoveAll(workDir)
+	// A module with an infinite loop: (func (export "_start") (loop (br 0)))
+	module := []byte{
+		0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00,
+		0x01, 0x04, 0x01, 0x60, 0x00, 0x00,
+		0x03, 0x02, 0x01, 0x00,
+		0x07, 0x0a, 0x01, 0x06, '_', 's', 't', 'a', 'r', 't', 0x00, 0x00,
+		0x0a, 0x09, 0x01, 0x07, 0x00, 0x03, 0x40, 0x0c, 0x00, 0x0b, 0x0b,
+	}
+	err = ioutil.WriteFile(filepath.Join(workDir, wasmFileName), module, 0644)
+	require.NoError(t, err)
+
+	limits := config.GetConfig().Konnectors.Limits
+	defer func() { config.GetConfig().Konnectors.Limits = limits }()
+	config.GetConfig().Konnectors.Limits = map[string]config.ExecLimits{
+		"service": {CPUTime: 100 * time.Millisecond},
+	}
+
+	j := job.NewJob(inst, &job.JobRequest{WorkerType: "service"})
+	ctx := job.NewWorkerContext("id", j, inst)
+	w := &fakeWasmWorker{serviceWorker: &serviceWorker{slug: "wasm-app", wasm: true}}
+	err = runWasm(ctx, w, workDir, nil)
+	assert.Equal(t, ErrCPULimit, err)
+}
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/cozy/cozy-stack/model/instance"
+	"github.com/cozy/cozy-stack/model/instance/lifecycle"
 	"github.com/cozy/cozy-stack/model/job"
 	"github.com/cozy/cozy-stack/model/note"
 	"github.com/cozy/cozy-stack/model/vfs"
@@ -41,6 +42,7 @@ const (
 	accountsToOrganization = "accounts-to-organization"
 	notesMimeType          = "notes-mime-type"
 	unwantedFolders        = "remove-unwanted-folders"
+	auditCleanTrigger      = "audit-clean-trigger"
 )
 
 // maxSimultaneousCalls is the maximal number of simultaneous calls to Swift
@@ -83,6 +85,8 @@ func worker(ctx *job.WorkerContext) error {
 		return migrateNotesMimeType(ctx.Instance.Domain)
 	case unwantedFolders:
 		return removeUnwantedFolders(ctx.Instance.Domain)
+	case auditCleanTrigger:
+		return lifecycle.EnsureAuditCleanTrigger(ctx.Instance)
 	default:
 		return fmt.Errorf("unknown migration type %q", msg.Type)
 	}
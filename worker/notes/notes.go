@@ -17,6 +17,14 @@ func init() {
 		Timeout:      30 * time.Second,
 		WorkerFunc:   WorkerPersist,
 	})
+
+	job.AddWorker(&job.WorkerConfig{
+		WorkerType:   "notes-export",
+		Concurrency:  runtime.NumCPU(),
+		MaxExecCount: 1,
+		Timeout:      10 * time.Minute,
+		WorkerFunc:   WorkerExport,
+	})
 }
 
 // WorkerPersist is used to persist a note to its file in the VFS. The changes
@@ -35,3 +43,29 @@ func WorkerPersist(ctx *job.WorkerContext) error {
 	}
 	return err
 }
+
+// ExportMessage is the message for exporting all the notes of a directory.
+type ExportMessage struct {
+	DirID  string `json:"dir_id"`
+	Format string `json:"format"`
+}
+
+// WorkerExport is used to export all the notes of a directory to a format
+// like PDF or DOCX, in a zip archive.
+func WorkerExport(ctx *job.WorkerContext) error {
+	var msg ExportMessage
+	if err := ctx.UnmarshalMessage(&msg); err != nil {
+		return err
+	}
+	if msg.Format == "" {
+		msg.Format = note.ExportPDF
+	}
+	log := ctx.Instance.Logger().WithNamespace("notes")
+	zip, err := note.ExportDir(ctx.Instance, msg.DirID, msg.Format)
+	if err != nil {
+		log.Warnf("Cannot export notes of %s: %s", msg.DirID, err)
+		return err
+	}
+	log.Infof("Notes of %s exported to %s", msg.DirID, zip.ID())
+	return nil
+}
@@ -0,0 +1,400 @@
+package updates
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/cozy/cozy-stack/model/app"
+	"github.com/cozy/cozy-stack/model/instance"
+	"github.com/cozy/cozy-stack/model/instance/lifecycle"
+	"github.com/cozy/cozy-stack/model/job"
+	"github.com/cozy/cozy-stack/pkg/consts"
+	"github.com/cozy/cozy-stack/pkg/couchdb"
+	"github.com/cozy/cozy-stack/pkg/couchdb/mango"
+)
+
+const (
+	// rolloutUpdateDuration is the duration after which a job for the update
+	// of the instances stops, and lets the next job continue the update.
+	rolloutUpdateDuration = 10 * time.Minute
+	rolloutPageSize       = 100
+)
+
+func init() {
+	job.AddWorker(&job.WorkerConfig{
+		WorkerType:   "rollouts",
+		Reserved:     true,
+		Concurrency:  1,
+		MaxExecCount: 1,
+		Timeout:      app.RolloutJobTimeout,
+		WorkerFunc:   RolloutWorker,
+	})
+}
+
+// PushRollout creates the rollout and pushes a job to execute it.
+func PushRollout(r *app.Rollout) (*job.Job, error) {
+	if err := app.CreateRollout(r); err != nil {
+		return nil, err
+	}
+	return r.PushJob()
+}
+
+// RolloutWorker executes the next step of a rollout: it updates a part of
+// the selected instances, it checks the error rates of the jobs for the
+// application on the updated instances, or it rolls back a part of these
+// instances. The update is rolled back if the error rates have degraded. The
+// state of the rollout is saved after each step, and the scheduler of the
+// rollouts pushes a new job for the next step.
+func RolloutWorker(ctx *job.WorkerContext) error {
+	var msg app.RolloutMessage
+	if err := ctx.UnmarshalMessage(&msg); err != nil {
+		return err
+	}
+	r, err := app.GetRollout(msg.RolloutID)
+	if err != nil {
+		return err
+	}
+	if r.Status == app.RolloutRollingBack {
+		return rolloutRollback(ctx, r)
+	}
+	if r.Status != app.RolloutRunning {
+		return nil
+	}
+	if r.WatchUntil == nil {
+		return rolloutUpdate(ctx, r)
+	}
+
+	log := ctx.Logger().WithField("rollout", r.ID()).WithField("slug", r.Slug)
+	degraded, err := rolloutCheck(r)
+	if err != nil {
+		return err
+	}
+	if degraded {
+		before, after, _ := r.ErrorRates()
+		reason := fmt.Sprintf("The error rate has increased from %.1f%% to %.1f%%",
+			before*100, after*100)
+		log.Info(reason)
+		r.Status = app.RolloutRollingBack
+		r.Reason = reason
+		r.Cursor = ""
+		if err := r.Save(); err != nil {
+			return err
+		}
+		return rolloutRollback(ctx, r)
+	}
+	if time.Now().After(*r.WatchUntil) {
+		r.Status = app.RolloutCompleted
+		log.Info("Rollout completed")
+		return r.Save()
+	}
+	r.NextStepAt = time.Now().UTC().Add(r.CheckInterval)
+	return r.Save()
+}
+
+// rolloutUpdate updates the application on the selected instances. The
+// updated instances are saved in their own documents, the rollout is saved
+// after each page of instances, and the update continues in the next job if
+// it takes too long. If the rollout has been canceled or rolled back in the
+// meantime, the save fails with a conflict and the update stops.
+func rolloutUpdate(ctx *job.WorkerContext, r *app.Rollout) error {
+	log := ctx.Logger().WithField("rollout", r.ID()).WithField("slug", r.Slug)
+	deadline := time.Now().Add(rolloutUpdateDuration)
+	for {
+		insts, next, err := instance.PaginatedList(rolloutPageSize, r.Cursor, 0)
+		if err != nil {
+			return err
+		}
+		for _, inst := range insts {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			default:
+			}
+			if !r.Selects(inst) || (inst.NoAutoUpdate && !r.Force) {
+				continue
+			}
+			ri, err := rolloutUpdateInstance(inst, r)
+			if err != nil {
+				log.WithField("domain", inst.Domain).
+					Infof("Cannot update %s: %s", r.Slug, err)
+			} else if ri != nil {
+				if err := r.AddInstance(ri); err != nil {
+					return err
+				}
+			}
+		}
+
+		r.Cursor = next
+		if next == "" {
+			until := time.Now().UTC().Add(r.Watch)
+			r.WatchUntil = &until
+			r.NextStepAt = time.Now().UTC().Add(r.CheckInterval)
+			log.Infof("%d instances updated, watching until %s", r.Updated, until)
+			return r.Save()
+		}
+		if time.Now().After(deadline) {
+			// The scheduler will push a new job to continue the update
+			r.NextStepAt = time.Now().UTC()
+			return r.Save()
+		}
+		if err := r.Save(); err != nil {
+			return err
+		}
+	}
+}
+
+func rolloutUpdateInstance(inst *instance.Instance, r *app.Rollout) (*app.RolloutInstance, error) {
+	man, err := app.GetBySlug(inst, r.Slug, r.AppType)
+	if err != nil {
+		if err == app.ErrNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	// Check that the previous version can be fetched for the rollback
+	if _, err := app.PinnedRegistrySource(man.Source(), man.Version()); err != nil {
+		return nil, err
+	}
+
+	installer, err := app.NewInstaller(inst, app.Copier(r.AppType, inst),
+		&app.InstallerOptions{
+			Operation:        app.Update,
+			Manifest:         man,
+			Registries:       inst.Registries(),
+			SourceURL:        r.Source,
+			PermissionsAcked: true,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	previousVersion := man.Version()
+	previousSource := man.Source()
+	updated, err := installer.RunSync()
+	if err != nil {
+		return nil, err
+	}
+	if updated.Version() == previousVersion {
+		return nil, nil
+	}
+
+	ri := &app.RolloutInstance{
+		Domain:          inst.Domain,
+		PreviousVersion: previousVersion,
+		PreviousSource:  previousSource,
+		Version:         updated.Version(),
+		UpdatedAt:       time.Now(),
+	}
+	ri.BaselineJobs, ri.BaselineErrors, err = countJobs(inst, r,
+		ri.UpdatedAt.Add(-r.Watch), ri.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return ri, nil
+}
+
+// rolloutCheck refreshes the number of jobs for the updated instances and the
+// stats of the rollout. It returns true if the error rate has degraded.
+func rolloutCheck(r *app.Rollout) (bool, error) {
+	now := time.Now()
+	updated := 0
+	stats := app.RolloutStats{}
+	err := r.ForEachInstance(func(ri *app.RolloutInstance) error {
+		updated++
+		defer stats.Add(ri)
+		inst, err := lifecycle.GetInstance(ri.Domain)
+		if err != nil {
+			return nil
+		}
+		jobs, errs, err := countJobs(inst, r, ri.UpdatedAt, now)
+		if err != nil {
+			return err
+		}
+		if jobs == ri.Jobs && errs == ri.Errors {
+			return nil
+		}
+		ri.Jobs, ri.Errors = jobs, errs
+		return ri.Save()
+	})
+	if err != nil {
+		return false, err
+	}
+	r.Updated = updated
+	r.Stats = stats
+	return r.Degraded(), nil
+}
+
+// rolloutRollback puts back the previous version of the application on the
+// instances updated by the rollout. These instances are pinned to this
+// version (the source is registry://<slug>/<channel>/<version>), so that
+// they are not updated again to the faulty version. The rollback continues in
+// the next job if it takes too long.
+func rolloutRollback(ctx *job.WorkerContext, r *app.Rollout) error {
+	log := ctx.Logger().WithField("rollout", r.ID()).WithField("slug", r.Slug)
+	deadline := time.Now().Add(rolloutUpdateDuration)
+	for {
+		instances, next, err := app.ListRolloutInstances(r.ID(), rolloutPageSize, r.Cursor)
+		if err != nil {
+			return err
+		}
+		for _, ri := range instances {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			default:
+			}
+			if ri.RolledBack {
+				continue
+			}
+			if err := rollbackInstance(r, ri); err != nil {
+				log.WithField("domain", ri.Domain).
+					Infof("Cannot roll back %s: %s", r.Slug, err)
+				ri.RollbackError = err.Error()
+			} else {
+				ri.RolledBack = true
+				ri.RollbackError = ""
+			}
+			if err := ri.Save(); err != nil {
+				return err
+			}
+		}
+
+		r.Cursor = next
+		if next == "" {
+			r.Status = app.RolloutRolledBack
+			log.Infof("Rollout rolled back: %s", r.Reason)
+			return r.Save()
+		}
+		if time.Now().After(deadline) {
+			// The scheduler will push a new job to continue the rollback
+			r.NextStepAt = time.Now().UTC()
+			return r.Save()
+		}
+		if err := r.Save(); err != nil {
+			return err
+		}
+	}
+}
+
+func rollbackInstance(r *app.Rollout, ri *app.RolloutInstance) error {
+	inst, err := lifecycle.GetInstance(ri.Domain)
+	if err != nil {
+		return err
+	}
+	man, err := app.GetBySlug(inst, r.Slug, r.AppType)
+	if err != nil {
+		return err
+	}
+	if man.Version() != ri.Version {
+		return errors.New("The application has been updated since the rollout")
+	}
+	source, err := app.PinnedRegistrySource(ri.PreviousSource, ri.PreviousVersion)
+	if err != nil {
+		return err
+	}
+	installer, err := app.NewInstaller(inst, app.Copier(r.AppType, inst),
+		&app.InstallerOptions{
+			Operation:        app.Update,
+			Manifest:         man,
+			Registries:       inst.Registries(),
+			SourceURL:        source,
+			PermissionsAcked: true,
+		},
+	)
+	if err != nil {
+		return err
+	}
+	_, err = installer.RunSync()
+	return err
+}
+
+// Unpin puts back the source of the application to its channel on the
+// instances rolled back by the rollout, so that they will be updated again by
+// the auto-updates (when a fixed version has been published). The version
+// installed on the instances is not changed.
+func Unpin(r *app.Rollout) error {
+	if r.Status != app.RolloutRolledBack {
+		return app.ErrRolloutNotRolledBack
+	}
+	return r.ForEachInstance(func(ri *app.RolloutInstance) error {
+		if !ri.RolledBack || ri.Unpinned {
+			return nil
+		}
+		if err := unpinInstance(r, ri); err != nil {
+			ri.RollbackError = err.Error()
+		} else {
+			ri.Unpinned = true
+			ri.RollbackError = ""
+		}
+		return ri.Save()
+	})
+}
+
+func unpinInstance(r *app.Rollout, ri *app.RolloutInstance) error {
+	inst, err := lifecycle.GetInstance(ri.Domain)
+	if err != nil {
+		return err
+	}
+	man, err := app.GetBySlug(inst, r.Slug, r.AppType)
+	if err != nil {
+		return err
+	}
+	pinned, err := app.PinnedRegistrySource(ri.PreviousSource, ri.PreviousVersion)
+	if err != nil {
+		return err
+	}
+	if man.Source() != pinned {
+		// The application has been reinstalled from another source since the
+		// rollback: there is nothing to unpin
+		return nil
+	}
+	channel, err := app.ChannelRegistrySource(ri.PreviousSource)
+	if err != nil {
+		return err
+	}
+	src, err := url.Parse(channel)
+	if err != nil {
+		return err
+	}
+	man.SetSource(src)
+	return man.Update(inst, nil)
+}
+
+// countJobs returns the number of finished jobs for the application of the
+// rollout on the instance, between the two dates, and the number of errored
+// jobs. Only the konnectors and the services have jobs.
+func countJobs(inst *instance.Instance, r *app.Rollout, from, to time.Time) (int, int, error) {
+	workerType := "service"
+	slugField := "message.slug"
+	if r.AppType == consts.KonnectorType {
+		workerType = "konnector"
+		slugField = "message.konnector"
+	}
+
+	total, errored := 0, 0
+	for _, state := range []job.State{job.Done, job.Errored} {
+		var jobs []*job.Job
+		req := &couchdb.FindRequest{
+			UseIndex: "by-worker-and-state",
+			Selector: mango.And(
+				mango.Equal("worker", workerType),
+				mango.Equal("state", state),
+				mango.Equal(slugField, r.Slug),
+				mango.Gt("queued_at", from),
+				mango.Lte("queued_at", to),
+			),
+			Limit: 1000,
+		}
+		err := couchdb.FindDocs(inst, consts.Jobs, req, &jobs)
+		if err != nil && !couchdb.IsNoDatabaseError(err) {
+			return 0, 0, err
+		}
+		total += len(jobs)
+		if state == job.Errored {
+			errored = len(jobs)
+		}
+	}
+	return total, errored, nil
+}
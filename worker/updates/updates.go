@@ -109,6 +109,11 @@ func UpdateAll(ctx *job.WorkerContext, opts *Options) error {
 	}
 	totalInstances = totalInstances - 1
 
+	rollouts, err := app.ListActiveRollouts()
+	if err != nil {
+		return err
+	}
+
 	// log a message for every hundredth instances updated, rounded to the
 	// closest multiple of 100.
 	countMark := totalInstances / 100
@@ -145,7 +150,7 @@ func UpdateAll(ctx *job.WorkerContext, opts *Options) error {
 			}
 			count++
 			if opts.Force || !inst.NoAutoUpdate {
-				installerPush(inst, insc, errc, opts)
+				installerPush(inst, insc, errc, opts, rollouts)
 			}
 			if count == totalInstances {
 				ctx.Logger().Infof("updated %d instances -- finished", count)
@@ -190,6 +195,11 @@ func UpdateInstance(ctx *job.WorkerContext, inst *instance.Instance, opts *Optio
 		return nil
 	}
 
+	rollouts, err := app.ListActiveRollouts()
+	if err != nil {
+		return err
+	}
+
 	var g sync.WaitGroup
 	g.Add(numUpdatersSingleInstance)
 
@@ -208,7 +218,7 @@ func UpdateInstance(ctx *job.WorkerContext, inst *instance.Instance, opts *Optio
 	}
 
 	go func() {
-		installerPush(inst, insc, errc, opts)
+		installerPush(inst, insc, errc, opts, rollouts)
 		close(insc)
 		g.Wait()
 		close(errc)
@@ -231,7 +241,10 @@ func UpdateInstance(ctx *job.WorkerContext, inst *instance.Instance, opts *Optio
 	return nil
 }
 
-func installerPush(inst *instance.Instance, insc chan *app.Installer, errc chan *updateError, opts *Options) {
+// installerPush pushes the installers for the applications of the instance.
+// The applications held by a running rollout on this instance are skipped:
+// they are updated (or rolled back) by the rollout.
+func installerPush(inst *instance.Instance, insc chan *app.Installer, errc chan *updateError, opts *Options, rollouts []*app.Rollout) {
 	registries := inst.Registries()
 
 	var g sync.WaitGroup
@@ -252,6 +265,9 @@ func installerPush(inst *instance.Instance, insc chan *app.Installer, errc chan
 			if filterSlug(webapp.Slug(), opts.Slugs) {
 				continue
 			}
+			if heldByRollout(rollouts, inst, webapp) {
+				continue
+			}
 			if opts.OnlyRegistry && strings.HasPrefix(webapp.Source(), "registry://") {
 				continue
 			}
@@ -284,6 +300,9 @@ func installerPush(inst *instance.Instance, insc chan *app.Installer, errc chan
 			if filterSlug(konn.Slug(), opts.Slugs) {
 				continue
 			}
+			if heldByRollout(rollouts, inst, konn) {
+				continue
+			}
 			if opts.OnlyRegistry && strings.HasPrefix(konn.Source(), "registry://") {
 				continue
 			}
@@ -304,6 +323,15 @@ func installerPush(inst *instance.Instance, insc chan *app.Installer, errc chan
 	g.Wait()
 }
 
+func heldByRollout(rollouts []*app.Rollout, inst *instance.Instance, man app.Manifest) bool {
+	for _, r := range rollouts {
+		if r.Holds(inst, man.Slug(), man.AppType()) {
+			return true
+		}
+	}
+	return false
+}
+
 func filterSlug(slug string, slugs []string) bool {
 	if len(slugs) == 0 {
 		return false